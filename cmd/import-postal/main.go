@@ -0,0 +1,126 @@
+// Package main provides a command for loading the Japan Post postal code master (KEN_ALL.CSV).
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/config"
+	"github.com/octop162/normal-form-app-by-claude/pkg/database"
+	"github.com/octop162/normal-form-app-by-claude/pkg/kenall"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const usage = `Usage: import-postal [flags]
+
+Replaces the ken_all table with the contents of KEN_ALL.CSV, downloaded from
+https://www.post.japanpost.jp/zipcode/download.html. The table is replaced in a
+single transaction, so lookups keep using the previous data until the import commits.
+
+Flags:
+`
+
+func main() {
+	file := flag.String("file", "KEN_ALL.CSV", "path to KEN_ALL.CSV")
+	encoding := flag.String("encoding", kenall.EncodingShiftJIS, "file encoding: sjis or utf8")
+	dryRun := flag.Bool("dry-run", false, "parse the file and report the row count without writing")
+
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(*file, *encoding, *dryRun); err != nil {
+		fmt.Fprintln(os.Stderr, "import-postal:", err)
+		os.Exit(1)
+	}
+}
+
+// run parses the file and replaces the ken_all table
+func run(path, encoding string, dryRun bool) error {
+	addresses, err := readAddresses(path, encoding)
+	if err != nil {
+		return err
+	}
+	if len(addresses) == 0 {
+		return fmt.Errorf("no postal codes found in %s", path)
+	}
+
+	if dryRun {
+		fmt.Printf("Parsed %d postal code(s) from %s\n", len(addresses), path)
+		return nil
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	log := logger.NewLogger(cfg.Log.Level)
+
+	db, err := database.NewDB(&cfg.Database, log)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	addressRepo := repository.NewAddressRepository(db.DB, log)
+	txManager := repository.NewTxManager(db.DB, log)
+
+	var imported int
+	err = txManager.WithinTransaction(context.Background(), func(txCtx context.Context) error {
+		imported, err = addressRepo.ReplaceAll(txCtx, addresses)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported %d postal code(s) from %s\n", imported, path)
+	return nil
+}
+
+// readAddresses parses every record in the file
+func readAddresses(path, encoding string) ([]*model.PostalAddress, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	reader, err := kenall.NewReader(file, encoding)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []*model.PostalAddress
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+
+		addresses = append(addresses, &model.PostalAddress{
+			LocalGovernmentCode: record.LocalGovernmentCode,
+			PostalCode:          record.PostalCode,
+			PrefectureKana:      record.PrefectureKana,
+			CityKana:            record.CityKana,
+			TownKana:            record.TownKana,
+			Prefecture:          record.Prefecture,
+			City:                record.City,
+			Town:                record.Town,
+		})
+	}
+
+	return addresses, nil
+}
@@ -0,0 +1,133 @@
+// Package main provides a command for applying the embedded database migrations.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/octop162/normal-form-app-by-claude/migrations"
+	"github.com/octop162/normal-form-app-by-claude/pkg/config"
+	"github.com/octop162/normal-form-app-by-claude/pkg/database"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/migrate"
+)
+
+const usage = `Usage: migrate <command> [arg]
+
+Commands:
+  up [N]      Apply all or N pending migrations
+  down N      Revert the last N migrations ("all" reverts every migration)
+  status      Print the applied and latest versions
+  force V     Record version V without running SQL (clears the dirty flag)
+
+Database settings are read from the same DB_* environment variables as the server.
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Arg(0), flag.Arg(1)); err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		os.Exit(1)
+	}
+}
+
+// run executes a single migration command
+func run(command, arg string) error {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	log := logger.NewLogger(cfg.Log.Level)
+
+	db, err := database.NewDB(&cfg.Database, log)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := migrate.New(db.DB, migrations.FS, log)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch command {
+	case "up":
+		steps, err := parseSteps(arg, false)
+		if err != nil {
+			return err
+		}
+		applied, err := migrator.Up(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migration(s)\n", applied)
+
+	case "down":
+		steps, err := parseSteps(arg, true)
+		if err != nil {
+			return err
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Reverted %d migration(s)\n", reverted)
+
+	case "status", "version":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version=%d dirty=%t latest=%d pending=%d\n",
+			status.Version, status.Dirty, status.Latest, status.Pending)
+
+	case "force":
+		version, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return fmt.Errorf("force requires a version number: %q", arg)
+		}
+		if err := migrator.Force(ctx, uint(version)); err != nil {
+			return err
+		}
+		fmt.Printf("Forced version %d\n", version)
+
+	default:
+		flag.Usage()
+		return fmt.Errorf("unknown command: %s", command)
+	}
+
+	return nil
+}
+
+// parseSteps parses the optional step count; down requires an explicit count so that
+// reverting every migration is never the accidental default
+func parseSteps(arg string, required bool) (int, error) {
+	if arg == "" {
+		if required {
+			return 0, fmt.Errorf("down requires a step count or \"all\"")
+		}
+		return 0, nil
+	}
+	if arg == "all" {
+		return 0, nil
+	}
+
+	steps, err := strconv.Atoi(arg)
+	if err != nil || steps <= 0 {
+		return 0, fmt.Errorf("invalid step count: %q", arg)
+	}
+	return steps, nil
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/middleware"
+	"github.com/octop162/normal-form-app-by-claude/pkg/config"
+)
+
+// adminListenerEnabled reports whether operational endpoints are served on their own port
+// instead of the public listener
+func adminListenerEnabled(cfg *config.Config) bool {
+	return cfg.Server.AdminPort != ""
+}
+
+// newAdminServer creates the internal listener serving health probes, metrics and, in debug
+// mode, pprof. It runs none of the public middleware: callers are probes and scrapers on the
+// internal network, not browsers, so CSRF, CORS and rate limiting do not apply.
+func newAdminServer(app *Application) *http.Server {
+	cfg := app.Config
+
+	r := gin.New()
+	r.Use(middleware.ErrorHandlerMiddleware(app.Logger))
+
+	r.GET("/health", app.HealthHandler.Health)
+	r.GET("/health/live", app.HealthHandler.LivenessProbe)
+	r.GET("/health/ready", app.HealthHandler.ReadinessProbe)
+	r.GET("/metrics", middleware.MetricsEndpoint())
+
+	// Profiles reveal the program's internals, so they are served only in debug mode
+	if cfg.Debug {
+		registerPprof(r)
+	}
+
+	return &http.Server{
+		Addr:         cfg.GetAdminAddress(),
+		Handler:      r,
+		ReadTimeout:  cfg.Server.AdminReadTimeout,
+		WriteTimeout: cfg.Server.AdminWriteTimeout,
+		IdleTimeout:  idleTimeoutSeconds * time.Second,
+	}
+}
+
+// registerPprof serves the Go runtime profiles under /debug/pprof
+func registerPprof(r *gin.Engine) {
+	debug := r.Group("/debug/pprof")
+	debug.GET("/", gin.WrapF(pprof.Index))
+	debug.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+	debug.GET("/profile", gin.WrapF(pprof.Profile))
+	debug.GET("/symbol", gin.WrapF(pprof.Symbol))
+	debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+	debug.GET("/trace", gin.WrapF(pprof.Trace))
+	// Named profiles such as heap, goroutine and allocs are served by the index handler
+	debug.GET("/:profile", gin.WrapF(pprof.Index))
+}
@@ -5,17 +5,22 @@ package main
 import (
 	"context"
 	"database/sql"
+	"flag"
+	"net"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/octop162/normal-form-app-by-claude/internal/handler"
 	"github.com/octop162/normal-form-app-by-claude/internal/middleware"
+	"github.com/octop162/normal-form-app-by-claude/internal/router"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/migrations"
 	"github.com/octop162/normal-form-app-by-claude/pkg/config"
+	"github.com/octop162/normal-form-app-by-claude/pkg/connlimit"
 	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/migrate"
 )
 
 const (
@@ -25,20 +30,67 @@ const (
 	shutdownTimeoutSeconds = 30
 )
 
+// recentErrorsShown is the number of recent errors kept for the admin status page
+const recentErrorsShown = 50
+
 // Application holds all application components
 type Application struct {
-	UserHandler    *handler.UserHandler
-	SessionHandler *handler.SessionHandler
-	OptionHandler  *handler.OptionHandler
-	AddressHandler *handler.AddressHandler
-	PlanHandler    *handler.PlanHandler
-	HealthHandler  *handler.HealthHandler
-	DB             *sql.DB
-	Logger         *logger.Logger
-	Config         *config.Config
+	UserHandler              *handler.UserHandler
+	SessionHandler           *handler.SessionHandler
+	OptionHandler            *handler.OptionHandler
+	AddressHandler           *handler.AddressHandler
+	PlanHandler              *handler.PlanHandler
+	HealthHandler            *handler.HealthHandler
+	AdminHandler             *handler.AdminHandler
+	UserLookupHandler        *handler.UserLookupHandler
+	PhoneVerificationHandler *handler.PhoneVerificationHandler
+	AttachmentHandler        *handler.AttachmentHandler
+	NormalizationHandler     *handler.NormalizationHandler
+	EmailTemplateHandler     *handler.EmailTemplateHandler
+	QuoteHandler             *handler.QuoteHandler
+	EmailEventHandler        *handler.EmailEventHandler
+	WebhookDeliveryHandler   *handler.WebhookDeliveryHandler
+	APIKeyHandler            *handler.APIKeyHandler
+	PartnerHandler           *handler.PartnerHandler
+	StatsHandler             *handler.StatsHandler
+	PersonalDataHandler      *handler.PersonalDataHandler
+	SecurityHandler          *handler.SecurityHandler
+	StatusPageHandler        *handler.StatusPageHandler
+	FormHandler              *handler.FormHandler
+	FeatureFlags             *service.FeatureFlags
+	ValidationRules          *service.ValidationRules
+	OutboxRelay              *service.OutboxRelay
+	DeletionRecordPurger     *service.DeletionRecordPurger
+	AttachmentScanner        *service.AttachmentScanner
+	ReservationReleaser      *service.ReservationReleaser
+	WebhookDispatcher        *service.WebhookDispatcher
+	NotificationService      *service.NotificationService
+	APIKeyService            service.APIKeyService
+	APIKeyUsageMeter         *service.APIKeyUsageMeter
+	SecurityMonitor          *service.SecurityMonitor
+	UserCreateQueue          *service.UserCreateQueue
+	ValidationTelemetry      *service.ValidationTelemetry
+	RateLimitStore           middleware.RateLimitStore
+	CSRFTokenStore           middleware.CSRFTokenStore
+	DB                       *sql.DB
+	Logger                   *logger.Logger
+	Config                   *config.Config
 }
 
 func main() {
+	migrateOnStart := flag.Bool("migrate", false, "apply pending database migrations before starting the server")
+	flag.Parse()
+
+	// The healthcheck command probes a running server and exits without starting one
+	if flag.Arg(0) == "healthcheck" {
+		os.Exit(runHealthCheck(flag.Args()[1:]))
+	}
+
+	// The rotate-keys command re-encrypts personal data with a new key and exits
+	if flag.Arg(0) == "rotate-keys" {
+		os.Exit(runRotateKeys(flag.Args()[1:]))
+	}
+
 	// Initialize application with dependency injection
 	app, cleanup, err := wireApp()
 	if err != nil {
@@ -56,16 +108,52 @@ func main() {
 	log.Infof("Starting normal-form-app server in %s mode", cfg.Server.Mode)
 	logger.InitDefaultLogger(cfg.Log.Level)
 
-	// Set Gin mode
-	if cfg.IsProduction() {
-		gin.SetMode(gin.ReleaseMode)
-	} else {
+	if *migrateOnStart {
+		if err := applyMigrations(app); err != nil {
+			log.WithError(err).Fatal("Failed to apply database migrations")
+		}
+	}
+
+	// Field validation rules must load; running without them would silently accept bad input
+	if err := app.ValidationRules.Load(context.Background()); err != nil {
+		log.WithError(err).Fatal("Failed to load validation rules")
+	}
+
+	// Gin mode follows the debug switch; handlers also check it before exposing bind errors
+	if cfg.Debug {
 		gin.SetMode(gin.DebugMode)
+		checkSchemaDrift(app)
+	} else {
+		gin.SetMode(gin.ReleaseMode)
 	}
 
 	// Create router
 	r := setupRouter(app)
 
+	// Start background workers
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+	if cfg.Outbox.Enabled {
+		go app.OutboxRelay.Run(workerCtx)
+	}
+	go app.DeletionRecordPurger.Run(workerCtx)
+	go app.AttachmentScanner.Run(workerCtx)
+	go app.ReservationReleaser.Run(workerCtx)
+	if cfg.Webhook.URL != "" {
+		go app.WebhookDispatcher.Run(workerCtx)
+	}
+	go app.NotificationService.Run(workerCtx)
+	go app.APIKeyUsageMeter.Run(workerCtx)
+	if cfg.ValidationTelemetry.Enabled {
+		go app.ValidationTelemetry.Run(workerCtx)
+	}
+	go app.FeatureFlags.Run(workerCtx)
+	if cfg.Security.MonitorEnabled {
+		go app.SecurityMonitor.Run(workerCtx)
+	}
+	// Tickets queued while the flag was on are still processed after it is turned off
+	go app.UserCreateQueue.Run(workerCtx)
+
 	// Create HTTP server with timeouts
 	srv := &http.Server{
 		Addr:         cfg.GetServerAddress(),
@@ -75,122 +163,338 @@ func main() {
 		IdleTimeout:  idleTimeoutSeconds * time.Second,
 	}
 
+	// Listening sockets are inherited from the previous process after a binary reload
+	upg, err := newUpgrader(log)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to read inherited listeners")
+	}
+
+	// Connection limits apply at the listener, before any request reaches middleware
+	ln, err := upg.listen("public", func() (net.Listener, error) { return net.Listen("tcp", srv.Addr) })
+	if err != nil {
+		log.WithError(err).Fatal("Failed to listen")
+	}
+	if cfg.Server.MaxConnections > 0 || cfg.Server.MaxConnectionsPerIP > 0 {
+		ln = connlimit.NewListener(ln, connlimit.Config{
+			MaxConnections:      cfg.Server.MaxConnections,
+			MaxConnectionsPerIP: cfg.Server.MaxConnectionsPerIP,
+		})
+	}
+
+	// Native TLS is for deployments without a fronting proxy terminating HTTPS
+	var redirectSrv *http.Server
+	if cfg.TLSEnabled() {
+		tlsConfig, certManager, err := newTLSConfig(cfg)
+		if err != nil {
+			log.WithError(err).Fatal("Invalid TLS configuration")
+		}
+		srv.TLSConfig = tlsConfig
+		if cfg.Server.HTTPRedirectPort != "" {
+			redirectSrv = newRedirectServer(cfg, certManager)
+		}
+	}
+
 	// Start server in a goroutine
 	go func() {
-		log.Infof("Server starting on %s", cfg.GetServerAddress())
-		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if srv.TLSConfig != nil {
+			log.Infof("Server starting on %s (HTTPS)", cfg.GetServerAddress())
+			err = srv.ServeTLS(ln, cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+		} else {
+			log.Infof("Server starting on %s", cfg.GetServerAddress())
+			err = srv.Serve(ln)
+		}
+		if err != nil && err != http.ErrServerClosed {
 			log.WithError(err).Fatal("Failed to start server")
 		}
 	}()
 
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	// A local reverse proxy can reach the same server over a Unix socket. It serves plain
+	// HTTP without connection limits, which the proxy enforces.
+	if cfg.Server.Socket != "" {
+		socketLn, err := upg.listen("socket", func() (net.Listener, error) {
+			return listenUnixSocket(cfg.Server.Socket, cfg.Server.SocketMode)
+		})
+		if err != nil {
+			log.WithError(err).Fatal("Failed to listen on Unix socket")
+		}
+		go func() {
+			log.Infof("Server starting on unix:%s", cfg.Server.Socket)
+			if err := srv.Serve(socketLn); err != nil && err != http.ErrServerClosed {
+				log.WithError(err).Fatal("Failed to serve Unix socket")
+			}
+		}()
+	}
+
+	if redirectSrv != nil {
+		redirectLn, err := upg.listen("redirect", func() (net.Listener, error) { return net.Listen("tcp", redirectSrv.Addr) })
+		if err != nil {
+			log.WithError(err).Fatal("Failed to listen for HTTP redirects")
+		}
+		go func() {
+			log.Infof("HTTP redirect server starting on %s", cfg.GetRedirectAddress())
+			if err := redirectSrv.Serve(redirectLn); err != nil && err != http.ErrServerClosed {
+				log.WithError(err).Fatal("Failed to start HTTP redirect server")
+			}
+		}()
+	}
+
+	// Operational endpoints get their own listener so the public one never exposes them
+	var adminSrv *http.Server
+	if adminListenerEnabled(cfg) {
+		adminSrv = newAdminServer(app)
+		adminLn, err := upg.listen("admin", func() (net.Listener, error) { return net.Listen("tcp", adminSrv.Addr) })
+		if err != nil {
+			log.WithError(err).Fatal("Failed to listen for admin server")
+		}
+		go func() {
+			log.Infof("Admin server starting on %s", cfg.GetAdminAddress())
+			if err := adminSrv.Serve(adminLn); err != nil && err != http.ErrServerClosed {
+				log.WithError(err).Fatal("Failed to start admin server")
+			}
+		}()
+	}
+
+	// Let the previous process, if any, hand over and drain
+	if err := upg.ready(); err != nil {
+		log.WithError(err).Warn("Failed to notify previous process of readiness")
+	}
+	if err := writePIDFile(cfg.Server.PIDFile); err != nil {
+		log.WithError(err).Warn("Failed to write PID file")
+	}
+
+	// Wait for interrupt signal to gracefully shutdown the server, or for a reload to hand
+	// the listeners to a new process
+	handedOver := awaitShutdown(upg, cfg.Server.ReloadTimeout, log)
 	log.Info("Shutting down server...")
+	stopWorkers()
 
 	// Give outstanding requests a deadline to complete
 	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeoutSeconds*time.Second)
 	defer cancel()
 
+	// Shutdown also closes the Unix socket listener, which removes the socket file unless a
+	// reload handed it to the new process
 	if err := srv.Shutdown(ctx); err != nil {
 		log.WithError(err).Fatal("Server forced to shutdown")
 	}
+	if redirectSrv != nil {
+		if err := redirectSrv.Shutdown(ctx); err != nil {
+			log.WithError(err).Warn("HTTP redirect server forced to shutdown")
+		}
+	}
+	// Probes keep answering until the public listener has drained
+	if adminSrv != nil {
+		if err := adminSrv.Shutdown(ctx); err != nil {
+			log.WithError(err).Warn("Admin server forced to shutdown")
+		}
+	}
+
+	// After a reload the PID file names the new process
+	if !handedOver {
+		removePIDFile(cfg.Server.PIDFile)
+	}
 
 	log.Info("Server exited")
 }
 
+// applyMigrations brings the schema up to date. Concurrent instances are serialized by
+// an advisory lock, so every replica can safely start with --migrate.
+func applyMigrations(app *Application) error {
+	migrator, err := migrate.New(app.DB, migrations.FS, app.Logger)
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.Up(context.Background(), 0)
+	if err != nil {
+		return err
+	}
+
+	app.Logger.WithField("applied", applied).Info("Database schema is up to date")
+	return nil
+}
+
+// checkSchemaDrift warns when the database schema does not match the migrations built into
+// this binary, which in development usually means a branch switch without migrating
+func checkSchemaDrift(app *Application) {
+	migrator, err := migrate.New(app.DB, migrations.FS, app.Logger)
+	if err != nil {
+		app.Logger.WithError(err).Warn("Failed to load migrations for the schema drift check")
+		return
+	}
+
+	status, err := migrator.Status(context.Background())
+	if err != nil {
+		app.Logger.WithError(err).Warn("Failed to read the migration status for the schema drift check")
+		return
+	}
+
+	log := app.Logger.WithField("version", status.Version).WithField("latest", status.Latest)
+	switch {
+	case status.Dirty:
+		log.Warn("Database schema is dirty; a migration failed midway")
+	case status.Version > status.Latest:
+		log.Warn("Database schema is newer than this build; it was migrated by a later version")
+	case status.Pending > 0:
+		log.WithField("pending", status.Pending).Warn("Database schema is behind this build; run with --migrate")
+	}
+}
+
+// partnerKeyAuthenticator adapts the API key service to the partner auth middleware
+func partnerKeyAuthenticator(apiKeys service.APIKeyService) middleware.PartnerKeyAuthenticator {
+	return func(ctx context.Context, key string) (*middleware.PartnerKey, error) {
+		apiKey, err := apiKeys.Authenticate(ctx, key)
+		if err != nil || apiKey == nil {
+			return nil, err
+		}
+		return &middleware.PartnerKey{ID: apiKey.ID, Partner: apiKey.Partner, Scopes: apiKey.Scopes}, nil
+	}
+}
+
 // setupRouter configures and returns the Gin router
 func setupRouter(app *Application) *gin.Engine {
 	r := gin.New()
 
-	// Add middleware
-	r.Use(middleware.SimpleLoggerMiddleware(app.Logger))
-	r.Use(middleware.ErrorHandlerMiddleware(app.Logger))
-	r.Use(middleware.CORSMiddleware())
-	
-	// Security middleware
-	r.Use(middleware.SecurityHeaders())
-	r.Use(middleware.InputSanitization())
-	r.Use(middleware.RateLimit(100, 1*time.Minute)) // 100 requests per minute
-	r.Use(middleware.CSRF())
-
-	// Set up 404 and 405 handlers
-	r.NoRoute(middleware.NotFoundMiddleware())
-	r.NoMethod(middleware.MethodNotAllowedMiddleware())
-
-	// Health check endpoints
-	health := r.Group("/health")
-	{
-		health.GET("", app.HealthHandler.Health)
-		health.GET("/live", app.HealthHandler.LivenessProbe)
-		health.GET("/ready", app.HealthHandler.ReadinessProbe)
-	}
-
-	// API v1 routes
-	api := r.Group("/api/v1")
-	{
-		api.GET("/ping", func(c *gin.Context) {
-			c.JSON(http.StatusOK, gin.H{
-				"success": true,
-				"data": gin.H{
-					"message": "pong",
-					"service": "normal-form-app",
-					"version": "1.0.0",
-				},
-			})
-		})
-		
-		// CSRF token endpoint - handled by CSRF middleware
-		api.GET("/csrf-token", func(c *gin.Context) {
-			// This route is handled by the CSRF middleware
-		})
+	// Resolve real client IPs behind Cloudflare/ALB before any middleware reads them
+	clientIPMiddleware, err := middleware.ConfigureClientIP(r, middleware.ClientIPConfig{
+		TrustedPlatform: app.Config.Server.TrustedPlatform,
+		TrustedProxies:  app.Config.Server.TrustedProxies,
+		ProxyDepth:      app.Config.Server.ProxyDepth,
+	})
+	if err != nil {
+		app.Logger.WithError(err).Fatal("Invalid client IP configuration")
+	}
+	if clientIPMiddleware != nil {
+		r.Use(clientIPMiddleware)
+	}
 
-		// User endpoints
-		users := api.Group("/users")
-		{
-			users.POST("", app.UserHandler.CreateUser)
-			users.POST("/validate", app.UserHandler.ValidateUser)
-			users.GET("/:id", app.UserHandler.GetUser)
-			users.PUT("/:id", app.UserHandler.UpdateUser)
-			users.DELETE("/:id", app.UserHandler.DeleteUser)
-		}
+	rateLimitPolicy, err := middleware.NewRateLimitPolicy(
+		app.Config.RateLimit.DefaultLimit,
+		app.Config.RateLimit.DefaultPeriod,
+		app.Config.RateLimit.DefaultBurst,
+		app.Config.RateLimit.Rules,
+	)
+	if err != nil {
+		app.Logger.WithError(err).Fatal("Invalid rate limit configuration")
+	}
 
-		// Session endpoints
-		sessions := api.Group("/sessions")
-		{
-			sessions.POST("", app.SessionHandler.CreateSession)
-			sessions.GET("/:id", app.SessionHandler.GetSession)
-			sessions.PUT("/:id", app.SessionHandler.UpdateSession)
-			sessions.DELETE("/:id", app.SessionHandler.DeleteSession)
-		}
+	rateLimit := middleware.RateLimitWithPolicy(rateLimitPolicy, app.RateLimitStore, app.Logger)
 
-		// Option endpoints
-		options := api.Group("/options")
-		{
-			options.GET("", app.OptionHandler.GetOptions)
-			options.POST("/check-inventory", app.OptionHandler.CheckInventory)
-			options.GET("/:type", app.OptionHandler.GetOption)
+	// Bodies of the form and partner APIs are inspected; admin bodies such as email templates
+	// legitimately hold markup, and uploads and webhooks are limited by their handlers
+	inspection := func(c *gin.Context) { c.Next() }
+	if app.Config.Inspection.Enabled {
+		inspection, err = middleware.RequestInspection(middleware.RequestInspectionConfig{
+			MaxBodyBytes:       app.Config.Inspection.MaxBodyBytes,
+			MaxDepth:           app.Config.Inspection.MaxDepth,
+			MaxArrayLength:     app.Config.Inspection.MaxArrayLength,
+			RejectControlChars: app.Config.Inspection.RejectControlChars,
+			InjectionChecks:    app.Config.Inspection.InjectionChecks,
+		}, app.Logger)
+		if err != nil {
+			app.Logger.WithError(err).Fatal("Invalid request inspection configuration")
 		}
+	}
 
-		// Address endpoints
-		api.GET("/address/search", app.AddressHandler.SearchAddress)
-		api.POST("/region/check", app.AddressHandler.CheckRegion)
-
-		// Prefecture endpoints
-		prefectures := api.Group("/prefectures")
-		{
-			prefectures.GET("", app.AddressHandler.GetPrefectures)
-			prefectures.GET("/:name", app.AddressHandler.GetPrefecture)
+	registry := router.NewRegistry(router.Options{
+		Groups: []router.Group{
+			{Name: groupSystem, Middleware: []gin.HandlerFunc{rateLimit}},
+			{Name: groupPublic, Middleware: []gin.HandlerFunc{
+				middleware.InputSanitization(middleware.ContentTypeJSON),
+				rateLimit,
+				inspection,
+				middleware.CSRF(app.CSRFTokenStore, app.Logger),
+			}},
+			{Name: groupUpload, Middleware: []gin.HandlerFunc{
+				middleware.InputSanitization(middleware.ContentTypeMultipart),
+				rateLimit,
+				middleware.CSRF(app.CSRFTokenStore, app.Logger),
+			}},
+			// Webhook callers authenticate with signatures rather than cookies, so CSRF does not
+			// apply; SNS posts JSON as text/plain
+			{Name: groupWebhook, Middleware: []gin.HandlerFunc{
+				middleware.InputSanitization(middleware.ContentTypeJSON, middleware.ContentTypeText),
+				rateLimit,
+			}},
+			// Admin callers authenticate with a token rather than cookies, so CSRF does not apply
+			{Name: groupAdmin, Middleware: []gin.HandlerFunc{
+				middleware.InputSanitization(middleware.ContentTypeJSON),
+				rateLimit,
+			}},
+			// Partners authenticate with API keys and are rate limited and metered per key
+			{Name: groupPartner, Middleware: []gin.HandlerFunc{
+				middleware.InputSanitization(middleware.ContentTypeJSON),
+				middleware.PartnerAuth(partnerKeyAuthenticator(app.APIKeyService), app.Logger),
+				middleware.PartnerUsage(app.APIKeyUsageMeter.Record),
+				middleware.RateLimitByKey(rateLimitPolicy, app.RateLimitStore, middleware.PartnerRateLimitKey, app.Logger),
+				inspection,
+			}},
+		},
+		AdminAuth:    middleware.AdminAuth(app.Config.Admin.APIToken),
+		PartnerScope: middleware.PartnerScope,
+		// Write endpoints are rejected while the read_only_mode feature flag is on
+		ReadOnly: middleware.ReadOnlyMode(
+			func() bool { return app.FeatureFlags.Enabled(service.FlagReadOnlyMode) },
+			app.Logger,
+		),
+		StrictMode: middleware.StrictModeConfig{
+			Classes: app.Config.StrictMode.Classes,
+			Routes:  app.Config.StrictMode.Routes,
+		},
+		Title:   "normal-form-app API",
+		Version: apiVersion,
+		Logger:  app.Logger,
+	})
+	if err := registry.Add(apiRoutes(app, registry)...); err != nil {
+		app.Logger.WithError(err).Fatal("Invalid route table")
+	}
+	var v1Sunset time.Time
+	if app.Config.APIVersions.V1Sunset != "" {
+		if v1Sunset, err = time.Parse(time.DateOnly, app.Config.APIVersions.V1Sunset); err != nil {
+			app.Logger.WithError(err).Fatal("Invalid API version configuration")
 		}
+	}
+	if err := registry.AddVersion(apiV2(v1Sunset)); err != nil {
+		app.Logger.WithError(err).Fatal("Invalid API version configuration")
+	}
+	if err := registry.CheckStrictMode(); err != nil {
+		app.Logger.WithError(err).Fatal("Invalid strict mode configuration")
+	}
+	rateLimitPolicy.Classes = registry.RateLimitClasses()
+	rateLimitPolicy.Origins = registry.RateLimitOrigins()
 
-		// Plan endpoints
-		plans := api.Group("/plans")
-		{
-			plans.GET("", app.PlanHandler.GetPlans)
-			plans.GET("/:type", app.PlanHandler.GetPlan)
+	// Global middleware runs for every request, before the middleware of the route's group
+	r.Use(middleware.CorrelationMiddleware())
+	r.Use(middleware.PerformanceMiddleware(registry.EndpointLabel))
+	r.Use(middleware.SimpleLoggerMiddleware(app.Logger))
+	if app.Config.Log.Bodies {
+		if app.Config.IsProduction() {
+			app.Logger.Warn("Request and response bodies are logged in production")
 		}
+		r.Use(middleware.BodyLogging(middleware.BodyLoggingConfig{
+			SampleRate: app.Config.Log.BodySampleRate,
+			MaxBytes:   app.Config.Log.BodyMaxBytes,
+		}, app.Logger))
 	}
+	if app.Config.Security.MonitorEnabled {
+		r.Use(middleware.SecurityMonitor(
+			app.SecurityMonitor.BlockedUntil, app.SecurityMonitor.Record, app.Config.Admin.APIToken, app.Logger,
+		))
+	}
+	r.Use(middleware.SandboxSelector(app.Config.Admin.APIToken, app.Logger))
+	r.Use(middleware.CacheBypass(app.Config.Admin.APIToken, app.Logger))
+	r.Use(middleware.CallerRole(app.Config.Admin.APIToken))
+	r.Use(middleware.ErrorHandlerMiddleware(app.Logger))
+	r.Use(middleware.CORSMiddleware())
+	r.Use(middleware.SecurityHeaders())
+
+	// Set up 404 and 405 handlers. Unmatched requests are still rate limited, so probing
+	// for endpoints is throttled like other traffic.
+	r.NoRoute(append(registry.GroupMiddleware(groupSystem), middleware.NotFoundMiddleware())...)
+	r.NoMethod(append(registry.GroupMiddleware(groupSystem), middleware.MethodNotAllowedMiddleware())...)
+
+	registry.Register(r)
 
 	return r
 }
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const (
+	// envInheritedListeners names the listening sockets passed to a new process, in file
+	// descriptor order starting at 3
+	envInheritedListeners = "GRACEFUL_LISTENERS"
+	// envReadyFD is the pipe the new process writes to once it serves requests
+	envReadyFD = "GRACEFUL_READY_FD"
+	// firstInheritedFD is the descriptor of the first entry of exec.Cmd.ExtraFiles
+	firstInheritedFD = 3
+)
+
+// upgrader hands the listening sockets to a new copy of the binary, so a deploy on a VM
+// replaces the process without refusing connections: the new process accepts on the same
+// sockets while the old one finishes its in-flight requests and exits.
+type upgrader struct {
+	inherited map[string]*os.File
+	readyPipe *os.File
+
+	names     []string
+	listeners map[string]net.Listener
+	log       *logger.Logger
+}
+
+// newUpgrader picks up the sockets passed by a parent process, if any
+func newUpgrader(log *logger.Logger) (*upgrader, error) {
+	u := &upgrader{
+		inherited: make(map[string]*os.File),
+		listeners: make(map[string]net.Listener),
+		log:       log,
+	}
+
+	names := os.Getenv(envInheritedListeners)
+	readyFD := os.Getenv(envReadyFD)
+	// A later upgrade of this process passes its own sockets
+	_ = os.Unsetenv(envInheritedListeners)
+	_ = os.Unsetenv(envReadyFD)
+
+	if names != "" {
+		for i, name := range strings.Split(names, ",") {
+			u.inherited[name] = os.NewFile(uintptr(firstInheritedFD+i), name)
+		}
+	}
+	if readyFD != "" {
+		fd, err := strconv.Atoi(readyFD)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", envReadyFD, readyFD, err)
+		}
+		u.readyPipe = os.NewFile(uintptr(fd), "ready")
+	}
+
+	return u, nil
+}
+
+// listen returns the socket named name inherited from the parent, or creates it with
+// create. Unix sockets are removed by whichever process closes them last.
+func (u *upgrader) listen(name string, create func() (net.Listener, error)) (net.Listener, error) {
+	var ln net.Listener
+	if file, ok := u.inherited[name]; ok {
+		delete(u.inherited, name)
+		inherited, err := net.FileListener(file)
+		_ = file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to inherit %s listener: %w", name, err)
+		}
+		if unixLn, ok := inherited.(*net.UnixListener); ok {
+			unixLn.SetUnlinkOnClose(true)
+		}
+		u.log.WithField("listener", name).Info("Inherited listener from previous process")
+		ln = inherited
+	} else {
+		created, err := create()
+		if err != nil {
+			return nil, err
+		}
+		ln = created
+	}
+
+	u.names = append(u.names, name)
+	u.listeners[name] = ln
+	return ln, nil
+}
+
+// ready tells the parent process, if any, that this process serves requests. Sockets the
+// parent passed but this configuration no longer uses are closed.
+func (u *upgrader) ready() error {
+	for name, file := range u.inherited {
+		u.log.WithField("listener", name).Warn("Closing inherited listener that is no longer configured")
+		_ = file.Close()
+	}
+	u.inherited = nil
+
+	if u.readyPipe == nil {
+		return nil
+	}
+	_, err := u.readyPipe.Write([]byte{1})
+	if closeErr := u.readyPipe.Close(); err == nil {
+		err = closeErr
+	}
+	u.readyPipe = nil
+	return err
+}
+
+// upgrade starts a new copy of the binary with the listening sockets and waits until it
+// serves requests. On failure the new process is stopped and this one keeps serving.
+func (u *upgrader) upgrade(timeout time.Duration) error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	files := make([]*os.File, 0, len(u.names)+1)
+	defer func() {
+		for _, file := range files {
+			_ = file.Close()
+		}
+	}()
+	for _, name := range u.names {
+		file, err := listenerFile(u.listeners[name])
+		if err != nil {
+			return fmt.Errorf("failed to pass %s listener: %w", name, err)
+		}
+		files = append(files, file)
+	}
+
+	readyRead, readyWrite, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create readiness pipe: %w", err)
+	}
+	defer readyRead.Close()
+	files = append(files, readyWrite)
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		envInheritedListeners+"="+strings.Join(u.names, ","),
+		envReadyFD+"="+strconv.Itoa(firstInheritedFD+len(u.names)),
+	)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start new process: %w", err)
+	}
+	// Only the child may hold the write end, so its exit also ends the wait
+	_ = readyWrite.Close()
+	files = files[:len(files)-1]
+
+	// Passing the descriptors switched the shared sockets to blocking mode, in which an
+	// accept of this process would hold its thread and delay shutdown
+	for name, ln := range u.listeners {
+		if err := setNonblocking(ln); err != nil {
+			u.log.WithError(err).WithField("listener", name).Warn("Failed to restore nonblocking listener")
+		}
+	}
+
+	u.log.WithField("pid", cmd.Process.Pid).Info("Started new process, waiting until it is ready")
+
+	readyErr := make(chan error, 1)
+	go func() {
+		// The child writes one byte when ready; if it exits first the read hits EOF
+		_, err := io.ReadFull(readyRead, make([]byte, 1))
+		readyErr <- err
+	}()
+
+	select {
+	case err := <-readyErr:
+		if err != nil {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+			return fmt.Errorf("new process exited during startup: %w", err)
+		}
+	case <-time.After(timeout):
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return fmt.Errorf("new process not ready within %s", timeout)
+	}
+	// The new process outlives this one, which must not wait for it
+	_ = cmd.Process.Release()
+
+	// The new process owns the Unix socket files now
+	for _, ln := range u.listeners {
+		if unixLn, ok := ln.(*net.UnixListener); ok {
+			unixLn.SetUnlinkOnClose(false)
+		}
+	}
+	return nil
+}
+
+// listenerFile duplicates the descriptor of a TCP or Unix listener
+func listenerFile(ln net.Listener) (*os.File, error) {
+	switch l := ln.(type) {
+	case *net.TCPListener:
+		return l.File()
+	case *net.UnixListener:
+		return l.File()
+	default:
+		return nil, fmt.Errorf("unsupported listener type %T", ln)
+	}
+}
+
+// awaitShutdown blocks until the process should stop and reports whether a new process took
+// over the listeners. A reload that fails leaves this process serving.
+func awaitShutdown(upg *upgrader, timeout time.Duration, log *logger.Logger) bool {
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	reload := make(chan os.Signal, 1)
+	// Notify without signals would relay every signal
+	if len(reloadSignals) > 0 {
+		signal.Notify(reload, reloadSignals...)
+	}
+
+	for {
+		select {
+		case <-quit:
+			return false
+		case <-reload:
+			log.Info("Reloading: starting new process")
+			if err := upg.upgrade(timeout); err != nil {
+				log.WithError(err).Error("Reload failed, continuing to serve")
+				continue
+			}
+			log.Info("New process is ready, draining this one")
+			return true
+		}
+	}
+}
+
+// writePIDFile records the PID of the serving process for service managers; empty path
+// disables it
+func writePIDFile(path string) error {
+	if path == "" {
+		return nil
+	}
+	return os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644) //nolint:gosec // PID files are world readable
+}
+
+// removePIDFile deletes the PID file unless another process has rewritten it
+func removePIDFile(path string) {
+	if path == "" {
+		return
+	}
+	if content, err := os.ReadFile(path); err == nil && strings.TrimSpace(string(content)) == strconv.Itoa(os.Getpid()) {
+		_ = os.Remove(path)
+	}
+}
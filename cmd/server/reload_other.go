@@ -0,0 +1,16 @@
+//go:build !unix
+
+package main
+
+import (
+	"net"
+	"os"
+)
+
+// reloadSignals is empty where passing listening sockets to a new process is unsupported
+var reloadSignals []os.Signal
+
+// setNonblocking is never needed without reloads
+func setNonblocking(net.Listener) error {
+	return nil
+}
@@ -0,0 +1,31 @@
+//go:build unix
+
+package main
+
+import (
+	"net"
+	"os"
+	"syscall"
+)
+
+// reloadSignals start a binary reload, as SIGUSR2 does for nginx binary upgrades
+var reloadSignals = []os.Signal{syscall.SIGUSR2}
+
+// setNonblocking puts the socket of a listener back into nonblocking mode
+func setNonblocking(ln net.Listener) error {
+	sc, ok := ln.(syscall.Conn)
+	if !ok {
+		return nil
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var setErr error
+	if err := raw.Control(func(fd uintptr) {
+		setErr = syscall.SetNonblock(int(fd), true)
+	}); err != nil {
+		return err
+	}
+	return setErr
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/config"
+	"github.com/octop162/normal-form-app-by-claude/pkg/database"
+	"github.com/octop162/normal-form-app-by-claude/pkg/fieldcrypt"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const rotateKeysUsage = `Usage: server rotate-keys -from KEY_ID [flags]
+
+Re-encrypts the personal data encrypted with the key -from (the users' phone, email and
+address columns and the payloads of queued registrations) with the active key named by
+PII_ENCRYPTION_KEY_ID. PII_ENCRYPTION_KEYS must hold both keys, and the servers must already
+be deployed with the new active key so that no new rows are written with the old one.
+
+Rows are rewritten in batches, each in its own transaction together with a checkpoint in
+key_rotations, so the command can be stopped (Ctrl-C or SIGTERM finishes the batch in hand)
+and run again to resume. When no rows are left with the old key it says so; only then can
+the old key be removed from PII_ENCRYPTION_KEYS and the secret store.
+
+Flags:
+`
+
+// rotateKeysOptions holds the flags of the rotate-keys command
+type rotateKeysOptions struct {
+	fromKeyID string
+	toKeyID   string
+	batchSize int
+	// maxRate caps the rows rewritten per second; 0 is unlimited
+	maxRate int
+	// pause is waited between batches
+	pause   time.Duration
+	restart bool
+}
+
+// rotationTarget is a table holding values encrypted with the field cipher
+type rotationTarget struct {
+	name string
+	// batch rewrites up to limit rows after afterID encrypted with the key, returning the last
+	// ID rewritten (0 when none remain) and the number rewritten
+	batch func(ctx context.Context, keyID string, afterID int64, limit int) (int64, int, error)
+	count func(ctx context.Context, keyID string) (int, error)
+}
+
+// runRotateKeys rotates the personal data from an old key to the active key and returns the
+// process exit code
+func runRotateKeys(args []string) int {
+	var opts rotateKeysOptions
+	flags := flag.NewFlagSet("rotate-keys", flag.ContinueOnError)
+	flags.StringVar(&opts.fromKeyID, "from", "", "ID of the key to rotate away from (required)")
+	flags.StringVar(&opts.toKeyID, "to", "",
+		"ID of the key to rotate to; must be PII_ENCRYPTION_KEY_ID (default: PII_ENCRYPTION_KEY_ID)")
+	flags.IntVar(&opts.batchSize, "batch-size", 500, "number of rows locked and rewritten per transaction")
+	flags.IntVar(&opts.maxRate, "max-rate", 0, "maximum rows rewritten per second (0: unlimited)")
+	flags.DurationVar(&opts.pause, "pause", 0, "pause between batches, e.g. 200ms")
+	flags.BoolVar(&opts.restart, "restart", false, "discard saved checkpoints and start from the first row")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), rotateKeysUsage)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := rotateKeys(ctx, &opts); err != nil {
+		fmt.Fprintln(os.Stderr, "rotate-keys:", err)
+		return 1
+	}
+	return 0
+}
+
+// rotateKeys checks the keys with the key provider and rotates each target in turn
+func rotateKeys(ctx context.Context, opts *rotateKeysOptions) error {
+	if opts.fromKeyID == "" {
+		return fmt.Errorf("-from is required")
+	}
+	if opts.batchSize <= 0 {
+		return fmt.Errorf("batch size must be positive")
+	}
+	if opts.maxRate < 0 {
+		return fmt.Errorf("max rate must not be negative")
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	log := logger.NewLogger(cfg.Log.Level)
+
+	keys, err := fieldcrypt.ParseKeys(cfg.Privacy.EncryptionKeys, cfg.Privacy.EncryptionKeyID)
+	if err != nil {
+		return fmt.Errorf("invalid personal data encryption keys: %w", err)
+	}
+	if err := cfg.Privacy.ValidateEncryption(); err != nil {
+		return err
+	}
+	if err := checkRotationKeys(ctx, keys, opts); err != nil {
+		return err
+	}
+	cipher := fieldcrypt.NewCipher(keys, cfg.Privacy.BlindIndexKey)
+
+	db, err := database.NewDB(&cfg.Database, log)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	userRepo := repository.NewUserRepository(db.DB, cipher, log)
+	ticketRepo := repository.NewUserCreateTicketRepository(db.DB, cipher, log)
+	rotationRepo := repository.NewKeyRotationRepository(db.DB, log)
+	txManager := repository.NewTxManager(db.DB, log)
+
+	targets := []rotationTarget{
+		{
+			name: model.KeyRotationTargetUsers,
+			batch: func(ctx context.Context, keyID string, afterID int64, limit int) (int64, int, error) {
+				lastID, rewritten, err := userRepo.RotateKeyBatch(ctx, keyID, int(afterID), limit)
+				return int64(lastID), rewritten, err
+			},
+			count: userRepo.CountEncryptedWithKey,
+		},
+		{
+			name:  model.KeyRotationTargetUserCreateTickets,
+			batch: ticketRepo.RotateKeyBatch,
+			count: ticketRepo.CountEncryptedWithKey,
+		},
+	}
+
+	fmt.Printf("Rotating personal data from key %s to key %s\n", opts.fromKeyID, opts.toKeyID)
+	for _, target := range targets {
+		if err := rotateTarget(ctx, txManager, rotationRepo, target, opts); err != nil {
+			return err
+		}
+	}
+
+	remaining := 0
+	for _, target := range targets {
+		count, err := target.count(ctx, opts.fromKeyID)
+		if err != nil {
+			return err
+		}
+		remaining += count
+	}
+	if remaining > 0 {
+		// Rows written by servers still running with the old active key
+		return fmt.Errorf("%d row(s) are still encrypted with key %s; check that every server uses "+
+			"PII_ENCRYPTION_KEY_ID=%s and run rotate-keys again", remaining, opts.fromKeyID, opts.toKeyID)
+	}
+
+	fmt.Printf("Done: no rows are encrypted with key %s; it can be removed from PII_ENCRYPTION_KEYS\n",
+		opts.fromKeyID)
+	return nil
+}
+
+// checkRotationKeys verifies that the rotation writes with the key the servers write with and
+// that the key provider can supply both keys before any row is touched
+func checkRotationKeys(ctx context.Context, keys fieldcrypt.KeyProvider, opts *rotateKeysOptions) error {
+	activeID := keys.ActiveKeyID()
+	if activeID == "" {
+		return fmt.Errorf("PII_ENCRYPTION_KEY_ID is not set; use reencrypt-pii to write plaintext")
+	}
+	if opts.toKeyID == "" {
+		opts.toKeyID = activeID
+	}
+	if opts.toKeyID != activeID {
+		return fmt.Errorf("key %s is not the active key %s; deploy the servers with PII_ENCRYPTION_KEY_ID=%s "+
+			"first so that new rows are not written with the old key", opts.toKeyID, activeID, opts.toKeyID)
+	}
+	if opts.fromKeyID == opts.toKeyID {
+		return fmt.Errorf("key %s is already the active key", opts.fromKeyID)
+	}
+
+	for _, id := range []string{opts.fromKeyID, opts.toKeyID} {
+		if _, err := keys.Key(ctx, id); err != nil {
+			return fmt.Errorf("key provider cannot supply key %s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// rotateTarget rewrites the rows of a target batch by batch from its checkpoint, pacing the
+// batches to the configured rate. When the context is cancelled it stops after the batch in
+// hand, leaving the checkpoint to resume from.
+func rotateTarget(
+	ctx context.Context,
+	txManager repository.TxManager,
+	rotationRepo repository.KeyRotationRepository,
+	target rotationTarget,
+	opts *rotateKeysOptions,
+) error {
+	// Batches are not cancelled halfway; the context only stops the loop between them
+	batchCtx := context.WithoutCancel(ctx)
+
+	rotation, err := rotationRepo.Start(batchCtx, opts.fromKeyID, opts.toKeyID, target.name)
+	if err != nil {
+		return err
+	}
+	if opts.restart && (rotation.LastID > 0 || rotation.CompletedAt != nil) {
+		if err := rotationRepo.Reset(batchCtx, rotation.ID); err != nil {
+			return err
+		}
+		rotation.LastID, rotation.Rewritten, rotation.CompletedAt = 0, 0, nil
+	}
+
+	pending, err := target.count(batchCtx, opts.fromKeyID)
+	if err != nil {
+		return err
+	}
+	if rotation.CompletedAt != nil {
+		if pending == 0 {
+			fmt.Printf("%s: already rotated (%d row(s))\n", target.name, rotation.Rewritten)
+			return nil
+		}
+		// Rows were written with the old key after the rotation completed
+		if err := rotationRepo.Reset(batchCtx, rotation.ID); err != nil {
+			return err
+		}
+		rotation.LastID, rotation.Rewritten, rotation.CompletedAt = 0, 0, nil
+	}
+	if rotation.LastID > 0 {
+		fmt.Printf("%s: resuming after ID %d (%d row(s) rewritten before)\n",
+			target.name, rotation.LastID, rotation.Rewritten)
+	}
+
+	lastID, rewritten := rotation.LastID, rotation.Rewritten
+	for {
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted; run rotate-keys again to resume %s after ID %d", target.name, lastID)
+		}
+
+		start := time.Now()
+		var batchLastID int64
+		var batchRewritten int
+		err := txManager.WithinTransaction(batchCtx, func(txCtx context.Context) error {
+			var err error
+			batchLastID, batchRewritten, err = target.batch(txCtx, opts.fromKeyID, lastID, opts.batchSize)
+			if err != nil || batchLastID == 0 {
+				return err
+			}
+			return rotationRepo.SaveProgress(txCtx, rotation.ID, batchLastID, batchRewritten)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to rotate %s after ID %d: %w", target.name, lastID, err)
+		}
+		if batchLastID == 0 {
+			break
+		}
+
+		lastID = batchLastID
+		rewritten += int64(batchRewritten)
+		fmt.Printf("%s: rewrote %d row(s) up to ID %d, about %d left\n",
+			target.name, rewritten, lastID, max(int64(pending)-(rewritten-rotation.Rewritten), 0))
+
+		if err := throttle(ctx, start, batchRewritten, opts); err != nil {
+			return fmt.Errorf("interrupted; run rotate-keys again to resume %s after ID %d", target.name, lastID)
+		}
+	}
+
+	// Rows before the checkpoint are left when servers still running with the old active key
+	// wrote them; the rotation is then not complete and the next run starts over
+	remaining, err := target.count(batchCtx, opts.fromKeyID)
+	if err != nil {
+		return err
+	}
+	if remaining > 0 {
+		if err := rotationRepo.Reset(batchCtx, rotation.ID); err != nil {
+			return err
+		}
+		fmt.Printf("%s: %d row(s) were written with key %s during the rotation\n",
+			target.name, remaining, opts.fromKeyID)
+		return nil
+	}
+
+	if err := rotationRepo.Complete(batchCtx, rotation.ID); err != nil {
+		return err
+	}
+	fmt.Printf("%s: done, rewrote %d row(s)\n", target.name, rewritten)
+	return nil
+}
+
+// throttle waits after a batch so that at most maxRate rows are rewritten per second, and at
+// least the configured pause. It returns the context's error when cancelled while waiting.
+func throttle(ctx context.Context, batchStart time.Time, rewritten int, opts *rotateKeysOptions) error {
+	wait := opts.pause
+	if opts.maxRate > 0 {
+		paced := time.Duration(rewritten)*time.Second/time.Duration(opts.maxRate) - time.Since(batchStart)
+		wait = max(wait, paced)
+	}
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,426 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/middleware"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/router"
+)
+
+// apiVersion is the API version reported by ping and the OpenAPI document
+const apiVersion = "1.0.0"
+
+// Middleware groups. Each group runs its own stack after the global middleware, so browser
+// protections such as CSRF apply only where browsers call with cookies.
+const (
+	// groupSystem serves probes, discovery and CSRF token issuance
+	groupSystem = "system"
+	// groupPublic serves the registration form
+	groupPublic = "public"
+	// groupUpload serves form file uploads, which are sent as multipart bodies
+	groupUpload = "upload"
+	// groupWebhook serves signed callbacks from third-party services
+	groupWebhook = "webhook"
+	// groupAdmin serves the token-authenticated admin API
+	groupAdmin = "admin"
+	// groupPartner serves the read-only partner API, authenticated by API keys
+	groupPartner = "partner"
+)
+
+// masterDataMaxAge is how long clients may cache master data responses
+const masterDataMaxAge = time.Hour
+
+// Rate limit classes. Routes of a class share the rule configured for the class name in
+// RATE_LIMIT_RULES; without one they use the default rule.
+const (
+	rateLimitUserWrite    = "user-write"
+	rateLimitLookup       = "lookup"
+	rateLimitPhone        = "phone"
+	rateLimitSessionWrite = "session-write"
+	rateLimitUpload       = "upload"
+	rateLimitExternalAPI  = "external-api"
+	rateLimitWebhook      = "webhook"
+	rateLimitAdmin        = "admin"
+	rateLimitPartner      = "partner"
+)
+
+var (
+	// noStore keeps personal data out of browser and proxy caches
+	noStore = router.CachePolicy{NoStore: true}
+	// versioned keeps personal data out of caches but tags it with its version, which
+	// clients send back in If-Match to update it
+	versioned = router.CachePolicy{NoStore: true, ETag: true}
+	// masterData lets clients reuse master data responses and revalidate them once expired
+	masterData = router.CachePolicy{MaxAge: masterDataMaxAge, ETag: true}
+	// revalidated lets clients revalidate responses they keep, e.g. options whose
+	// availability changes too often for a max age
+	revalidated = router.CachePolicy{ETag: true}
+)
+
+// API version path prefixes
+const (
+	apiV1Prefix = "/api/v1"
+	apiV2Prefix = "/api/v2"
+)
+
+// apiV2 describes version 2 of the form API. It serves the version 1 routes of the form and
+// its discovery endpoints with the same handlers; routes whose DTOs change in version 2 get a
+// shim adapting them to the handler. Version 2 sends the address of a user as an object. The
+// admin API and webhooks are not versioned.
+func apiV2(v1Sunset time.Time) router.Version {
+	return router.Version{
+		Prefix:      apiV2Prefix,
+		Predecessor: apiV1Prefix,
+		NameSuffix:  "V2",
+		Groups:      []string{groupSystem, groupPublic, groupUpload},
+		Shims: map[string]router.Shim{
+			"createUser":        structuredAddress,
+			"validateUser":      structuredAddress,
+			"updateUser":        structuredAddress,
+			"patchUser":         structuredAddress,
+			"previewUserUpdate": structuredAddress,
+		},
+		PredecessorSunset: v1Sunset,
+	}
+}
+
+// apiRoutes returns the route table of the server
+func apiRoutes(app *Application, registry *router.Registry) []router.Route {
+	var routes []router.Route
+	// Health probes move to the admin listener when it is enabled
+	if !adminListenerEnabled(app.Config) {
+		routes = append(routes, healthRoutes(app)...)
+	}
+
+	return append(routes, []router.Route{
+		{Method: http.MethodGet, Path: "/api/v1/ping", Handler: ping,
+			Name: "ping", Summary: "Connectivity check", Tag: "system",
+			Group: groupSystem, Auth: router.AuthPublic},
+		{Method: http.MethodGet, Path: "/api/v1/openapi.json", Handler: registry.ServeOpenAPI,
+			Name: "getOpenAPI", Summary: "OpenAPI document generated from the route table", Tag: "system",
+			Group: groupSystem, Auth: router.AuthPublic, Cache: masterData},
+		{Method: http.MethodGet, Path: "/api/v1/csrf-token", Handler: middleware.CSRFTokenHandler(app.CSRFTokenStore, app.Logger),
+			Name: "getCSRFToken", Summary: "Issue a CSRF token", Tag: "security",
+			Group: groupSystem, Auth: router.AuthPublic, Cache: noStore},
+
+		// User endpoints
+		{Method: http.MethodPost, Path: "/api/v1/users", Handler: app.UserHandler.CreateUser,
+			Name: "createUser", Summary: "Register a user", Tag: "users",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitUserWrite, Cache: noStore, Mutates: true, StrictClass: router.StrictClassSubmit},
+		{Method: http.MethodGet, Path: "/api/v1/users/tickets/:ticket_id", Handler: app.UserHandler.GetCreateTicket,
+			Name: "getUserCreateTicket", Summary: "Get the status of a queued registration", Tag: "users",
+			Group: groupPublic, Auth: router.AuthPublic, Cache: noStore},
+		{Method: http.MethodPost, Path: "/api/v1/users/validate", Handler: app.UserHandler.ValidateUser,
+			Name: "validateUser", Summary: "Validate registration data", Tag: "users",
+			Group: groupPublic, Auth: router.AuthPublic, Cache: noStore, StrictClass: router.StrictClassSubmit},
+		{Method: http.MethodPost, Path: "/api/v1/users/lookup", Handler: app.UserLookupHandler.StartLookup,
+			Name: "startUserLookup", Summary: "Send a lookup verification code", Tag: "users",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitLookup, Cache: noStore, Mutates: true},
+		{Method: http.MethodPost, Path: "/api/v1/users/lookup/verify", Handler: app.UserLookupHandler.VerifyLookup,
+			Name: "verifyUserLookup", Summary: "Verify a lookup code", Tag: "users",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitLookup, Cache: noStore, Mutates: true},
+		{Method: http.MethodPost, Path: "/api/v1/users/phone/send-code", Handler: app.PhoneVerificationHandler.SendCode,
+			Name: "sendPhoneCode", Summary: "Send an SMS verification code", Tag: "users",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitPhone, Cache: noStore, Mutates: true},
+		{Method: http.MethodPost, Path: "/api/v1/users/phone/verify-code", Handler: app.PhoneVerificationHandler.VerifyCode,
+			Name: "verifyPhoneCode", Summary: "Verify an SMS verification code", Tag: "users",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitPhone, Cache: noStore, Mutates: true},
+		{Method: http.MethodGet, Path: "/api/v1/users/:id", Handler: app.UserHandler.GetUser,
+			Name: "getUser", Summary: "Get a user", Tag: "users",
+			Group: groupPublic, Auth: router.AuthPublic, Cache: versioned},
+		{Method: http.MethodPut, Path: "/api/v1/users/:id", Handler: app.UserHandler.UpdateUser,
+			Name: "updateUser", Summary: "Replace a user", Tag: "users",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitUserWrite, Cache: noStore, Mutates: true},
+		{Method: http.MethodPatch, Path: "/api/v1/users/:id", Handler: app.UserHandler.PatchUser,
+			Name: "patchUser", Summary: "Update user fields", Tag: "users",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitUserWrite, Cache: noStore, Mutates: true},
+		{Method: http.MethodPost, Path: "/api/v1/users/:id/preview-update", Handler: app.UserHandler.PreviewUpdateUser,
+			Name: "previewUserUpdate", Summary: "Preview a user update", Tag: "users",
+			Group: groupPublic, Auth: router.AuthPublic, Cache: noStore},
+		{Method: http.MethodDelete, Path: "/api/v1/users/:id", Handler: app.UserHandler.DeleteUser,
+			Name: "deleteUser", Summary: "Delete a user", Tag: "users",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitUserWrite, Mutates: true},
+		// Personal data requests are handled by operators, so they require the admin token
+		{Method: http.MethodPost, Path: "/api/v1/users/:id/export", Handler: app.PersonalDataHandler.ExportUser,
+			Name: "exportUserData", Summary: "Export everything stored about a user", Tag: "users",
+			Group: groupAdmin, Auth: router.AuthAdmin, RateLimitClass: rateLimitAdmin, Cache: noStore},
+		{Method: http.MethodPost, Path: "/api/v1/users/:id/erase", Handler: app.PersonalDataHandler.EraseUser,
+			Name: "eraseUserData", Summary: "Erase the personal data of a user", Tag: "users",
+			Group: groupAdmin, Auth: router.AuthAdmin, RateLimitClass: rateLimitAdmin, Cache: noStore, Mutates: true},
+
+		// Session endpoints
+		{Method: http.MethodPost, Path: "/api/v1/sessions", Handler: app.SessionHandler.CreateSession,
+			Name: "createSession", Summary: "Start a form session", Tag: "sessions",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitSessionWrite, Cache: noStore, Mutates: true},
+		{Method: http.MethodGet, Path: "/api/v1/sessions/:id", Handler: app.SessionHandler.GetSession,
+			Name: "getSession", Summary: "Get saved form data", Tag: "sessions",
+			Group: groupPublic, Auth: router.AuthPublic, Cache: noStore},
+		{Method: http.MethodPut, Path: "/api/v1/sessions/:id", Handler: app.SessionHandler.UpdateSession,
+			Name: "updateSession", Summary: "Replace saved form data", Tag: "sessions",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitSessionWrite, Cache: noStore, Mutates: true},
+		{Method: http.MethodPatch, Path: "/api/v1/sessions/:id/fields", Handler: app.SessionHandler.UpdateSessionFields,
+			Name: "updateSessionFields", Summary: "Autosave form fields", Tag: "sessions",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitSessionWrite, Cache: noStore, Mutates: true},
+		{Method: http.MethodPost, Path: "/api/v1/sessions/:id/steps/:step/complete", Handler: app.SessionHandler.CompleteStep,
+			Name: "completeSessionStep", Summary: "Validate a wizard step", Tag: "sessions",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitSessionWrite, Cache: noStore, Mutates: true},
+		{Method: http.MethodGet, Path: "/api/v1/sessions/:id/progress", Handler: app.SessionHandler.GetProgress,
+			Name: "getSessionProgress", Summary: "Get wizard progress", Tag: "sessions",
+			Group: groupPublic, Auth: router.AuthPublic, Cache: noStore},
+		{Method: http.MethodGet, Path: "/api/v1/sessions/:id/summary", Handler: app.SessionHandler.GetSummary,
+			Name: "getSessionSummary", Summary: "Get saved form data formatted for confirmation", Tag: "sessions",
+			Group: groupPublic, Auth: router.AuthPublic, Cache: noStore},
+		{Method: http.MethodDelete, Path: "/api/v1/sessions/:id", Handler: app.SessionHandler.DeleteSession,
+			Name: "deleteSession", Summary: "Discard a form session", Tag: "sessions",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitSessionWrite, Mutates: true},
+		{Method: http.MethodPost, Path: "/api/v1/sessions/:id/attachments", Handler: app.AttachmentHandler.LinkToSession,
+			Name: "linkSessionAttachment", Summary: "Attach a scanned file to a form session", Tag: "sessions",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitSessionWrite, Cache: noStore, Mutates: true},
+
+		// Attachment endpoints
+		{Method: http.MethodPost, Path: "/api/v1/attachments", Handler: app.AttachmentHandler.UploadAttachment,
+			Name: "uploadAttachment", Summary: "Upload a file for malware scanning", Tag: "attachments",
+			Group: groupUpload, Auth: router.AuthPublic, RateLimitClass: rateLimitUpload, Cache: noStore, Mutates: true},
+		{Method: http.MethodGet, Path: "/api/v1/attachments/:id", Handler: app.AttachmentHandler.GetAttachment,
+			Name: "getAttachment", Summary: "Get the scan status of an attachment", Tag: "attachments",
+			Group: groupPublic, Auth: router.AuthPublic, Cache: noStore},
+
+		// Option endpoints
+		{Method: http.MethodGet, Path: "/api/v1/options", Handler: app.OptionHandler.GetOptions,
+			Name: "listOptions", Summary: "List options", Tag: "master-data",
+			Group: groupPublic, Auth: router.AuthPublic, Cache: revalidated},
+		{Method: http.MethodPost, Path: "/api/v1/options/check-inventory", Handler: app.OptionHandler.CheckInventory,
+			Name: "checkInventory", Summary: "Check option inventory", Tag: "external-apis",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitExternalAPI, StrictClass: router.StrictClassBrowse},
+		{Method: http.MethodPost, Path: "/api/v1/options/availability", Handler: app.OptionHandler.CheckAvailability,
+			Name: "checkOptionAvailability", Summary: "Check option stock and region restrictions", Tag: "external-apis",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitExternalAPI, StrictClass: router.StrictClassBrowse},
+		{Method: http.MethodPost, Path: "/api/v1/options/reserve", Handler: app.OptionHandler.ReserveOptions,
+			Name: "reserveOptions", Summary: "Hold option stock for a form session", Tag: "external-apis",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitExternalAPI, Cache: noStore, Mutates: true, StrictClass: router.StrictClassSubmit},
+		{Method: http.MethodGet, Path: "/api/v1/options/recommended", Handler: app.OptionHandler.GetRecommendedOptions,
+			Name: "recommendOptions", Summary: "Rank the options of a plan for a region", Tag: "external-apis",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitExternalAPI, Cache: noStore, StrictClass: router.StrictClassBrowse},
+		{Method: http.MethodGet, Path: "/api/v1/options/:type", Handler: app.OptionHandler.GetOption,
+			Name: "getOption", Summary: "Get an option", Tag: "master-data",
+			Group: groupPublic, Auth: router.AuthPublic},
+
+		// Address endpoints
+		{Method: http.MethodGet, Path: "/api/v1/address/search", Handler: app.AddressHandler.SearchAddress,
+			Name: "searchAddress", Summary: "Search an address by postal code", Tag: "external-apis",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitExternalAPI, StrictClass: router.StrictClassBrowse},
+		{Method: http.MethodGet, Path: "/api/v1/address/reverse-search", Handler: app.AddressHandler.ReverseSearchAddress,
+			Name: "reverseSearchAddress", Summary: "Search postal codes by address", Tag: "external-apis",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitExternalAPI},
+		{Method: http.MethodGet, Path: "/api/v1/address/buildings", Handler: app.AddressHandler.SearchBuildings,
+			Name: "searchBuildings", Summary: "Suggest building names at an address", Tag: "master-data",
+			Group: groupPublic, Auth: router.AuthPublic, Cache: masterData},
+		{Method: http.MethodPost, Path: "/api/v1/region/check", Handler: app.AddressHandler.CheckRegion,
+			Name: "checkRegion", Summary: "Check regional restrictions", Tag: "external-apis",
+			Group: groupPublic, Auth: router.AuthPublic, RateLimitClass: rateLimitExternalAPI, StrictClass: router.StrictClassBrowse},
+
+		// Input normalization endpoints
+		{Method: http.MethodPost, Path: "/api/v1/normalize", Handler: app.NormalizationHandler.NormalizeKana,
+			Name: "normalizeKana", Summary: "Normalize kana input", Tag: "input",
+			Group: groupPublic, Auth: router.AuthPublic},
+		{Method: http.MethodPost, Path: "/api/v1/address/normalize", Handler: app.NormalizationHandler.NormalizeAddress,
+			Name: "normalizeAddress", Summary: "Normalize address parts to the stored form", Tag: "input",
+			Group: groupPublic, Auth: router.AuthPublic},
+
+		// Form bootstrap endpoint; it issues a CSRF token, so it is never cached
+		{Method: http.MethodGet, Path: "/api/v1/form/bootstrap", Handler: app.FormHandler.GetBootstrap,
+			Name: "getFormBootstrap", Summary: "Get master data and a CSRF token for the form in one request", Tag: "master-data",
+			Group: groupPublic, Auth: router.AuthPublic, Cache: noStore},
+
+		// Prefecture endpoints
+		{Method: http.MethodGet, Path: "/api/v1/prefectures", Handler: app.AddressHandler.GetPrefectures,
+			Name: "listPrefectures", Summary: "List prefectures", Tag: "master-data",
+			Group: groupPublic, Auth: router.AuthPublic, Cache: masterData},
+		{Method: http.MethodGet, Path: "/api/v1/prefectures/:name", Handler: app.AddressHandler.GetPrefecture,
+			Name: "getPrefecture", Summary: "Get a prefecture", Tag: "master-data",
+			Group: groupPublic, Auth: router.AuthPublic, Cache: masterData},
+
+		// Plan endpoints
+		{Method: http.MethodGet, Path: "/api/v1/plans", Handler: app.PlanHandler.GetPlans,
+			Name: "listPlans", Summary: "List plans", Tag: "master-data",
+			Group: groupPublic, Auth: router.AuthPublic, Cache: masterData},
+		{Method: http.MethodGet, Path: "/api/v1/plans/:type", Handler: app.PlanHandler.GetPlan,
+			Name: "getPlan", Summary: "Get a plan", Tag: "master-data",
+			Group: groupPublic, Auth: router.AuthPublic, Cache: masterData},
+		{Method: http.MethodPost, Path: "/api/v1/quotes", Handler: app.QuoteHandler.CreateQuote,
+			Name: "createQuote", Summary: "Price a plan and options per month", Tag: "master-data",
+			Group: groupPublic, Auth: router.AuthPublic, Cache: noStore},
+
+		// Webhook endpoints
+		{Method: http.MethodPost, Path: "/api/v1/webhooks/email-events", Handler: app.EmailEventHandler.ReceiveEvents,
+			Name: "receiveEmailEvents", Summary: "Receive mail provider bounce and complaint events", Tag: "webhooks",
+			Group: groupWebhook, Auth: router.AuthPublic, RateLimitClass: rateLimitWebhook, Cache: noStore, Mutates: true},
+		{Method: http.MethodPost, Path: "/api/v1/webhooks/email-events/:provider", Handler: app.EmailEventHandler.ReceiveEvents,
+			Name: "receiveProviderEmailEvents", Summary: "Receive bounce and complaint events of a mail provider", Tag: "webhooks",
+			Group: groupWebhook, Auth: router.AuthPublic, RateLimitClass: rateLimitWebhook, Cache: noStore, Mutates: true},
+
+		// Partner endpoints
+		{Method: http.MethodGet, Path: "/partner/v1/availability", Handler: app.PartnerHandler.GetAvailability,
+			Name: "getPartnerAvailability", Summary: "Check service availability at an address", Tag: "partner",
+			Group: groupPartner, Auth: router.AuthPartner, Scope: model.ScopeAvailabilityRead,
+			RateLimitClass: rateLimitPartner, Cache: noStore, StrictClass: router.StrictClassBrowse},
+		{Method: http.MethodGet, Path: "/partner/v1/plans", Handler: app.PartnerHandler.GetPlans,
+			Name: "listPartnerPlans", Summary: "List the plans offered to new registrations", Tag: "partner",
+			Group: groupPartner, Auth: router.AuthPartner, Scope: model.ScopePlansRead,
+			RateLimitClass: rateLimitPartner, Cache: noStore},
+
+		// Admin endpoints
+		adminRoute(http.MethodGet, "/external-apis", app.AdminHandler.GetExternalAPIs,
+			"listExternalAPIs", "List external API status"),
+		adminRoute(http.MethodPost, "/external-apis/:name/circuit-breaker", app.AdminHandler.SetCircuitBreakerOverride,
+			"setCircuitBreakerOverride", "Override an external API circuit breaker"),
+		adminRoute(http.MethodPost, "/external-apis/:name/probe", app.AdminHandler.ProbeExternalAPI,
+			"probeExternalAPI", "Probe an external API"),
+		adminRoute(http.MethodPost, "/users/bulk-status", app.AdminHandler.BulkUpdateUserStatus,
+			"bulkUpdateUserStatus", "Change the status of many users"),
+		adminRoute(http.MethodGet, "/users/:id", app.AdminHandler.GetUser,
+			"adminGetUser", "Get a user with legal hold and email deliverability"),
+		adminRoute(http.MethodGet, "/users/:id/duplicates", app.AdminHandler.GetUserDuplicates,
+			"adminGetUserDuplicates", "List users that may be the same person as a user"),
+		adminRoute(http.MethodPut, "/users/:id/legal-hold", app.AdminHandler.SetUserLegalHold,
+			"setUserLegalHold", "Place or release a legal hold"),
+		adminRoute(http.MethodPost, "/master-data/cache/invalidate", app.AdminHandler.InvalidateMasterDataCache,
+			"invalidateMasterDataCache", "Invalidate cached master data"),
+		adminRoute(http.MethodGet, "/options", app.AdminHandler.ListOptions,
+			"adminListOptions", "List options including inactive ones"),
+		adminRoute(http.MethodPost, "/options", app.AdminHandler.CreateOption,
+			"createOption", "Create an option"),
+		adminRoute(http.MethodPut, "/options/:type", app.AdminHandler.UpdateOption,
+			"updateOption", "Update an option"),
+		adminRoute(http.MethodDelete, "/options/:type", app.AdminHandler.DeleteOption,
+			"deleteOption", "Delete an option"),
+		adminRoute(http.MethodGet, "/plans", app.AdminHandler.ListPlans,
+			"adminListPlans", "List plans, including inactive ones"),
+		adminRoute(http.MethodPost, "/plans", app.AdminHandler.CreatePlan,
+			"createPlan", "Add a plan"),
+		adminRoute(http.MethodPut, "/plans/:type", app.AdminHandler.UpdatePlan,
+			"updatePlan", "Replace the settings of a plan"),
+		adminRoute(http.MethodPost, "/plans/:type/deactivate", app.AdminHandler.DeactivatePlan,
+			"deactivatePlan", "Stop offering a plan to new registrations"),
+		adminRoute(http.MethodGet, "/region-restrictions", app.AdminHandler.ListRegionRestrictions,
+			"listRegionRestrictions", "List region restriction master data"),
+		adminRoute(http.MethodPost, "/region-restrictions", app.AdminHandler.CreateRegionRestriction,
+			"createRegionRestriction", "Add a region restriction"),
+		adminRoute(http.MethodPut, "/region-restrictions/:id", app.AdminHandler.UpdateRegionRestriction,
+			"updateRegionRestriction", "Replace a region restriction"),
+		adminRoute(http.MethodDelete, "/region-restrictions/:id", app.AdminHandler.DeleteRegionRestriction,
+			"deleteRegionRestriction", "Delete a region restriction"),
+		adminRoute(http.MethodGet, "/memory-stores", app.AdminHandler.GetMemoryStores,
+			"getMemoryStores", "Report in-memory store usage"),
+		adminRoute(http.MethodGet, "/feature-flags", app.AdminHandler.GetFeatureFlags,
+			"listFeatureFlags", "List feature flags"),
+		adminRoute(http.MethodPut, "/feature-flags/:name", app.AdminHandler.SetFeatureFlag,
+			"setFeatureFlag", "Override a feature flag"),
+		adminRoute(http.MethodDelete, "/feature-flags/:name", app.AdminHandler.ClearFeatureFlag,
+			"clearFeatureFlag", "Clear a feature flag override"),
+		adminRoute(http.MethodGet, "/validation-rules", app.AdminHandler.GetValidationRules,
+			"listValidationRules", "List field validation rules"),
+		adminRoute(http.MethodPost, "/validation-rules/reload", app.AdminHandler.ReloadValidationRules,
+			"reloadValidationRules", "Reload field validation rules"),
+		adminRoute(http.MethodGet, "/email-templates", app.EmailTemplateHandler.ListTemplates,
+			"listEmailTemplates", "List email templates and their active versions"),
+		adminRoute(http.MethodGet, "/email-templates/:name", app.EmailTemplateHandler.GetTemplate,
+			"getEmailTemplate", "List the versions of an email template"),
+		adminRoute(http.MethodPost, "/email-templates/:name/versions", app.EmailTemplateHandler.CreateVersion,
+			"createEmailTemplateVersion", "Add a version of an email template"),
+		adminRoute(http.MethodPost, "/email-templates/:name/versions/:version/activate", app.EmailTemplateHandler.ActivateVersion,
+			"activateEmailTemplateVersion", "Send a version of an email template"),
+		adminRoute(http.MethodPost, "/email-templates/:name/preview", app.EmailTemplateHandler.Preview,
+			"previewEmailTemplate", "Render an email template"),
+		adminRoute(http.MethodPost, "/email-templates/:name/test-send", app.EmailTemplateHandler.TestSend,
+			"testSendEmailTemplate", "Send a test email of a template"),
+		adminRoute(http.MethodGet, "/events", app.AdminHandler.ListEvents,
+			"listUndeliveredEvents", "List events whose publishing or webhook delivery failed"),
+		adminRoute(http.MethodPost, "/events/:id/replay", app.AdminHandler.ReplayEvent,
+			"replayEvent", "Retry the failed deliveries of an event now"),
+		adminRoute(http.MethodGet, "/reservations", app.AdminHandler.ListReservations,
+			"listReservations", "List option stock holds by status, option, session and age"),
+		adminRoute(http.MethodPost, "/reservations/:id/release", app.AdminHandler.ReleaseReservation,
+			"releaseReservation", "Return the stock of a hold to inventory"),
+		adminRoute(http.MethodPost, "/reservations/:id/extend", app.AdminHandler.ExtendReservation,
+			"extendReservation", "Move the expiry of a hold within the lifetime of its session"),
+		adminRoute(http.MethodGet, "/webhook-deliveries", app.WebhookDeliveryHandler.ListDeliveries,
+			"listWebhookDeliveries", "List webhook deliveries by status, dead letters by default"),
+		adminRoute(http.MethodPost, "/webhook-deliveries/:id/replay", app.WebhookDeliveryHandler.ReplayDelivery,
+			"replayWebhookDelivery", "Send an undelivered webhook again with a fresh retry schedule"),
+		adminRoute(http.MethodGet, "/api-keys", app.APIKeyHandler.ListKeys,
+			"listAPIKeys", "List partner API keys, including revoked ones"),
+		adminRoute(http.MethodPost, "/api-keys", app.APIKeyHandler.CreateKey,
+			"createAPIKey", "Issue a partner API key"),
+		adminRoute(http.MethodPost, "/api-keys/:id/revoke", app.APIKeyHandler.RevokeKey,
+			"revokeAPIKey", "Revoke a partner API key"),
+		adminRoute(http.MethodGet, "/api-keys/:id/usage", app.APIKeyHandler.GetUsage,
+			"getAPIKeyUsage", "Report the monthly usage of a partner API key, as JSON or CSV"),
+		adminRoute(http.MethodGet, "/stats/funnel", app.StatsHandler.GetFunnel,
+			"getFunnelStats", "Report how far form sessions got and where they dropped off"),
+		adminRoute(http.MethodGet, "/stats/validation-failures", app.StatsHandler.GetValidationFailures,
+			"getValidationFailureStats", "Report which fields fail validation for which reasons at each form stage"),
+		adminRoute(http.MethodGet, "/attachments", app.AttachmentHandler.ListAttachments,
+			"adminListAttachments", "List attachments by scan status"),
+		adminRoute(http.MethodGet, "/security/events", app.SecurityHandler.ListEvents,
+			"listSecurityEvents", "List CSRF failures, rate limit hits and validation failures by client IP"),
+		adminRoute(http.MethodGet, "/security/blocks", app.SecurityHandler.ListBlocks,
+			"listIPBlocks", "List client IPs blocked after repeated security events"),
+		adminRoute(http.MethodDelete, "/security/blocks/:ip", app.SecurityHandler.Unblock,
+			"unblockIP", "Lift the block of a client IP"),
+		adminRoute(http.MethodGet, "/security/bot-detection", app.SecurityHandler.GetBotDetectionStats,
+			"getBotDetectionStats", "Count registrations rejected as automated by reason"),
+		adminRoute(http.MethodGet, "/metrics", middleware.MetricsEndpoint(),
+			"getMetrics", "Request metrics per route"),
+		// The status page is for browsers, so it is served outside the JSON API
+		{Method: http.MethodGet, Path: "/admin/status", Handler: app.StatusPageHandler.GetStatus,
+			Name: "adminStatusPage", Summary: "HTML summary of health, circuit breakers, jobs, caches and recent errors", Tag: "admin",
+			Group: groupAdmin, Auth: router.AuthAdmin, RateLimitClass: rateLimitAdmin, Cache: noStore},
+	}...)
+}
+
+// healthRoutes returns the health probe endpoints of the public listener
+func healthRoutes(app *Application) []router.Route {
+	return []router.Route{
+		{Method: http.MethodGet, Path: "/health", Handler: app.HealthHandler.Health,
+			Name: "health", Summary: "Service and dependency health", Tag: "health",
+			Group: groupSystem, Auth: router.AuthPublic},
+		{Method: http.MethodGet, Path: "/health/live", Handler: app.HealthHandler.LivenessProbe,
+			Name: "livenessProbe", Summary: "Liveness probe", Tag: "health",
+			Group: groupSystem, Auth: router.AuthPublic},
+		{Method: http.MethodGet, Path: "/health/ready", Handler: app.HealthHandler.ReadinessProbe,
+			Name: "readinessProbe", Summary: "Readiness probe", Tag: "health",
+			Group: groupSystem, Auth: router.AuthPublic},
+	}
+}
+
+// adminRoute declares an admin endpoint under /api/v1/admin
+func adminRoute(method, path string, handler gin.HandlerFunc, name, summary string) router.Route {
+	return router.Route{
+		Method:         method,
+		Path:           "/api/v1/admin" + path,
+		Handler:        handler,
+		Name:           name,
+		Summary:        summary,
+		Tag:            "admin",
+		Group:          groupAdmin,
+		Auth:           router.AuthAdmin,
+		RateLimitClass: rateLimitAdmin,
+		Cache:          noStore,
+	}
+}
+
+// ping responds to connectivity checks
+func ping(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"message": "pong",
+			"service": "normal-form-app",
+			"version": apiVersion,
+		},
+	})
+}
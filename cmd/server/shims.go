@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/router"
+)
+
+// addressFields are the user fields version 2 groups into an address object
+var addressFields = []string{
+	"postal_code1", "postal_code2", "prefecture", "city", "town", "chome", "banchi", "go", "building", "room",
+}
+
+// structuredAddress adapts version 2 user requests, which send the address as an object, to
+// the flat address fields of the version 1 DTOs:
+//
+//	{"address": {"prefecture": "東京都", "city": "千代田区", ...}}
+//
+// becomes {"prefecture": "東京都", "city": "千代田区", ...}. Flat address fields are rejected,
+// so that a client half migrated to version 2 fails loudly instead of losing fields.
+var structuredAddress = router.Shim{Request: flattenAddress}
+
+// flattenAddress moves the fields of the address object to the top level of a request
+func flattenAddress(document map[string]any) error {
+	for _, field := range addressFields {
+		if _, ok := document[field]; ok {
+			return fmt.Errorf("%s must be sent in the address object", field)
+		}
+	}
+
+	value, ok := document["address"]
+	if !ok {
+		return nil
+	}
+	address, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("address must be an object")
+	}
+	for field, value := range address {
+		if !slices.Contains(addressFields, field) {
+			return fmt.Errorf("unknown address field %q", field)
+		}
+		document[field] = value
+	}
+	delete(document, "address")
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestFlattenAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		document map[string]any
+		want     map[string]any
+		wantErr  bool
+	}{
+		{
+			name:     "address object",
+			document: map[string]any{"last_name": "山田", "address": map[string]any{"prefecture": "東京都", "city": "千代田区"}},
+			want:     map[string]any{"last_name": "山田", "prefecture": "東京都", "city": "千代田区"},
+		},
+		{
+			name:     "no address",
+			document: map[string]any{"last_name": "山田"},
+			want:     map[string]any{"last_name": "山田"},
+		},
+		{
+			name:     "flat address field",
+			document: map[string]any{"prefecture": "東京都"},
+			wantErr:  true,
+		},
+		{
+			name:     "address is not an object",
+			document: map[string]any{"address": "東京都千代田区"},
+			wantErr:  true,
+		},
+		{
+			name:     "unknown address field",
+			document: map[string]any{"address": map[string]any{"country": "JP"}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := flattenAddress(tt.document)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("flattenAddress(%v) succeeded, want an error", tt.document)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("flattenAddress: %v", err)
+			}
+			if !maps.Equal(tt.document, tt.want) {
+				t.Errorf("flattenAddress = %v, want %v", tt.document, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/config"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// redirectTimeout bounds requests to the redirect listener, which serves no application data
+const redirectTimeout = 5 * time.Second
+
+// modernCipherSuites are the TLS 1.2 suites offered: forward secret AEAD ciphers only.
+// TLS 1.3 suites are not configurable and are always secure.
+var modernCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// newTLSConfig builds the TLS settings of the public listener. With autocert domains the
+// certificates come from Let's Encrypt and the returned manager must also answer HTTP
+// challenges on the redirect listener; otherwise the certificate files are loaded by the
+// server and the manager is nil.
+func newTLSConfig(cfg *config.Config) (*tls.Config, *autocert.Manager, error) {
+	server := cfg.Server
+	autocertEnabled := len(server.TLSAutocertDomains) > 0
+
+	if autocertEnabled && server.TLSCertFile != "" {
+		return nil, nil, errors.New("TLS_CERT_FILE and TLS_AUTOCERT_DOMAINS are mutually exclusive")
+	}
+	if !autocertEnabled && (server.TLSCertFile == "" || server.TLSKeyFile == "") {
+		return nil, nil, errors.New("TLS_CERT_FILE and TLS_KEY_FILE must both be set")
+	}
+
+	minVersion, err := parseTLSVersion(server.TLSMinVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:       minVersion,
+		CipherSuites:     modernCipherSuites,
+		CurvePreferences: []tls.CurveID{tls.X25519, tls.CurveP256},
+	}
+	if !autocertEnabled {
+		return tlsConfig, nil, nil
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(server.TLSAutocertDomains...),
+		Cache:      autocert.DirCache(server.TLSAutocertCacheDir),
+		Email:      server.TLSAutocertEmail,
+	}
+	tlsConfig.GetCertificate = manager.GetCertificate
+	// TLS-ALPN challenges are answered on the HTTPS port itself
+	tlsConfig.NextProtos = []string{acme.ALPNProto}
+	return tlsConfig, manager, nil
+}
+
+// parseTLSVersion converts TLS_MIN_VERSION to a tls version constant
+func parseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS_MIN_VERSION %q: use 1.2 or 1.3", version)
+	}
+}
+
+// newRedirectServer creates the plain HTTP listener sending clients to the HTTPS port.
+// When certificates come from Let's Encrypt it answers HTTP challenges first.
+func newRedirectServer(cfg *config.Config, manager *autocert.Manager) *http.Server {
+	httpsPort := cfg.Server.Port
+
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		// 308 keeps the method and body of form submissions
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+	if manager != nil {
+		handler = manager.HTTPHandler(handler)
+	}
+
+	return &http.Server{
+		Addr:         cfg.GetRedirectAddress(),
+		Handler:      handler,
+		ReadTimeout:  redirectTimeout,
+		WriteTimeout: redirectTimeout,
+		IdleTimeout:  idleTimeoutSeconds * time.Second,
+	}
+}
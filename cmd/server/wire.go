@@ -5,17 +5,34 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 
 	"github.com/google/wire"
+	"github.com/octop162/normal-form-app-by-claude/internal/domain"
 	"github.com/octop162/normal-form-app-by-claude/internal/handler"
+	"github.com/octop162/normal-form-app-by-claude/internal/middleware"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
 	"github.com/octop162/normal-form-app-by-claude/internal/repository"
 	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/pkg/captcha"
 	"github.com/octop162/normal-form-app-by-claude/pkg/config"
 	"github.com/octop162/normal-form-app-by-claude/pkg/database"
+	"github.com/octop162/normal-form-app-by-claude/pkg/events"
 	"github.com/octop162/normal-form-app-by-claude/pkg/external"
+	"github.com/octop162/normal-form-app-by-claude/pkg/fieldcrypt"
 	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/lru"
+	"github.com/octop162/normal-form-app-by-claude/pkg/mail"
+	"github.com/octop162/normal-form-app-by-claude/pkg/masking"
+	"github.com/octop162/normal-form-app-by-claude/pkg/redis"
+	"github.com/octop162/normal-form-app-by-claude/pkg/scan"
+	"github.com/octop162/normal-form-app-by-claude/pkg/sms"
+	"github.com/octop162/normal-form-app-by-claude/pkg/upload"
 	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+	"github.com/octop162/normal-form-app-by-claude/pkg/webhook"
 )
 
 // Provider functions for dependency injection
@@ -31,6 +48,21 @@ func provideSQLDB(db *database.DB) *sql.DB {
 	return db.DB
 }
 
+// provideFieldCipher creates the cipher of the personal data columns from the configured keys
+func provideFieldCipher(cfg *config.Config, log *logger.Logger) (*fieldcrypt.Cipher, error) {
+	keys, err := fieldcrypt.ParseKeys(cfg.Privacy.EncryptionKeys, cfg.Privacy.EncryptionKeyID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid personal data encryption keys: %w", err)
+	}
+	if err := cfg.Privacy.ValidateEncryption(); err != nil {
+		return nil, err
+	}
+	if cfg.Privacy.EncryptionKeyID == "" {
+		log.Warn("Personal data encryption is disabled; phone, email and address are stored in plaintext")
+	}
+	return fieldcrypt.NewCipher(keys, cfg.Privacy.BlindIndexKey), nil
+}
+
 func provideCleanupFunc(db *database.DB) func() {
 	return func() {
 		if db != nil {
@@ -41,8 +73,11 @@ func provideCleanupFunc(db *database.DB) func() {
 	}
 }
 
-func provideExternalAPIManager(cfg *config.Config, log *logger.Logger) *external.Manager {
-	managerConfig := &external.ManagerConfig{}
+func provideExternalAPIManager(cfg *config.Config, log *logger.Logger) (*external.Manager, error) {
+	managerConfig := &external.ManagerConfig{
+		AvailabilityTimeout:     cfg.ExternalAPI.AvailabilityTimeout,
+		AvailabilityCallTimeout: cfg.ExternalAPI.AvailabilityCallTimeout,
+	}
 	
 	// Only create clients if base URLs are configured
 	if cfg.ExternalAPI.InventoryAPI.BaseURL != "" {
@@ -51,6 +86,17 @@ func provideExternalAPIManager(cfg *config.Config, log *logger.Logger) *external
 			Timeout:    cfg.ExternalAPI.InventoryAPI.Timeout,
 			MaxRetries: cfg.ExternalAPI.InventoryAPI.MaxRetries,
 			RetryDelay: cfg.ExternalAPI.InventoryAPI.RetryDelay,
+
+			MaxRetryDelay:      cfg.ExternalAPI.InventoryAPI.MaxRetryDelay,
+			EndpointMaxRetries: cfg.ExternalAPI.InventoryAPI.EndpointMaxRetries,
+
+			CircuitBreakerThreshold: cfg.ExternalAPI.InventoryAPI.CircuitBreakerThreshold,
+			CircuitBreakerTimeout:   cfg.ExternalAPI.InventoryAPI.CircuitBreakerTimeout,
+
+			SandboxBaseURL: cfg.ExternalAPI.InventoryAPI.SandboxBaseURL,
+
+			CacheTTL:        cfg.ExternalAPI.InventoryAPI.CacheTTL,
+			CacheMaxEntries: cfg.ExternalAPI.InventoryAPI.CacheMaxEntries,
 		}
 	}
 	
@@ -60,38 +106,724 @@ func provideExternalAPIManager(cfg *config.Config, log *logger.Logger) *external
 			Timeout:    cfg.ExternalAPI.RegionAPI.Timeout,
 			MaxRetries: cfg.ExternalAPI.RegionAPI.MaxRetries,
 			RetryDelay: cfg.ExternalAPI.RegionAPI.RetryDelay,
+
+			MaxRetryDelay:      cfg.ExternalAPI.RegionAPI.MaxRetryDelay,
+			EndpointMaxRetries: cfg.ExternalAPI.RegionAPI.EndpointMaxRetries,
+
+			CircuitBreakerThreshold: cfg.ExternalAPI.RegionAPI.CircuitBreakerThreshold,
+			CircuitBreakerTimeout:   cfg.ExternalAPI.RegionAPI.CircuitBreakerTimeout,
+
+			SandboxBaseURL: cfg.ExternalAPI.RegionAPI.SandboxBaseURL,
+
+			CacheTTL:        cfg.ExternalAPI.RegionAPI.CacheTTL,
+			CacheMaxEntries: cfg.ExternalAPI.RegionAPI.CacheMaxEntries,
 		}
 	}
 	
-	if cfg.ExternalAPI.AddressAPI.BaseURL != "" {
+	addressProvider, err := external.NewAddressProvider(cfg.ExternalAPI.AddressProvider, cfg.ExternalAPI.AddressAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	managerConfig.AddressProvider = addressProvider
+
+	// Public providers work without configuring a base URL
+	addressBaseURL := cfg.ExternalAPI.AddressAPI.BaseURL
+	if addressBaseURL == "" {
+		addressBaseURL = addressProvider.DefaultBaseURL()
+	}
+	if addressBaseURL != "" {
 		managerConfig.AddressAPI = &external.Config{
-			BaseURL:    cfg.ExternalAPI.AddressAPI.BaseURL,
+			BaseURL:    addressBaseURL,
 			Timeout:    cfg.ExternalAPI.AddressAPI.Timeout,
 			MaxRetries: cfg.ExternalAPI.AddressAPI.MaxRetries,
 			RetryDelay: cfg.ExternalAPI.AddressAPI.RetryDelay,
+
+			MaxRetryDelay:      cfg.ExternalAPI.AddressAPI.MaxRetryDelay,
+			EndpointMaxRetries: cfg.ExternalAPI.AddressAPI.EndpointMaxRetries,
+
+			CircuitBreakerThreshold: cfg.ExternalAPI.AddressAPI.CircuitBreakerThreshold,
+			CircuitBreakerTimeout:   cfg.ExternalAPI.AddressAPI.CircuitBreakerTimeout,
+
+			SandboxBaseURL: cfg.ExternalAPI.AddressAPI.SandboxBaseURL,
+
+			CacheTTL:        cfg.ExternalAPI.AddressAPI.CacheTTL,
+			CacheMaxEntries: cfg.ExternalAPI.AddressAPI.CacheMaxEntries,
 		}
 	}
 	
-	return external.NewManager(managerConfig, log)
+	// Every client connects under the same egress policy; base URLs outside its allowlist
+	// fail at startup instead of on every call
+	egress := &external.EgressPolicy{
+		AllowedHosts:    cfg.ExternalAPI.EgressAllowedHosts,
+		BlockPrivateIPs: cfg.ExternalAPI.EgressBlockPrivateIPs,
+		PinDNS:          cfg.ExternalAPI.EgressPinDNS,
+		PinTTL:          cfg.ExternalAPI.EgressPinTTL,
+	}
+	for _, apiConfig := range []*external.Config{
+		managerConfig.InventoryAPI, managerConfig.RegionAPI, managerConfig.AddressAPI,
+	} {
+		if apiConfig == nil {
+			continue
+		}
+		apiConfig.Egress = egress
+		for _, baseURL := range []string{apiConfig.BaseURL, apiConfig.SandboxBaseURL} {
+			if baseURL == "" {
+				continue
+			}
+			if err := egress.CheckURL(baseURL); err != nil {
+				return nil, fmt.Errorf("invalid external API configuration: %w", err)
+			}
+		}
+	}
+
+	return external.NewManager(managerConfig, log), nil
+}
+
+func provideEventPublisher(cfg *config.Config, log *logger.Logger) (events.Publisher, func(), error) {
+	publisher, err := events.NewPublisher(&events.Config{
+		Driver:  cfg.Events.Driver,
+		Source:  cfg.Events.Source,
+		Timeout: cfg.Events.Timeout,
+		Kafka: events.KafkaConfig{
+			RESTProxyURL: cfg.Events.KafkaRESTProxyURL,
+			Topic:        cfg.Events.KafkaTopic,
+		},
+		NATS: events.NATSConfig{
+			URL:           cfg.Events.NATSURL,
+			SubjectPrefix: cfg.Events.NATSSubjectPrefix,
+		},
+	}, log)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cleanup := func() {
+		if err := publisher.Close(); err != nil {
+			log.WithError(err).Warn("Failed to close event publisher")
+		}
+	}
+	return publisher, cleanup, nil
+}
+
+func provideRedisClient(cfg *config.Config, log *logger.Logger) (*redis.Client, func()) {
+	// Redis is optional; nil means in-memory backends are used
+	if cfg.Redis.Addr == "" {
+		return nil, func() {}
+	}
+
+	client := redis.NewClient(&redis.Config{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+		PoolSize: cfg.Redis.PoolSize,
+	}, log)
+
+	cleanup := func() {
+		if err := client.Close(); err != nil {
+			log.WithError(err).Warn("Failed to close Redis client")
+		}
+	}
+	return client, cleanup
+}
+
+func provideRateLimitStore(cfg *config.Config, redisClient *redis.Client) (middleware.RateLimitStore, error) {
+	switch cfg.RateLimit.Backend {
+	case "", "memory":
+		return middleware.NewMemoryRateLimitStore(cfg.RateLimit.MemoryMaxEntries), nil
+	case "redis":
+		if redisClient == nil {
+			return nil, errors.New("rate limit backend redis requires REDIS_ADDR")
+		}
+		return middleware.NewRedisRateLimitStore(redisClient, cfg.Redis.KeyPrefix), nil
+	default:
+		return nil, fmt.Errorf("unsupported rate limit backend: %s", cfg.RateLimit.Backend)
+	}
+}
+
+func provideCSRFTokenStore(cfg *config.Config, redisClient *redis.Client) (middleware.CSRFTokenStore, error) {
+	switch cfg.CSRF.Backend {
+	case "", "memory":
+		return middleware.NewMemoryCSRFTokenStore(cfg.CSRF.MemoryMaxTokens), nil
+	case "redis":
+		if redisClient == nil {
+			return nil, errors.New("CSRF store backend redis requires REDIS_ADDR")
+		}
+		return middleware.NewRedisCSRFTokenStore(redisClient, cfg.Redis.KeyPrefix), nil
+	default:
+		return nil, fmt.Errorf("unsupported CSRF store backend: %s", cfg.CSRF.Backend)
+	}
+}
+
+// provideCSRFTokenIssuer issues CSRF tokens for the form bootstrap response from the store
+// the CSRF middleware validates against
+func provideCSRFTokenIssuer(store middleware.CSRFTokenStore) handler.CSRFTokenIssuer {
+	return func(ctx context.Context) (string, error) {
+		return middleware.IssueCSRFToken(ctx, store)
+	}
+}
+
+func provideOutboxPublisher(
+	cfg *config.Config,
+	eventBus events.Publisher,
+	deliveryRepo repository.WebhookDeliveryRepository,
+	log *logger.Logger,
+) service.OutboxPublisher {
+	var publishers []service.OutboxPublisher
+
+	if cfg.Webhook.URL != "" {
+		publishers = append(publishers, service.NewWebhookQueuePublisher(deliveryRepo))
+	}
+	if cfg.Events.Driver != "" && cfg.Events.Driver != events.DriverNoop {
+		publishers = append(publishers, service.NewEventBusOutboxPublisher(eventBus))
+	}
+
+	switch len(publishers) {
+	case 0:
+		// No consumer configured: events are only logged (development default)
+		return service.NewLogOutboxPublisher(log)
+	case 1:
+		return publishers[0]
+	default:
+		return service.NewFanoutOutboxPublisher(publishers...)
+	}
+}
+
+func provideDomainEventBus(outbox *service.UserEventOutbox, notifications *service.NotificationService) domain.Bus {
+	bus := domain.NewBus()
+	outbox.Subscribe(bus)
+	notifications.Subscribe(bus)
+	return bus
+}
+
+func provideNotificationService(
+	cfg *config.Config,
+	txManager repository.TxManager,
+	notificationRepo repository.EmailNotificationRepository,
+	userRepo repository.UserRepository,
+	userOptionRepo repository.UserOptionRepository,
+	planRepo repository.PlanRepository,
+	optionRepo repository.OptionRepository,
+	templates service.EmailTemplateService,
+	mailer mail.Sender,
+	log *logger.Logger,
+) *service.NotificationService {
+	return service.NewNotificationService(
+		txManager, notificationRepo, userRepo, userOptionRepo, planRepo, optionRepo, templates, mailer,
+		service.NotificationServiceConfig{
+			ConfirmationEnabled: cfg.Notification.ConfirmationEnabled,
+			PollInterval:        cfg.Notification.PollInterval,
+			BatchSize:           cfg.Notification.BatchSize,
+			MaxAttempts:         cfg.Notification.MaxAttempts,
+			RetryBaseDelay:      cfg.Notification.RetryBaseDelay,
+		}, log)
+}
+
+func provideAPIKeyUsageMeter(
+	cfg *config.Config, usageRepo repository.APIKeyUsageRepository, log *logger.Logger,
+) *service.APIKeyUsageMeter {
+	return service.NewAPIKeyUsageMeter(usageRepo, cfg.Partner.UsageFlushInterval, log)
+}
+
+func provideOutboxRelay(
+	cfg *config.Config,
+	txManager repository.TxManager,
+	outboxRepo repository.OutboxRepository,
+	publisher service.OutboxPublisher,
+	log *logger.Logger,
+) *service.OutboxRelay {
+	return service.NewOutboxRelay(txManager, outboxRepo, publisher, service.OutboxRelayConfig{
+		PollInterval:   cfg.Outbox.PollInterval,
+		BatchSize:      cfg.Outbox.BatchSize,
+		MaxAttempts:    cfg.Outbox.MaxAttempts,
+		RetryBaseDelay: cfg.Outbox.RetryBaseDelay,
+		Lease:          cfg.Outbox.Lease,
+	}, log)
+}
+
+func provideWebhookDispatcher(
+	cfg *config.Config,
+	txManager repository.TxManager,
+	deliveryRepo repository.WebhookDeliveryRepository,
+	log *logger.Logger,
+) *service.WebhookDispatcher {
+	sender := webhook.NewSender(&webhook.Config{
+		URL:     cfg.Webhook.URL,
+		Secret:  cfg.Webhook.Secret,
+		Timeout: cfg.Webhook.Timeout,
+	}, log)
+	return service.NewWebhookDispatcher(txManager, deliveryRepo, sender, service.WebhookDispatcherConfig{
+		PollInterval:  cfg.Webhook.PollInterval,
+		BatchSize:     cfg.Webhook.BatchSize,
+		RetrySchedule: cfg.Webhook.RetrySchedule,
+	}, log)
+}
+
+func provideDeletionPolicy(cfg *config.Config) service.DeletionPolicy {
+	return service.DeletionPolicy{
+		Retention:    cfg.Privacy.DeletionRecordRetention,
+		EmailHashKey: cfg.Privacy.EmailHashKey,
+	}
+}
+
+func provideDeletionRecordPurger(
+	cfg *config.Config, repo repository.DeletionRecordRepository, log *logger.Logger,
+) *service.DeletionRecordPurger {
+	return service.NewDeletionRecordPurger(repo, cfg.Privacy.DeletionRecordPurgeInterval, log)
+}
+
+// provideMailSender creates the configured sender, which sends nothing to addresses whose
+// users bounced or complained
+func provideMailSender(
+	cfg *config.Config, userRepo repository.UserRepository, log *logger.Logger,
+) (mail.Sender, error) {
+	sender, err := mail.NewSender(mailConfig(cfg), log)
+	if err != nil {
+		return nil, err
+	}
+	return mail.NewSuppressingSender(sender, userRepo, log), nil
+}
+
+func provideEmailEventSources(cfg *config.Config, log *logger.Logger) (service.EmailEventSources, error) {
+	return mail.NewEventSources(mailConfig(cfg), log)
+}
+
+// mailConfig converts the mail settings to the mail package configuration
+func mailConfig(cfg *config.Config) *mail.Config {
+	return &mail.Config{
+		Driver:    cfg.Mail.Driver,
+		From:      cfg.Mail.From,
+		Timeout:   cfg.Mail.Timeout,
+		RateLimit: cfg.Mail.RateLimit,
+		SMTP: mail.SMTPConfig{
+			Host:     cfg.Mail.SMTPHost,
+			Port:     cfg.Mail.SMTPPort,
+			Username: cfg.Mail.SMTPUsername,
+			Password: cfg.Mail.SMTPPassword,
+		},
+		SES: mail.SESConfig{
+			Region:           cfg.Mail.SESRegion,
+			AccessKeyID:      cfg.Mail.SESAccessKeyID,
+			SecretAccessKey:  cfg.Mail.SESSecretAccessKey,
+			SessionToken:     cfg.Mail.SESSessionToken,
+			ConfigurationSet: cfg.Mail.SESConfigurationSet,
+			WebhookTopicARNs: cfg.Mail.SESWebhookTopicARNs,
+		},
+		SendGrid: mail.SendGridConfig{
+			APIKey:           cfg.Mail.SendGridAPIKey,
+			WebhookPublicKey: cfg.Mail.SendGridWebhookPublicKey,
+		},
+	}
+}
+
+func providePricingConfig(cfg *config.Config) service.PricingConfig {
+	return service.PricingConfig{
+		TaxRates:    cfg.Pricing.TaxRates,
+		TaxRounding: cfg.Pricing.TaxRounding,
+	}
+}
+
+func provideUserLookupConfig(cfg *config.Config) service.UserLookupConfig {
+	return service.UserLookupConfig{
+		CodeTTL:     cfg.Lookup.CodeTTL,
+		MaxAttempts: cfg.Lookup.MaxAttempts,
+	}
 }
 
+func provideSMSSender(cfg *config.Config, log *logger.Logger) (sms.Sender, error) {
+	return sms.NewSender(&sms.Config{
+		Driver:  cfg.SMS.Driver,
+		From:    cfg.SMS.From,
+		Timeout: cfg.SMS.Timeout,
+		HTTP: sms.HTTPConfig{
+			URL:    cfg.SMS.GatewayURL,
+			APIKey: cfg.SMS.APIKey,
+		},
+	}, log)
+}
+
+func providePhoneVerificationConfig(cfg *config.Config) service.PhoneVerificationConfig {
+	return service.PhoneVerificationConfig{
+		CodeTTL:        cfg.Phone.CodeTTL,
+		MaxAttempts:    cfg.Phone.MaxAttempts,
+		ResendInterval: cfg.Phone.ResendInterval,
+		VerifiedTTL:    cfg.Phone.VerifiedTTL,
+	}
+}
+
+func provideCaptchaVerifier(cfg *config.Config, log *logger.Logger) (captcha.Verifier, error) {
+	driver := cfg.Captcha.Driver
+	if cfg.IsTest() {
+		// Automated tests cannot solve captchas
+		driver = captcha.DriverNone
+	}
+
+	return captcha.NewVerifier(&captcha.Config{
+		Driver:    driver,
+		SecretKey: cfg.Captcha.SecretKey,
+		SiteKey:   cfg.Captcha.SiteKey,
+		VerifyURL: cfg.Captcha.VerifyURL,
+		MinScore:  cfg.Captcha.MinScore,
+		Timeout:   cfg.Captcha.Timeout,
+	}, log)
+}
+
+func provideCaptchaConfig(cfg *config.Config) service.CaptchaConfig {
+	return service.CaptchaConfig{VerifiedTTL: cfg.Captcha.VerifiedTTL}
+}
+
+func provideBotDetectorConfig(cfg *config.Config) service.BotDetectorConfig {
+	return service.BotDetectorConfig{
+		// Automated tests submit the form faster than any person
+		Enabled:     cfg.BotDetection.Enabled && !cfg.IsTest(),
+		SigningKey:  cfg.BotDetection.SigningKey,
+		MinFillTime: cfg.BotDetection.MinFillTime,
+		MaxTokenAge: cfg.BotDetection.MaxTokenAge,
+	}
+}
+
+func provideValidationTelemetryConfig(cfg *config.Config) service.ValidationTelemetryConfig {
+	return service.ValidationTelemetryConfig{
+		Enabled:       cfg.ValidationTelemetry.Enabled,
+		FlushInterval: cfg.ValidationTelemetry.FlushInterval,
+	}
+}
+
+func provideScanner(cfg *config.Config, log *logger.Logger) (scan.Scanner, error) {
+	return scan.NewScanner(&scan.Config{
+		Driver:  cfg.Scan.Driver,
+		Timeout: cfg.Scan.Timeout,
+		ClamAV:  scan.ClamAVConfig{Addr: cfg.Scan.ClamAVAddr},
+		ICAP:    scan.ICAPConfig{URL: cfg.Scan.ICAPURL},
+	}, log)
+}
+
+func provideAttachmentStores(cfg *config.Config) service.AttachmentStores {
+	return service.AttachmentStores{
+		Uploads:    upload.NewFileStorage(cfg.Upload.Dir),
+		Quarantine: upload.NewFileStorage(cfg.Upload.QuarantineDir),
+	}
+}
+
+func provideUploadProcessor(cfg *config.Config, stores service.AttachmentStores) *upload.Processor {
+	return upload.NewProcessor(upload.Config{MaxBytes: cfg.Upload.MaxBytes}, stores.Uploads)
+}
+
+func provideAttachmentScanner(
+	cfg *config.Config,
+	txManager repository.TxManager,
+	attachmentRepo repository.AttachmentRepository,
+	outboxRepo repository.OutboxRepository,
+	scanner scan.Scanner,
+	stores service.AttachmentStores,
+	log *logger.Logger,
+) *service.AttachmentScanner {
+	return service.NewAttachmentScanner(txManager, attachmentRepo, outboxRepo, scanner, stores, service.AttachmentScannerConfig{
+		PollInterval:   cfg.Scan.PollInterval,
+		BatchSize:      cfg.Scan.BatchSize,
+		MaxAttempts:    cfg.Scan.MaxAttempts,
+		RetryBaseDelay: cfg.Scan.RetryBaseDelay,
+	}, log)
+}
+
+func provideReservationConfig(cfg *config.Config) service.ReservationConfig {
+	return service.ReservationConfig{TTL: cfg.Reservation.TTL}
+}
+
+func provideReservationReleaser(
+	cfg *config.Config, repo repository.ReservationRepository, log *logger.Logger,
+) *service.ReservationReleaser {
+	return service.NewReservationReleaser(repo, cfg.Reservation.ReleaseInterval, log)
+}
+
+func provideSessionConfig(cfg *config.Config) service.SessionConfig {
+	return service.SessionConfig{
+		IdleTimeout: cfg.Session.IdleTimeout,
+		MaxLifetime: cfg.Session.MaxLifetime,
+
+		MaxPerClientIP: cfg.Session.MaxPerClientIP,
+		MaxPerEmail:    cfg.Session.MaxPerEmail,
+		EvictOldest:    cfg.Session.EvictOldest,
+
+		WizardRequired: cfg.Session.WizardRequired,
+
+		MaxDataBytes: cfg.Session.MaxDataBytes,
+		AllowedKeys:  cfg.Session.AllowedKeys,
+	}
+}
+
+func provideDuplicateConfig(cfg *config.Config) service.DuplicateConfig {
+	return service.DuplicateConfig{
+		Strategies:    cfg.Duplicate.Strategies,
+		MinConfidence: cfg.Duplicate.MinConfidence,
+	}
+}
+
+func provideValidationRuleConfig(cfg *config.Config) service.ValidationRuleConfig {
+	return service.ValidationRuleConfig{
+		Source: cfg.Validation.RulesSource,
+		File:   cfg.Validation.RulesFile,
+	}
+}
+
+func provideMaskingPolicy(cfg *config.Config) (*masking.Policy, error) {
+	return masking.NewPolicy(cfg.Masking.Policy)
+}
+
+// provideMemoryStores collects the in-process stores that report their size; stores
+// backed by Redis are not listed
+func provideMemoryStores(
+	rateLimitStore middleware.RateLimitStore, csrfTokenStore middleware.CSRFTokenStore,
+) handler.MemoryStores {
+	stores := handler.MemoryStores{"response_cache": middleware.ResponseCache()}
+	if reporter, ok := rateLimitStore.(lru.StatsReporter); ok {
+		stores["rate_limit_buckets"] = reporter
+	}
+	if reporter, ok := csrfTokenStore.(lru.StatsReporter); ok {
+		stores["csrf_tokens"] = reporter
+	}
+	return stores
+}
+
+// provideRecentErrors keeps the errors logged from now on for the admin status page
+func provideRecentErrors(log *logger.Logger) *logger.RecentErrors {
+	recent := logger.NewRecentErrors(recentErrorsShown)
+	log.AddHook(recent)
+	return recent
+}
+
+// provideStatusJobs lists the background workers started by main for the admin status page
+func provideStatusJobs(cfg *config.Config) handler.StatusJobs {
+	return handler.StatusJobs{
+		{Name: "outbox_relay", Interval: cfg.Outbox.PollInterval, Enabled: cfg.Outbox.Enabled},
+		{Name: "deletion_record_purge", Interval: cfg.Privacy.DeletionRecordPurgeInterval, Enabled: true},
+		{Name: "attachment_scan", Interval: cfg.Scan.PollInterval, Enabled: true},
+		{Name: "reservation_release", Interval: cfg.Reservation.ReleaseInterval, Enabled: true},
+		{Name: "webhook_dispatch", Interval: cfg.Webhook.PollInterval, Enabled: cfg.Webhook.URL != ""},
+		{Name: "notification_send", Interval: cfg.Notification.PollInterval, Enabled: true},
+		{Name: "api_key_usage_flush", Interval: cfg.Partner.UsageFlushInterval, Enabled: true},
+		{Name: "feature_flag_refresh", Interval: cfg.Features.RefreshInterval, Enabled: true},
+		{Name: "security_block_refresh", Interval: cfg.Security.RefreshInterval, Enabled: cfg.Security.MonitorEnabled},
+		{Name: "user_create_queue", Interval: cfg.UserQueue.PollInterval, Enabled: true},
+		{Name: "validation_telemetry_flush", Interval: cfg.ValidationTelemetry.FlushInterval, Enabled: cfg.ValidationTelemetry.Enabled},
+	}
+}
+
+// provideHealthConfig selects the external API checks of the health endpoint
+func provideHealthConfig(cfg *config.Config) (handler.HealthConfig, error) {
+	for _, name := range cfg.Health.CriticalAPIs {
+		switch name {
+		case external.ClientNameInventory, external.ClientNameRegion, external.ClientNameAddress:
+		default:
+			return handler.HealthConfig{}, fmt.Errorf("unknown external API %q in HEALTH_CRITICAL_APIS", name)
+		}
+	}
+	return handler.HealthConfig{
+		ExternalChecks:   cfg.Health.ExternalChecks,
+		CriticalAPIs:     cfg.Health.CriticalAPIs,
+		ExternalCacheTTL: cfg.Health.ExternalCacheTTL,
+		ExternalTimeout:  cfg.Health.ExternalTimeout,
+	}, nil
+}
+
+// provideReadinessConfig selects the load criteria of the readiness probe
+func provideReadinessConfig(cfg *config.Config) (handler.ReadinessConfig, error) {
+	for _, name := range cfg.Readiness.CircuitBreakers {
+		switch name {
+		case external.ClientNameInventory, external.ClientNameRegion, external.ClientNameAddress:
+		default:
+			return handler.ReadinessConfig{}, fmt.Errorf("unknown external API %q in READINESS_CIRCUIT_BREAKERS", name)
+		}
+	}
+	return handler.ReadinessConfig{
+		DBPoolMaxUsage:  cfg.Readiness.DBPoolMaxUsage,
+		QueueMaxBacklog: cfg.Readiness.QueueMaxBacklog,
+		CircuitBreakers: cfg.Readiness.CircuitBreakers,
+	}, nil
+}
+
+func provideFeatureFlagConfig(cfg *config.Config) service.FeatureFlagConfig {
+	return service.FeatureFlagConfig{
+		Defaults: map[string]bool{
+			service.FlagSkipInventoryCheck:       cfg.Features.SkipInventoryCheck,
+			service.FlagDisableRegionRestriction: cfg.Features.DisableRegionRestriction,
+			service.FlagReadOnlyMode:             cfg.Features.ReadOnlyMode,
+			service.FlagQueueUserCreation:        cfg.Features.QueueUserCreation,
+		},
+		RefreshInterval: cfg.Features.RefreshInterval,
+	}
+}
+
+func provideSecurityMonitorConfig(cfg *config.Config) service.SecurityMonitorConfig {
+	return service.SecurityMonitorConfig{
+		Window: cfg.Security.Window,
+		Thresholds: map[string]int{
+			model.SecurityEventCSRFFailure:       cfg.Security.CSRFFailureThreshold,
+			model.SecurityEventRateLimited:       cfg.Security.RateLimitThreshold,
+			model.SecurityEventValidationFailure: cfg.Security.ValidationFailureThreshold,
+		},
+		BlockDuration:   cfg.Security.BlockDuration,
+		RefreshInterval: cfg.Security.RefreshInterval,
+		Retention:       cfg.Security.Retention,
+		QueueSize:       cfg.Security.QueueSize,
+	}
+}
+
+func provideUserCreateQueueConfig(cfg *config.Config) service.UserCreateQueueConfig {
+	return service.UserCreateQueueConfig{
+		Workers:        cfg.UserQueue.Workers,
+		PollInterval:   cfg.UserQueue.PollInterval,
+		Lease:          cfg.UserQueue.Lease,
+		MaxAttempts:    cfg.UserQueue.MaxAttempts,
+		RetryBaseDelay: cfg.UserQueue.RetryBaseDelay,
+		Retention:      cfg.UserQueue.Retention,
+	}
+}
+
+func provideInventoryCacheConfig(cfg *config.Config) service.InventoryCacheConfig {
+	return service.InventoryCacheConfig{
+		TTL:      cfg.Cache.InventoryTTL,
+		StaleTTL: cfg.Cache.InventoryStaleTTL,
+	}
+}
+
+func provideStockStateConfig(cfg *config.Config) service.StockStateConfig {
+	return service.StockStateConfig{
+		LowStockThreshold:  cfg.Inventory.LowStockThreshold,
+		LowStockThresholds: cfg.Inventory.LowStockThresholds,
+	}
+}
+
+func provideRecommendationConfig(cfg *config.Config) service.RecommendationConfig {
+	return service.RecommendationConfig{
+		PopularityTTL: cfg.Recommend.PopularityTTL,
+		MinSample:     cfg.Recommend.MinSample,
+	}
+}
+
+func provideOptionRepository(cfg *config.Config, db *sql.DB, log *logger.Logger) repository.OptionRepository {
+	repo := repository.NewOptionRepository(db, log)
+	if !cfg.Cache.MasterDataEnabled {
+		return repo
+	}
+	return repository.NewCachedOptionRepository(repo, cfg.Cache.MasterDataTTL, log)
+}
+
+func providePlanRepository(cfg *config.Config, db *sql.DB, log *logger.Logger) repository.PlanRepository {
+	repo := repository.NewPlanRepository(db, log)
+	if !cfg.Cache.MasterDataEnabled {
+		return repo
+	}
+	return repository.NewCachedPlanRepository(repo, cfg.Cache.MasterDataTTL, log)
+}
+
+func providePrefectureRepository(cfg *config.Config, db *sql.DB, log *logger.Logger) repository.PrefectureRepository {
+	repo := repository.NewPrefectureRepository(db, log)
+	if !cfg.Cache.MasterDataEnabled {
+		return repo
+	}
+	return repository.NewCachedPrefectureRepository(repo, cfg.Cache.MasterDataTTL, log)
+}
 
 // Repository provider set
 var repositorySet = wire.NewSet(
 	repository.NewUserRepository,
 	repository.NewSessionRepository,
 	repository.NewUserOptionRepository,
-	repository.NewOptionRepository,
-	repository.NewPrefectureRepository,
+	provideOptionRepository,
+	providePrefectureRepository,
+	providePlanRepository,
+	repository.NewAddressRepository,
+	repository.NewBuildingRepository,
+	repository.NewUserPricingSnapshotRepository,
+	repository.NewMasterDataCache,
+	repository.NewOutboxRepository,
+	repository.NewAuditLogRepository,
+	repository.NewDeletionRecordRepository,
+	repository.NewUserLookupRepository,
+	repository.NewPhoneVerificationRepository,
+	repository.NewAttachmentRepository,
+	repository.NewReservationRepository,
+	repository.NewFeatureFlagRepository,
+	repository.NewValidationRuleRepository,
+	repository.NewRegionRestrictionRepository,
+	repository.NewEmailTemplateRepository,
+	repository.NewWebhookDeliveryRepository,
+	repository.NewEmailNotificationRepository,
+	repository.NewAPIKeyRepository,
+	repository.NewAPIKeyUsageRepository,
+	repository.NewSessionEventRepository,
+	repository.NewSecurityEventRepository,
+	repository.NewUserCreateTicketRepository,
+	repository.NewValidationCountRepository,
+	repository.NewTxManager,
 )
 
 // Service provider set
 var serviceSet = wire.NewSet(
 	service.NewUserService,
+	service.NewUserEventOutbox,
+	provideNotificationService,
+	provideDomainEventBus,
 	service.NewSessionService,
 	service.NewOptionService,
+	provideInventoryCacheConfig,
+	provideStockStateConfig,
+	service.NewRecommendationService,
+	provideRecommendationConfig,
 	service.NewAddressService,
 	service.NewPlanService,
+	service.NewAdminUserService,
+	service.NewAdminOptionService,
+	service.NewAdminRegionService,
+	service.NewAdminPlanService,
+	service.NewAdminEventService,
+	service.NewAdminReservationService,
+	provideOutboxPublisher,
+	provideOutboxRelay,
+	provideWebhookDispatcher,
+	service.NewWebhookDeliveryService,
+	provideDeletionPolicy,
+	provideDeletionRecordPurger,
+	service.NewEmailTemplateService,
+	service.NewUserLookupService,
+	provideUserLookupConfig,
+	service.NewPhoneVerificationService,
+	providePhoneVerificationConfig,
+	service.NewAttachmentService,
+	provideAttachmentStores,
+	provideUploadProcessor,
+	provideAttachmentScanner,
+	service.NewReservationService,
+	provideReservationConfig,
+	provideReservationReleaser,
+	service.NewAvailabilityService,
+	service.NewNormalizationService,
+	provideSessionConfig,
+	service.NewFeatureFlags,
+	provideFeatureFlagConfig,
+	service.NewValidationRules,
+	provideValidationRuleConfig,
+	service.NewDuplicateService,
+	provideDuplicateConfig,
+	service.NewPricingService,
+	providePricingConfig,
+	service.NewQuoteService,
+	service.NewEmailEventService,
+	provideEmailEventSources,
+	service.NewAPIKeyService,
+	provideAPIKeyUsageMeter,
+	service.NewStatsService,
+	service.NewPersonalDataService,
+	service.NewSecurityMonitor,
+	provideSecurityMonitorConfig,
+	service.NewUserCreateQueue,
+	provideUserCreateQueueConfig,
+	service.NewCaptchaService,
+	provideCaptchaConfig,
+	service.NewBotDetector,
+	provideBotDetectorConfig,
+	service.NewValidationTelemetry,
+	provideValidationTelemetryConfig,
 )
 
 // Handler provider set
@@ -102,6 +834,26 @@ var handlerSet = wire.NewSet(
 	handler.NewAddressHandler,
 	handler.NewPlanHandler,
 	handler.NewHealthHandler,
+	handler.NewAdminHandler,
+	handler.NewUserLookupHandler,
+	handler.NewPhoneVerificationHandler,
+	handler.NewAttachmentHandler,
+	handler.NewNormalizationHandler,
+	handler.NewEmailTemplateHandler,
+	handler.NewQuoteHandler,
+	handler.NewEmailEventHandler,
+	handler.NewWebhookDeliveryHandler,
+	handler.NewAPIKeyHandler,
+	handler.NewPartnerHandler,
+	handler.NewStatsHandler,
+	handler.NewPersonalDataHandler,
+	handler.NewSecurityHandler,
+	handler.NewStatusPageHandler,
+	provideStatusJobs,
+	provideHealthConfig,
+	provideReadinessConfig,
+	handler.NewFormHandler,
+	provideCSRFTokenIssuer,
 )
 
 // Infrastructure provider set
@@ -110,8 +862,20 @@ var infrastructureSet = wire.NewSet(
 	provideLogger,
 	provideDB,
 	provideSQLDB,
+	provideFieldCipher,
 	provideCleanupFunc,
 	provideExternalAPIManager,
+	provideEventPublisher,
+	provideRedisClient,
+	provideRateLimitStore,
+	provideCSRFTokenStore,
+	provideMailSender,
+	provideSMSSender,
+	provideScanner,
+	provideCaptchaVerifier,
+	provideMaskingPolicy,
+	provideMemoryStores,
+	provideRecentErrors,
 	validator.NewValidator,
 )
 
@@ -2,15 +2,20 @@
 import React, { useCallback, useEffect, useState } from 'react';
 import { useFormContext } from '../../contexts/FormContext';
 import { useRealtimeValidation } from '../../hooks/useRealtimeValidation';
-import { useGetPlans, useGetOptions, useOptionAvailability } from '../../hooks/useApi';
+import { useGetPlans, useGetOptions, useOptionAvailability, useRecommendedOptions } from '../../hooks/useApi';
+import FormField from '../common/FormField';
 import SelectField from '../common/SelectField';
+import Button from '../common/Button';
 import CheckboxGroup from '../common/CheckboxGroup';
 import ErrorMessage from '../common/ErrorMessage';
 import LoadingSpinner from '../common/LoadingSpinner';
 import { PLAN_AVAILABLE_OPTIONS, LOADING_MESSAGES } from '../../utils/constants';
-import type { UserFormData } from '../../types/form';
+import type { UserFormData, OptionDetail } from '../../types/form';
 import type { CheckboxOption } from '../common/CheckboxGroup';
 
+// Number of recommended options shown above the option list
+const MAX_RECOMMENDATIONS = 3;
+
 const PlanOptionForm: React.FC = () => {
   const { formData, updateFormData, errors } = useFormContext();
   const { createCheckboxChangeHandler } = useRealtimeValidation();
@@ -20,6 +25,7 @@ const PlanOptionForm: React.FC = () => {
   const plansApi = useGetPlans();
   const optionsApi = useGetOptions();
   const availabilityApi = useOptionAvailability();
+  const recommendationsApi = useRecommendedOptions();
 
   // Load plans and options on mount
   useEffect(() => {
@@ -47,17 +53,49 @@ const PlanOptionForm: React.FC = () => {
     }
   }, [formData.planType, formData.optionTypes, formData.prefecture, formData.city]);
 
+  // Recommend options once the plan and prefecture are known; the city adds region restrictions
+  useEffect(() => {
+    if (formData.planType && formData.prefecture) {
+      recommendationsApi.execute(formData.planType, formData.prefecture, formData.city).catch(() => {
+        // Recommendations are optional; the section is hidden when they fail
+      });
+    } else {
+      recommendationsApi.reset();
+    }
+  }, [formData.planType, formData.prefecture, formData.city]);
+
   const handlePlanChange = useCallback((e: React.ChangeEvent<HTMLSelectElement>) => {
     const planType = e.target.value;
     updateFormData({ 
       planType,
       // Clear options when plan changes
-      optionTypes: []
+      optionTypes: [],
+      optionDetails: {}
     });
   }, [updateFormData]);
 
   const handleOptionsChange = createCheckboxChangeHandler('optionTypes');
 
+  const handleRecommendationSelect = useCallback((optionType: string) => {
+    if (!formData.optionTypes.includes(optionType)) {
+      updateFormData({ optionTypes: [...formData.optionTypes, optionType] });
+    }
+  }, [formData.optionTypes, updateFormData]);
+
+  // Quantity is kept between 1 and the option's maximum
+  const handleOptionDetailChange = useCallback((optionType: string, maxQuantity: number, detail: Partial<OptionDetail>) => {
+    const current = formData.optionDetails[optionType] || { quantity: 1, startDate: '' };
+    const next = { ...current, ...detail };
+    next.quantity = Math.min(Math.max(Math.trunc(next.quantity) || 1, 1), maxQuantity);
+
+    updateFormData({
+      optionDetails: {
+        ...formData.optionDetails,
+        [optionType]: next
+      }
+    });
+  }, [formData.optionDetails, updateFormData]);
+
   // Plan options
   const planOptions = plansApi.data?.plans
     ?.filter(plan => plan.is_active)
@@ -79,8 +117,9 @@ const PlanOptionForm: React.FC = () => {
     )
     ?.map(option => {
       const inventory = availabilityApi.data.inventory[option.option_type];
+      const stockState = availabilityApi.data.states[option.option_type];
       const isRestricted = availabilityApi.data.restrictions[option.option_type] === false;
-      const isOutOfStock = inventory !== undefined && inventory === 0;
+      const isOutOfStock = stockState === 'out_of_stock';
       const isDisabled = isRestricted || isOutOfStock;
       
       let description = option.description;
@@ -92,6 +131,8 @@ const PlanOptionForm: React.FC = () => {
         description += ' [在庫切れ]';
       } else if (isRestricted) {
         description += ' [地域制限]';
+      } else if (stockState === 'low_stock') {
+        description += ` [残りわずか: ${inventory}]`;
       } else if (inventory !== undefined) {
         description += ` [在庫: ${inventory}]`;
       }
@@ -143,6 +184,41 @@ const PlanOptionForm: React.FC = () => {
             <ErrorMessage error={optionsApi.error} />
           )}
           
+          {recommendationsApi.data && recommendationsApi.data.recommendations.length > 0 && (
+            <div className="option-recommendations">
+              <h4>おすすめ</h4>
+              <p className="option-recommendations__note">
+                {recommendationsApi.data.popularity_scope === 'prefecture'
+                  ? `${recommendationsApi.data.prefecture}で同じプランを選んだ方によく選ばれています`
+                  : '同じプランを選んだ方によく選ばれています'}
+              </p>
+              {recommendationsApi.data.recommendations.slice(0, MAX_RECOMMENDATIONS).map(recommendation => {
+                const isSelected = formData.optionTypes.includes(recommendation.option_type);
+
+                return (
+                  <div key={recommendation.option_type} className="option-recommendation">
+                    <span className="option-recommendation__name">{recommendation.option_name}</span>
+                    {recommendation.plan_compatibility === formData.planType && (
+                      <span className="option-recommendation__badge">プラン専用</span>
+                    )}
+                    {recommendation.stock_state === 'low_stock' && (
+                      <span className="availability-status availability-status--low-stock">残りわずか</span>
+                    )}
+                    <Button
+                      type="button"
+                      variant="outline"
+                      size="small"
+                      onClick={() => handleRecommendationSelect(recommendation.option_type)}
+                      disabled={isSelected}
+                    >
+                      {isSelected ? '選択済み' : '追加する'}
+                    </Button>
+                  </div>
+                );
+              })}
+            </div>
+          )}
+
           <div className="form-row">
             <CheckboxGroup
               name="optionTypes"
@@ -161,6 +237,36 @@ const PlanOptionForm: React.FC = () => {
             />
           </div>
           
+          {formData.optionTypes.map(optionType => {
+            const option = optionsApi.data?.options?.find(opt => opt.option_type === optionType);
+            const optionName = option ? option.option_name : optionType;
+            const maxQuantity = option?.max_quantity || 1;
+            const detail = formData.optionDetails[optionType] || { quantity: 1, startDate: '' };
+
+            return (
+              <div key={optionType} className="form-row option-detail">
+                <FormField
+                  name={`optionDetails.${optionType}.quantity`}
+                  label={`${optionName} 数量`}
+                  type="number"
+                  value={String(detail.quantity)}
+                  onChange={e => handleOptionDetailChange(optionType, maxQuantity, { quantity: Number(e.target.value) })}
+                  helpText={`最大${maxQuantity}個まで`}
+                  error={errors[`optionDetails.${optionType}.quantity`]}
+                />
+                <FormField
+                  name={`optionDetails.${optionType}.startDate`}
+                  label={`${optionName} 利用開始希望日`}
+                  type="date"
+                  value={detail.startDate}
+                  onChange={e => handleOptionDetailChange(optionType, maxQuantity, { startDate: e.target.value })}
+                  helpText="未入力の場合は登録後すぐに開始します"
+                  error={errors[`optionDetails.${optionType}.startDate`]}
+                />
+              </div>
+            );
+          })}
+
           {availabilityApi.isLoading && isAvailabilityCheckReady && (
             <LoadingSpinner message={LOADING_MESSAGES.CHECKING_INVENTORY} />
           )}
@@ -175,6 +281,7 @@ const PlanOptionForm: React.FC = () => {
               {Object.entries(availabilityApi.data.inventory).map(([optionType, stock]) => {
                 const option = optionsApi.data?.options?.find(opt => opt.option_type === optionType);
                 const isRestricted = availabilityApi.data.restrictions[optionType] === false;
+                const stockState = availabilityApi.data.states[optionType];
                 
                 if (!option) return null;
                 
@@ -185,10 +292,14 @@ const PlanOptionForm: React.FC = () => {
                       <span className="availability-status availability-status--restricted">
                         地域制限により選択できません
                       </span>
-                    ) : stock === 0 ? (
+                    ) : stockState === 'out_of_stock' ? (
                       <span className="availability-status availability-status--out-of-stock">
                         在庫切れ
                       </span>
+                    ) : stockState === 'low_stock' ? (
+                      <span className="availability-status availability-status--low-stock">
+                        残りわずか ({stock}個)
+                      </span>
                     ) : (
                       <span className="availability-status availability-status--available">
                         在庫あり ({stock}個)
@@ -30,7 +30,8 @@ const initialFormData: UserFormData = {
   email: '',
   emailConfirm: '',
   planType: '',
-  optionTypes: []
+  optionTypes: [],
+  optionDetails: {}
 };
 
 // Form state interface
@@ -106,7 +107,8 @@ const formReducer = (state: FormState, action: FormAction): FormState => {
     case 'LOAD_FROM_SESSION':
       return {
         ...state,
-        formData: action.payload.formData,
+        // Fill fields added since the data was saved
+        formData: { ...initialFormData, ...action.payload.formData },
         sessionId: action.payload.sessionId
       };
     
@@ -228,6 +230,9 @@ export const FormProvider: React.FC<FormProviderProps> = ({ children }) => {
       if (Array.isArray(value)) {
         return value.length > 0;
       }
+      if (value && typeof value === 'object') {
+        return Object.keys(value).length > 0;
+      }
       return value !== '' && value !== undefined && value !== null;
     });
   }, [state.formData]);
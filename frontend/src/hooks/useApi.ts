@@ -115,6 +115,10 @@ export const useGetPlans = () => {
   return useApi(ApiService.getPlans);
 };
 
+export const useRecommendedOptions = () => {
+  return useApi(ApiService.getRecommendedOptions);
+};
+
 export const useCheckInventory = () => {
   return useApi(ApiService.checkInventory);
 };
@@ -156,6 +160,7 @@ export const useOptionAvailability = () => {
 
       return {
         inventory: inventoryResult.inventory,
+        states: inventoryResult.states,
         restrictions: regionResult.restrictions
       };
     } catch (error) {
@@ -166,6 +171,7 @@ export const useOptionAvailability = () => {
   return {
     data: {
       inventory: inventoryHook.data?.inventory || {},
+      states: inventoryHook.data?.states || {},
       restrictions: regionHook.data?.restrictions || {}
     },
     isLoading: inventoryHook.isLoading || regionHook.isLoading,
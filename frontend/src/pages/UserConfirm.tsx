@@ -64,7 +64,7 @@ const UserConfirm: React.FC = () => {
       address: formatFullAddress(formData),
       email: formData.email,
       plan: formatPlanName(formData.planType, plans),
-      options: formatOptionNames(formData.optionTypes, options)
+      options: formatOptionNames(formData.optionTypes, options, formData.optionDetails)
     };
   }, [formData, plansApi.data, optionsApi.data]);
 
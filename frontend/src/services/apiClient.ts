@@ -11,6 +11,10 @@ import type {
   SessionCreateRequest,
   SessionCreateResponse,
   SessionGetResponse,
+  SessionFieldsUpdateRequest,
+  SessionUpdateResponse,
+  WizardStepName,
+  WizardProgressResponse,
   AddressSearchRequest,  
   AddressSearchResponse,
   PrefecturesGetResponse,
@@ -20,6 +24,7 @@ import type {
   InventoryCheckResponse,
   RegionCheckRequest,
   RegionCheckResponse,
+  OptionRecommendationResponse,
   HealthCheckResponse
 } from '../types/api';
 
@@ -155,8 +160,13 @@ export class ApiService {
   }
 
   // User endpoints
-  static async createUser(userData: UserCreateRequest): Promise<UserCreateResponse> {
-    const response = await apiClient.post<ApiResponse<UserCreateResponse>>('/api/v1/users', userData);
+  // Pass the session ID to submit data validated by the session's form wizard
+  static async createUser(userData: UserCreateRequest, sessionId?: string): Promise<UserCreateResponse> {
+    const response = await apiClient.post<ApiResponse<UserCreateResponse>>(
+      '/api/v1/users',
+      userData,
+      sessionId ? { headers: { 'X-Session-ID': sessionId } } : undefined
+    );
     if (!response.data.success || !response.data.data) {
       throw response.data.error || new Error('User creation failed');
     }
@@ -195,6 +205,39 @@ export class ApiService {
     }
   }
 
+  // Merge changed fields into the session; rejected with SESSION_VERSION_CONFLICT if it was saved elsewhere
+  static async updateSessionFields(
+    sessionId: string,
+    request: SessionFieldsUpdateRequest
+  ): Promise<SessionUpdateResponse> {
+    const response = await apiClient.patch<ApiResponse<SessionUpdateResponse>>(
+      `/api/v1/sessions/${sessionId}/fields`,
+      request
+    );
+    if (!response.data.success || !response.data.data) {
+      throw response.data.error || new Error('Session update failed');
+    }
+    return response.data.data;
+  }
+
+  static async completeSessionStep(sessionId: string, step: WizardStepName): Promise<WizardProgressResponse> {
+    const response = await apiClient.post<ApiResponse<WizardProgressResponse>>(
+      `/api/v1/sessions/${sessionId}/steps/${step}/complete`
+    );
+    if (!response.data.success || !response.data.data) {
+      throw response.data.error || new Error('Wizard step validation failed');
+    }
+    return response.data.data;
+  }
+
+  static async getSessionProgress(sessionId: string): Promise<WizardProgressResponse> {
+    const response = await apiClient.get<ApiResponse<WizardProgressResponse>>(`/api/v1/sessions/${sessionId}/progress`);
+    if (!response.data.success || !response.data.data) {
+      throw response.data.error || new Error('Wizard progress retrieval failed');
+    }
+    return response.data.data;
+  }
+
   static async deleteSession(sessionId: string): Promise<void> {
     const response = await apiClient.delete<ApiResponse<void>>(`/api/v1/sessions/${sessionId}`);
     if (!response.data.success) {
@@ -238,6 +281,21 @@ export class ApiService {
     return response.data.data;
   }
 
+  // City is optional; without it region restrictions are not applied
+  static async getRecommendedOptions(
+    planType: string,
+    prefecture: string,
+    city?: string
+  ): Promise<OptionRecommendationResponse> {
+    const response = await apiClient.get<ApiResponse<OptionRecommendationResponse>>('/api/v1/options/recommended', {
+      params: { plan_type: planType, prefecture, city: city || undefined }
+    });
+    if (!response.data.success || !response.data.data) {
+      throw response.data.error || new Error('Option recommendation failed');
+    }
+    return response.data.data;
+  }
+
   // Inventory and region check endpoints
   static async checkInventory(optionTypes: string[]): Promise<InventoryCheckResponse> {
     const response = await apiClient.post<ApiResponse<InventoryCheckResponse>>('/api/v1/options/check-inventory', {
@@ -36,6 +36,7 @@ export interface UserCreateRequest {
   email_confirm: string;
   plan_type: string;
   option_types: string[];
+  option_details?: Record<string, OptionDetailRequest>;
 }
 
 export interface UserValidateRequest {
@@ -60,6 +61,7 @@ export interface UserValidateRequest {
   email_confirm: string;
   plan_type: string;
   option_types: string[];
+  option_details?: Record<string, OptionDetailRequest>;
 }
 
 export interface UserCreateResponse {
@@ -80,15 +82,47 @@ export interface SessionCreateRequest {
 
 export interface SessionCreateResponse {
   session_id: string;
+  version: number;
   expires_at: string;
 }
 
 export interface SessionGetResponse {
   session_id: string;
   user_data: UserCreateRequest;
+  version: number;
   expires_at: string;
 }
 
+// Autosave of individual fields; null removes a field
+export interface SessionFieldsUpdateRequest {
+  fields: Partial<Record<keyof UserCreateRequest, unknown>>;
+  version: number;
+}
+
+// Form wizard progress tracked in the session
+export type WizardStepName = 'personal_info' | 'address' | 'plan_options';
+
+export interface WizardStepStatus {
+  step: WizardStepName;
+  status: 'pending' | 'valid' | 'invalid' | 'stale';
+  errors?: Record<string, string>;
+  validated_at?: string;
+}
+
+export interface WizardProgressResponse {
+  session_id: string;
+  current_step?: WizardStepName;
+  complete: boolean;
+  steps: WizardStepStatus[];
+}
+
+export interface SessionUpdateResponse {
+  session_id: string;
+  version: number;
+  expires_at: string;
+  updated_at: string;
+}
+
 // Address and prefecture types
 export interface AddressSearchRequest {
   postal_code: string;
@@ -114,11 +148,17 @@ export interface PrefecturesGetResponse {
 }
 
 // Option and plan types
+export interface OptionDetailRequest {
+  quantity?: number;
+  start_date?: string;
+}
+
 export interface OptionResponse {
   option_type: string;
   option_name: string;
   description: string;
   is_active: boolean;
+  max_quantity?: number;
   price?: number;
 }
 
@@ -143,8 +183,12 @@ export interface InventoryCheckRequest {
   option_types: string[];
 }
 
+// Stock state of an option, mapped from its stock by the server's thresholds
+export type StockState = 'in_stock' | 'low_stock' | 'out_of_stock';
+
 export interface InventoryCheckResponse {
   inventory: Record<string, number>;
+  states: Record<string, StockState>;
 }
 
 export interface RegionCheckRequest {
@@ -157,6 +201,28 @@ export interface RegionCheckResponse {
   restrictions: Record<string, boolean>;
 }
 
+// Option recommendation types
+export interface OptionRecommendationScores {
+  availability: number;
+  popularity: number;
+  plan_fit: number;
+}
+
+export interface OptionRecommendation extends OptionResponse {
+  plan_compatibility: string;
+  score: number;
+  scores: OptionRecommendationScores;
+  stock_state: StockState;
+  take_up_rate: number;
+}
+
+export interface OptionRecommendationResponse {
+  plan_type: string;
+  prefecture: string;
+  popularity_scope: 'prefecture' | 'nationwide';
+  recommendations: OptionRecommendation[];
+}
+
 // Health check types
 export interface HealthCheckResponse {
   status: string;
@@ -34,6 +34,13 @@ export interface UserFormData {
   // Plan and options
   planType: string;
   optionTypes: string[];
+  optionDetails: Record<string, OptionDetail>;
+}
+
+// Quantity and desired start date of a selected option
+export interface OptionDetail {
+  quantity: number;
+  startDate: string; // YYYY-MM-DD, empty for as soon as possible
 }
 
 // Form step types
@@ -93,7 +100,7 @@ export interface FormFieldProps {
   label: string;
   required?: boolean;
   placeholder?: string;
-  type?: 'text' | 'email' | 'tel' | 'number';
+  type?: 'text' | 'email' | 'tel' | 'number' | 'date';
   maxLength?: number;
   pattern?: string;
   helpText?: string;
@@ -1,6 +1,38 @@
 // API data transformers for frontend-backend communication
-import type { UserFormData } from '../types/form';
-import type { UserCreateRequest, UserValidateRequest } from '../types/api';
+import type { UserFormData, OptionDetail } from '../types/form';
+import type { UserCreateRequest, UserValidateRequest, OptionDetailRequest } from '../types/api';
+
+// Transform option details of the selected options to backend API format
+const transformOptionDetailsToApi = (
+  optionTypes: string[],
+  optionDetails: Record<string, OptionDetail>
+): Record<string, OptionDetailRequest> => {
+  const details: Record<string, OptionDetailRequest> = {};
+  optionTypes.forEach(type => {
+    const detail = optionDetails[type];
+    if (detail) {
+      details[type] = {
+        quantity: detail.quantity,
+        start_date: detail.startDate || undefined
+      };
+    }
+  });
+  return details;
+};
+
+// Transform option details from backend API format
+const transformOptionDetailsFromApi = (
+  optionDetails: Record<string, OptionDetailRequest> = {}
+): Record<string, OptionDetail> => {
+  const details: Record<string, OptionDetail> = {};
+  Object.entries(optionDetails).forEach(([type, detail]) => {
+    details[type] = {
+      quantity: detail.quantity || 1,
+      startDate: detail.start_date || ''
+    };
+  });
+  return details;
+};
 
 // Transform frontend form data to backend API format
 export const transformFormDataToApiRequest = (formData: UserFormData): UserCreateRequest => {
@@ -25,7 +57,8 @@ export const transformFormDataToApiRequest = (formData: UserFormData): UserCreat
     email: formData.email,
     email_confirm: formData.emailConfirm,
     plan_type: formData.planType,
-    option_types: formData.optionTypes
+    option_types: formData.optionTypes,
+    option_details: transformOptionDetailsToApi(formData.optionTypes, formData.optionDetails)
   };
 };
 
@@ -57,7 +90,8 @@ export const transformApiResponseToFormData = (apiData: UserCreateRequest): User
     email: apiData.email,
     emailConfirm: apiData.email_confirm,
     planType: apiData.plan_type,
-    optionTypes: apiData.option_types
+    optionTypes: apiData.option_types,
+    optionDetails: transformOptionDetailsFromApi(apiData.option_details)
   };
 };
 
@@ -99,11 +133,22 @@ export const isValidPostalCodeFormat = (postalCode1: string, postalCode2: string
   return /^\d{3}$/.test(postalCode1) && /^\d{4}$/.test(postalCode2);
 };
 
-// Format option names for display
-export const formatOptionNames = (optionTypes: string[], availableOptions: Array<{ option_type: string; option_name: string }>): string => {
+// Format option names for display, with quantity and start date when given
+export const formatOptionNames = (
+  optionTypes: string[],
+  availableOptions: Array<{ option_type: string; option_name: string }>,
+  optionDetails: Record<string, OptionDetail> = {}
+): string => {
   const optionNames = optionTypes.map(type => {
     const option = availableOptions.find(opt => opt.option_type === type);
-    return option ? option.option_name : type;
+    const name = option ? option.option_name : type;
+    const detail = optionDetails[type];
+    if (!detail) {
+      return name;
+    }
+
+    const startDate = detail.startDate ? ` ${detail.startDate}開始` : '';
+    return `${name}（${detail.quantity}個${startDate}）`;
   });
   
   return optionNames.join('、');
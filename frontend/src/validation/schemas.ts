@@ -141,7 +141,14 @@ export const userFormSchema = z.object({
     .min(1, 'プランを選択してください'),
   
   optionTypes: z.array(z.string())
-    .default([])
+    .default([]),
+
+  optionDetails: z.record(z.object({
+    quantity: z.number()
+      .int('数量は整数で入力してください')
+      .min(1, '数量は1以上で入力してください'),
+    startDate: z.string()
+  })).default({})
 }).refine((data) => {
   // Email confirmation validation
   return data.email === data.emailConfirm;
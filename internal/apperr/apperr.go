@@ -0,0 +1,71 @@
+// Package apperr defines the error kinds shared by repositories, services and handlers.
+// Handlers map kinds to responses with errors.Is, so messages can be reworded freely.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error kinds
+var (
+	// ErrNotFound reports that the requested resource does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrDuplicate reports that the resource conflicts with an existing one
+	ErrDuplicate = errors.New("duplicate")
+	// ErrValidation reports that the input is invalid
+	ErrValidation = errors.New("validation failed")
+	// ErrExpired reports that the resource existed but has expired
+	ErrExpired = errors.New("expired")
+	// ErrLegalHold reports that the operation is blocked by a legal hold
+	ErrLegalHold = errors.New("legal hold")
+	// ErrExternalAPI reports that an external API the operation depends on failed
+	ErrExternalAPI = errors.New("external API failed")
+	// ErrExternalTimeout reports that an external API the operation depends on did not
+	// answer in time
+	ErrExternalTimeout = errors.New("external API timed out")
+	// ErrConflict reports that the resource changed since the version the caller based the
+	// operation on
+	ErrConflict = errors.New("conflict")
+)
+
+// kindError marks an error with a kind without changing its message
+type kindError struct {
+	kind error
+	err  error
+}
+
+// Error returns the message of the marked error
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap exposes both the kind and the marked error to errors.Is and errors.As
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// Errorf formats an error like fmt.Errorf, including %w wrapping, and marks it with kind
+func Errorf(kind error, format string, args ...any) error {
+	return &kindError{kind: kind, err: fmt.Errorf(format, args...)}
+}
+
+// VersionConflictError reports that an update was based on an outdated version of a
+// resource. It is of kind ErrConflict; CurrentVersion lets clients tell how far behind
+// they are.
+type VersionConflictError struct {
+	Resource        string
+	ID              string
+	ExpectedVersion int
+	CurrentVersion  int
+}
+
+// Error describes the conflict
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%s %s is at version %d, not %d", e.Resource, e.ID, e.CurrentVersion, e.ExpectedVersion)
+}
+
+// Is reports the error as of kind ErrConflict
+func (e *VersionConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
@@ -0,0 +1,109 @@
+// Package domain defines the typed domain events raised by the service layer and the bus
+// that delivers them to subscribers.
+package domain
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+)
+
+// Domain event names
+const (
+	EventUserCreated    = "UserCreated"
+	EventUserUpdated    = "UserUpdated"
+	EventUserDeleted    = "UserDeleted"
+	EventOptionsChanged = "OptionsChanged"
+)
+
+// Event is a change of state raised by the service layer
+type Event interface {
+	EventName() string
+}
+
+// UserCreated is raised when a user registers
+type UserCreated struct {
+	User        *model.User
+	OptionTypes []string
+}
+
+// EventName returns EventUserCreated
+func (UserCreated) EventName() string { return EventUserCreated }
+
+// UserUpdated is raised when an update changes any column of a user. ChangedFields are
+// named after the columns.
+type UserUpdated struct {
+	Previous      *model.User
+	User          *model.User
+	ChangedFields []string
+}
+
+// EventName returns EventUserUpdated
+func (UserUpdated) EventName() string { return EventUserUpdated }
+
+// UserDeleted is raised when a user is deleted. User is the state before the deletion.
+type UserDeleted struct {
+	User   *model.User
+	Reason string
+}
+
+// EventName returns EventUserDeleted
+func (UserDeleted) EventName() string { return EventUserDeleted }
+
+// OptionsChanged is raised when options of a user are added, removed or have their details
+// updated
+type OptionsChanged struct {
+	UserID  int
+	Added   []string
+	Removed []string
+	Updated []string
+}
+
+// EventName returns EventOptionsChanged
+func (OptionsChanged) EventName() string { return EventOptionsChanged }
+
+// Handler handles a domain event
+type Handler func(ctx context.Context, event Event) error
+
+// Bus delivers domain events to the handlers subscribed to their name. Events are
+// published within the transaction of the change, so a handler writing through the
+// repositories commits or rolls back with it and a handler error rolls the change back.
+// Handlers with side effects outside the database should record them in the outbox.
+type Bus interface {
+	Subscribe(name string, handler Handler)
+	Publish(ctx context.Context, event Event) error
+}
+
+// bus implements Bus by calling handlers synchronously in subscription order
+type bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewBus creates an in-process domain event bus
+func NewBus() Bus {
+	return &bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe adds a handler for events of name
+func (b *bus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], handler)
+}
+
+// Publish calls the handlers of the event, stopping at the first error
+func (b *bus) Publish(ctx context.Context, event Event) error {
+	b.mu.RLock()
+	handlers := b.handlers[event.EventName()]
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		if err := handler(ctx, event); err != nil {
+			return fmt.Errorf("failed to handle %s: %w", event.EventName(), err)
+		}
+	}
+	return nil
+}
@@ -15,6 +15,50 @@ type AddressSearchResponse struct {
 	PostalCode string `json:"postal_code,omitempty"`
 }
 
+// AddressReverseSearchRequest represents the request for postal code search by address
+type AddressReverseSearchRequest struct {
+	Prefecture string `form:"prefecture" validate:"required,max=10"`
+	City       string `form:"city" validate:"required,max=50"`
+	Town       string `form:"town" validate:"max=200"`
+}
+
+// AddressReverseSearchResult represents a postal code matching the address
+type AddressReverseSearchResult struct {
+	PostalCode string `json:"postal_code"`
+	Prefecture string `json:"prefecture"`
+	City       string `json:"city"`
+	Town       string `json:"town,omitempty"`
+}
+
+// AddressReverseSearchResponse represents the response for postal code search by address
+type AddressReverseSearchResponse struct {
+	Found   bool                         `json:"found"`
+	Results []AddressReverseSearchResult `json:"results"`
+	// Truncated is true when more postal codes matched than were returned
+	Truncated bool `json:"truncated"`
+}
+
+// BuildingSearchRequest represents the request for building names at an address
+type BuildingSearchRequest struct {
+	PostalCode string `form:"postal_code" validate:"required,len=7,numeric"`
+	Banchi     string `form:"banchi" validate:"required,max=40"`
+}
+
+// BuildingSuggestion represents a building at the address
+type BuildingSuggestion struct {
+	Name string `json:"name"`
+	// Banchi is the block numbers of the building, which may go further than those searched
+	Banchi string `json:"banchi"`
+}
+
+// BuildingSearchResponse represents the response for building names at an address
+type BuildingSearchResponse struct {
+	Found     bool                 `json:"found"`
+	Buildings []BuildingSuggestion `json:"buildings"`
+	// Truncated is true when more buildings matched than were returned
+	Truncated bool `json:"truncated"`
+}
+
 // RegionCheckRequest represents the request for region restriction check
 type RegionCheckRequest struct {
 	Prefecture  string   `json:"prefecture" validate:"required"`
@@ -0,0 +1,344 @@
+// Package dto defines data transfer objects for admin endpoints.
+package dto
+
+import (
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/external"
+	"github.com/octop162/normal-form-app-by-claude/pkg/lru"
+	"github.com/octop162/normal-form-app-by-claude/pkg/masking"
+	"github.com/octop162/normal-form-app-by-claude/pkg/money"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+// ExternalAPIsResponse represents the response for the external API inspection endpoint
+type ExternalAPIsResponse struct {
+	APIs []*external.ClientStatus `json:"apis"`
+}
+
+// MemoryStoresResponse represents the size of the bounded in-process stores
+type MemoryStoresResponse struct {
+	Stores map[string]lru.Stats `json:"stores"`
+}
+
+// CircuitBreakerOverrideRequest represents the request to override a circuit breaker
+type CircuitBreakerOverrideRequest struct {
+	Override string `json:"override" validate:"required,oneof=none force_open force_closed"`
+}
+
+// Bulk user status actions
+const (
+	BulkUserStatusActionSuspend  = "suspend"
+	BulkUserStatusActionActivate = "activate"
+)
+
+// Per-user outcomes of a bulk status change
+const (
+	BulkUserStatusResultUpdated   = "updated"
+	BulkUserStatusResultUnchanged = "unchanged"
+	BulkUserStatusResultNotFound  = "not_found"
+	BulkUserStatusResultFailed    = "failed"
+)
+
+// BulkUserStatusRequest represents the request to change the status of several users
+type BulkUserStatusRequest struct {
+	UserIDs []int  `json:"user_ids" validate:"required,min=1,max=1000,dive,gt=0"`
+	Action  string `json:"action" validate:"required,oneof=suspend activate"`
+	Reason  string `json:"reason" validate:"max=255"`
+}
+
+// BulkUserStatusResult reports the outcome for a single user
+type BulkUserStatusResult struct {
+	UserID         int    `json:"user_id"`
+	Result         string `json:"result"`
+	PreviousStatus string `json:"previous_status,omitempty"`
+	Status         string `json:"status,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// BulkUserStatusResponse represents the per-user report of a bulk status change
+type BulkUserStatusResponse struct {
+	Action    string                 `json:"action"`
+	Status    string                 `json:"status"`
+	Total     int                    `json:"total"`
+	Updated   int                    `json:"updated"`
+	Unchanged int                    `json:"unchanged"`
+	NotFound  int                    `json:"not_found"`
+	Failed    int                    `json:"failed"`
+	Results   []BulkUserStatusResult `json:"results"`
+}
+
+// AdminUserResponse represents a user with the account state only administrators see
+type AdminUserResponse struct {
+	UserResponse
+	LegalHold           bool                        `json:"legal_hold"`
+	EmailDeliverability EmailDeliverabilityResponse `json:"email_deliverability"`
+}
+
+// AdminUserDuplicatesResponse lists the users that may be the same person as a user, most
+// likely first
+type AdminUserDuplicatesResponse struct {
+	UserID     int                       `json:"user_id"`
+	Candidates []AdminDuplicateCandidate `json:"candidates"`
+}
+
+// AdminDuplicateCandidate is a user that may be the same person, with the strategies that
+// matched it
+type AdminDuplicateCandidate struct {
+	User UserResponse `json:"user"`
+	// Confidence is the highest confidence of the matches, from 0 to 1
+	Confidence float64               `json:"confidence"`
+	Matches    []AdminDuplicateMatch `json:"matches"`
+}
+
+// AdminDuplicateMatch is a duplicate detection strategy that matched a candidate
+type AdminDuplicateMatch struct {
+	Strategy   string  `json:"strategy"`
+	Confidence float64 `json:"confidence"`
+}
+
+// Mask masks the personal data of the candidates for the role
+func (r *AdminUserDuplicatesResponse) Mask(policy *masking.Policy, role string) {
+	for i := range r.Candidates {
+		r.Candidates[i].User.Mask(policy, role)
+	}
+}
+
+// EmailDeliverabilityResponse represents what the mail provider reported about a user's
+// email address. Suppressed users receive no email until they change the address.
+type EmailDeliverabilityResponse struct {
+	Status     string     `json:"status"`
+	Suppressed bool       `json:"suppressed"`
+	Reason     *string    `json:"reason,omitempty"`
+	UpdatedAt  *time.Time `json:"updated_at,omitempty"`
+}
+
+// LegalHoldRequest represents the request to place or release a legal hold on a user
+type LegalHoldRequest struct {
+	Enabled *bool  `json:"enabled" validate:"required"`
+	Reason  string `json:"reason" validate:"max=500"`
+}
+
+// LegalHoldResponse represents the legal hold state of a user
+type LegalHoldResponse struct {
+	UserID    int  `json:"user_id"`
+	LegalHold bool `json:"legal_hold"`
+}
+
+// Feature flag value sources
+const (
+	FeatureFlagSourceDefault  = "default"
+	FeatureFlagSourceOverride = "override"
+)
+
+// FeatureFlagResponse represents the effective state of a feature flag
+type FeatureFlagResponse struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Enabled     bool       `json:"enabled"`
+	Default     bool       `json:"default"`
+	Source      string     `json:"source"`
+	Reason      *string    `json:"reason,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// FeatureFlagsResponse represents the list of known feature flags
+type FeatureFlagsResponse struct {
+	Flags []FeatureFlagResponse `json:"flags"`
+}
+
+// FeatureFlagUpdateRequest represents the request to override a feature flag at runtime
+type FeatureFlagUpdateRequest struct {
+	Enabled *bool  `json:"enabled" validate:"required"`
+	Reason  string `json:"reason" validate:"max=255"`
+}
+
+// ValidationRulesResponse represents the field validation rules currently in effect
+type ValidationRulesResponse struct {
+	Source   string                `json:"source"`
+	LoadedAt *time.Time            `json:"loaded_at,omitempty"`
+	Rules    []validator.FieldRule `json:"rules"`
+}
+
+// AdminOptionRequest represents the settings of an option in the master data
+type AdminOptionRequest struct {
+	OptionName        string  `json:"option_name" validate:"required,max=100"`
+	Description       *string `json:"description"`
+	PlanCompatibility string  `json:"plan_compatibility" validate:"required,oneof=A B AB"`
+	MaxQuantity       int     `json:"max_quantity" validate:"required,min=1"`
+	// MonthlyPrice is the price of one unit per month, excluding tax
+	MonthlyPrice *money.Yen `json:"monthly_price" validate:"required,min=0"`
+	IsActive     *bool      `json:"is_active" validate:"required"`
+	// ValidFrom and ValidUntil schedule when the option is offered; null means unbounded
+	ValidFrom  *time.Time `json:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until"`
+}
+
+// AdminOptionCreateRequest represents the request to add an option
+type AdminOptionCreateRequest struct {
+	OptionType string `json:"option_type" validate:"required,oneof=AA BB AB"`
+	AdminOptionRequest
+}
+
+// AdminOptionResponse represents an option in the master data
+type AdminOptionResponse struct {
+	OptionType        string     `json:"option_type"`
+	OptionName        string     `json:"option_name"`
+	Description       *string    `json:"description"`
+	PlanCompatibility string     `json:"plan_compatibility"`
+	MaxQuantity       int        `json:"max_quantity"`
+	MonthlyPrice      money.Yen  `json:"monthly_price"`
+	IsActive          bool       `json:"is_active"`
+	ValidFrom         *time.Time `json:"valid_from"`
+	ValidUntil        *time.Time `json:"valid_until"`
+	// Available reports whether the option is offered right now
+	Available bool      `json:"available"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AdminOptionsResponse represents all options in the master data
+type AdminOptionsResponse struct {
+	Options []AdminOptionResponse `json:"options"`
+}
+
+// AdminRegionRestrictionListRequest filters region restrictions by prefecture
+type AdminRegionRestrictionListRequest struct {
+	Prefecture string `form:"prefecture" validate:"omitempty,max=10"`
+}
+
+// AdminRegionRestrictionRequest represents whether an option may be offered in a region. An
+// empty city applies to the whole prefecture and a city entry overrides it.
+type AdminRegionRestrictionRequest struct {
+	Prefecture string  `json:"prefecture" validate:"required,max=10"`
+	City       string  `json:"city" validate:"max=50"`
+	OptionType string  `json:"option_type" validate:"required,oneof=AA BB AB"`
+	Allowed    *bool   `json:"allowed" validate:"required"`
+	Note       *string `json:"note" validate:"omitempty,max=255"`
+}
+
+// AdminRegionRestrictionResponse represents a region restriction in the master data
+type AdminRegionRestrictionResponse struct {
+	ID         int       `json:"id"`
+	Prefecture string    `json:"prefecture"`
+	City       string    `json:"city"`
+	OptionType string    `json:"option_type"`
+	Allowed    bool      `json:"allowed"`
+	Note       *string   `json:"note"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// AdminRegionRestrictionsResponse represents region restrictions in the master data
+type AdminRegionRestrictionsResponse struct {
+	Restrictions []AdminRegionRestrictionResponse `json:"restrictions"`
+}
+
+// AdminPlanRequest represents the settings of a plan in the master data
+type AdminPlanRequest struct {
+	PlanName    string  `json:"plan_name" validate:"required,max=100"`
+	Description *string `json:"description"`
+	// MonthlyPrice is the price per month, excluding tax
+	MonthlyPrice *money.Yen `json:"monthly_price" validate:"required,min=0"`
+	DisplayOrder int        `json:"display_order" validate:"min=0"`
+	IsActive     *bool      `json:"is_active" validate:"required"`
+}
+
+// AdminPlanCreateRequest represents the request to add a plan
+type AdminPlanCreateRequest struct {
+	PlanType string `json:"plan_type" validate:"required,alphanum,max=10"`
+	AdminPlanRequest
+}
+
+// AdminPlanResponse represents a plan in the master data
+type AdminPlanResponse struct {
+	PlanType     string    `json:"plan_type"`
+	PlanName     string    `json:"plan_name"`
+	Description  *string   `json:"description"`
+	MonthlyPrice money.Yen `json:"monthly_price"`
+	DisplayOrder int       `json:"display_order"`
+	IsActive     bool      `json:"is_active"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// AdminPlansResponse represents all plans in the master data
+type AdminPlansResponse struct {
+	Plans []AdminPlanResponse `json:"plans"`
+}
+
+// AdminEventListRequest filters the events whose publishing or webhook delivery failed.
+// State failed keeps the events no longer retried, retrying those awaiting a retry, and
+// an empty state lists both.
+type AdminEventListRequest struct {
+	State     string `form:"state" validate:"omitempty,oneof=failed retrying"`
+	EventType string `form:"event_type" validate:"omitempty,max=100"`
+	Limit     int    `form:"limit" validate:"omitempty,min=1,max=100"`
+	Offset    int    `form:"offset" validate:"omitempty,min=0"`
+}
+
+// AdminEventDeliveryResponse represents the delivery of an event to one consumer
+type AdminEventDeliveryResponse struct {
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts"`
+	// LastError is the cause of the last failed attempt
+	LastError *string `json:"last_error"`
+	// NextAttemptAt is when a delivery awaiting a retry is next attempted
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+}
+
+// AdminEventResponse represents an outbox event with its publishing by the relay and its
+// webhook delivery, which is null when the event was not queued for the webhook endpoint
+type AdminEventResponse struct {
+	ID            int64                       `json:"id"`
+	AggregateType string                      `json:"aggregate_type"`
+	AggregateID   string                      `json:"aggregate_id"`
+	EventType     string                      `json:"event_type"`
+	CorrelationID *string                     `json:"correlation_id"`
+	CreatedAt     time.Time                   `json:"created_at"`
+	Outbox        AdminEventDeliveryResponse  `json:"outbox"`
+	Webhook       *AdminEventDeliveryResponse `json:"webhook"`
+}
+
+// AdminEventListResponse lists events whose delivery failed, newest first
+type AdminEventListResponse struct {
+	Events []*AdminEventResponse `json:"events"`
+}
+
+// AdminReservationListRequest filters option stock holds. Status defaults to held, and
+// MinAgeMinutes keeps the holds placed at least that many minutes ago.
+type AdminReservationListRequest struct {
+	Status        string `form:"status" validate:"omitempty,oneof=held consumed released"`
+	OptionType    string `form:"option_type" validate:"omitempty,oneof=AA BB AB"`
+	SessionID     string `form:"session_id" validate:"omitempty,max=255"`
+	MinAgeMinutes int    `form:"min_age_minutes" validate:"omitempty,min=0"`
+	Limit         int    `form:"limit" validate:"omitempty,min=1,max=100"`
+	Offset        int    `form:"offset" validate:"omitempty,min=0"`
+}
+
+// AdminReservationExtendRequest represents moving the expiry of a hold by Minutes, within
+// the lifetime of its session
+type AdminReservationExtendRequest struct {
+	Minutes int `json:"minutes" validate:"required,min=1,max=1440"`
+}
+
+// AdminReservationResponse is the admin view of an option stock hold
+type AdminReservationResponse struct {
+	ID int `json:"id"`
+	// SessionID is null once the session was deleted
+	SessionID  *string   `json:"session_id"`
+	OptionType string    `json:"option_type"`
+	Quantity   int       `json:"quantity"`
+	Status     string    `json:"status"`
+	UserID     *int      `json:"user_id"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// SessionExpiresAt is null once the session was deleted
+	SessionExpiresAt *time.Time `json:"session_expires_at"`
+	// AgeSeconds is how long ago the hold was placed
+	AgeSeconds int64     `json:"age_seconds"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// AdminReservationListResponse lists option stock holds, oldest first
+type AdminReservationListResponse struct {
+	Reservations []*AdminReservationResponse `json:"reservations"`
+}
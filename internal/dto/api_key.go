@@ -0,0 +1,72 @@
+// Package dto defines data transfer objects for partner API key administration.
+package dto
+
+import "time"
+
+// AdminAPIKeyCreateRequest represents a new partner API key
+type AdminAPIKeyCreateRequest struct {
+	Partner string   `json:"partner" validate:"required,max=100"`
+	Scopes  []string `json:"scopes" validate:"required,min=1,dive,oneof=availability:read plans:read"`
+	Note    *string  `json:"note" validate:"omitempty,max=255"`
+}
+
+// AdminAPIKeyResponse is the admin view of a partner API key. The key itself is not
+// included; KeyPrefix tells keys apart.
+type AdminAPIKeyResponse struct {
+	ID        int64      `json:"id"`
+	Partner   string     `json:"partner"`
+	KeyPrefix string     `json:"key_prefix"`
+	Scopes    []string   `json:"scopes"`
+	Note      *string    `json:"note"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// AdminAPIKeyCreatedResponse is a newly created key. Key is shown only in this response.
+type AdminAPIKeyCreatedResponse struct {
+	*AdminAPIKeyResponse
+	Key string `json:"key"`
+}
+
+// AdminAPIKeyListResponse lists partner API keys for administrators
+type AdminAPIKeyListResponse struct {
+	Keys []*AdminAPIKeyResponse `json:"keys"`
+}
+
+// AdminAPIKeyUsageRequest selects the usage report of a key. Month defaults to the current
+// month (UTC) and Granularity to day; Format csv returns the report as a CSV file.
+type AdminAPIKeyUsageRequest struct {
+	Month       string `form:"month" validate:"omitempty,datetime=2006-01"`
+	Granularity string `form:"granularity" validate:"omitempty,oneof=hour day"`
+	Format      string `form:"format" validate:"omitempty,oneof=json csv"`
+}
+
+// APIKeyUsageCounts is the metered usage of a key over some period
+type APIKeyUsageCounts struct {
+	Requests      int64 `json:"requests"`
+	RequestBytes  int64 `json:"request_bytes"`
+	ResponseBytes int64 `json:"response_bytes"`
+	ClientErrors  int64 `json:"client_errors"`
+	ServerErrors  int64 `json:"server_errors"`
+	// ErrorRate is the share of requests answered with a 4xx or 5xx status
+	ErrorRate float64 `json:"error_rate"`
+}
+
+// AdminAPIKeyUsageEntry is the usage of a key for an endpoint, in a period when PeriodStart
+// is set
+type AdminAPIKeyUsageEntry struct {
+	PeriodStart *time.Time `json:"period_start,omitempty"`
+	Endpoint    string     `json:"endpoint"`
+	APIKeyUsageCounts
+}
+
+// AdminAPIKeyUsageResponse is the usage report of a key for a month
+type AdminAPIKeyUsageResponse struct {
+	APIKeyID    int64                    `json:"api_key_id"`
+	Partner     string                   `json:"partner"`
+	Month       string                   `json:"month"`
+	Granularity string                   `json:"granularity"`
+	Total       APIKeyUsageCounts        `json:"total"`
+	Endpoints   []*AdminAPIKeyUsageEntry `json:"endpoints"`
+	Usage       []*AdminAPIKeyUsageEntry `json:"usage"`
+}
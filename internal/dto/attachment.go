@@ -0,0 +1,52 @@
+// Package dto defines data transfer objects for uploaded attachments.
+package dto
+
+import (
+	"time"
+)
+
+// AttachmentResponse describes an uploaded attachment and its malware scan status
+type AttachmentResponse struct {
+	ID          string `json:"id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	// ScanStatus is "pending", "clean" or "infected"; only clean attachments can be linked
+	ScanStatus string    `json:"scan_status"`
+	SessionID  *string   `json:"session_id,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AttachmentLinkRequest represents the request to link an attachment to a form session
+type AttachmentLinkRequest struct {
+	AttachmentID string `json:"attachment_id" validate:"required,max=64"`
+}
+
+// AdminAttachmentListRequest represents the admin query for attachments by scan status
+type AdminAttachmentListRequest struct {
+	ScanStatus string `form:"scan_status" validate:"omitempty,oneof=pending clean infected"`
+	Limit      int    `form:"limit" validate:"omitempty,min=1,max=100"`
+	Offset     int    `form:"offset" validate:"omitempty,min=0"`
+}
+
+// AdminAttachmentResponse is the admin view of an attachment, including scan details
+type AdminAttachmentResponse struct {
+	ID            string     `json:"id"`
+	SessionID     *string    `json:"session_id"`
+	Filename      string     `json:"filename"`
+	ContentType   string     `json:"content_type"`
+	Size          int64      `json:"size"`
+	SHA256        string     `json:"sha256"`
+	ScanStatus    string     `json:"scan_status"`
+	ScanSignature *string    `json:"scan_signature"`
+	ScanAttempts  int        `json:"scan_attempts"`
+	ScanError     *string    `json:"scan_error"`
+	ScannedAt     *time.Time `json:"scanned_at"`
+	QuarantinedAt *time.Time `json:"quarantined_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// AdminAttachmentListResponse lists attachments for administrators
+type AdminAttachmentListResponse struct {
+	Attachments []*AdminAttachmentResponse `json:"attachments"`
+}
@@ -1,6 +1,12 @@
 // Package dto defines common data transfer objects for API communication.
 package dto
 
+import (
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/money"
+)
+
 // APIResponse represents the standard API response format
 type APIResponse struct {
 	Success bool        `json:"success"`
@@ -47,4 +53,47 @@ type PlanResponse struct {
 	PlanType    string `json:"plan_type"`
 	PlanName    string `json:"plan_name"`
 	Description string `json:"description,omitempty"`
+	// MonthlyPrice excludes tax; quotes add it
+	MonthlyPrice money.Yen `json:"monthly_price"`
+}
+
+// QuoteRequest represents the plan and options to price, in the same shape as the
+// registration form
+type QuoteRequest struct {
+	PlanType    string   `json:"plan_type" validate:"required,alphanum,max=10"`
+	OptionTypes []string `json:"option_types" validate:"omitempty,unique,dive,oneof=AA BB AB"`
+	// OptionDetails holds quantities keyed by option type; options without an entry count once
+	OptionDetails map[string]UserOptionDetail `json:"option_details,omitempty" validate:"omitempty,dive"`
+}
+
+// QuoteResponse represents the monthly price of a plan and options. Line amounts and the
+// subtotal exclude tax, which is computed once on the subtotal.
+type QuoteResponse struct {
+	PlanType string      `json:"plan_type"`
+	Lines    []QuoteLine `json:"lines"`
+	Subtotal money.Yen   `json:"subtotal"`
+	// TaxRate is the rate in effect when the quote was made, e.g. "10%"
+	TaxRate  string    `json:"tax_rate"`
+	Tax      money.Yen `json:"tax"`
+	Total    money.Yen `json:"total"`
+	QuotedAt time.Time `json:"quoted_at"`
+}
+
+// QuoteLine represents one priced item of a quote
+type QuoteLine struct {
+	// Kind is "plan" or "option"
+	Kind      string    `json:"kind"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	UnitPrice money.Yen `json:"unit_price"`
+	Quantity  int       `json:"quantity"`
+	Amount    money.Yen `json:"amount"`
+}
+
+// EmailEventsResponse represents the outcome of a bounce and complaint webhook delivery:
+// the events it reported and the users whose email deliverability they updated
+type EmailEventsResponse struct {
+	Provider string `json:"provider"`
+	Events   int    `json:"events"`
+	Updated  int64  `json:"updated"`
 }
@@ -0,0 +1,72 @@
+// Package dto defines data transfer objects for email template management.
+package dto
+
+import (
+	"time"
+)
+
+// EmailTemplateSummary represents a template and the version that is sent. ActiveVersion
+// 0 means the built-in template.
+type EmailTemplateSummary struct {
+	Name          string `json:"name"`
+	ActiveVersion int    `json:"active_version"`
+}
+
+// EmailTemplatesResponse lists the templates the application sends
+type EmailTemplatesResponse struct {
+	Templates []EmailTemplateSummary `json:"templates"`
+}
+
+// EmailTemplateVersionResponse represents one version of a template; version 0 is built in.
+// Only built-in templates have an HTML part.
+type EmailTemplateVersionResponse struct {
+	Name      string     `json:"name"`
+	Version   int        `json:"version"`
+	Subject   string     `json:"subject"`
+	Body      string     `json:"body"`
+	HTMLBody  string     `json:"html_body,omitempty"`
+	IsActive  bool       `json:"is_active"`
+	Note      *string    `json:"note,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty"`
+}
+
+// EmailTemplateVersionsResponse represents every version of a template, newest first and
+// ending with the built-in one
+type EmailTemplateVersionsResponse struct {
+	Name          string                         `json:"name"`
+	ActiveVersion int                            `json:"active_version"`
+	Versions      []EmailTemplateVersionResponse `json:"versions"`
+}
+
+// EmailTemplateVersionRequest represents a new version of a template. Activate sends it
+// from now on; otherwise it can be previewed and activated later.
+type EmailTemplateVersionRequest struct {
+	Subject  string  `json:"subject" validate:"required,max=255"`
+	Body     string  `json:"body" validate:"required,max=20000"`
+	Note     *string `json:"note" validate:"omitempty,max=255"`
+	Activate bool    `json:"activate"`
+}
+
+// EmailTemplatePreviewRequest selects what to render: a draft Subject and Body, a stored
+// Version, or the active version when neither is given. Data replaces the sample data.
+type EmailTemplatePreviewRequest struct {
+	Version *int           `json:"version" validate:"omitempty,min=0"`
+	Subject *string        `json:"subject" validate:"omitempty,max=255"`
+	Body    *string        `json:"body" validate:"omitempty,max=20000"`
+	Data    map[string]any `json:"data"`
+}
+
+// EmailTemplatePreviewResponse represents a rendered email
+type EmailTemplatePreviewResponse struct {
+	Name     string `json:"name"`
+	Version  *int   `json:"version,omitempty"`
+	Subject  string `json:"subject"`
+	Body     string `json:"body"`
+	HTMLBody string `json:"html_body,omitempty"`
+}
+
+// EmailTemplateTestSendRequest represents a test email of a template rendered like a preview
+type EmailTemplateTestSendRequest struct {
+	To string `json:"to" validate:"required,email,max=256"`
+	EmailTemplatePreviewRequest
+}
@@ -0,0 +1,32 @@
+// Package dto defines data transfer objects for the form bootstrap API.
+package dto
+
+// Parts of the form bootstrap response, as named in the fields query parameter
+const (
+	FormFieldPlans       = "plans"
+	FormFieldOptions     = "options"
+	FormFieldPrefectures = "prefectures"
+	FormFieldCSRFToken   = "csrf_token"
+)
+
+// FormFields lists every part of the form bootstrap response, in response order
+var FormFields = []string{FormFieldPlans, FormFieldOptions, FormFieldPrefectures, FormFieldCSRFToken}
+
+// FormBootstrapRequest represents the query for the data the form loads on start. Fields
+// may be repeated or comma-separated; all parts are returned when it is empty. Options are
+// those of PlanType, narrowed to Region, or all active options without a plan.
+type FormBootstrapRequest struct {
+	Fields   []string `form:"fields"`
+	PlanType string   `form:"plan_type"`
+	Region   string   `form:"region"`
+}
+
+// FormBootstrapResponse holds the master data and CSRF token the form loads on start. The
+// embedded responses are flattened into it, so each part has the shape of its own endpoint;
+// parts not requested are left nil and omitted.
+type FormBootstrapResponse struct {
+	*PlansGetResponse
+	*OptionsGetResponse
+	*PrefecturesGetResponse
+	CSRFToken string `json:"csrf_token,omitempty"`
+}
@@ -0,0 +1,47 @@
+// Package dto defines data transfer objects for self-service user lookups.
+package dto
+
+import (
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/masking"
+)
+
+// UserLookupRequest represents the request to start a self-service lookup
+type UserLookupRequest struct {
+	Email string `json:"email" validate:"required,email,max=256"`
+}
+
+// UserLookupResponse is returned whether or not the email is registered
+type UserLookupResponse struct {
+	LookupID  string    `json:"lookup_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Message   string    `json:"message"`
+}
+
+// UserLookupVerifyRequest represents the request to verify a lookup code
+type UserLookupVerifyRequest struct {
+	LookupID string `json:"lookup_id" validate:"required,max=64"`
+	Code     string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// UserLookupResultResponse is the limited, masked view of a registration
+type UserLookupResultResponse struct {
+	Status       string    `json:"status"`
+	Name         string    `json:"name"`
+	Email        string    `json:"email"`
+	PhoneNumber  string    `json:"phone_number"`
+	Prefecture   string    `json:"prefecture"`
+	PlanType     string    `json:"plan_type"`
+	OptionTypes  []string  `json:"option_types"`
+	RegisteredAt time.Time `json:"registered_at"`
+	// Options adds the quantity and start date of each option in OptionTypes
+	Options []UserOptionSummary `json:"options"`
+}
+
+// Mask applies the masking policy for the caller role to the personal data fields
+func (r *UserLookupResultResponse) Mask(policy *masking.Policy, role string) {
+	r.Name = policy.Mask(role, masking.FieldName, r.Name)
+	r.Email = policy.Mask(role, masking.FieldEmail, r.Email)
+	r.PhoneNumber = policy.Mask(role, masking.FieldPhone, r.PhoneNumber)
+}
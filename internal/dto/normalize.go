@@ -0,0 +1,41 @@
+// Package dto defines data transfer objects for input normalization.
+package dto
+
+// NormalizeKanaRequest represents the request to normalize katakana fields.
+// Keys are field names chosen by the client, e.g. "last_name_kana".
+type NormalizeKanaRequest struct {
+	Fields map[string]string `json:"fields" validate:"required,min=1,max=10,dive,keys,max=64,endkeys,max=100"`
+}
+
+// NormalizedValue represents a single normalized field
+type NormalizedValue struct {
+	Value string `json:"value"`
+	// Changed is true when the value differs from the input
+	Changed bool `json:"changed"`
+	// Valid is true when the value is in the form the field expects: katakana for kana
+	// fields, Arabic numerals for address block numbers
+	Valid bool `json:"valid"`
+}
+
+// NormalizeKanaResponse represents the normalized fields keyed like the request
+type NormalizeKanaResponse struct {
+	Fields map[string]NormalizedValue `json:"fields"`
+}
+
+// NormalizeAddressRequest represents the address parts below the city to normalize, as
+// entered in the registration form
+type NormalizeAddressRequest struct {
+	Town     string `json:"town" validate:"max=100"`
+	Chome    string `json:"chome" validate:"max=20"`
+	Banchi   string `json:"banchi" validate:"max=20"`
+	Go       string `json:"go" validate:"max=20"`
+	Building string `json:"building" validate:"max=200"`
+	Room     string `json:"room" validate:"max=40"`
+}
+
+// NormalizeAddressResponse represents every address part of the request normalized, keyed
+// by field name. A part may receive a value split from another, e.g. the chome written at
+// the end of the town.
+type NormalizeAddressResponse struct {
+	Fields map[string]NormalizedValue `json:"fields"`
+}
@@ -1,6 +1,13 @@
 // Package dto defines data transfer objects for option management.
 package dto
 
+import (
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/external"
+	"github.com/octop162/normal-form-app-by-claude/pkg/money"
+)
+
 // OptionResponse represents an option in API responses
 type OptionResponse struct {
 	ID                int    `json:"id"`
@@ -8,12 +15,15 @@ type OptionResponse struct {
 	OptionName        string `json:"option_name"`
 	Description       string `json:"description,omitempty"`
 	PlanCompatibility string `json:"plan_compatibility"`
+	MaxQuantity       int    `json:"max_quantity"`
 	IsActive          bool   `json:"is_active"`
+	// MonthlyPrice is per unit and excludes tax; quotes add it
+	MonthlyPrice money.Yen `json:"monthly_price"`
 }
 
 // OptionsGetRequest represents the request for getting available options
 type OptionsGetRequest struct {
-	PlanType string `form:"plan_type" validate:"required,oneof=A B"`
+	PlanType string `form:"plan_type" validate:"required,alphanum,max=10"`
 	Region   string `form:"region" validate:"omitempty"`
 }
 
@@ -30,4 +40,98 @@ type InventoryCheckRequest struct {
 // InventoryCheckResponse represents the response for inventory check
 type InventoryCheckResponse struct {
 	Inventory map[string]int `json:"inventory"`
+	// States maps the stock of each option to in_stock, low_stock or out_of_stock
+	States map[string]string `json:"states"`
+	// Stale is set when cached figures past their TTL were returned while fresh ones load
+	Stale     bool      `json:"stale"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// OptionAvailabilityRequest represents the request for checking stock and region
+// restrictions of options in one call
+type OptionAvailabilityRequest struct {
+	Prefecture  string   `json:"prefecture" validate:"required"`
+	City        string   `json:"city" validate:"required"`
+	OptionTypes []string `json:"option_types" validate:"required,min=1,dive,oneof=AA BB AB"`
+}
+
+// OptionAvailabilityResponse represents the combined stock and region availability of options
+type OptionAvailabilityResponse struct {
+	*external.OptionAvailabilityResult
+	// StockStates maps the stock of each option to in_stock, low_stock or out_of_stock
+	StockStates map[string]string `json:"stock_states"`
+	// Stale is set when cached stock past its TTL was used
+	Stale     bool      `json:"stale"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// OptionReserveRequest represents the request to hold option stock for a form session
+type OptionReserveRequest struct {
+	SessionID   string   `json:"session_id" validate:"required,max=255"`
+	OptionTypes []string `json:"option_types" validate:"required,min=1,dive,oneof=AA BB AB"`
+	// OptionDetails sets the quantity to hold by option type; the quantity defaults to 1
+	OptionDetails map[string]UserOptionDetail `json:"option_details,omitempty" validate:"omitempty,dive"`
+}
+
+// OptionReservationResponse represents a held option
+type OptionReservationResponse struct {
+	OptionType string `json:"option_type"`
+	Quantity   int    `json:"quantity"`
+}
+
+// OptionReserveResponse represents the holds of a form session
+type OptionReserveResponse struct {
+	SessionID    string                      `json:"session_id"`
+	Reservations []OptionReservationResponse `json:"reservations"`
+	ExpiresAt    time.Time                   `json:"expires_at"`
+}
+
+// Popularity scopes of option recommendations
+const (
+	PopularityScopePrefecture = "prefecture"
+	PopularityScopeNationwide = "nationwide"
+)
+
+// OptionRecommendationRequest selects the plan and region options are recommended for. City
+// is optional; when given, region restrictions are applied as well as stock.
+type OptionRecommendationRequest struct {
+	PlanType   string `form:"plan_type" validate:"required,alphanum,max=10"`
+	Prefecture string `form:"prefecture" validate:"required,max=10"`
+	City       string `form:"city" validate:"omitempty,max=50"`
+}
+
+// OptionRecommendationScores breaks a recommendation score down into its factors, each
+// between 0 and 1
+type OptionRecommendationScores struct {
+	// Availability is 1 for options in stock and lower for options low on stock
+	Availability float64 `json:"availability"`
+	// Popularity is the take-up of the option relative to the most taken-up option
+	Popularity float64 `json:"popularity"`
+	// PlanFit is 1 for options made for the plan only and lower for options of every plan
+	PlanFit float64 `json:"plan_fit"`
+}
+
+// OptionRecommendation represents a recommended option
+type OptionRecommendation struct {
+	OptionResponse
+	// Score is the weighted sum of Scores; recommendations are ordered by it
+	Score      float64                    `json:"score"`
+	Scores     OptionRecommendationScores `json:"scores"`
+	StockState string                     `json:"stock_state"`
+	// TakeUpRate is the share of registrations of the plan that selected the option
+	TakeUpRate float64 `json:"take_up_rate"`
+}
+
+// OptionRecommendationResponse represents the options recommended for a plan and region,
+// best first. Options that cannot be selected there are left out.
+type OptionRecommendationResponse struct {
+	PlanType   string `json:"plan_type"`
+	Prefecture string `json:"prefecture"`
+	// PopularityScope is prefecture, or nationwide when the prefecture has too few
+	// registrations of the plan to rank by
+	PopularityScope string                 `json:"popularity_scope"`
+	Recommendations []OptionRecommendation `json:"recommendations"`
+	// Stale is set when cached stock past its TTL was used
+	Stale     bool      `json:"stale"`
+	CheckedAt time.Time `json:"checked_at"`
 }
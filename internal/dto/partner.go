@@ -0,0 +1,10 @@
+// Package dto defines data transfer objects for the partner API.
+package dto
+
+// PartnerAvailabilityRequest represents the partner query for option availability in an
+// area. OptionTypes may be repeated or comma-separated.
+type PartnerAvailabilityRequest struct {
+	Prefecture  string   `form:"prefecture"`
+	City        string   `form:"city"`
+	OptionTypes []string `form:"option_types"`
+}
@@ -0,0 +1,81 @@
+// Package dto defines data transfer objects for personal data requests.
+package dto
+
+import "time"
+
+// PersonalDataExport is everything stored about a user, returned for a request to access
+// personal data. Nothing is masked.
+type PersonalDataExport struct {
+	ExportedAt time.Time             `json:"exported_at"`
+	User       PersonalDataUser      `json:"user"`
+	Options    []PersonalDataOption  `json:"options"`
+	Sessions   []PersonalDataSession `json:"sessions"`
+}
+
+// PersonalDataUser is the stored registration of a user, column by column
+type PersonalDataUser struct {
+	ID                           int        `json:"id"`
+	LastName                     string     `json:"last_name"`
+	FirstName                    string     `json:"first_name"`
+	LastNameKana                 string     `json:"last_name_kana"`
+	FirstNameKana                string     `json:"first_name_kana"`
+	Phone1                       string     `json:"phone1"`
+	Phone2                       string     `json:"phone2"`
+	Phone3                       string     `json:"phone3"`
+	PhoneVerified                bool       `json:"phone_verified"`
+	PostalCode1                  string     `json:"postal_code1"`
+	PostalCode2                  string     `json:"postal_code2"`
+	Prefecture                   string     `json:"prefecture"`
+	City                         string     `json:"city"`
+	Town                         *string    `json:"town"`
+	Chome                        *string    `json:"chome"`
+	Banchi                       string     `json:"banchi"`
+	Go                           *string    `json:"go"`
+	Building                     *string    `json:"building"`
+	Room                         *string    `json:"room"`
+	Email                        string     `json:"email"`
+	PlanType                     string     `json:"plan_type"`
+	Locale                       string     `json:"locale"`
+	Status                       string     `json:"status"`
+	LegalHold                    bool       `json:"legal_hold"`
+	EmailDeliverability          string     `json:"email_deliverability"`
+	EmailDeliverabilityReason    *string    `json:"email_deliverability_reason"`
+	EmailDeliverabilityUpdatedAt *time.Time `json:"email_deliverability_updated_at"`
+	ErasedAt                     *time.Time `json:"erased_at"`
+	CreatedAt                    time.Time  `json:"created_at"`
+	UpdatedAt                    time.Time  `json:"updated_at"`
+}
+
+// PersonalDataOption is an option stored for a user
+type PersonalDataOption struct {
+	OptionType string    `json:"option_type"`
+	Quantity   int       `json:"quantity"`
+	StartDate  *string   `json:"start_date"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// PersonalDataSession is a form session stored for the email address of a user, including
+// expired sessions not yet cleaned up
+type PersonalDataSession struct {
+	ID        string          `json:"id"`
+	UserData  SessionFormData `json:"user_data"`
+	ClientIP  *string         `json:"client_ip"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	ExpiresAt time.Time       `json:"expires_at"`
+}
+
+// PersonalDataEraseRequest represents the optional body of a request to erase personal data
+type PersonalDataEraseRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// PersonalDataEraseResponse reports what an erasure removed
+type PersonalDataEraseResponse struct {
+	UserID   int       `json:"user_id"`
+	ErasedAt time.Time `json:"erased_at"`
+	// SessionsDeleted is the number of form sessions of the user's email address deleted
+	SessionsDeleted int64 `json:"sessions_deleted"`
+	// NotificationsSkipped is the number of pending notification emails cancelled
+	NotificationsSkipped int64 `json:"notifications_skipped"`
+}
@@ -0,0 +1,32 @@
+// Package dto defines data transfer objects for SMS phone verification.
+package dto
+
+import (
+	"time"
+)
+
+// PhoneSendCodeRequest represents the request to send an SMS verification code
+type PhoneSendCodeRequest struct {
+	Phone1 string `json:"phone1" validate:"required,len=3,numeric"`
+	Phone2 string `json:"phone2" validate:"required,min=1,max=4,numeric"`
+	Phone3 string `json:"phone3" validate:"required,len=4,numeric"`
+}
+
+// PhoneSendCodeResponse identifies the code sent to the phone number
+type PhoneSendCodeResponse struct {
+	VerificationID string    `json:"verification_id"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	// ResendAfter is the earliest time another code can be sent to the number
+	ResendAfter time.Time `json:"resend_after"`
+}
+
+// PhoneVerifyCodeRequest represents the request to verify an SMS code
+type PhoneVerifyCodeRequest struct {
+	VerificationID string `json:"verification_id" validate:"required,max=64"`
+	Code           string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// PhoneVerifyCodeResponse confirms the phone number was verified
+type PhoneVerifyCodeResponse struct {
+	PhoneVerified bool `json:"phone_verified"`
+}
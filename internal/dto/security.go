@@ -0,0 +1,57 @@
+// Package dto defines data transfer objects for security event review.
+package dto
+
+import "time"
+
+// SecurityEventListRequest filters recorded security events by client IP and type
+type SecurityEventListRequest struct {
+	ClientIP  string `form:"client_ip" validate:"omitempty,ip"`
+	EventType string `form:"event_type" validate:"omitempty,oneof=csrf_failure rate_limited validation_failure"`
+	Limit     int    `form:"limit" validate:"omitempty,min=1,max=100"`
+	Offset    int    `form:"offset" validate:"omitempty,min=0"`
+}
+
+// SecurityEventResponse represents a request rejected as a CSRF failure, a rate limit hit or
+// invalid input
+type SecurityEventResponse struct {
+	ID         int64     `json:"id"`
+	ClientIP   string    `json:"client_ip"`
+	EventType  string    `json:"event_type"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	StatusCode int       `json:"status_code"`
+	RequestID  *string   `json:"request_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// SecurityEventListResponse lists security events, newest first
+type SecurityEventListResponse struct {
+	Events []*SecurityEventResponse `json:"events"`
+}
+
+// IPBlockResponse represents a client IP whose requests are rejected
+type IPBlockResponse struct {
+	ClientIP string `json:"client_ip"`
+	// Reason is the event type whose repetition caused the block
+	Reason       string    `json:"reason"`
+	EventCount   int       `json:"event_count"`
+	BlockedUntil time.Time `json:"blocked_until"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// IPBlockListResponse lists the client IPs currently blocked, latest expiry first
+type IPBlockListResponse struct {
+	Blocks []*IPBlockResponse `json:"blocks"`
+}
+
+// BotDetectionStatsResponse reports the registrations rejected as automated since the server
+// started
+type BotDetectionStatsResponse struct {
+	Enabled     bool   `json:"enabled"`
+	MinFillTime string `json:"min_fill_time"`
+	// Blocked counts rejections by reason (honeypot, too_fast, missing_token, invalid_token,
+	// expired_token)
+	Blocked map[string]int64 `json:"blocked"`
+	Total   int64            `json:"total"`
+}
@@ -2,39 +2,160 @@
 package dto
 
 import (
+	"bytes"
+	"encoding/json"
 	"time"
 )
 
 // SessionCreateRequest represents the request for creating a session
 type SessionCreateRequest struct {
-	UserData map[string]interface{} `json:"user_data" validate:"required"`
+	UserData *SessionFormData `json:"user_data" validate:"required"`
+}
+
+// SessionFormData represents the form data saved in a session: the fields of a user
+// registration request, each optional as the form is filled in step by step. Tokens and the
+// honeypot field of the registration request are not part of it.
+type SessionFormData struct {
+	LastName      *string                      `json:"last_name,omitempty"`
+	FirstName     *string                      `json:"first_name,omitempty"`
+	LastNameKana  *string                      `json:"last_name_kana,omitempty"`
+	FirstNameKana *string                      `json:"first_name_kana,omitempty"`
+	Phone1        *string                      `json:"phone1,omitempty"`
+	Phone2        *string                      `json:"phone2,omitempty"`
+	Phone3        *string                      `json:"phone3,omitempty"`
+	PostalCode1   *string                      `json:"postal_code1,omitempty"`
+	PostalCode2   *string                      `json:"postal_code2,omitempty"`
+	Prefecture    *string                      `json:"prefecture,omitempty"`
+	City          *string                      `json:"city,omitempty"`
+	Town          *string                      `json:"town,omitempty"`
+	Chome         *string                      `json:"chome,omitempty"`
+	Banchi        *string                      `json:"banchi,omitempty"`
+	Go            *string                      `json:"go,omitempty"`
+	Building      *string                      `json:"building,omitempty"`
+	Room          *string                      `json:"room,omitempty"`
+	Email         *string                      `json:"email,omitempty"`
+	EmailConfirm  *string                      `json:"email_confirm,omitempty"`
+	PlanType      *string                      `json:"plan_type,omitempty"`
+	OptionTypes   []string                     `json:"option_types,omitempty"`
+	OptionDetails map[string]SessionFormOption `json:"option_details,omitempty"`
+	Locale        *string                      `json:"locale,omitempty"`
+}
+
+// SessionFormOption represents the saved settings of a selected option
+type SessionFormOption struct {
+	Quantity  *int    `json:"quantity,omitempty"`
+	StartDate *string `json:"start_date,omitempty"`
+}
+
+// UnmarshalJSON decodes form data, rejecting members that are not form fields so that
+// misspelled or unexpected fields are reported instead of silently dropped
+func (d *SessionFormData) UnmarshalJSON(data []byte) error {
+	// The alias has the fields but not the method, so decoding it does not recurse
+	type sessionFormData SessionFormData
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode((*sessionFormData)(d))
+}
+
+// SessionLifetime describes how long a session lives. ExpiresAt moves forward by the idle
+// timeout on every save but never past MaxExpiresAt.
+type SessionLifetime struct {
+	IdleTimeoutSeconds int64      `json:"idle_timeout_seconds"`
+	MaxLifetimeSeconds int64      `json:"max_lifetime_seconds,omitempty"`
+	MaxExpiresAt       *time.Time `json:"max_expires_at,omitempty"`
 }
 
 // SessionCreateResponse represents the response for session creation
 type SessionCreateResponse struct {
 	SessionID string    `json:"session_id"`
+	Version   int       `json:"version"`
 	ExpiresAt time.Time `json:"expires_at"`
+	SessionLifetime
+
+	// FormToken records when the form was issued; registrations send it back as form_token.
+	// It is only issued when bot detection is enabled.
+	FormToken string `json:"form_token,omitempty"`
 }
 
 // SessionUpdateRequest represents the request for updating a session
 type SessionUpdateRequest struct {
-	UserData map[string]interface{} `json:"user_data" validate:"required"`
+	UserData *SessionFormData `json:"user_data" validate:"required"`
+}
+
+// SessionFieldsUpdateRequest represents an autosave of individual form fields. Fields are
+// merged into the saved data by top-level key and a null value removes the key. Version must
+// be the version last read or saved by the caller.
+type SessionFieldsUpdateRequest struct {
+	Fields  map[string]interface{} `json:"fields" validate:"required,min=1"`
+	Version int                    `json:"version" validate:"required,min=1"`
 }
 
 // SessionUpdateResponse represents the response for session update
 type SessionUpdateResponse struct {
 	SessionID string    `json:"session_id"`
+	Version   int       `json:"version"`
 	ExpiresAt time.Time `json:"expires_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	SessionLifetime
 }
 
 // SessionGetResponse represents the response for session retrieval
 type SessionGetResponse struct {
-	SessionID string                 `json:"session_id"`
-	UserData  map[string]interface{} `json:"user_data"`
-	ExpiresAt time.Time              `json:"expires_at"`
-	CreatedAt time.Time              `json:"created_at"`
-	UpdatedAt time.Time              `json:"updated_at"`
+	SessionID string          `json:"session_id"`
+	UserData  SessionFormData `json:"user_data"`
+	Version   int             `json:"version"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	SessionLifetime
+}
+
+// WizardStepResponse represents the status of a form wizard step. Status is "pending",
+// "valid", "invalid" or "stale" (valid, but its fields were edited afterwards).
+type WizardStepResponse struct {
+	Step        string            `json:"step"`
+	Status      string            `json:"status"`
+	Errors      map[string]string `json:"errors,omitempty"`
+	ValidatedAt *time.Time        `json:"validated_at,omitempty"`
+}
+
+// WizardProgressResponse represents the progress of a session through the form wizard
+type WizardProgressResponse struct {
+	SessionID string `json:"session_id"`
+	// CurrentStep is the first step that is not valid; it is omitted once all steps are valid
+	CurrentStep string               `json:"current_step,omitempty"`
+	Complete    bool                 `json:"complete"`
+	Steps       []WizardStepResponse `json:"steps"`
+}
+
+// SessionSummaryRequest selects how the form summary is formatted. DateStyle overrides the
+// date style of the channel.
+type SessionSummaryRequest struct {
+	Channel   string `form:"channel"`
+	DateStyle string `form:"date_style"`
+}
+
+// SessionSummaryResponse represents saved form data formatted for a confirmation screen or
+// document. Values are display strings and fields not yet entered are empty.
+type SessionSummaryResponse struct {
+	SessionID   string                 `json:"session_id"`
+	Channel     string                 `json:"channel"`
+	Name        string                 `json:"name"`
+	NameKana    string                 `json:"name_kana"`
+	PhoneNumber string                 `json:"phone_number"`
+	PostalCode  string                 `json:"postal_code"`
+	Address     string                 `json:"address"`
+	Email       string                 `json:"email"`
+	PlanType    string                 `json:"plan_type"`
+	Options     []SessionSummaryOption `json:"options"`
+	ExpiresAt   string                 `json:"expires_at"`
+}
+
+// SessionSummaryOption represents a selected option in the form summary
+type SessionSummaryOption struct {
+	OptionType string `json:"option_type"`
+	Quantity   string `json:"quantity"`
+	StartDate  string `json:"start_date,omitempty"`
 }
 
 // SessionDeleteResponse represents the response for session deletion
@@ -0,0 +1,73 @@
+// Package dto defines data transfer objects for admin statistics.
+package dto
+
+// AdminFunnelRequest selects the sessions of the registration funnel by the day they started
+// (UTC). Both days are included; the default is the last 30 days.
+type AdminFunnelRequest struct {
+	From string `form:"from" validate:"omitempty,datetime=2006-01-02"`
+	To   string `form:"to" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// AdminFunnelStage is a stage of the registration funnel: started, a wizard step or submitted
+type AdminFunnelStage struct {
+	Stage string `json:"stage"`
+	// Sessions is the number of sessions that reached the stage
+	Sessions int64 `json:"sessions"`
+	// ReachRate is the share of started sessions that reached the stage
+	ReachRate float64 `json:"reach_rate"`
+	// DropOffRate is the share of sessions at the stage that did not reach the next one;
+	// omitted for the last stage
+	DropOffRate *float64 `json:"drop_off_rate,omitempty"`
+	// FailedSessions is the number of sessions whose validation of a wizard step failed at
+	// least once
+	FailedSessions *int64 `json:"failed_sessions,omitempty"`
+	// MedianSecondsFromStart is the median time from the start of a session to the stage
+	MedianSecondsFromStart *float64 `json:"median_seconds_from_start,omitempty"`
+}
+
+// AdminFunnelResponse reports how far the sessions started in a period got through the form
+type AdminFunnelResponse struct {
+	From           string             `json:"from"`
+	To             string             `json:"to"`
+	Started        int64              `json:"started"`
+	Submitted      int64              `json:"submitted"`
+	ConversionRate float64            `json:"conversion_rate"`
+	Stages         []AdminFunnelStage `json:"stages"`
+}
+
+// AdminValidationFailuresRequest selects the validation failures of registration data by day
+// (UTC) and, optionally, form stage. Both days are included; the default is the last 30 days.
+type AdminValidationFailuresRequest struct {
+	From  string `form:"from" validate:"omitempty,datetime=2006-01-02"`
+	To    string `form:"to" validate:"omitempty,datetime=2006-01-02"`
+	Stage string `form:"stage" validate:"omitempty,max=50"`
+}
+
+// AdminValidationFailure is a field rejected for a failure code at a form stage
+type AdminValidationFailure struct {
+	Field string `json:"field"`
+	// Code is the failure category, e.g. required, katakana, max or rule_pattern
+	Code  string `json:"code"`
+	Count int64  `json:"count"`
+	// Rate is the share of the stage's validations that rejected the field for the code
+	Rate float64 `json:"rate"`
+}
+
+// AdminValidationStage reports the validations at a form stage: a wizard step, validate or submit
+type AdminValidationStage struct {
+	Stage    string `json:"stage"`
+	Attempts int64  `json:"attempts"`
+	// Failed is the number of validations with at least one error
+	Failed      int64   `json:"failed"`
+	FailureRate float64 `json:"failure_rate"`
+	// Failures are listed most frequent first
+	Failures []AdminValidationFailure `json:"failures"`
+}
+
+// AdminValidationFailuresResponse reports which fields failed validation for which reasons
+// in a period
+type AdminValidationFailuresResponse struct {
+	From   string                  `json:"from"`
+	To     string                  `json:"to"`
+	Stages []*AdminValidationStage `json:"stages"`
+}
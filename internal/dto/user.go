@@ -3,6 +3,9 @@ package dto
 
 import (
 	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/masking"
+	"github.com/octop162/normal-form-app-by-claude/pkg/money"
 )
 
 // UserCreateRequest represents the request for user registration
@@ -26,8 +29,42 @@ type UserCreateRequest struct {
 	Room          *string  `json:"room" validate:"omitempty,max=20"`
 	Email         string   `json:"email" validate:"required,email,max=256"`
 	EmailConfirm  string   `json:"email_confirm" validate:"required,eqfield=Email"`
-	PlanType      string   `json:"plan_type" validate:"required,oneof=A B"`
+	PlanType      string   `json:"plan_type" validate:"required,alphanum,max=10"`
 	OptionTypes   []string `json:"option_types" validate:"dive,oneof=AA BB AB"`
+
+	// OptionDetails holds the settings of selected options keyed by option type; options
+	// without an entry are ordered once, starting as soon as possible
+	OptionDetails map[string]UserOptionDetail `json:"option_details,omitempty" validate:"omitempty,dive"`
+
+	// Locale is the language the user is addressed in (ja, en). On registration it takes
+	// precedence over Accept-Language; other requests ignore it.
+	Locale string `json:"locale,omitempty" validate:"omitempty,oneof=ja en"`
+
+	// CaptchaToken is the token the captcha widget returned. Registrations and their
+	// validation require it unless the captcha driver is none; other requests ignore it.
+	CaptchaToken string `json:"captcha_token,omitempty"`
+
+	// Website is a honeypot: the form hides it from people, so registrations that fill it in
+	// are rejected as automated when bot detection is enabled
+	Website string `json:"website,omitempty"`
+	// FormToken is the form_token issued with the form session. Registrations require it when
+	// bot detection is enabled; other requests ignore it.
+	FormToken string `json:"form_token,omitempty"`
+}
+
+// UserOptionDetail represents the settings of a selected option
+type UserOptionDetail struct {
+	// Quantity defaults to 1 when omitted
+	Quantity int `json:"quantity" validate:"omitempty,min=1"`
+	// StartDate is the desired start date (YYYY-MM-DD)
+	StartDate *string `json:"start_date" validate:"omitempty,datetime=2006-01-02"`
+}
+
+// UserOptionSummary represents a selected option and its settings in API responses
+type UserOptionSummary struct {
+	OptionType string  `json:"option_type"`
+	Quantity   int     `json:"quantity"`
+	StartDate  *string `json:"start_date,omitempty"`
 }
 
 // UserCreateResponse represents the response for user registration
@@ -45,6 +82,56 @@ type UserValidateRequest struct {
 type UserValidateResponse struct {
 	Valid  bool              `json:"valid"`
 	Errors map[string]string `json:"errors,omitempty"`
+
+	// Codes holds the failure code of every error, such as required or katakana, for
+	// validation telemetry; it is not sent to clients
+	Codes map[string]string `json:"-"`
+}
+
+// UserUpdateRequest represents the replacement of a user
+type UserUpdateRequest struct {
+	UserCreateRequest
+
+	// Version is the version of the user the update is based on; the update is rejected with
+	// a conflict if the user has changed since. Omitted, the update applies to any version.
+	Version *int `json:"version,omitempty" validate:"omitempty,min=1"`
+}
+
+// UserPatchRequest represents a partial user update. Omitted fields keep their stored
+// values; an empty string clears an optional address field.
+type UserPatchRequest struct {
+	LastName      *string   `json:"last_name" validate:"omitempty,min=1,max=15"`
+	FirstName     *string   `json:"first_name" validate:"omitempty,min=1,max=15"`
+	LastNameKana  *string   `json:"last_name_kana" validate:"omitempty,min=1,max=15,katakana"`
+	FirstNameKana *string   `json:"first_name_kana" validate:"omitempty,min=1,max=15,katakana"`
+	Phone1        *string   `json:"phone1" validate:"omitempty,len=3,numeric"`
+	Phone2        *string   `json:"phone2" validate:"omitempty,min=1,max=4,numeric"`
+	Phone3        *string   `json:"phone3" validate:"omitempty,len=4,numeric"`
+	PostalCode1   *string   `json:"postal_code1" validate:"omitempty,len=3,numeric"`
+	PostalCode2   *string   `json:"postal_code2" validate:"omitempty,len=4,numeric"`
+	Prefecture    *string   `json:"prefecture" validate:"omitempty,min=1,max=10"`
+	City          *string   `json:"city" validate:"omitempty,min=1,max=50"`
+	Town          *string   `json:"town" validate:"omitempty,max=50"`
+	Chome         *string   `json:"chome" validate:"omitempty,max=10"`
+	Banchi        *string   `json:"banchi" validate:"omitempty,min=1,max=10"`
+	Go            *string   `json:"go" validate:"omitempty,max=10"`
+	Building      *string   `json:"building" validate:"omitempty,max=100"`
+	Room          *string   `json:"room" validate:"omitempty,max=20"`
+	Email         *string   `json:"email" validate:"omitempty,email,max=256"`
+	EmailConfirm  *string   `json:"email_confirm"`
+	PlanType      *string   `json:"plan_type" validate:"omitempty,alphanum,max=10"`
+	OptionTypes   *[]string `json:"option_types" validate:"omitempty,dive,oneof=AA BB AB"`
+
+	// OptionDetails is merged into the stored settings by option type
+	OptionDetails map[string]UserOptionDetail `json:"option_details,omitempty" validate:"omitempty,dive"`
+
+	// Version is the version of the user the patch is based on, as in UserUpdateRequest
+	Version *int `json:"version,omitempty" validate:"omitempty,min=1"`
+}
+
+// UserDeleteRequest represents the optional body of a user deletion request
+type UserDeleteRequest struct {
+	Reason string `json:"reason"`
 }
 
 // UserResponse represents a user in API responses
@@ -55,10 +142,58 @@ type UserResponse struct {
 	LastNameKana  string    `json:"last_name_kana"`
 	FirstNameKana string    `json:"first_name_kana"`
 	PhoneNumber   string    `json:"phone_number"`
+	PhoneVerified bool      `json:"phone_verified"`
 	PostalCode    string    `json:"postal_code"`
 	Address       string    `json:"address"`
 	Email         string    `json:"email"`
 	PlanType      string    `json:"plan_type"`
+	Locale        string    `json:"locale"`
+	Status        string    `json:"status"`
+	Version       int       `json:"version"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
+	// PricingSnapshot holds the prices agreed to at registration; users registered before
+	// prices were recorded have none
+	PricingSnapshot *UserPricingSnapshotResponse `json:"pricing_snapshot,omitempty"`
+}
+
+// UserPricingSnapshotResponse represents the monthly prices a user agreed to at registration,
+// in the shape of a quote. Later changes to prices or the tax rate do not alter it.
+type UserPricingSnapshotResponse struct {
+	PlanType string      `json:"plan_type"`
+	Lines    []QuoteLine `json:"lines"`
+	Subtotal money.Yen   `json:"subtotal"`
+	// TaxRate is the rate in effect at registration, e.g. "10%"
+	TaxRate  string    `json:"tax_rate"`
+	Tax      money.Yen `json:"tax"`
+	Total    money.Yen `json:"total"`
+	PricedAt time.Time `json:"priced_at"`
+}
+
+// Mask applies the masking policy for the caller role to the personal data fields
+func (r *UserResponse) Mask(policy *masking.Policy, role string) {
+	r.LastName = policy.Mask(role, masking.FieldName, r.LastName)
+	r.FirstName = policy.Mask(role, masking.FieldName, r.FirstName)
+	r.LastNameKana = policy.Mask(role, masking.FieldName, r.LastNameKana)
+	r.FirstNameKana = policy.Mask(role, masking.FieldName, r.FirstNameKana)
+	r.PhoneNumber = policy.Mask(role, masking.FieldPhone, r.PhoneNumber)
+	r.PostalCode = policy.Mask(role, masking.FieldPostalCode, r.PostalCode)
+	r.Address = policy.Mask(role, masking.FieldAddress, r.Address)
+	r.Email = policy.Mask(role, masking.FieldEmail, r.Email)
+}
+
+// UserFieldChange describes a single field that would change on update
+type UserFieldChange struct {
+	Field    string      `json:"field"`
+	Current  interface{} `json:"current"`
+	Proposed interface{} `json:"proposed"`
+}
+
+// UserUpdatePreviewResponse represents the response for a user update preview
+type UserUpdatePreviewResponse struct {
+	UserID     int               `json:"user_id"`
+	HasChanges bool              `json:"has_changes"`
+	Changes    []UserFieldChange `json:"changes"`
+	Valid      bool              `json:"valid"`
+	Errors     map[string]string `json:"errors,omitempty"`
 }
@@ -0,0 +1,19 @@
+// Package dto defines data transfer objects for queued user registration.
+package dto
+
+import "time"
+
+// UserCreateTicketResponse represents a registration accepted while user creation is queued
+type UserCreateTicketResponse struct {
+	TicketID string `json:"ticket_id"`
+	// Status is queued, processing, completed or failed
+	Status string `json:"status"`
+	// StatusURL is polled until the status is completed or failed
+	StatusURL string `json:"status_url"`
+	// UserID is the created user once the status is completed
+	UserID *int `json:"user_id,omitempty"`
+	// Error is the error POST /users would have returned once the status is failed
+	Error       *APIError  `json:"error,omitempty"`
+	AcceptedAt  time.Time  `json:"accepted_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
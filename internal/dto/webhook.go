@@ -0,0 +1,37 @@
+// Package dto defines data transfer objects for webhook delivery administration.
+package dto
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AdminWebhookDeliveryListRequest represents the admin query for webhook deliveries by status
+type AdminWebhookDeliveryListRequest struct {
+	Status string `form:"status" validate:"omitempty,oneof=pending delivered dead"`
+	Limit  int    `form:"limit" validate:"omitempty,min=1,max=100"`
+	Offset int    `form:"offset" validate:"omitempty,min=0"`
+}
+
+// AdminWebhookDeliveryResponse is the admin view of a queued webhook delivery
+type AdminWebhookDeliveryResponse struct {
+	ID            int64           `json:"id"`
+	EventID       int64           `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	CorrelationID *string         `json:"correlation_id"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Status        string          `json:"status"`
+	Attempts      int             `json:"attempts"`
+	LastError     *string         `json:"last_error"`
+	// NextAttemptAt is when a pending delivery is next sent
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	DeadAt        *time.Time `json:"dead_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// AdminWebhookDeliveryListResponse lists webhook deliveries for administrators
+type AdminWebhookDeliveryListResponse struct {
+	Deliveries []*AdminWebhookDeliveryResponse `json:"deliveries"`
+}
@@ -34,7 +34,7 @@ func (h *AddressHandler) SearchAddress(c *gin.Context) {
 			Error: &dto.APIError{
 				Code:    ErrorCodeInvalidRequest,
 				Message: "Invalid query parameters",
-				Details: map[string]string{"bind_error": err.Error()},
+				Details: bindErrorDetails(err),
 			},
 		})
 		return
@@ -43,6 +43,10 @@ func (h *AddressHandler) SearchAddress(c *gin.Context) {
 	// Search address by postal code
 	resp, err := h.addressService.SearchByPostalCode(c.Request.Context(), &req)
 	if err != nil {
+		if isExternalAPIError(err) {
+			handleServiceError(c, err, h.log, "search address", ErrorCodeAddressSearchFailed)
+			return
+		}
 		h.log.WithError(err).Error("Failed to search address")
 		c.JSON(http.StatusInternalServerError, dto.APIResponse{
 			Success: false,
@@ -60,6 +64,50 @@ func (h *AddressHandler) SearchAddress(c *gin.Context) {
 	})
 }
 
+// ReverseSearchAddress handles GET /api/v1/address/reverse-search
+func (h *AddressHandler) ReverseSearchAddress(c *gin.Context) {
+	var req dto.AddressReverseSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithBindError(c, err, h.log, "address reverse search")
+		return
+	}
+
+	resp, err := h.addressService.ReverseSearch(c.Request.Context(), &req)
+	if err != nil {
+		if isValidationError(err) {
+			handleServiceError(c, err, h.log, "search postal codes by address", ErrorCodeAddressSearchFailed)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrorCodeAddressSearchFailed,
+			"Failed to search postal codes", h.log, err)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// SearchBuildings handles GET /api/v1/address/buildings
+func (h *AddressHandler) SearchBuildings(c *gin.Context) {
+	var req dto.BuildingSearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithBindError(c, err, h.log, "building search")
+		return
+	}
+
+	resp, err := h.addressService.SearchBuildings(c.Request.Context(), &req)
+	if err != nil {
+		if isValidationError(err) {
+			handleServiceError(c, err, h.log, "search buildings", ErrorCodeAddressSearchFailed)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrorCodeAddressSearchFailed,
+			"Failed to search buildings", h.log, err)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
 // CheckRegion handles POST /api/v1/region/check
 func (h *AddressHandler) CheckRegion(c *gin.Context) {
 	var req dto.RegionCheckRequest
@@ -70,7 +118,7 @@ func (h *AddressHandler) CheckRegion(c *gin.Context) {
 			Error: &dto.APIError{
 				Code:    ErrorCodeInvalidRequest,
 				Message: "Invalid request format",
-				Details: map[string]string{"bind_error": err.Error()},
+				Details: bindErrorDetails(err),
 			},
 		})
 		return
@@ -79,6 +127,10 @@ func (h *AddressHandler) CheckRegion(c *gin.Context) {
 	// Check region restrictions
 	resp, err := h.addressService.CheckRegionRestrictions(c.Request.Context(), &req)
 	if err != nil {
+		if isExternalAPIError(err) {
+			handleServiceError(c, err, h.log, "check region restrictions", ErrorCodeRegionCheckFailed)
+			return
+		}
 		h.log.WithError(err).Error("Failed to check region restrictions")
 		c.JSON(http.StatusInternalServerError, dto.APIResponse{
 			Success: false,
@@ -0,0 +1,596 @@
+// Package handler provides HTTP handlers for operational admin endpoints.
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/pkg/external"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/lru"
+	"github.com/octop162/normal-form-app-by-claude/pkg/masking"
+)
+
+// MemoryStores names the bounded in-process stores reported by the admin API
+type MemoryStores map[string]lru.StatsReporter
+
+// AdminHandler handles admin HTTP requests
+type AdminHandler struct {
+	externalAPI        *external.Manager
+	adminUserService   service.AdminUserService
+	adminOptionService service.AdminOptionService
+	adminRegionService service.AdminRegionService
+	adminPlanService   service.AdminPlanService
+	adminEventService  service.AdminEventService
+	reservationService service.AdminReservationService
+	masterDataCache    repository.CacheInvalidator
+	featureFlags       *service.FeatureFlags
+	validationRules    *service.ValidationRules
+	memoryStores       MemoryStores
+	masking            *masking.Policy
+	log                *logger.Logger
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(
+	externalAPI *external.Manager,
+	adminUserService service.AdminUserService,
+	adminOptionService service.AdminOptionService,
+	adminRegionService service.AdminRegionService,
+	adminPlanService service.AdminPlanService,
+	adminEventService service.AdminEventService,
+	reservationService service.AdminReservationService,
+	masterDataCache repository.CacheInvalidator,
+	featureFlags *service.FeatureFlags,
+	validationRules *service.ValidationRules,
+	memoryStores MemoryStores,
+	maskingPolicy *masking.Policy,
+	log *logger.Logger,
+) *AdminHandler {
+	return &AdminHandler{
+		externalAPI:        externalAPI,
+		adminUserService:   adminUserService,
+		adminOptionService: adminOptionService,
+		adminRegionService: adminRegionService,
+		adminPlanService:   adminPlanService,
+		adminEventService:  adminEventService,
+		reservationService: reservationService,
+		masterDataCache:    masterDataCache,
+		featureFlags:       featureFlags,
+		validationRules:    validationRules,
+		memoryStores:       memoryStores,
+		masking:            maskingPolicy,
+		log:                log,
+	}
+}
+
+// GetExternalAPIs handles GET /api/v1/admin/external-apis
+func (h *AdminHandler) GetExternalAPIs(c *gin.Context) {
+	respondWithSuccess(c, http.StatusOK, &dto.ExternalAPIsResponse{
+		APIs: h.externalAPI.ClientStatuses(),
+	})
+}
+
+// SetCircuitBreakerOverride handles POST /api/v1/admin/external-apis/:name/circuit-breaker
+func (h *AdminHandler) SetCircuitBreakerOverride(c *gin.Context) {
+	name := c.Param("name")
+
+	var req dto.CircuitBreakerOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "circuit breaker override")
+		return
+	}
+
+	status, err := h.externalAPI.SetCircuitOverride(name, external.CircuitOverride(req.Override))
+	if err != nil {
+		h.respondWithExternalAPIError(c, err, name)
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).
+		WithField("client", name).
+		WithField("override", req.Override).
+		WithField("client_ip", c.ClientIP()).
+		Warn("Admin changed circuit breaker override")
+
+	respondWithSuccess(c, http.StatusOK, status)
+}
+
+// ProbeExternalAPI handles POST /api/v1/admin/external-apis/:name/probe
+func (h *AdminHandler) ProbeExternalAPI(c *gin.Context) {
+	name := c.Param("name")
+
+	result, err := h.externalAPI.Probe(c.Request.Context(), name)
+	if err != nil {
+		h.respondWithExternalAPIError(c, err, name)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, result)
+}
+
+// BulkUpdateUserStatus handles POST /api/v1/admin/users/bulk-status
+func (h *AdminHandler) BulkUpdateUserStatus(c *gin.Context) {
+	var req dto.BulkUserStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "bulk user status")
+		return
+	}
+
+	// Per-user failures are reported in the response body rather than as an error status
+	resp, err := h.adminUserService.BulkUpdateStatus(c.Request.Context(), &req, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "bulk update user status", ErrorCodeUserNotFound)
+		return
+	}
+
+	h.log.WithContext(c.Request.Context()).
+		WithField("action", resp.Action).
+		WithField("updated", resp.Updated).
+		WithField("client_ip", c.ClientIP()).
+		Warn("Admin changed user statuses")
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// GetUser handles GET /api/v1/admin/users/:id
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	idParam := c.Param("id")
+	userID, err := strconv.Atoi(idParam)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrorCodeInvalidUserID, "User ID must be a valid integer", nil, nil)
+		return
+	}
+
+	resp, err := h.adminUserService.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		handleServiceError(c, err, h.log, "get user", ErrorCodeUserNotFound)
+		return
+	}
+
+	resp.Mask(h.masking, masking.RoleFromContext(c.Request.Context()))
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// GetUserDuplicates handles GET /api/v1/admin/users/:id/duplicates
+func (h *AdminHandler) GetUserDuplicates(c *gin.Context) {
+	idParam := c.Param("id")
+	userID, err := strconv.Atoi(idParam)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrorCodeInvalidUserID, "User ID must be a valid integer", nil, nil)
+		return
+	}
+
+	resp, err := h.adminUserService.FindDuplicates(c.Request.Context(), userID)
+	if err != nil {
+		handleServiceError(c, err, h.log, "find duplicate users", ErrorCodeUserNotFound)
+		return
+	}
+
+	resp.Mask(h.masking, masking.RoleFromContext(c.Request.Context()))
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// SetUserLegalHold handles PUT /api/v1/admin/users/:id/legal-hold
+func (h *AdminHandler) SetUserLegalHold(c *gin.Context) {
+	idParam := c.Param("id")
+	userID, err := strconv.Atoi(idParam)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrorCodeInvalidUserID, "User ID must be a valid integer", nil, nil)
+		return
+	}
+
+	var req dto.LegalHoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "legal hold")
+		return
+	}
+
+	resp, err := h.adminUserService.SetLegalHold(c.Request.Context(), userID, &req, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "set user legal hold", ErrorCodeUserNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// InvalidateMasterDataCache handles POST /api/v1/admin/master-data/cache/invalidate
+func (h *AdminHandler) InvalidateMasterDataCache(c *gin.Context) {
+	h.masterDataCache.Invalidate()
+
+	h.log.WithContext(c.Request.Context()).
+		WithField("client_ip", c.ClientIP()).
+		Warn("Admin invalidated master data cache")
+
+	respondWithSuccess(c, http.StatusOK, map[string]string{"message": "Master data cache invalidated"})
+}
+
+// ListOptions handles GET /api/v1/admin/options
+func (h *AdminHandler) ListOptions(c *gin.Context) {
+	resp, err := h.adminOptionService.ListOptions(c.Request.Context())
+	if err != nil {
+		handleServiceError(c, err, h.log, "list options", ErrorCodeOptionNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// CreateOption handles POST /api/v1/admin/options
+func (h *AdminHandler) CreateOption(c *gin.Context) {
+	var req dto.AdminOptionCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "option create")
+		return
+	}
+
+	resp, err := h.adminOptionService.CreateOption(c.Request.Context(), &req, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "create option", ErrorCodeOptionNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusCreated, resp)
+}
+
+// UpdateOption handles PUT /api/v1/admin/options/:type
+func (h *AdminHandler) UpdateOption(c *gin.Context) {
+	var req dto.AdminOptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "option update")
+		return
+	}
+
+	resp, err := h.adminOptionService.UpdateOption(c.Request.Context(), c.Param("type"), &req, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "update option", ErrorCodeOptionNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// DeleteOption handles DELETE /api/v1/admin/options/:type
+func (h *AdminHandler) DeleteOption(c *gin.Context) {
+	err := h.adminOptionService.DeleteOption(c.Request.Context(), c.Param("type"), c.ClientIP())
+	if errors.Is(err, service.ErrOptionInUse) {
+		respondWithError(c, http.StatusConflict, ErrorCodeOptionInUse, MessageOptionInUse, nil, nil)
+		return
+	}
+	if err != nil {
+		handleServiceError(c, err, h.log, "delete option", ErrorCodeOptionNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, map[string]string{"message": "Option deleted"})
+}
+
+// ListRegionRestrictions handles GET /api/v1/admin/region-restrictions
+func (h *AdminHandler) ListRegionRestrictions(c *gin.Context) {
+	var req dto.AdminRegionRestrictionListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithBindError(c, err, h.log, "region restriction list")
+		return
+	}
+
+	resp, err := h.adminRegionService.ListRegionRestrictions(c.Request.Context(), &req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "list region restrictions", ErrorCodeRegionRestrictionNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// CreateRegionRestriction handles POST /api/v1/admin/region-restrictions
+func (h *AdminHandler) CreateRegionRestriction(c *gin.Context) {
+	var req dto.AdminRegionRestrictionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "region restriction create")
+		return
+	}
+
+	resp, err := h.adminRegionService.CreateRegionRestriction(c.Request.Context(), &req, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "create region restriction", ErrorCodeRegionRestrictionNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusCreated, resp)
+}
+
+// UpdateRegionRestriction handles PUT /api/v1/admin/region-restrictions/:id
+func (h *AdminHandler) UpdateRegionRestriction(c *gin.Context) {
+	id, ok := h.regionRestrictionID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.AdminRegionRestrictionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "region restriction update")
+		return
+	}
+
+	resp, err := h.adminRegionService.UpdateRegionRestriction(c.Request.Context(), id, &req, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "update region restriction", ErrorCodeRegionRestrictionNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// DeleteRegionRestriction handles DELETE /api/v1/admin/region-restrictions/:id
+func (h *AdminHandler) DeleteRegionRestriction(c *gin.Context) {
+	id, ok := h.regionRestrictionID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.adminRegionService.DeleteRegionRestriction(c.Request.Context(), id, c.ClientIP()); err != nil {
+		handleServiceError(c, err, h.log, "delete region restriction", ErrorCodeRegionRestrictionNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, map[string]string{"message": "Region restriction deleted"})
+}
+
+// regionRestrictionID parses the :id parameter, responding with 400 when it is not a number
+func (h *AdminHandler) regionRestrictionID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrorCodeInvalidRegionRestrictionID,
+			"Region restriction ID must be a valid integer", nil, nil)
+		return 0, false
+	}
+	return id, true
+}
+
+// ListPlans handles GET /api/v1/admin/plans
+func (h *AdminHandler) ListPlans(c *gin.Context) {
+	resp, err := h.adminPlanService.ListPlans(c.Request.Context())
+	if err != nil {
+		handleServiceError(c, err, h.log, "list plans", ErrorCodePlanNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// CreatePlan handles POST /api/v1/admin/plans
+func (h *AdminHandler) CreatePlan(c *gin.Context) {
+	var req dto.AdminPlanCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "plan create")
+		return
+	}
+
+	resp, err := h.adminPlanService.CreatePlan(c.Request.Context(), &req, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "create plan", ErrorCodePlanNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusCreated, resp)
+}
+
+// UpdatePlan handles PUT /api/v1/admin/plans/:type
+func (h *AdminHandler) UpdatePlan(c *gin.Context) {
+	var req dto.AdminPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "plan update")
+		return
+	}
+
+	resp, err := h.adminPlanService.UpdatePlan(c.Request.Context(), c.Param("type"), &req, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "update plan", ErrorCodePlanNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// DeactivatePlan handles POST /api/v1/admin/plans/:type/deactivate
+func (h *AdminHandler) DeactivatePlan(c *gin.Context) {
+	resp, err := h.adminPlanService.DeactivatePlan(c.Request.Context(), c.Param("type"), c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "deactivate plan", ErrorCodePlanNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// ListEvents handles GET /api/v1/admin/events
+func (h *AdminHandler) ListEvents(c *gin.Context) {
+	var req dto.AdminEventListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithBindError(c, err, h.log, "event list")
+		return
+	}
+
+	resp, err := h.adminEventService.ListEvents(c.Request.Context(), &req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "list events", ErrorCodeEventNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// ReplayEvent handles POST /api/v1/admin/events/:id/replay
+func (h *AdminHandler) ReplayEvent(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrorCodeInvalidEventID,
+			"Event ID must be a valid integer", nil, nil)
+		return
+	}
+
+	resp, err := h.adminEventService.ReplayEvent(c.Request.Context(), id, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "replay event", ErrorCodeEventNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// ListReservations handles GET /api/v1/admin/reservations
+func (h *AdminHandler) ListReservations(c *gin.Context) {
+	var req dto.AdminReservationListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithBindError(c, err, h.log, "reservation list")
+		return
+	}
+
+	resp, err := h.reservationService.ListReservations(c.Request.Context(), &req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "list reservations", ErrorCodeReservationNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// ReleaseReservation handles POST /api/v1/admin/reservations/:id/release
+func (h *AdminHandler) ReleaseReservation(c *gin.Context) {
+	id, ok := h.reservationID(c)
+	if !ok {
+		return
+	}
+
+	resp, err := h.reservationService.ReleaseReservation(c.Request.Context(), id, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "release reservation", ErrorCodeReservationNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// ExtendReservation handles POST /api/v1/admin/reservations/:id/extend
+func (h *AdminHandler) ExtendReservation(c *gin.Context) {
+	id, ok := h.reservationID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.AdminReservationExtendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "reservation extend")
+		return
+	}
+
+	resp, err := h.reservationService.ExtendReservation(c.Request.Context(), id, &req, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "extend reservation", ErrorCodeReservationNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// reservationID parses the :id parameter, responding with 400 when it is not a number
+func (h *AdminHandler) reservationID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrorCodeInvalidReservationID,
+			"Reservation ID must be a valid integer", nil, nil)
+		return 0, false
+	}
+	return id, true
+}
+
+// GetMemoryStores handles GET /api/v1/admin/memory-stores
+func (h *AdminHandler) GetMemoryStores(c *gin.Context) {
+	resp := &dto.MemoryStoresResponse{Stores: make(map[string]lru.Stats, len(h.memoryStores))}
+	for name, store := range h.memoryStores {
+		resp.Stores[name] = store.Stats()
+	}
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// GetFeatureFlags handles GET /api/v1/admin/feature-flags
+func (h *AdminHandler) GetFeatureFlags(c *gin.Context) {
+	respondWithSuccess(c, http.StatusOK, h.featureFlags.List())
+}
+
+// SetFeatureFlag handles PUT /api/v1/admin/feature-flags/:name
+func (h *AdminHandler) SetFeatureFlag(c *gin.Context) {
+	var req dto.FeatureFlagUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "feature flag update")
+		return
+	}
+
+	resp, err := h.featureFlags.Set(c.Request.Context(), c.Param("name"), &req, c.ClientIP())
+	if err != nil {
+		h.respondWithFeatureFlagError(c, err, "set feature flag")
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// ClearFeatureFlag handles DELETE /api/v1/admin/feature-flags/:name
+func (h *AdminHandler) ClearFeatureFlag(c *gin.Context) {
+	resp, err := h.featureFlags.Clear(c.Request.Context(), c.Param("name"), c.ClientIP())
+	if err != nil {
+		h.respondWithFeatureFlagError(c, err, "clear feature flag")
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// GetValidationRules handles GET /api/v1/admin/validation-rules
+func (h *AdminHandler) GetValidationRules(c *gin.Context) {
+	respondWithSuccess(c, http.StatusOK, h.validationRules.List())
+}
+
+// ReloadValidationRules handles POST /api/v1/admin/validation-rules/reload
+func (h *AdminHandler) ReloadValidationRules(c *gin.Context) {
+	resp, err := h.validationRules.Reload(c.Request.Context(), c.ClientIP())
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidValidationRules) {
+			respondWithError(c, http.StatusUnprocessableEntity, ErrorCodeInvalidValidationRules, err.Error(), nil, nil)
+			return
+		}
+		respondWithError(c, http.StatusInternalServerError, ErrorCodeInternalError, MessageInternalError, h.log, err)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// respondWithFeatureFlagError maps feature flag errors to responses
+func (h *AdminHandler) respondWithFeatureFlagError(c *gin.Context, err error, operation string) {
+	if errors.Is(err, service.ErrUnknownFeatureFlag) {
+		respondWithError(c, http.StatusNotFound, ErrorCodeFeatureFlagNotFound, MessageFeatureFlagNotFound, nil, nil)
+		return
+	}
+	handleServiceError(c, err, h.log, operation, ErrorCodeFeatureFlagNotFound)
+}
+
+// respondWithExternalAPIError maps external API manager errors to responses
+func (h *AdminHandler) respondWithExternalAPIError(c *gin.Context, err error, name string) {
+	switch {
+	case errors.Is(err, external.ErrUnknownClient):
+		respondWithError(c, http.StatusNotFound, ErrorCodeExternalAPINotFound, MessageExternalAPINotFound, nil, nil)
+	case errors.Is(err, external.ErrClientNotConfigured):
+		respondWithError(c, http.StatusConflict, ErrorCodeExternalAPINotConfigured, MessageExternalAPINotConfigured, nil, nil)
+	default:
+		// Remaining errors are caused by invalid input such as an unknown override
+		h.log.WithError(err).WithField("client", name).Warn("Rejected external API admin operation")
+		respondWithError(c, http.StatusBadRequest, ErrorCodeInvalidRequest, err.Error(), nil, nil)
+	}
+}
@@ -0,0 +1,135 @@
+// Package handler provides HTTP handlers for partner API key administration.
+package handler
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// APIKeyHandler handles partner API key administration HTTP requests
+type APIKeyHandler struct {
+	keyService service.APIKeyService
+	log        *logger.Logger
+}
+
+// NewAPIKeyHandler creates a new API key handler
+func NewAPIKeyHandler(keyService service.APIKeyService, log *logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		keyService: keyService,
+		log:        log,
+	}
+}
+
+// ListKeys handles GET /api/v1/admin/api-keys
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	resp, err := h.keyService.ListKeys(c.Request.Context())
+	if err != nil {
+		handleServiceError(c, err, h.log, "list API keys", ErrorCodeAPIKeyNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// CreateKey handles POST /api/v1/admin/api-keys
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	var req dto.AdminAPIKeyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "API key create")
+		return
+	}
+
+	resp, err := h.keyService.CreateKey(c.Request.Context(), &req, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "create API key", ErrorCodeAPIKeyNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusCreated, resp)
+}
+
+// RevokeKey handles POST /api/v1/admin/api-keys/:id/revoke
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrorCodeInvalidAPIKeyID,
+			"API key ID must be a valid integer", nil, nil)
+		return
+	}
+
+	resp, err := h.keyService.RevokeKey(c.Request.Context(), id, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "revoke API key", ErrorCodeAPIKeyNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// GetUsage handles GET /api/v1/admin/api-keys/:id/usage
+func (h *APIKeyHandler) GetUsage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrorCodeInvalidAPIKeyID,
+			"API key ID must be a valid integer", nil, nil)
+		return
+	}
+
+	var req dto.AdminAPIKeyUsageRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithBindError(c, err, h.log, "API key usage")
+		return
+	}
+
+	resp, err := h.keyService.GetUsage(c.Request.Context(), id, &req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "get API key usage", ErrorCodeAPIKeyNotFound)
+		return
+	}
+
+	if req.Format == "csv" {
+		h.writeUsageCSV(c, resp)
+		return
+	}
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// writeUsageCSV writes a usage report as a CSV file, one row per period and endpoint
+func (h *APIKeyHandler) writeUsageCSV(c *gin.Context, resp *dto.AdminAPIKeyUsageResponse) {
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="api-key-%d-usage-%s.csv"`, resp.APIKeyID, resp.Month))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	_ = w.Write([]string{
+		"partner", "api_key_id", "period_start", "endpoint", "requests",
+		"request_bytes", "response_bytes", "client_errors", "server_errors", "error_rate",
+	})
+	for _, entry := range resp.Usage {
+		_ = w.Write([]string{
+			resp.Partner,
+			strconv.FormatInt(resp.APIKeyID, 10),
+			entry.PeriodStart.Format(time.RFC3339),
+			entry.Endpoint,
+			strconv.FormatInt(entry.Requests, 10),
+			strconv.FormatInt(entry.RequestBytes, 10),
+			strconv.FormatInt(entry.ResponseBytes, 10),
+			strconv.FormatInt(entry.ClientErrors, 10),
+			strconv.FormatInt(entry.ServerErrors, 10),
+			strconv.FormatFloat(entry.ErrorRate, 'f', 4, 64),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		h.log.WithContext(c.Request.Context()).WithError(err).Error("Failed to write API key usage CSV")
+	}
+}
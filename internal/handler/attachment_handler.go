@@ -0,0 +1,132 @@
+// Package handler provides HTTP handlers for uploaded attachments.
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/upload"
+)
+
+// attachmentFormField is the multipart field carrying the uploaded file
+const attachmentFormField = "file"
+
+// AttachmentHandler handles attachment HTTP requests
+type AttachmentHandler struct {
+	attachmentService service.AttachmentService
+	log               *logger.Logger
+}
+
+// NewAttachmentHandler creates a new attachment handler
+func NewAttachmentHandler(attachmentService service.AttachmentService, log *logger.Logger) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+		log:               log,
+	}
+}
+
+// UploadAttachment handles POST /api/v1/attachments. The file is streamed from the
+// multipart body to storage without buffering the whole request.
+func (h *AttachmentHandler) UploadAttachment(c *gin.Context) {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		respondWithBindError(c, err, h.log, "attachment upload")
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			respondWithError(c, http.StatusBadRequest, ErrorCodeValidationError, MessageAttachmentFileRequired, nil, nil)
+			return
+		}
+		if err != nil {
+			respondWithBindError(c, err, h.log, "attachment upload")
+			return
+		}
+		if part.FormName() != attachmentFormField || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		resp, err := h.attachmentService.Upload(c.Request.Context(), part.FileName(), part)
+		part.Close()
+		if errors.Is(err, upload.ErrTooLarge) {
+			respondWithError(c, http.StatusRequestEntityTooLarge, ErrorCodeAttachmentTooLarge,
+				MessageAttachmentTooLarge, nil, nil)
+			return
+		}
+		if err != nil {
+			handleServiceError(c, err, h.log, "upload attachment", ErrorCodeAttachmentNotFound)
+			return
+		}
+
+		respondWithSuccess(c, http.StatusAccepted, resp)
+		return
+	}
+}
+
+// GetAttachment handles GET /api/v1/attachments/:id
+func (h *AttachmentHandler) GetAttachment(c *gin.Context) {
+	id := c.Param("id")
+
+	resp, err := h.attachmentService.GetAttachment(c.Request.Context(), id)
+	if err != nil {
+		if isNotFoundError(err) {
+			respondWithError(c, http.StatusNotFound, ErrorCodeAttachmentNotFound, MessageAttachmentNotFound, nil, nil)
+			return
+		}
+		handleServiceError(c, err, h.log, "get attachment", ErrorCodeAttachmentNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// LinkToSession handles POST /api/v1/sessions/:id/attachments
+func (h *AttachmentHandler) LinkToSession(c *gin.Context) {
+	sessionID := c.Param("id")
+
+	var req dto.AttachmentLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "attachment link")
+		return
+	}
+
+	resp, err := h.attachmentService.LinkToSession(c.Request.Context(), sessionID, &req)
+	switch {
+	case errors.Is(err, service.ErrAttachmentScanPending):
+		respondWithError(c, http.StatusConflict, ErrorCodeAttachmentScanPending, MessageAttachmentScanPending, nil, nil)
+		return
+	case errors.Is(err, service.ErrAttachmentInfected):
+		respondWithError(c, http.StatusConflict, ErrorCodeAttachmentInfected, MessageAttachmentInfected, nil, nil)
+		return
+	case err != nil:
+		handleServiceError(c, err, h.log, "link attachment", ErrorCodeNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// ListAttachments handles GET /api/v1/admin/attachments
+func (h *AttachmentHandler) ListAttachments(c *gin.Context) {
+	var req dto.AdminAttachmentListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithBindError(c, err, h.log, "attachment list")
+		return
+	}
+
+	resp, err := h.attachmentService.ListForAdmin(c.Request.Context(), &req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "list attachments", ErrorCodeAttachmentNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
@@ -1,6 +1,9 @@
 // Package handler provides constants for HTTP handlers.
 package handler
 
+// HeaderSessionID carries the form session a registration was entered in
+const HeaderSessionID = "X-Session-ID"
+
 // HTTP Error Codes
 const (
 	// Generic errors
@@ -13,37 +16,150 @@ const (
 	// User-specific errors
 	ErrorCodeUserNotFound  = "USER_NOT_FOUND"
 	ErrorCodeInvalidUserID = "INVALID_USER_ID"
+	ErrorCodeLegalHold     = "LEGAL_HOLD"
+
+	// ErrorCodeCaptchaFailed reports a registration whose captcha token is missing or rejected
+	ErrorCodeCaptchaFailed = "CAPTCHA_FAILED"
+
+	// ErrorCodeSubmissionRejected reports a registration rejected as automated by bot detection
+	ErrorCodeSubmissionRejected = "SUBMISSION_REJECTED"
+
+	// ErrorCodePossibleDuplicate reports a registration matching an existing user by a duplicate detection strategy
+	ErrorCodePossibleDuplicate = "POSSIBLE_DUPLICATE"
+
+	// Lookup-specific errors
+	ErrorCodeLookupVerificationFailed = "LOOKUP_VERIFICATION_FAILED"
+
+	// Phone verification errors
+	ErrorCodePhoneVerificationFailed = "PHONE_VERIFICATION_FAILED"
+	ErrorCodePhoneCodeCooldown       = "PHONE_CODE_COOLDOWN"
+
+	// Attachment-specific errors
+	ErrorCodeAttachmentNotFound    = "ATTACHMENT_NOT_FOUND"
+	ErrorCodeAttachmentTooLarge    = "ATTACHMENT_TOO_LARGE"
+	ErrorCodeAttachmentScanPending = "ATTACHMENT_SCAN_PENDING"
+	ErrorCodeAttachmentInfected    = "ATTACHMENT_INFECTED"
 
 	// Session-specific errors
-	ErrorCodeSessionNotFound     = "SESSION_NOT_FOUND"
-	ErrorCodeSessionCreateFailed = "SESSION_CREATE_FAILED"
-	ErrorCodeMissingSessionID    = "MISSING_SESSION_ID"
+	ErrorCodeSessionNotFound      = "SESSION_NOT_FOUND"
+	ErrorCodeSessionCreateFailed  = "SESSION_CREATE_FAILED"
+	ErrorCodeMissingSessionID     = "MISSING_SESSION_ID"
+	ErrorCodeSessionLimitExceeded = "SESSION_LIMIT_EXCEEDED"
+	ErrorCodeSessionConflict      = "SESSION_VERSION_CONFLICT"
+	ErrorCodeWizardStepNotFound   = "WIZARD_STEP_NOT_FOUND"
+	ErrorCodeWizardOutOfOrder     = "WIZARD_STEP_OUT_OF_ORDER"
+	ErrorCodeWizardIncomplete     = "WIZARD_INCOMPLETE"
 
 	// Option-specific errors
 	ErrorCodeOptionNotFound       = "OPTION_NOT_FOUND"
 	ErrorCodeMissingOptionType    = "MISSING_OPTION_TYPE"
 	ErrorCodeInventoryCheckFailed = "INVENTORY_CHECK_FAILED"
+	ErrorCodeOptionInUse          = "OPTION_IN_USE"
+	// ErrorCodeInsufficientInventory reports options whose stock is held by other sessions or sold out
+	ErrorCodeInsufficientInventory = "INSUFFICIENT_INVENTORY"
 
 	// Address-specific errors
 	ErrorCodeAddressSearchFailed   = "ADDRESS_SEARCH_FAILED"
 	ErrorCodeRegionCheckFailed     = "REGION_CHECK_FAILED"
 	ErrorCodePrefectureNotFound    = "PREFECTURE_NOT_FOUND"
 	ErrorCodeMissingPrefectureName = "MISSING_PREFECTURE_NAME"
+	// ErrorCodeRegionRestrictionNotFound reports an unknown region restriction master entry
+	ErrorCodeRegionRestrictionNotFound  = "REGION_RESTRICTION_NOT_FOUND"
+	ErrorCodeInvalidRegionRestrictionID = "INVALID_REGION_RESTRICTION_ID"
 
 	// Plan-specific errors
 	ErrorCodePlanNotFound    = "PLAN_NOT_FOUND"
 	ErrorCodeMissingPlanType = "MISSING_PLAN_TYPE"
+
+	// Admin-specific errors
+	ErrorCodeExternalAPINotFound      = "EXTERNAL_API_NOT_FOUND"
+	ErrorCodeExternalAPINotConfigured = "EXTERNAL_API_NOT_CONFIGURED"
+	ErrorCodeFeatureFlagNotFound      = "FEATURE_FLAG_NOT_FOUND"
+	ErrorCodeInvalidValidationRules   = "INVALID_VALIDATION_RULES"
+
+	// Email template errors
+	ErrorCodeEmailTemplateNotFound       = "EMAIL_TEMPLATE_NOT_FOUND"
+	ErrorCodeInvalidEmailTemplateVersion = "INVALID_EMAIL_TEMPLATE_VERSION"
+
+	// Email event webhook errors
+	ErrorCodeEmailEventProviderNotFound = "EMAIL_EVENT_PROVIDER_NOT_FOUND"
+	ErrorCodeInvalidWebhookSignature    = "INVALID_WEBHOOK_SIGNATURE"
+	ErrorCodeWebhookPayloadTooLarge     = "WEBHOOK_PAYLOAD_TOO_LARGE"
+
+	// Webhook delivery errors
+	ErrorCodeWebhookDeliveryNotFound  = "WEBHOOK_DELIVERY_NOT_FOUND"
+	ErrorCodeInvalidWebhookDeliveryID = "INVALID_WEBHOOK_DELIVERY_ID"
+	ErrorCodeEventNotFound            = "EVENT_NOT_FOUND"
+	ErrorCodeInvalidEventID           = "INVALID_EVENT_ID"
+
+	// API key errors
+	ErrorCodeAPIKeyNotFound  = "API_KEY_NOT_FOUND"
+	ErrorCodeInvalidAPIKeyID = "INVALID_API_KEY_ID"
+
+	// Security monitoring errors
+	ErrorCodeIPBlockNotFound = "IP_BLOCK_NOT_FOUND"
+	ErrorCodeInvalidClientIP = "INVALID_CLIENT_IP"
+
+	// Queued user creation errors
+	ErrorCodeUserCreateTicketNotFound = "USER_CREATE_TICKET_NOT_FOUND"
+
+	// Form bootstrap errors
+	ErrorCodeInvalidFields             = "INVALID_FIELDS"
+	ErrorCodeCSRFTokenGenerationFailed = "CSRF_TOKEN_GENERATION_FAILED"
+
+	// Option reservation administration errors
+	ErrorCodeReservationNotFound  = "RESERVATION_NOT_FOUND"
+	ErrorCodeInvalidReservationID = "INVALID_RESERVATION_ID"
+
+	// Conditional request errors
+	ErrorCodePreconditionFailed = "PRECONDITION_FAILED"
+	ErrorCodeVersionConflict    = "VERSION_CONFLICT"
 )
 
 // HTTP Error Messages
 const (
-	MessageInvalidRequest     = "Invalid request format"
-	MessageInvalidQueryParams = "Invalid query parameters"
-	MessageInternalError      = "Internal server error"
-	MessageValidationFailed   = "Validation failed"
-	MessageUserNotFound       = "User not found"
-	MessageSessionNotFound    = "Session not found or expired"
-	MessageOptionNotFound     = "Option not found"
-	MessagePrefectureNotFound = "Prefecture not found"
-	MessagePlanNotFound       = "Plan not found"
+	MessageInvalidRequest           = "Invalid request format"
+	MessageInvalidQueryParams       = "Invalid query parameters"
+	MessageInternalError            = "Internal server error"
+	MessageValidationFailed         = "Validation failed"
+	MessageUserNotFound             = "User not found"
+	MessagePossibleDuplicate        = "This registration may duplicate an existing user"
+	MessageCaptchaFailed            = "Captcha verification failed; solve the captcha again"
+	MessageSubmissionRejected       = "Submission could not be accepted; reload the form and try again"
+	MessageSessionNotFound          = "Session not found or expired"
+	MessageSessionLimitExceeded     = "Too many active sessions; finish or discard an existing draft"
+	MessageSessionConflict          = "Session was saved by another request; reload it and retry"
+	MessageWizardStepNotFound       = "Wizard step not found"
+	MessageOptionNotFound           = "Option not found"
+	MessageOptionInUse              = "Option is selected by users; set valid_until to withdraw it instead"
+	MessageInsufficientInventory    = "Not enough stock to reserve the selected options"
+	MessagePrefectureNotFound       = "Prefecture not found"
+	MessagePlanNotFound             = "Plan not found"
+	MessageExternalAPINotFound      = "External API not found"
+	MessageExternalAPINotConfigured = "External API is not configured"
+	MessageLookupVerificationFailed = "Invalid or expired verification code"
+	MessageFeatureFlagNotFound      = "Feature flag not found"
+	MessagePhoneVerificationFailed  = "Invalid or expired verification code"
+	MessagePhoneCodeCooldown        = "A verification code was sent recently; wait before requesting another"
+	MessageAttachmentNotFound       = "Attachment not found"
+	MessageAttachmentFileRequired   = "A file must be uploaded in the file field"
+	MessageAttachmentTooLarge       = "Attachment exceeds the size limit"
+	MessageAttachmentScanPending    = "Attachment is still being scanned; retry once scan_status is clean"
+	MessageAttachmentInfected       = "Attachment failed the malware scan"
+	MessageInvalidWebhookSignature  = "Webhook signature could not be verified"
+	MessageWebhookPayloadTooLarge   = "Webhook payload exceeds the size limit"
+
+	// Form bootstrap messages
+	MessageCSRFTokenGenerationFailed = "Failed to generate CSRF token"
+
+	// Conditional request messages
+	MessageUserPreconditionFailed = "User was modified since it was read; get it again and reapply the changes"
+	MessageVersionConflict        = "Resource was modified since the given version; get it again and reapply the changes"
+
+	// External API failures are shown to users as is, so they are in Japanese
+	MessageExternalAPIUnavailable = "外部サービスに接続できないため、処理を完了できませんでした。しばらくしてから再度お試しください"
+	MessageExternalAPITimeout     = "外部サービスの応答がないため、処理を完了できませんでした。しばらくしてから再度お試しください"
+	MessageInventoryUnverified    = "在庫を確認できないため、処理を完了できませんでした。しばらくしてから再度お試しください"
+	MessageRegionUnverified       = "提供エリアを確認できないため、処理を完了できませんでした。しばらくしてから再度お試しください"
+	MessageAddressUnverified      = "住所を確認できないため、処理を完了できませんでした。しばらくしてから再度お試しください"
 )
@@ -0,0 +1,62 @@
+// Package handler provides HTTP handlers for mail provider webhooks.
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/mail"
+)
+
+// maxEmailEventBodyBytes bounds a webhook delivery; SendGrid batches up to a few hundred
+// events per request
+const maxEmailEventBodyBytes = 1 << 20
+
+// EmailEventHandler handles bounce and complaint webhooks of mail providers
+type EmailEventHandler struct {
+	emailEventService service.EmailEventService
+	log               *logger.Logger
+}
+
+// NewEmailEventHandler creates a new email event handler
+func NewEmailEventHandler(emailEventService service.EmailEventService, log *logger.Logger) *EmailEventHandler {
+	return &EmailEventHandler{
+		emailEventService: emailEventService,
+		log:               log,
+	}
+}
+
+// ReceiveEvents handles POST /api/v1/webhooks/email-events and
+// POST /api/v1/webhooks/email-events/:provider; without a provider in the path it is
+// detected from the request
+func (h *EmailEventHandler) ReceiveEvents(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxEmailEventBodyBytes))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		respondWithError(c, http.StatusRequestEntityTooLarge, ErrorCodeWebhookPayloadTooLarge,
+			MessageWebhookPayloadTooLarge, nil, nil)
+		return
+	}
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrorCodeInvalidRequest, MessageInvalidRequest, nil, nil)
+		return
+	}
+
+	resp, err := h.emailEventService.Ingest(c.Request.Context(), c.Param("provider"), c.Request.Header, body)
+	if errors.Is(err, mail.ErrInvalidSignature) {
+		h.log.WithContext(c.Request.Context()).WithError(err).Warn("Rejected email event webhook")
+		respondWithError(c, http.StatusUnauthorized, ErrorCodeInvalidWebhookSignature,
+			MessageInvalidWebhookSignature, nil, nil)
+		return
+	}
+	if err != nil {
+		handleServiceError(c, err, h.log, "receive email events", ErrorCodeEmailEventProviderNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
@@ -0,0 +1,117 @@
+// Package handler provides HTTP handlers for email template administration.
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// EmailTemplateHandler handles email template administration HTTP requests
+type EmailTemplateHandler struct {
+	templateService service.EmailTemplateService
+	log             *logger.Logger
+}
+
+// NewEmailTemplateHandler creates a new email template handler
+func NewEmailTemplateHandler(templateService service.EmailTemplateService, log *logger.Logger) *EmailTemplateHandler {
+	return &EmailTemplateHandler{
+		templateService: templateService,
+		log:             log,
+	}
+}
+
+// ListTemplates handles GET /api/v1/admin/email-templates
+func (h *EmailTemplateHandler) ListTemplates(c *gin.Context) {
+	resp, err := h.templateService.ListTemplates(c.Request.Context())
+	if err != nil {
+		handleServiceError(c, err, h.log, "list email templates", ErrorCodeEmailTemplateNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// GetTemplate handles GET /api/v1/admin/email-templates/:name
+func (h *EmailTemplateHandler) GetTemplate(c *gin.Context) {
+	resp, err := h.templateService.GetTemplate(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		handleServiceError(c, err, h.log, "get email template", ErrorCodeEmailTemplateNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// CreateVersion handles POST /api/v1/admin/email-templates/:name/versions
+func (h *EmailTemplateHandler) CreateVersion(c *gin.Context) {
+	var req dto.EmailTemplateVersionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "email template version")
+		return
+	}
+
+	resp, err := h.templateService.CreateVersion(c.Request.Context(), c.Param("name"), &req, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "create email template version", ErrorCodeEmailTemplateNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusCreated, resp)
+}
+
+// ActivateVersion handles POST /api/v1/admin/email-templates/:name/versions/:version/activate
+func (h *EmailTemplateHandler) ActivateVersion(c *gin.Context) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 0 {
+		respondWithError(c, http.StatusBadRequest, ErrorCodeInvalidEmailTemplateVersion,
+			"Email template version must be a non-negative integer", nil, nil)
+		return
+	}
+
+	resp, err := h.templateService.ActivateVersion(c.Request.Context(), c.Param("name"), version, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "activate email template version", ErrorCodeEmailTemplateNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// Preview handles POST /api/v1/admin/email-templates/:name/preview
+func (h *EmailTemplateHandler) Preview(c *gin.Context) {
+	var req dto.EmailTemplatePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "email template preview")
+		return
+	}
+
+	resp, err := h.templateService.Preview(c.Request.Context(), c.Param("name"), &req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "preview email template", ErrorCodeEmailTemplateNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// TestSend handles POST /api/v1/admin/email-templates/:name/test-send
+func (h *EmailTemplateHandler) TestSend(c *gin.Context) {
+	var req dto.EmailTemplateTestSendRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "email template test send")
+		return
+	}
+
+	resp, err := h.templateService.TestSend(c.Request.Context(), c.Param("name"), &req, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "send test email", ErrorCodeEmailTemplateNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/pkg/correlation"
 )
 
 // ErrorCode represents error codes for the application
@@ -33,7 +34,7 @@ const (
 
 	// Business logic error codes
 	ErrorCodeUserAlreadyExists     ErrorCode = "USER_ALREADY_EXISTS"
-	ErrorCodeUserNotFound          ErrorCode = "USER_NOT_FOUND"
+	ErrorCodeUserNotFoundError     ErrorCode = "USER_NOT_FOUND"
 	ErrorCodeSessionExpired        ErrorCode = "SESSION_EXPIRED"
 	ErrorCodeSessionNotFoundError  ErrorCode = "SESSION_NOT_FOUND"
 	ErrorCodeInvalidSessionData    ErrorCode = "INVALID_SESSION_DATA"
@@ -51,6 +52,11 @@ const (
 	ErrorCodeExternalAPITimeout   ErrorCode = "EXTERNAL_API_TIMEOUT"
 	ErrorCodeExternalAPIRateLimit ErrorCode = "EXTERNAL_API_RATE_LIMIT"
 
+	// Strict mode error codes, for data that could not be verified with the external API
+	ErrorCodeInventoryUnverified ErrorCode = "INVENTORY_UNVERIFIED"
+	ErrorCodeRegionUnverified    ErrorCode = "REGION_UNVERIFIED"
+	ErrorCodeAddressUnverified   ErrorCode = "ADDRESS_UNVERIFIED"
+
 	// Security error codes
 	ErrorCodeCSRFTokenMissing     ErrorCode = "CSRF_TOKEN_MISSING"
 	ErrorCodeCSRFTokenInvalid     ErrorCode = "CSRF_TOKEN_INVALID"
@@ -150,10 +156,11 @@ type ErrorDetail struct {
 
 // ErrorMeta represents metadata for error tracking
 type ErrorMeta struct {
-	RequestID string `json:"request_id,omitempty"`
-	Timestamp string `json:"timestamp,omitempty"`
-	Path      string `json:"path,omitempty"`
-	Method    string `json:"method,omitempty"`
+	RequestID     string `json:"request_id,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+	Timestamp     string `json:"timestamp,omitempty"`
+	Path          string `json:"path,omitempty"`
+	Method        string `json:"method,omitempty"`
 }
 
 // HandleError handles application errors and returns appropriate HTTP responses
@@ -185,10 +192,11 @@ func HandleError(c *gin.Context, err error) {
 		Success: false,
 		Error:   errorDetail,
 		Meta: &ErrorMeta{
-			RequestID: c.GetHeader("X-Request-ID"),
-			Timestamp: time.Now().Format("2006-01-02T15:04:05Z07:00"),
-			Path:      c.Request.URL.Path,
-			Method:    c.Request.Method,
+			RequestID:     correlation.RequestID(c.Request.Context()),
+			CorrelationID: correlation.CorrelationID(c.Request.Context()),
+			Timestamp:     time.Now().Format("2006-01-02T15:04:05Z07:00"),
+			Path:          c.Request.URL.Path,
+			Method:        c.Request.Method,
 		},
 	}
 
@@ -210,10 +218,11 @@ func HandleValidationErrors(c *gin.Context, errors map[string]string) {
 			Details: errors,
 		},
 		Meta: &ErrorMeta{
-			RequestID: c.GetHeader("X-Request-ID"),
-			Timestamp: time.Now().Format("2006-01-02T15:04:05Z07:00"),
-			Path:      c.Request.URL.Path,
-			Method:    c.Request.Method,
+			RequestID:     correlation.RequestID(c.Request.Context()),
+			CorrelationID: correlation.CorrelationID(c.Request.Context()),
+			Timestamp:     time.Now().Format("2006-01-02T15:04:05Z07:00"),
+			Path:          c.Request.URL.Path,
+			Method:        c.Request.Method,
 		},
 	}
 
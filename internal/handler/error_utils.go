@@ -2,96 +2,37 @@
 package handler
 
 import (
-	"strings"
+	"errors"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
 )
 
 // isValidationError checks if the error is a validation error
 func isValidationError(err error) bool {
-	if err == nil {
-		return false
-	}
-
-	errMsg := err.Error()
-	validationKeywords := []string{
-		"validation",
-		"invalid",
-		"required",
-		"format",
-		"length",
-	}
-
-	for _, keyword := range validationKeywords {
-		if strings.Contains(strings.ToLower(errMsg), keyword) {
-			return true
-		}
-	}
-
-	return false
+	return errors.Is(err, apperr.ErrValidation)
 }
 
 // isDuplicateError checks if the error is a duplicate/conflict error
 func isDuplicateError(err error) bool {
-	if err == nil {
-		return false
-	}
-
-	errMsg := err.Error()
-	duplicateKeywords := []string{
-		"already exists",
-		"duplicate",
-		"conflict",
-		"unique constraint",
-	}
-
-	for _, keyword := range duplicateKeywords {
-		if strings.Contains(strings.ToLower(errMsg), keyword) {
-			return true
-		}
-	}
-
-	return false
+	return errors.Is(err, apperr.ErrDuplicate)
 }
 
 // isNotFoundError checks if the error is a not found error
 func isNotFoundError(err error) bool {
-	if err == nil {
-		return false
-	}
-
-	errMsg := err.Error()
-	notFoundKeywords := []string{
-		"not found",
-		"does not exist",
-		"no rows",
-	}
-
-	for _, keyword := range notFoundKeywords {
-		if strings.Contains(strings.ToLower(errMsg), keyword) {
-			return true
-		}
-	}
-
-	return false
+	return errors.Is(err, apperr.ErrNotFound)
 }
 
 // isExpiredError checks if the error is related to expiration
 func isExpiredError(err error) bool {
-	if err == nil {
-		return false
-	}
-
-	errMsg := err.Error()
-	expiredKeywords := []string{
-		"expired",
-		"timeout",
-		"timed out",
-	}
+	return errors.Is(err, apperr.ErrExpired)
+}
 
-	for _, keyword := range expiredKeywords {
-		if strings.Contains(strings.ToLower(errMsg), keyword) {
-			return true
-		}
-	}
+// isLegalHoldError checks if the operation was blocked by a legal hold
+func isLegalHoldError(err error) bool {
+	return errors.Is(err, apperr.ErrLegalHold)
+}
 
-	return false
+// isExternalAPIError checks if an external API failed, including timeouts
+func isExternalAPIError(err error) bool {
+	return errors.Is(err, apperr.ErrExternalAPI) || errors.Is(err, apperr.ErrExternalTimeout)
 }
@@ -0,0 +1,123 @@
+// Package handler provides HTTP handlers for the form bootstrap API.
+package handler
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// CSRFTokenIssuer issues a single-use CSRF token for the form
+type CSRFTokenIssuer func(ctx context.Context) (string, error)
+
+// FormHandler serves the data the form loads on start in a single request, in place of
+// the plan, option, prefecture and CSRF token endpoints
+type FormHandler struct {
+	planService    service.PlanService
+	optionService  service.OptionService
+	addressService service.AddressService
+	issueCSRFToken CSRFTokenIssuer
+	log            *logger.Logger
+}
+
+// NewFormHandler creates a new form handler
+func NewFormHandler(
+	planService service.PlanService,
+	optionService service.OptionService,
+	addressService service.AddressService,
+	issueCSRFToken CSRFTokenIssuer,
+	log *logger.Logger,
+) *FormHandler {
+	return &FormHandler{
+		planService:    planService,
+		optionService:  optionService,
+		addressService: addressService,
+		issueCSRFToken: issueCSRFToken,
+		log:            log,
+	}
+}
+
+// GetBootstrap handles GET /api/v1/form/bootstrap
+func (h *FormHandler) GetBootstrap(c *gin.Context) {
+	var req dto.FormBootstrapRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithBindError(c, err, h.log, "form bootstrap")
+		return
+	}
+
+	fields, unknown := parseFormFields(req.Fields)
+	if unknown != "" {
+		respondWithError(c, http.StatusBadRequest, ErrorCodeInvalidFields,
+			"Unknown field: "+unknown+"; fields are "+strings.Join(dto.FormFields, ", "), nil, nil)
+		return
+	}
+
+	ctx := c.Request.Context()
+	resp := &dto.FormBootstrapResponse{}
+	var err error
+
+	if fields[dto.FormFieldPlans] {
+		if resp.PlansGetResponse, err = h.planService.GetAvailablePlans(ctx); err != nil {
+			handleServiceError(c, err, h.log, "get plans for form bootstrap", ErrorCodePlanNotFound)
+			return
+		}
+	}
+	if fields[dto.FormFieldOptions] {
+		resp.OptionsGetResponse, err = h.optionService.GetAvailableOptions(ctx, &dto.OptionsGetRequest{
+			PlanType: req.PlanType,
+			Region:   req.Region,
+		})
+		if err != nil {
+			handleServiceError(c, err, h.log, "get options for form bootstrap", ErrorCodeOptionNotFound)
+			return
+		}
+	}
+	if fields[dto.FormFieldPrefectures] {
+		if resp.PrefecturesGetResponse, err = h.addressService.GetPrefectures(ctx); err != nil {
+			handleServiceError(c, err, h.log, "get prefectures for form bootstrap", ErrorCodePrefectureNotFound)
+			return
+		}
+	}
+	// The token is issued last, so that it is not stored for a response that failed
+	if fields[dto.FormFieldCSRFToken] {
+		if resp.CSRFToken, err = h.issueCSRFToken(ctx); err != nil {
+			respondWithError(c, http.StatusInternalServerError, ErrorCodeCSRFTokenGenerationFailed,
+				MessageCSRFTokenGenerationFailed, h.log, err)
+			return
+		}
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// parseFormFields returns the requested parts of the form bootstrap response, from repeated
+// or comma-separated names, with every part when none is named. It also returns the first
+// unknown name, if any.
+func parseFormFields(values []string) (map[string]bool, string) {
+	fields := make(map[string]bool, len(dto.FormFields))
+	for _, value := range values {
+		for _, field := range strings.Split(value, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			if !slices.Contains(dto.FormFields, field) {
+				return nil, field
+			}
+			fields[field] = true
+		}
+	}
+
+	if len(fields) == 0 {
+		for _, field := range dto.FormFields {
+			fields[field] = true
+		}
+	}
+	return fields, ""
+}
@@ -2,83 +2,222 @@
 package handler
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
 	"github.com/octop162/normal-form-app-by-claude/pkg/database"
+	"github.com/octop162/normal-form-app-by-claude/pkg/external"
 	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
 )
 
 const (
 	statusHealthy       = "healthy"
+	statusDegraded      = "degraded"
 	statusUnhealthy     = "unhealthy"
 	statusNotConfigured = "not configured"
+
+	// readinessBacklogTimeout bounds the query counting the registration backlog
+	readinessBacklogTimeout = 2 * time.Second
+
+	// defaultHealthExternalTimeout bounds external API health calls when no timeout is set
+	defaultHealthExternalTimeout = 5 * time.Second
 )
 
+// ReadinessConfig holds the load criteria that fail the readiness probe; each is off when zero
+type ReadinessConfig struct {
+	// DBPoolMaxUsage is the share of the connection pool in use that fails readiness
+	DBPoolMaxUsage float64
+	// QueueMaxBacklog is the number of due queued registrations above which readiness fails
+	QueueMaxBacklog int
+	// CircuitBreakers lists the external APIs whose open circuit breaker fails readiness
+	CircuitBreakers []string
+}
+
+// HealthConfig holds the external API checks of the health endpoint
+type HealthConfig struct {
+	// ExternalChecks calls the configured external APIs on health checks
+	ExternalChecks bool
+	// CriticalAPIs lists the external APIs whose failure makes the service unhealthy; the
+	// failure of the others only degrades it
+	CriticalAPIs []string
+	// ExternalCacheTTL is how long external API results are reused between health checks
+	ExternalCacheTTL time.Duration
+	// ExternalTimeout bounds the external API calls of a health check
+	ExternalTimeout time.Duration
+}
+
 // HealthHandler handles health check requests
 type HealthHandler struct {
-	db  *database.DB
-	log *logger.Logger
+	db          *database.DB
+	userQueue   *service.UserCreateQueue
+	externalAPI *external.Manager
+	health      HealthConfig
+	readiness   ReadinessConfig
+	log         *logger.Logger
+
+	// externalMu serializes external API checks, so that concurrent health checks share one
+	// round of calls
+	externalMu     sync.Mutex
+	externalResult *external.HealthCheckResult
+	externalAt     time.Time
 }
 
 // HealthResponse represents the health check response
 type HealthResponse struct {
-	Status    string            `json:"status"`
-	Service   string            `json:"service"`
-	Version   string            `json:"version"`
-	Timestamp string            `json:"timestamp"`
-	Checks    map[string]string `json:"checks"`
+	Status       string                      `json:"status"`
+	Service      string                      `json:"service"`
+	Version      string                      `json:"version"`
+	Timestamp    string                      `json:"timestamp"`
+	Checks       map[string]string           `json:"checks"`
+	Dependencies map[string]DependencyHealth `json:"dependencies"`
+}
+
+// DependencyHealth is the result of checking one dependency
+type DependencyHealth struct {
+	Status string `json:"status"`
+	// Critical dependencies make the service unhealthy when they fail; others degrade it
+	Critical  bool   `json:"critical"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+	CheckedAt string `json:"checked_at"`
 }
 
 // NewHealthHandler creates a new health handler
-func NewHealthHandler(db *database.DB, log *logger.Logger) *HealthHandler {
+func NewHealthHandler(
+	db *database.DB,
+	userQueue *service.UserCreateQueue,
+	externalAPI *external.Manager,
+	health HealthConfig,
+	readiness ReadinessConfig,
+	log *logger.Logger,
+) *HealthHandler {
 	return &HealthHandler{
-		db:  db,
-		log: log,
+		db:          db,
+		userQueue:   userQueue,
+		externalAPI: externalAPI,
+		health:      health,
+		readiness:   readiness,
+		log:         log,
 	}
 }
 
-// Health handles GET /health requests
+// Health handles GET /health requests. A degraded service, with only non-critical
+// dependencies failing, still answers 200.
 func (h *HealthHandler) Health(c *gin.Context) {
+	status, checks, dependencies := h.check(c.Request.Context())
+
+	response := HealthResponse{
+		Status:       status,
+		Service:      "normal-form-app",
+		Version:      "1.0.0",
+		Timestamp:    time.Now().Format(time.RFC3339),
+		Checks:       checks,
+		Dependencies: dependencies,
+	}
+
+	// Set appropriate status code
+	statusCode := http.StatusOK
+	if status == statusUnhealthy {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	c.JSON(statusCode, response)
+}
+
+// check runs the dependency checks and returns the overall status with the result of each:
+// unhealthy when a critical dependency fails, degraded when only others do
+func (h *HealthHandler) check(ctx context.Context) (string, map[string]string, map[string]DependencyHealth) {
 	checks := make(map[string]string)
+	dependencies := make(map[string]DependencyHealth)
+	status := statusHealthy
+	record := func(name string, dependency DependencyHealth) {
+		dependencies[name] = dependency
+		if dependency.Status == statusHealthy {
+			return
+		}
+		if dependency.Critical {
+			status = statusUnhealthy
+		} else if status == statusHealthy {
+			status = statusDegraded
+		}
+	}
 
 	// Check database connection
 	if h.db != nil {
-		if err := h.db.HealthCheck(); err != nil {
+		start := time.Now()
+		err := h.db.HealthCheck()
+		dependency := DependencyHealth{
+			Status:    statusHealthy,
+			Critical:  true,
+			LatencyMS: time.Since(start).Milliseconds(),
+			CheckedAt: start.Format(time.RFC3339),
+		}
+		if err != nil {
 			h.log.WithError(err).Error("Database health check failed")
 			checks["database"] = statusUnhealthy + ": " + err.Error()
+			dependency.Status = statusUnhealthy
+			dependency.Error = err.Error()
 		} else {
 			checks["database"] = statusHealthy
 		}
+		record("database", dependency)
 	} else {
 		checks["database"] = statusNotConfigured
 	}
 
-	// Determine overall status
-	status := statusHealthy
-	for _, check := range checks {
-		if check != statusHealthy && check != statusNotConfigured {
-			status = statusUnhealthy
-			break
+	if h.health.ExternalChecks && h.externalAPI != nil {
+		for name, service := range h.externalHealth(ctx).Services {
+			dependency := DependencyHealth{
+				Status:    service.Status,
+				Critical:  slices.Contains(h.health.CriticalAPIs, name),
+				LatencyMS: service.Latency.Milliseconds(),
+				Error:     service.Error,
+				CheckedAt: service.CheckedAt.Format(time.RFC3339),
+			}
+			check := service.Status
+			if service.Error != "" {
+				check += ": " + service.Error
+			}
+			checks["external."+name] = check
+			record(name, dependency)
 		}
 	}
 
-	response := HealthResponse{
-		Status:    status,
-		Service:   "normal-form-app",
-		Version:   "1.0.0",
-		Timestamp: time.Now().Format(time.RFC3339),
-		Checks:    checks,
+	return status, checks, dependencies
+}
+
+// externalHealth returns the result of checking the external APIs, calling them again only
+// once the previous result is older than the cache TTL
+func (h *HealthHandler) externalHealth(ctx context.Context) *external.HealthCheckResult {
+	h.externalMu.Lock()
+	defer h.externalMu.Unlock()
+
+	if h.externalResult != nil && time.Since(h.externalAt) < h.health.ExternalCacheTTL {
+		return h.externalResult
 	}
 
-	// Set appropriate status code
-	statusCode := http.StatusOK
-	if status == statusUnhealthy {
-		statusCode = http.StatusServiceUnavailable
+	// The result is shared with later checks, so a client going away must not cut it short
+	timeout := h.health.ExternalTimeout
+	if timeout <= 0 {
+		timeout = defaultHealthExternalTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+	result := h.externalAPI.HealthCheck(ctx)
+	for name, service := range result.Services {
+		if service.Cause() != nil {
+			h.log.WithError(service.Cause()).WithField("api", name).Warn("External API health check failed")
+		}
 	}
 
-	c.JSON(statusCode, response)
+	h.externalResult, h.externalAt = result, time.Now()
+	return result
 }
 
 // LivenessProbe handles GET /health/live requests
@@ -89,8 +228,19 @@ func (h *HealthHandler) LivenessProbe(c *gin.Context) {
 	})
 }
 
-// ReadinessProbe handles GET /health/ready requests
+// ReadinessProbe handles GET /health/ready requests. Besides the database connection, it
+// fails while the instance is saturated by the configured criteria, as requests routed to it
+// would only wait or fail with 503.
 func (h *HealthHandler) ReadinessProbe(c *gin.Context) {
+	checks := make(map[string]string)
+	reason := ""
+	fail := func(check, result string) {
+		checks[check] = result
+		if reason == "" {
+			reason = check + " not ready"
+		}
+	}
+
 	// Check if database is ready
 	if h.db != nil {
 		if err := h.db.HealthCheck(); err != nil {
@@ -101,10 +251,63 @@ func (h *HealthHandler) ReadinessProbe(c *gin.Context) {
 			})
 			return
 		}
+		checks["database"] = "ready"
+
+		if h.readiness.DBPoolMaxUsage > 0 {
+			stats := h.db.Stats()
+			result := fmt.Sprintf("%d/%d connections in use", stats.InUse, stats.MaxOpenConnections)
+			if stats.MaxOpenConnections > 0 &&
+				float64(stats.InUse) >= h.readiness.DBPoolMaxUsage*float64(stats.MaxOpenConnections) {
+				fail("database_pool", result)
+			} else {
+				checks["database_pool"] = result
+			}
+		}
+	}
+
+	if h.readiness.QueueMaxBacklog > 0 && h.userQueue != nil {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), readinessBacklogTimeout)
+		backlog, err := h.userQueue.Backlog(ctx)
+		cancel()
+		switch {
+		case err != nil:
+			h.log.WithError(err).Error("Failed to count user create queue backlog")
+			fail("user_create_queue", "backlog unknown")
+		case backlog > h.readiness.QueueMaxBacklog:
+			fail("user_create_queue", fmt.Sprintf("%d registrations due (limit %d)", backlog, h.readiness.QueueMaxBacklog))
+		default:
+			checks["user_create_queue"] = fmt.Sprintf("%d registrations due", backlog)
+		}
+	}
+
+	if len(h.readiness.CircuitBreakers) > 0 && h.externalAPI != nil {
+		for _, api := range h.externalAPI.ClientStatuses() {
+			if !slices.Contains(h.readiness.CircuitBreakers, api.Name) || api.CircuitBreaker == nil {
+				continue
+			}
+			check := "circuit_breaker." + api.Name
+			if api.CircuitBreaker.State == external.CircuitOpen {
+				fail(check, string(api.CircuitBreaker.State))
+			} else {
+				checks[check] = string(api.CircuitBreaker.State)
+			}
+		}
+	}
+
+	if reason != "" {
+		h.log.WithField("reason", reason).Warn("Readiness probe failed")
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status":    "not ready",
+			"reason":    reason,
+			"checks":    checks,
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":    "ready",
+		"checks":    checks,
 		"timestamp": time.Now().Format(time.RFC3339),
 	})
 }
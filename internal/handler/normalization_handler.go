@@ -0,0 +1,61 @@
+// Package handler provides HTTP handlers for input normalization.
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// NormalizationHandler handles input normalization HTTP requests
+type NormalizationHandler struct {
+	normalizationService service.NormalizationService
+	log                  *logger.Logger
+}
+
+// NewNormalizationHandler creates a new normalization handler
+func NewNormalizationHandler(
+	normalizationService service.NormalizationService, log *logger.Logger,
+) *NormalizationHandler {
+	return &NormalizationHandler{
+		normalizationService: normalizationService,
+		log:                  log,
+	}
+}
+
+// NormalizeKana handles POST /api/v1/normalize
+func (h *NormalizationHandler) NormalizeKana(c *gin.Context) {
+	var req dto.NormalizeKanaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "normalize")
+		return
+	}
+
+	resp, err := h.normalizationService.NormalizeKana(c.Request.Context(), &req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "normalize kana fields", ErrorCodeInternalError)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// NormalizeAddress handles POST /api/v1/address/normalize
+func (h *NormalizationHandler) NormalizeAddress(c *gin.Context) {
+	var req dto.NormalizeAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "address normalize")
+		return
+	}
+
+	resp, err := h.normalizationService.NormalizeAddress(c.Request.Context(), &req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "normalize address", ErrorCodeInternalError)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
@@ -2,7 +2,9 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/octop162/normal-form-app-by-claude/internal/dto"
@@ -12,15 +14,27 @@ import (
 
 // OptionHandler handles option-related HTTP requests
 type OptionHandler struct {
-	optionService service.OptionService
-	log           *logger.Logger
+	optionService       service.OptionService
+	reservationService  service.ReservationService
+	availabilityService service.AvailabilityService
+	recommendations     service.RecommendationService
+	log                 *logger.Logger
 }
 
 // NewOptionHandler creates a new option handler
-func NewOptionHandler(optionService service.OptionService, log *logger.Logger) *OptionHandler {
+func NewOptionHandler(
+	optionService service.OptionService,
+	reservationService service.ReservationService,
+	availabilityService service.AvailabilityService,
+	recommendations service.RecommendationService,
+	log *logger.Logger,
+) *OptionHandler {
 	return &OptionHandler{
-		optionService: optionService,
-		log:           log,
+		optionService:       optionService,
+		reservationService:  reservationService,
+		availabilityService: availabilityService,
+		recommendations:     recommendations,
+		log:                 log,
 	}
 }
 
@@ -34,7 +48,7 @@ func (h *OptionHandler) GetOptions(c *gin.Context) {
 			Error: &dto.APIError{
 				Code:    ErrorCodeInvalidRequest,
 				Message: "Invalid query parameters",
-				Details: map[string]string{"bind_error": err.Error()},
+				Details: bindErrorDetails(err),
 			},
 		})
 		return
@@ -70,7 +84,7 @@ func (h *OptionHandler) CheckInventory(c *gin.Context) {
 			Error: &dto.APIError{
 				Code:    ErrorCodeInvalidRequest,
 				Message: "Invalid request format",
-				Details: map[string]string{"bind_error": err.Error()},
+				Details: bindErrorDetails(err),
 			},
 		})
 		return
@@ -79,6 +93,10 @@ func (h *OptionHandler) CheckInventory(c *gin.Context) {
 	// Check inventory
 	resp, err := h.optionService.CheckInventory(c.Request.Context(), &req)
 	if err != nil {
+		if isExternalAPIError(err) {
+			handleServiceError(c, err, h.log, "check inventory", ErrorCodeInventoryCheckFailed)
+			return
+		}
 		h.log.WithError(err).Error("Failed to check inventory")
 		c.JSON(http.StatusInternalServerError, dto.APIResponse{
 			Success: false,
@@ -96,6 +114,80 @@ func (h *OptionHandler) CheckInventory(c *gin.Context) {
 	})
 }
 
+// CheckAvailability handles POST /api/v1/options/availability
+func (h *OptionHandler) CheckAvailability(c *gin.Context) {
+	var req dto.OptionAvailabilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "option availability")
+		return
+	}
+
+	resp, err := h.availabilityService.CheckAvailability(c.Request.Context(), &req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "check option availability", ErrorCodeOptionNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// GetRecommendedOptions handles GET /api/v1/options/recommended
+func (h *OptionHandler) GetRecommendedOptions(c *gin.Context) {
+	var req dto.OptionRecommendationRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithBindError(c, err, h.log, "option recommendation")
+		return
+	}
+
+	resp, err := h.recommendations.Recommend(c.Request.Context(), &req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "recommend options", ErrorCodeOptionNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// ReserveOptions handles POST /api/v1/options/reserve
+func (h *OptionHandler) ReserveOptions(c *gin.Context) {
+	var req dto.OptionReserveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "option reserve")
+		return
+	}
+
+	resp, err := h.reservationService.Reserve(c.Request.Context(), &req)
+	var inventoryErr *service.InsufficientInventoryError
+	if errors.As(err, &inventoryErr) {
+		respondWithInsufficientInventory(c, inventoryErr)
+		return
+	}
+	if err != nil {
+		handleServiceError(c, err, h.log, "reserve options", ErrorCodeSessionNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// respondWithInsufficientInventory sends a 409 listing the quantity still available for each
+// option type that could not be reserved
+func respondWithInsufficientInventory(c *gin.Context, err *service.InsufficientInventoryError) {
+	details := make(map[string]string, len(err.Available))
+	for optionType, available := range err.Available {
+		details[optionType] = strconv.Itoa(available)
+	}
+
+	c.JSON(http.StatusConflict, dto.APIResponse{
+		Success: false,
+		Error: &dto.APIError{
+			Code:    ErrorCodeInsufficientInventory,
+			Message: MessageInsufficientInventory,
+			Details: details,
+		},
+	})
+}
+
 // GetOption handles GET /api/v1/options/:type
 func (h *OptionHandler) GetOption(c *gin.Context) {
 	optionType := c.Param("type")
@@ -0,0 +1,67 @@
+// Package handler provides HTTP handlers for the partner API.
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// PartnerHandler handles read-only partner API requests
+type PartnerHandler struct {
+	availabilityService service.AvailabilityService
+	planService         service.PlanService
+	log                 *logger.Logger
+}
+
+// NewPartnerHandler creates a new partner handler
+func NewPartnerHandler(
+	availabilityService service.AvailabilityService, planService service.PlanService, log *logger.Logger,
+) *PartnerHandler {
+	return &PartnerHandler{
+		availabilityService: availabilityService,
+		planService:         planService,
+		log:                 log,
+	}
+}
+
+// GetAvailability handles GET /partner/v1/availability
+func (h *PartnerHandler) GetAvailability(c *gin.Context) {
+	var query dto.PartnerAvailabilityRequest
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondWithBindError(c, err, h.log, "partner availability")
+		return
+	}
+
+	req := &dto.OptionAvailabilityRequest{Prefecture: query.Prefecture, City: query.City}
+	for _, optionTypes := range query.OptionTypes {
+		for _, optionType := range strings.Split(optionTypes, ",") {
+			if optionType = strings.TrimSpace(optionType); optionType != "" {
+				req.OptionTypes = append(req.OptionTypes, optionType)
+			}
+		}
+	}
+
+	resp, err := h.availabilityService.CheckAvailability(c.Request.Context(), req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "check partner availability", ErrorCodeOptionNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// GetPlans handles GET /partner/v1/plans
+func (h *PartnerHandler) GetPlans(c *gin.Context) {
+	resp, err := h.planService.GetAvailablePlans(c.Request.Context())
+	if err != nil {
+		handleServiceError(c, err, h.log, "get partner plans", ErrorCodePlanNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
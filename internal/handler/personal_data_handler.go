@@ -0,0 +1,69 @@
+// Package handler provides HTTP handlers for personal data requests.
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// PersonalDataHandler handles personal data export and erasure HTTP requests
+type PersonalDataHandler struct {
+	personalDataService service.PersonalDataService
+	log                 *logger.Logger
+}
+
+// NewPersonalDataHandler creates a new personal data handler
+func NewPersonalDataHandler(personalDataService service.PersonalDataService, log *logger.Logger) *PersonalDataHandler {
+	return &PersonalDataHandler{
+		personalDataService: personalDataService,
+		log:                 log,
+	}
+}
+
+// ExportUser handles POST /api/v1/users/:id/export
+func (h *PersonalDataHandler) ExportUser(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrorCodeInvalidUserID, "User ID must be a valid integer", nil, nil)
+		return
+	}
+
+	resp, err := h.personalDataService.Export(c.Request.Context(), userID, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "export personal data", ErrorCodeUserNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// EraseUser handles POST /api/v1/users/:id/erase
+func (h *PersonalDataHandler) EraseUser(c *gin.Context) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrorCodeInvalidUserID, "User ID must be a valid integer", nil, nil)
+		return
+	}
+
+	// The body is optional and only carries the reason recorded in the audit log
+	var req dto.PersonalDataEraseRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondWithBindError(c, err, h.log, "personal data erase")
+			return
+		}
+	}
+
+	resp, err := h.personalDataService.Erase(c.Request.Context(), userID, &req, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "erase personal data", ErrorCodeUserNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
@@ -0,0 +1,73 @@
+// Package handler provides HTTP handlers for SMS phone verification.
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// PhoneVerificationHandler handles SMS phone verification HTTP requests
+type PhoneVerificationHandler struct {
+	phoneService service.PhoneVerificationService
+	log          *logger.Logger
+}
+
+// NewPhoneVerificationHandler creates a new phone verification handler
+func NewPhoneVerificationHandler(
+	phoneService service.PhoneVerificationService, log *logger.Logger,
+) *PhoneVerificationHandler {
+	return &PhoneVerificationHandler{
+		phoneService: phoneService,
+		log:          log,
+	}
+}
+
+// SendCode handles POST /api/v1/users/phone/send-code
+func (h *PhoneVerificationHandler) SendCode(c *gin.Context) {
+	var req dto.PhoneSendCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "phone send code")
+		return
+	}
+
+	resp, err := h.phoneService.SendCode(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrPhoneCodeCooldown) {
+			respondWithError(c, http.StatusTooManyRequests, ErrorCodePhoneCodeCooldown,
+				MessagePhoneCodeCooldown, nil, nil)
+			return
+		}
+		handleServiceError(c, err, h.log, "send phone verification code", ErrorCodeNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusAccepted, resp)
+}
+
+// VerifyCode handles POST /api/v1/users/phone/verify-code
+func (h *PhoneVerificationHandler) VerifyCode(c *gin.Context) {
+	var req dto.PhoneVerifyCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "phone verify code")
+		return
+	}
+
+	resp, err := h.phoneService.VerifyCode(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrPhoneVerificationFailed) {
+			h.log.WithContext(c.Request.Context()).WithField("client_ip", c.ClientIP()).Warn("Phone verification failed")
+			respondWithError(c, http.StatusBadRequest, ErrorCodePhoneVerificationFailed,
+				MessagePhoneVerificationFailed, nil, nil)
+			return
+		}
+		handleServiceError(c, err, h.log, "verify phone code", ErrorCodeNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
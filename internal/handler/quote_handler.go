@@ -0,0 +1,42 @@
+// Package handler provides HTTP handlers for price quotes.
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// QuoteHandler handles quote HTTP requests
+type QuoteHandler struct {
+	quoteService service.QuoteService
+	log          *logger.Logger
+}
+
+// NewQuoteHandler creates a new quote handler
+func NewQuoteHandler(quoteService service.QuoteService, log *logger.Logger) *QuoteHandler {
+	return &QuoteHandler{
+		quoteService: quoteService,
+		log:          log,
+	}
+}
+
+// CreateQuote handles POST /api/v1/quotes
+func (h *QuoteHandler) CreateQuote(c *gin.Context) {
+	var req dto.QuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "quote")
+		return
+	}
+
+	resp, err := h.quoteService.Quote(c.Request.Context(), &req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "create quote", ErrorCodePlanNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
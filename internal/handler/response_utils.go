@@ -2,10 +2,15 @@
 package handler
 
 import (
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
 	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
 	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
 )
 
@@ -35,11 +40,21 @@ func respondWithBindError(c *gin.Context, err error, log *logger.Logger, operati
 		Error: &dto.APIError{
 			Code:    ErrorCodeInvalidRequest,
 			Message: MessageInvalidRequest,
-			Details: map[string]string{"bind_error": err.Error()},
+			Details: bindErrorDetails(err),
 		},
 	})
 }
 
+// bindErrorDetails returns the decoder's message for a request that failed to bind. The
+// message names Go types and struct fields, so it is only sent in debug mode; Gin runs in
+// debug mode exactly when Config.Debug is set.
+func bindErrorDetails(err error) map[string]string {
+	if !gin.IsDebugging() {
+		return nil
+	}
+	return map[string]string{"bind_error": err.Error()}
+}
+
 // respondWithSuccess sends a success response
 func respondWithSuccess(c *gin.Context, statusCode int, data interface{}) {
 	c.JSON(statusCode, dto.APIResponse{
@@ -48,24 +63,57 @@ func respondWithSuccess(c *gin.Context, statusCode int, data interface{}) {
 	})
 }
 
-// handleServiceError determines the appropriate error response based on error type
+// respondWithVersionConflict sends a 409 naming the version the update was based on and the
+// current one, so that the client can get the resource again and reapply its changes
+func respondWithVersionConflict(c *gin.Context, conflict *apperr.VersionConflictError, log *logger.Logger) {
+	if log != nil {
+		log.WithError(conflict).Info("Update rejected by version conflict")
+	}
+
+	c.JSON(http.StatusConflict, dto.APIResponse{
+		Success: false,
+		Error: &dto.APIError{
+			Code:    ErrorCodeVersionConflict,
+			Message: MessageVersionConflict,
+			Details: map[string]string{
+				"resource":         conflict.Resource,
+				"id":               conflict.ID,
+				"expected_version": strconv.Itoa(conflict.ExpectedVersion),
+				"current_version":  strconv.Itoa(conflict.CurrentVersion),
+			},
+		},
+	})
+}
+
+// handleServiceError maps the error kind (see package apperr) to a response; errors
+// without a kind are internal errors
 func handleServiceError(c *gin.Context, err error, log *logger.Logger, operation string, notFoundCode string) {
+	if isExternalAPIError(err) {
+		respondWithExternalAPIError(c, err, log, operation)
+		return
+	}
+	var conflict *apperr.VersionConflictError
+	if errors.As(err, &conflict) {
+		respondWithVersionConflict(c, conflict, log)
+		return
+	}
+
 	statusCode := http.StatusInternalServerError
 	errorCode := ErrorCodeInternalError
 
 	switch {
-	case isValidationError(err):
+	case errors.Is(err, apperr.ErrValidation):
 		statusCode = http.StatusBadRequest
 		errorCode = ErrorCodeValidationError
-	case isNotFoundError(err):
+	case errors.Is(err, apperr.ErrNotFound), errors.Is(err, apperr.ErrExpired):
 		statusCode = http.StatusNotFound
 		errorCode = notFoundCode
-	case isDuplicateError(err):
+	case errors.Is(err, apperr.ErrDuplicate):
 		statusCode = http.StatusConflict
 		errorCode = ErrorCodeDuplicateError
-	case isExpiredError(err):
-		statusCode = http.StatusNotFound
-		errorCode = notFoundCode
+	case errors.Is(err, apperr.ErrLegalHold):
+		statusCode = http.StatusConflict
+		errorCode = ErrorCodeLegalHold
 	}
 
 	if log != nil {
@@ -81,6 +129,50 @@ func handleServiceError(c *gin.Context, err error, log *logger.Logger, operation
 	})
 }
 
+// strictExternalAPIErrors are the codes of external API failures rejected in strict mode,
+// naming the data left unverified instead of the API
+var strictExternalAPIErrors = map[string]struct {
+	code    ErrorCode
+	message string
+}{
+	service.ExternalAPIInventory: {ErrorCodeInventoryUnverified, MessageInventoryUnverified},
+	service.ExternalAPIRegion:    {ErrorCodeRegionUnverified, MessageRegionUnverified},
+	service.ExternalAPIAddress:   {ErrorCodeAddressUnverified, MessageAddressUnverified},
+}
+
+// respondWithExternalAPIError sends a 503 for a failed external API with a message users can
+// be shown as is, asking the client to retry after the wait the API suggested
+func respondWithExternalAPIError(c *gin.Context, err error, log *logger.Logger, operation string) {
+	appErr := NewExternalAPIError(ErrorCodeExternalAPIError, MessageExternalAPIUnavailable, err)
+	if errors.Is(err, apperr.ErrExternalTimeout) {
+		appErr = NewExternalAPIError(ErrorCodeExternalAPITimeout, MessageExternalAPITimeout, err)
+	}
+
+	retryAfter := service.DefaultExternalRetryAfter
+	var externalErr *service.ExternalAPIError
+	if errors.As(err, &externalErr) {
+		if unverified, ok := strictExternalAPIErrors[externalErr.API]; ok && externalErr.Strict {
+			appErr = NewExternalAPIError(unverified.code, unverified.message, err)
+		}
+		retryAfter = externalErr.RetryAfter
+		appErr.Details = map[string]string{"api": externalErr.API}
+	}
+
+	if log != nil {
+		log.WithError(err).Warnf("Failed to %s", operation)
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	c.JSON(appErr.StatusCode, dto.APIResponse{
+		Success: false,
+		Error: &dto.APIError{
+			Code:    string(appErr.Code),
+			Message: appErr.Message,
+			Details: appErr.Details,
+		},
+	})
+}
+
 // validatePathParam validates that a path parameter is not empty
 func validatePathParam(c *gin.Context, paramName, paramValue, errorCode, errorMessage string, log *logger.Logger) bool {
 	if paramValue == "" {
@@ -0,0 +1,80 @@
+// Package handler provides HTTP handlers for reviewing security events and IP blocks.
+package handler
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// SecurityHandler handles security event review HTTP requests
+type SecurityHandler struct {
+	monitor     *service.SecurityMonitor
+	botDetector *service.BotDetector
+	log         *logger.Logger
+}
+
+// NewSecurityHandler creates a new security handler
+func NewSecurityHandler(
+	monitor *service.SecurityMonitor, botDetector *service.BotDetector, log *logger.Logger,
+) *SecurityHandler {
+	return &SecurityHandler{
+		monitor:     monitor,
+		botDetector: botDetector,
+		log:         log,
+	}
+}
+
+// ListEvents handles GET /api/v1/admin/security/events
+func (h *SecurityHandler) ListEvents(c *gin.Context) {
+	var req dto.SecurityEventListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithBindError(c, err, h.log, "security event list")
+		return
+	}
+
+	resp, err := h.monitor.ListEvents(c.Request.Context(), &req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "list security events", ErrorCodeIPBlockNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// ListBlocks handles GET /api/v1/admin/security/blocks
+func (h *SecurityHandler) ListBlocks(c *gin.Context) {
+	resp, err := h.monitor.ListBlocks(c.Request.Context())
+	if err != nil {
+		handleServiceError(c, err, h.log, "list IP blocks", ErrorCodeIPBlockNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// Unblock handles DELETE /api/v1/admin/security/blocks/:ip
+func (h *SecurityHandler) Unblock(c *gin.Context) {
+	clientIP := c.Param("ip")
+	if net.ParseIP(clientIP) == nil {
+		respondWithError(c, http.StatusBadRequest, ErrorCodeInvalidClientIP,
+			"Client IP must be a valid IPv4 or IPv6 address", nil, nil)
+		return
+	}
+
+	if err := h.monitor.Unblock(c.Request.Context(), clientIP, c.ClientIP()); err != nil {
+		handleServiceError(c, err, h.log, "unblock client IP", ErrorCodeIPBlockNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, map[string]string{"message": "Client IP unblocked"})
+}
+
+// GetBotDetectionStats handles GET /api/v1/admin/security/bot-detection
+func (h *SecurityHandler) GetBotDetectionStats(c *gin.Context) {
+	respondWithSuccess(c, http.StatusOK, h.botDetector.Stats())
+}
@@ -2,6 +2,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -13,13 +14,17 @@ import (
 // SessionHandler handles session-related HTTP requests
 type SessionHandler struct {
 	sessionService service.SessionService
+	botDetector    *service.BotDetector
 	log            *logger.Logger
 }
 
 // NewSessionHandler creates a new session handler
-func NewSessionHandler(sessionService service.SessionService, log *logger.Logger) *SessionHandler {
+func NewSessionHandler(
+	sessionService service.SessionService, botDetector *service.BotDetector, log *logger.Logger,
+) *SessionHandler {
 	return &SessionHandler{
 		sessionService: sessionService,
+		botDetector:    botDetector,
 		log:            log,
 	}
 }
@@ -34,14 +39,22 @@ func (h *SessionHandler) CreateSession(c *gin.Context) {
 			Error: &dto.APIError{
 				Code:    ErrorCodeInvalidRequest,
 				Message: "Invalid request format",
-				Details: map[string]string{"bind_error": err.Error()},
+				Details: bindErrorDetails(err),
 			},
 		})
 		return
 	}
 
 	// Create session
-	resp, err := h.sessionService.CreateSession(c.Request.Context(), &req)
+	resp, err := h.sessionService.CreateSession(c.Request.Context(), &req, c.ClientIP())
+	if errors.Is(err, service.ErrSessionLimitExceeded) {
+		respondWithError(c, http.StatusTooManyRequests, ErrorCodeSessionLimitExceeded, MessageSessionLimitExceeded, nil, nil)
+		return
+	}
+	if errors.Is(err, service.ErrInvalidSessionData) {
+		respondWithError(c, http.StatusBadRequest, string(ErrorCodeInvalidSessionData), err.Error(), nil, nil)
+		return
+	}
 	if err != nil {
 		h.log.WithError(err).Error("Failed to create session")
 		c.JSON(http.StatusInternalServerError, dto.APIResponse{
@@ -53,6 +66,7 @@ func (h *SessionHandler) CreateSession(c *gin.Context) {
 		})
 		return
 	}
+	resp.FormToken = h.botDetector.IssueFormToken()
 
 	h.log.WithField("session_id", resp.SessionID).Info("Session created successfully")
 	c.JSON(http.StatusCreated, dto.APIResponse{
@@ -128,7 +142,7 @@ func (h *SessionHandler) UpdateSession(c *gin.Context) {
 			Error: &dto.APIError{
 				Code:    ErrorCodeInvalidRequest,
 				Message: "Invalid request format",
-				Details: map[string]string{"bind_error": err.Error()},
+				Details: bindErrorDetails(err),
 			},
 		})
 		return
@@ -136,6 +150,10 @@ func (h *SessionHandler) UpdateSession(c *gin.Context) {
 
 	// Update session
 	resp, err := h.sessionService.UpdateSession(c.Request.Context(), sessionID, &req)
+	if errors.Is(err, service.ErrInvalidSessionData) {
+		respondWithError(c, http.StatusBadRequest, string(ErrorCodeInvalidSessionData), err.Error(), nil, nil)
+		return
+	}
 	if err != nil {
 		h.log.WithError(err).WithField("session_id", sessionID).Error("Failed to update session")
 
@@ -164,6 +182,98 @@ func (h *SessionHandler) UpdateSession(c *gin.Context) {
 	})
 }
 
+// UpdateSessionFields handles PATCH /api/v1/sessions/:id/fields
+func (h *SessionHandler) UpdateSessionFields(c *gin.Context) {
+	sessionID := c.Param("id")
+	if !validatePathParam(c, "session ID", sessionID, ErrorCodeMissingSessionID, "Session ID is required", h.log) {
+		return
+	}
+
+	var req dto.SessionFieldsUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "session fields update")
+		return
+	}
+
+	resp, err := h.sessionService.UpdateSessionFields(c.Request.Context(), sessionID, &req)
+	if errors.Is(err, service.ErrSessionVersionConflict) {
+		respondWithError(c, http.StatusConflict, ErrorCodeSessionConflict, MessageSessionConflict, nil, nil)
+		return
+	}
+	if errors.Is(err, service.ErrInvalidSessionData) {
+		respondWithError(c, http.StatusBadRequest, string(ErrorCodeInvalidSessionData), err.Error(), nil, nil)
+		return
+	}
+	if err != nil {
+		handleServiceError(c, err, h.log, "update session fields", ErrorCodeSessionNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// CompleteStep handles POST /api/v1/sessions/:id/steps/:step/complete
+func (h *SessionHandler) CompleteStep(c *gin.Context) {
+	sessionID := c.Param("id")
+	if !validatePathParam(c, "session ID", sessionID, ErrorCodeMissingSessionID, "Session ID is required", h.log) {
+		return
+	}
+
+	// An invalid step is reported in the progress, so only lookup and ordering failures are errors
+	resp, err := h.sessionService.CompleteStep(c.Request.Context(), sessionID, c.Param("step"))
+	switch {
+	case errors.Is(err, service.ErrUnknownWizardStep):
+		respondWithError(c, http.StatusNotFound, ErrorCodeWizardStepNotFound, MessageWizardStepNotFound, nil, nil)
+		return
+	case errors.Is(err, service.ErrWizardStepOutOfOrder):
+		respondWithError(c, http.StatusConflict, ErrorCodeWizardOutOfOrder, err.Error(), nil, nil)
+		return
+	case err != nil:
+		handleServiceError(c, err, h.log, "complete wizard step", ErrorCodeSessionNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// GetProgress handles GET /api/v1/sessions/:id/progress
+func (h *SessionHandler) GetProgress(c *gin.Context) {
+	sessionID := c.Param("id")
+	if !validatePathParam(c, "session ID", sessionID, ErrorCodeMissingSessionID, "Session ID is required", h.log) {
+		return
+	}
+
+	resp, err := h.sessionService.GetProgress(c.Request.Context(), sessionID)
+	if err != nil {
+		handleServiceError(c, err, h.log, "get wizard progress", ErrorCodeSessionNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// GetSummary handles GET /api/v1/sessions/:id/summary
+func (h *SessionHandler) GetSummary(c *gin.Context) {
+	sessionID := c.Param("id")
+	if !validatePathParam(c, "session ID", sessionID, ErrorCodeMissingSessionID, "Session ID is required", h.log) {
+		return
+	}
+
+	var req dto.SessionSummaryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithBindError(c, err, h.log, "session summary")
+		return
+	}
+
+	resp, err := h.sessionService.GetSummary(c.Request.Context(), sessionID, &req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "get session summary", ErrorCodeSessionNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
 // DeleteSession handles DELETE /api/v1/sessions/:id
 func (h *SessionHandler) DeleteSession(c *gin.Context) {
 	sessionID := c.Param("id")
@@ -0,0 +1,59 @@
+// Package handler provides HTTP handlers for admin statistics.
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// StatsHandler handles admin statistics HTTP requests
+type StatsHandler struct {
+	statsService service.StatsService
+	log          *logger.Logger
+}
+
+// NewStatsHandler creates a new stats handler
+func NewStatsHandler(statsService service.StatsService, log *logger.Logger) *StatsHandler {
+	return &StatsHandler{
+		statsService: statsService,
+		log:          log,
+	}
+}
+
+// GetFunnel handles GET /api/v1/admin/stats/funnel
+func (h *StatsHandler) GetFunnel(c *gin.Context) {
+	var req dto.AdminFunnelRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithBindError(c, err, h.log, "funnel stats")
+		return
+	}
+
+	resp, err := h.statsService.GetFunnel(c.Request.Context(), &req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "get funnel stats", ErrorCodeSessionNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// GetValidationFailures handles GET /api/v1/admin/stats/validation-failures
+func (h *StatsHandler) GetValidationFailures(c *gin.Context) {
+	var req dto.AdminValidationFailuresRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithBindError(c, err, h.log, "validation failure stats")
+		return
+	}
+
+	resp, err := h.statsService.GetValidationFailures(c.Request.Context(), &req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "get validation failure stats", ErrorCodeSessionNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
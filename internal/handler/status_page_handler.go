@@ -0,0 +1,213 @@
+// Package handler provides the server-rendered admin status page.
+package handler
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"html/template"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/pkg/external"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/lru"
+)
+
+// statusPageRefresh is how often the status page reloads itself, in seconds
+const statusPageRefresh = 30
+
+// StatusJob describes a background job listed on the status page
+type StatusJob struct {
+	Name     string
+	Interval time.Duration
+	Enabled  bool
+}
+
+// StatusJobs lists the background jobs of the server in the order they are shown
+type StatusJobs []StatusJob
+
+// statusCache is a cache row of the status page
+type statusCache struct {
+	Name    string
+	Entries int
+	Max     int
+	Hits    int64
+	Misses  int64
+	HitRate string
+}
+
+// statusStore is a memory store row of the status page
+type statusStore struct {
+	Name  string
+	Stats lru.Stats
+}
+
+// statusPage is the data the status page template renders
+type statusPage struct {
+	GeneratedAt  time.Time
+	Refresh      int
+	Health       string
+	Checks       map[string]string
+	APIs         []*external.ClientStatus
+	Jobs         StatusJobs
+	Caches       []statusCache
+	Stores       []statusStore
+	RecentErrors []logger.RecentEntry
+}
+
+// StatusPageHandler serves an HTML summary of the server's state for operators without a
+// metrics stack
+type StatusPageHandler struct {
+	health       *HealthHandler
+	externalAPI  *external.Manager
+	memoryStores MemoryStores
+	jobs         StatusJobs
+	recentErrors *logger.RecentErrors
+	log          *logger.Logger
+}
+
+// NewStatusPageHandler creates a new status page handler
+func NewStatusPageHandler(
+	health *HealthHandler,
+	externalAPI *external.Manager,
+	memoryStores MemoryStores,
+	jobs StatusJobs,
+	recentErrors *logger.RecentErrors,
+	log *logger.Logger,
+) *StatusPageHandler {
+	return &StatusPageHandler{
+		health:       health,
+		externalAPI:  externalAPI,
+		memoryStores: memoryStores,
+		jobs:         jobs,
+		recentErrors: recentErrors,
+		log:          log,
+	}
+}
+
+// GetStatus handles GET /admin/status
+func (h *StatusPageHandler) GetStatus(c *gin.Context) {
+	page := statusPage{
+		GeneratedAt:  time.Now(),
+		Refresh:      statusPageRefresh,
+		APIs:         h.externalAPI.ClientStatuses(),
+		Jobs:         h.jobs,
+		RecentErrors: h.recentErrors.Entries(),
+	}
+	page.Health, page.Checks, _ = h.health.check(c.Request.Context())
+
+	for _, api := range page.APIs {
+		if api.Cache == nil {
+			continue
+		}
+		page.Caches = append(page.Caches, statusCache{
+			Name:    "external_api." + api.Name,
+			Entries: api.Cache.Entries,
+			Max:     api.Cache.MaxEntries,
+			Hits:    api.Cache.Hits,
+			Misses:  api.Cache.Misses,
+			HitRate: hitRate(api.Cache.Hits, api.Cache.Misses),
+		})
+	}
+	for name, store := range h.memoryStores {
+		page.Stores = append(page.Stores, statusStore{Name: name, Stats: store.Stats()})
+	}
+	slices.SortFunc(page.Stores, func(a, b statusStore) int { return cmp.Compare(a.Name, b.Name) })
+
+	var body bytes.Buffer
+	if err := statusPageTemplate.Execute(&body, page); err != nil {
+		h.log.WithError(err).Error("Failed to render status page")
+		c.String(http.StatusInternalServerError, "Failed to render status page")
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", body.Bytes())
+}
+
+// hitRate formats the share of lookups answered from a cache
+func hitRate(hits, misses int64) string {
+	if hits+misses == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(hits)*100/float64(hits+misses))
+}
+
+// statusPageTemplate renders the status page; it needs no scripts or external assets
+var statusPageTemplate = template.Must(template.New("status").Funcs(template.FuncMap{
+	"timestamp": func(t time.Time) string { return t.Format("2006-01-02 15:04:05") },
+	"optionalTimestamp": func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.Format("2006-01-02 15:04:05")
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="ja">
+<head>
+<meta charset="utf-8">
+<meta http-equiv="refresh" content="{{.Refresh}}">
+<title>normal-form-app status</title>
+<style>
+body { font-family: sans-serif; margin: 1.5rem; color: #222; }
+h1 { font-size: 1.4rem; }
+h2 { font-size: 1.1rem; margin-top: 2rem; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 0.3rem 0.6rem; text-align: left; vertical-align: top; }
+th { background: #f3f3f3; }
+.healthy, .closed { color: #176f2c; }
+.unhealthy, .open { color: #b3261e; font-weight: bold; }
+.half_open, .degraded { color: #9a6700; }
+.muted { color: #777; }
+.spaced { margin-top: 1rem; }
+</style>
+</head>
+<body>
+<h1>normal-form-app status</h1>
+<p class="muted">Generated {{timestamp .GeneratedAt}}; reloads every {{.Refresh}} seconds</p>
+
+<h2>Health: <span class="{{.Health}}">{{.Health}}</span></h2>
+<table>
+<tr><th>Check</th><th>Result</th></tr>
+{{range $name, $result := .Checks}}<tr><td>{{$name}}</td><td>{{$result}}</td></tr>
+{{end}}</table>
+
+<h2>External APIs</h2>
+<table>
+<tr><th>API</th><th>Circuit breaker</th><th>Failures</th><th>Override</th><th>Last success</th><th>Last error</th></tr>
+{{range .APIs}}<tr><td>{{.Name}}</td>
+{{if .CircuitBreaker}}<td class="{{.CircuitBreaker.State}}">{{.CircuitBreaker.State}}</td>
+<td>{{.CircuitBreaker.ConsecutiveFailures}} / {{.CircuitBreaker.FailureThreshold}}</td>
+<td>{{.CircuitBreaker.Override}}</td>
+<td>{{optionalTimestamp .LastSuccessAt}}</td>
+<td>{{if .LastError}}{{optionalTimestamp .LastErrorAt}}: {{.LastError}}{{else}}-{{end}}</td>
+{{else}}<td class="muted" colspan="5">not configured</td>{{end}}</tr>
+{{end}}</table>
+
+<h2>Background jobs</h2>
+<table>
+<tr><th>Job</th><th>Interval</th><th>Running</th></tr>
+{{range .Jobs}}<tr><td>{{.Name}}</td><td>{{.Interval}}</td><td>{{if .Enabled}}yes{{else}}<span class="muted">no</span>{{end}}</td></tr>
+{{end}}</table>
+
+<h2>Caches</h2>
+<table>
+<tr><th>Cache</th><th>Entries</th><th>Hits</th><th>Misses</th><th>Hit rate</th></tr>
+{{range .Caches}}<tr><td>{{.Name}}</td><td>{{.Entries}} / {{.Max}}</td><td>{{.Hits}}</td><td>{{.Misses}}</td><td>{{.HitRate}}</td></tr>
+{{else}}<tr><td class="muted" colspan="5">No external API response caches are enabled</td></tr>
+{{end}}</table>
+<table class="spaced">
+<tr><th>Memory store</th><th>Entries</th><th>Evictions</th></tr>
+{{range .Stores}}<tr><td>{{.Name}}</td><td>{{.Stats.Entries}} / {{.Stats.MaxEntries}}</td><td>{{.Stats.Evictions}}</td></tr>
+{{end}}</table>
+
+<h2>Recent errors</h2>
+<table>
+<tr><th>Time</th><th>Level</th><th>Message</th><th>Error</th><th>Request ID</th></tr>
+{{range .RecentErrors}}<tr><td>{{timestamp .Time}}</td><td>{{.Level}}</td><td>{{.Message}}</td><td>{{.Error}}</td><td>{{.RequestID}}</td></tr>
+{{else}}<tr><td class="muted" colspan="5">No errors since the server started</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
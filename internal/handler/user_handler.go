@@ -2,26 +2,57 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
 	"github.com/octop162/normal-form-app-by-claude/internal/dto"
 	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/pkg/etag"
+	"github.com/octop162/normal-form-app-by-claude/pkg/locale"
 	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/masking"
 )
 
 // UserHandler handles user-related HTTP requests
 type UserHandler struct {
-	userService service.UserService
-	log         *logger.Logger
+	userService        service.UserService
+	sessionService     service.SessionService
+	reservationService service.ReservationService
+	userCreateQueue    *service.UserCreateQueue
+	captchaService     service.CaptchaService
+	botDetector        *service.BotDetector
+	telemetry          *service.ValidationTelemetry
+	masking            *masking.Policy
+	log                *logger.Logger
 }
 
 // NewUserHandler creates a new user handler
-func NewUserHandler(userService service.UserService, log *logger.Logger) *UserHandler {
+func NewUserHandler(
+	userService service.UserService,
+	sessionService service.SessionService,
+	reservationService service.ReservationService,
+	userCreateQueue *service.UserCreateQueue,
+	captchaService service.CaptchaService,
+	botDetector *service.BotDetector,
+	telemetry *service.ValidationTelemetry,
+	maskingPolicy *masking.Policy,
+	log *logger.Logger,
+) *UserHandler {
 	return &UserHandler{
-		userService: userService,
-		log:         log,
+		userService:        userService,
+		sessionService:     sessionService,
+		reservationService: reservationService,
+		userCreateQueue:    userCreateQueue,
+		captchaService:     captchaService,
+		botDetector:        botDetector,
+		telemetry:          telemetry,
+		masking:            maskingPolicy,
+		log:                log,
 	}
 }
 
@@ -35,14 +66,48 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 			Error: &dto.APIError{
 				Code:    ErrorCodeInvalidRequest,
 				Message: "Invalid request format",
-				Details: map[string]string{"bind_error": err.Error()},
+				Details: bindErrorDetails(err),
 			},
 		})
 		return
 	}
 
+	// Bot detection runs first so that automated submissions do not spend captcha verifications
+	if !h.detectBot(c, &req) || !h.verifyCaptcha(c, &req, h.captchaService.Redeem) {
+		return
+	}
+
+	// Registrations from the form wizard must submit the data its steps validated
+	err := h.sessionService.CheckWizardComplete(c.Request.Context(), c.GetHeader(HeaderSessionID), &req)
+	if errors.Is(err, service.ErrWizardIncomplete) {
+		respondWithError(c, http.StatusConflict, ErrorCodeWizardIncomplete, err.Error(), nil, nil)
+		return
+	}
+	if err != nil {
+		handleServiceError(c, err, h.log, "check form wizard", ErrorCodeSessionNotFound)
+		return
+	}
+
+	// The user is addressed in the language chosen explicitly or preferred by the browser
+	req.Locale = locale.Negotiate(req.Locale, c.GetHeader("Accept-Language"))
+
+	// During spikes registrations are accepted now and created in the background
+	if h.userCreateQueue.Enabled() {
+		h.enqueueUser(c, &req)
+		return
+	}
+
 	// Create user
 	resp, err := h.userService.CreateUser(c.Request.Context(), &req)
+	var duplicateErr *service.PossibleDuplicateError
+	if errors.As(err, &duplicateErr) {
+		respondWithPossibleDuplicate(c, duplicateErr)
+		return
+	}
+	if isExternalAPIError(err) {
+		respondWithExternalAPIError(c, err, h.log, "create user")
+		return
+	}
 	if err != nil {
 		h.log.WithError(err).Error("Failed to create user")
 
@@ -69,6 +134,14 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
+	// The user is already created, so holds left unconsumed are only logged; they expire on their own
+	if err := h.reservationService.Consume(c.Request.Context(), c.GetHeader(HeaderSessionID), resp.ID); err != nil {
+		h.log.WithError(err).WithField("user_id", resp.ID).Warn("Failed to consume option reservations")
+	}
+	if err := h.sessionService.RecordSubmission(c.Request.Context(), c.GetHeader(HeaderSessionID)); err != nil {
+		h.log.WithError(err).WithField("user_id", resp.ID).Warn("Failed to record form session submission")
+	}
+
 	h.log.WithField("user_id", resp.ID).Info("User created successfully")
 	c.JSON(http.StatusCreated, dto.APIResponse{
 		Success: true,
@@ -76,6 +149,91 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 	})
 }
 
+// enqueueUser queues a registration and responds 202 with the ticket to poll
+func (h *UserHandler) enqueueUser(c *gin.Context, req *dto.UserCreateRequest) {
+	resp, err := h.userCreateQueue.Enqueue(c.Request.Context(), req, c.GetHeader(HeaderSessionID))
+	if err != nil {
+		handleServiceError(c, err, h.log, "queue user creation", ErrorCodeInternalError)
+		return
+	}
+
+	resp.StatusURL = userCreateTicketURL(c, resp.TicketID)
+	c.Header("Location", resp.StatusURL)
+	respondWithSuccess(c, http.StatusAccepted, resp)
+}
+
+// GetCreateTicket handles GET /api/v1/users/tickets/:ticket_id
+func (h *UserHandler) GetCreateTicket(c *gin.Context) {
+	resp, err := h.userCreateQueue.GetTicket(c.Request.Context(), c.Param("ticket_id"))
+	if err != nil {
+		handleServiceError(c, err, h.log, "get user create ticket", ErrorCodeUserCreateTicketNotFound)
+		return
+	}
+
+	resp.StatusURL = userCreateTicketURL(c, resp.TicketID)
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// verifyCaptcha verifies the captcha token of a registration with verify, the captcha
+// service's Verify for validations or Redeem for registrations, responding with an error when
+// it fails. The token is cleared so that it is never queued or stored with the registration.
+func (h *UserHandler) verifyCaptcha(
+	c *gin.Context, req *dto.UserCreateRequest, verify func(ctx context.Context, token, remoteIP string) error,
+) bool {
+	token := req.CaptchaToken
+	req.CaptchaToken = ""
+
+	err := verify(c.Request.Context(), token, c.ClientIP())
+	if errors.Is(err, service.ErrCaptchaFailed) {
+		respondWithError(c, http.StatusBadRequest, ErrorCodeCaptchaFailed, MessageCaptchaFailed, nil, nil)
+		return false
+	}
+	if err != nil {
+		handleServiceError(c, err, h.log, "verify captcha", ErrorCodeInternalError)
+		return false
+	}
+	return true
+}
+
+// detectBot rejects a registration that filled the honeypot field or came too soon after
+// the form was issued. The reason is only logged, so that bots cannot learn which check
+// caught them. The bot detection fields are cleared so that they are never stored.
+func (h *UserHandler) detectBot(c *gin.Context, req *dto.UserCreateRequest) bool {
+	honeypot, formToken := req.Website, req.FormToken
+	req.Website, req.FormToken = "", ""
+
+	err := h.botDetector.Check(c.Request.Context(), honeypot, formToken, c.ClientIP())
+	if errors.Is(err, service.ErrBotDetected) {
+		respondWithError(c, http.StatusBadRequest, ErrorCodeSubmissionRejected, MessageSubmissionRejected, nil, nil)
+		return false
+	}
+	return true
+}
+
+// userCreateTicketURL returns the URL polled for the status of a queued registration, in the
+// API version the request was made in
+func userCreateTicketURL(c *gin.Context, ticketID string) string {
+	version, _, _ := strings.Cut(c.FullPath(), "/users")
+	return version + "/users/tickets/" + ticketID
+}
+
+// respondWithPossibleDuplicate sends a 409 listing the criteria the registration matched
+func respondWithPossibleDuplicate(c *gin.Context, err *service.PossibleDuplicateError) {
+	details := make(map[string]string, len(err.Criteria))
+	for _, criterion := range err.Criteria {
+		details[criterion] = "matches an existing user"
+	}
+
+	c.JSON(http.StatusConflict, dto.APIResponse{
+		Success: false,
+		Error: &dto.APIError{
+			Code:    ErrorCodePossibleDuplicate,
+			Message: MessagePossibleDuplicate,
+			Details: details,
+		},
+	})
+}
+
 // ValidateUser handles POST /api/v1/users/validate
 func (h *UserHandler) ValidateUser(c *gin.Context) {
 	var req dto.UserValidateRequest
@@ -86,14 +244,23 @@ func (h *UserHandler) ValidateUser(c *gin.Context) {
 			Error: &dto.APIError{
 				Code:    ErrorCodeInvalidRequest,
 				Message: "Invalid request format",
-				Details: map[string]string{"bind_error": err.Error()},
+				Details: bindErrorDetails(err),
 			},
 		})
 		return
 	}
 
+	if !h.verifyCaptcha(c, &req.UserCreateRequest, h.captchaService.Verify) {
+		return
+	}
+
 	// Validate user data
 	resp, err := h.userService.ValidateUserData(c.Request.Context(), &req)
+	if isExternalAPIError(err) {
+		// Only strict mode checks registrations against external APIs
+		respondWithExternalAPIError(c, err, h.log, "validate user data")
+		return
+	}
 	if err != nil {
 		h.log.WithError(err).Error("Failed to validate user data")
 		c.JSON(http.StatusInternalServerError, dto.APIResponse{
@@ -105,6 +272,7 @@ func (h *UserHandler) ValidateUser(c *gin.Context) {
 		})
 		return
 	}
+	h.telemetry.Record(c.Request.Context(), service.ValidationStageValidate, resp.Codes)
 
 	c.JSON(http.StatusOK, dto.APIResponse{
 		Success: true,
@@ -151,13 +319,17 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		return
 	}
 
+	role := masking.RoleFromContext(c.Request.Context())
+	resp.Mask(h.masking, role)
+	c.Header(etag.HeaderETag, service.UserETag(resp.ID, resp.Version, role))
 	c.JSON(http.StatusOK, dto.APIResponse{
 		Success: true,
 		Data:    resp,
 	})
 }
 
-// UpdateUser handles PUT /api/v1/users/:id
+// UpdateUser handles PUT /api/v1/users/:id. With If-Match, the update only applies if the
+// user has not changed since the client read it.
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	idParam := c.Param("id")
 	userID, err := strconv.Atoi(idParam)
@@ -173,7 +345,7 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	var req dto.UserCreateRequest
+	var req dto.UserUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		h.log.WithError(err).Error("Failed to bind user update request")
 		c.JSON(http.StatusBadRequest, dto.APIResponse{
@@ -181,15 +353,26 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 			Error: &dto.APIError{
 				Code:    ErrorCodeInvalidRequest,
 				Message: "Invalid request format",
-				Details: map[string]string{"bind_error": err.Error()},
+				Details: bindErrorDetails(err),
 			},
 		})
 		return
 	}
 
 	// Update user
-	resp, err := h.userService.UpdateUser(c.Request.Context(), userID, &req)
+	resp, err := h.userService.UpdateUser(c.Request.Context(), userID, &req, c.GetHeader(etag.HeaderIfMatch))
 	if err != nil {
+		if errors.Is(err, service.ErrUserPreconditionFailed) {
+			h.log.WithError(err).WithField("user_id", userID).Info("User update precondition failed")
+			respondWithError(c, http.StatusPreconditionFailed, ErrorCodePreconditionFailed,
+				MessageUserPreconditionFailed, nil, nil)
+			return
+		}
+		var conflict *apperr.VersionConflictError
+		if errors.As(err, &conflict) {
+			respondWithVersionConflict(c, conflict, h.log)
+			return
+		}
 		h.log.WithError(err).WithField("user_id", userID).Error("Failed to update user")
 
 		statusCode := http.StatusInternalServerError
@@ -217,12 +400,76 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	}
 
 	h.log.WithField("user_id", userID).Info("User updated successfully")
+	role := masking.RoleFromContext(c.Request.Context())
+	resp.Mask(h.masking, role)
+	c.Header(etag.HeaderETag, service.UserETag(resp.ID, resp.Version, role))
 	c.JSON(http.StatusOK, dto.APIResponse{
 		Success: true,
 		Data:    resp,
 	})
 }
 
+// PreviewUpdateUser handles POST /api/v1/users/:id/preview-update
+func (h *UserHandler) PreviewUpdateUser(c *gin.Context) {
+	idParam := c.Param("id")
+	userID, err := strconv.Atoi(idParam)
+	if err != nil {
+		h.log.WithError(err).WithField("id_param", idParam).Error("Invalid user ID")
+		respondWithError(c, http.StatusBadRequest, ErrorCodeInvalidUserID, "User ID must be a valid integer", nil, nil)
+		return
+	}
+
+	var req dto.UserCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "user update preview")
+		return
+	}
+
+	// Validation problems are part of the preview, so only lookup failures are errors here
+	resp, err := h.userService.PreviewUpdateUser(c.Request.Context(), userID, &req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "preview user update", ErrorCodeUserNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// PatchUser handles PATCH /api/v1/users/:id. If-Match is honoured as in UpdateUser.
+func (h *UserHandler) PatchUser(c *gin.Context) {
+	idParam := c.Param("id")
+	userID, err := strconv.Atoi(idParam)
+	if err != nil {
+		h.log.WithError(err).WithField("id_param", idParam).Error("Invalid user ID")
+		respondWithError(c, http.StatusBadRequest, ErrorCodeInvalidUserID, "User ID must be a valid integer", nil, nil)
+		return
+	}
+
+	var req dto.UserPatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "user patch")
+		return
+	}
+
+	resp, err := h.userService.PatchUser(c.Request.Context(), userID, &req, c.GetHeader(etag.HeaderIfMatch))
+	if errors.Is(err, service.ErrUserPreconditionFailed) {
+		h.log.WithError(err).WithField("user_id", userID).Info("User patch precondition failed")
+		respondWithError(c, http.StatusPreconditionFailed, ErrorCodePreconditionFailed,
+			MessageUserPreconditionFailed, nil, nil)
+		return
+	}
+	if err != nil {
+		handleServiceError(c, err, h.log, "patch user", ErrorCodeUserNotFound)
+		return
+	}
+
+	h.log.WithField("user_id", userID).Info("User patched successfully")
+	role := masking.RoleFromContext(c.Request.Context())
+	resp.Mask(h.masking, role)
+	c.Header(etag.HeaderETag, service.UserETag(resp.ID, resp.Version, role))
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
 // DeleteUser handles DELETE /api/v1/users/:id
 func (h *UserHandler) DeleteUser(c *gin.Context) {
 	idParam := c.Param("id")
@@ -239,15 +486,30 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
+	// The body is optional and only carries the deletion reason
+	var req dto.UserDeleteRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respondWithBindError(c, err, h.log, "user delete")
+			return
+		}
+	}
+
 	// Delete user
-	err = h.userService.DeleteUser(c.Request.Context(), userID)
+	err = h.userService.DeleteUser(c.Request.Context(), userID, req.Reason)
 	if err != nil {
 		h.log.WithError(err).WithField("user_id", userID).Error("Failed to delete user")
 
 		statusCode := http.StatusInternalServerError
 		errorCode := ErrorCodeInternalError
 
-		if isNotFoundError(err) {
+		if isLegalHoldError(err) {
+			statusCode = http.StatusConflict
+			errorCode = ErrorCodeLegalHold
+		} else if isValidationError(err) {
+			statusCode = http.StatusBadRequest
+			errorCode = ErrorCodeValidationError
+		} else if isNotFoundError(err) {
 			statusCode = http.StatusNotFound
 			errorCode = ErrorCodeUserNotFound
 		}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/middleware"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/pkg/etag"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/masking"
+)
+
+// stubUserService serves a single user, checking If-Match as the user service does
+type stubUserService struct {
+	service.UserService
+	user dto.UserResponse
+}
+
+func (s *stubUserService) GetUserByID(_ context.Context, _ int) (*dto.UserResponse, error) {
+	user := s.user
+	return &user, nil
+}
+
+func (s *stubUserService) UpdateUser(
+	_ context.Context, _ int, _ *dto.UserUpdateRequest, ifMatch string,
+) (*dto.UserResponse, error) {
+	return s.write(ifMatch)
+}
+
+func (s *stubUserService) PatchUser(
+	_ context.Context, _ int, _ *dto.UserPatchRequest, ifMatch string,
+) (*dto.UserResponse, error) {
+	return s.write(ifMatch)
+}
+
+func (s *stubUserService) write(ifMatch string) (*dto.UserResponse, error) {
+	tag := service.UserETag(s.user.ID, s.user.Version, masking.RolePublic)
+	if ifMatch != "" && !etag.MatchesAny(ifMatch, tag) {
+		return nil, fmt.Errorf("%w: user %d is at %s", service.ErrUserPreconditionFailed, s.user.ID, tag)
+	}
+	user := s.user
+	user.Version++
+	return &user, nil
+}
+
+// newUserTestEngine serves the user routes of a handler backed by a stub user at version 2
+func newUserTestEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := NewUserHandler(&stubUserService{user: dto.UserResponse{ID: 1, Version: 2}},
+		nil, nil, nil, nil, nil, nil, nil, logger.NewLogger("error"))
+
+	r := gin.New()
+	r.GET("/api/v1/users/:id", middleware.ETag(), h.GetUser)
+	r.PUT("/api/v1/users/:id", h.UpdateUser)
+	r.PATCH("/api/v1/users/:id", h.PatchUser)
+	return r
+}
+
+func TestUserHandler_Preconditions(t *testing.T) {
+	current := service.UserETag(1, 2, masking.RolePublic)
+	stale := service.UserETag(1, 1, masking.RolePublic)
+
+	tests := []struct {
+		name       string
+		method     string
+		header     string
+		tag        string
+		wantStatus int
+		wantCode   string
+	}{
+		{"get without If-None-Match", http.MethodGet, "", "", http.StatusOK, ""},
+		{"get with current If-None-Match", http.MethodGet, etag.HeaderIfNoneMatch, current, http.StatusNotModified, ""},
+		{"get with stale If-None-Match", http.MethodGet, etag.HeaderIfNoneMatch, stale, http.StatusOK, ""},
+		{"put with current If-Match", http.MethodPut, etag.HeaderIfMatch, current, http.StatusOK, ""},
+		{"put with stale If-Match", http.MethodPut, etag.HeaderIfMatch, stale, http.StatusPreconditionFailed, ErrorCodePreconditionFailed},
+		{"patch without If-Match", http.MethodPatch, "", "", http.StatusOK, ""},
+		{"patch with current If-Match", http.MethodPatch, etag.HeaderIfMatch, current, http.StatusOK, ""},
+		{"patch with stale If-Match", http.MethodPatch, etag.HeaderIfMatch, stale, http.StatusPreconditionFailed, ErrorCodePreconditionFailed},
+	}
+
+	r := newUserTestEngine()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/v1/users/1", strings.NewReader(`{}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.tag)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantCode != "" && !strings.Contains(w.Body.String(), `"code":"`+tt.wantCode+`"`) {
+				t.Errorf("body = %s, want error code %s", w.Body, tt.wantCode)
+			}
+			if w.Code == http.StatusOK && w.Header().Get(etag.HeaderETag) == "" {
+				t.Errorf("response has no %s header", etag.HeaderETag)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+// Package handler provides HTTP handlers for self-service user lookups.
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// UserLookupHandler handles self-service lookup HTTP requests
+type UserLookupHandler struct {
+	lookupService service.UserLookupService
+	log           *logger.Logger
+}
+
+// NewUserLookupHandler creates a new user lookup handler
+func NewUserLookupHandler(lookupService service.UserLookupService, log *logger.Logger) *UserLookupHandler {
+	return &UserLookupHandler{
+		lookupService: lookupService,
+		log:           log,
+	}
+}
+
+// StartLookup handles POST /api/v1/users/lookup
+func (h *UserLookupHandler) StartLookup(c *gin.Context) {
+	var req dto.UserLookupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "user lookup")
+		return
+	}
+
+	resp, err := h.lookupService.StartLookup(c.Request.Context(), &req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "start user lookup", ErrorCodeUserNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusAccepted, resp)
+}
+
+// VerifyLookup handles POST /api/v1/users/lookup/verify
+func (h *UserLookupHandler) VerifyLookup(c *gin.Context) {
+	var req dto.UserLookupVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithBindError(c, err, h.log, "user lookup verification")
+		return
+	}
+
+	resp, err := h.lookupService.VerifyLookup(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrLookupVerificationFailed) {
+			h.log.WithContext(c.Request.Context()).WithField("client_ip", c.ClientIP()).Warn("User lookup verification failed")
+			respondWithError(c, http.StatusBadRequest, ErrorCodeLookupVerificationFailed,
+				MessageLookupVerificationFailed, nil, nil)
+			return
+		}
+		handleServiceError(c, err, h.log, "verify user lookup", ErrorCodeUserNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
@@ -0,0 +1,63 @@
+// Package handler provides HTTP handlers for webhook delivery administration.
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/service"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// WebhookDeliveryHandler handles webhook delivery administration HTTP requests
+type WebhookDeliveryHandler struct {
+	deliveryService service.WebhookDeliveryService
+	log             *logger.Logger
+}
+
+// NewWebhookDeliveryHandler creates a new webhook delivery handler
+func NewWebhookDeliveryHandler(
+	deliveryService service.WebhookDeliveryService, log *logger.Logger,
+) *WebhookDeliveryHandler {
+	return &WebhookDeliveryHandler{
+		deliveryService: deliveryService,
+		log:             log,
+	}
+}
+
+// ListDeliveries handles GET /api/v1/admin/webhook-deliveries
+func (h *WebhookDeliveryHandler) ListDeliveries(c *gin.Context) {
+	var req dto.AdminWebhookDeliveryListRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithBindError(c, err, h.log, "webhook delivery list")
+		return
+	}
+
+	resp, err := h.deliveryService.ListDeliveries(c.Request.Context(), &req)
+	if err != nil {
+		handleServiceError(c, err, h.log, "list webhook deliveries", ErrorCodeWebhookDeliveryNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
+
+// ReplayDelivery handles POST /api/v1/admin/webhook-deliveries/:id/replay
+func (h *WebhookDeliveryHandler) ReplayDelivery(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, ErrorCodeInvalidWebhookDeliveryID,
+			"Webhook delivery ID must be a valid integer", nil, nil)
+		return
+	}
+
+	resp, err := h.deliveryService.ReplayDelivery(c.Request.Context(), id, c.ClientIP())
+	if err != nil {
+		handleServiceError(c, err, h.log, "replay webhook delivery", ErrorCodeWebhookDeliveryNotFound)
+		return
+	}
+
+	respondWithSuccess(c, http.StatusOK, resp)
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/pkg/masking"
+)
+
+const (
+	headerAdminToken    = "X-Admin-Token"
+	bearerPrefix        = "Bearer "
+	errorCodeAdminToken = "ADMIN_UNAUTHORIZED"
+	basicChallenge      = `Basic realm="admin", charset="UTF-8"`
+)
+
+// AdminAuth protects admin endpoints with a static API token sent as
+// "Authorization: Bearer <token>" or "X-Admin-Token: <token>". Browsers may send it as the
+// password of HTTP Basic authentication with any user name; they are asked for it when they
+// request an HTML page without it.
+// When no token is configured the admin endpoints are disabled and respond 404.
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.JSON(http.StatusNotFound, ErrorResponse{
+				Error:   "Not Found",
+				Message: "The requested resource was not found",
+				Code:    http.StatusNotFound,
+			})
+			c.Abort()
+			return
+		}
+
+		if !hasAdminToken(c, token) {
+			if strings.Contains(c.GetHeader("Accept"), "text/html") {
+				c.Header("WWW-Authenticate", basicChallenge)
+			}
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    errorCodeAdminToken,
+					"message": "Valid admin token is required",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// CallerRole records the caller role used for response masking: callers presenting
+// the admin token are admins, everyone else is public
+func CallerRole(adminToken string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := masking.RolePublic
+		if hasAdminToken(c, adminToken) {
+			role = masking.RoleAdmin
+		}
+		c.Request = c.Request.WithContext(masking.WithRole(c.Request.Context(), role))
+
+		c.Next()
+	}
+}
+
+// hasAdminToken reports whether the request carries the configured admin token
+func hasAdminToken(c *gin.Context, token string) bool {
+	if token == "" {
+		return false
+	}
+
+	provided := c.GetHeader(headerAdminToken)
+	if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, bearerPrefix) {
+		provided = strings.TrimPrefix(auth, bearerPrefix)
+	} else if _, password, ok := c.Request.BasicAuth(); ok {
+		provided = password
+	}
+
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
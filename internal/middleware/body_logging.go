@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/masking"
+)
+
+// HeaderDebugTrace asks for the bodies of a request to be logged; its value labels the log entry
+const HeaderDebugTrace = "X-Debug-Trace"
+
+// debugTraceLabelLimit bounds the length of the X-Debug-Trace label logged
+const debugTraceLabelLimit = 64
+
+// bodyMaskRules are the masking rules of JSON members holding personal data or secrets, by
+// member name at any depth. Prefecture, city and postal code are kept, as they identify no
+// one and most address problems are about them.
+var bodyMaskRules = map[string]masking.Rule{
+	"last_name":         masking.RuleFirstChar,
+	"first_name":        masking.RuleFirstChar,
+	"last_name_kana":    masking.RuleFirstChar,
+	"first_name_kana":   masking.RuleFirstChar,
+	"name":              masking.RuleFirstChar,
+	"email":             masking.RuleEmail,
+	"email_confirm":     masking.RuleEmail,
+	"phone":             masking.RuleLast4,
+	"phone_number":      masking.RuleLast4,
+	"phone1":            masking.RuleRedact,
+	"phone2":            masking.RuleRedact,
+	"phone3":            masking.RuleRedact,
+	"address":           masking.RuleRedact,
+	"town":              masking.RuleRedact,
+	"chome":             masking.RuleRedact,
+	"banchi":            masking.RuleRedact,
+	"go":                masking.RuleRedact,
+	"building":          masking.RuleRedact,
+	"room":              masking.RuleRedact,
+	"verification_code": masking.RuleRedact,
+	"token":             masking.RuleRedact,
+	"captcha_token":     masking.RuleRedact,
+	"form_token":        masking.RuleRedact,
+	"csrf_token":        masking.RuleRedact,
+	"password":          masking.RuleRedact,
+	"secret":            masking.RuleRedact,
+	"api_key":           masking.RuleRedact,
+}
+
+// numericSecretMembers are members holding a secret when numeric and a harmless value
+// otherwise: "code" is a verification code in requests but an error code in responses
+var numericSecretMembers = map[string]bool{"code": true}
+
+// BodyLoggingConfig selects the requests whose bodies are logged
+type BodyLoggingConfig struct {
+	// SampleRate is the share of requests logged, from 0 to 1; requests with X-Debug-Trace
+	// are always logged
+	SampleRate float64
+	// MaxBytes bounds each body captured and logged
+	MaxBytes int
+}
+
+// BodyLogging logs the request and response bodies of a sample of requests, and of every
+// request sending X-Debug-Trace, for reproducing problems with submitted forms. JSON bodies
+// are logged with names, contact details, street addresses and secrets masked; other
+// bodies are logged by size only. Bodies are captured up to MaxBytes, so large uploads are
+// not buffered.
+func BodyLogging(cfg BodyLoggingConfig, log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		trace := c.GetHeader(HeaderDebugTrace)
+		if trace == "" && (cfg.SampleRate <= 0 || rand.Float64() >= cfg.SampleRate) {
+			c.Next()
+			return
+		}
+
+		var requestBody []byte
+		requestSize := c.Request.ContentLength
+		if c.Request.Body != nil && c.Request.Body != http.NoBody {
+			// Only the captured prefix is held; the rest is streamed to the handler
+			requestBody, _ = io.ReadAll(io.LimitReader(c.Request.Body, int64(cfg.MaxBytes)+1))
+			c.Request.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(requestBody), c.Request.Body), c.Request.Body}
+		}
+
+		writer := &bodyCaptureWriter{ResponseWriter: c.Writer, limit: cfg.MaxBytes}
+		c.Writer = writer
+
+		c.Next()
+
+		entry := log.WithContext(c.Request.Context()).WithFields(map[string]interface{}{
+			"method":        c.Request.Method,
+			"path":          c.Request.URL.Path,
+			"status":        c.Writer.Status(),
+			"request_size":  requestSize,
+			"request_body":  loggableBody(c.Request.Header.Get("Content-Type"), requestBody, cfg.MaxBytes),
+			"response_size": writer.Size(),
+			"response_body": loggableBody(writer.Header().Get("Content-Type"), writer.body.Bytes(), cfg.MaxBytes),
+		})
+		if trace != "" {
+			entry = entry.WithField("debug_trace", truncateRunes(trace, debugTraceLabelLimit))
+		}
+		entry.Info("Request and response bodies")
+	}
+}
+
+// bodyCaptureWriter copies the first limit bytes of a response while writing it
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	limit int
+	body  bytes.Buffer
+}
+
+func (w *bodyCaptureWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// capture keeps data up to one byte past the limit, so truncation can be told apart
+func (w *bodyCaptureWriter) capture(data []byte) {
+	if room := w.limit + 1 - w.body.Len(); room > 0 {
+		w.body.Write(data[:min(len(data), room)])
+	}
+}
+
+// loggableBody renders a captured body for the log: JSON masked, other content omitted
+func loggableBody(contentType string, body []byte, maxBytes int) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if !strings.Contains(contentType, "json") {
+		return "[non-JSON body not logged]"
+	}
+	if len(body) > maxBytes {
+		// A truncated document cannot be parsed, so nothing in it can be masked
+		return fmt.Sprintf("[JSON body over %d bytes not logged]", maxBytes)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		// The error describes the syntax error without quoting the body
+		return fmt.Sprintf("[invalid JSON: %v]", err)
+	}
+
+	masked, err := json.Marshal(maskBodyValue("", value))
+	if err != nil {
+		return fmt.Sprintf("[JSON body not logged: %v]", err)
+	}
+	return string(masked)
+}
+
+// maskBodyValue masks the strings of value held by members named in bodyMaskRules; strings
+// in an array take the rule of the member holding the array
+func maskBodyValue(key string, value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for member, item := range v {
+			v[member] = maskBodyValue(strings.ToLower(member), item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = maskBodyValue(key, item)
+		}
+		return v
+	case string:
+		rule, ok := bodyMaskRules[key]
+		if numericSecretMembers[key] && isDigits(v) {
+			rule, ok = masking.RuleRedact, true
+		}
+		if !ok {
+			return v
+		}
+		if rule == masking.RuleRedact {
+			// The length is kept, as length limits are a common cause of rejected forms
+			return fmt.Sprintf("[redacted %d chars]", utf8.RuneCountInString(v))
+		}
+		return masking.Apply(rule, v)
+	default:
+		return v
+	}
+}
+
+// isDigits reports whether s is a non-empty run of ASCII digits
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, "0123456789") == ""
+}
+
+// truncateRunes shortens s to at most limit characters
+func truncateRunes(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	return string([]rune(s)[:limit])
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/pkg/correlation"
+)
+
+// Gin context keys for the request identifiers
+const (
+	ContextKeyRequestID     = "request_id"
+	ContextKeyCorrelationID = "correlation_id"
+)
+
+// CorrelationMiddleware assigns a server-generated request ID to every request and accepts
+// the caller's X-Correlation-ID (generating one when it is missing or malformed).
+// Both are stored in the request context and echoed in the response headers.
+func CorrelationMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := correlation.NewID()
+
+		correlationID := c.GetHeader(correlation.HeaderCorrelationID)
+		if !correlation.IsValid(correlationID) {
+			correlationID = correlation.NewID()
+		}
+
+		ctx := correlation.WithRequestID(c.Request.Context(), requestID)
+		ctx = correlation.WithCorrelationID(ctx, correlationID)
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Set(ContextKeyRequestID, requestID)
+		c.Set(ContextKeyCorrelationID, correlationID)
+		c.Header(correlation.HeaderRequestID, requestID)
+		c.Header(correlation.HeaderCorrelationID, correlationID)
+
+		c.Next()
+	}
+}
@@ -45,10 +45,19 @@ func CORSMiddleware() gin.HandlerFunc {
 			"Referer",
 			"User-Agent",
 			"X-Requested-With",
+			"X-Correlation-ID",
+			"X-Session-ID",
+			"X-Use-Sandbox",
+			"X-Bypass-Cache",
+			"X-Admin-Token",
+			"X-Debug-Trace",
 		},
 		ExposeHeaders: []string{
 			"Content-Length",
 			"Content-Type",
+			"X-Request-ID",
+			"X-Correlation-ID",
+			"X-Sandbox",
 		},
 		AllowCredentials: true,
 		MaxAge:           corsMaxAgeHours * time.Hour,
@@ -79,10 +88,14 @@ func CORSProductionMiddleware(allowedOrigins []string) gin.HandlerFunc {
 			"Authorization",
 			"Accept",
 			"X-Requested-With",
+			"X-Correlation-ID",
+			"X-Session-ID",
 		},
 		ExposeHeaders: []string{
 			"Content-Length",
 			"Content-Type",
+			"X-Request-ID",
+			"X-Correlation-ID",
 		},
 		AllowCredentials: true,
 		MaxAge:           corsMaxAgeHours * time.Hour,
@@ -0,0 +1,225 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/lru"
+	"github.com/octop162/normal-form-app-by-claude/pkg/redis"
+)
+
+const (
+	csrfTokenBytes      = 32
+	csrfTokenTTL        = 4 * time.Hour
+	csrfCleanupInterval = 1 * time.Hour
+)
+
+// CSRFTokenStore stores single-use CSRF tokens
+type CSRFTokenStore interface {
+	// Save stores a token valid for ttl
+	Save(ctx context.Context, token string, ttl time.Duration) error
+	// Consume removes a token and reports whether it existed and was not expired
+	Consume(ctx context.Context, token string) (bool, error)
+}
+
+// IssueCSRFToken creates a new random token and stores it
+func IssueCSRFToken(ctx context.Context, store CSRFTokenStore) (string, error) {
+	bytes := make([]byte, csrfTokenBytes)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	token := base64.URLEncoding.EncodeToString(bytes)
+
+	if err := store.Save(ctx, token, csrfTokenTTL); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// CSRFTokenHandler issues a new CSRF token
+func CSRFTokenHandler(store CSRFTokenStore, log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, err := IssueCSRFToken(c.Request.Context(), store)
+		if err != nil {
+			log.WithContext(c.Request.Context()).WithError(err).Error("Failed to generate CSRF token")
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "CSRF_TOKEN_GENERATION_FAILED",
+					"message": "Failed to generate CSRF token",
+				},
+			})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"data": gin.H{
+				"token": token,
+			},
+		})
+	}
+}
+
+// CSRF middleware for CSRF protection. Tokens are validated fail-closed: if the
+// store is unavailable, state-changing requests are rejected. It belongs on the middleware
+// groups of cookie-based browser routes only; routes authenticating with a token, such as
+// the admin API, are not exposed to cross-site request forgery.
+func CSRF(store CSRFTokenStore, log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Skip CSRF check for safe methods
+		if c.Request.Method == "GET" || c.Request.Method == "HEAD" || c.Request.Method == "OPTIONS" {
+			c.Next()
+			return
+		}
+
+		// Get token from header
+		token := c.GetHeader("X-CSRF-Token")
+		if token == "" {
+			c.Set(securityEventContextKey, securityEventCSRFFailure)
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "CSRF_TOKEN_MISSING",
+					"message": "CSRF token is required",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		// Validate token
+		valid, err := store.Consume(c.Request.Context(), token)
+		if err != nil {
+			log.WithContext(c.Request.Context()).WithError(err).Error("Failed to validate CSRF token")
+			c.JSON(http.StatusServiceUnavailable, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "CSRF_TOKEN_VALIDATION_FAILED",
+					"message": "Unable to validate CSRF token. Please try again later.",
+				},
+			})
+			c.Abort()
+			return
+		}
+		if !valid {
+			c.Set(securityEventContextKey, securityEventCSRFFailure)
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "CSRF_TOKEN_INVALID",
+					"message": "Invalid or expired CSRF token",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// memoryCSRFTokenStore stores CSRF tokens with expiration in process memory (single instance only).
+// Tokens are bounded so that token requests cannot grow memory without limit between
+// cleanups; when full, the oldest token is evicted and fails validation.
+type memoryCSRFTokenStore struct {
+	tokens *lru.Cache[string, time.Time]
+	mutex  sync.Mutex
+}
+
+// NewMemoryCSRFTokenStore creates an in-memory CSRF token store holding at most maxTokens
+// tokens (unbounded when maxTokens is not positive)
+func NewMemoryCSRFTokenStore(maxTokens int) CSRFTokenStore {
+	store := &memoryCSRFTokenStore{
+		tokens: lru.New[string, time.Time](maxTokens),
+	}
+	// Start cleanup goroutine
+	go store.cleanup()
+	return store
+}
+
+// Save stores a token
+func (s *memoryCSRFTokenStore) Save(_ context.Context, token string, ttl time.Duration) error {
+	s.mutex.Lock()
+	s.tokens.Add(token, time.Now().Add(ttl))
+	s.mutex.Unlock()
+	return nil
+}
+
+// Consume removes a token (single use) and reports whether it was valid
+func (s *memoryCSRFTokenStore) Consume(_ context.Context, token string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	expiration, exists := s.tokens.Remove(token)
+	if !exists {
+		return false, nil
+	}
+
+	return time.Now().Before(expiration), nil
+}
+
+// cleanup removes expired tokens
+func (s *memoryCSRFTokenStore) cleanup() {
+	ticker := time.NewTicker(csrfCleanupInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mutex.Lock()
+		now := time.Now()
+		s.tokens.RemoveFunc(func(_ string, expiration time.Time) bool {
+			return now.After(expiration)
+		})
+		s.mutex.Unlock()
+	}
+}
+
+// Stats reports the number of tokens held
+func (s *memoryCSRFTokenStore) Stats() lru.Stats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.tokens.Stats()
+}
+
+// redisCSRFTokenStore stores CSRF tokens in Redis so any instance can validate them
+type redisCSRFTokenStore struct {
+	client    *redis.Client
+	keyPrefix string
+}
+
+// NewRedisCSRFTokenStore creates a Redis-backed CSRF token store
+func NewRedisCSRFTokenStore(client *redis.Client, keyPrefix string) CSRFTokenStore {
+	return &redisCSRFTokenStore{
+		client:    client,
+		keyPrefix: keyPrefix,
+	}
+}
+
+// Save stores a token with a Redis expiry
+func (s *redisCSRFTokenStore) Save(ctx context.Context, token string, ttl time.Duration) error {
+	if _, err := s.client.Do(ctx, "SET", s.key(token), "1", "PX", ttl.Milliseconds()); err != nil {
+		return fmt.Errorf("failed to save CSRF token: %w", err)
+	}
+	return nil
+}
+
+// Consume deletes a token; expired tokens are already gone, so a successful delete means valid
+func (s *redisCSRFTokenStore) Consume(ctx context.Context, token string) (bool, error) {
+	deleted, err := redis.Int64(s.client.Do(ctx, "DEL", s.key(token)))
+	if err != nil {
+		return false, fmt.Errorf("failed to consume CSRF token: %w", err)
+	}
+	return deleted == 1, nil
+}
+
+// key returns the Redis key for a token
+func (s *redisCSRFTokenStore) key(token string) string {
+	return s.keyPrefix + "csrf:" + token
+}
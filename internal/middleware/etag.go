@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/pkg/etag"
+)
+
+// ETag tags successful GET responses and answers If-None-Match with 304 Not Modified when
+// the client already has the current representation. Handlers that know the version of a
+// resource may set the ETag header themselves, e.g. so that updates can check If-Match
+// against it; other responses are tagged by a hash of their body.
+func ETag() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+
+		writer := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		body := writer.body.Bytes()
+		if writer.Status() != http.StatusOK {
+			writer.ResponseWriter.WriteHeader(writer.Status())
+			_, _ = writer.ResponseWriter.Write(body)
+			return
+		}
+
+		header := writer.Header()
+		tag := header.Get(etag.HeaderETag)
+		if tag == "" {
+			tag = etag.FromBytes(body)
+			header.Set(etag.HeaderETag, tag)
+		}
+
+		if !etag.NoneMatch(c.GetHeader(etag.HeaderIfNoneMatch), tag) {
+			// A 304 carries the validators and caching headers but no content
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			writer.ResponseWriter.WriteHeader(http.StatusNotModified)
+			writer.ResponseWriter.WriteHeaderNow()
+			return
+		}
+
+		writer.ResponseWriter.WriteHeader(http.StatusOK)
+		_, _ = writer.ResponseWriter.Write(body)
+	}
+}
@@ -0,0 +1,315 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const (
+	errorCodeRequestTooLarge   = "REQUEST_TOO_LARGE"
+	errorCodeJSONTooDeep       = "JSON_TOO_DEEP"
+	errorCodeJSONArrayTooLong  = "JSON_ARRAY_TOO_LONG"
+	errorCodeInvalidCharacters = "INVALID_CHARACTERS"
+	errorCodeSuspiciousInput   = "SUSPICIOUS_INPUT"
+)
+
+// Injection checks RequestInspection can apply to JSON strings
+const (
+	InjectionCheckSQL           = "sql"
+	InjectionCheckScript        = "script"
+	InjectionCheckPathTraversal = "path_traversal"
+)
+
+// injectionPatterns match the common forms of each injection. They target attack syntax
+// rather than single characters, so names such as O'Brien and addresses such as 1-2-3 pass.
+var injectionPatterns = map[string]*regexp.Regexp{
+	InjectionCheckSQL: regexp.MustCompile(`(?i)\bunion\s+(all\s+)?select\b|` +
+		`'\s*(or|and)\s+'?\w+'?\s*=\s*'?\w+|;\s*(drop|delete|insert|update|alter|truncate)\s|` +
+		`\b(sleep|pg_sleep|benchmark)\s*\(|\bwaitfor\s+delay\b|'\s*;?\s*--`),
+	InjectionCheckScript: regexp.MustCompile(`(?i)<\s*/?\s*(script|iframe|object|embed|svg)\b|` +
+		`javascript\s*:|\bon[a-z]+\s*=|<\s*img\b[^>]*\bsrc\s*=`),
+	InjectionCheckPathTraversal: regexp.MustCompile(`\.\.[/\\]|%2e%2e(%2f|%5c)`),
+}
+
+// RequestInspectionConfig limits the JSON bodies of POST, PUT and PATCH requests
+type RequestInspectionConfig struct {
+	// MaxBodyBytes bounds the body size; 0 is unlimited
+	MaxBodyBytes int64
+	// MaxDepth bounds the nesting of objects and arrays; 0 is unlimited
+	MaxDepth int
+	// MaxArrayLength bounds the elements of each array; 0 is unlimited
+	MaxArrayLength int
+	// RejectControlChars rejects strings holding control characters other than tab and newlines
+	RejectControlChars bool
+	// InjectionChecks names the injection patterns rejected in strings: sql, script,
+	// path_traversal; "none" checks none
+	InjectionChecks []string
+}
+
+// inspectionError is a reason a request body is rejected
+type inspectionError struct {
+	code    string
+	message string
+	// field is the JSON path of the offending value, e.g. option_types[2]; empty for the body
+	field string
+}
+
+func (e *inspectionError) Error() string {
+	return e.message
+}
+
+// RequestInspection rejects POST, PUT and PATCH requests whose body is too large, or whose
+// JSON nests too deeply, holds too long an array or holds strings with control characters
+// or injection patterns, with a 400 before handlers bind the body. Object keys are checked
+// like values. Bodies that are not valid JSON are passed on for binding to report.
+func RequestInspection(cfg RequestInspectionConfig, log *logger.Logger) (gin.HandlerFunc, error) {
+	patterns := make(map[string]*regexp.Regexp, len(cfg.InjectionChecks))
+	for _, name := range cfg.InjectionChecks {
+		name = strings.TrimSpace(name)
+		if name == "" || name == "none" {
+			continue
+		}
+		pattern, ok := injectionPatterns[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown injection check: %s", name)
+		}
+		patterns[name] = pattern
+	}
+	inspector := &bodyInspector{config: cfg, patterns: patterns}
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			c.Next()
+			return
+		}
+
+		body, err := inspector.read(c.Request)
+		if err == nil && isJSONRequest(c.Request) {
+			err = inspector.inspect(body)
+		}
+
+		var rejected *inspectionError
+		if errors.As(err, &rejected) {
+			log.WithContext(c.Request.Context()).
+				WithField("code", rejected.code).
+				WithField("field", rejected.field).
+				WithField("path", c.FullPath()).
+				Warn("Rejected request body")
+			apiError := gin.H{"code": rejected.code, "message": rejected.message}
+			if rejected.field != "" {
+				apiError["details"] = gin.H{"field": rejected.field}
+			}
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "error": apiError})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			// The client stopped sending; binding reports the truncated body
+			c.Next()
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}, nil
+}
+
+// isJSONRequest reports whether the request body is declared as JSON
+func isJSONRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && (mediaType == ContentTypeJSON || strings.HasSuffix(mediaType, "+json"))
+}
+
+// bodyInspector checks request bodies against a RequestInspectionConfig
+type bodyInspector struct {
+	config   RequestInspectionConfig
+	patterns map[string]*regexp.Regexp
+}
+
+// read reads the body, rejecting it once it exceeds the size limit
+func (i *bodyInspector) read(r *http.Request) ([]byte, error) {
+	tooLarge := &inspectionError{
+		code:    errorCodeRequestTooLarge,
+		message: fmt.Sprintf("Request body must be at most %d bytes", i.config.MaxBodyBytes),
+	}
+	if i.config.MaxBodyBytes > 0 && r.ContentLength > i.config.MaxBodyBytes {
+		return nil, tooLarge
+	}
+
+	reader := io.Reader(r.Body)
+	if i.config.MaxBodyBytes > 0 {
+		reader = io.LimitReader(r.Body, i.config.MaxBodyBytes+1)
+	}
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if i.config.MaxBodyBytes > 0 && int64(len(body)) > i.config.MaxBodyBytes {
+		return nil, tooLarge
+	}
+	return body, nil
+}
+
+// jsonContainer is an object or array being read, with the path of the value read last
+type jsonContainer struct {
+	array  bool
+	path   string
+	length int
+	// key is the member of an object whose value is read next
+	key       string
+	expectKey bool
+}
+
+// inspect walks the JSON tokens of body, checking nesting, array lengths and strings
+func (i *bodyInspector) inspect(body []byte) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var stack []*jsonContainer
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			// Invalid JSON is left to binding
+			return nil
+		}
+
+		var parent *jsonContainer
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1]
+		}
+
+		// An object key is a string read where a key is expected
+		if key, ok := token.(string); ok && parent != nil && !parent.array && parent.expectKey {
+			parent.key = key
+			parent.expectKey = false
+			if err := i.checkString(key, joinJSONPath(parent.path, key)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		path := valuePath(parent)
+		switch value := token.(type) {
+		case json.Delim:
+			switch value {
+			case '{', '[':
+				if err := i.countElement(parent, path); err != nil {
+					return err
+				}
+				if i.config.MaxDepth > 0 && len(stack)+1 > i.config.MaxDepth {
+					return &inspectionError{
+						code:    errorCodeJSONTooDeep,
+						message: fmt.Sprintf("JSON must nest at most %d levels", i.config.MaxDepth),
+						field:   path,
+					}
+				}
+				stack = append(stack, &jsonContainer{array: value == '[', path: path, expectKey: value == '{'})
+				continue
+			case '}', ']':
+				stack = stack[:len(stack)-1]
+			}
+		case string:
+			if err := i.countElement(parent, path); err != nil {
+				return err
+			}
+			if err := i.checkString(value, path); err != nil {
+				return err
+			}
+		default:
+			if err := i.countElement(parent, path); err != nil {
+				return err
+			}
+		}
+
+		// After a member's value the next token of an object is a key
+		if len(stack) > 0 && !stack[len(stack)-1].array {
+			stack[len(stack)-1].expectKey = true
+		}
+	}
+}
+
+// countElement counts a value read into an array, rejecting the array once it is too long
+func (i *bodyInspector) countElement(parent *jsonContainer, path string) error {
+	if parent == nil || !parent.array {
+		return nil
+	}
+	parent.length++
+	if i.config.MaxArrayLength > 0 && parent.length > i.config.MaxArrayLength {
+		return &inspectionError{
+			code:    errorCodeJSONArrayTooLong,
+			message: fmt.Sprintf("JSON arrays must hold at most %d elements", i.config.MaxArrayLength),
+			field:   parent.path,
+		}
+	}
+	return nil
+}
+
+// checkString rejects control characters and injection patterns. The value itself is never
+// echoed or logged.
+func (i *bodyInspector) checkString(value, path string) error {
+	if i.config.RejectControlChars && strings.ContainsFunc(value, isForbiddenControlChar) {
+		return &inspectionError{
+			code:    errorCodeInvalidCharacters,
+			message: "Input must not contain control characters",
+			field:   path,
+		}
+	}
+	for _, pattern := range i.patterns {
+		if pattern.MatchString(value) {
+			return &inspectionError{
+				code:    errorCodeSuspiciousInput,
+				message: "Input contains a disallowed pattern",
+				field:   path,
+			}
+		}
+	}
+	return nil
+}
+
+// isForbiddenControlChar reports control characters other than tab, line feed and carriage return
+func isForbiddenControlChar(r rune) bool {
+	switch r {
+	case '\t', '\n', '\r':
+		return false
+	}
+	return r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0)
+}
+
+// valuePath returns the path of the next value read into parent
+func valuePath(parent *jsonContainer) string {
+	if parent == nil {
+		return ""
+	}
+	if parent.array {
+		return parent.path + "[" + strconv.Itoa(parent.length) + "]"
+	}
+	return joinJSONPath(parent.path, parent.key)
+}
+
+// joinJSONPath appends an object member to a path
+func joinJSONPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -17,7 +17,7 @@ const (
 // LoggerMiddleware creates a Gin middleware for logging HTTP requests
 func LoggerMiddleware(log *logger.Logger) gin.HandlerFunc {
 	return gin.LoggerWithFormatter(func(param gin.LogFormatterParams) string {
-		log.WithFields(map[string]interface{}{
+		log.WithContext(param.Request.Context()).WithFields(map[string]interface{}{
 			"timestamp":  param.TimeStamp.Format("2006-01-02T15:04:05.000Z07:00"),
 			"status":     param.StatusCode,
 			"latency":    param.Latency.String(),
@@ -58,7 +58,7 @@ func SimpleLoggerMiddleware(log *logger.Logger) gin.HandlerFunc {
 		}
 
 		// Log level based on status code
-		logEntry := log.WithFields(map[string]interface{}{
+		logEntry := log.WithContext(c.Request.Context()).WithFields(map[string]interface{}{
 			"status":     statusCode,
 			"latency":    latency.String(),
 			"client_ip":  clientIP,
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const (
+	headerAPIKey = "X-API-Key"
+
+	errorCodeAPIKeyUnauthorized = "API_KEY_UNAUTHORIZED"
+	errorCodeAPIKeyScope        = "API_KEY_SCOPE_REQUIRED"
+
+	partnerKeyContextKey = "partner_key"
+)
+
+// PartnerKey is the API key a partner request was authenticated with
+type PartnerKey struct {
+	ID      int64
+	Partner string
+	Scopes  []string
+}
+
+// PartnerKeyAuthenticator resolves an API key sent by a partner. It returns nil for a key
+// that is unknown or revoked.
+type PartnerKeyAuthenticator func(ctx context.Context, key string) (*PartnerKey, error)
+
+// PartnerAuth authenticates partner requests by the "X-API-Key: <key>" header and records
+// the key for the middleware and handlers that follow
+func PartnerAuth(authenticate PartnerKeyAuthenticator, log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var key *PartnerKey
+		if provided := c.GetHeader(headerAPIKey); provided != "" {
+			var err error
+			key, err = authenticate(c.Request.Context(), provided)
+			if err != nil {
+				log.WithContext(c.Request.Context()).WithError(err).Error("Failed to authenticate partner API key")
+				c.JSON(http.StatusInternalServerError, gin.H{
+					"success": false,
+					"error": gin.H{
+						"code":    "INTERNAL_ERROR",
+						"message": "Failed to authenticate the API key",
+					},
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		if key == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    errorCodeAPIKeyUnauthorized,
+					"message": "Valid API key is required",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set(partnerKeyContextKey, key)
+		c.Next()
+	}
+}
+
+// PartnerScope rejects partner requests whose API key does not grant scope
+func PartnerScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := PartnerKeyFromContext(c)
+		if !ok || !slices.Contains(key.Scopes, scope) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    errorCodeAPIKeyScope,
+					"message": "The API key does not grant the " + scope + " scope",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// PartnerRateLimitKey keys partner rate limit buckets by API key, so each partner has its
+// own limit however many addresses it calls from
+func PartnerRateLimitKey(c *gin.Context) string {
+	if key, ok := PartnerKeyFromContext(c); ok {
+		return "key:" + strconv.FormatInt(key.ID, 10)
+	}
+	return c.ClientIP()
+}
+
+// PartnerUsageRecorder records a partner request: the key, the route pattern such as
+// "/partner/v1/plans", the response status and the body sizes
+type PartnerUsageRecorder func(keyID int64, endpoint string, status int, requestBytes, responseBytes int64)
+
+// PartnerUsage records every authenticated partner request once it is answered. It runs
+// before the rate limit so rejected requests are reported as errors.
+func PartnerUsage(record PartnerUsageRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		key, ok := PartnerKeyFromContext(c)
+		if !ok {
+			return
+		}
+		requestBytes := max(c.Request.ContentLength, 0)
+		responseBytes := int64(max(c.Writer.Size(), 0))
+		record(key.ID, c.FullPath(), c.Writer.Status(), requestBytes, responseBytes)
+	}
+}
+
+// PartnerKeyFromContext returns the API key the request was authenticated with
+func PartnerKeyFromContext(c *gin.Context) (*PartnerKey, bool) {
+	value, ok := c.Get(partnerKeyContextKey)
+	if !ok {
+		return nil, false
+	}
+	key, ok := value.(*PartnerKey)
+	return key, ok
+}
@@ -1,29 +1,103 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"runtime"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/pkg/lru"
 )
 
-// ResponseWriter wrapper for capturing response size
+// defaultResponseCacheMaxEntries bounds the response cache used by CacheMiddleware
+const defaultResponseCacheMaxEntries = 10000
+
+// maxPooledBufferSize keeps unusually large response bodies from being held by the buffer pool
+const maxPooledBufferSize = 64 << 10
+
+// ResponseWriter wrapper for capturing response size and, when body is set, the response body
 type responseWriter struct {
 	gin.ResponseWriter
 	size int
+	body *bytes.Buffer
 }
 
 func (rw *responseWriter) Write(data []byte) (int, error) {
 	size, err := rw.ResponseWriter.Write(data)
 	rw.size += size
+	if rw.body != nil {
+		rw.body.Write(data[:size])
+	}
 	return size, err
 }
 
+// WriteString is overridden so that c.String responses are counted too
+func (rw *responseWriter) WriteString(s string) (int, error) {
+	size, err := rw.ResponseWriter.WriteString(s)
+	rw.size += size
+	if rw.body != nil {
+		rw.body.WriteString(s[:size])
+	}
+	return size, err
+}
+
+// Wrappers and buffers are allocated on every request, so they are reused through pools
+var (
+	responseWriterPool = sync.Pool{New: func() any { return &responseWriter{} }}
+	bufferPool         = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+)
+
+// acquireResponseWriter wraps the writer with a pooled responseWriter
+func acquireResponseWriter(w gin.ResponseWriter) *responseWriter {
+	rw := responseWriterPool.Get().(*responseWriter)
+	rw.ResponseWriter = w
+	return rw
+}
+
+// releaseResponseWriter returns the wrapper to the pool. The caller must have restored
+// c.Writer to the wrapped writer so nothing refers to the wrapper afterwards.
+func releaseResponseWriter(rw *responseWriter) {
+	*rw = responseWriter{}
+	responseWriterPool.Put(rw)
+}
+
+// acquireBuffer returns an empty pooled buffer
+func acquireBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// releaseBuffer returns the buffer to the pool unless it has grown too large to keep
+func releaseBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// writeJSON encodes the response into a pooled buffer instead of a fresh allocation per request
+func writeJSON(c *gin.Context, status int, obj any) {
+	buf := acquireBuffer()
+	defer releaseBuffer(buf)
+
+	if err := json.NewEncoder(buf).Encode(obj); err != nil {
+		_ = c.Error(err)
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Data(status, "application/json; charset=utf-8", buf.Bytes())
+}
+
 // PerformanceMetrics stores performance metrics
 type PerformanceMetrics struct {
 	RequestCount     int64         `json:"request_count"`
@@ -36,155 +110,147 @@ type PerformanceMetrics struct {
 	MemoryUsage      uint64        `json:"memory_usage_bytes"`
 }
 
-// MetricsCollector collects and manages performance metrics
-type MetricsCollector struct {
-	mutex           sync.RWMutex
-	requestCount    int64
-	totalDuration   time.Duration
-	minDuration     time.Duration
-	maxDuration     time.Duration
-	errorCount      int64
-	endpointMetrics map[string]*PerformanceMetrics
+// metricCounters holds lock-free counters for one set of requests
+type metricCounters struct {
+	requestCount  atomic.Int64
+	totalDuration atomic.Int64
+	minDuration   atomic.Int64
+	maxDuration   atomic.Int64
+	errorCount    atomic.Int64
 }
 
-// NewMetricsCollector creates a new metrics collector
-func NewMetricsCollector() *MetricsCollector {
-	return &MetricsCollector{
-		endpointMetrics: make(map[string]*PerformanceMetrics),
-		minDuration:     time.Hour, // Initialize with large value
-	}
+// newMetricCounters creates counters with the minimum set above any real duration
+func newMetricCounters() *metricCounters {
+	counters := &metricCounters{}
+	counters.minDuration.Store(math.MaxInt64)
+	return counters
 }
 
-var globalMetricsCollector = NewMetricsCollector()
-
-// RecordRequest records metrics for a request
-func (mc *MetricsCollector) RecordRequest(endpoint string, duration time.Duration, isError bool) {
-	mc.mutex.Lock()
-	defer mc.mutex.Unlock()
-
-	mc.requestCount++
-	mc.totalDuration += duration
-
-	if duration < mc.minDuration {
-		mc.minDuration = duration
-	}
-	if duration > mc.maxDuration {
-		mc.maxDuration = duration
-	}
-
+// record adds a single request to the counters
+func (m *metricCounters) record(duration time.Duration, isError bool) {
+	m.requestCount.Add(1)
+	m.totalDuration.Add(int64(duration))
 	if isError {
-		mc.errorCount++
+		m.errorCount.Add(1)
 	}
 
-	// Update endpoint-specific metrics
-	if _, exists := mc.endpointMetrics[endpoint]; !exists {
-		mc.endpointMetrics[endpoint] = &PerformanceMetrics{
-			MinDuration: time.Hour,
+	for current := m.minDuration.Load(); int64(duration) < current; current = m.minDuration.Load() {
+		if m.minDuration.CompareAndSwap(current, int64(duration)) {
+			break
 		}
 	}
+	for current := m.maxDuration.Load(); int64(duration) > current; current = m.maxDuration.Load() {
+		if m.maxDuration.CompareAndSwap(current, int64(duration)) {
+			break
+		}
+	}
+}
 
-	endpointMetric := mc.endpointMetrics[endpoint]
-	endpointMetric.RequestCount++
-	endpointMetric.TotalDuration += duration
-
-	if duration < endpointMetric.MinDuration {
-		endpointMetric.MinDuration = duration
+// snapshot returns the current values. Counters are read individually, so a snapshot
+// taken during concurrent updates may be off by the requests in flight.
+func (m *metricCounters) snapshot() PerformanceMetrics {
+	metrics := PerformanceMetrics{
+		RequestCount:  m.requestCount.Load(),
+		TotalDuration: time.Duration(m.totalDuration.Load()),
+		MaxDuration:   time.Duration(m.maxDuration.Load()),
+		ErrorCount:    m.errorCount.Load(),
 	}
-	if duration > endpointMetric.MaxDuration {
-		endpointMetric.MaxDuration = duration
+	if metrics.RequestCount > 0 {
+		metrics.AverageDuration = metrics.TotalDuration / time.Duration(metrics.RequestCount)
+		metrics.MinDuration = time.Duration(m.minDuration.Load())
 	}
+	return metrics
+}
 
-	if isError {
-		endpointMetric.ErrorCount++
-	}
+// MetricsCollector collects and manages performance metrics.
+// Recording is lock-free: counters are atomics and per-endpoint counters live in a
+// sync.Map, which serves the read-mostly lookups of known endpoints without locking.
+type MetricsCollector struct {
+	overall   atomic.Pointer[metricCounters]
+	endpoints sync.Map // endpoint -> *metricCounters
+}
+
+// NewMetricsCollector creates a new metrics collector
+func NewMetricsCollector() *MetricsCollector {
+	mc := &MetricsCollector{}
+	mc.overall.Store(newMetricCounters())
+	return mc
+}
 
-	if endpointMetric.RequestCount > 0 {
-		endpointMetric.AverageDuration = endpointMetric.TotalDuration / time.Duration(endpointMetric.RequestCount)
+var globalMetricsCollector = NewMetricsCollector()
+
+// RecordRequest records metrics for a request
+func (mc *MetricsCollector) RecordRequest(endpoint string, duration time.Duration, isError bool) {
+	mc.overall.Load().record(duration, isError)
+
+	counters, ok := mc.endpoints.Load(endpoint)
+	if !ok {
+		counters, _ = mc.endpoints.LoadOrStore(endpoint, newMetricCounters())
 	}
+	counters.(*metricCounters).record(duration, isError)
 }
 
 // GetMetrics returns current metrics
 func (mc *MetricsCollector) GetMetrics() PerformanceMetrics {
-	mc.mutex.RLock()
-	defer mc.mutex.RUnlock()
-
-	var avgDuration time.Duration
-	if mc.requestCount > 0 {
-		avgDuration = mc.totalDuration / time.Duration(mc.requestCount)
-	}
+	metrics := mc.overall.Load().snapshot()
 
 	var memStats runtime.MemStats
 	runtime.ReadMemStats(&memStats)
+	metrics.ActiveGoroutines = runtime.NumGoroutine()
+	metrics.MemoryUsage = memStats.Alloc
 
-	return PerformanceMetrics{
-		RequestCount:     mc.requestCount,
-		TotalDuration:    mc.totalDuration,
-		AverageDuration:  avgDuration,
-		MinDuration:      mc.minDuration,
-		MaxDuration:      mc.maxDuration,
-		ErrorCount:       mc.errorCount,
-		ActiveGoroutines: runtime.NumGoroutine(),
-		MemoryUsage:      memStats.Alloc,
-	}
+	return metrics
 }
 
 // GetEndpointMetrics returns metrics for a specific endpoint
 func (mc *MetricsCollector) GetEndpointMetrics(endpoint string) *PerformanceMetrics {
-	mc.mutex.RLock()
-	defer mc.mutex.RUnlock()
-
-	if metric, exists := mc.endpointMetrics[endpoint]; exists {
-		// Create a copy to avoid race conditions
-		metricCopy := *metric
-		metricCopy.ActiveGoroutines = runtime.NumGoroutine()
-		
-		var memStats runtime.MemStats
-		runtime.ReadMemStats(&memStats)
-		metricCopy.MemoryUsage = memStats.Alloc
-		
-		return &metricCopy
+	counters, exists := mc.endpoints.Load(endpoint)
+	if !exists {
+		return nil
 	}
-	return nil
+
+	metrics := counters.(*metricCounters).snapshot()
+	metrics.ActiveGoroutines = runtime.NumGoroutine()
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	metrics.MemoryUsage = memStats.Alloc
+
+	return &metrics
 }
 
 // GetAllEndpointMetrics returns metrics for all endpoints
 func (mc *MetricsCollector) GetAllEndpointMetrics() map[string]*PerformanceMetrics {
-	mc.mutex.RLock()
-	defer mc.mutex.RUnlock()
-
 	result := make(map[string]*PerformanceMetrics)
-	for endpoint, metric := range mc.endpointMetrics {
-		// Create a copy to avoid race conditions
-		metricCopy := *metric
-		result[endpoint] = &metricCopy
-	}
+	mc.endpoints.Range(func(key, value any) bool {
+		metrics := value.(*metricCounters).snapshot()
+		result[key.(string)] = &metrics
+		return true
+	})
 	return result
 }
 
 // Reset resets all metrics
 func (mc *MetricsCollector) Reset() {
-	mc.mutex.Lock()
-	defer mc.mutex.Unlock()
-
-	mc.requestCount = 0
-	mc.totalDuration = 0
-	mc.minDuration = time.Hour
-	mc.maxDuration = 0
-	mc.errorCount = 0
-	mc.endpointMetrics = make(map[string]*PerformanceMetrics)
+	mc.overall.Store(newMetricCounters())
+	mc.endpoints.Clear()
 }
 
-// PerformanceMiddleware tracks request performance
-func PerformanceMiddleware() gin.HandlerFunc {
+// PerformanceMiddleware tracks request performance per endpoint. label names the endpoint
+// of a request; it should return a fixed set of names (such as route names) rather than
+// raw paths, or every resource ID would get its own metrics.
+func PerformanceMiddleware(label func(c *gin.Context) string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
-		path := c.Request.URL.Path
-		method := c.Request.Method
-		endpoint := fmt.Sprintf("%s %s", method, path)
+		endpoint := label(c)
 
 		// Create response writer wrapper
-		rw := &responseWriter{ResponseWriter: c.Writer}
+		rw := acquireResponseWriter(c.Writer)
 		c.Writer = rw
+		defer func() {
+			c.Writer = rw.ResponseWriter
+			releaseResponseWriter(rw)
+		}()
 
 		// Add performance context
 		c.Set("performance_start", start)
@@ -223,7 +289,7 @@ func MetricsEndpoint() gin.HandlerFunc {
 			},
 		}
 		
-		c.JSON(http.StatusOK, response)
+		writeJSON(c, http.StatusOK, response)
 	}
 }
 
@@ -261,14 +327,28 @@ type CacheItem struct {
 	ExpiresAt time.Time
 }
 
+// cacheItemPool recycles entries that are replaced, deleted or expired
+var cacheItemPool = sync.Pool{New: func() any { return &CacheItem{} }}
+
+// releaseCacheItem returns an entry removed from the cache to the pool. Callers must hold
+// the cache lock; Get copies Data out under the lock, so no reader keeps the entry.
+func releaseCacheItem(item *CacheItem) {
+	*item = CacheItem{}
+	cacheItemPool.Put(item)
+}
+
+// MemoryCache is a TTL cache bounded to a maximum number of entries; when full, the least
+// recently used entry is evicted
 type MemoryCache struct {
-	mutex sync.RWMutex
-	items map[string]*CacheItem
+	mutex sync.Mutex
+	items *lru.Cache[string, *CacheItem]
 }
 
-func NewMemoryCache() *MemoryCache {
+// NewMemoryCache creates a cache holding at most maxEntries entries (unbounded when
+// maxEntries is not positive)
+func NewMemoryCache(maxEntries int) *MemoryCache {
 	cache := &MemoryCache{
-		items: make(map[string]*CacheItem),
+		items: lru.New[string, *CacheItem](maxEntries),
 	}
 	
 	// Start cleanup goroutine
@@ -281,23 +361,24 @@ func (mc *MemoryCache) Set(key string, value interface{}, ttl time.Duration) {
 	mc.mutex.Lock()
 	defer mc.mutex.Unlock()
 	
-	mc.items[key] = &CacheItem{
-		Data:      value,
-		ExpiresAt: time.Now().Add(ttl),
+	item, exists := mc.items.Get(key)
+	if !exists {
+		item = cacheItemPool.Get().(*CacheItem)
+		if evicted, ok := mc.items.Add(key, item); ok {
+			releaseCacheItem(evicted)
+		}
 	}
+	item.Data = value
+	item.ExpiresAt = time.Now().Add(ttl)
 }
 
+// Get returns a live entry. Expired entries are left for cleanup.
 func (mc *MemoryCache) Get(key string) (interface{}, bool) {
-	mc.mutex.RLock()
-	defer mc.mutex.RUnlock()
-	
-	item, exists := mc.items[key]
-	if !exists {
-		return nil, false
-	}
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
 	
-	if time.Now().After(item.ExpiresAt) {
-		delete(mc.items, key)
+	item, exists := mc.items.Get(key)
+	if !exists || time.Now().After(item.ExpiresAt) {
 		return nil, false
 	}
 	
@@ -308,7 +389,16 @@ func (mc *MemoryCache) Delete(key string) {
 	mc.mutex.Lock()
 	defer mc.mutex.Unlock()
 	
-	delete(mc.items, key)
+	if item, exists := mc.items.Remove(key); exists {
+		releaseCacheItem(item)
+	}
+}
+
+// Stats reports the number of entries held
+func (mc *MemoryCache) Stats() lru.Stats {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+	return mc.items.Stats()
 }
 
 func (mc *MemoryCache) cleanup() {
@@ -318,63 +408,157 @@ func (mc *MemoryCache) cleanup() {
 	for range ticker.C {
 		mc.mutex.Lock()
 		now := time.Now()
-		for key, item := range mc.items {
-			if now.After(item.ExpiresAt) {
-				delete(mc.items, key)
+		mc.items.RemoveFunc(func(_ string, item *CacheItem) bool {
+			if !now.After(item.ExpiresAt) {
+				return false
 			}
-		}
+			releaseCacheItem(item)
+			return true
+		})
 		mc.mutex.Unlock()
 	}
 }
 
-var globalCache = NewMemoryCache()
+var globalCache = NewMemoryCache(defaultResponseCacheMaxEntries)
+
+// ResponseCache returns the cache used by CacheMiddleware
+func ResponseCache() *MemoryCache {
+	return globalCache
+}
+
+// cachedResponse is a captured GET response
+type cachedResponse struct {
+	contentType  string
+	cacheControl string
+	etag         string
+	body         []byte
+	// vary holds the request headers named by the response's Vary header and the values
+	// the response was produced for; only requests with the same values are served it
+	vary       []string
+	varyValues []string
+}
+
+// credentialHeaders identify requests whose responses may depend on who is calling
+var credentialHeaders = []string{"Authorization", "Cookie", "X-API-Key"}
 
-// CacheMiddleware provides response caching for GET requests
+// CacheMiddleware provides response caching for anonymous GET requests. Only responses the
+// route marks as shareable, with Cache-Control: public, are cached, so personal data and
+// per-caller responses are never served to another client. Requests carrying credentials
+// bypass the cache, and responses are only served to requests matching their Vary headers.
 func CacheMiddleware(ttl time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Only cache GET requests
-		if c.Request.Method != "GET" {
+		if c.Request.Method != http.MethodGet || hasCredentials(c.Request) {
 			c.Next()
 			return
 		}
-		
-		// Skip caching for specific endpoints
-		path := c.Request.URL.Path
-		skipCache := []string{"/health", "/metrics", "/api/v1/csrf-token"}
-		for _, skip := range skipCache {
-			if path == skip {
-				c.Next()
-				return
-			}
-		}
-		
-		// Generate cache key
-		cacheKey := fmt.Sprintf("%s:%s:%s", c.Request.Method, path, c.Request.URL.RawQuery)
-		
-		// Try to get from cache
+
+		cacheKey := fmt.Sprintf("%s:%s:%s", c.Request.Method, c.Request.URL.Path, c.Request.URL.RawQuery)
+
 		if cachedData, exists := globalCache.Get(cacheKey); exists {
-			if response, ok := cachedData.(gin.H); ok {
+			if response, ok := cachedData.(*cachedResponse); ok && response.matches(c.Request) {
 				c.Header("X-Cache", "HIT")
-				c.JSON(http.StatusOK, response)
+				c.Header("Cache-Control", response.cacheControl)
+				if response.etag != "" {
+					c.Header("ETag", response.etag)
+				}
+				if len(response.vary) > 0 {
+					c.Header("Vary", strings.Join(response.vary, ", "))
+				}
+				c.Data(http.StatusOK, response.contentType, response.body)
+				c.Abort()
 				return
 			}
 		}
-		
+
 		// Create response writer to capture response
-		rw := &responseWriter{ResponseWriter: c.Writer}
+		buf := acquireBuffer()
+		rw := acquireResponseWriter(c.Writer)
+		rw.body = buf
 		c.Writer = rw
-		
+		defer func() {
+			c.Writer = rw.ResponseWriter
+			releaseResponseWriter(rw)
+			releaseBuffer(buf)
+		}()
+
+		c.Header("X-Cache", "MISS")
 		c.Next()
-		
-		// Cache successful responses
-		if c.Writer.Status() == http.StatusOK && rw.size > 0 {
-			// This is a simplified caching approach
-			// In practice, you'd need to capture the actual response data
-			c.Header("X-Cache", "MISS")
+
+		// Cache successful responses. The body is copied because the buffer goes back to the pool.
+		header := c.Writer.Header()
+		if c.Writer.Status() != http.StatusOK || buf.Len() == 0 || !shareable(header) {
+			return
+		}
+		vary := varyHeaders(header)
+		if slices.Contains(vary, "*") {
+			return
 		}
+		globalCache.Set(cacheKey, &cachedResponse{
+			contentType:  header.Get("Content-Type"),
+			cacheControl: header.Get("Cache-Control"),
+			etag:         header.Get("ETag"),
+			body:         bytes.Clone(buf.Bytes()),
+			vary:         vary,
+			varyValues:   headerValues(c.Request, vary),
+		}, ttl)
 	}
 }
 
+// hasCredentials reports whether a request identifies its caller
+func hasCredentials(req *http.Request) bool {
+	for _, name := range credentialHeaders {
+		if req.Header.Get(name) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// shareable reports whether a response may be served to other clients: it must be marked
+// public, not restricted by private or no-store, and must not set cookies
+func shareable(header http.Header) bool {
+	if header.Get("Set-Cookie") != "" {
+		return false
+	}
+	public := false
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		switch strings.ToLower(strings.TrimSpace(directive)) {
+		case "public":
+			public = true
+		case "private", "no-store", "no-cache":
+			return false
+		}
+	}
+	return public
+}
+
+// varyHeaders returns the canonical names of the request headers a response varies on
+func varyHeaders(header http.Header) []string {
+	var names []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
+
+// headerValues returns the values of the named request headers
+func headerValues(req *http.Request, names []string) []string {
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = strings.Join(req.Header.Values(name), ",")
+	}
+	return values
+}
+
+// matches reports whether a request has the header values the response was produced for
+func (r *cachedResponse) matches(req *http.Request) bool {
+	return len(r.vary) == 0 || slices.Equal(headerValues(req, r.vary), r.varyValues)
+}
+
 // Graceful timeout middleware
 func TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BenchmarkMetricsCollector_Record records requests from parallel goroutines spread over
+// a fixed set of endpoints, as the performance middleware does under load
+func BenchmarkMetricsCollector_Record(b *testing.B) {
+	endpoints := make([]string, 16)
+	for i := range endpoints {
+		endpoints[i] = fmt.Sprintf("GET /api/v1/endpoint/%d", i)
+	}
+
+	mc := NewMetricsCollector()
+	var worker atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := int(worker.Add(1))
+		for pb.Next() {
+			mc.RecordRequest(endpoints[i%len(endpoints)], time.Duration(i)*time.Microsecond, i%10 == 0)
+			i++
+		}
+	})
+}
+
+// discardResponseWriter is a reusable http.ResponseWriter, so that allocations measured by
+// the middleware benchmarks are those of the middleware rather than of a recorder
+type discardResponseWriter struct {
+	header http.Header
+}
+
+func (w *discardResponseWriter) Header() http.Header            { return w.header }
+func (w *discardResponseWriter) Write(data []byte) (int, error) { return len(data), nil }
+func (w *discardResponseWriter) WriteHeader(int)                {}
+
+// benchmarkHandler serves GET path from parallel goroutines and reports allocations
+func benchmarkHandler(b *testing.B, r *gin.Engine, path string) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		w := &discardResponseWriter{header: make(http.Header)}
+		for pb.Next() {
+			clear(w.header)
+			r.ServeHTTP(w, req)
+		}
+	})
+}
+
+// newBenchmarkEngine returns an engine serving a small, publicly cacheable JSON body at /items
+func newBenchmarkEngine(middleware ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	r.Use(middleware...)
+	r.GET("/items", func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=60")
+		c.JSON(http.StatusOK, gin.H{"success": true, "data": []string{"a", "b", "c"}})
+	})
+	r.GET("/metrics", MetricsEndpoint())
+	return r
+}
+
+func BenchmarkPerformanceMiddleware(b *testing.B) {
+	r := newBenchmarkEngine(PerformanceMiddleware(func(c *gin.Context) string { return c.FullPath() }))
+	benchmarkHandler(b, r, "/items")
+}
+
+func BenchmarkMetricsEndpoint(b *testing.B) {
+	r := newBenchmarkEngine()
+	benchmarkHandler(b, r, "/metrics")
+}
+
+func TestCacheMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		cacheControl string
+		vary         string
+		// first and second are the request headers of two requests in turn
+		first, second map[string]string
+		wantSecond    string
+	}{
+		{"public response", "public, max-age=60", "", nil, nil, "HIT"},
+		{"no Cache-Control", "", "", nil, nil, "MISS"},
+		{"private response", "private, max-age=60", "", nil, nil, "MISS"},
+		{"no-store response", "no-store", "", nil, nil, "MISS"},
+		{"authorized request", "public, max-age=60", "", nil, map[string]string{"Authorization": "Bearer token"}, ""},
+		{"request with cookies", "public, max-age=60", "", nil, map[string]string{"Cookie": "session=1"}, ""},
+		{"cached for another client", "public, max-age=60", "", map[string]string{"Cookie": "session=1"}, nil, "MISS"},
+		{"same Vary value", "public, max-age=60", "Accept-Language",
+			map[string]string{"Accept-Language": "en"}, map[string]string{"Accept-Language": "en"}, "HIT"},
+		{"other Vary value", "public, max-age=60", "Accept-Language",
+			map[string]string{"Accept-Language": "en"}, map[string]string{"Accept-Language": "ja"}, "MISS"},
+		{"Vary *", "public, max-age=60", "*", nil, nil, "MISS"},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(CacheMiddleware(time.Minute))
+			served := 0
+			r.GET("/cached", func(c *gin.Context) {
+				served++
+				if tt.cacheControl != "" {
+					c.Header("Cache-Control", tt.cacheControl)
+				}
+				if tt.vary != "" {
+					c.Header("Vary", tt.vary)
+				}
+				c.String(http.StatusOK, "served %d", served)
+			})
+
+			// The response cache is shared, so every case uses its own URL
+			path := "/cached?case=" + strconv.Itoa(i)
+			var w *httptest.ResponseRecorder
+			for _, headers := range []map[string]string{tt.first, tt.second} {
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				for name, value := range headers {
+					req.Header.Set(name, value)
+				}
+				w = httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+			}
+
+			if got := w.Header().Get("X-Cache"); got != tt.wantSecond {
+				t.Errorf("second request X-Cache = %q, want %q", got, tt.wantSecond)
+			}
+			wantServed := 2
+			if tt.wantSecond == "HIT" {
+				wantServed = 1
+			}
+			if served != wantServed {
+				t.Errorf("handler served %d requests, want %d", served, wantServed)
+			}
+		})
+	}
+}
+
+func BenchmarkCacheMiddleware_Miss(b *testing.B) {
+	// A zero TTL expires every entry at once, so each request is a miss that captures the body
+	r := newBenchmarkEngine(CacheMiddleware(0))
+	benchmarkHandler(b, r, "/items?miss")
+}
+
+func BenchmarkCacheMiddleware_Hit(b *testing.B) {
+	r := newBenchmarkEngine(CacheMiddleware(time.Hour))
+	benchmarkHandler(b, r, "/items?hit")
+}
+
+func BenchmarkMemoryCache_SetDelete(b *testing.B) {
+	cache := NewMemoryCache(1000)
+	var worker atomic.Int64
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		key := fmt.Sprintf("key-%d", worker.Add(1))
+		for pb.Next() {
+			cache.Set(key, key, time.Minute)
+			cache.Delete(key)
+		}
+	})
+}
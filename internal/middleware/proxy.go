@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// resolvedClientIPHeader carries the client IP computed by ProxyDepth.
+	// It is always overwritten so clients cannot spoof it.
+	resolvedClientIPHeader = "X-Resolved-Client-IP"
+
+	platformCloudflare = "cloudflare"
+	platformAppEngine  = "appengine"
+)
+
+// ClientIPConfig describes how the client IP should be resolved behind proxies
+type ClientIPConfig struct {
+	TrustedPlatform string
+	TrustedProxies  []string
+	ProxyDepth      int
+}
+
+// ConfigureClientIP applies trusted platform and proxy settings to the engine so that
+// c.ClientIP() reports the real client address for rate limiting and logging.
+// It returns the middleware that must be registered first when proxy depth is used, or nil.
+// A trusted platform and a proxy depth cannot both be set, since the platform header would
+// silently take precedence over the depth.
+func ConfigureClientIP(r *gin.Engine, cfg ClientIPConfig) (gin.HandlerFunc, error) {
+	if cfg.ProxyDepth < 0 {
+		return nil, fmt.Errorf("proxy depth must not be negative: %d", cfg.ProxyDepth)
+	}
+	if cfg.TrustedPlatform != "" && cfg.ProxyDepth > 0 {
+		return nil, errors.New("trusted platform and proxy depth cannot both be set")
+	}
+
+	// Never trust forwarding headers unless proxies are explicitly configured
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
+	switch strings.ToLower(cfg.TrustedPlatform) {
+	case "":
+	case platformCloudflare:
+		r.TrustedPlatform = gin.PlatformCloudflare
+		return nil, nil
+	case platformAppEngine:
+		r.TrustedPlatform = gin.PlatformGoogleAppEngine
+		return nil, nil
+	default:
+		// Custom header name provided by the fronting platform
+		r.TrustedPlatform = cfg.TrustedPlatform
+		return nil, nil
+	}
+
+	if cfg.ProxyDepth == 0 {
+		return nil, nil
+	}
+
+	trustedProxies, err := parseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	r.TrustedPlatform = resolvedClientIPHeader
+	return ProxyDepth(cfg.ProxyDepth, trustedProxies), nil
+}
+
+// ProxyDepth middleware resolves the client IP from X-Forwarded-For assuming a fixed
+// number of proxies in front of the server (e.g. 1 for a single ALB). The header is only
+// honoured on connections from a trusted proxy or over the Unix socket; other clients could
+// write it themselves, so their connecting address is used.
+func ProxyDepth(depth int, trustedProxies []*net.IPNet) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request.Header.Del(resolvedClientIPHeader)
+
+		peer, trusted := peerAddress(c.Request.RemoteAddr, trustedProxies)
+		ip := ""
+		if trusted {
+			ip = clientIPAtDepth(c.GetHeader("X-Forwarded-For"), depth)
+		}
+		if ip == "" {
+			ip = peer
+		}
+		if ip != "" {
+			c.Request.Header.Set(resolvedClientIPHeader, ip)
+		}
+
+		c.Next()
+	}
+}
+
+// peerAddress returns the IP of the connecting peer and whether it is a trusted proxy. Peers
+// on the Unix socket have no IP and are trusted, since only local processes reach the socket.
+func peerAddress(remoteAddr string, trustedProxies []*net.IPNet) (string, bool) {
+	host, _, err := net.SplitHostPort(strings.TrimSpace(remoteAddr))
+	if err != nil {
+		return "", true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "", false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return host, true
+		}
+	}
+	return host, false
+}
+
+// parseTrustedProxies parses trusted proxy CIDRs and IPs, as accepted by gin
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(proxies))
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := net.IPv6len * 8
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), net.IPv4len*8
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", proxy, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// clientIPAtDepth returns the address appended by the outermost trusted proxy
+func clientIPAtDepth(forwardedFor string, depth int) string {
+	if forwardedFor == "" {
+		return ""
+	}
+
+	hops := strings.Split(forwardedFor, ",")
+	if len(hops) < depth {
+		// Fewer hops than expected proxies: the request bypassed part of the chain, so
+		// every hop may have been written by the client
+		return ""
+	}
+
+	ip := strings.TrimSpace(hops[len(hops)-depth])
+	if net.ParseIP(ip) == nil {
+		return ""
+	}
+	return ip
+}
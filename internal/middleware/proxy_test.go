@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestProxyDepth_ClientIP(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		depth        int
+		remoteAddr   string
+		forwardedFor string
+		want         string
+	}{
+		{"trusted proxy", 1, "10.0.0.2:4321", "203.0.113.5", "203.0.113.5"},
+		{"trusted proxy appending to a spoofed hop", 1, "10.0.0.2:4321", "192.0.2.66, 203.0.113.5", "203.0.113.5"},
+		{"trusted proxy without header", 1, "10.0.0.2:4321", "", "10.0.0.2"},
+		{"untrusted peer", 1, "198.51.100.7:4321", "203.0.113.5", "198.51.100.7"},
+		{"Unix socket peer", 1, "@", "203.0.113.5", "203.0.113.5"},
+		{"two proxies", 2, "10.0.0.2:4321", "203.0.113.5, 10.0.0.9", "203.0.113.5"},
+		{"fewer hops than proxies", 2, "10.0.0.2:4321", "203.0.113.5", "10.0.0.2"},
+		{"invalid hop", 1, "10.0.0.2:4321", "not-an-ip", "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			proxyDepth, err := ConfigureClientIP(r, ClientIPConfig{
+				TrustedProxies: []string{"10.0.0.0/8"},
+				ProxyDepth:     tt.depth,
+			})
+			if err != nil {
+				t.Fatalf("ConfigureClientIP: %v", err)
+			}
+			r.Use(proxyDepth)
+			var got string
+			r.GET("/", func(c *gin.Context) { got = c.ClientIP() })
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigureClientIP_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ClientIPConfig
+	}{
+		{"platform and depth", ClientIPConfig{TrustedPlatform: "cloudflare", ProxyDepth: 1}},
+		{"negative depth", ClientIPConfig{ProxyDepth: -1}},
+		{"invalid trusted proxy", ClientIPConfig{TrustedProxies: []string{"10.0.0.0/33"}, ProxyDepth: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ConfigureClientIP(gin.New(), tt.cfg); err == nil {
+				t.Errorf("ConfigureClientIP(%+v) succeeded, want an error", tt.cfg)
+			}
+		})
+	}
+}
@@ -0,0 +1,367 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/lru"
+	"github.com/octop162/normal-form-app-by-claude/pkg/redis"
+)
+
+const (
+	rateLimitCleanupInterval = 10 * time.Minute
+	rateLimitStoreTimeout    = 100 * time.Millisecond
+	defaultPolicyName        = "default"
+)
+
+// RateLimitRule limits requests to limit per period with bursts of up to burst requests
+type RateLimitRule struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+	Burst  int
+}
+
+// rate returns the token refill rate per second
+func (r *RateLimitRule) rate() float64 {
+	return float64(r.Limit) / r.Period.Seconds()
+}
+
+// RateLimitPolicy maps routes to rules. Routes are matched on "METHOD /full/route/:pattern",
+// of the API version that introduced them; a route without a specific rule uses the rule of
+// its class, and otherwise the default rule.
+type RateLimitPolicy struct {
+	Default RateLimitRule
+	// Routes holds rules keyed by route or by class name
+	Routes map[string]RateLimitRule
+	// Classes maps routes to their rate limit class
+	Classes map[string]string
+	// Origins maps routes carried over to a later API version to the routes they were
+	// introduced as, whose rules and buckets they share
+	Origins map[string]string
+}
+
+// NewRateLimitPolicy creates a policy from the default limit and a route rule spec (see ParseRateLimitRules)
+func NewRateLimitPolicy(limit int, period time.Duration, burst int, routeRules string) (*RateLimitPolicy, error) {
+	if limit <= 0 || period <= 0 {
+		return nil, fmt.Errorf("default rate limit must be positive: %d/%s", limit, period)
+	}
+	if burst <= 0 {
+		burst = limit
+	}
+
+	routes, err := ParseRateLimitRules(routeRules)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RateLimitPolicy{
+		Default: RateLimitRule{Name: defaultPolicyName, Limit: limit, Period: period, Burst: burst},
+		Routes:  routes,
+	}, nil
+}
+
+// ParseRateLimitRules parses route rules in the form
+// "METHOD /path=LIMIT/PERIOD[:BURST]", separated by semicolons,
+// e.g. "POST /api/v1/users/validate=20/1m;POST /api/v1/users=10/1m:3".
+func ParseRateLimitRules(spec string) (map[string]RateLimitRule, error) {
+	rules := make(map[string]RateLimitRule)
+
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		route, limitSpec, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid rate limit rule %q: missing '='", entry)
+		}
+
+		key := strings.TrimSpace(route)
+		if method, path, isRoute := strings.Cut(key, " "); isRoute {
+			path = strings.TrimSpace(path)
+			if !strings.HasPrefix(path, "/") {
+				return nil, fmt.Errorf("invalid rate limit rule %q: route must be \"METHOD /path\"", entry)
+			}
+			key = strings.ToUpper(method) + " " + path
+		} else if key == "" {
+			return nil, fmt.Errorf("invalid rate limit rule %q: missing route or class", entry)
+		}
+
+		rule, err := parseRateLimit(key, strings.TrimSpace(limitSpec))
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit rule %q: %w", entry, err)
+		}
+		rules[key] = rule
+	}
+
+	return rules, nil
+}
+
+// parseRateLimit parses "LIMIT/PERIOD[:BURST]"
+func parseRateLimit(name, spec string) (RateLimitRule, error) {
+	rateSpec, burstSpec, hasBurst := strings.Cut(spec, ":")
+
+	limitSpec, periodSpec, ok := strings.Cut(rateSpec, "/")
+	if !ok {
+		return RateLimitRule{}, fmt.Errorf("limit must be \"LIMIT/PERIOD\"")
+	}
+
+	limit, err := strconv.Atoi(limitSpec)
+	if err != nil || limit <= 0 {
+		return RateLimitRule{}, fmt.Errorf("limit must be a positive integer")
+	}
+
+	period, err := time.ParseDuration(periodSpec)
+	if err != nil || period <= 0 {
+		return RateLimitRule{}, fmt.Errorf("period must be a positive duration")
+	}
+
+	burst := limit
+	if hasBurst {
+		burst, err = strconv.Atoi(burstSpec)
+		if err != nil || burst <= 0 {
+			return RateLimitRule{}, fmt.Errorf("burst must be a positive integer")
+		}
+	}
+
+	return RateLimitRule{Name: name, Limit: limit, Period: period, Burst: burst}, nil
+}
+
+// ruleFor returns the rule applying to a method and route pattern
+func (p *RateLimitPolicy) ruleFor(method, route string) RateLimitRule {
+	key := method + " " + route
+	if origin, ok := p.Origins[key]; ok {
+		key = origin
+	}
+	if rule, ok := p.Routes[key]; ok {
+		return rule
+	}
+	if class, ok := p.Classes[key]; ok {
+		if rule, ok := p.Routes[class]; ok {
+			return rule
+		}
+	}
+	return p.Default
+}
+
+// RateLimitResult is the outcome of taking a token
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// RateLimitStore holds token buckets
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, rule RateLimitRule) (*RateLimitResult, error)
+}
+
+// RateLimitWithPolicy applies per-route token bucket limits keyed by client IP.
+// If the store is unavailable the request is allowed so an outage of the backend
+// does not take the API down.
+func RateLimitWithPolicy(policy *RateLimitPolicy, store RateLimitStore, log *logger.Logger) gin.HandlerFunc {
+	return RateLimitByKey(policy, store, func(c *gin.Context) string { return c.ClientIP() }, log)
+}
+
+// RateLimitByKey applies per-route token bucket limits like RateLimitWithPolicy, with a
+// bucket per value of keyOf instead of per client IP
+func RateLimitByKey(
+	policy *RateLimitPolicy, store RateLimitStore, keyOf func(c *gin.Context) string, log *logger.Logger,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule := policy.ruleFor(c.Request.Method, c.FullPath())
+		key := rule.Name + "|" + keyOf(c)
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), rateLimitStoreTimeout)
+		result, err := store.Take(ctx, key, rule)
+		cancel()
+		if err != nil {
+			log.WithContext(c.Request.Context()).WithError(err).WithField("rule", rule.Name).
+				Warn("Rate limit store unavailable, allowing request")
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(rule.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+
+			c.JSON(http.StatusTooManyRequests, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "RATE_LIMIT_EXCEEDED",
+					"message": "Too many requests. Please try again later.",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// tokenBucket is the in-memory state of a single bucket
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	// refill is how long the bucket takes to fill up from empty under its rule
+	refill time.Duration
+}
+
+// memoryRateLimitStore keeps token buckets in process memory (single instance only).
+// Buckets are bounded so that requests from many addresses cannot grow memory without
+// limit between cleanups; when full, the least recently used bucket is evicted, which
+// resets that client to a full bucket.
+type memoryRateLimitStore struct {
+	buckets *lru.Cache[string, *tokenBucket]
+	mutex   sync.Mutex
+}
+
+// NewMemoryRateLimitStore creates an in-memory rate limit store holding at most maxEntries
+// buckets (unbounded when maxEntries is not positive)
+func NewMemoryRateLimitStore(maxEntries int) RateLimitStore {
+	store := &memoryRateLimitStore{
+		buckets: lru.New[string, *tokenBucket](maxEntries),
+	}
+	// Start cleanup goroutine
+	go store.cleanup()
+	return store
+}
+
+// Take refills the bucket for the elapsed time and consumes one token if available
+func (s *memoryRateLimitStore) Take(_ context.Context, key string, rule RateLimitRule) (*RateLimitResult, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	bucket, exists := s.buckets.Get(key)
+	if !exists {
+		bucket = &tokenBucket{tokens: float64(rule.Burst), updated: now}
+		s.buckets.Add(key, bucket)
+	}
+
+	elapsed := now.Sub(bucket.updated).Seconds()
+	bucket.tokens = math.Min(float64(rule.Burst), bucket.tokens+elapsed*rule.rate())
+	bucket.updated = now
+	bucket.refill = time.Duration(float64(rule.Burst) / rule.rate() * float64(time.Second))
+
+	if bucket.tokens < 1 {
+		wait := (1 - bucket.tokens) / rule.rate()
+		return &RateLimitResult{
+			Allowed:    false,
+			RetryAfter: time.Duration(wait * float64(time.Second)),
+		}, nil
+	}
+
+	bucket.tokens--
+	return &RateLimitResult{Allowed: true, Remaining: int(bucket.tokens)}, nil
+}
+
+// cleanup removes buckets that have been idle long enough to be full again, so that
+// removing them does not hand their clients tokens early
+func (s *memoryRateLimitStore) cleanup() {
+	ticker := time.NewTicker(rateLimitCleanupInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.mutex.Lock()
+		now := time.Now()
+		s.buckets.RemoveFunc(func(_ string, bucket *tokenBucket) bool {
+			return now.After(bucket.updated.Add(bucket.refill))
+		})
+		s.mutex.Unlock()
+	}
+}
+
+// Stats reports the number of buckets held
+func (s *memoryRateLimitStore) Stats() lru.Stats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.buckets.Stats()
+}
+
+// tokenBucketScript atomically refills and takes from a bucket stored as a hash.
+// Server time is used so all instances share the same clock.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000 + math.floor(tonumber(t[2]) / 1000)
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1]) or burst
+local updated = tonumber(state[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - updated) * rate / 1000)
+
+local allowed = 0
+local retry_ms = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry_ms = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+return {allowed, math.floor(tokens), retry_ms}
+`)
+
+// redisRateLimitStore keeps token buckets in Redis so limits hold across instances
+type redisRateLimitStore struct {
+	client    *redis.Client
+	keyPrefix string
+}
+
+// NewRedisRateLimitStore creates a Redis-backed rate limit store
+func NewRedisRateLimitStore(client *redis.Client, keyPrefix string) RateLimitStore {
+	return &redisRateLimitStore{
+		client:    client,
+		keyPrefix: keyPrefix,
+	}
+}
+
+// Take consumes one token from the bucket stored in Redis
+func (s *redisRateLimitStore) Take(ctx context.Context, key string, rule RateLimitRule) (*RateLimitResult, error) {
+	values, err := redis.Values(tokenBucketScript.Run(ctx, s.client,
+		[]string{s.keyPrefix + "ratelimit:" + key}, rule.rate(), rule.Burst))
+	if err != nil {
+		return nil, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+	if len(values) != 3 {
+		return nil, fmt.Errorf("unexpected rate limit script reply length: %d", len(values))
+	}
+
+	allowed, err := redis.Int64(values[0], nil)
+	if err != nil {
+		return nil, err
+	}
+	remaining, err := redis.Int64(values[1], nil)
+	if err != nil {
+		return nil, err
+	}
+	retryMillis, err := redis.Int64(values[2], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RateLimitResult{
+		Allowed:    allowed == 1,
+		Remaining:  int(remaining),
+		RetryAfter: time.Duration(retryMillis) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseRateLimitRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    map[string]RateLimitRule
+		wantErr bool
+	}{
+		{
+			name: "route and class rules",
+			spec: "POST /api/v1/users=10/1m:3; phone=5/10m",
+			want: map[string]RateLimitRule{
+				"POST /api/v1/users": {Name: "POST /api/v1/users", Limit: 10, Period: time.Minute, Burst: 3},
+				"phone":              {Name: "phone", Limit: 5, Period: 10 * time.Minute, Burst: 5},
+			},
+		},
+		{
+			name: "method is case-insensitive",
+			spec: "post  /api/v1/users=10/1m",
+			want: map[string]RateLimitRule{
+				"POST /api/v1/users": {Name: "POST /api/v1/users", Limit: 10, Period: time.Minute, Burst: 10},
+			},
+		},
+		{name: "empty", spec: " ; ", want: map[string]RateLimitRule{}},
+		{name: "missing '='", spec: "POST /api/v1/users 10/1m", wantErr: true},
+		{name: "relative path", spec: "POST api/v1/users=10/1m", wantErr: true},
+		{name: "missing route", spec: "=10/1m", wantErr: true},
+		{name: "missing period", spec: "phone=10", wantErr: true},
+		{name: "zero limit", spec: "phone=0/1m", wantErr: true},
+		{name: "invalid period", spec: "phone=10/soon", wantErr: true},
+		{name: "zero burst", spec: "phone=10/1m:0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRateLimitRules(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRateLimitRules(%q) err = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParseRateLimitRules(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+			for key, rule := range tt.want {
+				if got[key] != rule {
+					t.Errorf("rule %q = %+v, want %+v", key, got[key], rule)
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimitPolicy_ruleFor(t *testing.T) {
+	policy, err := NewRateLimitPolicy(100, time.Minute, 0,
+		"POST /api/v1/users/phone/send-code=5/10m:2;phone=10/10m;admin=60/1m")
+	if err != nil {
+		t.Fatalf("NewRateLimitPolicy: %v", err)
+	}
+	policy.Classes = map[string]string{
+		"POST /api/v1/users/phone/send-code":   "phone",
+		"POST /api/v1/users/phone/verify-code": "phone",
+		"POST /api/v2/users/phone/send-code":   "phone",
+		"POST /api/v2/users/phone/verify-code": "phone",
+		"POST /api/v1/sessions":                "session-write",
+	}
+	policy.Origins = map[string]string{
+		"POST /api/v2/users/phone/send-code":   "POST /api/v1/users/phone/send-code",
+		"POST /api/v2/users/phone/verify-code": "POST /api/v1/users/phone/verify-code",
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		route  string
+		want   string
+	}{
+		{"route rule", "POST", "/api/v1/users/phone/send-code", "POST /api/v1/users/phone/send-code"},
+		{"route rule over class rule", "POST", "/api/v2/users/phone/send-code", "POST /api/v1/users/phone/send-code"},
+		{"class rule", "POST", "/api/v1/users/phone/verify-code", "phone"},
+		{"class rule of a later version", "POST", "/api/v2/users/phone/verify-code", "phone"},
+		{"class without a rule", "POST", "/api/v1/sessions", defaultPolicyName},
+		{"other method", "GET", "/api/v1/users/phone/send-code", defaultPolicyName},
+		{"unmatched route", "GET", "", defaultPolicyName},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.ruleFor(tt.method, tt.route); got.Name != tt.want {
+				t.Errorf("ruleFor(%s %s) = %s, want %s", tt.method, tt.route, got.Name, tt.want)
+			}
+		})
+	}
+
+	if policy.Default.Burst != 100 {
+		t.Errorf("default burst = %d, want the limit when unset", policy.Default.Burst)
+	}
+}
+
+func TestMemoryRateLimitStore_Take(t *testing.T) {
+	store := NewMemoryRateLimitStore(100)
+	rule := RateLimitRule{Name: "test", Limit: 2, Period: time.Hour, Burst: 2}
+	ctx := context.Background()
+
+	for i, want := range []bool{true, true, false} {
+		result, err := store.Take(ctx, "client", rule)
+		if err != nil {
+			t.Fatalf("Take: %v", err)
+		}
+		if result.Allowed != want {
+			t.Errorf("request %d: allowed = %v, want %v", i+1, result.Allowed, want)
+		}
+	}
+
+	// Buckets are per key
+	if result, _ := store.Take(ctx, "other", rule); !result.Allowed {
+		t.Error("another key shares the exhausted bucket")
+	}
+}
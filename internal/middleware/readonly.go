@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const (
+	errorCodeReadOnlyMode = "READ_ONLY_MODE"
+)
+
+// ReadOnlyMode rejects POST, PUT, PATCH and DELETE requests with 503 while enabled reports true.
+// Routes in exemptRoutes (gin full paths such as "/api/v1/users/validate") do not change
+// data and stay available.
+func ReadOnlyMode(enabled func() bool, log *logger.Logger, exemptRoutes ...string) gin.HandlerFunc {
+	exempt := make(map[string]struct{}, len(exemptRoutes))
+	for _, route := range exemptRoutes {
+		exempt[route] = struct{}{}
+	}
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			c.Next()
+			return
+		}
+
+		if _, ok := exempt[c.FullPath()]; ok || !enabled() {
+			c.Next()
+			return
+		}
+
+		log.WithContext(c.Request.Context()).
+			WithField("method", c.Request.Method).
+			WithField("path", c.FullPath()).
+			Info("Rejected write request in read-only mode")
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"error": gin.H{
+				"code":    errorCodeReadOnlyMode,
+				"message": "The service is temporarily read-only; please try again later",
+			},
+		})
+		c.Abort()
+	}
+}
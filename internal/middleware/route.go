@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheControl sets the Cache-Control header of responses
+func CacheControl(value string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", value)
+		c.Next()
+	}
+}
+
+// Deprecated announces that a route will be removed (RFC 8594): it sets the Deprecation
+// header and, when known, the Sunset date and a Link to the successor route. Parameters in
+// the successor route pattern, such as :id, take the values of the request.
+func Deprecated(sunset time.Time, successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		if !sunset.IsZero() {
+			c.Header("Sunset", sunset.UTC().Format(http.TimeFormat))
+		}
+		if successor != "" {
+			c.Header("Link", "<"+successorPath(c, successor)+`>; rel="successor-version"`)
+		}
+		c.Next()
+	}
+}
+
+// successorPath fills the parameters of a route pattern with those of the request
+func successorPath(c *gin.Context, pattern string) string {
+	if !strings.ContainsAny(pattern, ":*") {
+		return pattern
+	}
+
+	segments := strings.Split(pattern, "/")
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			segments[i] = url.PathEscape(c.Param(segment[1:]))
+		case strings.HasPrefix(segment, "*"):
+			segments[i] = strings.TrimPrefix(c.Param(segment[1:]), "/")
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/pkg/external"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const (
+	headerUseSandbox  = "X-Use-Sandbox"
+	headerSandbox     = "X-Sandbox"
+	headerBypassCache = "X-Bypass-Cache"
+)
+
+// SandboxSelector routes external API calls of a request to partner sandboxes when
+// "X-Use-Sandbox: true" is sent together with a valid admin token. Requests asking for
+// the sandbox without the token are rejected rather than silently hitting production.
+func SandboxSelector(adminToken string, log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		useSandbox, err := strconv.ParseBool(c.GetHeader(headerUseSandbox))
+		if err != nil || !useSandbox {
+			c.Next()
+			return
+		}
+
+		if !hasAdminToken(c, adminToken) {
+			log.WithContext(c.Request.Context()).
+				WithField("client_ip", c.ClientIP()).
+				Warn("Sandbox routing requested without admin token")
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "SANDBOX_NOT_ALLOWED",
+					"message": "Sandbox routing requires an admin token",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(external.WithSandbox(c.Request.Context()))
+		c.Header(headerSandbox, "true")
+
+		c.Next()
+	}
+}
+
+// CacheBypass makes external API calls of a request skip cached responses when
+// "X-Bypass-Cache: true" is sent together with a valid admin token, for debugging answers
+// that look stale. Requests asking for it without the token are rejected.
+func CacheBypass(adminToken string, log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		bypass, err := strconv.ParseBool(c.GetHeader(headerBypassCache))
+		if err != nil || !bypass {
+			c.Next()
+			return
+		}
+
+		if !hasAdminToken(c, adminToken) {
+			log.WithContext(c.Request.Context()).
+				WithField("client_ip", c.ClientIP()).
+				Warn("Cache bypass requested without admin token")
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    "CACHE_BYPASS_NOT_ALLOWED",
+					"message": "Cache bypass requires an admin token",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(external.WithoutCache(c.Request.Context()))
+
+		c.Next()
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const (
+	errorCodeSuspiciousActivity = "SUSPICIOUS_ACTIVITY"
+
+	// securityEventContextKey marks a request rejected by middleware that the response status
+	// alone does not identify, such as a CSRF failure
+	securityEventContextKey = "security_event"
+
+	// Security event types; they match the types stored in security_events
+	securityEventCSRFFailure       = "csrf_failure"
+	securityEventRateLimited       = "rate_limited"
+	securityEventValidationFailure = "validation_failure"
+)
+
+// SecurityBlockChecker reports whether a client IP is blocked and until when
+type SecurityBlockChecker func(clientIP string) (time.Time, bool)
+
+// SecurityEventRecorder records a rejected request from a client IP. It must not block.
+type SecurityEventRecorder func(clientIP, eventType, method, path string, status int, requestID string)
+
+// SecurityMonitor rejects requests from blocked client IPs with 403 and reports CSRF failures,
+// rate limit hits (429) and invalid input (400 and 422) so that repeated ones can block the IP.
+// Callers presenting the admin token are neither rejected nor reported, so operators cannot
+// lock themselves out.
+func SecurityMonitor(
+	blockedUntil SecurityBlockChecker, record SecurityEventRecorder, adminToken string, log *logger.Logger,
+) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if hasAdminToken(c, adminToken) {
+			c.Next()
+			return
+		}
+
+		clientIP := c.ClientIP()
+		if until, blocked := blockedUntil(clientIP); blocked {
+			log.WithContext(c.Request.Context()).
+				WithField("client_ip", clientIP).
+				WithField("path", c.Request.URL.Path).
+				Debug("Rejected request from blocked client IP")
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(until).Seconds()))))
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error": gin.H{
+					"code":    errorCodeSuspiciousActivity,
+					"message": "Requests from this client are temporarily blocked due to suspicious activity",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		eventType := c.GetString(securityEventContextKey)
+		if eventType == "" {
+			switch c.Writer.Status() {
+			case http.StatusTooManyRequests:
+				eventType = securityEventRateLimited
+			case http.StatusBadRequest, http.StatusUnprocessableEntity:
+				eventType = securityEventValidationFailure
+			default:
+				return
+			}
+		}
+
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path
+		}
+		record(clientIP, eventType, c.Request.Method, path, c.Writer.Status(), c.GetString(ContextKeyRequestID))
+	}
+}
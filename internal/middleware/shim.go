@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const errorCodeShimFailed = "INCOMPATIBLE_REQUEST"
+
+// JSONRewrite rewrites a decoded JSON document in place
+type JSONRewrite func(document map[string]any) error
+
+// JSONShim serves a handler to clients of another API version by rewriting JSON bodies:
+// request rewrites the request body before the handler binds it, and response rewrites the
+// response body the handler wrote. Either may be nil. Bodies that are not JSON objects are
+// passed through unchanged; a request the rewrite rejects gets a 400.
+func JSONShim(request, response JSONRewrite, log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if request != nil && c.Request.Body != nil && c.Request.Body != http.NoBody &&
+			strings.Contains(c.GetHeader("Content-Type"), "json") {
+			body, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"success": false, "error": gin.H{
+					"code": errorCodeShimFailed, "message": "Failed to read the request body",
+				}})
+				return
+			}
+			body, err = rewriteJSON(body, request)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"success": false, "error": gin.H{
+					"code": errorCodeShimFailed, "message": err.Error(),
+				}})
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			c.Request.ContentLength = int64(len(body))
+			c.Request.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		}
+
+		if response == nil {
+			c.Next()
+			return
+		}
+
+		writer := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		body := writer.body.Bytes()
+		if strings.Contains(writer.Header().Get("Content-Type"), "json") {
+			rewritten, err := rewriteJSON(body, response)
+			if err != nil {
+				// The handler's work is done, so the response is sent as the handler wrote it
+				log.WithContext(c.Request.Context()).WithError(err).Error("Failed to rewrite response for API version")
+			} else {
+				body = rewritten
+			}
+		}
+		if writer.Header().Get("Content-Length") != "" {
+			writer.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		}
+		writer.ResponseWriter.WriteHeader(writer.Status())
+		_, _ = writer.ResponseWriter.Write(body)
+	}
+}
+
+// rewriteJSON applies rewrite to a JSON object, returning documents of other kinds as they are
+func rewriteJSON(body []byte, rewrite JSONRewrite) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var document map[string]any
+	if err := decoder.Decode(&document); err != nil || document == nil {
+		return body, nil
+	}
+	if err := rewrite(document); err != nil {
+		return nil, err
+	}
+	return json.Marshal(document)
+}
+
+// bufferedWriter holds a response back until it is rewritten
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/pkg/external"
+)
+
+// StrictModeConfig selects the routes served in strict mode
+type StrictModeConfig struct {
+	// Classes are the route classes served strictly, e.g. submit or browse
+	Classes []string
+	// Routes are the operation IDs of routes served strictly whatever their class
+	Routes []string
+}
+
+// StrictMode serves requests in strict mode: conditions that are otherwise logged and
+// worked around, such as a failed external API answered from local data, reject the request
+func StrictMode() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(external.WithStrict(c.Request.Context()))
+		c.Next()
+	}
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"slices"
+	"time"
+)
+
+// Partner API key scopes
+const (
+	ScopeAvailabilityRead = "availability:read"
+	ScopePlansRead        = "plans:read"
+)
+
+// APIKeyScopes lists the scopes a partner API key can grant
+var APIKeyScopes = []string{ScopeAvailabilityRead, ScopePlansRead}
+
+// APIKey is a key a partner calls the partner API with. Only the hash of the key is stored.
+type APIKey struct {
+	ID        int64      `json:"id" db:"id"`
+	Partner   string     `json:"partner" db:"partner"`
+	KeyPrefix string     `json:"key_prefix" db:"key_prefix"`
+	KeyHash   string     `json:"-" db:"key_hash"`
+	Scopes    []string   `json:"scopes" db:"scopes"`
+	Note      *string    `json:"note" db:"note"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at" db:"revoked_at"`
+}
+
+// HasScope reports whether the key grants scope
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// IsRevoked reports whether the key was revoked
+func (k *APIKey) IsRevoked() bool {
+	return k.RevokedAt != nil
+}
+
+// APIKeyUsage is the requests a key made to an endpoint in a period, an hour as recorded
+type APIKeyUsage struct {
+	APIKeyID         int64     `json:"api_key_id" db:"api_key_id"`
+	PeriodStart      time.Time `json:"period_start" db:"period_start"`
+	Endpoint         string    `json:"endpoint" db:"endpoint"`
+	RequestCount     int64     `json:"request_count" db:"request_count"`
+	RequestBytes     int64     `json:"request_bytes" db:"request_bytes"`
+	ResponseBytes    int64     `json:"response_bytes" db:"response_bytes"`
+	ClientErrorCount int64     `json:"client_error_count" db:"client_error_count"`
+	ServerErrorCount int64     `json:"server_error_count" db:"server_error_count"`
+}
+
+// Add adds the counts of other to u
+func (u *APIKeyUsage) Add(other *APIKeyUsage) {
+	u.RequestCount += other.RequestCount
+	u.RequestBytes += other.RequestBytes
+	u.ResponseBytes += other.ResponseBytes
+	u.ClientErrorCount += other.ClientErrorCount
+	u.ServerErrorCount += other.ServerErrorCount
+}
+
+// ErrorRate returns the share of requests answered with an error status
+func (u *APIKeyUsage) ErrorRate() float64 {
+	if u.RequestCount == 0 {
+		return 0
+	}
+	return float64(u.ClientErrorCount+u.ServerErrorCount) / float64(u.RequestCount)
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+)
+
+// Attachment scan statuses
+const (
+	ScanStatusPending  = "pending"
+	ScanStatusClean    = "clean"
+	ScanStatusInfected = "infected"
+)
+
+// Attachment is an uploaded file. It can be linked to a form session once its malware
+// scan is clean; infected files are moved to quarantine.
+type Attachment struct {
+	ID            string     `json:"id" db:"id"`
+	SessionID     *string    `json:"session_id" db:"session_id"`
+	StorageKey    string     `json:"-" db:"storage_key"`
+	Filename      string     `json:"filename" db:"filename"`
+	ContentType   string     `json:"content_type" db:"content_type"`
+	Size          int64      `json:"size" db:"size"`
+	SHA256        string     `json:"sha256" db:"sha256"`
+	ScanStatus    string     `json:"scan_status" db:"scan_status"`
+	ScanSignature *string    `json:"scan_signature" db:"scan_signature"`
+	ScanAttempts  int        `json:"scan_attempts" db:"scan_attempts"`
+	ScanError     *string    `json:"scan_error" db:"scan_error"`
+	NextScanAt    time.Time  `json:"next_scan_at" db:"next_scan_at"`
+	ScannedAt     *time.Time `json:"scanned_at" db:"scanned_at"`
+	QuarantinedAt *time.Time `json:"quarantined_at" db:"quarantined_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit actors
+const (
+	AuditActorAdminAPI = "admin_api"
+)
+
+// AuditLog represents an administrative change recorded for later review
+type AuditLog struct {
+	ID            int64           `json:"id" db:"id"`
+	Actor         string          `json:"actor" db:"actor"`
+	ActorIP       *string         `json:"actor_ip" db:"actor_ip"`
+	Action        string          `json:"action" db:"action"`
+	ResourceType  string          `json:"resource_type" db:"resource_type"`
+	ResourceID    string          `json:"resource_id" db:"resource_id"`
+	Details       json.RawMessage `json:"details" db:"details"`
+	CorrelationID *string         `json:"correlation_id" db:"correlation_id"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+)
+
+// Building represents a row of the building name master, keyed by postal code and block
+// numbers
+type Building struct {
+	ID         int       `json:"id" db:"id"`
+	PostalCode string    `json:"postal_code" db:"postal_code"`
+	Banchi     string    `json:"banchi" db:"banchi"`
+	Name       string    `json:"name" db:"name"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
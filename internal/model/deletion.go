@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+)
+
+// Deletion operators
+const (
+	DeletionOperatorUserAPI  = "user_api"
+	DeletionOperatorAdminAPI = "admin_api"
+)
+
+// DeletionRecord is the minimal tombstone kept after a user is hard-deleted
+type DeletionRecord struct {
+	ID          int64     `json:"id" db:"id"`
+	UserID      int       `json:"user_id" db:"user_id"`
+	EmailHash   string    `json:"email_hash" db:"email_hash"`
+	Reason      *string   `json:"reason" db:"reason"`
+	Operator    string    `json:"operator" db:"operator"`
+	DeletedAt   time.Time `json:"deleted_at" db:"deleted_at"`
+	RetainUntil time.Time `json:"retain_until" db:"retain_until"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// Email notification statuses
+const (
+	EmailNotificationPending = "pending"
+	EmailNotificationSent    = "sent"
+	// EmailNotificationFailed is a notification whose send attempts ran out
+	EmailNotificationFailed = "failed"
+	// EmailNotificationSkipped is a notification not sent because the address is suppressed
+	EmailNotificationSkipped = "skipped"
+)
+
+// EmailNotification is an email queued for a user. The notification service renders
+// the template from the user's current data when it sends the email.
+type EmailNotification struct {
+	ID            int64      `json:"id" db:"id"`
+	Template      string     `json:"template" db:"template"`
+	UserID        int        `json:"user_id" db:"user_id"`
+	Status        string     `json:"status" db:"status"`
+	Attempts      int        `json:"attempts" db:"attempts"`
+	LastError     *string    `json:"last_error" db:"last_error"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	SentAt        *time.Time `json:"sent_at" db:"sent_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
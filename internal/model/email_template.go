@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+)
+
+// EmailTemplate represents a stored version of an email template. Versions are immutable;
+// editing a template adds a version and activating it switches what is sent.
+type EmailTemplate struct {
+	ID        int       `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	Version   int       `json:"version" db:"version"`
+	Subject   string    `json:"subject" db:"subject"`
+	Body      string    `json:"body" db:"body"`
+	IsActive  bool      `json:"is_active" db:"is_active"`
+	Note      *string   `json:"note" db:"note"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,13 @@
+package model
+
+import (
+	"time"
+)
+
+// FeatureFlagOverride represents a runtime override of a configured feature flag
+type FeatureFlagOverride struct {
+	Name      string    `json:"name" db:"name"`
+	Enabled   bool      `json:"enabled" db:"enabled"`
+	Reason    *string   `json:"reason" db:"reason"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Key rotation targets, the tables holding values encrypted with the field cipher
+const (
+	KeyRotationTargetUsers             = "users"
+	KeyRotationTargetUserCreateTickets = "user_create_tickets"
+)
+
+// KeyRotation is the checkpoint of re-encrypting one table from one key to another
+type KeyRotation struct {
+	ID        int64  `json:"id" db:"id"`
+	FromKeyID string `json:"from_key_id" db:"from_key_id"`
+	ToKeyID   string `json:"to_key_id" db:"to_key_id"`
+	Target    string `json:"target" db:"target"`
+	// LastID is the last row rewritten; the rotation continues after it
+	LastID      int64      `json:"last_id" db:"last_id"`
+	Rewritten   int64      `json:"rewritten" db:"rewritten"`
+	CompletedAt *time.Time `json:"completed_at" db:"completed_at"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`
+}
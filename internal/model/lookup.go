@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// UserLookup is a pending self-service lookup awaiting email verification
+type UserLookup struct {
+	ID         string     `json:"id" db:"id"`
+	UserID     int        `json:"user_id" db:"user_id"`
+	CodeHash   string     `json:"-" db:"code_hash"`
+	Attempts   int        `json:"attempts" db:"attempts"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	VerifiedAt *time.Time `json:"verified_at" db:"verified_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// IsExpired checks if the verification code can no longer be used
+func (l *UserLookup) IsExpired() bool {
+	return time.Now().After(l.ExpiresAt)
+}
@@ -0,0 +1,17 @@
+package model
+
+// OptionTakeUp counts the registrations of a plan, in one prefecture or nationwide, and
+// how many of them selected each option
+type OptionTakeUp struct {
+	Users int64
+	// Options maps option types to the number of registrations that selected them
+	Options map[string]int64
+}
+
+// Rate returns the share of the registrations that selected optionType
+func (t *OptionTakeUp) Rate(optionType string) float64 {
+	if t.Users == 0 {
+		return 0
+	}
+	return float64(t.Options[optionType]) / float64(t.Users)
+}
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Outbox event statuses
+const (
+	OutboxStatusPending   = "pending"
+	OutboxStatusPublished = "published"
+	OutboxStatusFailed    = "failed"
+)
+
+// OutboxEvent represents an event recorded in the same transaction as the state change
+// and delivered to downstream consumers by the outbox relay
+type OutboxEvent struct {
+	ID            int64           `json:"id" db:"id"`
+	AggregateType string          `json:"aggregate_type" db:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id" db:"aggregate_id"`
+	EventType     string          `json:"event_type" db:"event_type"`
+	Payload       json.RawMessage `json:"payload" db:"payload"`
+	CorrelationID *string         `json:"correlation_id" db:"correlation_id"`
+	Status        string          `json:"status" db:"status"`
+	Attempts      int             `json:"attempts" db:"attempts"`
+	LastError     *string         `json:"last_error" db:"last_error"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	PublishedAt   *time.Time      `json:"published_at" db:"published_at"`
+}
+
+// Delivery states of the admin listing of undelivered events
+const (
+	// EventDeliveryFailed is an event no longer retried by the relay or the webhook dispatcher
+	EventDeliveryFailed = "failed"
+	// EventDeliveryRetrying is an event whose last attempt failed and is retried later
+	EventDeliveryRetrying = "retrying"
+)
+
+// OutboxEventDeliveries is an outbox event with its webhook delivery, nil when the event was
+// not queued for the webhook endpoint
+type OutboxEventDeliveries struct {
+	Event   *OutboxEvent
+	Webhook *WebhookDelivery
+}
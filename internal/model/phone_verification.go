@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+)
+
+// PhoneVerification is an SMS code sent to confirm a phone number
+type PhoneVerification struct {
+	ID         string     `json:"id" db:"id"`
+	Phone      string     `json:"phone" db:"phone"`
+	CodeHash   string     `json:"-" db:"code_hash"`
+	Attempts   int        `json:"attempts" db:"attempts"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	VerifiedAt *time.Time `json:"verified_at" db:"verified_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// IsExpired checks if the verification code can no longer be used
+func (v *PhoneVerification) IsExpired() bool {
+	return time.Now().After(v.ExpiresAt)
+}
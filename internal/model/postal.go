@@ -0,0 +1,19 @@
+package model
+
+import (
+	"time"
+)
+
+// PostalAddress represents a row of the Japan Post postal code master (KEN_ALL)
+type PostalAddress struct {
+	ID                  int       `json:"id" db:"id"`
+	LocalGovernmentCode string    `json:"local_government_code" db:"local_government_code"`
+	PostalCode          string    `json:"postal_code" db:"postal_code"`
+	PrefectureKana      string    `json:"prefecture_kana" db:"prefecture_kana"`
+	CityKana            string    `json:"city_kana" db:"city_kana"`
+	TownKana            string    `json:"town_kana" db:"town_kana"`
+	Prefecture          string    `json:"prefecture" db:"prefecture"`
+	City                string    `json:"city" db:"city"`
+	Town                string    `json:"town" db:"town"`
+	CreatedAt           time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/money"
+)
+
+// UserPricingSnapshot represents the monthly prices a user agreed to at registration. It is
+// written once, so later changes to plan and option prices or to the tax rate do not alter it.
+type UserPricingSnapshot struct {
+	UserID   int           `json:"user_id" db:"user_id"`
+	PlanType string        `json:"plan_type" db:"plan_type"`
+	Lines    []PricingLine `json:"lines" db:"lines"`
+	// Subtotal excludes tax, which is computed once on it at TaxRate
+	Subtotal  money.Yen  `json:"subtotal" db:"subtotal"`
+	TaxRate   money.Rate `json:"tax_rate" db:"tax_rate"`
+	Tax       money.Yen  `json:"tax" db:"tax"`
+	Total     money.Yen  `json:"total" db:"total"`
+	PricedAt  time.Time  `json:"priced_at" db:"priced_at"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+}
+
+// PricingLine represents the plan or one option of a pricing snapshot
+type PricingLine struct {
+	// Kind is "plan" or "option"
+	Kind      string    `json:"kind"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	UnitPrice money.Yen `json:"unit_price"`
+	Quantity  int       `json:"quantity"`
+	Amount    money.Yen `json:"amount"`
+}
@@ -0,0 +1,18 @@
+package model
+
+import (
+	"time"
+)
+
+// RegionRestriction records whether an option may be offered in a prefecture or one of its
+// cities. An empty City applies to the whole prefecture.
+type RegionRestriction struct {
+	ID         int       `json:"id" db:"id"`
+	Prefecture string    `json:"prefecture" db:"prefecture"`
+	City       string    `json:"city" db:"city"`
+	OptionType string    `json:"option_type" db:"option_type"`
+	Allowed    bool      `json:"allowed" db:"allowed"`
+	Note       *string   `json:"note" db:"note"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
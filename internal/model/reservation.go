@@ -0,0 +1,29 @@
+package model
+
+import (
+	"time"
+)
+
+// Reservation statuses
+const (
+	ReservationStatusHeld     = "held"
+	ReservationStatusConsumed = "consumed"
+	ReservationStatusReleased = "released"
+)
+
+// OptionReservation holds option stock for a form session until the user registers or the
+// hold expires
+type OptionReservation struct {
+	ID int `json:"id" db:"id"`
+	// SessionID is nil once the session was deleted
+	SessionID  *string   `json:"session_id" db:"session_id"`
+	OptionType string    `json:"option_type" db:"option_type"`
+	Quantity   int       `json:"quantity" db:"quantity"`
+	Status     string    `json:"status" db:"status"`
+	UserID     *int      `json:"user_id" db:"user_id"`
+	ExpiresAt  time.Time `json:"expires_at" db:"expires_at"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+	// SessionExpiresAt is when the session expires; nil when the session was deleted
+	SessionExpiresAt *time.Time `json:"session_expires_at" db:"session_expires_at"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// Security event types
+const (
+	// SecurityEventCSRFFailure is a request rejected for a missing or invalid CSRF token
+	SecurityEventCSRFFailure = "csrf_failure"
+	// SecurityEventRateLimited is a request rejected by a rate limit
+	SecurityEventRateLimited = "rate_limited"
+	// SecurityEventValidationFailure is a request rejected as invalid input
+	SecurityEventValidationFailure = "validation_failure"
+)
+
+// SecurityEvent records a rejected request that may be part of an attack
+type SecurityEvent struct {
+	ID         int64     `json:"id" db:"id"`
+	ClientIP   string    `json:"client_ip" db:"client_ip"`
+	EventType  string    `json:"event_type" db:"event_type"`
+	Method     string    `json:"method" db:"method"`
+	Path       string    `json:"path" db:"path"`
+	StatusCode int       `json:"status_code" db:"status_code"`
+	RequestID  *string   `json:"request_id" db:"request_id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// IPBlock rejects every request from a client IP until it expires or is lifted
+type IPBlock struct {
+	ClientIP string `json:"client_ip" db:"client_ip"`
+	// Reason is the event type whose repetition caused the block
+	Reason       string    `json:"reason" db:"reason"`
+	EventCount   int       `json:"event_count" db:"event_count"`
+	BlockedUntil time.Time `json:"blocked_until" db:"blocked_until"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
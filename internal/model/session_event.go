@@ -0,0 +1,31 @@
+package model
+
+import "time"
+
+// Session event types
+const (
+	SessionEventCreated       = "created"
+	SessionEventStepCompleted = "step_completed"
+	// SessionEventStepFailed is a wizard step that failed validation
+	SessionEventStepFailed = "step_failed"
+	// SessionEventSubmitted is a session whose data was registered as a user
+	SessionEventSubmitted = "submitted"
+)
+
+// SessionEvent records that a form session reached a point of the registration funnel
+type SessionEvent struct {
+	ID        int64     `json:"id" db:"id"`
+	SessionID string    `json:"session_id" db:"session_id"`
+	EventType string    `json:"event_type" db:"event_type"`
+	Step      *string   `json:"step" db:"step"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// SessionFunnelCount is the number of sessions started in a period that had an event, for
+// step events per step, and the median time from the start of the session to the event
+type SessionFunnelCount struct {
+	EventType     string
+	Step          string
+	Sessions      int64
+	MedianSeconds float64
+}
@@ -0,0 +1,41 @@
+package model
+
+// SessionFormSchemaVersion is the version of the SessionFormData layout saved by this build.
+// Data saved before the layout was versioned reads as version 0, which has the same layout
+// as version 1.
+const SessionFormSchemaVersion = 1
+
+// SessionFormData is the form data saved in a session: the fields of a registration request,
+// each optional as the form is filled in step by step. Tokens and the honeypot field of the
+// request are never saved.
+type SessionFormData struct {
+	LastName      *string                        `json:"last_name,omitempty"`
+	FirstName     *string                        `json:"first_name,omitempty"`
+	LastNameKana  *string                        `json:"last_name_kana,omitempty"`
+	FirstNameKana *string                        `json:"first_name_kana,omitempty"`
+	Phone1        *string                        `json:"phone1,omitempty"`
+	Phone2        *string                        `json:"phone2,omitempty"`
+	Phone3        *string                        `json:"phone3,omitempty"`
+	PostalCode1   *string                        `json:"postal_code1,omitempty"`
+	PostalCode2   *string                        `json:"postal_code2,omitempty"`
+	Prefecture    *string                        `json:"prefecture,omitempty"`
+	City          *string                        `json:"city,omitempty"`
+	Town          *string                        `json:"town,omitempty"`
+	Chome         *string                        `json:"chome,omitempty"`
+	Banchi        *string                        `json:"banchi,omitempty"`
+	Go            *string                        `json:"go,omitempty"`
+	Building      *string                        `json:"building,omitempty"`
+	Room          *string                        `json:"room,omitempty"`
+	Email         *string                        `json:"email,omitempty"`
+	EmailConfirm  *string                        `json:"email_confirm,omitempty"`
+	PlanType      *string                        `json:"plan_type,omitempty"`
+	OptionTypes   []string                       `json:"option_types,omitempty"`
+	OptionDetails map[string]SessionOptionDetail `json:"option_details,omitempty"`
+	Locale        *string                        `json:"locale,omitempty"`
+}
+
+// SessionOptionDetail is the saved settings of a selected option
+type SessionOptionDetail struct {
+	Quantity  *int    `json:"quantity,omitempty"`
+	StartDate *string `json:"start_date,omitempty"`
+}
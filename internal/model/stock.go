@@ -0,0 +1,8 @@
+package model
+
+// Stock states shown to users instead of raw stock numbers
+const (
+	StockStateInStock    = "in_stock"
+	StockStateLowStock   = "low_stock"
+	StockStateOutOfStock = "out_of_stock"
+)
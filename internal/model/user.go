@@ -3,6 +3,22 @@ package model
 
 import (
 	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/money"
+)
+
+// User account statuses
+const (
+	UserStatusActive    = "active"
+	UserStatusSuspended = "suspended"
+)
+
+// Email deliverability statuses reported by the mail provider. Email is not sent to
+// bounced or complained addresses.
+const (
+	EmailDeliverable = "deliverable"
+	EmailBounced     = "bounced"
+	EmailComplained  = "complained"
 )
 
 // User represents a registered user
@@ -15,6 +31,8 @@ type User struct {
 	Phone1       string    `json:"phone1" db:"phone1"`
 	Phone2       string    `json:"phone2" db:"phone2"`
 	Phone3       string    `json:"phone3" db:"phone3"`
+	// PhoneVerified is set once the phone number was confirmed by SMS
+	PhoneVerified bool     `json:"phone_verified" db:"phone_verified"`
 	PostalCode1  string    `json:"postal_code1" db:"postal_code1"`
 	PostalCode2  string    `json:"postal_code2" db:"postal_code2"`
 	Prefecture   string    `json:"prefecture" db:"prefecture"`
@@ -27,23 +45,44 @@ type User struct {
 	Room         *string   `json:"room" db:"room"`
 	Email        string    `json:"email" db:"email"`
 	PlanType     string    `json:"plan_type" db:"plan_type"`
+	// Locale is the language negotiated at registration; emails are written in it
+	Locale       string    `json:"locale" db:"locale"`
+	Status       string    `json:"status" db:"status"`
+	LegalHold    bool      `json:"legal_hold" db:"legal_hold"`
+	// EmailDeliverability is reset to deliverable when the email address changes
+	EmailDeliverability          string     `json:"email_deliverability" db:"email_deliverability"`
+	EmailDeliverabilityReason    *string    `json:"email_deliverability_reason" db:"email_deliverability_reason"`
+	EmailDeliverabilityUpdatedAt *time.Time `json:"email_deliverability_updated_at" db:"email_deliverability_updated_at"`
+	// ErasedAt is set once the personal data was replaced with placeholders on request
+	ErasedAt     *time.Time `json:"erased_at" db:"erased_at"`
+	// Version is incremented by every update; updates carrying another version are rejected
+	Version      int       `json:"version" db:"version"`
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // UserOption represents a selected option for a user
 type UserOption struct {
-	ID         int       `json:"id" db:"id"`
-	UserID     int       `json:"user_id" db:"user_id"`
-	OptionType string    `json:"option_type" db:"option_type"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	ID         int    `json:"id" db:"id"`
+	UserID     int    `json:"user_id" db:"user_id"`
+	OptionType string `json:"option_type" db:"option_type"`
+	Quantity   int    `json:"quantity" db:"quantity"`
+	// StartDate is the desired start date; nil means as soon as possible
+	StartDate *time.Time `json:"start_date" db:"start_date"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
 }
 
 // UserSession represents a temporary session for form data
 type UserSession struct {
 	ID        string                 `json:"id" db:"id"`
-	UserData  map[string]interface{} `json:"user_data" db:"user_data"`
+	UserData  SessionFormData        `json:"user_data" db:"user_data"`
 	ExpiresAt time.Time              `json:"expires_at" db:"expires_at"`
+	// ClientIP and Email identify the owner for per-owner session limits
+	ClientIP  *string                `json:"client_ip" db:"client_ip"`
+	Email     *string                `json:"email" db:"email"`
+	// Version increases on every save so concurrent writers can detect conflicts
+	Version   int                    `json:"version" db:"version"`
+	Wizard    WizardState            `json:"wizard" db:"wizard_state"`
 	CreatedAt time.Time              `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time              `json:"updated_at" db:"updated_at"`
 }
@@ -55,9 +94,30 @@ type OptionMaster struct {
 	OptionName        string    `json:"option_name" db:"option_name"`
 	Description       *string   `json:"description" db:"description"`
 	PlanCompatibility string    `json:"plan_compatibility" db:"plan_compatibility"`
+	MaxQuantity       int       `json:"max_quantity" db:"max_quantity"`
 	IsActive          bool      `json:"is_active" db:"is_active"`
-	CreatedAt         time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
+	// MonthlyPrice is the price of one unit per month, excluding tax
+	MonthlyPrice money.Yen `json:"monthly_price" db:"monthly_price"`
+	// ValidFrom and ValidUntil bound when the option is offered; nil means unbounded
+	ValidFrom  *time.Time `json:"valid_from" db:"valid_from"`
+	ValidUntil *time.Time `json:"valid_until" db:"valid_until"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// PlanMaster represents master data for plans
+type PlanMaster struct {
+	ID          int     `json:"id" db:"id"`
+	PlanType    string  `json:"plan_type" db:"plan_type"`
+	PlanName    string  `json:"plan_name" db:"plan_name"`
+	Description *string `json:"description" db:"description"`
+	// MonthlyPrice is the price per month, excluding tax
+	MonthlyPrice money.Yen `json:"monthly_price" db:"monthly_price"`
+	// DisplayOrder sorts the plan list, ascending
+	DisplayOrder int       `json:"display_order" db:"display_order"`
+	IsActive     bool      `json:"is_active" db:"is_active"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // PrefectureMaster represents master data for prefectures
@@ -124,9 +184,34 @@ func (s *UserSession) IsExpired() bool {
 	return time.Now().After(s.ExpiresAt)
 }
 
+// IsAvailableAt reports whether the option is active and within its validity period at t
+func (o *OptionMaster) IsAvailableAt(t time.Time) bool {
+	if !o.IsActive {
+		return false
+	}
+	if o.ValidFrom != nil && t.Before(*o.ValidFrom) {
+		return false
+	}
+	if o.ValidUntil != nil && !t.Before(*o.ValidUntil) {
+		return false
+	}
+	return true
+}
+
+// EmailSuppressed reports whether email to the user is suppressed because the address
+// bounced or the user complained
+func (u *User) EmailSuppressed() bool {
+	return u.EmailDeliverability == EmailBounced || u.EmailDeliverability == EmailComplained
+}
+
+// IsErased reports whether the personal data of the user was erased
+func (u *User) IsErased() bool {
+	return u.ErasedAt != nil
+}
+
 // CanUseOption checks if the option is compatible with the user's plan
 func (u *User) CanUseOption(option *OptionMaster) bool {
-	if !option.IsActive {
+	if !option.IsAvailableAt(time.Now()) {
 		return false
 	}
 	
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// User create ticket statuses
+const (
+	UserCreateTicketQueued     = "queued"
+	UserCreateTicketProcessing = "processing"
+	UserCreateTicketCompleted  = "completed"
+	UserCreateTicketFailed     = "failed"
+)
+
+// UserCreateTicket is a registration accepted while user creation is queued. Queue workers
+// create the user in the order tickets were accepted.
+type UserCreateTicket struct {
+	// ID is the order of acceptance
+	ID int64 `json:"id" db:"id"`
+	// TicketID is the random ID clients poll the status with
+	TicketID string `json:"ticket_id" db:"ticket_id"`
+	Status   string `json:"status" db:"status"`
+	// Payload is the registration request as JSON. It is only loaded when a worker claims the
+	// ticket and is cleared once the ticket is completed or failed.
+	Payload   *string `json:"-" db:"payload"`
+	SessionID *string `json:"session_id" db:"session_id"`
+	UserID    *int    `json:"user_id" db:"user_id"`
+	// ErrorCode, ErrorMessage and ErrorDetails are the API error of a failed ticket
+	ErrorCode     *string           `json:"error_code" db:"error_code"`
+	ErrorMessage  *string           `json:"error_message" db:"error_message"`
+	ErrorDetails  map[string]string `json:"error_details" db:"error_details"`
+	Attempts      int               `json:"attempts" db:"attempts"`
+	LastError     *string           `json:"last_error" db:"last_error"`
+	NextAttemptAt time.Time         `json:"next_attempt_at" db:"next_attempt_at"`
+	CompletedAt   *time.Time        `json:"completed_at" db:"completed_at"`
+	CreatedAt     time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// ValidationAttemptCount is the number of validations of registration data at a form stage
+// on a day, and how many of them failed
+type ValidationAttemptCount struct {
+	Day      time.Time `json:"day" db:"day"`
+	Stage    string    `json:"stage" db:"stage"`
+	Attempts int64     `json:"attempts" db:"attempts"`
+	Failed   int64     `json:"failed" db:"failed"`
+}
+
+// ValidationFailureCount is the number of validations at a form stage on a day that
+// rejected a field for a failure code. It holds no values entered in the form.
+type ValidationFailureCount struct {
+	Day      time.Time `json:"day" db:"day"`
+	Stage    string    `json:"stage" db:"stage"`
+	Field    string    `json:"field" db:"field"`
+	Code     string    `json:"code" db:"code"`
+	Failures int64     `json:"failures" db:"failures"`
+}
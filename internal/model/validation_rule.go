@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+)
+
+// ValidationRule represents a configurable validation rule for a form field
+type ValidationRule struct {
+	FieldName      string    `json:"field_name" db:"field_name"`
+	Required       bool      `json:"required" db:"required"`
+	MaxLength      *int      `json:"max_length" db:"max_length"`
+	Pattern        *string   `json:"pattern" db:"pattern"`
+	ForbiddenChars *string   `json:"forbidden_chars" db:"forbidden_chars"`
+	Message        *string   `json:"message" db:"message"`
+	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+}
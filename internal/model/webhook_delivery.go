@@ -0,0 +1,33 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	// WebhookDeliveryDead is a delivery whose retry schedule ran out; it stays until replayed
+	WebhookDeliveryDead = "dead"
+)
+
+// WebhookDelivery is an outbox event queued for the webhook endpoint. The webhook
+// dispatcher sends it, retrying on the configured schedule.
+type WebhookDelivery struct {
+	ID            int64           `json:"id" db:"id"`
+	OutboxEventID int64           `json:"outbox_event_id" db:"outbox_event_id"`
+	EventType     string          `json:"event_type" db:"event_type"`
+	CorrelationID *string         `json:"correlation_id" db:"correlation_id"`
+	Payload       json.RawMessage `json:"payload" db:"payload"`
+	OccurredAt    time.Time       `json:"occurred_at" db:"occurred_at"`
+	Status        string          `json:"status" db:"status"`
+	Attempts      int             `json:"attempts" db:"attempts"`
+	LastError     *string         `json:"last_error" db:"last_error"`
+	NextAttemptAt time.Time       `json:"next_attempt_at" db:"next_attempt_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at" db:"delivered_at"`
+	DeadAt        *time.Time      `json:"dead_at" db:"dead_at"`
+	CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at" db:"updated_at"`
+}
@@ -0,0 +1,28 @@
+package model
+
+import (
+	"time"
+)
+
+// Wizard step statuses
+const (
+	WizardStepPending = "pending"
+	WizardStepValid   = "valid"
+	WizardStepInvalid = "invalid"
+	// WizardStepStale marks a valid step whose fields were edited after it was validated
+	WizardStepStale = "stale"
+)
+
+// WizardState is the progress of a form session through the wizard steps
+type WizardState struct {
+	Steps map[string]WizardStep `json:"steps"`
+}
+
+// WizardStep is the result of the last validation of a wizard step
+type WizardStep struct {
+	Status string            `json:"status"`
+	Errors map[string]string `json:"errors,omitempty"`
+	// Fingerprint identifies the field values that were validated
+	Fingerprint string    `json:"fingerprint,omitempty"`
+	ValidatedAt time.Time `json:"validated_at"`
+}
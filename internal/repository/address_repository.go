@@ -0,0 +1,156 @@
+// Package repository provides postal code master data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// postalAddressInsertBatchSize keeps each INSERT well below the PostgreSQL parameter limit
+const postalAddressInsertBatchSize = 1000
+
+// AddressRepository defines the interface for postal code master data access
+type AddressRepository interface {
+	FindByPostalCode(ctx context.Context, postalCode string) ([]*model.PostalAddress, error)
+	FindByAddress(ctx context.Context, prefecture, city, town string, limit int) ([]*model.PostalAddress, error)
+	ReplaceAll(ctx context.Context, addresses []*model.PostalAddress) (int, error)
+}
+
+// addressRepository implements AddressRepository
+type addressRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewAddressRepository creates a new address repository
+func NewAddressRepository(db *sql.DB, log *logger.Logger) AddressRepository {
+	return &addressRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// FindByPostalCode retrieves every entry for a 7-digit postal code
+func (r *addressRepository) FindByPostalCode(ctx context.Context, postalCode string) ([]*model.PostalAddress, error) {
+	query := `
+		SELECT id, local_government_code, postal_code, prefecture_kana, city_kana, town_kana,
+		       prefecture, city, town, created_at
+		FROM ken_all
+		WHERE postal_code = $1
+		ORDER BY id ASC`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, postalCode)
+	if err != nil {
+		r.log.WithError(err).WithField("postal_code", postalCode).Error("Failed to find postal addresses")
+		return nil, fmt.Errorf("failed to find postal addresses: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPostalAddresses(rows)
+}
+
+// FindByAddress retrieves up to limit entries in a city. An empty town matches every town;
+// otherwise towns that start with the given town, or that the given town starts with
+// (e.g. "丸の内" for "丸の内1丁目"), match.
+func (r *addressRepository) FindByAddress(
+	ctx context.Context, prefecture, city, town string, limit int,
+) ([]*model.PostalAddress, error) {
+	query := `
+		SELECT id, local_government_code, postal_code, prefecture_kana, city_kana, town_kana,
+		       prefecture, city, town, created_at
+		FROM ken_all
+		WHERE prefecture = $1 AND city = $2
+		  AND ($3 = '' OR (town <> '' AND (town LIKE $4 || '%' OR $3 LIKE town || '%')))
+		ORDER BY postal_code ASC, id ASC
+		LIMIT $5`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, prefecture, city, town, escapeLike(town), limit)
+	if err != nil {
+		r.log.WithError(err).
+			WithField("prefecture", prefecture).
+			WithField("city", city).
+			Error("Failed to find postal addresses by address")
+		return nil, fmt.Errorf("failed to find postal addresses by address: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPostalAddresses(rows)
+}
+
+// scanPostalAddresses reads every row of a ken_all query
+func scanPostalAddresses(rows *sql.Rows) ([]*model.PostalAddress, error) {
+	var addresses []*model.PostalAddress
+	for rows.Next() {
+		address := &model.PostalAddress{}
+		err := rows.Scan(
+			&address.ID, &address.LocalGovernmentCode, &address.PostalCode,
+			&address.PrefectureKana, &address.CityKana, &address.TownKana,
+			&address.Prefecture, &address.City, &address.Town, &address.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan postal address: %w", err)
+		}
+		addresses = append(addresses, address)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate postal addresses: %w", err)
+	}
+
+	return addresses, nil
+}
+
+// escapeLike escapes LIKE wildcards so user input is matched literally
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
+
+// ReplaceAll deletes every entry and inserts the given ones, returning the number inserted.
+// Call it inside a transaction so that readers never see a partially loaded table.
+func (r *addressRepository) ReplaceAll(ctx context.Context, addresses []*model.PostalAddress) (int, error) {
+	exec := executor(ctx, r.db)
+
+	if _, err := exec.ExecContext(ctx, `DELETE FROM ken_all`); err != nil {
+		r.log.WithError(err).Error("Failed to clear postal addresses")
+		return 0, fmt.Errorf("failed to clear postal addresses: %w", err)
+	}
+
+	inserted := 0
+	for start := 0; start < len(addresses); start += postalAddressInsertBatchSize {
+		end := min(start+postalAddressInsertBatchSize, len(addresses))
+		batch := addresses[start:end]
+
+		const columns = 8
+		placeholders := make([]string, 0, len(batch))
+		args := make([]any, 0, len(batch)*columns)
+		for i, address := range batch {
+			base := i * columns
+			placeholders = append(placeholders, fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+				base+1, base+2, base+3, base+4, base+5, base+6, base+7, base+8))
+			args = append(args,
+				address.LocalGovernmentCode, address.PostalCode,
+				address.PrefectureKana, address.CityKana, address.TownKana,
+				address.Prefecture, address.City, address.Town,
+			)
+		}
+
+		query := `
+			INSERT INTO ken_all (
+				local_government_code, postal_code, prefecture_kana, city_kana, town_kana,
+				prefecture, city, town
+			) VALUES ` + strings.Join(placeholders, ", ")
+
+		if _, err := exec.ExecContext(ctx, query, args...); err != nil {
+			r.log.WithError(err).WithField("offset", start).Error("Failed to insert postal addresses")
+			return inserted, fmt.Errorf("failed to insert postal addresses: %w", err)
+		}
+		inserted += len(batch)
+	}
+
+	return inserted, nil
+}
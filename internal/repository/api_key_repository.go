@@ -0,0 +1,156 @@
+// Package repository provides partner API key data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// apiKeyColumns lists the columns scanned by scanAPIKey
+const apiKeyColumns = `id, partner, key_prefix, key_hash, scopes, note, created_at, revoked_at`
+
+// scopeSeparator joins the scopes of a key in the scopes column
+const scopeSeparator = ","
+
+// APIKeyRepository defines the interface for partner API key data access
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *model.APIKey) (*model.APIKey, error)
+	GetByID(ctx context.Context, id int64) (*model.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*model.APIKey, error)
+	List(ctx context.Context) ([]*model.APIKey, error)
+	Revoke(ctx context.Context, id int64) (*model.APIKey, error)
+}
+
+// apiKeyRepository implements APIKeyRepository
+type apiKeyRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *sql.DB, log *logger.Logger) APIKeyRepository {
+	return &apiKeyRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// Create stores a new key
+func (r *apiKeyRepository) Create(ctx context.Context, key *model.APIKey) (*model.APIKey, error) {
+	query := `
+		INSERT INTO api_keys (partner, key_prefix, key_hash, scopes, note)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + apiKeyColumns
+
+	created, err := scanAPIKey(executor(ctx, r.db).QueryRowContext(ctx, query,
+		key.Partner, key.KeyPrefix, key.KeyHash, strings.Join(key.Scopes, scopeSeparator), key.Note,
+	))
+	if err != nil {
+		r.log.WithError(err).WithField("partner", key.Partner).Error("Failed to create API key")
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetByID retrieves a key
+func (r *apiKeyRepository) GetByID(ctx context.Context, id int64) (*model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = $1`
+
+	key, err := scanAPIKey(executor(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, apperr.Errorf(apperr.ErrNotFound, "API key %d not found", id)
+	}
+	if err != nil {
+		r.log.WithError(err).WithField("api_key_id", id).Error("Failed to get API key")
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return key, nil
+}
+
+// GetByHash retrieves the key with a hash, including revoked keys
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE key_hash = $1`
+
+	key, err := scanAPIKey(executor(ctx, r.db).QueryRowContext(ctx, query, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, apperr.Errorf(apperr.ErrNotFound, "API key not found")
+	}
+	if err != nil {
+		r.log.WithError(err).Error("Failed to get API key by hash")
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	return key, nil
+}
+
+// List lists every key, newest first
+func (r *apiKeyRepository) List(ctx context.Context) ([]*model.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + ` FROM api_keys ORDER BY id DESC`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to list API keys")
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []*model.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			r.log.WithError(err).Error("Failed to scan API key row")
+			return nil, fmt.Errorf("failed to scan API key row: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log.WithError(err).Error("Error iterating API key rows")
+		return nil, fmt.Errorf("error iterating API key rows: %w", err)
+	}
+
+	return keys, nil
+}
+
+// Revoke revokes a key. Revoking a revoked key keeps its original revocation time.
+func (r *apiKeyRepository) Revoke(ctx context.Context, id int64) (*model.APIKey, error) {
+	query := `
+		UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1
+		RETURNING ` + apiKeyColumns
+
+	key, err := scanAPIKey(executor(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, apperr.Errorf(apperr.ErrNotFound, "API key %d not found", id)
+	}
+	if err != nil {
+		r.log.WithError(err).WithField("api_key_id", id).Error("Failed to revoke API key")
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	return key, nil
+}
+
+// scanAPIKey scans a row selected with apiKeyColumns
+func scanAPIKey(row rowScanner) (*model.APIKey, error) {
+	var key model.APIKey
+	var scopes string
+	err := row.Scan(
+		&key.ID, &key.Partner, &key.KeyPrefix, &key.KeyHash, &scopes, &key.Note, &key.CreatedAt, &key.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if scopes != "" {
+		key.Scopes = strings.Split(scopes, scopeSeparator)
+	}
+	return &key, nil
+}
@@ -0,0 +1,103 @@
+// Package repository provides partner API usage metering data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// APIKeyUsageRepository defines the interface for partner API usage data access
+type APIKeyUsageRepository interface {
+	Add(ctx context.Context, usage *model.APIKeyUsage) error
+	Summarize(ctx context.Context, apiKeyID int64, from, to time.Time, unit string) ([]*model.APIKeyUsage, error)
+}
+
+// apiKeyUsageRepository implements APIKeyUsageRepository
+type apiKeyUsageRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewAPIKeyUsageRepository creates a new API key usage repository
+func NewAPIKeyUsageRepository(db *sql.DB, log *logger.Logger) APIKeyUsageRepository {
+	return &apiKeyUsageRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// Add adds requests to the counts of a key, hour and endpoint
+func (r *apiKeyUsageRepository) Add(ctx context.Context, usage *model.APIKeyUsage) error {
+	query := `
+		INSERT INTO api_key_usage (
+			api_key_id, period_start, endpoint, request_count,
+			request_bytes, response_bytes, client_error_count, server_error_count
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (api_key_id, period_start, endpoint) DO UPDATE
+		SET request_count = api_key_usage.request_count + EXCLUDED.request_count,
+			request_bytes = api_key_usage.request_bytes + EXCLUDED.request_bytes,
+			response_bytes = api_key_usage.response_bytes + EXCLUDED.response_bytes,
+			client_error_count = api_key_usage.client_error_count + EXCLUDED.client_error_count,
+			server_error_count = api_key_usage.server_error_count + EXCLUDED.server_error_count`
+
+	_, err := executor(ctx, r.db).ExecContext(ctx, query,
+		usage.APIKeyID, usage.PeriodStart, usage.Endpoint, usage.RequestCount,
+		usage.RequestBytes, usage.ResponseBytes, usage.ClientErrorCount, usage.ServerErrorCount,
+	)
+	if err != nil {
+		r.log.WithError(err).WithField("api_key_id", usage.APIKeyID).Error("Failed to record API key usage")
+		return fmt.Errorf("failed to record API key usage: %w", err)
+	}
+
+	return nil
+}
+
+// Summarize returns the usage of a key in [from, to) per endpoint and unit, "hour" or "day",
+// ordered by period and endpoint
+func (r *apiKeyUsageRepository) Summarize(
+	ctx context.Context, apiKeyID int64, from, to time.Time, unit string,
+) ([]*model.APIKeyUsage, error) {
+	query := `
+		SELECT api_key_id, date_trunc($4, period_start) AS period, endpoint,
+			SUM(request_count), SUM(request_bytes), SUM(response_bytes),
+			SUM(client_error_count), SUM(server_error_count)
+		FROM api_key_usage
+		WHERE api_key_id = $1 AND period_start >= $2 AND period_start < $3
+		GROUP BY api_key_id, period, endpoint
+		ORDER BY period, endpoint`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, apiKeyID, from, to, unit)
+	if err != nil {
+		r.log.WithError(err).WithField("api_key_id", apiKeyID).Error("Failed to summarize API key usage")
+		return nil, fmt.Errorf("failed to summarize API key usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []*model.APIKeyUsage
+	for rows.Next() {
+		var u model.APIKeyUsage
+		err := rows.Scan(
+			&u.APIKeyID, &u.PeriodStart, &u.Endpoint,
+			&u.RequestCount, &u.RequestBytes, &u.ResponseBytes,
+			&u.ClientErrorCount, &u.ServerErrorCount,
+		)
+		if err != nil {
+			r.log.WithError(err).Error("Failed to scan API key usage row")
+			return nil, fmt.Errorf("failed to scan API key usage row: %w", err)
+		}
+		usage = append(usage, &u)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log.WithError(err).Error("Error iterating API key usage rows")
+		return nil, fmt.Errorf("error iterating API key usage rows: %w", err)
+	}
+
+	return usage, nil
+}
@@ -0,0 +1,212 @@
+// Package repository provides uploaded attachment data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// AttachmentRepository defines the interface for attachment data access
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment *model.Attachment) error
+	GetByID(ctx context.Context, id string) (*model.Attachment, error)
+	GetByIDForUpdate(ctx context.Context, id string) (*model.Attachment, error)
+	ClaimPending(ctx context.Context, limit, maxAttempts int) ([]*model.Attachment, error)
+	MarkClean(ctx context.Context, id string) error
+	MarkInfected(ctx context.Context, id, signature, quarantineKey string) error
+	MarkScanFailed(ctx context.Context, id, scanError string, nextScanAt time.Time) error
+	LinkToSession(ctx context.Context, id, sessionID string) error
+	ListByStatus(ctx context.Context, status string, limit, offset int) ([]*model.Attachment, error)
+}
+
+// attachmentRepository implements AttachmentRepository
+type attachmentRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewAttachmentRepository creates a new attachment repository
+func NewAttachmentRepository(db *sql.DB, log *logger.Logger) AttachmentRepository {
+	return &attachmentRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// attachmentColumns lists the columns scanned by scanAttachment
+const attachmentColumns = `id, session_id, storage_key, filename, content_type, size, sha256,
+		scan_status, scan_signature, scan_attempts, scan_error, next_scan_at, scanned_at,
+		quarantined_at, created_at`
+
+// Create stores a new attachment awaiting its scan
+func (r *attachmentRepository) Create(ctx context.Context, attachment *model.Attachment) error {
+	query := `
+		INSERT INTO attachments (id, storage_key, filename, content_type, size, sha256)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING scan_status, next_scan_at, created_at`
+
+	err := executor(ctx, r.db).QueryRowContext(ctx, query,
+		attachment.ID, attachment.StorageKey, attachment.Filename, attachment.ContentType,
+		attachment.Size, attachment.SHA256,
+	).Scan(&attachment.ScanStatus, &attachment.NextScanAt, &attachment.CreatedAt)
+
+	if err != nil {
+		r.log.WithError(err).Error("Failed to create attachment")
+		return fmt.Errorf("failed to create attachment: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an attachment by ID
+func (r *attachmentRepository) GetByID(ctx context.Context, id string) (*model.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM attachments WHERE id = $1`
+	return r.getOne(ctx, query, id)
+}
+
+// GetByIDForUpdate retrieves an attachment and locks it until the surrounding transaction ends
+func (r *attachmentRepository) GetByIDForUpdate(ctx context.Context, id string) (*model.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + ` FROM attachments WHERE id = $1 FOR UPDATE`
+	return r.getOne(ctx, query, id)
+}
+
+// getOne runs a query returning a single attachment
+func (r *attachmentRepository) getOne(ctx context.Context, query string, id string) (*model.Attachment, error) {
+	attachment, err := scanAttachment(executor(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Errorf(apperr.ErrNotFound, "attachment not found: %w", err)
+		}
+		r.log.WithError(err).Error("Failed to get attachment")
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	return attachment, nil
+}
+
+// ClaimPending locks up to limit attachments due for scanning. Rows stay locked until the
+// surrounding transaction ends, so concurrent scanners never scan the same file twice.
+// Attachments that failed maxAttempts scans are left for an administrator.
+func (r *attachmentRepository) ClaimPending(ctx context.Context, limit, maxAttempts int) ([]*model.Attachment, error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM attachments
+		WHERE scan_status = 'pending' AND next_scan_at <= NOW() AND scan_attempts < $2
+		ORDER BY created_at ASC
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`
+
+	return r.query(ctx, query, limit, maxAttempts)
+}
+
+// MarkClean records a clean scan
+func (r *attachmentRepository) MarkClean(ctx context.Context, id string) error {
+	query := `
+		UPDATE attachments SET scan_status = 'clean', scan_error = NULL, scanned_at = NOW()
+		WHERE id = $1`
+
+	return r.exec(ctx, "mark attachment clean", query, id)
+}
+
+// MarkInfected records an infected scan and the quarantine key the content was moved to
+func (r *attachmentRepository) MarkInfected(ctx context.Context, id, signature, quarantineKey string) error {
+	query := `
+		UPDATE attachments
+		SET scan_status = 'infected', scan_signature = $2, storage_key = $3, scan_error = NULL,
+			scanned_at = NOW(), quarantined_at = NOW()
+		WHERE id = $1`
+
+	return r.exec(ctx, "mark attachment infected", query, id, signature, quarantineKey)
+}
+
+// MarkScanFailed records a failed scan attempt and schedules the next one
+func (r *attachmentRepository) MarkScanFailed(ctx context.Context, id, scanError string, nextScanAt time.Time) error {
+	query := `
+		UPDATE attachments
+		SET scan_attempts = scan_attempts + 1, scan_error = $2, next_scan_at = $3
+		WHERE id = $1`
+
+	return r.exec(ctx, "mark attachment scan failed", query, id, scanError, nextScanAt)
+}
+
+// LinkToSession links an attachment to a form session
+func (r *attachmentRepository) LinkToSession(ctx context.Context, id, sessionID string) error {
+	query := `UPDATE attachments SET session_id = $2 WHERE id = $1`
+
+	return r.exec(ctx, "link attachment to session", query, id, sessionID)
+}
+
+// ListByStatus retrieves attachments with a scan status, newest first
+func (r *attachmentRepository) ListByStatus(
+	ctx context.Context, status string, limit, offset int,
+) ([]*model.Attachment, error) {
+	query := `
+		SELECT ` + attachmentColumns + `
+		FROM attachments
+		WHERE scan_status = $1
+		ORDER BY created_at DESC
+		LIMIT $2 OFFSET $3`
+
+	return r.query(ctx, query, status, limit, offset)
+}
+
+// exec runs an update and logs failures under the operation name
+func (r *attachmentRepository) exec(ctx context.Context, operation, query string, args ...any) error {
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+		r.log.WithError(err).Errorf("Failed to %s", operation)
+		return fmt.Errorf("failed to %s: %w", operation, err)
+	}
+	return nil
+}
+
+// query runs a query returning attachments
+func (r *attachmentRepository) query(ctx context.Context, query string, args ...any) ([]*model.Attachment, error) {
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to query attachments")
+		return nil, fmt.Errorf("failed to query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []*model.Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			r.log.WithError(err).Error("Failed to scan attachment row")
+			return nil, fmt.Errorf("failed to scan attachment row: %w", err)
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate attachment rows: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanAttachment reads the attachmentColumns of a row
+func scanAttachment(row rowScanner) (*model.Attachment, error) {
+	var attachment model.Attachment
+	err := row.Scan(
+		&attachment.ID, &attachment.SessionID, &attachment.StorageKey, &attachment.Filename,
+		&attachment.ContentType, &attachment.Size, &attachment.SHA256,
+		&attachment.ScanStatus, &attachment.ScanSignature, &attachment.ScanAttempts, &attachment.ScanError,
+		&attachment.NextScanAt, &attachment.ScannedAt, &attachment.QuarantinedAt, &attachment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
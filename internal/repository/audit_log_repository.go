@@ -0,0 +1,59 @@
+// Package repository provides audit log data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// AuditLogRepository defines the interface for audit log data access
+type AuditLogRepository interface {
+	Create(ctx context.Context, entry *model.AuditLog) (*model.AuditLog, error)
+}
+
+// auditLogRepository implements AuditLogRepository
+type auditLogRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewAuditLogRepository creates a new audit log repository
+func NewAuditLogRepository(db *sql.DB, log *logger.Logger) AuditLogRepository {
+	return &auditLogRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// Create records an audit entry. Call it with a transactional context so the entry
+// is committed atomically with the change it describes.
+func (r *auditLogRepository) Create(ctx context.Context, entry *model.AuditLog) (*model.AuditLog, error) {
+	query := `
+		INSERT INTO audit_logs (actor, actor_ip, action, resource_type, resource_id, details, correlation_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at`
+
+	var details []byte
+	if len(entry.Details) > 0 {
+		details = entry.Details
+	}
+
+	created := *entry
+	err := executor(ctx, r.db).QueryRowContext(ctx, query,
+		entry.Actor, entry.ActorIP, entry.Action, entry.ResourceType, entry.ResourceID, details, entry.CorrelationID,
+	).Scan(&created.ID, &created.CreatedAt)
+
+	if err != nil {
+		r.log.WithError(err).
+			WithField("action", entry.Action).
+			WithField("resource_id", entry.ResourceID).
+			Error("Failed to create audit log")
+		return nil, fmt.Errorf("failed to create audit log: %w", err)
+	}
+
+	return &created, nil
+}
@@ -0,0 +1,73 @@
+// Package repository provides building name master data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// BuildingRepository defines the interface for building name master data access
+type BuildingRepository interface {
+	FindByAddress(ctx context.Context, postalCode, banchi string, limit int) ([]*model.Building, error)
+}
+
+// buildingRepository implements BuildingRepository
+type buildingRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewBuildingRepository creates a new building repository
+func NewBuildingRepository(db *sql.DB, log *logger.Logger) BuildingRepository {
+	return &buildingRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// FindByAddress retrieves up to limit buildings at block numbers in a postal code area.
+// Buildings at the given block numbers match, as do those at further numbers under them
+// (8-1 and 8-2 for 8), so that the number entered so far is enough.
+func (r *buildingRepository) FindByAddress(
+	ctx context.Context, postalCode, banchi string, limit int,
+) ([]*model.Building, error) {
+	query := `
+		SELECT id, postal_code, banchi, name, created_at, updated_at
+		FROM buildings
+		WHERE postal_code = $1 AND (banchi = $2 OR banchi LIKE $3 || '-%')
+		ORDER BY banchi ASC, name ASC
+		LIMIT $4`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, postalCode, banchi, escapeLike(banchi), limit)
+	if err != nil {
+		r.log.WithError(err).
+			WithField("postal_code", postalCode).
+			WithField("banchi", banchi).
+			Error("Failed to find buildings")
+		return nil, fmt.Errorf("failed to find buildings: %w", err)
+	}
+	defer rows.Close()
+
+	var buildings []*model.Building
+	for rows.Next() {
+		building := &model.Building{}
+		err := rows.Scan(
+			&building.ID, &building.PostalCode, &building.Banchi, &building.Name,
+			&building.CreatedAt, &building.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan building: %w", err)
+		}
+		buildings = append(buildings, building)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate buildings: %w", err)
+	}
+
+	return buildings, nil
+}
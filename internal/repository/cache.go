@@ -0,0 +1,102 @@
+// Package repository provides in-process caching for rarely changing master data.
+package repository
+
+import (
+	"sync"
+	"time"
+)
+
+// CacheInvalidator is implemented by caching repositories so cached data can be dropped
+// explicitly, e.g. after master data is changed directly in the database
+type CacheInvalidator interface {
+	Invalidate()
+}
+
+// ttlCache is a concurrency-safe map whose entries expire after a fixed TTL
+type ttlCache[V any] struct {
+	mu      sync.RWMutex
+	ttl     time.Duration
+	entries map[string]ttlCacheEntry[V]
+}
+
+// ttlCacheEntry is a cached value with its expiry time
+type ttlCacheEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// newTTLCache creates an empty cache
+func newTTLCache[V any](ttl time.Duration) *ttlCache[V] {
+	return &ttlCache[V]{
+		ttl:     ttl,
+		entries: make(map[string]ttlCacheEntry[V]),
+	}
+}
+
+// Get returns the value for key if it is present and not expired
+func (c *ttlCache[V]) Get(key string) (V, bool) {
+	c.mu.RLock()
+	entry, ok := c.entries[key]
+	c.mu.RUnlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// Set stores value for key
+func (c *ttlCache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	c.entries[key] = ttlCacheEntry[V]{value: value, expiresAt: time.Now().Add(c.ttl)}
+	c.mu.Unlock()
+}
+
+// Clear drops every entry
+func (c *ttlCache[V]) Clear() {
+	c.mu.Lock()
+	c.entries = make(map[string]ttlCacheEntry[V])
+	c.mu.Unlock()
+}
+
+// cached returns the cached value for key or loads and stores it. Errors are not cached.
+func cached[V any](c *ttlCache[V], key string, load func() (V, error)) (V, error) {
+	if value, ok := c.Get(key); ok {
+		return value, nil
+	}
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+
+	c.Set(key, value)
+	return value, nil
+}
+
+// masterDataCache invalidates several caching repositories at once
+type masterDataCache struct {
+	invalidators []CacheInvalidator
+}
+
+// NewMasterDataCache returns an invalidation hook for the given repositories.
+// Repositories that are not cached are ignored.
+func NewMasterDataCache(
+	optionRepo OptionRepository, prefectureRepo PrefectureRepository, planRepo PlanRepository,
+) CacheInvalidator {
+	cache := &masterDataCache{}
+	for _, repo := range []any{optionRepo, prefectureRepo, planRepo} {
+		if invalidator, ok := repo.(CacheInvalidator); ok {
+			cache.invalidators = append(cache.invalidators, invalidator)
+		}
+	}
+	return cache
+}
+
+// Invalidate drops the cached data of every repository
+func (c *masterDataCache) Invalidate() {
+	for _, invalidator := range c.invalidators {
+		invalidator.Invalidate()
+	}
+}
@@ -0,0 +1,92 @@
+// Package repository provides a caching decorator for option master data.
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// Cache keys for option lists
+const (
+	optionCacheKeyAll    = "all"
+	optionCacheKeyActive = "active"
+)
+
+// cachedOptionRepository decorates an OptionRepository with a TTL cache.
+// Cached values are shared between callers and must not be modified.
+type cachedOptionRepository struct {
+	next    OptionRepository
+	lists   *ttlCache[[]*model.OptionMaster]
+	options *ttlCache[*model.OptionMaster]
+	log     *logger.Logger
+}
+
+// NewCachedOptionRepository wraps next with an in-memory cache whose entries expire after ttl
+func NewCachedOptionRepository(next OptionRepository, ttl time.Duration, log *logger.Logger) OptionRepository {
+	return &cachedOptionRepository{
+		next:    next,
+		lists:   newTTLCache[[]*model.OptionMaster](ttl),
+		options: newTTLCache[*model.OptionMaster](ttl),
+		log:     log,
+	}
+}
+
+// GetAll retrieves all option master data
+func (r *cachedOptionRepository) GetAll(ctx context.Context) ([]*model.OptionMaster, error) {
+	return cached(r.lists, optionCacheKeyAll, func() ([]*model.OptionMaster, error) {
+		return r.next.GetAll(ctx)
+	})
+}
+
+// GetByPlanType retrieves options by plan type
+func (r *cachedOptionRepository) GetByPlanType(ctx context.Context, planType string) ([]*model.OptionMaster, error) {
+	return cached(r.lists, "plan:"+planType, func() ([]*model.OptionMaster, error) {
+		return r.next.GetByPlanType(ctx, planType)
+	})
+}
+
+// GetByOptionType retrieves an option by option type
+func (r *cachedOptionRepository) GetByOptionType(ctx context.Context, optionType string) (*model.OptionMaster, error) {
+	return cached(r.options, optionType, func() (*model.OptionMaster, error) {
+		return r.next.GetByOptionType(ctx, optionType)
+	})
+}
+
+// GetActiveOptions retrieves all active options
+func (r *cachedOptionRepository) GetActiveOptions(ctx context.Context) ([]*model.OptionMaster, error) {
+	return cached(r.lists, optionCacheKeyActive, func() ([]*model.OptionMaster, error) {
+		return r.next.GetActiveOptions(ctx)
+	})
+}
+
+// GetCompatibleOptions retrieves options compatible with a plan type
+func (r *cachedOptionRepository) GetCompatibleOptions(ctx context.Context, planType string) ([]*model.OptionMaster, error) {
+	return cached(r.lists, "compatible:"+planType, func() ([]*model.OptionMaster, error) {
+		return r.next.GetCompatibleOptions(ctx, planType)
+	})
+}
+
+// Create creates an option. The cache is not touched; invalidate it once the change is committed.
+func (r *cachedOptionRepository) Create(ctx context.Context, option *model.OptionMaster) (*model.OptionMaster, error) {
+	return r.next.Create(ctx, option)
+}
+
+// Update updates an option. The cache is not touched; invalidate it once the change is committed.
+func (r *cachedOptionRepository) Update(ctx context.Context, option *model.OptionMaster) (*model.OptionMaster, error) {
+	return r.next.Update(ctx, option)
+}
+
+// Delete deletes an option. The cache is not touched; invalidate it once the change is committed.
+func (r *cachedOptionRepository) Delete(ctx context.Context, optionType string) error {
+	return r.next.Delete(ctx, optionType)
+}
+
+// Invalidate drops all cached options
+func (r *cachedOptionRepository) Invalidate() {
+	r.lists.Clear()
+	r.options.Clear()
+	r.log.Info("Option master data cache invalidated")
+}
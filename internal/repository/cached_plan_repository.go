@@ -0,0 +1,78 @@
+// Package repository provides a caching decorator for plan master data.
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// Cache keys for plan lists
+const (
+	planCacheKeyAll    = "all"
+	planCacheKeyActive = "active"
+)
+
+// cachedPlanRepository decorates a PlanRepository with a TTL cache.
+// Cached values are shared between callers and must not be modified.
+type cachedPlanRepository struct {
+	next  PlanRepository
+	lists *ttlCache[[]*model.PlanMaster]
+	plans *ttlCache[*model.PlanMaster]
+	log   *logger.Logger
+}
+
+// NewCachedPlanRepository wraps next with an in-memory cache whose entries expire after ttl
+func NewCachedPlanRepository(next PlanRepository, ttl time.Duration, log *logger.Logger) PlanRepository {
+	return &cachedPlanRepository{
+		next:  next,
+		lists: newTTLCache[[]*model.PlanMaster](ttl),
+		plans: newTTLCache[*model.PlanMaster](ttl),
+		log:   log,
+	}
+}
+
+// GetAll retrieves all plan master data
+func (r *cachedPlanRepository) GetAll(ctx context.Context) ([]*model.PlanMaster, error) {
+	return cached(r.lists, planCacheKeyAll, func() ([]*model.PlanMaster, error) {
+		return r.next.GetAll(ctx)
+	})
+}
+
+// GetActivePlans retrieves the active plans
+func (r *cachedPlanRepository) GetActivePlans(ctx context.Context) ([]*model.PlanMaster, error) {
+	return cached(r.lists, planCacheKeyActive, func() ([]*model.PlanMaster, error) {
+		return r.next.GetActivePlans(ctx)
+	})
+}
+
+// GetByPlanType retrieves a plan by plan type
+func (r *cachedPlanRepository) GetByPlanType(ctx context.Context, planType string) (*model.PlanMaster, error) {
+	return cached(r.plans, planType, func() (*model.PlanMaster, error) {
+		return r.next.GetByPlanType(ctx, planType)
+	})
+}
+
+// Create creates a plan. The cache is not touched; invalidate it once the change is committed.
+func (r *cachedPlanRepository) Create(ctx context.Context, plan *model.PlanMaster) (*model.PlanMaster, error) {
+	return r.next.Create(ctx, plan)
+}
+
+// Update updates a plan. The cache is not touched; invalidate it once the change is committed.
+func (r *cachedPlanRepository) Update(ctx context.Context, plan *model.PlanMaster) (*model.PlanMaster, error) {
+	return r.next.Update(ctx, plan)
+}
+
+// Deactivate deactivates a plan. The cache is not touched; invalidate it once the change is committed.
+func (r *cachedPlanRepository) Deactivate(ctx context.Context, planType string) (*model.PlanMaster, error) {
+	return r.next.Deactivate(ctx, planType)
+}
+
+// Invalidate drops all cached plans
+func (r *cachedPlanRepository) Invalidate() {
+	r.lists.Clear()
+	r.plans.Clear()
+	r.log.Info("Plan master data cache invalidated")
+}
@@ -0,0 +1,77 @@
+// Package repository provides a caching decorator for prefecture master data.
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// Cache keys for prefecture lists
+const (
+	prefectureCacheKeyAll    = "all"
+	prefectureCacheKeyActive = "active"
+)
+
+// cachedPrefectureRepository decorates a PrefectureRepository with a TTL cache.
+// Cached values are shared between callers and must not be modified.
+type cachedPrefectureRepository struct {
+	next        PrefectureRepository
+	lists       *ttlCache[[]*model.PrefectureMaster]
+	prefectures *ttlCache[*model.PrefectureMaster]
+	log         *logger.Logger
+}
+
+// NewCachedPrefectureRepository wraps next with an in-memory cache whose entries expire after ttl
+func NewCachedPrefectureRepository(next PrefectureRepository, ttl time.Duration, log *logger.Logger) PrefectureRepository {
+	return &cachedPrefectureRepository{
+		next:        next,
+		lists:       newTTLCache[[]*model.PrefectureMaster](ttl),
+		prefectures: newTTLCache[*model.PrefectureMaster](ttl),
+		log:         log,
+	}
+}
+
+// GetAll retrieves all prefecture master data
+func (r *cachedPrefectureRepository) GetAll(ctx context.Context) ([]*model.PrefectureMaster, error) {
+	return cached(r.lists, prefectureCacheKeyAll, func() ([]*model.PrefectureMaster, error) {
+		return r.next.GetAll(ctx)
+	})
+}
+
+// GetByCode retrieves a prefecture by code
+func (r *cachedPrefectureRepository) GetByCode(ctx context.Context, prefectureCode string) (*model.PrefectureMaster, error) {
+	return cached(r.prefectures, "code:"+prefectureCode, func() (*model.PrefectureMaster, error) {
+		return r.next.GetByCode(ctx, prefectureCode)
+	})
+}
+
+// GetByName retrieves a prefecture by name
+func (r *cachedPrefectureRepository) GetByName(ctx context.Context, prefectureName string) (*model.PrefectureMaster, error) {
+	return cached(r.prefectures, "name:"+prefectureName, func() (*model.PrefectureMaster, error) {
+		return r.next.GetByName(ctx, prefectureName)
+	})
+}
+
+// GetByRegion retrieves prefectures by region
+func (r *cachedPrefectureRepository) GetByRegion(ctx context.Context, region string) ([]*model.PrefectureMaster, error) {
+	return cached(r.lists, "region:"+region, func() ([]*model.PrefectureMaster, error) {
+		return r.next.GetByRegion(ctx, region)
+	})
+}
+
+// GetActive retrieves all active prefectures
+func (r *cachedPrefectureRepository) GetActive(ctx context.Context) ([]*model.PrefectureMaster, error) {
+	return cached(r.lists, prefectureCacheKeyActive, func() ([]*model.PrefectureMaster, error) {
+		return r.next.GetActive(ctx)
+	})
+}
+
+// Invalidate drops all cached prefectures
+func (r *cachedPrefectureRepository) Invalidate() {
+	r.lists.Clear()
+	r.prefectures.Clear()
+	r.log.Info("Prefecture master data cache invalidated")
+}
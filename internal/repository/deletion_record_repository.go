@@ -0,0 +1,73 @@
+// Package repository provides deletion tombstone data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// DeletionRecordRepository defines the interface for deletion tombstone data access
+type DeletionRecordRepository interface {
+	Create(ctx context.Context, record *model.DeletionRecord) (*model.DeletionRecord, error)
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// deletionRecordRepository implements DeletionRecordRepository
+type deletionRecordRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewDeletionRecordRepository creates a new deletion record repository
+func NewDeletionRecordRepository(db *sql.DB, log *logger.Logger) DeletionRecordRepository {
+	return &deletionRecordRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// Create records a tombstone. Call it with a transactional context so the tombstone
+// is committed atomically with the deletion.
+func (r *deletionRecordRepository) Create(
+	ctx context.Context, record *model.DeletionRecord,
+) (*model.DeletionRecord, error) {
+	query := `
+		INSERT INTO deletion_records (user_id, email_hash, reason, operator, retain_until)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, deleted_at`
+
+	created := *record
+	err := executor(ctx, r.db).QueryRowContext(ctx, query,
+		record.UserID, record.EmailHash, record.Reason, record.Operator, record.RetainUntil,
+	).Scan(&created.ID, &created.DeletedAt)
+
+	if err != nil {
+		r.log.WithError(err).WithField("user_id", record.UserID).Error("Failed to create deletion record")
+		return nil, fmt.Errorf("failed to create deletion record: %w", err)
+	}
+
+	return &created, nil
+}
+
+// DeleteExpired purges tombstones whose retention period has ended
+func (r *deletionRecordRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	query := `DELETE FROM deletion_records WHERE retain_until <= $1`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, now)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to purge expired deletion records")
+		return 0, fmt.Errorf("failed to purge expired deletion records: %w", err)
+	}
+
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return purged, nil
+}
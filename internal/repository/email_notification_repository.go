@@ -0,0 +1,172 @@
+// Package repository provides email notification queue data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// EmailNotificationRepository defines the interface for email notification queue data access
+type EmailNotificationRepository interface {
+	Enqueue(ctx context.Context, template string, userID int) error
+	ClaimDue(ctx context.Context, limit int) ([]*model.EmailNotification, error)
+	MarkSent(ctx context.Context, id int64) error
+	MarkSkipped(ctx context.Context, id int64, reason string) error
+	MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, terminal bool) error
+	SkipPendingByUserID(ctx context.Context, userID int, reason string) (int64, error)
+}
+
+// emailNotificationRepository implements EmailNotificationRepository
+type emailNotificationRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewEmailNotificationRepository creates a new email notification repository
+func NewEmailNotificationRepository(db *sql.DB, log *logger.Logger) EmailNotificationRepository {
+	return &emailNotificationRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// emailNotificationColumns lists the columns scanned by scanEmailNotification
+const emailNotificationColumns = `id, template, user_id, status, attempts, last_error, next_attempt_at,
+		sent_at, created_at, updated_at`
+
+// Enqueue queues a notification email for a user, due now
+func (r *emailNotificationRepository) Enqueue(ctx context.Context, template string, userID int) error {
+	query := `INSERT INTO email_notifications (template, user_id) VALUES ($1, $2)`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, template, userID); err != nil {
+		r.log.WithError(err).
+			WithField("template", template).
+			WithField("user_id", userID).
+			Error("Failed to enqueue email notification")
+		return fmt.Errorf("failed to enqueue email notification: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDue locks up to limit pending notifications whose next attempt is due. Rows stay
+// locked until the surrounding transaction ends, so concurrent workers never send one twice.
+func (r *emailNotificationRepository) ClaimDue(ctx context.Context, limit int) ([]*model.EmailNotification, error) {
+	query := `
+		SELECT ` + emailNotificationColumns + `
+		FROM email_notifications
+		WHERE status = 'pending' AND next_attempt_at <= NOW()
+		ORDER BY id ASC
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, limit)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to query email notifications")
+		return nil, fmt.Errorf("failed to query email notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []*model.EmailNotification
+	for rows.Next() {
+		notification, err := scanEmailNotification(rows)
+		if err != nil {
+			r.log.WithError(err).Error("Failed to scan email notification row")
+			return nil, fmt.Errorf("failed to scan email notification row: %w", err)
+		}
+		notifications = append(notifications, notification)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log.WithError(err).Error("Error iterating email notification rows")
+		return nil, fmt.Errorf("error iterating email notification rows: %w", err)
+	}
+
+	return notifications, nil
+}
+
+// MarkSent records a sent notification
+func (r *emailNotificationRepository) MarkSent(ctx context.Context, id int64) error {
+	query := `
+		UPDATE email_notifications SET
+			status = 'sent', attempts = attempts + 1, last_error = NULL, sent_at = NOW(), updated_at = NOW()
+		WHERE id = $1`
+
+	return r.exec(ctx, "mark email notification as sent", query, id)
+}
+
+// MarkSkipped records a notification that is not sent, with the reason
+func (r *emailNotificationRepository) MarkSkipped(ctx context.Context, id int64, reason string) error {
+	query := `
+		UPDATE email_notifications SET status = 'skipped', last_error = $2, updated_at = NOW()
+		WHERE id = $1`
+
+	return r.exec(ctx, "mark email notification as skipped", query, id, reason)
+}
+
+// MarkFailed records a failed attempt and schedules the next one. A terminal failure is
+// not retried.
+func (r *emailNotificationRepository) MarkFailed(
+	ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, terminal bool,
+) error {
+	status := model.EmailNotificationPending
+	if terminal {
+		status = model.EmailNotificationFailed
+	}
+
+	query := `
+		UPDATE email_notifications SET
+			status = $2, attempts = attempts + 1, last_error = $3, next_attempt_at = $4, updated_at = NOW()
+		WHERE id = $1`
+
+	return r.exec(ctx, "mark email notification as failed", query, id, status, lastError, nextAttemptAt)
+}
+
+// SkipPendingByUserID records every pending notification of a user as not sent, with the
+// reason, returning the number skipped
+func (r *emailNotificationRepository) SkipPendingByUserID(ctx context.Context, userID int, reason string) (int64, error) {
+	query := `
+		UPDATE email_notifications SET status = 'skipped', last_error = $2, updated_at = NOW()
+		WHERE user_id = $1 AND status = 'pending'`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, userID, reason)
+	if err != nil {
+		r.log.WithError(err).WithField("user_id", userID).Error("Failed to skip pending email notifications")
+		return 0, fmt.Errorf("failed to skip pending email notifications: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// exec runs an update and logs failures under the operation name
+func (r *emailNotificationRepository) exec(ctx context.Context, operation, query string, args ...any) error {
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+		r.log.WithError(err).Errorf("Failed to %s", operation)
+		return fmt.Errorf("failed to %s: %w", operation, err)
+	}
+	return nil
+}
+
+// scanEmailNotification scans a row selected with emailNotificationColumns
+func scanEmailNotification(row rowScanner) (*model.EmailNotification, error) {
+	var notification model.EmailNotification
+	err := row.Scan(
+		&notification.ID, &notification.Template, &notification.UserID, &notification.Status,
+		&notification.Attempts, &notification.LastError, &notification.NextAttemptAt, &notification.SentAt,
+		&notification.CreatedAt, &notification.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
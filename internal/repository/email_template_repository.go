@@ -0,0 +1,201 @@
+// Package repository provides email template version data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// Columns scanned by scanEmailTemplate
+const emailTemplateColumns = `id, name, version, subject, body, is_active, note, created_at`
+
+// EmailTemplateRepository defines the interface for email template version data access
+type EmailTemplateRepository interface {
+	GetActive(ctx context.Context, name string) (*model.EmailTemplate, error)
+	GetAllActive(ctx context.Context) ([]*model.EmailTemplate, error)
+	GetVersion(ctx context.Context, name string, version int) (*model.EmailTemplate, error)
+	ListVersions(ctx context.Context, name string) ([]*model.EmailTemplate, error)
+	Create(ctx context.Context, template *model.EmailTemplate) (*model.EmailTemplate, error)
+	Activate(ctx context.Context, name string, version int) error
+}
+
+// emailTemplateRepository implements EmailTemplateRepository
+type emailTemplateRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewEmailTemplateRepository creates a new email template repository
+func NewEmailTemplateRepository(db *sql.DB, log *logger.Logger) EmailTemplateRepository {
+	return &emailTemplateRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// GetActive retrieves the version of a template that is sent
+func (r *emailTemplateRepository) GetActive(ctx context.Context, name string) (*model.EmailTemplate, error) {
+	query := `
+		SELECT ` + emailTemplateColumns + `
+		FROM email_templates
+		WHERE name = $1 AND is_active`
+
+	template, err := scanEmailTemplate(executor(ctx, r.db).QueryRowContext(ctx, query, name))
+	if err == sql.ErrNoRows {
+		return nil, apperr.Errorf(apperr.ErrNotFound, "no active version of email template %s", name)
+	}
+	if err != nil {
+		r.log.WithError(err).WithField("template", name).Error("Failed to get active email template")
+		return nil, fmt.Errorf("failed to get active email template: %w", err)
+	}
+
+	return template, nil
+}
+
+// GetAllActive retrieves the active version of every template that has one
+func (r *emailTemplateRepository) GetAllActive(ctx context.Context) ([]*model.EmailTemplate, error) {
+	query := `
+		SELECT ` + emailTemplateColumns + `
+		FROM email_templates
+		WHERE is_active
+		ORDER BY name ASC`
+
+	return r.query(ctx, query)
+}
+
+// GetVersion retrieves one version of a template
+func (r *emailTemplateRepository) GetVersion(
+	ctx context.Context, name string, version int,
+) (*model.EmailTemplate, error) {
+	query := `
+		SELECT ` + emailTemplateColumns + `
+		FROM email_templates
+		WHERE name = $1 AND version = $2`
+
+	template, err := scanEmailTemplate(executor(ctx, r.db).QueryRowContext(ctx, query, name, version))
+	if err == sql.ErrNoRows {
+		return nil, apperr.Errorf(apperr.ErrNotFound, "email template %s version %d not found", name, version)
+	}
+	if err != nil {
+		r.log.WithError(err).WithField("template", name).Error("Failed to get email template version")
+		return nil, fmt.Errorf("failed to get email template version: %w", err)
+	}
+
+	return template, nil
+}
+
+// ListVersions retrieves every version of a template, newest first
+func (r *emailTemplateRepository) ListVersions(ctx context.Context, name string) ([]*model.EmailTemplate, error) {
+	query := `
+		SELECT ` + emailTemplateColumns + `
+		FROM email_templates
+		WHERE name = $1
+		ORDER BY version DESC`
+
+	return r.query(ctx, query, name)
+}
+
+// Create adds a version numbered after the latest one. Two versions created at once
+// conflict on the number and the later one fails as a duplicate.
+func (r *emailTemplateRepository) Create(
+	ctx context.Context, template *model.EmailTemplate,
+) (*model.EmailTemplate, error) {
+	query := `
+		INSERT INTO email_templates (name, version, subject, body, note)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3, $4
+		FROM email_templates
+		WHERE name = $1
+		RETURNING ` + emailTemplateColumns
+
+	created, err := scanEmailTemplate(executor(ctx, r.db).QueryRowContext(ctx, query,
+		template.Name, template.Subject, template.Body, template.Note,
+	))
+	if isUniqueViolation(err) {
+		return nil, apperr.Errorf(apperr.ErrDuplicate, "another version of email template %s was created at the same time", template.Name)
+	}
+	if err != nil {
+		r.log.WithError(err).WithField("template", template.Name).Error("Failed to create email template version")
+		return nil, fmt.Errorf("failed to create email template version: %w", err)
+	}
+
+	return created, nil
+}
+
+// Activate makes version the one sent. Version 0 deactivates every version so the built-in
+// template is sent. Call it within a transaction so no moment has two active versions.
+func (r *emailTemplateRepository) Activate(ctx context.Context, name string, version int) error {
+	db := executor(ctx, r.db)
+
+	if _, err := db.ExecContext(ctx,
+		`UPDATE email_templates SET is_active = FALSE WHERE name = $1 AND is_active`, name,
+	); err != nil {
+		r.log.WithError(err).WithField("template", name).Error("Failed to deactivate email template")
+		return fmt.Errorf("failed to deactivate email template: %w", err)
+	}
+	if version == 0 {
+		return nil
+	}
+
+	result, err := db.ExecContext(ctx,
+		`UPDATE email_templates SET is_active = TRUE WHERE name = $1 AND version = $2`, name, version,
+	)
+	if err != nil {
+		r.log.WithError(err).WithField("template", name).Error("Failed to activate email template")
+		return fmt.Errorf("failed to activate email template: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return apperr.Errorf(apperr.ErrNotFound, "email template %s version %d not found", name, version)
+	}
+
+	return nil
+}
+
+// query executes a query and returns template versions
+func (r *emailTemplateRepository) query(
+	ctx context.Context, query string, args ...any,
+) ([]*model.EmailTemplate, error) {
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to query email templates")
+		return nil, fmt.Errorf("failed to query email templates: %w", err)
+	}
+	defer rows.Close()
+
+	var templates []*model.EmailTemplate
+	for rows.Next() {
+		template, err := scanEmailTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email template: %w", err)
+		}
+		templates = append(templates, template)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate email templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+// scanEmailTemplate scans a row selected with emailTemplateColumns
+func scanEmailTemplate(row interface{ Scan(dest ...any) error }) (*model.EmailTemplate, error) {
+	var template model.EmailTemplate
+	err := row.Scan(
+		&template.ID, &template.Name, &template.Version, &template.Subject, &template.Body,
+		&template.IsActive, &template.Note, &template.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
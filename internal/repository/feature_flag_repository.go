@@ -0,0 +1,97 @@
+// Package repository provides feature flag override data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// FeatureFlagRepository defines the interface for feature flag override data access
+type FeatureFlagRepository interface {
+	GetAll(ctx context.Context) ([]*model.FeatureFlagOverride, error)
+	Upsert(ctx context.Context, override *model.FeatureFlagOverride) (*model.FeatureFlagOverride, error)
+	Delete(ctx context.Context, name string) (bool, error)
+}
+
+// featureFlagRepository implements FeatureFlagRepository
+type featureFlagRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewFeatureFlagRepository creates a new feature flag repository
+func NewFeatureFlagRepository(db *sql.DB, log *logger.Logger) FeatureFlagRepository {
+	return &featureFlagRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// GetAll retrieves every override
+func (r *featureFlagRepository) GetAll(ctx context.Context) ([]*model.FeatureFlagOverride, error) {
+	query := `SELECT name, enabled, reason, updated_at FROM feature_flags ORDER BY name`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to get feature flag overrides")
+		return nil, fmt.Errorf("failed to get feature flag overrides: %w", err)
+	}
+	defer rows.Close()
+
+	var overrides []*model.FeatureFlagOverride
+	for rows.Next() {
+		override := &model.FeatureFlagOverride{}
+		if err := rows.Scan(&override.Name, &override.Enabled, &override.Reason, &override.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag override: %w", err)
+		}
+		overrides = append(overrides, override)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate feature flag overrides: %w", err)
+	}
+
+	return overrides, nil
+}
+
+// Upsert creates or replaces the override for a flag
+func (r *featureFlagRepository) Upsert(
+	ctx context.Context, override *model.FeatureFlagOverride,
+) (*model.FeatureFlagOverride, error) {
+	query := `
+		INSERT INTO feature_flags (name, enabled, reason, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (name) DO UPDATE
+		SET enabled = EXCLUDED.enabled, reason = EXCLUDED.reason, updated_at = EXCLUDED.updated_at
+		RETURNING updated_at`
+
+	saved := *override
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, override.Name, override.Enabled, override.Reason).
+		Scan(&saved.UpdatedAt)
+	if err != nil {
+		r.log.WithError(err).WithField("flag", override.Name).Error("Failed to save feature flag override")
+		return nil, fmt.Errorf("failed to save feature flag override: %w", err)
+	}
+
+	return &saved, nil
+}
+
+// Delete removes the override for a flag and reports whether one existed
+func (r *featureFlagRepository) Delete(ctx context.Context, name string) (bool, error) {
+	result, err := executor(ctx, r.db).ExecContext(ctx, `DELETE FROM feature_flags WHERE name = $1`, name)
+	if err != nil {
+		r.log.WithError(err).WithField("flag", name).Error("Failed to delete feature flag override")
+		return false, fmt.Errorf("failed to delete feature flag override: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	return affected > 0, nil
+}
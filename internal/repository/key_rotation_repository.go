@@ -0,0 +1,90 @@
+// Package repository provides key rotation checkpoint data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const keyRotationColumns = `id, from_key_id, to_key_id, target, last_id, rewritten, completed_at, created_at, updated_at`
+
+// KeyRotationRepository defines the interface for key rotation checkpoint data access
+type KeyRotationRepository interface {
+	Start(ctx context.Context, fromKeyID, toKeyID, target string) (*model.KeyRotation, error)
+	SaveProgress(ctx context.Context, id, lastID int64, rewritten int) error
+	Complete(ctx context.Context, id int64) error
+	Reset(ctx context.Context, id int64) error
+}
+
+// keyRotationRepository implements KeyRotationRepository
+type keyRotationRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewKeyRotationRepository creates a new key rotation repository
+func NewKeyRotationRepository(db *sql.DB, log *logger.Logger) KeyRotationRepository {
+	return &keyRotationRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// Start returns the checkpoint of rotating the target from one key to another, creating it
+// when the rotation has not run before
+func (r *keyRotationRepository) Start(ctx context.Context, fromKeyID, toKeyID, target string) (*model.KeyRotation, error) {
+	query := `
+		INSERT INTO key_rotations (from_key_id, to_key_id, target)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (from_key_id, to_key_id, target) DO UPDATE SET updated_at = NOW()
+		RETURNING ` + keyRotationColumns
+
+	var rotation model.KeyRotation
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, fromKeyID, toKeyID, target).Scan(
+		&rotation.ID, &rotation.FromKeyID, &rotation.ToKeyID, &rotation.Target, &rotation.LastID,
+		&rotation.Rewritten, &rotation.CompletedAt, &rotation.CreatedAt, &rotation.UpdatedAt,
+	)
+	if err != nil {
+		r.log.WithError(err).WithField("target", target).Error("Failed to start key rotation")
+		return nil, fmt.Errorf("failed to start key rotation: %w", err)
+	}
+
+	return &rotation, nil
+}
+
+// SaveProgress records the last row rewritten and adds the rows rewritten in a batch
+func (r *keyRotationRepository) SaveProgress(ctx context.Context, id, lastID int64, rewritten int) error {
+	query := `
+		UPDATE key_rotations SET last_id = $2, rewritten = rewritten + $3, updated_at = NOW()
+		WHERE id = $1`
+
+	return r.exec(ctx, "save key rotation progress", query, id, lastID, rewritten)
+}
+
+// Complete records that no rows of the target are left encrypted with the old key
+func (r *keyRotationRepository) Complete(ctx context.Context, id int64) error {
+	query := `UPDATE key_rotations SET completed_at = NOW(), updated_at = NOW() WHERE id = $1`
+
+	return r.exec(ctx, "complete key rotation", query, id)
+}
+
+// Reset clears the checkpoint so the rotation starts over from the first row
+func (r *keyRotationRepository) Reset(ctx context.Context, id int64) error {
+	query := `
+		UPDATE key_rotations SET last_id = 0, rewritten = 0, completed_at = NULL, updated_at = NOW()
+		WHERE id = $1`
+
+	return r.exec(ctx, "reset key rotation", query, id)
+}
+
+func (r *keyRotationRepository) exec(ctx context.Context, operation, query string, args ...any) error {
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+		r.log.WithError(err).Errorf("Failed to %s", operation)
+		return fmt.Errorf("failed to %s: %w", operation, err)
+	}
+	return nil
+}
@@ -6,10 +6,22 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
 	"github.com/octop162/normal-form-app-by-claude/internal/model"
 	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
 )
 
+const (
+	// Columns scanned by scanOption
+	optionColumns = `id, option_type, option_name, description, plan_compatibility, max_quantity,
+		monthly_price, is_active, valid_from, valid_until, created_at, updated_at`
+
+	// Matches options that are active and within their validity period
+	optionAvailableCondition = `is_active = true
+		AND (valid_from IS NULL OR valid_from <= NOW())
+		AND (valid_until IS NULL OR valid_until > NOW())`
+)
+
 // OptionRepository defines the interface for option master data access
 type OptionRepository interface {
 	GetAll(ctx context.Context) ([]*model.OptionMaster, error)
@@ -17,6 +29,9 @@ type OptionRepository interface {
 	GetByOptionType(ctx context.Context, optionType string) (*model.OptionMaster, error)
 	GetActiveOptions(ctx context.Context) ([]*model.OptionMaster, error)
 	GetCompatibleOptions(ctx context.Context, planType string) ([]*model.OptionMaster, error)
+	Create(ctx context.Context, option *model.OptionMaster) (*model.OptionMaster, error)
+	Update(ctx context.Context, option *model.OptionMaster) (*model.OptionMaster, error)
+	Delete(ctx context.Context, optionType string) error
 }
 
 // optionRepository implements OptionRepository
@@ -33,22 +48,22 @@ func NewOptionRepository(db *sql.DB, log *logger.Logger) OptionRepository {
 	}
 }
 
-// GetAll retrieves all option master data
+// GetAll retrieves all option master data, including inactive and scheduled options
 func (r *optionRepository) GetAll(ctx context.Context) ([]*model.OptionMaster, error) {
 	query := `
-		SELECT id, option_type, option_name, description, plan_compatibility, is_active, created_at, updated_at
+		SELECT ` + optionColumns + `
 		FROM options_master
 		ORDER BY option_type ASC`
 
 	return r.queryOptions(ctx, query)
 }
 
-// GetByPlanType retrieves options compatible with a specific plan type
+// GetByPlanType retrieves available options compatible with a specific plan type
 func (r *optionRepository) GetByPlanType(ctx context.Context, planType string) ([]*model.OptionMaster, error) {
 	query := `
-		SELECT id, option_type, option_name, description, plan_compatibility, is_active, created_at, updated_at
+		SELECT ` + optionColumns + `
 		FROM options_master
-		WHERE is_active = true AND (plan_compatibility = $1 OR plan_compatibility = 'AB')
+		WHERE ` + optionAvailableCondition + ` AND (plan_compatibility = $1 OR plan_compatibility = 'AB')
 		ORDER BY option_type ASC`
 
 	rows, err := r.db.QueryContext(ctx, query, planType)
@@ -61,46 +76,114 @@ func (r *optionRepository) GetByPlanType(ctx context.Context, planType string) (
 	return r.scanOptions(rows)
 }
 
-// GetByOptionType retrieves a specific option by option type
+// GetByOptionType retrieves a specific option by option type, whether or not it is available
 func (r *optionRepository) GetByOptionType(ctx context.Context, optionType string) (*model.OptionMaster, error) {
 	query := `
-		SELECT id, option_type, option_name, description, plan_compatibility, is_active, created_at, updated_at
+		SELECT ` + optionColumns + `
 		FROM options_master
 		WHERE option_type = $1`
 
-	var option model.OptionMaster
-	err := r.db.QueryRowContext(ctx, query, optionType).Scan(
-		&option.ID, &option.OptionType, &option.OptionName, &option.Description,
-		&option.PlanCompatibility, &option.IsActive, &option.CreatedAt, &option.UpdatedAt,
-	)
-
+	option, err := scanOption(executor(ctx, r.db).QueryRowContext(ctx, query, optionType))
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("option not found: %w", err)
+			return nil, apperr.Errorf(apperr.ErrNotFound, "option not found: %w", err)
 		}
 		r.log.WithError(err).WithField("option_type", optionType).Error("Failed to get option by type")
 		return nil, fmt.Errorf("failed to get option by type: %w", err)
 	}
 
-	return &option, nil
+	return option, nil
 }
 
-// GetActiveOptions retrieves all active options
+// GetActiveOptions retrieves all options that are active and within their validity period
 func (r *optionRepository) GetActiveOptions(ctx context.Context) ([]*model.OptionMaster, error) {
 	query := `
-		SELECT id, option_type, option_name, description, plan_compatibility, is_active, created_at, updated_at
+		SELECT ` + optionColumns + `
 		FROM options_master
-		WHERE is_active = true
+		WHERE ` + optionAvailableCondition + `
 		ORDER BY option_type ASC`
 
 	return r.queryOptions(ctx, query)
 }
 
-// GetCompatibleOptions retrieves options compatible with a specific plan type (active only)
+// GetCompatibleOptions retrieves options compatible with a specific plan type (available only)
 func (r *optionRepository) GetCompatibleOptions(ctx context.Context, planType string) ([]*model.OptionMaster, error) {
 	return r.GetByPlanType(ctx, planType)
 }
 
+// Create creates a new option
+func (r *optionRepository) Create(ctx context.Context, option *model.OptionMaster) (*model.OptionMaster, error) {
+	query := `
+		INSERT INTO options_master (
+			option_type, option_name, description, plan_compatibility, max_quantity,
+			monthly_price, is_active, valid_from, valid_until
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING ` + optionColumns
+
+	created, err := scanOption(executor(ctx, r.db).QueryRowContext(ctx, query,
+		option.OptionType, option.OptionName, option.Description, option.PlanCompatibility, option.MaxQuantity,
+		option.MonthlyPrice, option.IsActive, option.ValidFrom, option.ValidUntil,
+	))
+	if isUniqueViolation(err) {
+		return nil, apperr.Errorf(apperr.ErrDuplicate, "option %s already exists", option.OptionType)
+	}
+	if err != nil {
+		r.log.WithError(err).WithField("option_type", option.OptionType).Error("Failed to create option")
+		return nil, fmt.Errorf("failed to create option: %w", err)
+	}
+
+	r.log.WithField("option_type", created.OptionType).Info("Option created successfully")
+	return created, nil
+}
+
+// Update updates the option with the same option type
+func (r *optionRepository) Update(ctx context.Context, option *model.OptionMaster) (*model.OptionMaster, error) {
+	query := `
+		UPDATE options_master SET
+			option_name = $2, description = $3, plan_compatibility = $4, max_quantity = $5,
+			monthly_price = $6, is_active = $7, valid_from = $8, valid_until = $9, updated_at = NOW()
+		WHERE option_type = $1
+		RETURNING ` + optionColumns
+
+	updated, err := scanOption(executor(ctx, r.db).QueryRowContext(ctx, query,
+		option.OptionType, option.OptionName, option.Description, option.PlanCompatibility, option.MaxQuantity,
+		option.MonthlyPrice, option.IsActive, option.ValidFrom, option.ValidUntil,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Errorf(apperr.ErrNotFound, "option not found: %w", err)
+		}
+		r.log.WithError(err).WithField("option_type", option.OptionType).Error("Failed to update option")
+		return nil, fmt.Errorf("failed to update option: %w", err)
+	}
+
+	r.log.WithField("option_type", updated.OptionType).Info("Option updated successfully")
+	return updated, nil
+}
+
+// Delete deletes an option by option type
+func (r *optionRepository) Delete(ctx context.Context, optionType string) error {
+	query := `DELETE FROM options_master WHERE option_type = $1`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, optionType)
+	if err != nil {
+		r.log.WithError(err).WithField("option_type", optionType).Error("Failed to delete option")
+		return fmt.Errorf("failed to delete option: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.Errorf(apperr.ErrNotFound, "option not found")
+	}
+
+	r.log.WithField("option_type", optionType).Info("Option deleted successfully")
+	return nil
+}
+
 // queryOptions executes a query and returns options
 func (r *optionRepository) queryOptions(
 	ctx context.Context, query string, args ...any,
@@ -120,16 +203,12 @@ func (r *optionRepository) scanOptions(rows *sql.Rows) ([]*model.OptionMaster, e
 	var options []*model.OptionMaster
 
 	for rows.Next() {
-		var option model.OptionMaster
-		err := rows.Scan(
-			&option.ID, &option.OptionType, &option.OptionName, &option.Description,
-			&option.PlanCompatibility, &option.IsActive, &option.CreatedAt, &option.UpdatedAt,
-		)
+		option, err := scanOption(rows)
 		if err != nil {
 			r.log.WithError(err).Error("Failed to scan option row")
 			return nil, fmt.Errorf("failed to scan option row: %w", err)
 		}
-		options = append(options, &option)
+		options = append(options, option)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -139,3 +218,17 @@ func (r *optionRepository) scanOptions(rows *sql.Rows) ([]*model.OptionMaster, e
 
 	return options, nil
 }
+
+// scanOption scans a row selected with optionColumns
+func scanOption(row interface{ Scan(dest ...any) error }) (*model.OptionMaster, error) {
+	var option model.OptionMaster
+	err := row.Scan(
+		&option.ID, &option.OptionType, &option.OptionName, &option.Description,
+		&option.PlanCompatibility, &option.MaxQuantity, &option.MonthlyPrice, &option.IsActive,
+		&option.ValidFrom, &option.ValidUntil, &option.CreatedAt, &option.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &option, nil
+}
@@ -0,0 +1,280 @@
+// Package repository provides outbox event data access functionality.
+package repository
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// OutboxRepository defines the interface for outbox event data access
+type OutboxRepository interface {
+	Create(ctx context.Context, event *model.OutboxEvent) (*model.OutboxEvent, error)
+	ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]*model.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, terminal bool) error
+	GetByID(ctx context.Context, id int64) (*model.OutboxEvent, error)
+	Requeue(ctx context.Context, id int64) (*model.OutboxEvent, error)
+	ListUndelivered(
+		ctx context.Context, state, eventType string, limit, offset int,
+	) ([]*model.OutboxEventDeliveries, error)
+}
+
+// outboxRepository implements OutboxRepository
+type outboxRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewOutboxRepository creates a new outbox repository
+func NewOutboxRepository(db *sql.DB, log *logger.Logger) OutboxRepository {
+	return &outboxRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// outboxEventColumns lists the columns scanned by scanOutboxEvent
+const outboxEventColumns = `id, aggregate_type, aggregate_id, event_type, payload, correlation_id, status, attempts,
+		last_error, next_attempt_at, created_at, published_at`
+
+// Create records a new pending event. Call it with a transactional context so the
+// event is committed atomically with the state change it describes.
+func (r *outboxRepository) Create(ctx context.Context, event *model.OutboxEvent) (*model.OutboxEvent, error) {
+	query := `
+		INSERT INTO outbox_events (aggregate_type, aggregate_id, event_type, payload, correlation_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, status, attempts, next_attempt_at, created_at`
+
+	created := *event
+	err := executor(ctx, r.db).QueryRowContext(ctx, query,
+		event.AggregateType, event.AggregateID, event.EventType, []byte(event.Payload), event.CorrelationID,
+	).Scan(&created.ID, &created.Status, &created.Attempts, &created.NextAttemptAt, &created.CreatedAt)
+
+	if err != nil {
+		r.log.WithError(err).
+			WithField("event_type", event.EventType).
+			WithField("aggregate_id", event.AggregateID).
+			Error("Failed to create outbox event")
+		return nil, fmt.Errorf("failed to create outbox event: %w", err)
+	}
+
+	return &created, nil
+}
+
+// ClaimPending leases up to limit due events for delivery, oldest first, by pushing their
+// next attempt back by lease. The claim is committed before the events are published, so
+// concurrent relays skip them without locks held during delivery. Events whose relay stopped
+// before recording the outcome become due again once the lease ends.
+func (r *outboxRepository) ClaimPending(ctx context.Context, limit int, lease time.Duration) ([]*model.OutboxEvent, error) {
+	query := `
+		UPDATE outbox_events SET next_attempt_at = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY id ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + outboxEventColumns
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		r.log.WithError(err).Error("Failed to claim pending outbox events")
+		return nil, fmt.Errorf("failed to claim pending outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*model.OutboxEvent
+	for rows.Next() {
+		event, scanErr := scanOutboxEvent(rows)
+		if scanErr != nil {
+			r.log.WithError(scanErr).Error("Failed to scan outbox event row")
+			return nil, fmt.Errorf("failed to scan outbox event row: %w", scanErr)
+		}
+		events = append(events, event)
+	}
+
+	if err = rows.Err(); err != nil {
+		r.log.WithError(err).Error("Error iterating outbox event rows")
+		return nil, fmt.Errorf("error iterating outbox event rows: %w", err)
+	}
+
+	// RETURNING does not keep the order of the subquery
+	slices.SortFunc(events, func(a, b *model.OutboxEvent) int { return cmp.Compare(a.ID, b.ID) })
+	return events, nil
+}
+
+// MarkPublished marks an event as delivered
+func (r *outboxRepository) MarkPublished(ctx context.Context, id int64) error {
+	query := `
+		UPDATE outbox_events SET
+			status = 'published', attempts = attempts + 1, last_error = NULL, published_at = NOW()
+		WHERE id = $1`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+		r.log.WithError(err).WithField("event_id", id).Error("Failed to mark outbox event as published")
+		return fmt.Errorf("failed to mark outbox event as published: %w", err)
+	}
+
+	return nil
+}
+
+// MarkFailed records a failed delivery attempt and schedules the next one.
+// Terminal failures are moved to the failed status and no longer retried.
+func (r *outboxRepository) MarkFailed(
+	ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, terminal bool,
+) error {
+	status := model.OutboxStatusPending
+	if terminal {
+		status = model.OutboxStatusFailed
+	}
+
+	query := `
+		UPDATE outbox_events SET
+			status = $2, attempts = attempts + 1, last_error = $3, next_attempt_at = $4
+		WHERE id = $1`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, id, status, lastError, nextAttemptAt); err != nil {
+		r.log.WithError(err).WithField("event_id", id).Error("Failed to mark outbox event as failed")
+		return fmt.Errorf("failed to mark outbox event as failed: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an event
+func (r *outboxRepository) GetByID(ctx context.Context, id int64) (*model.OutboxEvent, error) {
+	query := `SELECT ` + outboxEventColumns + ` FROM outbox_events WHERE id = $1`
+
+	event, err := scanOutboxEvent(executor(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, apperr.Errorf(apperr.ErrNotFound, "event %d not found", id)
+	}
+	if err != nil {
+		r.log.WithError(err).WithField("event_id", id).Error("Failed to get outbox event")
+		return nil, fmt.Errorf("failed to get outbox event: %w", err)
+	}
+
+	return event, nil
+}
+
+// Requeue makes an unpublished event due now and restarts its attempts. An event that was
+// published meanwhile is not requeued and nil is returned.
+func (r *outboxRepository) Requeue(ctx context.Context, id int64) (*model.OutboxEvent, error) {
+	query := `
+		UPDATE outbox_events SET
+			status = 'pending', attempts = 0, next_attempt_at = NOW()
+		WHERE id = $1 AND status <> 'published'
+		RETURNING ` + outboxEventColumns
+
+	event, err := scanOutboxEvent(executor(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		r.log.WithError(err).WithField("event_id", id).Error("Failed to requeue outbox event")
+		return nil, fmt.Errorf("failed to requeue outbox event: %w", err)
+	}
+
+	return event, nil
+}
+
+// ListUndelivered lists events, newest first, whose publishing or webhook delivery has
+// failed. State model.EventDeliveryFailed keeps those that are no longer retried,
+// model.EventDeliveryRetrying those awaiting a retry and an empty state both. An empty
+// eventType lists every type.
+func (r *outboxRepository) ListUndelivered(
+	ctx context.Context, state, eventType string, limit, offset int,
+) ([]*model.OutboxEventDeliveries, error) {
+	failed := `o.status = 'failed' OR w.status = 'dead'`
+	retrying := `(o.status = 'pending' AND o.attempts > 0) OR (w.status = 'pending' AND w.attempts > 0)`
+	condition := failed + ` OR ` + retrying
+	switch state {
+	case model.EventDeliveryFailed:
+		condition = failed
+	case model.EventDeliveryRetrying:
+		condition = retrying
+	}
+
+	query := `
+		SELECT o.id, o.aggregate_type, o.aggregate_id, o.event_type, o.payload, o.correlation_id, o.status,
+			   o.attempts, o.last_error, o.next_attempt_at, o.created_at, o.published_at,
+			   w.id, w.status, w.attempts, w.last_error, w.next_attempt_at, w.delivered_at, w.dead_at
+		FROM outbox_events o
+		LEFT JOIN webhook_deliveries w ON w.outbox_event_id = o.id
+		WHERE (` + condition + `) AND ($1 = '' OR o.event_type = $1)
+		ORDER BY o.id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, eventType, limit, offset)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to list undelivered outbox events")
+		return nil, fmt.Errorf("failed to list undelivered outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*model.OutboxEventDeliveries
+	for rows.Next() {
+		var event model.OutboxEvent
+		var payload []byte
+		var webhookID, webhookAttempts sql.NullInt64
+		var webhookStatus sql.NullString
+		var webhookNextAttemptAt sql.NullTime
+		var webhook model.WebhookDelivery
+		err := rows.Scan(
+			&event.ID, &event.AggregateType, &event.AggregateID, &event.EventType, &payload,
+			&event.CorrelationID, &event.Status, &event.Attempts, &event.LastError, &event.NextAttemptAt,
+			&event.CreatedAt, &event.PublishedAt,
+			&webhookID, &webhookStatus, &webhookAttempts, &webhook.LastError, &webhookNextAttemptAt,
+			&webhook.DeliveredAt, &webhook.DeadAt,
+		)
+		if err != nil {
+			r.log.WithError(err).Error("Failed to scan outbox event row")
+			return nil, fmt.Errorf("failed to scan outbox event row: %w", err)
+		}
+		event.Payload = payload
+
+		result := &model.OutboxEventDeliveries{Event: &event}
+		if webhookID.Valid {
+			webhook.ID = webhookID.Int64
+			webhook.OutboxEventID = event.ID
+			webhook.EventType = event.EventType
+			webhook.Status = webhookStatus.String
+			webhook.Attempts = int(webhookAttempts.Int64)
+			webhook.NextAttemptAt = webhookNextAttemptAt.Time
+			result.Webhook = &webhook
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log.WithError(err).Error("Error iterating outbox event rows")
+		return nil, fmt.Errorf("error iterating outbox event rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// scanOutboxEvent scans a row selected with outboxEventColumns
+func scanOutboxEvent(row rowScanner) (*model.OutboxEvent, error) {
+	var event model.OutboxEvent
+	var payload []byte
+	err := row.Scan(
+		&event.ID, &event.AggregateType, &event.AggregateID, &event.EventType, &payload,
+		&event.CorrelationID, &event.Status, &event.Attempts, &event.LastError, &event.NextAttemptAt,
+		&event.CreatedAt, &event.PublishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	event.Payload = payload
+	return &event, nil
+}
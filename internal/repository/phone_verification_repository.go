@@ -0,0 +1,157 @@
+// Package repository provides SMS phone verification data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// PhoneVerificationRepository defines the interface for phone verification data access
+type PhoneVerificationRepository interface {
+	Create(ctx context.Context, verification *model.PhoneVerification) error
+	GetByIDForUpdate(ctx context.Context, id string) (*model.PhoneVerification, error)
+	CountSince(ctx context.Context, phone string, since time.Time) (int, error)
+	ExistsVerifiedSince(ctx context.Context, phone string, since time.Time) (bool, error)
+	IncrementAttempts(ctx context.Context, id string) error
+	MarkVerified(ctx context.Context, id string) error
+	DeleteExpired(ctx context.Context, now, verifiedBefore time.Time) (int64, error)
+}
+
+// phoneVerificationRepository implements PhoneVerificationRepository
+type phoneVerificationRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewPhoneVerificationRepository creates a new phone verification repository
+func NewPhoneVerificationRepository(db *sql.DB, log *logger.Logger) PhoneVerificationRepository {
+	return &phoneVerificationRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// Create stores a new verification code
+func (r *phoneVerificationRepository) Create(ctx context.Context, verification *model.PhoneVerification) error {
+	query := `
+		INSERT INTO phone_verifications (id, phone, code_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at`
+
+	err := executor(ctx, r.db).QueryRowContext(ctx, query,
+		verification.ID, verification.Phone, verification.CodeHash, verification.ExpiresAt,
+	).Scan(&verification.CreatedAt)
+
+	if err != nil {
+		r.log.WithError(err).Error("Failed to create phone verification")
+		return fmt.Errorf("failed to create phone verification: %w", err)
+	}
+
+	return nil
+}
+
+// GetByIDForUpdate retrieves a verification and locks it until the surrounding transaction
+// ends, so concurrent verification attempts are counted one at a time
+func (r *phoneVerificationRepository) GetByIDForUpdate(
+	ctx context.Context, id string,
+) (*model.PhoneVerification, error) {
+	query := `
+		SELECT id, phone, code_hash, attempts, expires_at, verified_at, created_at
+		FROM phone_verifications WHERE id = $1
+		FOR UPDATE`
+
+	var verification model.PhoneVerification
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
+		&verification.ID, &verification.Phone, &verification.CodeHash, &verification.Attempts,
+		&verification.ExpiresAt, &verification.VerifiedAt, &verification.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Errorf(apperr.ErrNotFound, "phone verification not found: %w", err)
+		}
+		r.log.WithError(err).Error("Failed to get phone verification")
+		return nil, fmt.Errorf("failed to get phone verification: %w", err)
+	}
+
+	return &verification, nil
+}
+
+// CountSince counts the codes sent to a phone number since the given time
+func (r *phoneVerificationRepository) CountSince(ctx context.Context, phone string, since time.Time) (int, error) {
+	query := `SELECT COUNT(*) FROM phone_verifications WHERE phone = $1 AND created_at > $2`
+
+	var count int
+	if err := executor(ctx, r.db).QueryRowContext(ctx, query, phone, since).Scan(&count); err != nil {
+		r.log.WithError(err).Error("Failed to count phone verifications")
+		return 0, fmt.Errorf("failed to count phone verifications: %w", err)
+	}
+
+	return count, nil
+}
+
+// ExistsVerifiedSince reports whether a code sent to the phone number was verified since the given time
+func (r *phoneVerificationRepository) ExistsVerifiedSince(
+	ctx context.Context, phone string, since time.Time,
+) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM phone_verifications WHERE phone = $1 AND verified_at > $2)`
+
+	var exists bool
+	if err := executor(ctx, r.db).QueryRowContext(ctx, query, phone, since).Scan(&exists); err != nil {
+		r.log.WithError(err).Error("Failed to check verified phone")
+		return false, fmt.Errorf("failed to check verified phone: %w", err)
+	}
+
+	return exists, nil
+}
+
+// IncrementAttempts records a failed verification attempt
+func (r *phoneVerificationRepository) IncrementAttempts(ctx context.Context, id string) error {
+	query := `UPDATE phone_verifications SET attempts = attempts + 1 WHERE id = $1`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+		r.log.WithError(err).Error("Failed to increment phone verification attempts")
+		return fmt.Errorf("failed to increment phone verification attempts: %w", err)
+	}
+
+	return nil
+}
+
+// MarkVerified marks the code as used
+func (r *phoneVerificationRepository) MarkVerified(ctx context.Context, id string) error {
+	query := `UPDATE phone_verifications SET verified_at = NOW() WHERE id = $1`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+		r.log.WithError(err).Error("Failed to mark phone verification as verified")
+		return fmt.Errorf("failed to mark phone verification as verified: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes codes that can no longer be used. Verified codes are kept until
+// verifiedBefore, since registrations still rely on them.
+func (r *phoneVerificationRepository) DeleteExpired(ctx context.Context, now, verifiedBefore time.Time) (int64, error) {
+	query := `
+		DELETE FROM phone_verifications
+		WHERE expires_at <= $1 AND (verified_at IS NULL OR verified_at <= $2)`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, now, verifiedBefore)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to delete expired phone verifications")
+		return 0, fmt.Errorf("failed to delete expired phone verifications: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
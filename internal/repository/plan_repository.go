@@ -0,0 +1,186 @@
+// Package repository provides plan master data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// Columns scanned by scanPlan
+const planColumns = `id, plan_type, plan_name, description, monthly_price, display_order, is_active,
+	created_at, updated_at`
+
+// PlanRepository defines the interface for plan master data access
+type PlanRepository interface {
+	GetAll(ctx context.Context) ([]*model.PlanMaster, error)
+	GetActivePlans(ctx context.Context) ([]*model.PlanMaster, error)
+	GetByPlanType(ctx context.Context, planType string) (*model.PlanMaster, error)
+	Create(ctx context.Context, plan *model.PlanMaster) (*model.PlanMaster, error)
+	Update(ctx context.Context, plan *model.PlanMaster) (*model.PlanMaster, error)
+	Deactivate(ctx context.Context, planType string) (*model.PlanMaster, error)
+}
+
+// planRepository implements PlanRepository
+type planRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewPlanRepository creates a new plan repository
+func NewPlanRepository(db *sql.DB, log *logger.Logger) PlanRepository {
+	return &planRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// GetAll retrieves all plan master data, including inactive plans
+func (r *planRepository) GetAll(ctx context.Context) ([]*model.PlanMaster, error) {
+	query := `
+		SELECT ` + planColumns + `
+		FROM plans_master
+		ORDER BY display_order ASC, plan_type ASC`
+
+	return r.queryPlans(ctx, query)
+}
+
+// GetActivePlans retrieves the plans new registrations may choose, in display order
+func (r *planRepository) GetActivePlans(ctx context.Context) ([]*model.PlanMaster, error) {
+	query := `
+		SELECT ` + planColumns + `
+		FROM plans_master
+		WHERE is_active = true
+		ORDER BY display_order ASC, plan_type ASC`
+
+	return r.queryPlans(ctx, query)
+}
+
+// GetByPlanType retrieves a plan by plan type, whether or not it is active
+func (r *planRepository) GetByPlanType(ctx context.Context, planType string) (*model.PlanMaster, error) {
+	query := `
+		SELECT ` + planColumns + `
+		FROM plans_master
+		WHERE plan_type = $1`
+
+	plan, err := scanPlan(executor(ctx, r.db).QueryRowContext(ctx, query, planType))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Errorf(apperr.ErrNotFound, "plan type %s not found", planType)
+		}
+		r.log.WithError(err).WithField("plan_type", planType).Error("Failed to get plan by type")
+		return nil, fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	return plan, nil
+}
+
+// Create creates a new plan
+func (r *planRepository) Create(ctx context.Context, plan *model.PlanMaster) (*model.PlanMaster, error) {
+	query := `
+		INSERT INTO plans_master (plan_type, plan_name, description, monthly_price, display_order, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + planColumns
+
+	created, err := scanPlan(executor(ctx, r.db).QueryRowContext(ctx, query,
+		plan.PlanType, plan.PlanName, plan.Description, plan.MonthlyPrice, plan.DisplayOrder, plan.IsActive,
+	))
+	if isUniqueViolation(err) {
+		return nil, apperr.Errorf(apperr.ErrDuplicate, "plan %s already exists", plan.PlanType)
+	}
+	if err != nil {
+		r.log.WithError(err).WithField("plan_type", plan.PlanType).Error("Failed to create plan")
+		return nil, fmt.Errorf("failed to create plan: %w", err)
+	}
+
+	r.log.WithField("plan_type", created.PlanType).Info("Plan created successfully")
+	return created, nil
+}
+
+// Update updates the plan with the same plan type
+func (r *planRepository) Update(ctx context.Context, plan *model.PlanMaster) (*model.PlanMaster, error) {
+	query := `
+		UPDATE plans_master SET
+			plan_name = $2, description = $3, monthly_price = $4, display_order = $5, is_active = $6,
+			updated_at = NOW()
+		WHERE plan_type = $1
+		RETURNING ` + planColumns
+
+	updated, err := scanPlan(executor(ctx, r.db).QueryRowContext(ctx, query,
+		plan.PlanType, plan.PlanName, plan.Description, plan.MonthlyPrice, plan.DisplayOrder, plan.IsActive,
+	))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Errorf(apperr.ErrNotFound, "plan type %s not found", plan.PlanType)
+		}
+		r.log.WithError(err).WithField("plan_type", plan.PlanType).Error("Failed to update plan")
+		return nil, fmt.Errorf("failed to update plan: %w", err)
+	}
+
+	r.log.WithField("plan_type", updated.PlanType).Info("Plan updated successfully")
+	return updated, nil
+}
+
+// Deactivate stops offering a plan to new registrations, keeping its other settings
+func (r *planRepository) Deactivate(ctx context.Context, planType string) (*model.PlanMaster, error) {
+	query := `
+		UPDATE plans_master SET is_active = false, updated_at = NOW()
+		WHERE plan_type = $1
+		RETURNING ` + planColumns
+
+	updated, err := scanPlan(executor(ctx, r.db).QueryRowContext(ctx, query, planType))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Errorf(apperr.ErrNotFound, "plan type %s not found", planType)
+		}
+		r.log.WithError(err).WithField("plan_type", planType).Error("Failed to deactivate plan")
+		return nil, fmt.Errorf("failed to deactivate plan: %w", err)
+	}
+
+	r.log.WithField("plan_type", planType).Info("Plan deactivated successfully")
+	return updated, nil
+}
+
+// queryPlans executes a query and returns plans
+func (r *planRepository) queryPlans(ctx context.Context, query string, args ...any) ([]*model.PlanMaster, error) {
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to query plans")
+		return nil, fmt.Errorf("failed to query plans: %w", err)
+	}
+	defer rows.Close()
+
+	var plans []*model.PlanMaster
+	for rows.Next() {
+		plan, err := scanPlan(rows)
+		if err != nil {
+			r.log.WithError(err).Error("Failed to scan plan row")
+			return nil, fmt.Errorf("failed to scan plan row: %w", err)
+		}
+		plans = append(plans, plan)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log.WithError(err).Error("Error iterating plan rows")
+		return nil, fmt.Errorf("error iterating plan rows: %w", err)
+	}
+
+	return plans, nil
+}
+
+// scanPlan scans a row selected with planColumns
+func scanPlan(row interface{ Scan(dest ...any) error }) (*model.PlanMaster, error) {
+	var plan model.PlanMaster
+	err := row.Scan(
+		&plan.ID, &plan.PlanType, &plan.PlanName, &plan.Description, &plan.MonthlyPrice,
+		&plan.DisplayOrder, &plan.IsActive, &plan.CreatedAt, &plan.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
 	"github.com/octop162/normal-form-app-by-claude/internal/model"
 	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
 )
@@ -58,7 +59,7 @@ func (r *prefectureRepository) GetByCode(ctx context.Context, prefectureCode str
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("prefecture not found: %w", err)
+			return nil, apperr.Errorf(apperr.ErrNotFound, "prefecture not found: %w", err)
 		}
 		r.log.WithError(err).WithField("prefecture_code", prefectureCode).Error("Failed to get prefecture by code")
 		return nil, fmt.Errorf("failed to get prefecture by code: %w", err)
@@ -82,7 +83,7 @@ func (r *prefectureRepository) GetByName(ctx context.Context, prefectureName str
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("prefecture not found: %w", err)
+			return nil, apperr.Errorf(apperr.ErrNotFound, "prefecture not found: %w", err)
 		}
 		r.log.WithError(err).WithField("prefecture_name", prefectureName).Error("Failed to get prefecture by name")
 		return nil, fmt.Errorf("failed to get prefecture by name: %w", err)
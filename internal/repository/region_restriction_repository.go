@@ -0,0 +1,183 @@
+// Package repository provides region restriction master data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// Columns scanned by scanRegionRestriction
+const regionRestrictionColumns = `id, prefecture, city, option_type, allowed, note, created_at, updated_at`
+
+// RegionRestrictionRepository defines the interface for region restriction data access
+type RegionRestrictionRepository interface {
+	List(ctx context.Context, prefecture string) ([]*model.RegionRestriction, error)
+	FindForRegion(ctx context.Context, prefecture, city string) ([]*model.RegionRestriction, error)
+	Create(ctx context.Context, restriction *model.RegionRestriction) (*model.RegionRestriction, error)
+	Update(ctx context.Context, restriction *model.RegionRestriction) (*model.RegionRestriction, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// regionRestrictionRepository implements RegionRestrictionRepository
+type regionRestrictionRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewRegionRestrictionRepository creates a new region restriction repository
+func NewRegionRestrictionRepository(db *sql.DB, log *logger.Logger) RegionRestrictionRepository {
+	return &regionRestrictionRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// List retrieves all restrictions, or those of one prefecture when prefecture is not empty
+func (r *regionRestrictionRepository) List(ctx context.Context, prefecture string) ([]*model.RegionRestriction, error) {
+	if prefecture == "" {
+		query := `
+			SELECT ` + regionRestrictionColumns + `
+			FROM region_restrictions
+			ORDER BY prefecture ASC, city ASC, option_type ASC`
+		return r.query(ctx, query)
+	}
+
+	query := `
+		SELECT ` + regionRestrictionColumns + `
+		FROM region_restrictions
+		WHERE prefecture = $1
+		ORDER BY city ASC, option_type ASC`
+
+	return r.query(ctx, query, prefecture)
+}
+
+// FindForRegion retrieves the restrictions that apply to a city: those of the whole
+// prefecture and those of the city itself
+func (r *regionRestrictionRepository) FindForRegion(
+	ctx context.Context, prefecture, city string,
+) ([]*model.RegionRestriction, error) {
+	query := `
+		SELECT ` + regionRestrictionColumns + `
+		FROM region_restrictions
+		WHERE prefecture = $1 AND (city = '' OR city = $2)
+		ORDER BY city ASC, option_type ASC`
+
+	return r.query(ctx, query, prefecture, city)
+}
+
+// Create adds a restriction
+func (r *regionRestrictionRepository) Create(
+	ctx context.Context, restriction *model.RegionRestriction,
+) (*model.RegionRestriction, error) {
+	query := `
+		INSERT INTO region_restrictions (prefecture, city, option_type, allowed, note)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + regionRestrictionColumns
+
+	created, err := scanRegionRestriction(executor(ctx, r.db).QueryRowContext(ctx, query,
+		restriction.Prefecture, restriction.City, restriction.OptionType, restriction.Allowed, restriction.Note,
+	))
+	if isUniqueViolation(err) {
+		return nil, apperr.Errorf(apperr.ErrDuplicate, "restriction of option %s in %s%s already exists",
+			restriction.OptionType, restriction.Prefecture, restriction.City)
+	}
+	if err != nil {
+		r.log.WithError(err).WithField("prefecture", restriction.Prefecture).Error("Failed to create region restriction")
+		return nil, fmt.Errorf("failed to create region restriction: %w", err)
+	}
+
+	return created, nil
+}
+
+// Update replaces the restriction with the same ID
+func (r *regionRestrictionRepository) Update(
+	ctx context.Context, restriction *model.RegionRestriction,
+) (*model.RegionRestriction, error) {
+	query := `
+		UPDATE region_restrictions SET
+			prefecture = $2, city = $3, option_type = $4, allowed = $5, note = $6, updated_at = NOW()
+		WHERE id = $1
+		RETURNING ` + regionRestrictionColumns
+
+	updated, err := scanRegionRestriction(executor(ctx, r.db).QueryRowContext(ctx, query,
+		restriction.ID, restriction.Prefecture, restriction.City, restriction.OptionType,
+		restriction.Allowed, restriction.Note,
+	))
+	if err == sql.ErrNoRows {
+		return nil, apperr.Errorf(apperr.ErrNotFound, "region restriction not found: %w", err)
+	}
+	if isUniqueViolation(err) {
+		return nil, apperr.Errorf(apperr.ErrDuplicate, "restriction of option %s in %s%s already exists",
+			restriction.OptionType, restriction.Prefecture, restriction.City)
+	}
+	if err != nil {
+		r.log.WithError(err).WithField("id", restriction.ID).Error("Failed to update region restriction")
+		return nil, fmt.Errorf("failed to update region restriction: %w", err)
+	}
+
+	return updated, nil
+}
+
+// Delete removes a restriction by ID
+func (r *regionRestrictionRepository) Delete(ctx context.Context, id int) error {
+	result, err := executor(ctx, r.db).ExecContext(ctx, `DELETE FROM region_restrictions WHERE id = $1`, id)
+	if err != nil {
+		r.log.WithError(err).WithField("id", id).Error("Failed to delete region restriction")
+		return fmt.Errorf("failed to delete region restriction: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if affected == 0 {
+		return apperr.Errorf(apperr.ErrNotFound, "region restriction not found")
+	}
+
+	return nil
+}
+
+// query executes a query and returns restrictions
+func (r *regionRestrictionRepository) query(
+	ctx context.Context, query string, args ...any,
+) ([]*model.RegionRestriction, error) {
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to query region restrictions")
+		return nil, fmt.Errorf("failed to query region restrictions: %w", err)
+	}
+	defer rows.Close()
+
+	var restrictions []*model.RegionRestriction
+	for rows.Next() {
+		restriction, err := scanRegionRestriction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan region restriction: %w", err)
+		}
+		restrictions = append(restrictions, restriction)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate region restrictions: %w", err)
+	}
+
+	return restrictions, nil
+}
+
+// scanRegionRestriction scans a row selected with regionRestrictionColumns
+func scanRegionRestriction(row interface{ Scan(dest ...any) error }) (*model.RegionRestriction, error) {
+	var restriction model.RegionRestriction
+	err := row.Scan(
+		&restriction.ID, &restriction.Prefecture, &restriction.City, &restriction.OptionType,
+		&restriction.Allowed, &restriction.Note, &restriction.CreatedAt, &restriction.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &restriction, nil
+}
@@ -0,0 +1,247 @@
+// Package repository provides option reservation data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// ReservationRepository defines the interface for option reservation data access
+type ReservationRepository interface {
+	Create(ctx context.Context, reservation *model.OptionReservation) error
+	LockOptionType(ctx context.Context, optionType string) error
+	SumHeld(ctx context.Context, optionType, excludeSessionID string) (int, error)
+	ReleaseBySession(ctx context.Context, sessionID string) (int64, error)
+	ConsumeBySession(ctx context.Context, sessionID string, userID int) (int64, error)
+	ReleaseExpired(ctx context.Context) (int64, error)
+	List(
+		ctx context.Context, status, optionType, sessionID string, createdBefore time.Time, limit, offset int,
+	) ([]*model.OptionReservation, error)
+	GetByIDForUpdate(ctx context.Context, id int) (*model.OptionReservation, error)
+	Release(ctx context.Context, id int) (*model.OptionReservation, error)
+	Extend(ctx context.Context, id int, expiresAt time.Time) (*model.OptionReservation, error)
+}
+
+// reservationColumns are the columns scanned by scanReservation, with the session joined as s
+const reservationColumns = `r.id, r.session_id, r.option_type, r.quantity, r.status, r.user_id,
+	r.expires_at, r.created_at, r.updated_at, s.expires_at`
+
+// reservationRepository implements ReservationRepository
+type reservationRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewReservationRepository creates a new reservation repository
+func NewReservationRepository(db *sql.DB, log *logger.Logger) ReservationRepository {
+	return &reservationRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// Create stores a new hold
+func (r *reservationRepository) Create(ctx context.Context, reservation *model.OptionReservation) error {
+	query := `
+		INSERT INTO option_reservations (session_id, option_type, quantity, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, status, created_at, updated_at`
+
+	err := executor(ctx, r.db).QueryRowContext(ctx, query,
+		reservation.SessionID, reservation.OptionType, reservation.Quantity, reservation.ExpiresAt,
+	).Scan(&reservation.ID, &reservation.Status, &reservation.CreatedAt, &reservation.UpdatedAt)
+
+	if err != nil {
+		r.log.WithError(err).Error("Failed to create option reservation")
+		return fmt.Errorf("failed to create option reservation: %w", err)
+	}
+
+	return nil
+}
+
+// LockOptionType serializes reservations of an option type until the surrounding transaction
+// ends, so concurrent sessions cannot hold more than the available stock. It must be called
+// inside a transaction.
+func (r *reservationRepository) LockOptionType(ctx context.Context, optionType string) error {
+	_, err := executor(ctx, r.db).ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`,
+		"option_reservation:"+optionType)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to lock option type")
+		return fmt.Errorf("failed to lock option type: %w", err)
+	}
+	return nil
+}
+
+// SumHeld returns the quantity of an option type held by unexpired reservations of sessions
+// other than excludeSessionID. Holds of deleted sessions have no session ID, so they are not
+// counted.
+func (r *reservationRepository) SumHeld(ctx context.Context, optionType, excludeSessionID string) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(quantity), 0)
+		FROM option_reservations
+		WHERE option_type = $1 AND status = 'held' AND expires_at > NOW() AND session_id <> $2`
+
+	var held int
+	if err := executor(ctx, r.db).QueryRowContext(ctx, query, optionType, excludeSessionID).Scan(&held); err != nil {
+		r.log.WithError(err).Error("Failed to sum held reservations")
+		return 0, fmt.Errorf("failed to sum held reservations: %w", err)
+	}
+
+	return held, nil
+}
+
+// ReleaseBySession releases every hold of a session
+func (r *reservationRepository) ReleaseBySession(ctx context.Context, sessionID string) (int64, error) {
+	query := `
+		UPDATE option_reservations SET status = 'released', updated_at = NOW()
+		WHERE session_id = $1 AND status = 'held'`
+
+	return r.exec(ctx, "release session reservations", query, sessionID)
+}
+
+// ConsumeBySession marks the unexpired holds of a session as used by a registration
+func (r *reservationRepository) ConsumeBySession(ctx context.Context, sessionID string, userID int) (int64, error) {
+	query := `
+		UPDATE option_reservations SET status = 'consumed', user_id = $2, updated_at = NOW()
+		WHERE session_id = $1 AND status = 'held' AND expires_at > NOW()`
+
+	return r.exec(ctx, "consume session reservations", query, sessionID, userID)
+}
+
+// ReleaseExpired releases holds whose time ran out or whose session expired or was deleted
+func (r *reservationRepository) ReleaseExpired(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE option_reservations SET status = 'released', updated_at = NOW()
+		WHERE status = 'held' AND (
+			expires_at <= NOW()
+			OR session_id IS NULL
+			OR session_id IN (SELECT id FROM user_sessions WHERE expires_at <= NOW())
+		)`
+
+	return r.exec(ctx, "release expired reservations", query)
+}
+
+// List retrieves reservations of a status created no later than createdBefore, oldest first.
+// An empty optionType or sessionID matches every reservation.
+func (r *reservationRepository) List(
+	ctx context.Context, status, optionType, sessionID string, createdBefore time.Time, limit, offset int,
+) ([]*model.OptionReservation, error) {
+	query := `
+		SELECT ` + reservationColumns + `
+		FROM option_reservations r
+		LEFT JOIN user_sessions s ON s.id = r.session_id
+		WHERE r.status = $1 AND r.created_at <= $2
+			AND ($3 = '' OR r.option_type = $3)
+			AND ($4 = '' OR r.session_id = $4)
+		ORDER BY r.created_at ASC, r.id ASC
+		LIMIT $5 OFFSET $6`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, status, createdBefore, optionType, sessionID, limit, offset)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to list option reservations")
+		return nil, fmt.Errorf("failed to list option reservations: %w", err)
+	}
+	defer rows.Close()
+
+	var reservations []*model.OptionReservation
+	for rows.Next() {
+		reservation, err := scanReservation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan option reservation row: %w", err)
+		}
+		reservations = append(reservations, reservation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate option reservation rows: %w", err)
+	}
+
+	return reservations, nil
+}
+
+// GetByIDForUpdate retrieves a reservation and locks it until the surrounding transaction ends
+func (r *reservationRepository) GetByIDForUpdate(ctx context.Context, id int) (*model.OptionReservation, error) {
+	query := `
+		SELECT ` + reservationColumns + `
+		FROM option_reservations r
+		LEFT JOIN user_sessions s ON s.id = r.session_id
+		WHERE r.id = $1
+		FOR UPDATE OF r`
+
+	reservation, err := scanReservation(executor(ctx, r.db).QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, apperr.Errorf(apperr.ErrNotFound, "option reservation %d not found", id)
+	}
+	if err != nil {
+		r.log.WithError(err).WithField("reservation_id", id).Error("Failed to get option reservation")
+		return nil, fmt.Errorf("failed to get option reservation: %w", err)
+	}
+
+	return reservation, nil
+}
+
+// Release releases a hold, returning nil when the reservation is not held
+func (r *reservationRepository) Release(ctx context.Context, id int) (*model.OptionReservation, error) {
+	query := `
+		UPDATE option_reservations SET status = 'released', updated_at = NOW()
+		WHERE id = $1 AND status = 'held'`
+
+	return r.updateHeld(ctx, "release option reservation", id, query, id)
+}
+
+// Extend moves the expiry of a hold, returning nil when the reservation is not held
+func (r *reservationRepository) Extend(ctx context.Context, id int, expiresAt time.Time) (*model.OptionReservation, error) {
+	query := `
+		UPDATE option_reservations SET expires_at = $2, updated_at = NOW()
+		WHERE id = $1 AND status = 'held'`
+
+	return r.updateHeld(ctx, "extend option reservation", id, query, id, expiresAt)
+}
+
+// updateHeld runs an update of a held reservation and returns the reservation as updated, or
+// nil when the update matched no row
+func (r *reservationRepository) updateHeld(
+	ctx context.Context, operation string, id int, query string, args ...any,
+) (*model.OptionReservation, error) {
+	updated, err := r.exec(ctx, operation, query, args...)
+	if err != nil || updated == 0 {
+		return nil, err
+	}
+	return r.GetByIDForUpdate(ctx, id)
+}
+
+// scanReservation scans a row of reservationColumns
+func scanReservation(row interface{ Scan(dest ...any) error }) (*model.OptionReservation, error) {
+	var reservation model.OptionReservation
+	err := row.Scan(
+		&reservation.ID, &reservation.SessionID, &reservation.OptionType, &reservation.Quantity,
+		&reservation.Status, &reservation.UserID, &reservation.ExpiresAt, &reservation.CreatedAt,
+		&reservation.UpdatedAt, &reservation.SessionExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// exec runs an update and returns the number of affected rows
+func (r *reservationRepository) exec(ctx context.Context, operation, query string, args ...any) (int64, error) {
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		r.log.WithError(err).Errorf("Failed to %s", operation)
+		return 0, fmt.Errorf("failed to %s: %w", operation, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
@@ -0,0 +1,228 @@
+// Package repository provides security event and IP block data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const securityEventColumns = `id, client_ip, event_type, method, path, status_code, request_id, created_at`
+
+const ipBlockColumns = `client_ip, reason, event_count, blocked_until, created_at, updated_at`
+
+// SecurityEventRepository defines the interface for security event and IP block data access
+type SecurityEventRepository interface {
+	Create(ctx context.Context, event *model.SecurityEvent) error
+	CountSince(ctx context.Context, clientIP, eventType string, since time.Time) (int, error)
+	List(ctx context.Context, clientIP, eventType string, limit, offset int) ([]*model.SecurityEvent, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+
+	Block(ctx context.Context, block *model.IPBlock) (*model.IPBlock, error)
+	ListActiveBlocks(ctx context.Context, now time.Time) ([]*model.IPBlock, error)
+	Unblock(ctx context.Context, clientIP string, now time.Time) (bool, error)
+}
+
+// securityEventRepository implements SecurityEventRepository
+type securityEventRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewSecurityEventRepository creates a new security event repository
+func NewSecurityEventRepository(db *sql.DB, log *logger.Logger) SecurityEventRepository {
+	return &securityEventRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// Create records a security event
+func (r *securityEventRepository) Create(ctx context.Context, event *model.SecurityEvent) error {
+	query := `
+		INSERT INTO security_events (client_ip, event_type, method, path, status_code, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	err := executor(ctx, r.db).QueryRowContext(ctx, query,
+		event.ClientIP, event.EventType, event.Method, event.Path, event.StatusCode, event.RequestID,
+	).Scan(&event.ID, &event.CreatedAt)
+	if err != nil {
+		r.log.WithError(err).
+			WithField("client_ip", event.ClientIP).
+			WithField("event_type", event.EventType).
+			Error("Failed to record security event")
+		return fmt.Errorf("failed to record security event: %w", err)
+	}
+
+	return nil
+}
+
+// CountSince counts the events of a type from a client IP recorded after since and after the
+// last block of the IP ended, so events that led to an earlier block are not counted again
+func (r *securityEventRepository) CountSince(
+	ctx context.Context, clientIP, eventType string, since time.Time,
+) (int, error) {
+	query := `
+		SELECT COUNT(*) FROM security_events
+		WHERE client_ip = $1 AND event_type = $2
+			AND created_at > GREATEST($3, COALESCE((SELECT blocked_until FROM ip_blocks WHERE client_ip = $1), $3))`
+
+	var count int
+	if err := executor(ctx, r.db).QueryRowContext(ctx, query, clientIP, eventType, since).Scan(&count); err != nil {
+		r.log.WithError(err).WithField("client_ip", clientIP).Error("Failed to count security events")
+		return 0, fmt.Errorf("failed to count security events: %w", err)
+	}
+
+	return count, nil
+}
+
+// List returns events newest first, optionally only those of a client IP or type
+func (r *securityEventRepository) List(
+	ctx context.Context, clientIP, eventType string, limit, offset int,
+) ([]*model.SecurityEvent, error) {
+	query := `
+		SELECT ` + securityEventColumns + `
+		FROM security_events
+		WHERE ($1 = '' OR client_ip = $1) AND ($2 = '' OR event_type = $2)
+		ORDER BY id DESC
+		LIMIT $3 OFFSET $4`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, clientIP, eventType, limit, offset)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to list security events")
+		return nil, fmt.Errorf("failed to list security events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*model.SecurityEvent
+	for rows.Next() {
+		var event model.SecurityEvent
+		err := rows.Scan(
+			&event.ID, &event.ClientIP, &event.EventType, &event.Method, &event.Path,
+			&event.StatusCode, &event.RequestID, &event.CreatedAt,
+		)
+		if err != nil {
+			r.log.WithError(err).Error("Failed to scan security event row")
+			return nil, fmt.Errorf("failed to scan security event row: %w", err)
+		}
+		events = append(events, &event)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log.WithError(err).Error("Error iterating security event rows")
+		return nil, fmt.Errorf("error iterating security event rows: %w", err)
+	}
+
+	return events, nil
+}
+
+// DeleteBefore deletes the events recorded before a time and returns how many were deleted
+func (r *securityEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM security_events WHERE created_at < $1`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, before)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to delete old security events")
+		return 0, fmt.Errorf("failed to delete old security events: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return deleted, nil
+}
+
+// Block blocks a client IP. A block still in place is extended, never shortened.
+func (r *securityEventRepository) Block(ctx context.Context, block *model.IPBlock) (*model.IPBlock, error) {
+	query := `
+		INSERT INTO ip_blocks (client_ip, reason, event_count, blocked_until)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (client_ip) DO UPDATE SET
+			reason = EXCLUDED.reason,
+			event_count = EXCLUDED.event_count,
+			blocked_until = GREATEST(ip_blocks.blocked_until, EXCLUDED.blocked_until),
+			updated_at = NOW()
+		RETURNING ` + ipBlockColumns
+
+	saved, err := scanIPBlock(executor(ctx, r.db).QueryRowContext(ctx, query,
+		block.ClientIP, block.Reason, block.EventCount, block.BlockedUntil,
+	))
+	if err != nil {
+		r.log.WithError(err).WithField("client_ip", block.ClientIP).Error("Failed to block client IP")
+		return nil, fmt.Errorf("failed to block client IP: %w", err)
+	}
+
+	return saved, nil
+}
+
+// ListActiveBlocks returns the blocks that have not expired at now, latest expiry first
+func (r *securityEventRepository) ListActiveBlocks(ctx context.Context, now time.Time) ([]*model.IPBlock, error) {
+	query := `
+		SELECT ` + ipBlockColumns + `
+		FROM ip_blocks
+		WHERE blocked_until > $1
+		ORDER BY blocked_until DESC`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, now)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to list IP blocks")
+		return nil, fmt.Errorf("failed to list IP blocks: %w", err)
+	}
+	defer rows.Close()
+
+	var blocks []*model.IPBlock
+	for rows.Next() {
+		block, err := scanIPBlock(rows)
+		if err != nil {
+			r.log.WithError(err).Error("Failed to scan IP block row")
+			return nil, fmt.Errorf("failed to scan IP block row: %w", err)
+		}
+		blocks = append(blocks, block)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log.WithError(err).Error("Error iterating IP block rows")
+		return nil, fmt.Errorf("error iterating IP block rows: %w", err)
+	}
+
+	return blocks, nil
+}
+
+// Unblock ends the block of a client IP at now and reports whether one was in place. The
+// row is kept so events before now do not count toward a new block.
+func (r *securityEventRepository) Unblock(ctx context.Context, clientIP string, now time.Time) (bool, error) {
+	query := `
+		UPDATE ip_blocks SET blocked_until = $2, updated_at = NOW()
+		WHERE client_ip = $1 AND blocked_until > $2`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, clientIP, now)
+	if err != nil {
+		r.log.WithError(err).WithField("client_ip", clientIP).Error("Failed to unblock client IP")
+		return false, fmt.Errorf("failed to unblock client IP: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return updated > 0, nil
+}
+
+// scanIPBlock scans a row selected with ipBlockColumns
+func scanIPBlock(row rowScanner) (*model.IPBlock, error) {
+	var block model.IPBlock
+	err := row.Scan(
+		&block.ClientIP, &block.Reason, &block.EventCount, &block.BlockedUntil,
+		&block.CreatedAt, &block.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &block, nil
+}
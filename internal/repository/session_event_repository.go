@@ -0,0 +1,93 @@
+// Package repository provides form session event data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// SessionEventRepository defines the interface for form session event data access
+type SessionEventRepository interface {
+	Create(ctx context.Context, event *model.SessionEvent) error
+	CountFunnel(ctx context.Context, from, to time.Time) ([]*model.SessionFunnelCount, error)
+}
+
+// sessionEventRepository implements SessionEventRepository
+type sessionEventRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewSessionEventRepository creates a new session event repository
+func NewSessionEventRepository(db *sql.DB, log *logger.Logger) SessionEventRepository {
+	return &sessionEventRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// Create records a session event
+func (r *sessionEventRepository) Create(ctx context.Context, event *model.SessionEvent) error {
+	query := `INSERT INTO session_events (session_id, event_type, step) VALUES ($1, $2, $3)`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, event.SessionID, event.EventType, event.Step); err != nil {
+		r.log.WithError(err).
+			WithField("session_id", event.SessionID).
+			WithField("event_type", event.EventType).
+			Error("Failed to record session event")
+		return fmt.Errorf("failed to record session event: %w", err)
+	}
+
+	return nil
+}
+
+// CountFunnel counts, for the sessions created in [from, to), the sessions with each event
+// type and step. A session counts once per event type and step however often it repeated it.
+func (r *sessionEventRepository) CountFunnel(ctx context.Context, from, to time.Time) ([]*model.SessionFunnelCount, error) {
+	query := `
+		WITH started AS (
+			SELECT session_id, MIN(created_at) AS started_at
+			FROM session_events
+			WHERE event_type = 'created' AND created_at >= $1 AND created_at < $2
+			GROUP BY session_id
+		), reached AS (
+			SELECT e.session_id, e.event_type, COALESCE(e.step, '') AS step, MIN(e.created_at) AS reached_at
+			FROM session_events e
+			JOIN started s ON s.session_id = e.session_id
+			GROUP BY e.session_id, e.event_type, COALESCE(e.step, '')
+		)
+		SELECT r.event_type, r.step, COUNT(*),
+			percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM r.reached_at - s.started_at))
+		FROM reached r
+		JOIN started s ON s.session_id = r.session_id
+		GROUP BY r.event_type, r.step`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, from, to)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to count session funnel")
+		return nil, fmt.Errorf("failed to count session funnel: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []*model.SessionFunnelCount
+	for rows.Next() {
+		var count model.SessionFunnelCount
+		if err := rows.Scan(&count.EventType, &count.Step, &count.Sessions, &count.MedianSeconds); err != nil {
+			r.log.WithError(err).Error("Failed to scan session funnel row")
+			return nil, fmt.Errorf("failed to scan session funnel row: %w", err)
+		}
+		counts = append(counts, &count)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log.WithError(err).Error("Error iterating session funnel rows")
+		return nil, fmt.Errorf("error iterating session funnel rows: %w", err)
+	}
+
+	return counts, nil
+}
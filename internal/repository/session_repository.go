@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
 	"github.com/octop162/normal-form-app-by-claude/internal/model"
 	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
 )
@@ -15,10 +16,17 @@ import (
 type SessionRepository interface {
 	Create(ctx context.Context, session *model.UserSession) (*model.UserSession, error)
 	GetByID(ctx context.Context, id string) (*model.UserSession, error)
+	GetByIDForUpdate(ctx context.Context, id string) (*model.UserSession, error)
 	Update(ctx context.Context, session *model.UserSession) (*model.UserSession, error)
+	UpdateWizardState(ctx context.Context, session *model.UserSession) error
 	Delete(ctx context.Context, id string) error
 	DeleteExpired(ctx context.Context) (int64, error)
 	Exists(ctx context.Context, id string) (bool, error)
+	LockOwner(ctx context.Context, key string) error
+	GetActiveIDsByClientIP(ctx context.Context, clientIP string) ([]string, error)
+	GetActiveIDsByEmail(ctx context.Context, email string) ([]string, error)
+	ListByEmail(ctx context.Context, email string) ([]*model.UserSession, error)
+	DeleteByEmail(ctx context.Context, email string) (int64, error)
 }
 
 // sessionRepository implements SessionRepository
@@ -37,20 +45,21 @@ func NewSessionRepository(db *sql.DB, log *logger.Logger) SessionRepository {
 
 // Create creates a new session
 func (r *sessionRepository) Create(ctx context.Context, session *model.UserSession) (*model.UserSession, error) {
-	userDataJSON, err := json.Marshal(session.UserData)
+	userDataJSON, err := encodeSessionForm(session.UserData)
 	if err != nil {
 		r.log.WithError(err).Error("Failed to marshal user data")
 		return nil, fmt.Errorf("failed to marshal user data: %w", err)
 	}
 
 	query := `
-		INSERT INTO user_sessions (id, user_data, expires_at)
-		VALUES ($1, $2, $3)
-		RETURNING created_at, updated_at`
+		INSERT INTO user_sessions (id, user_data, expires_at, client_ip, email)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING version, created_at, updated_at`
 
 	var createdSession model.UserSession
-	err = r.db.QueryRowContext(ctx, query, session.ID, userDataJSON, session.ExpiresAt).
-		Scan(&createdSession.CreatedAt, &createdSession.UpdatedAt)
+	err = executor(ctx, r.db).QueryRowContext(
+		ctx, query, session.ID, userDataJSON, session.ExpiresAt, session.ClientIP, session.Email,
+	).Scan(&createdSession.Version, &createdSession.CreatedAt, &createdSession.UpdatedAt)
 
 	if err != nil {
 		r.log.WithError(err).WithField("session_id", session.ID).Error("Failed to create session")
@@ -60,6 +69,8 @@ func (r *sessionRepository) Create(ctx context.Context, session *model.UserSessi
 	createdSession.ID = session.ID
 	createdSession.UserData = session.UserData
 	createdSession.ExpiresAt = session.ExpiresAt
+	createdSession.ClientIP = session.ClientIP
+	createdSession.Email = session.Email
 
 	r.log.WithField("session_id", createdSession.ID).Info("Session created successfully")
 	return &createdSession, nil
@@ -67,39 +78,54 @@ func (r *sessionRepository) Create(ctx context.Context, session *model.UserSessi
 
 // GetByID retrieves a session by ID
 func (r *sessionRepository) GetByID(ctx context.Context, id string) (*model.UserSession, error) {
+	return r.getByID(ctx, id, "")
+}
+
+// GetByIDForUpdate retrieves a session by ID and locks it until the surrounding transaction ends
+func (r *sessionRepository) GetByIDForUpdate(ctx context.Context, id string) (*model.UserSession, error) {
+	return r.getByID(ctx, id, " FOR UPDATE")
+}
+
+// getByID retrieves an unexpired session by ID, appending lockClause to the query
+func (r *sessionRepository) getByID(ctx context.Context, id, lockClause string) (*model.UserSession, error) {
 	query := `
-		SELECT id, user_data, expires_at, created_at, updated_at
+		SELECT id, user_data, expires_at, client_ip, email, version, wizard_state, created_at, updated_at
 		FROM user_sessions
-		WHERE id = $1 AND expires_at > NOW()`
+		WHERE id = $1 AND expires_at > NOW()` + lockClause
 
 	var session model.UserSession
-	var userDataJSON []byte
+	var userDataJSON, wizardJSON []byte
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&session.ID, &userDataJSON, &session.ExpiresAt,
-		&session.CreatedAt, &session.UpdatedAt,
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
+		&session.ID, &userDataJSON, &session.ExpiresAt, &session.ClientIP, &session.Email,
+		&session.Version, &wizardJSON, &session.CreatedAt, &session.UpdatedAt,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("session not found or expired: %w", err)
+			return nil, apperr.Errorf(apperr.ErrNotFound, "session not found or expired: %w", err)
 		}
 		r.log.WithError(err).WithField("session_id", id).Error("Failed to get session")
 		return nil, fmt.Errorf("failed to get session: %w", err)
 	}
 
 	// Unmarshal user data
-	if err := json.Unmarshal(userDataJSON, &session.UserData); err != nil {
+	if session.UserData, err = decodeSessionForm(userDataJSON); err != nil {
 		r.log.WithError(err).WithField("session_id", id).Error("Failed to unmarshal user data")
 		return nil, fmt.Errorf("failed to unmarshal user data: %w", err)
 	}
 
+	if err := json.Unmarshal(wizardJSON, &session.Wizard); err != nil {
+		r.log.WithError(err).WithField("session_id", id).Error("Failed to unmarshal wizard state")
+		return nil, fmt.Errorf("failed to unmarshal wizard state: %w", err)
+	}
+
 	return &session, nil
 }
 
-// Update updates an existing session
+// Update updates an existing session and increments its version
 func (r *sessionRepository) Update(ctx context.Context, session *model.UserSession) (*model.UserSession, error) {
-	userDataJSON, err := json.Marshal(session.UserData)
+	userDataJSON, err := encodeSessionForm(session.UserData)
 	if err != nil {
 		r.log.WithError(err).Error("Failed to marshal user data")
 		return nil, fmt.Errorf("failed to marshal user data: %w", err)
@@ -109,16 +135,18 @@ func (r *sessionRepository) Update(ctx context.Context, session *model.UserSessi
 		UPDATE user_sessions SET
 			user_data = $2,
 			expires_at = $3,
+			email = $4,
+			version = version + 1,
 			updated_at = NOW()
 		WHERE id = $1 AND expires_at > NOW()
-		RETURNING updated_at`
+		RETURNING version, updated_at`
 
-	err = r.db.QueryRowContext(ctx, query, session.ID, userDataJSON, session.ExpiresAt).
-		Scan(&session.UpdatedAt)
+	err = executor(ctx, r.db).QueryRowContext(ctx, query, session.ID, userDataJSON, session.ExpiresAt, session.Email).
+		Scan(&session.Version, &session.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("session not found or expired")
+			return nil, apperr.Errorf(apperr.ErrNotFound, "session not found or expired")
 		}
 		r.log.WithError(err).WithField("session_id", session.ID).Error("Failed to update session")
 		return nil, fmt.Errorf("failed to update session: %w", err)
@@ -128,11 +156,37 @@ func (r *sessionRepository) Update(ctx context.Context, session *model.UserSessi
 	return session, nil
 }
 
+// UpdateWizardState saves the wizard state of a session without touching its form data
+func (r *sessionRepository) UpdateWizardState(ctx context.Context, session *model.UserSession) error {
+	wizardJSON, err := json.Marshal(session.Wizard)
+	if err != nil {
+		return fmt.Errorf("failed to marshal wizard state: %w", err)
+	}
+
+	query := `
+		UPDATE user_sessions SET
+			wizard_state = $2,
+			updated_at = NOW()
+		WHERE id = $1 AND expires_at > NOW()
+		RETURNING updated_at`
+
+	err = executor(ctx, r.db).QueryRowContext(ctx, query, session.ID, wizardJSON).Scan(&session.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.Errorf(apperr.ErrNotFound, "session not found or expired")
+		}
+		r.log.WithError(err).WithField("session_id", session.ID).Error("Failed to update wizard state")
+		return fmt.Errorf("failed to update wizard state: %w", err)
+	}
+
+	return nil
+}
+
 // Delete deletes a session by ID
 func (r *sessionRepository) Delete(ctx context.Context, id string) error {
 	query := `DELETE FROM user_sessions WHERE id = $1`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
 		r.log.WithError(err).WithField("session_id", id).Error("Failed to delete session")
 		return fmt.Errorf("failed to delete session: %w", err)
@@ -144,7 +198,7 @@ func (r *sessionRepository) Delete(ctx context.Context, id string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("session not found")
+		return apperr.Errorf(apperr.ErrNotFound, "session not found")
 	}
 
 	r.log.WithField("session_id", id).Info("Session deleted successfully")
@@ -186,3 +240,139 @@ func (r *sessionRepository) Exists(ctx context.Context, id string) (bool, error)
 
 	return exists, nil
 }
+
+// LockOwner serializes session creation for an owner key (e.g. a client IP) until the
+// surrounding transaction ends. It must be called inside a transaction.
+func (r *sessionRepository) LockOwner(ctx context.Context, key string) error {
+	if _, err := executor(ctx, r.db).ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, key); err != nil {
+		r.log.WithError(err).Error("Failed to lock session owner")
+		return fmt.Errorf("failed to lock session owner: %w", err)
+	}
+	return nil
+}
+
+// GetActiveIDsByClientIP retrieves the IDs of unexpired sessions created from an IP, oldest first
+func (r *sessionRepository) GetActiveIDsByClientIP(ctx context.Context, clientIP string) ([]string, error) {
+	return r.getActiveIDs(ctx, `client_ip = $1`, clientIP)
+}
+
+// GetActiveIDsByEmail retrieves the IDs of unexpired sessions for an email address, oldest first
+func (r *sessionRepository) GetActiveIDsByEmail(ctx context.Context, email string) ([]string, error) {
+	return r.getActiveIDs(ctx, `email = $1`, email)
+}
+
+// getActiveIDs retrieves the IDs of unexpired sessions matching condition, oldest first
+func (r *sessionRepository) getActiveIDs(ctx context.Context, condition, value string) ([]string, error) {
+	query := `
+		SELECT id FROM user_sessions
+		WHERE ` + condition + ` AND expires_at > NOW()
+		ORDER BY created_at ASC, id ASC`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, value)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to get active sessions")
+		return nil, fmt.Errorf("failed to get active sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan session ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate active sessions: %w", err)
+	}
+
+	return ids, nil
+}
+
+// ListByEmail retrieves every session of an email address, including expired sessions not
+// yet cleaned up, oldest first
+func (r *sessionRepository) ListByEmail(ctx context.Context, email string) ([]*model.UserSession, error) {
+	query := `
+		SELECT id, user_data, expires_at, client_ip, email, version, wizard_state, created_at, updated_at
+		FROM user_sessions
+		WHERE email = $1
+		ORDER BY created_at ASC, id ASC`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, email)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to list sessions by email")
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*model.UserSession
+	for rows.Next() {
+		var session model.UserSession
+		var userDataJSON, wizardJSON []byte
+		err := rows.Scan(
+			&session.ID, &userDataJSON, &session.ExpiresAt, &session.ClientIP, &session.Email,
+			&session.Version, &wizardJSON, &session.CreatedAt, &session.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session row: %w", err)
+		}
+		if session.UserData, err = decodeSessionForm(userDataJSON); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal user data of session %s: %w", session.ID, err)
+		}
+		if err := json.Unmarshal(wizardJSON, &session.Wizard); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal wizard state of session %s: %w", session.ID, err)
+		}
+		sessions = append(sessions, &session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// DeleteByEmail deletes every session of an email address, returning the number deleted
+func (r *sessionRepository) DeleteByEmail(ctx context.Context, email string) (int64, error) {
+	query := `DELETE FROM user_sessions WHERE email = $1`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, email)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to delete sessions by email")
+		return 0, fmt.Errorf("failed to delete sessions: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rowsAffected, nil
+}
+
+// sessionFormRecord is the stored layout of session form data, tagged with its schema version
+type sessionFormRecord struct {
+	SchemaVersion int `json:"schema_version"`
+	model.SessionFormData
+}
+
+// encodeSessionForm encodes form data for the user_data column in the current schema version
+func encodeSessionForm(form model.SessionFormData) ([]byte, error) {
+	return json.Marshal(sessionFormRecord{SchemaVersion: model.SessionFormSchemaVersion, SessionFormData: form})
+}
+
+// decodeSessionForm decodes the user_data column. Members that are not form fields, which
+// sessions saved before the data was typed may hold, are dropped.
+func decodeSessionForm(data []byte) (model.SessionFormData, error) {
+	var record sessionFormRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return model.SessionFormData{}, err
+	}
+	if record.SchemaVersion > model.SessionFormSchemaVersion {
+		return model.SessionFormData{}, fmt.Errorf("schema version %d is newer than %d",
+			record.SchemaVersion, model.SessionFormSchemaVersion)
+	}
+	return record.SessionFormData, nil
+}
@@ -0,0 +1,80 @@
+// Package repository provides transaction management for repositories.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// DBTX is the subset of *sql.DB and *sql.Tx used by repositories
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+}
+
+// txKey is the context key for the active transaction
+type txKey struct{}
+
+// TxManager runs a unit of work inside a single database transaction
+type TxManager interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// txManager implements TxManager
+type txManager struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewTxManager creates a new transaction manager
+func NewTxManager(db *sql.DB, log *logger.Logger) TxManager {
+	return &txManager{
+		db:  db,
+		log: log,
+	}
+}
+
+// WithinTransaction executes fn with a context carrying the transaction.
+// Repositories called with that context join the transaction; nested calls reuse it.
+func (m *txManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := txFromContext(ctx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			m.log.WithError(rollbackErr).Error("Failed to rollback transaction")
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// txFromContext returns the transaction stored in the context, if any
+func txFromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return tx, ok
+}
+
+// executor returns the active transaction or falls back to the database handle
+func executor(ctx context.Context, db *sql.DB) DBTX {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx
+	}
+	return db
+}
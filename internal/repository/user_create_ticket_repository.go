@@ -0,0 +1,315 @@
+// Package repository provides queued user registration data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/fieldcrypt"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// userCreateTicketPayloadColumn names the payload for the field cipher
+const userCreateTicketPayloadColumn = "user_create_ticket_payload"
+
+// userCreateTicketColumns lists the columns scanned by scanUserCreateTicket
+const userCreateTicketColumns = `id, ticket_id, status, session_id, user_id, error_code, error_message,
+		error_details, attempts, last_error, next_attempt_at, completed_at, created_at, updated_at`
+
+// UserCreateTicketRepository defines the interface for queued user registration data access
+type UserCreateTicketRepository interface {
+	Create(ctx context.Context, ticket *model.UserCreateTicket, email string) error
+	GetByTicketID(ctx context.Context, ticketID string) (*model.UserCreateTicket, error)
+	ClaimNext(ctx context.Context, lease time.Duration) (*model.UserCreateTicket, error)
+	CountDue(ctx context.Context) (int, error)
+	MarkCompleted(ctx context.Context, id int64, userID int) error
+	MarkFailed(ctx context.Context, id int64, code, message string, details map[string]string) error
+	Retry(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error)
+	RotateKeyBatch(ctx context.Context, keyID string, afterID int64, limit int) (int64, int, error)
+	CountEncryptedWithKey(ctx context.Context, keyID string) (int, error)
+}
+
+// userCreateTicketRepository implements UserCreateTicketRepository
+type userCreateTicketRepository struct {
+	db     *sql.DB
+	cipher *fieldcrypt.Cipher
+	log    *logger.Logger
+}
+
+// NewUserCreateTicketRepository creates a new user create ticket repository. Payloads hold
+// personal data, so they are encrypted with the cipher of the users table.
+func NewUserCreateTicketRepository(db *sql.DB, cipher *fieldcrypt.Cipher, log *logger.Logger) UserCreateTicketRepository {
+	return &userCreateTicketRepository{
+		db:     db,
+		cipher: cipher,
+		log:    log,
+	}
+}
+
+// Create queues a ticket with its payload. email is the address registered, by which tickets
+// of one address are processed one at a time.
+func (r *userCreateTicketRepository) Create(ctx context.Context, ticket *model.UserCreateTicket, email string) error {
+	if ticket.Payload == nil {
+		return fmt.Errorf("user create ticket %s has no payload", ticket.TicketID)
+	}
+	payload, err := r.cipher.Encrypt(ctx, userCreateTicketPayloadColumn, *ticket.Payload)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt user create ticket payload: %w", err)
+	}
+
+	query := `
+		INSERT INTO user_create_tickets (ticket_id, email_hash, payload, session_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, status, next_attempt_at, created_at, updated_at`
+
+	err = executor(ctx, r.db).QueryRowContext(ctx, query,
+		ticket.TicketID, emailBlindIndex(r.cipher, email), payload, ticket.SessionID,
+	).Scan(&ticket.ID, &ticket.Status, &ticket.NextAttemptAt, &ticket.CreatedAt, &ticket.UpdatedAt)
+	if err != nil {
+		r.log.WithError(err).WithField("ticket_id", ticket.TicketID).Error("Failed to create user create ticket")
+		return fmt.Errorf("failed to create user create ticket: %w", err)
+	}
+
+	return nil
+}
+
+// GetByTicketID returns a ticket without its payload
+func (r *userCreateTicketRepository) GetByTicketID(ctx context.Context, ticketID string) (*model.UserCreateTicket, error) {
+	query := `SELECT ` + userCreateTicketColumns + ` FROM user_create_tickets WHERE ticket_id = $1`
+
+	ticket, err := scanUserCreateTicket(executor(ctx, r.db).QueryRowContext(ctx, query, ticketID))
+	if err == sql.ErrNoRows {
+		return nil, apperr.Errorf(apperr.ErrNotFound, "user create ticket not found: %s", ticketID)
+	}
+	if err != nil {
+		r.log.WithError(err).WithField("ticket_id", ticketID).Error("Failed to get user create ticket")
+		return nil, fmt.Errorf("failed to get user create ticket: %w", err)
+	}
+
+	return ticket, nil
+}
+
+// ClaimNext marks the earliest accepted ticket that is due as processing for lease and returns
+// it with its payload decrypted, or nil when none is due. A ticket waits while an earlier
+// ticket of the same email address is unfinished, so registrations of one address are
+// decided in the order they were accepted. Tickets whose lease expired, because their worker
+// stopped, are claimed again.
+func (r *userCreateTicketRepository) ClaimNext(ctx context.Context, lease time.Duration) (*model.UserCreateTicket, error) {
+	query := `
+		UPDATE user_create_tickets SET
+			status = 'processing', attempts = attempts + 1,
+			locked_until = NOW() + make_interval(secs => $1), updated_at = NOW()
+		WHERE id = (
+			SELECT t.id FROM user_create_tickets t
+			WHERE t.status IN ('queued', 'processing')
+				AND (t.status = 'queued' AND t.next_attempt_at <= NOW() OR t.locked_until < NOW())
+				AND NOT EXISTS (
+					SELECT 1 FROM user_create_tickets e
+					WHERE e.email_hash = t.email_hash AND e.id < t.id AND e.status IN ('queued', 'processing')
+				)
+			ORDER BY t.id
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + userCreateTicketColumns + `, payload`
+
+	var payload sql.NullString
+	ticket, err := scanUserCreateTicket(executor(ctx, r.db).QueryRowContext(ctx, query, lease.Seconds()), &payload)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		r.log.WithError(err).Error("Failed to claim user create ticket")
+		return nil, fmt.Errorf("failed to claim user create ticket: %w", err)
+	}
+
+	if payload.Valid {
+		decrypted, err := r.cipher.Decrypt(ctx, userCreateTicketPayloadColumn, payload.String)
+		if err != nil {
+			r.log.WithError(err).WithField("ticket_id", ticket.TicketID).Error("Failed to decrypt user create ticket")
+			return nil, fmt.Errorf("failed to decrypt user create ticket %s: %w", ticket.TicketID, err)
+		}
+		ticket.Payload = &decrypted
+	}
+
+	return ticket, nil
+}
+
+// MarkCompleted records the user created for a ticket and clears its payload
+func (r *userCreateTicketRepository) MarkCompleted(ctx context.Context, id int64, userID int) error {
+	query := `
+		UPDATE user_create_tickets SET
+			status = 'completed', user_id = $2, payload = NULL, locked_until = NULL,
+			completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1`
+
+	return r.exec(ctx, "mark user create ticket as completed", query, id, userID)
+}
+
+// MarkFailed records the API error of a ticket that will not be retried and clears its payload
+func (r *userCreateTicketRepository) MarkFailed(
+	ctx context.Context, id int64, code, message string, details map[string]string,
+) error {
+	var detailsJSON any
+	if len(details) > 0 {
+		encoded, err := json.Marshal(details)
+		if err != nil {
+			return fmt.Errorf("failed to marshal user create ticket error details: %w", err)
+		}
+		detailsJSON = encoded
+	}
+
+	query := `
+		UPDATE user_create_tickets SET
+			status = 'failed', error_code = $2, error_message = $3, error_details = $4,
+			payload = NULL, locked_until = NULL, completed_at = NOW(), updated_at = NOW()
+		WHERE id = $1`
+
+	return r.exec(ctx, "mark user create ticket as failed", query, id, code, message, detailsJSON)
+}
+
+// Retry queues a ticket again after a failed attempt
+func (r *userCreateTicketRepository) Retry(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time) error {
+	query := `
+		UPDATE user_create_tickets SET
+			status = 'queued', last_error = $2, next_attempt_at = $3, locked_until = NULL, updated_at = NOW()
+		WHERE id = $1`
+
+	return r.exec(ctx, "retry user create ticket", query, id, lastError, nextAttemptAt)
+}
+
+// DeleteFinishedBefore deletes the completed and failed tickets finished before a time and
+// returns how many were deleted
+func (r *userCreateTicketRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM user_create_tickets WHERE status IN ('completed', 'failed') AND completed_at < $1`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, before)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to delete finished user create tickets")
+		return 0, fmt.Errorf("failed to delete finished user create tickets: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	return deleted, nil
+}
+
+// RotateKeyBatch re-encrypts with the active key the payloads of up to limit tickets with IDs
+// above afterID that are encrypted with the key. The rows are locked until the surrounding
+// transaction ends, so a payload cleared meanwhile by a worker is not written back. It
+// returns the last ID rewritten, 0 when no such tickets remain, and the number rewritten.
+func (r *userCreateTicketRepository) RotateKeyBatch(
+	ctx context.Context, keyID string, afterID int64, limit int,
+) (int64, int, error) {
+	query := `
+		SELECT id, payload FROM user_create_tickets
+		WHERE starts_with(payload, $1) AND id > $2
+		ORDER BY id
+		LIMIT $3
+		FOR UPDATE`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, fieldcrypt.KeyPrefix(keyID), afterID, limit)
+	if err != nil {
+		r.log.WithError(err).WithField("key_id", keyID).Error("Failed to list user create tickets to rotate")
+		return 0, 0, fmt.Errorf("failed to list user create tickets to rotate: %w", err)
+	}
+	defer rows.Close()
+
+	payloads := make(map[int64]string)
+	var lastID int64
+	for rows.Next() {
+		var id int64
+		var payload string
+		if err := rows.Scan(&id, &payload); err != nil {
+			r.log.WithError(err).Error("Failed to scan user create ticket row")
+			return 0, 0, fmt.Errorf("failed to scan user create ticket row: %w", err)
+		}
+		payloads[id] = payload
+		lastID = id
+	}
+	if err = rows.Err(); err != nil {
+		r.log.WithError(err).Error("Error iterating user create ticket rows")
+		return 0, 0, fmt.Errorf("error iterating user create ticket rows: %w", err)
+	}
+	rows.Close()
+
+	for id, payload := range payloads {
+		decrypted, err := r.cipher.Decrypt(ctx, userCreateTicketPayloadColumn, payload)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to decrypt user create ticket %d: %w", id, err)
+		}
+		encrypted, err := r.cipher.Encrypt(ctx, userCreateTicketPayloadColumn, decrypted)
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to encrypt user create ticket %d: %w", id, err)
+		}
+		query := `UPDATE user_create_tickets SET payload = $2 WHERE id = $1`
+		if err := r.exec(ctx, "re-encrypt user create ticket", query, id, encrypted); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	return lastID, len(payloads), nil
+}
+
+// CountEncryptedWithKey counts the tickets whose payload is encrypted with the key
+func (r *userCreateTicketRepository) CountEncryptedWithKey(ctx context.Context, keyID string) (int, error) {
+	query := `SELECT COUNT(*) FROM user_create_tickets WHERE starts_with(payload, $1)`
+
+	var count int
+	if err := executor(ctx, r.db).QueryRowContext(ctx, query, fieldcrypt.KeyPrefix(keyID)).Scan(&count); err != nil {
+		r.log.WithError(err).WithField("key_id", keyID).Error("Failed to count user create tickets encrypted with key")
+		return 0, fmt.Errorf("failed to count user create tickets encrypted with key: %w", err)
+	}
+	return count, nil
+}
+
+// CountDue counts the queued tickets that are due, i.e. the backlog waiting for a worker
+func (r *userCreateTicketRepository) CountDue(ctx context.Context) (int, error) {
+	query := `SELECT COUNT(*) FROM user_create_tickets WHERE status = 'queued' AND next_attempt_at <= NOW()`
+
+	var count int
+	if err := executor(ctx, r.db).QueryRowContext(ctx, query).Scan(&count); err != nil {
+		r.log.WithError(err).Error("Failed to count due user create tickets")
+		return 0, fmt.Errorf("failed to count due user create tickets: %w", err)
+	}
+	return count, nil
+}
+
+// exec runs a statement, logging and wrapping its error with the operation
+func (r *userCreateTicketRepository) exec(ctx context.Context, operation, query string, args ...any) error {
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+		r.log.WithError(err).Errorf("Failed to %s", operation)
+		return fmt.Errorf("failed to %s: %w", operation, err)
+	}
+	return nil
+}
+
+// scanUserCreateTicket scans a row selected with userCreateTicketColumns followed by the
+// columns scanned into extra
+func scanUserCreateTicket(row rowScanner, extra ...any) (*model.UserCreateTicket, error) {
+	var ticket model.UserCreateTicket
+	var details []byte
+	dest := append([]any{
+		&ticket.ID, &ticket.TicketID, &ticket.Status, &ticket.SessionID, &ticket.UserID,
+		&ticket.ErrorCode, &ticket.ErrorMessage, &details, &ticket.Attempts, &ticket.LastError,
+		&ticket.NextAttemptAt, &ticket.CompletedAt, &ticket.CreatedAt, &ticket.UpdatedAt,
+	}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	if len(details) > 0 {
+		if err := json.Unmarshal(details, &ticket.ErrorDetails); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal user create ticket error details: %w", err)
+		}
+	}
+	return &ticket, nil
+}
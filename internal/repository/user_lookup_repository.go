@@ -0,0 +1,122 @@
+// Package repository provides self-service lookup data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// UserLookupRepository defines the interface for self-service lookup data access
+type UserLookupRepository interface {
+	Create(ctx context.Context, lookup *model.UserLookup) error
+	GetByIDForUpdate(ctx context.Context, id string) (*model.UserLookup, error)
+	IncrementAttempts(ctx context.Context, id string) error
+	MarkVerified(ctx context.Context, id string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// userLookupRepository implements UserLookupRepository
+type userLookupRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewUserLookupRepository creates a new user lookup repository
+func NewUserLookupRepository(db *sql.DB, log *logger.Logger) UserLookupRepository {
+	return &userLookupRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// Create stores a new pending lookup
+func (r *userLookupRepository) Create(ctx context.Context, lookup *model.UserLookup) error {
+	query := `
+		INSERT INTO user_lookups (id, user_id, code_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at`
+
+	err := executor(ctx, r.db).QueryRowContext(ctx, query,
+		lookup.ID, lookup.UserID, lookup.CodeHash, lookup.ExpiresAt,
+	).Scan(&lookup.CreatedAt)
+
+	if err != nil {
+		r.log.WithError(err).WithField("user_id", lookup.UserID).Error("Failed to create user lookup")
+		return fmt.Errorf("failed to create user lookup: %w", err)
+	}
+
+	return nil
+}
+
+// GetByIDForUpdate retrieves a lookup and locks it until the surrounding transaction ends,
+// so concurrent verification attempts are counted one at a time
+func (r *userLookupRepository) GetByIDForUpdate(ctx context.Context, id string) (*model.UserLookup, error) {
+	query := `
+		SELECT id, user_id, code_hash, attempts, expires_at, verified_at, created_at
+		FROM user_lookups WHERE id = $1
+		FOR UPDATE`
+
+	var lookup model.UserLookup
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
+		&lookup.ID, &lookup.UserID, &lookup.CodeHash, &lookup.Attempts,
+		&lookup.ExpiresAt, &lookup.VerifiedAt, &lookup.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, apperr.Errorf(apperr.ErrNotFound, "user lookup not found: %w", err)
+		}
+		r.log.WithError(err).Error("Failed to get user lookup")
+		return nil, fmt.Errorf("failed to get user lookup: %w", err)
+	}
+
+	return &lookup, nil
+}
+
+// IncrementAttempts records a failed verification attempt
+func (r *userLookupRepository) IncrementAttempts(ctx context.Context, id string) error {
+	query := `UPDATE user_lookups SET attempts = attempts + 1 WHERE id = $1`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+		r.log.WithError(err).Error("Failed to increment user lookup attempts")
+		return fmt.Errorf("failed to increment user lookup attempts: %w", err)
+	}
+
+	return nil
+}
+
+// MarkVerified marks the lookup as used
+func (r *userLookupRepository) MarkVerified(ctx context.Context, id string) error {
+	query := `UPDATE user_lookups SET verified_at = NOW() WHERE id = $1`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+		r.log.WithError(err).Error("Failed to mark user lookup as verified")
+		return fmt.Errorf("failed to mark user lookup as verified: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes lookups whose codes can no longer be used
+func (r *userLookupRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	query := `DELETE FROM user_lookups WHERE expires_at <= $1`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, now)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to delete expired user lookups")
+		return 0, fmt.Errorf("failed to delete expired user lookups: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
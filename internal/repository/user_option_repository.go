@@ -6,6 +6,7 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
 	"github.com/octop162/normal-form-app-by-claude/internal/model"
 	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
 )
@@ -17,6 +18,9 @@ type UserOptionRepository interface {
 	DeleteByUserID(ctx context.Context, userID int) error
 	CreateBatch(ctx context.Context, userOptions []*model.UserOption) error
 	DeleteByUserIDAndOptionType(ctx context.Context, userID int, optionType string) error
+	UpdateDetails(ctx context.Context, userOption *model.UserOption) error
+	ExistsByOptionType(ctx context.Context, optionType string) (bool, error)
+	CountTakeUp(ctx context.Context, planType, prefecture string) (*model.OptionTakeUp, error)
 }
 
 // userOptionRepository implements UserOptionRepository
@@ -36,14 +40,18 @@ func NewUserOptionRepository(db *sql.DB, log *logger.Logger) UserOptionRepositor
 // Create creates a new user option
 func (r *userOptionRepository) Create(ctx context.Context, userOption *model.UserOption) (*model.UserOption, error) {
 	query := `
-		INSERT INTO user_options (user_id, option_type)
-		VALUES ($1, $2)
+		INSERT INTO user_options (user_id, option_type, quantity, start_date)
+		VALUES ($1, $2, $3, $4)
 		RETURNING id, created_at`
 
 	var createdOption model.UserOption
-	err := r.db.QueryRowContext(ctx, query, userOption.UserID, userOption.OptionType).
-		Scan(&createdOption.ID, &createdOption.CreatedAt)
+	err := executor(ctx, r.db).QueryRowContext(ctx, query,
+		userOption.UserID, userOption.OptionType, userOption.Quantity, userOption.StartDate,
+	).Scan(&createdOption.ID, &createdOption.CreatedAt)
 
+	if isUniqueViolation(err) {
+		return nil, apperr.Errorf(apperr.ErrDuplicate, "option %s is already selected", userOption.OptionType)
+	}
 	if err != nil {
 		r.log.WithError(err).
 			WithField("user_id", userOption.UserID).
@@ -54,6 +62,8 @@ func (r *userOptionRepository) Create(ctx context.Context, userOption *model.Use
 
 	createdOption.UserID = userOption.UserID
 	createdOption.OptionType = userOption.OptionType
+	createdOption.Quantity = userOption.Quantity
+	createdOption.StartDate = userOption.StartDate
 
 	r.log.WithField("user_option_id", createdOption.ID).Info("User option created successfully")
 	return &createdOption, nil
@@ -62,12 +72,12 @@ func (r *userOptionRepository) Create(ctx context.Context, userOption *model.Use
 // GetByUserID retrieves all user options by user ID
 func (r *userOptionRepository) GetByUserID(ctx context.Context, userID int) ([]*model.UserOption, error) {
 	query := `
-		SELECT id, user_id, option_type, created_at
+		SELECT id, user_id, option_type, quantity, start_date, created_at
 		FROM user_options
 		WHERE user_id = $1
 		ORDER BY created_at ASC`
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, userID)
 	if err != nil {
 		r.log.WithError(err).WithField("user_id", userID).Error("Failed to get user options")
 		return nil, fmt.Errorf("failed to get user options: %w", err)
@@ -77,7 +87,9 @@ func (r *userOptionRepository) GetByUserID(ctx context.Context, userID int) ([]*
 	var userOptions []*model.UserOption
 	for rows.Next() {
 		var option model.UserOption
-		scanErr := rows.Scan(&option.ID, &option.UserID, &option.OptionType, &option.CreatedAt)
+		scanErr := rows.Scan(
+			&option.ID, &option.UserID, &option.OptionType, &option.Quantity, &option.StartDate, &option.CreatedAt,
+		)
 		if scanErr != nil {
 			r.log.WithError(scanErr).Error("Failed to scan user option row")
 			return nil, fmt.Errorf("failed to scan user option row: %w", scanErr)
@@ -97,7 +109,7 @@ func (r *userOptionRepository) GetByUserID(ctx context.Context, userID int) ([]*
 func (r *userOptionRepository) DeleteByUserID(ctx context.Context, userID int) error {
 	query := `DELETE FROM user_options WHERE user_id = $1`
 
-	result, err := r.db.ExecContext(ctx, query, userID)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, userID)
 	if err != nil {
 		r.log.WithError(err).WithField("user_id", userID).Error("Failed to delete user options")
 		return fmt.Errorf("failed to delete user options: %w", err)
@@ -120,6 +132,11 @@ func (r *userOptionRepository) CreateBatch(ctx context.Context, userOptions []*m
 		return nil
 	}
 
+	// Join the caller's transaction when one is active
+	if tx, ok := txFromContext(ctx); ok {
+		return r.insertBatch(ctx, tx, userOptions)
+	}
+
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -132,7 +149,20 @@ func (r *userOptionRepository) CreateBatch(ctx context.Context, userOptions []*m
 		}
 	}()
 
-	query := `INSERT INTO user_options (user_id, option_type) VALUES ($1, $2)`
+	if err = r.insertBatch(ctx, tx, userOptions); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// insertBatch inserts user options using the given transaction
+func (r *userOptionRepository) insertBatch(ctx context.Context, tx *sql.Tx, userOptions []*model.UserOption) error {
+	query := `INSERT INTO user_options (user_id, option_type, quantity, start_date) VALUES ($1, $2, $3, $4)`
 	stmt, err := tx.PrepareContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
@@ -140,7 +170,10 @@ func (r *userOptionRepository) CreateBatch(ctx context.Context, userOptions []*m
 	defer stmt.Close()
 
 	for _, option := range userOptions {
-		_, err = stmt.ExecContext(ctx, option.UserID, option.OptionType)
+		_, err = stmt.ExecContext(ctx, option.UserID, option.OptionType, option.Quantity, option.StartDate)
+		if isUniqueViolation(err) {
+			return apperr.Errorf(apperr.ErrDuplicate, "option %s is already selected", option.OptionType)
+		}
 		if err != nil {
 			r.log.WithError(err).
 				WithField("user_id", option.UserID).
@@ -150,10 +183,6 @@ func (r *userOptionRepository) CreateBatch(ctx context.Context, userOptions []*m
 		}
 	}
 
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
-	}
-
 	r.log.WithField("batch_size", len(userOptions)).Info("User options batch created successfully")
 	return nil
 }
@@ -162,7 +191,7 @@ func (r *userOptionRepository) CreateBatch(ctx context.Context, userOptions []*m
 func (r *userOptionRepository) DeleteByUserIDAndOptionType(ctx context.Context, userID int, optionType string) error {
 	query := `DELETE FROM user_options WHERE user_id = $1 AND option_type = $2`
 
-	result, err := r.db.ExecContext(ctx, query, userID, optionType)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, userID, optionType)
 	if err != nil {
 		r.log.WithError(err).
 			WithField("user_id", userID).
@@ -177,7 +206,7 @@ func (r *userOptionRepository) DeleteByUserIDAndOptionType(ctx context.Context,
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user option not found")
+		return apperr.Errorf(apperr.ErrNotFound, "user option not found")
 	}
 
 	r.log.WithField("user_id", userID).
@@ -185,3 +214,93 @@ func (r *userOptionRepository) DeleteByUserIDAndOptionType(ctx context.Context,
 		Info("User option deleted successfully")
 	return nil
 }
+
+// UpdateDetails updates the quantity and start date of a selected option
+func (r *userOptionRepository) UpdateDetails(ctx context.Context, userOption *model.UserOption) error {
+	query := `
+		UPDATE user_options
+		SET quantity = $3, start_date = $4
+		WHERE user_id = $1 AND option_type = $2`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query,
+		userOption.UserID, userOption.OptionType, userOption.Quantity, userOption.StartDate,
+	)
+	if err != nil {
+		r.log.WithError(err).
+			WithField("user_id", userOption.UserID).
+			WithField("option_type", userOption.OptionType).
+			Error("Failed to update user option details")
+		return fmt.Errorf("failed to update user option details: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.Errorf(apperr.ErrNotFound, "user option not found")
+	}
+
+	return nil
+}
+
+// ExistsByOptionType checks whether any user has selected the option
+func (r *userOptionRepository) ExistsByOptionType(ctx context.Context, optionType string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM user_options WHERE option_type = $1)`
+
+	var exists bool
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, optionType).Scan(&exists)
+	if err != nil {
+		r.log.WithError(err).WithField("option_type", optionType).Error("Failed to check option usage")
+		return false, fmt.Errorf("failed to check option usage: %w", err)
+	}
+
+	return exists, nil
+}
+
+// CountTakeUp counts the registrations of a plan in a prefecture, or nationwide when
+// prefecture is empty, and how many of them selected each option. Erased users are counted;
+// their plan, options and region are kept for statistics.
+func (r *userOptionRepository) CountTakeUp(ctx context.Context, planType, prefecture string) (*model.OptionTakeUp, error) {
+	query := `
+		SELECT NULL, COUNT(*)
+		FROM users u
+		WHERE u.plan_type = $1 AND ($2 = '' OR u.prefecture = $2)
+		UNION ALL
+		SELECT uo.option_type, COUNT(DISTINCT uo.user_id)
+		FROM user_options uo
+		JOIN users u ON u.id = uo.user_id
+		WHERE u.plan_type = $1 AND ($2 = '' OR u.prefecture = $2)
+		GROUP BY uo.option_type`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, planType, prefecture)
+	if err != nil {
+		r.log.WithError(err).
+			WithField("plan_type", planType).
+			WithField("prefecture", prefecture).
+			Error("Failed to count option take-up")
+		return nil, fmt.Errorf("failed to count option take-up: %w", err)
+	}
+	defer rows.Close()
+
+	takeUp := &model.OptionTakeUp{Options: make(map[string]int64)}
+	for rows.Next() {
+		var optionType sql.NullString
+		var count int64
+		if err := rows.Scan(&optionType, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan option take-up row: %w", err)
+		}
+		if optionType.Valid {
+			takeUp.Options[optionType.String] = count
+		} else {
+			takeUp.Users = count
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate option take-up rows: %w", err)
+	}
+
+	return takeUp, nil
+}
@@ -0,0 +1,86 @@
+// Package repository provides user pricing snapshot data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// UserPricingSnapshotRepository defines the interface for user pricing snapshot data access
+type UserPricingSnapshotRepository interface {
+	Create(ctx context.Context, snapshot *model.UserPricingSnapshot) error
+	GetByUserID(ctx context.Context, userID int) (*model.UserPricingSnapshot, error)
+}
+
+// userPricingSnapshotRepository implements UserPricingSnapshotRepository
+type userPricingSnapshotRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewUserPricingSnapshotRepository creates a new user pricing snapshot repository
+func NewUserPricingSnapshotRepository(db *sql.DB, log *logger.Logger) UserPricingSnapshotRepository {
+	return &userPricingSnapshotRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// Create stores the pricing snapshot of a user. Call it with the transactional context that
+// creates the user, so a user is never registered without the prices it agreed to.
+func (r *userPricingSnapshotRepository) Create(ctx context.Context, snapshot *model.UserPricingSnapshot) error {
+	lines, err := json.Marshal(snapshot.Lines)
+	if err != nil {
+		return fmt.Errorf("failed to encode pricing lines: %w", err)
+	}
+
+	query := `
+		INSERT INTO user_pricing_snapshots (user_id, plan_type, lines, subtotal, tax_rate, tax, total, priced_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at`
+
+	err = executor(ctx, r.db).QueryRowContext(ctx, query,
+		snapshot.UserID, snapshot.PlanType, lines, snapshot.Subtotal, snapshot.TaxRate,
+		snapshot.Tax, snapshot.Total, snapshot.PricedAt,
+	).Scan(&snapshot.CreatedAt)
+	if err != nil {
+		r.log.WithError(err).WithField("user_id", snapshot.UserID).Error("Failed to create user pricing snapshot")
+		return fmt.Errorf("failed to create user pricing snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// GetByUserID retrieves the pricing snapshot of a user, or nil for users registered before
+// snapshots were recorded
+func (r *userPricingSnapshotRepository) GetByUserID(ctx context.Context, userID int) (*model.UserPricingSnapshot, error) {
+	query := `
+		SELECT user_id, plan_type, lines, subtotal, tax_rate, tax, total, priced_at, created_at
+		FROM user_pricing_snapshots
+		WHERE user_id = $1`
+
+	var snapshot model.UserPricingSnapshot
+	var lines []byte
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, userID).Scan(
+		&snapshot.UserID, &snapshot.PlanType, &lines, &snapshot.Subtotal, &snapshot.TaxRate,
+		&snapshot.Tax, &snapshot.Total, &snapshot.PricedAt, &snapshot.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		r.log.WithError(err).WithField("user_id", userID).Error("Failed to get user pricing snapshot")
+		return nil, fmt.Errorf("failed to get user pricing snapshot: %w", err)
+	}
+
+	if err := json.Unmarshal(lines, &snapshot.Lines); err != nil {
+		return nil, fmt.Errorf("failed to decode pricing lines of user %d: %w", userID, err)
+	}
+
+	return &snapshot, nil
+}
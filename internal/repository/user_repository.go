@@ -5,33 +5,64 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
 	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/fieldcrypt"
 	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/masking"
 )
 
+// nameKanaCandidateLimit bounds the users listed by kana name alone
+const nameKanaCandidateLimit = 200
+
 // UserRepository defines the interface for user data access
 type UserRepository interface {
 	Create(ctx context.Context, user *model.User) (*model.User, error)
 	GetByID(ctx context.Context, id int) (*model.User, error)
+	GetByIDForUpdate(ctx context.Context, id int) (*model.User, error)
 	GetByEmail(ctx context.Context, email string) (*model.User, error)
 	Update(ctx context.Context, user *model.User) (*model.User, error)
+	UpdateColumns(ctx context.Context, user *model.User, columns []string) (*model.User, error)
 	Delete(ctx context.Context, id int) error
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	ExistsByPhone(ctx context.Context, phone string) (bool, error)
+	ListByPhone(ctx context.Context, phone string) ([]*model.User, error)
+	MarkPhoneVerified(ctx context.Context, phone string) (int64, error)
+	SetEmailDeliverability(ctx context.Context, email, status, reason string) (int64, error)
+	IsEmailSuppressed(ctx context.Context, email string) (bool, error)
+	ListByNameKana(ctx context.Context, lastNameKana, firstNameKana string) ([]*model.User, error)
+	ListByNameKanaAndPostalCode(
+		ctx context.Context, lastNameKana, firstNameKana, postalCode1, postalCode2 string,
+	) ([]*model.User, error)
+	GetStatusForUpdate(ctx context.Context, id int) (string, error)
+	UpdateStatus(ctx context.Context, id int, status string) error
+	SetLegalHold(ctx context.Context, id int, enabled bool) error
+	Anonymize(ctx context.Context, id int) (time.Time, error)
 	List(ctx context.Context, limit, offset int) ([]*model.User, error)
+	ReencryptBatch(ctx context.Context, afterID, limit int) (int, int, error)
+	RotateKeyBatch(ctx context.Context, keyID string, afterID, limit int) (int, int, error)
+	CountEncryptedWithKey(ctx context.Context, keyID string) (int, error)
 }
 
-// userRepository implements UserRepository
+// userRepository implements UserRepository. Phone, email and street address are encrypted
+// with cipher on write and decrypted on read; email and phone are searched by blind index.
 type userRepository struct {
-	db  *sql.DB
-	log *logger.Logger
+	db     *sql.DB
+	cipher *fieldcrypt.Cipher
+	log    *logger.Logger
 }
 
 // NewUserRepository creates a new user repository
-func NewUserRepository(db *sql.DB, log *logger.Logger) UserRepository {
+func NewUserRepository(db *sql.DB, cipher *fieldcrypt.Cipher, log *logger.Logger) UserRepository {
 	return &userRepository{
-		db:  db,
-		log: log,
+		db:     db,
+		cipher: cipher,
+		log:    log,
 	}
 }
 
@@ -42,20 +73,33 @@ func (r *userRepository) Create(ctx context.Context, user *model.User) (*model.U
 			last_name, first_name, last_name_kana, first_name_kana,
 			phone1, phone2, phone3, postal_code1, postal_code2,
 			prefecture, city, town, chome, banchi, go, building, room,
-			email, plan_type
+			email, plan_type, phone_verified, email_hash, phone_hash, locale
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19
-		) RETURNING id, created_at, updated_at`
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
+			$21, $22, $23
+		) RETURNING id, status, legal_hold, email_deliverability, version, created_at, updated_at`
+
+	sealed, err := r.encryptUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
 
 	var createdUser model.User
-	err := r.db.QueryRowContext(ctx, query,
-		user.LastName, user.FirstName, user.LastNameKana, user.FirstNameKana,
-		user.Phone1, user.Phone2, user.Phone3, user.PostalCode1, user.PostalCode2,
-		user.Prefecture, user.City, user.Town, user.Chome, user.Banchi,
-		user.Go, user.Building, user.Room, user.Email, user.PlanType,
-	).Scan(&createdUser.ID, &createdUser.CreatedAt, &createdUser.UpdatedAt)
+	err = executor(ctx, r.db).QueryRowContext(ctx, query,
+		sealed.LastName, sealed.FirstName, sealed.LastNameKana, sealed.FirstNameKana,
+		sealed.Phone1, sealed.Phone2, sealed.Phone3, sealed.PostalCode1, sealed.PostalCode2,
+		sealed.Prefecture, sealed.City, sealed.Town, sealed.Chome, sealed.Banchi,
+		sealed.Go, sealed.Building, sealed.Room, sealed.Email, sealed.PlanType, sealed.PhoneVerified,
+		r.emailIndex(user.Email), r.phoneIndex(userPhone(user)), user.Locale,
+	).Scan(
+		&createdUser.ID, &createdUser.Status, &createdUser.LegalHold, &createdUser.EmailDeliverability,
+		&createdUser.Version, &createdUser.CreatedAt, &createdUser.UpdatedAt,
+	)
 
 	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, apperr.Errorf(apperr.ErrDuplicate, "user with email %s already exists", user.Email)
+		}
 		r.log.WithError(err).Error("Failed to create user")
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
@@ -68,6 +112,7 @@ func (r *userRepository) Create(ctx context.Context, user *model.User) (*model.U
 	createdUser.Phone1 = user.Phone1
 	createdUser.Phone2 = user.Phone2
 	createdUser.Phone3 = user.Phone3
+	createdUser.PhoneVerified = user.PhoneVerified
 	createdUser.PostalCode1 = user.PostalCode1
 	createdUser.PostalCode2 = user.PostalCode2
 	createdUser.Prefecture = user.Prefecture
@@ -80,6 +125,7 @@ func (r *userRepository) Create(ctx context.Context, user *model.User) (*model.U
 	createdUser.Room = user.Room
 	createdUser.Email = user.Email
 	createdUser.PlanType = user.PlanType
+	createdUser.Locale = user.Locale
 
 	r.log.WithField("user_id", createdUser.ID).Info("User created successfully")
 	return &createdUser, nil
@@ -91,7 +137,9 @@ func (r *userRepository) GetByID(ctx context.Context, id int) (*model.User, erro
 		SELECT id, last_name, first_name, last_name_kana, first_name_kana,
 			   phone1, phone2, phone3, postal_code1, postal_code2,
 			   prefecture, city, town, chome, banchi, go, building, room,
-			   email, plan_type, created_at, updated_at
+			   email, plan_type, locale, status, legal_hold, phone_verified,
+			   email_deliverability, email_deliverability_reason, email_deliverability_updated_at,
+			   erased_at, version, created_at, updated_at
 		FROM users WHERE id = $1`
 
 	user, err := r.scanSingleUser(ctx, query, id)
@@ -103,18 +151,41 @@ func (r *userRepository) GetByID(ctx context.Context, id int) (*model.User, erro
 	return user, nil
 }
 
-// GetByEmail retrieves a user by email
+// GetByIDForUpdate retrieves a user by ID and locks the row until the surrounding transaction ends
+func (r *userRepository) GetByIDForUpdate(ctx context.Context, id int) (*model.User, error) {
+	query := `
+		SELECT id, last_name, first_name, last_name_kana, first_name_kana,
+			   phone1, phone2, phone3, postal_code1, postal_code2,
+			   prefecture, city, town, chome, banchi, go, building, room,
+			   email, plan_type, locale, status, legal_hold, phone_verified,
+			   email_deliverability, email_deliverability_reason, email_deliverability_updated_at,
+			   erased_at, version, created_at, updated_at
+		FROM users WHERE id = $1
+		FOR UPDATE`
+
+	user, err := r.scanSingleUser(ctx, query, id)
+	if err != nil {
+		r.log.WithError(err).WithField("user_id", id).Error("Failed to get user by ID for update")
+		return nil, fmt.Errorf("failed to get user by ID: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetByEmail retrieves a user by email, compared case-insensitively
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
 	query := `
 		SELECT id, last_name, first_name, last_name_kana, first_name_kana,
 			   phone1, phone2, phone3, postal_code1, postal_code2,
 			   prefecture, city, town, chome, banchi, go, building, room,
-			   email, plan_type, created_at, updated_at
-		FROM users WHERE email = $1`
+			   email, plan_type, locale, status, legal_hold, phone_verified,
+			   email_deliverability, email_deliverability_reason, email_deliverability_updated_at,
+			   erased_at, version, created_at, updated_at
+		FROM users WHERE ` + indexedMatch("email_hash", "$1", "LOWER(email)", "LOWER($2)")
 
-	user, err := r.scanSingleUser(ctx, query, email)
+	user, err := r.scanSingleUser(ctx, query, r.emailIndex(email), email)
 	if err != nil {
-		r.log.WithError(err).WithField("email", email).Error("Failed to get user by email")
+		r.log.WithError(err).WithField("email", masking.Apply(masking.RuleEmail, email)).Error("Failed to get user by email")
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
 	}
 
@@ -122,27 +193,34 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*model.U
 }
 
 // scanSingleUser scans a single user from query result
-func (r *userRepository) scanSingleUser(ctx context.Context, query string, arg any) (*model.User, error) {
+func (r *userRepository) scanSingleUser(ctx context.Context, query string, args ...any) (*model.User, error) {
 	var user model.User
-	err := r.db.QueryRowContext(ctx, query, arg).Scan(
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, args...).Scan(
 		&user.ID, &user.LastName, &user.FirstName, &user.LastNameKana, &user.FirstNameKana,
 		&user.Phone1, &user.Phone2, &user.Phone3, &user.PostalCode1, &user.PostalCode2,
 		&user.Prefecture, &user.City, &user.Town, &user.Chome, &user.Banchi,
-		&user.Go, &user.Building, &user.Room, &user.Email, &user.PlanType,
-		&user.CreatedAt, &user.UpdatedAt,
+		&user.Go, &user.Building, &user.Room, &user.Email, &user.PlanType, &user.Locale,
+		&user.Status, &user.LegalHold, &user.PhoneVerified,
+		&user.EmailDeliverability, &user.EmailDeliverabilityReason, &user.EmailDeliverabilityUpdatedAt,
+		&user.ErasedAt, &user.Version, &user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found: %w", err)
+			return nil, apperr.Errorf(apperr.ErrNotFound, "user not found: %w", err)
 		}
 		return nil, err
 	}
 
+	if err := r.decryptUser(ctx, &user); err != nil {
+		return nil, err
+	}
 	return &user, nil
 }
 
-// Update updates an existing user
+// Update updates an existing user. user.Version is the version the update is based on: if
+// the stored user is at another version, the update fails with an
+// *apperr.VersionConflictError.
 func (r *userRepository) Update(ctx context.Context, user *model.User) (*model.User, error) {
 	query := `
 		UPDATE users SET
@@ -150,18 +228,38 @@ func (r *userRepository) Update(ctx context.Context, user *model.User) (*model.U
 			phone1 = $6, phone2 = $7, phone3 = $8, postal_code1 = $9, postal_code2 = $10,
 			prefecture = $11, city = $12, town = $13, chome = $14, banchi = $15,
 			go = $16, building = $17, room = $18, email = $19, plan_type = $20,
-			updated_at = NOW()
-		WHERE id = $1
-		RETURNING updated_at`
+			` + phoneVerifiedAssignment("$21", "$22") + `,
+			` + emailDeliverabilityResetAssignments("$23", "$24") + `,
+			phone_hash = $21, email_hash = $23,
+			version = version + 1, updated_at = NOW()
+		WHERE id = $1 AND version = $25
+		RETURNING phone_verified, email_deliverability, email_deliverability_reason,
+			email_deliverability_updated_at, version, updated_at`
+
+	sealed, err := r.encryptUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
 
-	err := r.db.QueryRowContext(ctx, query,
-		user.ID, user.LastName, user.FirstName, user.LastNameKana, user.FirstNameKana,
-		user.Phone1, user.Phone2, user.Phone3, user.PostalCode1, user.PostalCode2,
-		user.Prefecture, user.City, user.Town, user.Chome, user.Banchi,
-		user.Go, user.Building, user.Room, user.Email, user.PlanType,
-	).Scan(&user.UpdatedAt)
+	err = executor(ctx, r.db).QueryRowContext(ctx, query,
+		user.ID, sealed.LastName, sealed.FirstName, sealed.LastNameKana, sealed.FirstNameKana,
+		sealed.Phone1, sealed.Phone2, sealed.Phone3, sealed.PostalCode1, sealed.PostalCode2,
+		sealed.Prefecture, sealed.City, sealed.Town, sealed.Chome, sealed.Banchi,
+		sealed.Go, sealed.Building, sealed.Room, sealed.Email, sealed.PlanType,
+		r.phoneIndex(userPhone(user)), userPhone(user), r.emailIndex(user.Email), user.Email,
+		user.Version,
+	).Scan(
+		&user.PhoneVerified, &user.EmailDeliverability, &user.EmailDeliverabilityReason,
+		&user.EmailDeliverabilityUpdatedAt, &user.Version, &user.UpdatedAt,
+	)
 
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, r.versionConflict(ctx, user)
+		}
+		if isUniqueViolation(err) {
+			return nil, apperr.Errorf(apperr.ErrDuplicate, "user with email %s already exists", user.Email)
+		}
 		r.log.WithError(err).WithField("user_id", user.ID).Error("Failed to update user")
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
@@ -170,11 +268,140 @@ func (r *userRepository) Update(ctx context.Context, user *model.User) (*model.U
 	return user, nil
 }
 
+// UpdateColumns writes only the given editable columns of user, leaving the others untouched.
+// user must hold the whole stored phone number, as its blind index covers all three parts.
+// Like Update, it fails with an *apperr.VersionConflictError unless the stored user is at
+// user.Version.
+func (r *userRepository) UpdateColumns(ctx context.Context, user *model.User, columns []string) (*model.User, error) {
+	sealed, err := r.encryptUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	values := editableUserColumns(sealed)
+
+	sorted := slices.Sorted(slices.Values(columns))
+	sorted = slices.Compact(sorted)
+
+	assignments := make([]string, 0, len(sorted)+1)
+	args := []any{user.ID}
+	for _, column := range sorted {
+		value, ok := values[column]
+		if !ok {
+			return nil, fmt.Errorf("column %s cannot be updated", column)
+		}
+		args = append(args, value)
+		assignments = append(assignments, column+" = $"+strconv.Itoa(len(args)))
+	}
+	if len(assignments) == 0 {
+		return nil, fmt.Errorf("no columns to update")
+	}
+	if slices.ContainsFunc(sorted, func(column string) bool { return slices.Contains(phoneColumns, column) }) {
+		args = append(args, r.phoneIndex(userPhone(user)), userPhone(user))
+		hash, phone := "$"+strconv.Itoa(len(args)-1), "$"+strconv.Itoa(len(args))
+		assignments = append(assignments, phoneVerifiedAssignment(hash, phone), "phone_hash = "+hash)
+	}
+	if slices.Contains(sorted, "email") {
+		args = append(args, r.emailIndex(user.Email), user.Email)
+		hash, email := "$"+strconv.Itoa(len(args)-1), "$"+strconv.Itoa(len(args))
+		assignments = append(assignments, emailDeliverabilityResetAssignments(hash, email), "email_hash = "+hash)
+	}
+	assignments = append(assignments, "version = version + 1", "updated_at = NOW()")
+	args = append(args, user.Version)
+
+	query := `UPDATE users SET ` + strings.Join(assignments, ", ") +
+		` WHERE id = $1 AND version = $` + strconv.Itoa(len(args)) + `
+		RETURNING phone_verified, email_deliverability, email_deliverability_reason,
+			email_deliverability_updated_at, version, updated_at`
+
+	err = executor(ctx, r.db).QueryRowContext(ctx, query, args...).Scan(
+		&user.PhoneVerified, &user.EmailDeliverability, &user.EmailDeliverabilityReason,
+		&user.EmailDeliverabilityUpdatedAt, &user.Version, &user.UpdatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, r.versionConflict(ctx, user)
+		}
+		if isUniqueViolation(err) {
+			return nil, apperr.Errorf(apperr.ErrDuplicate, "user with email %s already exists", user.Email)
+		}
+		r.log.WithError(err).WithField("user_id", user.ID).Error("Failed to update user columns")
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	r.log.WithField("user_id", user.ID).WithField("columns", sorted).Info("User updated successfully")
+	return user, nil
+}
+
+// versionConflict explains why an update of user matched no row: the user was deleted, or
+// it is at another version than the update was based on
+func (r *userRepository) versionConflict(ctx context.Context, user *model.User) error {
+	var current int
+	err := executor(ctx, r.db).QueryRowContext(ctx, `SELECT version FROM users WHERE id = $1`, user.ID).Scan(&current)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return apperr.Errorf(apperr.ErrNotFound, "user not found: %w", err)
+		}
+		return fmt.Errorf("failed to get user version: %w", err)
+	}
+	return &apperr.VersionConflictError{
+		Resource:        "user",
+		ID:              strconv.Itoa(user.ID),
+		ExpectedVersion: user.Version,
+		CurrentVersion:  current,
+	}
+}
+
+// phoneColumns are the columns holding the parts of the phone number
+var phoneColumns = []string{"phone1", "phone2", "phone3"}
+
+// phoneVerifiedAssignment returns the assignment clearing phone_verified when an update
+// changes the phone number to the one with the blind index and digits in the placeholders
+func phoneVerifiedAssignment(hashPlaceholder, phonePlaceholder string) string {
+	return "phone_verified = phone_verified AND COALESCE(" +
+		indexedMatch("phone_hash", hashPlaceholder, "(phone1 || phone2 || phone3)", phonePlaceholder) + ", FALSE)"
+}
+
+// emailDeliverabilityResetAssignments returns the assignments resetting the email
+// deliverability when an update changes the address to the one with the blind index and
+// address in the placeholders; the reported status belongs to the previous address
+func emailDeliverabilityResetAssignments(hashPlaceholder, emailPlaceholder string) string {
+	changed := "NOT COALESCE(" +
+		indexedMatch("email_hash", hashPlaceholder, "LOWER(email)", "LOWER("+emailPlaceholder+")") + ", FALSE)"
+	return "email_deliverability = CASE WHEN " + changed + " THEN 'deliverable' ELSE email_deliverability END, " +
+		"email_deliverability_reason = CASE WHEN " + changed + " THEN NULL ELSE email_deliverability_reason END, " +
+		"email_deliverability_updated_at = CASE WHEN " + changed + " THEN NULL ELSE email_deliverability_updated_at END"
+}
+
+// editableUserColumns maps the columns a user update may write to their values
+func editableUserColumns(user *model.User) map[string]any {
+	return map[string]any{
+		"last_name":       user.LastName,
+		"first_name":      user.FirstName,
+		"last_name_kana":  user.LastNameKana,
+		"first_name_kana": user.FirstNameKana,
+		"phone1":          user.Phone1,
+		"phone2":          user.Phone2,
+		"phone3":          user.Phone3,
+		"postal_code1":    user.PostalCode1,
+		"postal_code2":    user.PostalCode2,
+		"prefecture":      user.Prefecture,
+		"city":            user.City,
+		"town":            user.Town,
+		"chome":           user.Chome,
+		"banchi":          user.Banchi,
+		"go":              user.Go,
+		"building":        user.Building,
+		"room":            user.Room,
+		"email":           user.Email,
+		"plan_type":       user.PlanType,
+	}
+}
+
 // Delete deletes a user by ID
 func (r *userRepository) Delete(ctx context.Context, id int) error {
 	query := `DELETE FROM users WHERE id = $1`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, id)
 	if err != nil {
 		r.log.WithError(err).WithField("user_id", id).Error("Failed to delete user")
 		return fmt.Errorf("failed to delete user: %w", err)
@@ -186,39 +413,281 @@ func (r *userRepository) Delete(ctx context.Context, id int) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
+		return apperr.Errorf(apperr.ErrNotFound, "user not found")
 	}
 
 	r.log.WithField("user_id", id).Info("User deleted successfully")
 	return nil
 }
 
-// ExistsByEmail checks if a user exists by email
+// GetStatusForUpdate returns the user's status and locks the row until the surrounding
+// transaction ends, so concurrent status changes are applied one at a time
+func (r *userRepository) GetStatusForUpdate(ctx context.Context, id int) (string, error) {
+	query := `SELECT status FROM users WHERE id = $1 FOR UPDATE`
+
+	var status string
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, id).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", apperr.Errorf(apperr.ErrNotFound, "user not found: %w", err)
+		}
+		r.log.WithError(err).WithField("user_id", id).Error("Failed to get user status")
+		return "", fmt.Errorf("failed to get user status: %w", err)
+	}
+
+	return status, nil
+}
+
+// UpdateStatus sets the user's account status
+func (r *userRepository) UpdateStatus(ctx context.Context, id int, status string) error {
+	query := `UPDATE users SET status = $2, version = version + 1, updated_at = NOW() WHERE id = $1`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, id, status)
+	if err != nil {
+		r.log.WithError(err).WithField("user_id", id).Error("Failed to update user status")
+		return fmt.Errorf("failed to update user status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.Errorf(apperr.ErrNotFound, "user not found")
+	}
+
+	return nil
+}
+
+// SetLegalHold places or releases a legal hold on the user
+func (r *userRepository) SetLegalHold(ctx context.Context, id int, enabled bool) error {
+	query := `UPDATE users SET legal_hold = $2, version = version + 1, updated_at = NOW() WHERE id = $1`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, id, enabled)
+	if err != nil {
+		r.log.WithError(err).WithField("user_id", id).Error("Failed to set legal hold")
+		return fmt.Errorf("failed to set legal hold: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return apperr.Errorf(apperr.ErrNotFound, "user not found")
+	}
+
+	return nil
+}
+
+// Anonymize replaces the personal data of the user with placeholders and marks it erased.
+// The plan, the prefecture, city and first half of the postal code and the registration date
+// are kept for aggregate statistics. The email placeholder is unique per user. Placeholders
+// are not personal data, so they are stored in plaintext without blind indexes.
+func (r *userRepository) Anonymize(ctx context.Context, id int) (time.Time, error) {
+	query := `
+		UPDATE users SET
+			last_name = '削除済み', first_name = '削除済み',
+			last_name_kana = 'サクジョズミ', first_name_kana = 'サクジョズミ',
+			phone1 = '000', phone2 = '0000', phone3 = '0000', phone_verified = FALSE,
+			postal_code2 = '0000', town = NULL, chome = NULL, banchi = '-', go = NULL,
+			building = NULL, room = NULL,
+			email = 'erased-' || id || '@invalid', email_hash = NULL, phone_hash = NULL,
+			email_deliverability = 'deliverable', email_deliverability_reason = NULL,
+			email_deliverability_updated_at = NULL,
+			erased_at = NOW(), version = version + 1, updated_at = NOW()
+		WHERE id = $1
+		RETURNING erased_at`
+
+	var erasedAt time.Time
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, id).Scan(&erasedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, apperr.Errorf(apperr.ErrNotFound, "user not found: %w", err)
+		}
+		r.log.WithError(err).WithField("user_id", id).Error("Failed to anonymize user")
+		return time.Time{}, fmt.Errorf("failed to anonymize user: %w", err)
+	}
+
+	r.log.WithField("user_id", id).Info("User anonymized successfully")
+	return erasedAt, nil
+}
+
+// ExistsByEmail checks if a user exists by email, compared case-insensitively
 func (r *userRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
-	query := `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE ` +
+		indexedMatch("email_hash", "$1", "LOWER(email)", "LOWER($2)") + `)`
 
 	var exists bool
-	err := r.db.QueryRowContext(ctx, query, email).Scan(&exists)
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, r.emailIndex(email), email).Scan(&exists)
 	if err != nil {
-		r.log.WithError(err).WithField("email", email).Error("Failed to check user existence")
+		r.log.WithError(err).WithField("email", masking.Apply(masking.RuleEmail, email)).Error("Failed to check user existence")
 		return false, fmt.Errorf("failed to check user existence: %w", err)
 	}
 
 	return exists, nil
 }
 
+// ExistsByPhone checks if a user has the phone number, compared as the concatenated
+// digits so that the same number split differently into parts still matches
+func (r *userRepository) ExistsByPhone(ctx context.Context, phone string) (bool, error) {
+	query := `SELECT EXISTS(SELECT 1 FROM users WHERE ` +
+		indexedMatch("phone_hash", "$1", "(phone1 || phone2 || phone3)", "$2") + `)`
+
+	var exists bool
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, r.phoneIndex(phone), phone).Scan(&exists)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to check user existence by phone")
+		return false, fmt.Errorf("failed to check user existence by phone: %w", err)
+	}
+
+	return exists, nil
+}
+
+// ListByPhone retrieves the users with the phone number, compared like ExistsByPhone
+func (r *userRepository) ListByPhone(ctx context.Context, phone string) ([]*model.User, error) {
+	query := `
+		SELECT id, last_name, first_name, last_name_kana, first_name_kana,
+			   phone1, phone2, phone3, postal_code1, postal_code2,
+			   prefecture, city, town, chome, banchi, go, building, room,
+			   email, plan_type, locale, status, legal_hold, phone_verified,
+			   email_deliverability, email_deliverability_reason, email_deliverability_updated_at,
+			   erased_at, version, created_at, updated_at
+		FROM users
+		WHERE ` + indexedMatch("phone_hash", "$1", "(phone1 || phone2 || phone3)", "$2") + `
+		ORDER BY id`
+
+	return r.queryUsers(ctx, query, r.phoneIndex(phone), phone)
+}
+
+// MarkPhoneVerified flags the users registered with a phone number as verified and returns
+// how many were updated
+func (r *userRepository) MarkPhoneVerified(ctx context.Context, phone string) (int64, error) {
+	query := `
+		UPDATE users SET phone_verified = TRUE, version = version + 1, updated_at = NOW()
+		WHERE ` + indexedMatch("phone_hash", "$1", "(phone1 || phone2 || phone3)", "$2") + `
+		  AND NOT phone_verified`
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, r.phoneIndex(phone), phone)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to mark phone as verified")
+		return 0, fmt.Errorf("failed to mark phone as verified: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return updated, nil
+}
+
+// SetEmailDeliverability records a mail provider report on the users registered with an
+// email address, compared case-insensitively, and returns how many were updated. A
+// complaint is kept when the address bounces later.
+func (r *userRepository) SetEmailDeliverability(ctx context.Context, email, status, reason string) (int64, error) {
+	query := `
+		UPDATE users SET
+			email_deliverability = CASE WHEN email_deliverability = 'complained'
+				THEN email_deliverability ELSE $2::VARCHAR END,
+			email_deliverability_reason = CASE WHEN email_deliverability = 'complained' AND $2::VARCHAR <> 'complained'
+				THEN email_deliverability_reason ELSE $3::VARCHAR END,
+			email_deliverability_updated_at = NOW(),
+			version = version + 1, updated_at = NOW()
+		WHERE ` + indexedMatch("email_hash", "$1", "LOWER(email)", "LOWER($4)")
+
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, r.emailIndex(email), status, reason, email)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to set email deliverability")
+		return 0, fmt.Errorf("failed to set email deliverability: %w", err)
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return updated, nil
+}
+
+// IsEmailSuppressed reports whether email is not sent to an address because it bounced or a
+// user registered with it complained
+func (r *userRepository) IsEmailSuppressed(ctx context.Context, email string) (bool, error) {
+	query := `
+		SELECT EXISTS(
+			SELECT 1 FROM users
+			WHERE ` + indexedMatch("email_hash", "$1", "LOWER(email)", "LOWER($2)") + `
+			  AND email_deliverability IN ('bounced', 'complained')
+		)`
+
+	var suppressed bool
+	err := executor(ctx, r.db).QueryRowContext(ctx, query, r.emailIndex(email), email).Scan(&suppressed)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to check email suppression")
+		return false, fmt.Errorf("failed to check email suppression: %w", err)
+	}
+
+	return suppressed, nil
+}
+
+// ListByNameKanaAndPostalCode retrieves the users with a kana name living in a postal code area
+func (r *userRepository) ListByNameKanaAndPostalCode(
+	ctx context.Context, lastNameKana, firstNameKana, postalCode1, postalCode2 string,
+) ([]*model.User, error) {
+	query := `
+		SELECT id, last_name, first_name, last_name_kana, first_name_kana,
+			   phone1, phone2, phone3, postal_code1, postal_code2,
+			   prefecture, city, town, chome, banchi, go, building, room,
+			   email, plan_type, locale, status, legal_hold, phone_verified,
+			   email_deliverability, email_deliverability_reason, email_deliverability_updated_at,
+			   erased_at, version, created_at, updated_at
+		FROM users
+		WHERE postal_code1 = $1 AND postal_code2 = $2
+		  AND last_name_kana = $3 AND first_name_kana = $4
+		ORDER BY id`
+
+	return r.queryUsers(ctx, query, postalCode1, postalCode2, lastNameKana, firstNameKana)
+}
+
+// ListByNameKana retrieves the most recent users with the kana name, at most
+// nameKanaCandidateLimit of them as common names are shared by many users
+func (r *userRepository) ListByNameKana(ctx context.Context, lastNameKana, firstNameKana string) ([]*model.User, error) {
+	query := `
+		SELECT id, last_name, first_name, last_name_kana, first_name_kana,
+			   phone1, phone2, phone3, postal_code1, postal_code2,
+			   prefecture, city, town, chome, banchi, go, building, room,
+			   email, plan_type, locale, status, legal_hold, phone_verified,
+			   email_deliverability, email_deliverability_reason, email_deliverability_updated_at,
+			   erased_at, version, created_at, updated_at
+		FROM users
+		WHERE last_name_kana = $1 AND first_name_kana = $2
+		ORDER BY id DESC
+		LIMIT $3`
+
+	return r.queryUsers(ctx, query, lastNameKana, firstNameKana, nameKanaCandidateLimit)
+}
+
 // List retrieves a list of users with pagination
 func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*model.User, error) {
 	query := `
 		SELECT id, last_name, first_name, last_name_kana, first_name_kana,
 			   phone1, phone2, phone3, postal_code1, postal_code2,
 			   prefecture, city, town, chome, banchi, go, building, room,
-			   email, plan_type, created_at, updated_at
+			   email, plan_type, locale, status, legal_hold, phone_verified,
+			   email_deliverability, email_deliverability_reason, email_deliverability_updated_at,
+			   erased_at, version, created_at, updated_at
 		FROM users
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2`
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	return r.queryUsers(ctx, query, limit, offset)
+}
+
+// queryUsers runs a query selecting full user rows
+func (r *userRepository) queryUsers(ctx context.Context, query string, args ...any) ([]*model.User, error) {
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		r.log.WithError(err).Error("Failed to list users")
 		return nil, fmt.Errorf("failed to list users: %w", err)
@@ -232,13 +701,18 @@ func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*model.
 			&user.ID, &user.LastName, &user.FirstName, &user.LastNameKana, &user.FirstNameKana,
 			&user.Phone1, &user.Phone2, &user.Phone3, &user.PostalCode1, &user.PostalCode2,
 			&user.Prefecture, &user.City, &user.Town, &user.Chome, &user.Banchi,
-			&user.Go, &user.Building, &user.Room, &user.Email, &user.PlanType,
-			&user.CreatedAt, &user.UpdatedAt,
+			&user.Go, &user.Building, &user.Room, &user.Email, &user.PlanType, &user.Locale,
+			&user.Status, &user.LegalHold, &user.PhoneVerified,
+			&user.EmailDeliverability, &user.EmailDeliverabilityReason, &user.EmailDeliverabilityUpdatedAt,
+			&user.ErasedAt, &user.Version, &user.CreatedAt, &user.UpdatedAt,
 		)
 		if scanErr != nil {
 			r.log.WithError(scanErr).Error("Failed to scan user row")
 			return nil, fmt.Errorf("failed to scan user row: %w", scanErr)
 		}
+		if err := r.decryptUser(ctx, &user); err != nil {
+			return nil, err
+		}
 		users = append(users, &user)
 	}
 
@@ -249,3 +723,276 @@ func (r *userRepository) List(ctx context.Context, limit, offset int) ([]*model.
 
 	return users, nil
 }
+
+// ReencryptBatch rewrites the personal data of up to limit users with IDs above afterID the
+// way new rows are written, with the active key or in plaintext when encryption is disabled,
+// and fills in their blind indexes. Rows already current are left alone and erased users are
+// skipped. The rows are locked until the surrounding transaction ends. It returns the last ID
+// scanned, 0 when no users remain, and the number of users rewritten.
+func (r *userRepository) ReencryptBatch(ctx context.Context, afterID, limit int) (int, int, error) {
+	query := `
+		SELECT id, phone1, phone2, phone3, town, chome, banchi, go, building, room, email,
+			   email_hash, phone_hash
+		FROM users
+		WHERE id > $1 AND erased_at IS NULL
+		ORDER BY id
+		LIMIT $2
+		FOR UPDATE`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, afterID, limit)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to list users to re-encrypt")
+		return 0, 0, fmt.Errorf("failed to list users to re-encrypt: %w", err)
+	}
+	defer rows.Close()
+
+	var stale []*model.User
+	lastID := 0
+	for rows.Next() {
+		var user model.User
+		var emailHash, phoneHash sql.NullString
+		scanErr := rows.Scan(
+			&user.ID, &user.Phone1, &user.Phone2, &user.Phone3, &user.Town, &user.Chome, &user.Banchi,
+			&user.Go, &user.Building, &user.Room, &user.Email, &emailHash, &phoneHash,
+		)
+		if scanErr != nil {
+			r.log.WithError(scanErr).Error("Failed to scan user row")
+			return 0, 0, fmt.Errorf("failed to scan user row: %w", scanErr)
+		}
+		lastID = user.ID
+
+		current := true
+		for _, value := range piiValues(&user) {
+			current = current && r.cipher.IsCurrent(value)
+		}
+		if err := r.decryptUser(ctx, &user); err != nil {
+			return 0, 0, err
+		}
+		if current && emailHash.String == r.emailIndex(user.Email) &&
+			phoneHash.String == r.phoneIndex(userPhone(&user)) {
+			continue
+		}
+		stale = append(stale, &user)
+	}
+	if err = rows.Err(); err != nil {
+		r.log.WithError(err).Error("Error iterating user rows")
+		return 0, 0, fmt.Errorf("error iterating user rows: %w", err)
+	}
+	rows.Close()
+
+	if err := r.rewritePII(ctx, stale); err != nil {
+		return 0, 0, err
+	}
+	return lastID, len(stale), nil
+}
+
+// userKeyFilter matches the users holding a value encrypted with the key whose prefix is $1
+const userKeyFilter = `(starts_with(phone1, $1) OR starts_with(phone2, $1) OR starts_with(phone3, $1)
+			OR starts_with(town, $1) OR starts_with(chome, $1) OR starts_with(banchi, $1)
+			OR starts_with(go, $1) OR starts_with(building, $1) OR starts_with(room, $1)
+			OR starts_with(email, $1))`
+
+// RotateKeyBatch rewrites the personal data of up to limit users with IDs above afterID that
+// hold a value encrypted with the key, using the active key. The rows are locked until the
+// surrounding transaction ends. It returns the last ID rewritten, 0 when no such users
+// remain, and the number of users rewritten.
+func (r *userRepository) RotateKeyBatch(ctx context.Context, keyID string, afterID, limit int) (int, int, error) {
+	query := `
+		SELECT id, phone1, phone2, phone3, town, chome, banchi, go, building, room, email
+		FROM users
+		WHERE ` + userKeyFilter + ` AND id > $2
+		ORDER BY id
+		LIMIT $3
+		FOR UPDATE`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, fieldcrypt.KeyPrefix(keyID), afterID, limit)
+	if err != nil {
+		r.log.WithError(err).WithField("key_id", keyID).Error("Failed to list users to rotate")
+		return 0, 0, fmt.Errorf("failed to list users to rotate: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*model.User
+	for rows.Next() {
+		var user model.User
+		scanErr := rows.Scan(
+			&user.ID, &user.Phone1, &user.Phone2, &user.Phone3, &user.Town, &user.Chome, &user.Banchi,
+			&user.Go, &user.Building, &user.Room, &user.Email,
+		)
+		if scanErr != nil {
+			r.log.WithError(scanErr).Error("Failed to scan user row")
+			return 0, 0, fmt.Errorf("failed to scan user row: %w", scanErr)
+		}
+		if err := r.decryptUser(ctx, &user); err != nil {
+			return 0, 0, err
+		}
+		users = append(users, &user)
+	}
+	if err = rows.Err(); err != nil {
+		r.log.WithError(err).Error("Error iterating user rows")
+		return 0, 0, fmt.Errorf("error iterating user rows: %w", err)
+	}
+	rows.Close()
+
+	if len(users) == 0 {
+		return 0, 0, nil
+	}
+	if err := r.rewritePII(ctx, users); err != nil {
+		return 0, 0, err
+	}
+	return users[len(users)-1].ID, len(users), nil
+}
+
+// CountEncryptedWithKey counts the users holding a value encrypted with the key
+func (r *userRepository) CountEncryptedWithKey(ctx context.Context, keyID string) (int, error) {
+	query := `SELECT COUNT(*) FROM users WHERE ` + userKeyFilter
+
+	var count int
+	if err := executor(ctx, r.db).QueryRowContext(ctx, query, fieldcrypt.KeyPrefix(keyID)).Scan(&count); err != nil {
+		r.log.WithError(err).WithField("key_id", keyID).Error("Failed to count users encrypted with key")
+		return 0, fmt.Errorf("failed to count users encrypted with key: %w", err)
+	}
+	return count, nil
+}
+
+// rewritePII writes the decrypted personal data of users back the way new rows are written
+// and recomputes their blind indexes
+func (r *userRepository) rewritePII(ctx context.Context, users []*model.User) error {
+	update := `
+		UPDATE users SET
+			phone1 = $2, phone2 = $3, phone3 = $4, town = $5, chome = $6, banchi = $7,
+			go = $8, building = $9, room = $10, email = $11, email_hash = $12, phone_hash = $13
+		WHERE id = $1`
+
+	for _, user := range users {
+		sealed, err := r.encryptUser(ctx, user)
+		if err != nil {
+			return err
+		}
+		_, err = executor(ctx, r.db).ExecContext(ctx, update,
+			user.ID, sealed.Phone1, sealed.Phone2, sealed.Phone3, sealed.Town, sealed.Chome, sealed.Banchi,
+			sealed.Go, sealed.Building, sealed.Room, sealed.Email,
+			r.emailIndex(user.Email), r.phoneIndex(userPhone(user)),
+		)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return apperr.Errorf(apperr.ErrDuplicate,
+					"user %d has the same email address as another user, ignoring case", user.ID)
+			}
+			r.log.WithError(err).WithField("user_id", user.ID).Error("Failed to re-encrypt user")
+			return fmt.Errorf("failed to re-encrypt user %d: %w", user.ID, err)
+		}
+	}
+	return nil
+}
+
+// piiFields maps the encrypted columns to the fields of user holding them; the optional
+// address parts are in the second map
+func piiFields(user *model.User) (map[string]*string, map[string]**string) {
+	required := map[string]*string{
+		"phone1": &user.Phone1,
+		"phone2": &user.Phone2,
+		"phone3": &user.Phone3,
+		"banchi": &user.Banchi,
+		"email":  &user.Email,
+	}
+	optional := map[string]**string{
+		"town":     &user.Town,
+		"chome":    &user.Chome,
+		"go":       &user.Go,
+		"building": &user.Building,
+		"room":     &user.Room,
+	}
+	return required, optional
+}
+
+// piiValues returns the values of the encrypted columns of user that are set
+func piiValues(user *model.User) []string {
+	required, optional := piiFields(user)
+	values := make([]string, 0, len(required)+len(optional))
+	for _, value := range required {
+		values = append(values, *value)
+	}
+	for _, value := range optional {
+		if *value != nil {
+			values = append(values, **value)
+		}
+	}
+	return values
+}
+
+// encryptUser returns a copy of user with the personal data encrypted for writing
+func (r *userRepository) encryptUser(ctx context.Context, user *model.User) (*model.User, error) {
+	sealed := *user
+	required, optional := piiFields(&sealed)
+	for column, value := range required {
+		encrypted, err := r.cipher.Encrypt(ctx, column, *value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt %s: %w", column, err)
+		}
+		*value = encrypted
+	}
+	for column, value := range optional {
+		if *value == nil {
+			continue
+		}
+		encrypted, err := r.cipher.Encrypt(ctx, column, **value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt %s: %w", column, err)
+		}
+		*value = &encrypted
+	}
+	return &sealed, nil
+}
+
+// decryptUser decrypts the personal data of a scanned user in place
+func (r *userRepository) decryptUser(ctx context.Context, user *model.User) error {
+	required, optional := piiFields(user)
+	for column, value := range required {
+		decrypted, err := r.cipher.Decrypt(ctx, column, *value)
+		if err != nil {
+			r.log.WithError(err).WithField("user_id", user.ID).Error("Failed to decrypt user")
+			return fmt.Errorf("failed to decrypt user %d: %w", user.ID, err)
+		}
+		*value = decrypted
+	}
+	for column, value := range optional {
+		if *value == nil {
+			continue
+		}
+		decrypted, err := r.cipher.Decrypt(ctx, column, **value)
+		if err != nil {
+			r.log.WithError(err).WithField("user_id", user.ID).Error("Failed to decrypt user")
+			return fmt.Errorf("failed to decrypt user %d: %w", user.ID, err)
+		}
+		*value = &decrypted
+	}
+	return nil
+}
+
+// emailIndex returns the blind index of an email address, which ignores case
+func (r *userRepository) emailIndex(email string) string {
+	return emailBlindIndex(r.cipher, email)
+}
+
+// emailBlindIndex returns the blind index of an email address as stored in users.email_hash
+func emailBlindIndex(cipher *fieldcrypt.Cipher, email string) string {
+	return cipher.BlindIndex("email", strings.ToLower(strings.TrimSpace(email)))
+}
+
+// phoneIndex returns the blind index of a phone number given as its concatenated digits
+func (r *userRepository) phoneIndex(phone string) string {
+	return r.cipher.BlindIndex("phone", phone)
+}
+
+// userPhone returns the phone number of user as its concatenated digits
+func userPhone(user *model.User) string {
+	return user.Phone1 + user.Phone2 + user.Phone3
+}
+
+// indexedMatch returns a condition matching rows by blind index, or for rows written before
+// encryption, which have no index yet, by comparing the plaintext expression with a value
+func indexedMatch(hashColumn, hashPlaceholder, plaintext, plaintextPlaceholder string) string {
+	return "(" + hashColumn + " = " + hashPlaceholder +
+		" OR (" + hashColumn + " IS NULL AND " + plaintext + " = " + plaintextPlaceholder + "))"
+}
@@ -0,0 +1,145 @@
+// Package repository provides validation count data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// ValidationCountRepository defines the interface for validation count data access
+type ValidationCountRepository interface {
+	AddAttempts(ctx context.Context, count *model.ValidationAttemptCount) error
+	AddFailures(ctx context.Context, count *model.ValidationFailureCount) error
+	SumAttempts(ctx context.Context, from, to time.Time, stage string) ([]*model.ValidationAttemptCount, error)
+	SumFailures(ctx context.Context, from, to time.Time, stage string) ([]*model.ValidationFailureCount, error)
+}
+
+// validationCountRepository implements ValidationCountRepository
+type validationCountRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewValidationCountRepository creates a new validation count repository
+func NewValidationCountRepository(db *sql.DB, log *logger.Logger) ValidationCountRepository {
+	return &validationCountRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// AddAttempts adds validations to the counts of a day and stage
+func (r *validationCountRepository) AddAttempts(ctx context.Context, count *model.ValidationAttemptCount) error {
+	query := `
+		INSERT INTO validation_attempt_counts (day, stage, attempts, failed)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (day, stage) DO UPDATE
+		SET attempts = validation_attempt_counts.attempts + EXCLUDED.attempts,
+			failed = validation_attempt_counts.failed + EXCLUDED.failed`
+
+	_, err := executor(ctx, r.db).ExecContext(ctx, query, count.Day, count.Stage, count.Attempts, count.Failed)
+	if err != nil {
+		r.log.WithError(err).WithField("stage", count.Stage).Error("Failed to record validation attempts")
+		return fmt.Errorf("failed to record validation attempts: %w", err)
+	}
+
+	return nil
+}
+
+// AddFailures adds validation errors to the counts of a day, stage, field and code
+func (r *validationCountRepository) AddFailures(ctx context.Context, count *model.ValidationFailureCount) error {
+	query := `
+		INSERT INTO validation_failure_counts (day, stage, field, code, failures)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (day, stage, field, code) DO UPDATE
+		SET failures = validation_failure_counts.failures + EXCLUDED.failures`
+
+	_, err := executor(ctx, r.db).ExecContext(ctx, query,
+		count.Day, count.Stage, count.Field, count.Code, count.Failures,
+	)
+	if err != nil {
+		r.log.WithError(err).WithField("stage", count.Stage).Error("Failed to record validation failures")
+		return fmt.Errorf("failed to record validation failures: %w", err)
+	}
+
+	return nil
+}
+
+// SumAttempts returns the validations of the days in [from, to) per stage. An empty stage
+// selects every stage.
+func (r *validationCountRepository) SumAttempts(
+	ctx context.Context, from, to time.Time, stage string,
+) ([]*model.ValidationAttemptCount, error) {
+	query := `
+		SELECT stage, SUM(attempts), SUM(failed)
+		FROM validation_attempt_counts
+		WHERE day >= $1 AND day < $2 AND ($3 = '' OR stage = $3)
+		GROUP BY stage
+		ORDER BY stage`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, from, to, stage)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to sum validation attempts")
+		return nil, fmt.Errorf("failed to sum validation attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []*model.ValidationAttemptCount
+	for rows.Next() {
+		count := model.ValidationAttemptCount{Day: from}
+		if err := rows.Scan(&count.Stage, &count.Attempts, &count.Failed); err != nil {
+			r.log.WithError(err).Error("Failed to scan validation attempt row")
+			return nil, fmt.Errorf("failed to scan validation attempt row: %w", err)
+		}
+		counts = append(counts, &count)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log.WithError(err).Error("Error iterating validation attempt rows")
+		return nil, fmt.Errorf("error iterating validation attempt rows: %w", err)
+	}
+
+	return counts, nil
+}
+
+// SumFailures returns the validation errors of the days in [from, to) per stage, field and
+// code, most frequent first. An empty stage selects every stage.
+func (r *validationCountRepository) SumFailures(
+	ctx context.Context, from, to time.Time, stage string,
+) ([]*model.ValidationFailureCount, error) {
+	query := `
+		SELECT stage, field, code, SUM(failures) AS failures
+		FROM validation_failure_counts
+		WHERE day >= $1 AND day < $2 AND ($3 = '' OR stage = $3)
+		GROUP BY stage, field, code
+		ORDER BY failures DESC, stage, field, code`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, from, to, stage)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to sum validation failures")
+		return nil, fmt.Errorf("failed to sum validation failures: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []*model.ValidationFailureCount
+	for rows.Next() {
+		count := model.ValidationFailureCount{Day: from}
+		if err := rows.Scan(&count.Stage, &count.Field, &count.Code, &count.Failures); err != nil {
+			r.log.WithError(err).Error("Failed to scan validation failure row")
+			return nil, fmt.Errorf("failed to scan validation failure row: %w", err)
+		}
+		counts = append(counts, &count)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log.WithError(err).Error("Error iterating validation failure rows")
+		return nil, fmt.Errorf("error iterating validation failure rows: %w", err)
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,64 @@
+// Package repository provides validation rule data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// ValidationRuleRepository defines the interface for validation rule data access
+type ValidationRuleRepository interface {
+	GetAll(ctx context.Context) ([]*model.ValidationRule, error)
+}
+
+// validationRuleRepository implements ValidationRuleRepository
+type validationRuleRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewValidationRuleRepository creates a new validation rule repository
+func NewValidationRuleRepository(db *sql.DB, log *logger.Logger) ValidationRuleRepository {
+	return &validationRuleRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// GetAll retrieves every rule
+func (r *validationRuleRepository) GetAll(ctx context.Context) ([]*model.ValidationRule, error) {
+	query := `
+		SELECT field_name, required, max_length, pattern, forbidden_chars, message, updated_at
+		FROM validation_rules
+		ORDER BY field_name`
+
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to get validation rules")
+		return nil, fmt.Errorf("failed to get validation rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*model.ValidationRule
+	for rows.Next() {
+		rule := &model.ValidationRule{}
+		err := rows.Scan(
+			&rule.FieldName, &rule.Required, &rule.MaxLength, &rule.Pattern,
+			&rule.ForbiddenChars, &rule.Message, &rule.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan validation rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate validation rules: %w", err)
+	}
+
+	return rules, nil
+}
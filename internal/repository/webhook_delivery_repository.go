@@ -0,0 +1,230 @@
+// Package repository provides webhook delivery queue data access functionality.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// WebhookDeliveryRepository defines the interface for webhook delivery queue data access
+type WebhookDeliveryRepository interface {
+	Enqueue(ctx context.Context, event *model.OutboxEvent) error
+	GetByID(ctx context.Context, id int64) (*model.WebhookDelivery, error)
+	GetByOutboxEventID(ctx context.Context, eventID int64) (*model.WebhookDelivery, error)
+	ClaimDue(ctx context.Context, limit int) ([]*model.WebhookDelivery, error)
+	MarkDelivered(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, dead bool) error
+	Requeue(ctx context.Context, id int64) (*model.WebhookDelivery, error)
+	ListByStatus(ctx context.Context, status string, limit, offset int) ([]*model.WebhookDelivery, error)
+}
+
+// webhookDeliveryRepository implements WebhookDeliveryRepository
+type webhookDeliveryRepository struct {
+	db  *sql.DB
+	log *logger.Logger
+}
+
+// NewWebhookDeliveryRepository creates a new webhook delivery repository
+func NewWebhookDeliveryRepository(db *sql.DB, log *logger.Logger) WebhookDeliveryRepository {
+	return &webhookDeliveryRepository{
+		db:  db,
+		log: log,
+	}
+}
+
+// webhookDeliveryColumns lists the columns scanned by scanWebhookDelivery
+const webhookDeliveryColumns = `id, outbox_event_id, event_type, correlation_id, payload, occurred_at,
+		status, attempts, last_error, next_attempt_at, delivered_at, dead_at, created_at, updated_at`
+
+// Enqueue queues an outbox event for the webhook endpoint. An event already queued is left
+// as it is, so a relay retrying the event for another consumer does not send it twice.
+func (r *webhookDeliveryRepository) Enqueue(ctx context.Context, event *model.OutboxEvent) error {
+	query := `
+		INSERT INTO webhook_deliveries (outbox_event_id, event_type, correlation_id, payload, occurred_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (outbox_event_id) DO NOTHING`
+
+	_, err := executor(ctx, r.db).ExecContext(ctx, query,
+		event.ID, event.EventType, event.CorrelationID, []byte(event.Payload), event.CreatedAt,
+	)
+	if err != nil {
+		r.log.WithError(err).WithField("event_id", event.ID).Error("Failed to enqueue webhook delivery")
+		return fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a delivery
+func (r *webhookDeliveryRepository) GetByID(ctx context.Context, id int64) (*model.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+
+	delivery, err := scanWebhookDelivery(executor(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, apperr.Errorf(apperr.ErrNotFound, "webhook delivery %d not found", id)
+	}
+	if err != nil {
+		r.log.WithError(err).WithField("delivery_id", id).Error("Failed to get webhook delivery")
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// GetByOutboxEventID retrieves the delivery of an outbox event
+func (r *webhookDeliveryRepository) GetByOutboxEventID(
+	ctx context.Context, eventID int64,
+) (*model.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE outbox_event_id = $1`
+
+	delivery, err := scanWebhookDelivery(executor(ctx, r.db).QueryRowContext(ctx, query, eventID))
+	if err == sql.ErrNoRows {
+		return nil, apperr.Errorf(apperr.ErrNotFound, "no webhook delivery of event %d", eventID)
+	}
+	if err != nil {
+		r.log.WithError(err).WithField("event_id", eventID).Error("Failed to get webhook delivery")
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// ClaimDue locks up to limit pending deliveries whose next attempt is due. Rows stay locked
+// until the surrounding transaction ends, so concurrent dispatchers never send one twice.
+func (r *webhookDeliveryRepository) ClaimDue(ctx context.Context, limit int) ([]*model.WebhookDelivery, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE status = 'pending' AND next_attempt_at <= NOW()
+		ORDER BY id ASC
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`
+
+	return r.query(ctx, query, limit)
+}
+
+// MarkDelivered records a successful delivery
+func (r *webhookDeliveryRepository) MarkDelivered(ctx context.Context, id int64) error {
+	query := `
+		UPDATE webhook_deliveries SET
+			status = 'delivered', attempts = attempts + 1, last_error = NULL,
+			delivered_at = NOW(), updated_at = NOW()
+		WHERE id = $1`
+
+	return r.exec(ctx, "mark webhook delivery as delivered", query, id)
+}
+
+// MarkFailed records a failed attempt and schedules the next one. A dead delivery is no
+// longer retried until it is requeued.
+func (r *webhookDeliveryRepository) MarkFailed(
+	ctx context.Context, id int64, lastError string, nextAttemptAt time.Time, dead bool,
+) error {
+	status := model.WebhookDeliveryPending
+	if dead {
+		status = model.WebhookDeliveryDead
+	}
+
+	query := `
+		UPDATE webhook_deliveries SET
+			status = $2, attempts = attempts + 1, last_error = $3, next_attempt_at = $4,
+			dead_at = CASE WHEN $5 THEN NOW() END, updated_at = NOW()
+		WHERE id = $1`
+
+	return r.exec(ctx, "mark webhook delivery as failed", query, id, status, lastError, nextAttemptAt, dead)
+}
+
+// Requeue makes an undelivered delivery due now and restarts its retry schedule. A delivery
+// that was delivered meanwhile is not requeued and nil is returned.
+func (r *webhookDeliveryRepository) Requeue(ctx context.Context, id int64) (*model.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries SET
+			status = 'pending', attempts = 0, next_attempt_at = NOW(), dead_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND status <> 'delivered'
+		RETURNING ` + webhookDeliveryColumns
+
+	delivery, err := scanWebhookDelivery(executor(ctx, r.db).QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		r.log.WithError(err).WithField("delivery_id", id).Error("Failed to requeue webhook delivery")
+		return nil, fmt.Errorf("failed to requeue webhook delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// ListByStatus lists deliveries of a status, newest first
+func (r *webhookDeliveryRepository) ListByStatus(
+	ctx context.Context, status string, limit, offset int,
+) ([]*model.WebhookDelivery, error) {
+	query := `
+		SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE status = $1
+		ORDER BY id DESC
+		LIMIT $2 OFFSET $3`
+
+	return r.query(ctx, query, status, limit, offset)
+}
+
+// exec runs an update and logs failures under the operation name
+func (r *webhookDeliveryRepository) exec(ctx context.Context, operation, query string, args ...any) error {
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+		r.log.WithError(err).Errorf("Failed to %s", operation)
+		return fmt.Errorf("failed to %s: %w", operation, err)
+	}
+	return nil
+}
+
+// query runs a query returning deliveries
+func (r *webhookDeliveryRepository) query(
+	ctx context.Context, query string, args ...any,
+) ([]*model.WebhookDelivery, error) {
+	rows, err := executor(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		r.log.WithError(err).Error("Failed to query webhook deliveries")
+		return nil, fmt.Errorf("failed to query webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*model.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			r.log.WithError(err).Error("Failed to scan webhook delivery row")
+			return nil, fmt.Errorf("failed to scan webhook delivery row: %w", err)
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		r.log.WithError(err).Error("Error iterating webhook delivery rows")
+		return nil, fmt.Errorf("error iterating webhook delivery rows: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// scanWebhookDelivery scans a row selected with webhookDeliveryColumns
+func scanWebhookDelivery(row rowScanner) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+	var payload []byte
+	err := row.Scan(
+		&delivery.ID, &delivery.OutboxEventID, &delivery.EventType, &delivery.CorrelationID, &payload,
+		&delivery.OccurredAt, &delivery.Status, &delivery.Attempts, &delivery.LastError,
+		&delivery.NextAttemptAt, &delivery.DeliveredAt, &delivery.DeadAt, &delivery.CreatedAt,
+		&delivery.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = payload
+	return &delivery, nil
+}
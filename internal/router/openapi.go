@@ -0,0 +1,135 @@
+package router
+
+import (
+	"net/http"
+	"strings"
+)
+
+// openAPIVersion is the OpenAPI specification version of the generated document
+const openAPIVersion = "3.0.3"
+
+// Security scheme names in the OpenAPI document
+const (
+	adminSecurityScheme   = "adminToken"
+	partnerSecurityScheme = "partnerAPIKey"
+)
+
+// OpenAPIDocument is the subset of an OpenAPI document generated from route metadata.
+// Request and response schemas are documented in docs/API_SPECIFICATION.md.
+type OpenAPIDocument struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Info       OpenAPIInfo                            `json:"info"`
+	Paths      map[string]map[string]OpenAPIOperation `json:"paths"`
+	Components OpenAPIComponents                      `json:"components"`
+}
+
+// OpenAPIInfo describes the API
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenAPIOperation describes one route
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Deprecated  bool                       `json:"deprecated,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses"`
+	RateLimit   string                     `json:"x-rate-limit-class,omitempty"`
+	Strict      bool                       `json:"x-strict-mode,omitempty"`
+	Sunset      string                     `json:"x-sunset,omitempty"`
+}
+
+// OpenAPIParameter describes a path parameter
+type OpenAPIParameter struct {
+	Name     string            `json:"name"`
+	In       string            `json:"in"`
+	Required bool              `json:"required"`
+	Schema   map[string]string `json:"schema"`
+}
+
+// OpenAPIResponse describes a response
+type OpenAPIResponse struct {
+	Description string `json:"description"`
+}
+
+// OpenAPIComponents holds the security schemes referenced by operations
+type OpenAPIComponents struct {
+	SecuritySchemes map[string]map[string]string `json:"securitySchemes"`
+}
+
+// OpenAPI generates the OpenAPI document describing every registered route
+func (r *Registry) OpenAPI() *OpenAPIDocument {
+	doc := &OpenAPIDocument{
+		OpenAPI: openAPIVersion,
+		Info:    OpenAPIInfo{Title: r.options.Title, Version: r.options.Version},
+		Paths:   make(map[string]map[string]OpenAPIOperation),
+		Components: OpenAPIComponents{
+			SecuritySchemes: map[string]map[string]string{
+				adminSecurityScheme:   {"type": "http", "scheme": "bearer"},
+				partnerSecurityScheme: {"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+		},
+	}
+
+	for _, route := range r.routes {
+		path, params := openAPIPath(route.Path)
+
+		operation := OpenAPIOperation{
+			OperationID: route.Name,
+			Summary:     route.Summary,
+			Parameters:  params,
+			Responses:   map[string]OpenAPIResponse{"default": {Description: "See the API specification"}},
+			RateLimit:   route.RateLimitClass,
+			Strict:      r.strict(&route),
+		}
+		if route.Tag != "" {
+			operation.Tags = []string{route.Tag}
+		}
+		switch route.Auth {
+		case AuthAdmin:
+			operation.Security = []map[string][]string{{adminSecurityScheme: {}}}
+		case AuthPartner:
+			operation.Security = []map[string][]string{{partnerSecurityScheme: {route.Scope}}}
+		}
+		if route.Deprecation != nil {
+			operation.Deprecated = true
+			if !route.Deprecation.Sunset.IsZero() {
+				operation.Sunset = route.Deprecation.Sunset.UTC().Format(http.TimeFormat)
+			}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]OpenAPIOperation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = operation
+	}
+
+	return doc
+}
+
+// openAPIPath converts a gin route pattern to an OpenAPI path template and its parameters:
+// "/users/:id" becomes "/users/{id}"
+func openAPIPath(pattern string) (string, []OpenAPIParameter) {
+	segments := strings.Split(pattern, "/")
+	var params []OpenAPIParameter
+
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			continue
+		}
+		name := segment[1:]
+		segments[i] = "{" + name + "}"
+		params = append(params, OpenAPIParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   map[string]string{"type": "string"},
+		})
+	}
+
+	return strings.Join(segments, "/"), params
+}
@@ -0,0 +1,430 @@
+// Package router registers HTTP routes from declarative definitions. Each route carries the
+// metadata that cross-cutting policies need (middleware group, authentication, rate limit
+// class, caching, deprecation), so router setup, the OpenAPI document and metrics labels all
+// read from a single table instead of repeating paths.
+package router
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/middleware"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// unmatchedLabel labels requests that matched no route, keeping metric label cardinality bounded
+const unmatchedLabel = "unmatched"
+
+// Auth is the authentication a route requires
+type Auth string
+
+const (
+	// AuthPublic routes are open to everyone
+	AuthPublic Auth = "public"
+	// AuthAdmin routes require the admin API token
+	AuthAdmin Auth = "admin"
+	// AuthPartner routes require a partner API key granting the route's Scope
+	AuthPartner Auth = "partner"
+)
+
+// CachePolicy controls the Cache-Control header of a route's responses. The zero value
+// leaves the header unset.
+type CachePolicy struct {
+	// MaxAge lets clients reuse a response for this long
+	MaxAge time.Duration
+	// NoStore forbids storing responses, for personal data
+	NoStore bool
+	// ETag tags GET responses so that clients can revalidate them with If-None-Match
+	ETag bool
+}
+
+// Strict mode classes. Operators choose the classes served in strict mode, so that, for
+// example, final submissions are rejected when data cannot be verified while browsing goes
+// on with fallbacks.
+const (
+	// StrictClassSubmit routes accept the registration
+	StrictClassSubmit = "submit"
+	// StrictClassBrowse routes look up data while the form is filled in
+	StrictClassBrowse = "browse"
+)
+
+// Deprecation marks a route that is scheduled for removal
+type Deprecation struct {
+	// Sunset is when the route stops being served
+	Sunset time.Time
+	// Successor is the path that replaces the route; its parameters are filled in from the
+	// request
+	Successor string
+}
+
+// Shim adapts the JSON bodies of a route to the DTOs of the handler it shares with another
+// API version. Either rewrite may be nil.
+type Shim struct {
+	// Request rewrites the request body before the handler binds it
+	Request middleware.JSONRewrite
+	// Response rewrites the response body the handler wrote
+	Response middleware.JSONRewrite
+}
+
+// Version describes an API version served under its own path prefix. Its routes are those of
+// the version it succeeds, sharing their handlers and metadata; routes whose DTOs changed are
+// adapted by shims, so the handlers keep serving both versions.
+type Version struct {
+	// Prefix is the path prefix of the version, e.g. /api/v2
+	Prefix string
+	// Predecessor is the path prefix of the version it succeeds, e.g. /api/v1
+	Predecessor string
+	// NameSuffix keeps the route names of the version unique, e.g. V2
+	NameSuffix string
+	// Groups are the middleware groups whose routes are carried over; routes of other groups,
+	// such as the admin API, stay on the predecessor only
+	Groups []string
+	// Shims adapt carried-over routes, by predecessor route name
+	Shims map[string]Shim
+	// Removed lists predecessor routes, by name, not carried over
+	Removed []string
+	// PredecessorSunset is when the carried-over predecessor routes stop being served; the
+	// zero value deprecates them without a date
+	PredecessorSunset time.Time
+}
+
+// Group is a named middleware stack shared by a set of routes. Middleware runs in the
+// order listed, after the engine's global middleware and before route-specific middleware.
+type Group struct {
+	Name       string
+	Middleware []gin.HandlerFunc
+}
+
+// Route describes an endpoint and the policies applied to it
+type Route struct {
+	Method  string
+	Path    string // gin route pattern, e.g. "/api/v1/users/:id"
+	Handler gin.HandlerFunc
+	// Group names the middleware group the route belongs to
+	Group string
+
+	// Name identifies the route as the OpenAPI operation ID and metrics label
+	Name    string
+	Summary string
+	Tag     string
+
+	Auth Auth
+	// Scope is the API key scope an AuthPartner route requires
+	Scope string
+	// RateLimitClass groups routes sharing a rate limit rule and bucket (see
+	// middleware.ParseRateLimitRules); classes without a configured rule use the default
+	RateLimitClass string
+	Cache          CachePolicy
+	// Mutates marks routes that change user or session data; they are rejected in read-only mode
+	Mutates bool
+	// Shim adapts the route to the handler of another API version
+	Shim *Shim
+	// StrictClass is the strict mode class of the route, StrictClassSubmit or StrictClassBrowse;
+	// routes without one are only strict when named in the strict mode configuration
+	StrictClass string
+	Deprecation *Deprecation
+
+	// originKey and originName identify the route a route of a later API version was first
+	// registered as; rate limit rules and strict mode settings configured for that route apply
+	// to its copies
+	originKey  string
+	originName string
+}
+
+// key returns the "METHOD /path" key identifying the route
+func (r *Route) key() string {
+	return r.Method + " " + r.Path
+}
+
+// origin returns the key and name of the route a route was first registered as
+func (r *Route) origin() (key, name string) {
+	if r.originKey != "" {
+		return r.originKey, r.originName
+	}
+	return r.key(), r.Name
+}
+
+// Options holds the middleware the registry attaches according to route metadata
+type Options struct {
+	// Groups are the middleware groups routes can belong to
+	Groups []Group
+	// AdminAuth guards AuthAdmin routes
+	AdminAuth gin.HandlerFunc
+	// PartnerScope guards AuthPartner routes with their scope. Partner routes authenticate
+	// the API key in their group's middleware, which runs first.
+	PartnerScope func(scope string) gin.HandlerFunc
+	// ReadOnly guards routes that mutate data
+	ReadOnly gin.HandlerFunc
+	// StrictMode selects the routes served in strict mode
+	StrictMode middleware.StrictModeConfig
+	// Title and Version describe the API in the OpenAPI document
+	Title   string
+	Version string
+	// Logger logs failures of shims
+	Logger *logger.Logger
+}
+
+// Registry holds the route table
+type Registry struct {
+	options Options
+	groups  map[string]Group
+	routes  []Route
+	byKey   map[string]*Route
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry(options Options) *Registry {
+	groups := make(map[string]Group, len(options.Groups))
+	for _, group := range options.Groups {
+		groups[group.Name] = group
+	}
+
+	return &Registry{
+		options: options,
+		groups:  groups,
+		byKey:   make(map[string]*Route),
+	}
+}
+
+// Add validates routes and appends them to the table
+func (r *Registry) Add(routes ...Route) error {
+	for _, route := range routes {
+		if err := r.validate(&route); err != nil {
+			return fmt.Errorf("invalid route %s: %w", route.key(), err)
+		}
+		r.routes = append(r.routes, route)
+	}
+
+	// Index after appending; pointers into the slice change when it grows
+	for i := range r.routes {
+		r.byKey[r.routes[i].key()] = &r.routes[i]
+	}
+	return nil
+}
+
+// validate rejects incomplete routes and routes registered twice
+func (r *Registry) validate(route *Route) error {
+	switch {
+	case route.Method == "" || !strings.HasPrefix(route.Path, "/"):
+		return errors.New("method and an absolute path are required")
+	case route.Handler == nil:
+		return errors.New("handler is required")
+	case route.Name == "":
+		return errors.New("name is required")
+	}
+
+	if _, ok := r.groups[route.Group]; !ok {
+		return fmt.Errorf("unknown middleware group %q", route.Group)
+	}
+
+	switch route.Auth {
+	case AuthPublic:
+	case AuthAdmin:
+		if r.options.AdminAuth == nil {
+			return errors.New("admin routes require AdminAuth middleware")
+		}
+	case AuthPartner:
+		if r.options.PartnerScope == nil {
+			return errors.New("partner routes require PartnerScope middleware")
+		}
+		if route.Scope == "" {
+			return errors.New("partner routes require a scope")
+		}
+	default:
+		return fmt.Errorf("unknown auth %q", route.Auth)
+	}
+
+	if route.Mutates && r.options.ReadOnly == nil {
+		return errors.New("mutating routes require ReadOnly middleware")
+	}
+
+	switch route.StrictClass {
+	case "", StrictClassSubmit, StrictClassBrowse:
+	default:
+		return fmt.Errorf("unknown strict class %q", route.StrictClass)
+	}
+
+	for _, existing := range r.routes {
+		if existing.key() == route.key() {
+			return errors.New("route is already registered")
+		}
+		if existing.Name == route.Name {
+			return fmt.Errorf("name %q is already used by %s", route.Name, existing.key())
+		}
+	}
+	return nil
+}
+
+// AddVersion adds the routes of an API version, carrying over the predecessor routes of its
+// groups that are neither removed nor already deprecated. Carried-over predecessor routes are
+// deprecated in favour of their successors.
+func (r *Registry) AddVersion(version Version) error {
+	if version.Prefix == "" || version.Predecessor == "" || version.NameSuffix == "" {
+		return errors.New("version prefix, predecessor and name suffix are required")
+	}
+	for name := range version.Shims {
+		if !slices.ContainsFunc(r.routes, func(route Route) bool { return route.Name == name }) {
+			return fmt.Errorf("shim for unknown route %q", name)
+		}
+	}
+
+	var routes []Route
+	for i := range r.routes {
+		predecessor := &r.routes[i]
+		rest, ok := strings.CutPrefix(predecessor.Path, version.Predecessor+"/")
+		if !ok || predecessor.Deprecation != nil || !slices.Contains(version.Groups, predecessor.Group) ||
+			slices.Contains(version.Removed, predecessor.Name) {
+			continue
+		}
+
+		route := *predecessor
+		route.Path = version.Prefix + "/" + rest
+		route.Name += version.NameSuffix
+		route.originKey, route.originName = predecessor.origin()
+		if shim, ok := version.Shims[predecessor.Name]; ok {
+			route.Shim = &shim
+		}
+		routes = append(routes, route)
+
+		predecessor.Deprecation = &Deprecation{Sunset: version.PredecessorSunset, Successor: route.Path}
+	}
+
+	return r.Add(routes...)
+}
+
+// Routes returns the route table in registration order
+func (r *Registry) Routes() []Route {
+	return r.routes
+}
+
+// Register adds every route to the engine with the middleware its metadata calls for
+func (r *Registry) Register(engine gin.IRoutes) {
+	for i := range r.routes {
+		route := &r.routes[i]
+		engine.Handle(route.Method, route.Path, r.handlers(route)...)
+	}
+}
+
+// handlers builds the handler chain of a route: its group's middleware, then the
+// middleware its metadata calls for, then the handler
+func (r *Registry) handlers(route *Route) []gin.HandlerFunc {
+	chain := r.GroupMiddleware(route.Group)
+
+	switch route.Auth {
+	case AuthAdmin:
+		chain = append(chain, r.options.AdminAuth)
+	case AuthPartner:
+		chain = append(chain, r.options.PartnerScope(route.Scope))
+	}
+	if route.Mutates {
+		chain = append(chain, r.options.ReadOnly)
+	}
+	if r.strict(route) {
+		chain = append(chain, middleware.StrictMode())
+	}
+	if value := cacheControl(route); value != "" {
+		chain = append(chain, middleware.CacheControl(value))
+	}
+	if route.Cache.ETag {
+		chain = append(chain, middleware.ETag())
+	}
+	if route.Deprecation != nil {
+		chain = append(chain, middleware.Deprecated(route.Deprecation.Sunset, route.Deprecation.Successor))
+	}
+	if route.Shim != nil {
+		chain = append(chain, middleware.JSONShim(route.Shim.Request, route.Shim.Response, r.options.Logger))
+	}
+
+	return append(chain, route.Handler)
+}
+
+// strict reports whether a route is served in strict mode. Routes are named in the strict mode
+// configuration by the name they were introduced with, which covers their later versions.
+func (r *Registry) strict(route *Route) bool {
+	_, name := route.origin()
+	return slices.Contains(r.options.StrictMode.Routes, name) ||
+		(route.StrictClass != "" && slices.Contains(r.options.StrictMode.Classes, route.StrictClass))
+}
+
+// CheckStrictMode reports strict mode classes and routes that match no route, so that a
+// misspelt name does not silently leave routes lenient
+func (r *Registry) CheckStrictMode() error {
+	for _, class := range r.options.StrictMode.Classes {
+		if class != StrictClassSubmit && class != StrictClassBrowse {
+			return fmt.Errorf("unknown strict class %q", class)
+		}
+	}
+	for _, name := range r.options.StrictMode.Routes {
+		if !slices.ContainsFunc(r.routes, func(route Route) bool { return route.Name == name }) {
+			return fmt.Errorf("unknown route %q", name)
+		}
+	}
+	return nil
+}
+
+// cacheControl returns the Cache-Control header value for a route, or "" to leave it unset
+func cacheControl(route *Route) string {
+	switch {
+	case route.Cache.NoStore:
+		return "no-store"
+	case route.Cache.MaxAge > 0:
+		// Admin and partner responses depend on the caller's credentials and must not be
+		// shared by proxies
+		scope := "public"
+		if route.Auth != AuthPublic {
+			scope = "private"
+		}
+		return fmt.Sprintf("%s, max-age=%d", scope, int(route.Cache.MaxAge.Seconds()))
+	default:
+		return ""
+	}
+}
+
+// GroupMiddleware returns a copy of the middleware stack of a group, e.g. to apply it to
+// the engine's 404 and 405 handlers
+func (r *Registry) GroupMiddleware(name string) []gin.HandlerFunc {
+	return slices.Clone(r.groups[name].Middleware)
+}
+
+// RateLimitClasses maps "METHOD /route" keys to the rate limit class of each classed route
+func (r *Registry) RateLimitClasses() map[string]string {
+	classes := make(map[string]string)
+	for _, route := range r.routes {
+		if route.RateLimitClass != "" {
+			classes[route.key()] = route.RateLimitClass
+		}
+	}
+	return classes
+}
+
+// RateLimitOrigins maps the "METHOD /route" keys of routes carried over to a later API version
+// to the keys of the routes they were introduced as, so that they share their rate limit rules
+// and buckets; otherwise a client could double its allowance by calling both versions
+func (r *Registry) RateLimitOrigins() map[string]string {
+	origins := make(map[string]string)
+	for _, route := range r.routes {
+		if route.originKey != "" {
+			origins[route.key()] = route.originKey
+		}
+	}
+	return origins
+}
+
+// EndpointLabel returns the metrics label of the route serving a request. Labels are route
+// names rather than raw paths, so IDs in URLs do not create a label per resource.
+func (r *Registry) EndpointLabel(c *gin.Context) string {
+	if route, ok := r.byKey[c.Request.Method+" "+c.FullPath()]; ok {
+		return route.Name
+	}
+	return unmatchedLabel
+}
+
+// ServeOpenAPI responds with the OpenAPI document generated from the route table
+func (r *Registry) ServeOpenAPI(c *gin.Context) {
+	c.JSON(http.StatusOK, r.OpenAPI())
+}
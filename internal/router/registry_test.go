@@ -0,0 +1,87 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/octop162/normal-form-app-by-claude/internal/middleware"
+)
+
+// newVersionedRegistry registers the SMS route under /api/v1 and carries it over to /api/v2
+func newVersionedRegistry(t *testing.T, options Options, group Group) *Registry {
+	t.Helper()
+
+	options.Groups = []Group{group}
+	registry := NewRegistry(options)
+	err := registry.Add(Route{
+		Method: http.MethodPost, Path: "/api/v1/users/phone/send-code",
+		Handler: func(c *gin.Context) { c.Status(http.StatusNoContent) },
+		Name:    "sendPhoneCode", Group: group.Name, Auth: AuthPublic, RateLimitClass: "phone",
+	})
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := registry.AddVersion(Version{
+		Prefix: "/api/v2", Predecessor: "/api/v1", NameSuffix: "V2", Groups: []string{group.Name},
+	}); err != nil {
+		t.Fatalf("AddVersion: %v", err)
+	}
+	return registry
+}
+
+func TestAddVersion_RateLimitRuleOfPredecessor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name  string
+		rules string
+	}{
+		{"route rule", "POST /api/v1/users/phone/send-code=2/10m"},
+		{"class rule", "phone=2/10m"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := middleware.NewRateLimitPolicy(100, time.Minute, 100, tt.rules)
+			if err != nil {
+				t.Fatalf("NewRateLimitPolicy: %v", err)
+			}
+			rateLimit := middleware.RateLimitWithPolicy(policy, middleware.NewMemoryRateLimitStore(100), nil)
+			registry := newVersionedRegistry(t, Options{}, Group{Name: "public", Middleware: []gin.HandlerFunc{rateLimit}})
+			policy.Classes = registry.RateLimitClasses()
+			policy.Origins = registry.RateLimitOrigins()
+
+			engine := gin.New()
+			registry.Register(engine)
+
+			// Both versions share one bucket, so the v1 request counts against the v2 limit
+			paths := []string{"/api/v1/users/phone/send-code", "/api/v2/users/phone/send-code", "/api/v2/users/phone/send-code"}
+			want := []int{http.StatusNoContent, http.StatusNoContent, http.StatusTooManyRequests}
+			for i, path := range paths {
+				w := httptest.NewRecorder()
+				engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+				if w.Code != want[i] {
+					t.Fatalf("request %d to %s: status = %d, want %d", i+1, path, w.Code, want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAddVersion_StrictModeByPredecessorName(t *testing.T) {
+	registry := newVersionedRegistry(t, Options{
+		StrictMode: middleware.StrictModeConfig{Routes: []string{"sendPhoneCode"}},
+	}, Group{Name: "public"})
+
+	if err := registry.CheckStrictMode(); err != nil {
+		t.Fatalf("CheckStrictMode: %v", err)
+	}
+	for _, route := range registry.Routes() {
+		if !registry.strict(&route) {
+			t.Errorf("route %s is not strict", route.Name)
+		}
+	}
+}
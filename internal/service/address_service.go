@@ -3,24 +3,35 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"slices"
 
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
 	"github.com/octop162/normal-form-app-by-claude/internal/dto"
 	"github.com/octop162/normal-form-app-by-claude/internal/model"
 	"github.com/octop162/normal-form-app-by-claude/internal/repository"
 	"github.com/octop162/normal-form-app-by-claude/pkg/external"
+	"github.com/octop162/normal-form-app-by-claude/pkg/japanese"
 	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
 )
 
 const (
 	// Postal code validation constants
 	postalCodeLength = 7
+
+	// reverseSearchLimit caps the postal codes returned for one address
+	reverseSearchLimit = 100
+
+	// buildingSearchLimit caps the building names suggested for one address
+	buildingSearchLimit = 50
 )
 
 // AddressService defines the interface for address business logic
 type AddressService interface {
 	SearchByPostalCode(ctx context.Context, req *dto.AddressSearchRequest) (*dto.AddressSearchResponse, error)
+	ReverseSearch(ctx context.Context, req *dto.AddressReverseSearchRequest) (*dto.AddressReverseSearchResponse, error)
+	SearchBuildings(ctx context.Context, req *dto.BuildingSearchRequest) (*dto.BuildingSearchResponse, error)
 	CheckRegionRestrictions(ctx context.Context, req *dto.RegionCheckRequest) (*dto.RegionCheckResponse, error)
 	GetPrefectures(ctx context.Context) (*dto.PrefecturesGetResponse, error)
 	GetPrefectureByName(ctx context.Context, name string) (*dto.PrefectureResponse, error)
@@ -28,21 +39,36 @@ type AddressService interface {
 
 // addressService implements AddressService
 type addressService struct {
-	prefectureRepo repository.PrefectureRepository
-	externalAPI    *external.Manager
-	log            *logger.Logger
+	prefectureRepo  repository.PrefectureRepository
+	addressRepo     repository.AddressRepository
+	buildingRepo    repository.BuildingRepository
+	restrictionRepo repository.RegionRestrictionRepository
+	externalAPI     *external.Manager
+	flags           *FeatureFlags
+	validator       *validator.CustomValidator
+	log             *logger.Logger
 }
 
 // NewAddressService creates a new address service
 func NewAddressService(
 	prefectureRepo repository.PrefectureRepository,
+	addressRepo repository.AddressRepository,
+	buildingRepo repository.BuildingRepository,
+	restrictionRepo repository.RegionRestrictionRepository,
 	externalAPI *external.Manager,
+	flags *FeatureFlags,
+	validator *validator.CustomValidator,
 	log *logger.Logger,
 ) AddressService {
 	return &addressService{
-		prefectureRepo: prefectureRepo,
-		externalAPI:    externalAPI,
-		log:            log,
+		prefectureRepo:  prefectureRepo,
+		addressRepo:     addressRepo,
+		buildingRepo:    buildingRepo,
+		restrictionRepo: restrictionRepo,
+		externalAPI:     externalAPI,
+		flags:           flags,
+		validator:       validator,
+		log:             log,
 	}
 }
 
@@ -58,10 +84,21 @@ func (s *addressService) SearchByPostalCode(
 	}
 
 	// Try external address API first if available
+	var apiErr error
 	if s.externalAPI != nil && s.externalAPI.AddressClient() != nil {
 		addressInfo, err := s.externalAPI.AddressClient().SearchByPostalCode(ctx, req.PostalCode)
+		if errors.Is(err, external.ErrAddressNotFound) {
+			return &dto.AddressSearchResponse{
+				Found: false,
+			}, nil
+		}
+		if err != nil && external.IsStrict(ctx) {
+			s.log.WithError(err).WithField("postal_code", req.PostalCode).Warn("External address API failed in strict mode")
+			return nil, newStrictExternalAPIError(ExternalAPIAddress, err)
+		}
 		if err != nil {
-			s.log.WithError(err).WithField("postal_code", req.PostalCode).Warn("External address API failed, falling back to mock data")
+			s.log.WithError(err).WithField("postal_code", req.PostalCode).Warn("External address API failed, falling back to postal code database")
+			apiErr = err
 		} else {
 			return &dto.AddressSearchResponse{
 				Found:      true,
@@ -73,40 +110,152 @@ func (s *addressService) SearchByPostalCode(
 		}
 	}
 
-	// Fallback to mock data
-	address := s.getMockAddressData(req.PostalCode)
-	if address == nil {
+	// Fallback to the local postal code database
+	addresses, err := s.addressRepo.FindByPostalCode(ctx, req.PostalCode)
+	if err != nil {
+		s.log.WithError(err).WithField("postal_code", req.PostalCode).Error("Failed to search postal code database")
+		if apiErr != nil {
+			return nil, newExternalAPIError(ExternalAPIAddress, apiErr)
+		}
+		return nil, fmt.Errorf("failed to search postal code database: %w", err)
+	}
+	if len(addresses) == 0 {
 		return &dto.AddressSearchResponse{
 			Found: false,
 		}, nil
 	}
 
+	address := addresses[0]
 	return &dto.AddressSearchResponse{
 		Found:      true,
 		Prefecture: address.Prefecture,
 		City:       address.City,
-		Town:       address.Town,
+		Town:       commonTown(addresses),
 		PostalCode: formatPostalCode(req.PostalCode),
 	}, nil
 }
 
+// ReverseSearch finds the postal codes of an address in the postal code database
+func (s *addressService) ReverseSearch(
+	ctx context.Context, req *dto.AddressReverseSearchRequest,
+) (*dto.AddressReverseSearchResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	// One extra row tells whether the results were truncated
+	addresses, err := s.addressRepo.FindByAddress(ctx, req.Prefecture, req.City, req.Town, reverseSearchLimit+1)
+	if err != nil {
+		s.log.WithError(err).
+			WithField("prefecture", req.Prefecture).
+			WithField("city", req.City).
+			Error("Failed to search postal codes by address")
+		return nil, fmt.Errorf("failed to search postal codes by address: %w", err)
+	}
+
+	resp := &dto.AddressReverseSearchResponse{
+		Results: make([]dto.AddressReverseSearchResult, 0, min(len(addresses), reverseSearchLimit)),
+	}
+	if len(addresses) > reverseSearchLimit {
+		addresses = addresses[:reverseSearchLimit]
+		resp.Truncated = true
+	}
+
+	// A postal code can appear once per town annotation; list each code and town once
+	seen := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		key := address.PostalCode + "|" + address.Town
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		resp.Results = append(resp.Results, dto.AddressReverseSearchResult{
+			PostalCode: formatPostalCode(address.PostalCode),
+			Prefecture: address.Prefecture,
+			City:       address.City,
+			Town:       address.Town,
+		})
+	}
+	resp.Found = len(resp.Results) > 0
+
+	return resp, nil
+}
+
+// SearchBuildings suggests the names of buildings at an address from the building name
+// master. The block numbers are matched in the form users are stored with, so "８番地１号"
+// finds buildings at 8-1.
+func (s *addressService) SearchBuildings(
+	ctx context.Context, req *dto.BuildingSearchRequest,
+) (*dto.BuildingSearchResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	// Block numbers that cannot be read as numbers (甲12) are matched as entered
+	banchi, _ := japanese.NormalizeBlockNumber(req.Banchi)
+
+	// One extra row tells whether the results were truncated
+	buildings, err := s.buildingRepo.FindByAddress(ctx, req.PostalCode, banchi, buildingSearchLimit+1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search buildings: %w", err)
+	}
+
+	resp := &dto.BuildingSearchResponse{
+		Buildings: make([]dto.BuildingSuggestion, 0, min(len(buildings), buildingSearchLimit)),
+	}
+	if len(buildings) > buildingSearchLimit {
+		buildings = buildings[:buildingSearchLimit]
+		resp.Truncated = true
+	}
+	for _, building := range buildings {
+		resp.Buildings = append(resp.Buildings, dto.BuildingSuggestion{
+			Name:   building.Name,
+			Banchi: building.Banchi,
+		})
+	}
+	resp.Found = len(resp.Buildings) > 0
+
+	return resp, nil
+}
+
 // CheckRegionRestrictions checks if options are available in the specified region
 func (s *addressService) CheckRegionRestrictions(
 	ctx context.Context, req *dto.RegionCheckRequest,
 ) (*dto.RegionCheckResponse, error) {
 	restrictions := make(map[string]bool)
 
+	if s.flags.Enabled(FlagDisableRegionRestriction) {
+		for _, optionType := range req.OptionTypes {
+			restrictions[optionType] = true
+		}
+		s.log.WithContext(ctx).WithField("prefecture", req.Prefecture).Debug("Region restrictions disabled by feature flag")
+		return &dto.RegionCheckResponse{
+			Restrictions: restrictions,
+		}, nil
+	}
+
 	// Try external region API first if available
+	var apiErr error
 	if s.externalAPI != nil && s.externalAPI.RegionClient() != nil {
 		regionRestrictions, err := s.externalAPI.RegionClient().CheckRegionRestrictions(
 			ctx, req.Prefecture, req.City, req.OptionTypes,
 		)
+		if err != nil && external.IsStrict(ctx) {
+			s.log.WithError(err).
+				WithField("prefecture", req.Prefecture).
+				WithField("city", req.City).
+				WithField("options", req.OptionTypes).
+				Warn("External region API failed in strict mode")
+			return nil, newStrictExternalAPIError(ExternalAPIRegion, err)
+		}
 		if err != nil {
 			s.log.WithError(err).
 				WithField("prefecture", req.Prefecture).
 				WithField("city", req.City).
 				WithField("options", req.OptionTypes).
 				Warn("External region API failed, falling back to local logic")
+			apiErr = err
 		} else {
 			return &dto.RegionCheckResponse{
 				Restrictions: regionRestrictions,
@@ -114,17 +263,26 @@ func (s *addressService) CheckRegionRestrictions(
 		}
 	}
 
-	// Fallback to local logic
-	prefecture, err := s.prefectureRepo.GetByName(ctx, req.Prefecture)
-	if err != nil {
+	// Fallback to the local region restriction master data. An unknown prefecture is reported
+	// as such; other failures leave nothing to answer with when the API failed too.
+	if _, err := s.prefectureRepo.GetByName(ctx, req.Prefecture); err != nil {
 		s.log.WithError(err).WithField("prefecture", req.Prefecture).Error("Failed to get prefecture")
+		if apiErr != nil && !errors.Is(err, apperr.ErrNotFound) {
+			return nil, newExternalAPIError(ExternalAPIRegion, apiErr)
+		}
 		return nil, fmt.Errorf("failed to get prefecture: %w", err)
 	}
 
-	// Check restrictions for each option type using local logic
+	entries, err := s.restrictionRepo.FindForRegion(ctx, req.Prefecture, req.City)
+	if err != nil {
+		if apiErr != nil {
+			return nil, newExternalAPIError(ExternalAPIRegion, apiErr)
+		}
+		return nil, fmt.Errorf("failed to get region restrictions: %w", err)
+	}
+
 	for _, optionType := range req.OptionTypes {
-		allowed := s.checkOptionAllowedInRegion(prefecture, req.City, optionType)
-		restrictions[optionType] = allowed
+		restrictions[optionType] = optionAllowedInRegion(entries, optionType)
 	}
 
 	return &dto.RegionCheckResponse{
@@ -163,71 +321,20 @@ func (s *addressService) GetPrefectureByName(ctx context.Context, name string) (
 	return &response, nil
 }
 
-// getMockAddressData returns mock address data for testing
-// TODO: Replace with actual external postal code API call
-func (s *addressService) getMockAddressData(postalCode string) *model.Address {
-	// Mock address data for common postal codes
-	mockData := map[string]*model.Address{
-		"1000001": {
-			PostalCode: "100-0001",
-			Prefecture: "東京都",
-			City:       "千代田区",
-			Town:       "千代田",
-		},
-		"1500002": {
-			PostalCode: "150-0002",
-			Prefecture: "東京都",
-			City:       "渋谷区",
-			Town:       "渋谷",
-		},
-		"5410041": {
-			PostalCode: "541-0041",
-			Prefecture: "大阪府",
-			City:       "大阪市中央区",
-			Town:       "北浜",
-		},
-		"2310023": {
-			PostalCode: "231-0023",
-			Prefecture: "神奈川県",
-			City:       "横浜市中区",
-			Town:       "山下町",
-		},
-		"4600008": {
-			PostalCode: "460-0008",
-			Prefecture: "愛知県",
-			City:       "名古屋市中区",
-			Town:       "栄",
-		},
-	}
-
-	return mockData[postalCode]
-}
-
-// checkOptionAllowedInRegion checks if an option is allowed in the specified region
-// TODO: Implement actual region restriction logic
-func (s *addressService) checkOptionAllowedInRegion(
-	prefecture *model.PrefectureMaster, _ string, optionType string,
-) bool {
-	// Mock region restrictions for testing
-	// In production, this would call external region restriction API
-
-	// Example restrictions:
-	// - AA option not available in certain remote areas
-	// - BB option restricted in some metropolitan areas
-	switch optionType {
-	case "AA":
-		// AA option not available in Hokkaido for this example
-		return prefecture.PrefectureName != "北海道"
-	case "BB":
-		// BB option not available in major metropolitan areas
-		restrictedCities := []string{"東京都", "大阪府", "愛知県"}
-		return !slices.Contains(restrictedCities, prefecture.PrefectureName)
-	case "AB":
-		// AB option available everywhere
-		return true
-	default:
-		return false
+// optionAllowedInRegion applies the restrictions of a city: its own entry overrides the
+// entry of the whole prefecture, and an option without either is allowed
+func optionAllowedInRegion(entries []*model.RegionRestriction, optionType string) bool {
+	allowed := true
+	for _, entry := range entries {
+		if entry.OptionType != optionType {
+			continue
+		}
+		if entry.City != "" {
+			return entry.Allowed
+		}
+		allowed = entry.Allowed
 	}
+	return allowed
 }
 
 // convertPrefectureToResponse converts prefecture model to response DTO
@@ -240,6 +347,18 @@ func (s *addressService) convertPrefectureToResponse(prefecture *model.Prefectur
 	}
 }
 
+// commonTown returns the town shared by every entry of a postal code. A postal code that
+// spans several towns returns "" so the user enters the town themselves.
+func commonTown(addresses []*model.PostalAddress) string {
+	town := addresses[0].Town
+	for _, address := range addresses[1:] {
+		if address.Town != town {
+			return ""
+		}
+	}
+	return town
+}
+
 // formatPostalCode formats postal code with hyphen (XXXXXXX -> XXX-XXXX)
 func formatPostalCode(postalCode string) string {
 	if len(postalCode) != postalCodeLength {
@@ -0,0 +1,181 @@
+// Package service provides administration of undelivered outbox events.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+const (
+	// defaultEventListLimit is the page size of admin event listings
+	defaultEventListLimit = 50
+)
+
+// AdminEventService defines the interface for recovering events whose delivery failed
+type AdminEventService interface {
+	ListEvents(ctx context.Context, req *dto.AdminEventListRequest) (*dto.AdminEventListResponse, error)
+	ReplayEvent(ctx context.Context, id int64, actorIP string) (*dto.AdminEventResponse, error)
+}
+
+// adminEventService implements AdminEventService
+type adminEventService struct {
+	outboxRepo   repository.OutboxRepository
+	deliveryRepo repository.WebhookDeliveryRepository
+	auditLogRepo repository.AuditLogRepository
+	txManager    repository.TxManager
+	validator    *validator.CustomValidator
+	log          *logger.Logger
+}
+
+// NewAdminEventService creates a new admin event service
+func NewAdminEventService(
+	outboxRepo repository.OutboxRepository,
+	deliveryRepo repository.WebhookDeliveryRepository,
+	auditLogRepo repository.AuditLogRepository,
+	txManager repository.TxManager,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) AdminEventService {
+	return &adminEventService{
+		outboxRepo:   outboxRepo,
+		deliveryRepo: deliveryRepo,
+		auditLogRepo: auditLogRepo,
+		txManager:    txManager,
+		validator:    validator,
+		log:          log,
+	}
+}
+
+// ListEvents lists the events whose publishing or webhook delivery failed, newest first
+func (s *adminEventService) ListEvents(
+	ctx context.Context, req *dto.AdminEventListRequest,
+) (*dto.AdminEventListResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultEventListLimit
+	}
+
+	results, err := s.outboxRepo.ListUndelivered(ctx, req.State, req.EventType, limit, req.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.AdminEventListResponse{Events: make([]*dto.AdminEventResponse, 0, len(results))}
+	for _, result := range results {
+		resp.Events = append(resp.Events, toAdminEventResponse(result.Event, result.Webhook))
+	}
+	return resp, nil
+}
+
+// ReplayEvent retries every failed delivery of an event now, restarting its attempts: the
+// relay publishes it again unless it was published, and its webhook is sent again unless
+// it was delivered. Consumers that already received the event deduplicate it by ID.
+func (s *adminEventService) ReplayEvent(
+	ctx context.Context, id int64, actorIP string,
+) (*dto.AdminEventResponse, error) {
+	var event *model.OutboxEvent
+	var delivery *model.WebhookDelivery
+	err := s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		event, err = s.outboxRepo.GetByID(txCtx, id)
+		if err != nil {
+			return err
+		}
+		delivery, err = s.deliveryRepo.GetByOutboxEventID(txCtx, id)
+		if err != nil && !errors.Is(err, apperr.ErrNotFound) {
+			return err
+		}
+
+		details := map[string]any{"outbox_status": event.Status}
+		replayed := false
+		if event.Status != model.OutboxStatusPublished {
+			requeued, err := s.outboxRepo.Requeue(txCtx, id)
+			if err != nil {
+				return err
+			}
+			if requeued != nil {
+				event, replayed = requeued, true
+			}
+		}
+		if delivery != nil {
+			details["webhook_status"] = delivery.Status
+			if delivery.Status != model.WebhookDeliveryDelivered {
+				requeued, err := s.deliveryRepo.Requeue(txCtx, delivery.ID)
+				if err != nil {
+					return err
+				}
+				if requeued != nil {
+					delivery, replayed = requeued, true
+				}
+			}
+		}
+		if !replayed {
+			return apperr.Errorf(apperr.ErrValidation, "event %d was already delivered to every consumer", id)
+		}
+
+		detailsJSON, err := json.Marshal(details)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit details: %w", err)
+		}
+		_, err = s.auditLogRepo.Create(txCtx, newAdminAuditLog(
+			txCtx, "outbox_event.replayed", "outbox_event", strconv.FormatInt(id, 10), detailsJSON, actorIP,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay event: %w", err)
+	}
+
+	s.log.WithField("event_id", id).WithField("event_type", event.EventType).Info("Event replayed")
+	return toAdminEventResponse(event, delivery), nil
+}
+
+// toAdminEventResponse converts an event and its webhook delivery to the admin view
+func toAdminEventResponse(event *model.OutboxEvent, delivery *model.WebhookDelivery) *dto.AdminEventResponse {
+	resp := &dto.AdminEventResponse{
+		ID:            event.ID,
+		AggregateType: event.AggregateType,
+		AggregateID:   event.AggregateID,
+		EventType:     event.EventType,
+		CorrelationID: event.CorrelationID,
+		CreatedAt:     event.CreatedAt,
+		Outbox: dto.AdminEventDeliveryResponse{
+			Status:      event.Status,
+			Attempts:    event.Attempts,
+			LastError:   event.LastError,
+			DeliveredAt: event.PublishedAt,
+		},
+	}
+	if event.Status == model.OutboxStatusPending {
+		nextAttemptAt := event.NextAttemptAt
+		resp.Outbox.NextAttemptAt = &nextAttemptAt
+	}
+
+	if delivery != nil {
+		resp.Webhook = &dto.AdminEventDeliveryResponse{
+			Status:      delivery.Status,
+			Attempts:    delivery.Attempts,
+			LastError:   delivery.LastError,
+			DeliveredAt: delivery.DeliveredAt,
+		}
+		if delivery.Status == model.WebhookDeliveryPending {
+			nextAttemptAt := delivery.NextAttemptAt
+			resp.Webhook.NextAttemptAt = &nextAttemptAt
+		}
+	}
+	return resp
+}
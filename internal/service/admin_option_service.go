@@ -0,0 +1,218 @@
+// Package service provides administrative option master data operations.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+// ErrOptionInUse is returned when deleting an option that users have selected; schedule
+// its end with valid_until instead
+var ErrOptionInUse = errors.New("option is selected by users")
+
+// AdminOptionService defines the interface for managing option master data
+type AdminOptionService interface {
+	ListOptions(ctx context.Context) (*dto.AdminOptionsResponse, error)
+	CreateOption(ctx context.Context, req *dto.AdminOptionCreateRequest, actorIP string) (*dto.AdminOptionResponse, error)
+	UpdateOption(
+		ctx context.Context, optionType string, req *dto.AdminOptionRequest, actorIP string,
+	) (*dto.AdminOptionResponse, error)
+	DeleteOption(ctx context.Context, optionType string, actorIP string) error
+}
+
+// adminOptionService implements AdminOptionService
+type adminOptionService struct {
+	optionRepo      repository.OptionRepository
+	userOptionRepo  repository.UserOptionRepository
+	auditLogRepo    repository.AuditLogRepository
+	txManager       repository.TxManager
+	masterDataCache repository.CacheInvalidator
+	validator       *validator.CustomValidator
+	log             *logger.Logger
+}
+
+// NewAdminOptionService creates a new admin option service
+func NewAdminOptionService(
+	optionRepo repository.OptionRepository,
+	userOptionRepo repository.UserOptionRepository,
+	auditLogRepo repository.AuditLogRepository,
+	txManager repository.TxManager,
+	masterDataCache repository.CacheInvalidator,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) AdminOptionService {
+	return &adminOptionService{
+		optionRepo:      optionRepo,
+		userOptionRepo:  userOptionRepo,
+		auditLogRepo:    auditLogRepo,
+		txManager:       txManager,
+		masterDataCache: masterDataCache,
+		validator:       validator,
+		log:             log,
+	}
+}
+
+// ListOptions returns all options, including inactive and scheduled ones
+func (s *adminOptionService) ListOptions(ctx context.Context) (*dto.AdminOptionsResponse, error) {
+	options, err := s.optionRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get options: %w", err)
+	}
+
+	resp := &dto.AdminOptionsResponse{Options: make([]dto.AdminOptionResponse, 0, len(options))}
+	for _, option := range options {
+		resp.Options = append(resp.Options, *toAdminOptionResponse(option))
+	}
+	return resp, nil
+}
+
+// CreateOption adds an option to the master data
+func (s *adminOptionService) CreateOption(
+	ctx context.Context, req *dto.AdminOptionCreateRequest, actorIP string,
+) (*dto.AdminOptionResponse, error) {
+	if err := s.validate(req, &req.AdminOptionRequest); err != nil {
+		return nil, err
+	}
+
+	option := newOptionMaster(req.OptionType, &req.AdminOptionRequest)
+
+	var created *model.OptionMaster
+	err := s.write(ctx, "option.created", req.OptionType, req, actorIP, func(txCtx context.Context) error {
+		var err error
+		created, err = s.optionRepo.Create(txCtx, option)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create option: %w", err)
+	}
+
+	return toAdminOptionResponse(created), nil
+}
+
+// UpdateOption replaces the settings of an option, including its validity period
+func (s *adminOptionService) UpdateOption(
+	ctx context.Context, optionType string, req *dto.AdminOptionRequest, actorIP string,
+) (*dto.AdminOptionResponse, error) {
+	if err := s.validate(req, req); err != nil {
+		return nil, err
+	}
+
+	option := newOptionMaster(optionType, req)
+
+	var updated *model.OptionMaster
+	err := s.write(ctx, "option.updated", optionType, req, actorIP, func(txCtx context.Context) error {
+		var err error
+		updated, err = s.optionRepo.Update(txCtx, option)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update option: %w", err)
+	}
+
+	return toAdminOptionResponse(updated), nil
+}
+
+// DeleteOption removes an option that no user has selected
+func (s *adminOptionService) DeleteOption(ctx context.Context, optionType string, actorIP string) error {
+	err := s.write(ctx, "option.deleted", optionType, nil, actorIP, func(txCtx context.Context) error {
+		inUse, err := s.userOptionRepo.ExistsByOptionType(txCtx, optionType)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			return ErrOptionInUse
+		}
+		return s.optionRepo.Delete(txCtx, optionType)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete option: %w", err)
+	}
+
+	return nil
+}
+
+// validate checks the request struct and the validity period
+func (s *adminOptionService) validate(req any, settings *dto.AdminOptionRequest) error {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+	if settings.ValidFrom != nil && settings.ValidUntil != nil && !settings.ValidFrom.Before(*settings.ValidUntil) {
+		return apperr.Errorf(apperr.ErrValidation, "validation errors: valid_from must be before valid_until")
+	}
+	return nil
+}
+
+// write runs change and its audit entry in one transaction, then drops the cached
+// master data so the change is visible immediately
+func (s *adminOptionService) write(
+	ctx context.Context, action, optionType string, details any, actorIP string,
+	change func(txCtx context.Context) error,
+) error {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+
+	err = s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := change(txCtx); err != nil {
+			return err
+		}
+
+		entry := newAdminAuditLog(txCtx, action, "option", optionType, detailsJSON, actorIP)
+		_, err := s.auditLogRepo.Create(txCtx, entry)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.masterDataCache.Invalidate()
+
+	s.log.WithContext(ctx).
+		WithField("action", action).
+		WithField("option_type", optionType).
+		Warn("Option master data changed")
+	return nil
+}
+
+// newOptionMaster builds the option model from an admin request
+func newOptionMaster(optionType string, req *dto.AdminOptionRequest) *model.OptionMaster {
+	return &model.OptionMaster{
+		OptionType:        optionType,
+		OptionName:        req.OptionName,
+		Description:       req.Description,
+		PlanCompatibility: req.PlanCompatibility,
+		MaxQuantity:       req.MaxQuantity,
+		MonthlyPrice:      *req.MonthlyPrice,
+		IsActive:          *req.IsActive,
+		ValidFrom:         req.ValidFrom,
+		ValidUntil:        req.ValidUntil,
+	}
+}
+
+// toAdminOptionResponse converts the option model to the admin representation
+func toAdminOptionResponse(option *model.OptionMaster) *dto.AdminOptionResponse {
+	return &dto.AdminOptionResponse{
+		OptionType:        option.OptionType,
+		OptionName:        option.OptionName,
+		Description:       option.Description,
+		PlanCompatibility: option.PlanCompatibility,
+		MaxQuantity:       option.MaxQuantity,
+		MonthlyPrice:      option.MonthlyPrice,
+		IsActive:          option.IsActive,
+		ValidFrom:         option.ValidFrom,
+		ValidUntil:        option.ValidUntil,
+		Available:         option.IsAvailableAt(time.Now()),
+		UpdatedAt:         option.UpdatedAt,
+	}
+}
@@ -0,0 +1,188 @@
+// Package service provides administrative plan master data operations.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+// AdminPlanService defines the interface for managing plan master data
+type AdminPlanService interface {
+	ListPlans(ctx context.Context) (*dto.AdminPlansResponse, error)
+	CreatePlan(ctx context.Context, req *dto.AdminPlanCreateRequest, actorIP string) (*dto.AdminPlanResponse, error)
+	UpdatePlan(ctx context.Context, planType string, req *dto.AdminPlanRequest, actorIP string) (*dto.AdminPlanResponse, error)
+	DeactivatePlan(ctx context.Context, planType string, actorIP string) (*dto.AdminPlanResponse, error)
+}
+
+// adminPlanService implements AdminPlanService
+type adminPlanService struct {
+	planRepo        repository.PlanRepository
+	auditLogRepo    repository.AuditLogRepository
+	txManager       repository.TxManager
+	masterDataCache repository.CacheInvalidator
+	validator       *validator.CustomValidator
+	log             *logger.Logger
+}
+
+// NewAdminPlanService creates a new admin plan service
+func NewAdminPlanService(
+	planRepo repository.PlanRepository,
+	auditLogRepo repository.AuditLogRepository,
+	txManager repository.TxManager,
+	masterDataCache repository.CacheInvalidator,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) AdminPlanService {
+	return &adminPlanService{
+		planRepo:        planRepo,
+		auditLogRepo:    auditLogRepo,
+		txManager:       txManager,
+		masterDataCache: masterDataCache,
+		validator:       validator,
+		log:             log,
+	}
+}
+
+// ListPlans returns all plans, including inactive ones
+func (s *adminPlanService) ListPlans(ctx context.Context) (*dto.AdminPlansResponse, error) {
+	plans, err := s.planRepo.GetAll(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plans: %w", err)
+	}
+
+	resp := &dto.AdminPlansResponse{Plans: make([]dto.AdminPlanResponse, 0, len(plans))}
+	for _, plan := range plans {
+		resp.Plans = append(resp.Plans, *toAdminPlanResponse(plan))
+	}
+	return resp, nil
+}
+
+// CreatePlan adds a plan to the master data
+func (s *adminPlanService) CreatePlan(
+	ctx context.Context, req *dto.AdminPlanCreateRequest, actorIP string,
+) (*dto.AdminPlanResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	plan := newPlanMaster(req.PlanType, &req.AdminPlanRequest)
+
+	var created *model.PlanMaster
+	err := s.write(ctx, "plan.created", req.PlanType, req, actorIP, func(txCtx context.Context) error {
+		var err error
+		created, err = s.planRepo.Create(txCtx, plan)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create plan: %w", err)
+	}
+
+	return toAdminPlanResponse(created), nil
+}
+
+// UpdatePlan replaces the settings of a plan
+func (s *adminPlanService) UpdatePlan(
+	ctx context.Context, planType string, req *dto.AdminPlanRequest, actorIP string,
+) (*dto.AdminPlanResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	plan := newPlanMaster(planType, req)
+
+	var updated *model.PlanMaster
+	err := s.write(ctx, "plan.updated", planType, req, actorIP, func(txCtx context.Context) error {
+		var err error
+		updated, err = s.planRepo.Update(txCtx, plan)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update plan: %w", err)
+	}
+
+	return toAdminPlanResponse(updated), nil
+}
+
+// DeactivatePlan stops offering a plan to new registrations. Plans are never deleted because
+// registrations reference them; users already on the plan keep it.
+func (s *adminPlanService) DeactivatePlan(
+	ctx context.Context, planType string, actorIP string,
+) (*dto.AdminPlanResponse, error) {
+	var updated *model.PlanMaster
+	err := s.write(ctx, "plan.deactivated", planType, nil, actorIP, func(txCtx context.Context) error {
+		var err error
+		updated, err = s.planRepo.Deactivate(txCtx, planType)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to deactivate plan: %w", err)
+	}
+
+	return toAdminPlanResponse(updated), nil
+}
+
+// write runs change and its audit entry in one transaction, then drops the cached
+// master data so the change is visible immediately
+func (s *adminPlanService) write(
+	ctx context.Context, action, planType string, details any, actorIP string,
+	change func(txCtx context.Context) error,
+) error {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+
+	err = s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := change(txCtx); err != nil {
+			return err
+		}
+
+		entry := newAdminAuditLog(txCtx, action, "plan", planType, detailsJSON, actorIP)
+		_, err := s.auditLogRepo.Create(txCtx, entry)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.masterDataCache.Invalidate()
+
+	s.log.WithContext(ctx).
+		WithField("action", action).
+		WithField("plan_type", planType).
+		Warn("Plan master data changed")
+	return nil
+}
+
+// newPlanMaster builds the plan model from an admin request
+func newPlanMaster(planType string, req *dto.AdminPlanRequest) *model.PlanMaster {
+	return &model.PlanMaster{
+		PlanType:     planType,
+		PlanName:     req.PlanName,
+		Description:  req.Description,
+		MonthlyPrice: *req.MonthlyPrice,
+		DisplayOrder: req.DisplayOrder,
+		IsActive:     *req.IsActive,
+	}
+}
+
+// toAdminPlanResponse converts the plan model to the admin representation
+func toAdminPlanResponse(plan *model.PlanMaster) *dto.AdminPlanResponse {
+	return &dto.AdminPlanResponse{
+		PlanType:     plan.PlanType,
+		PlanName:     plan.PlanName,
+		Description:  plan.Description,
+		MonthlyPrice: plan.MonthlyPrice,
+		DisplayOrder: plan.DisplayOrder,
+		IsActive:     plan.IsActive,
+		UpdatedAt:    plan.UpdatedAt,
+	}
+}
@@ -0,0 +1,215 @@
+// Package service provides administrative region restriction master data operations.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+// AdminRegionService defines the interface for managing region restriction master data
+type AdminRegionService interface {
+	ListRegionRestrictions(
+		ctx context.Context, req *dto.AdminRegionRestrictionListRequest,
+	) (*dto.AdminRegionRestrictionsResponse, error)
+	CreateRegionRestriction(
+		ctx context.Context, req *dto.AdminRegionRestrictionRequest, actorIP string,
+	) (*dto.AdminRegionRestrictionResponse, error)
+	UpdateRegionRestriction(
+		ctx context.Context, id int, req *dto.AdminRegionRestrictionRequest, actorIP string,
+	) (*dto.AdminRegionRestrictionResponse, error)
+	DeleteRegionRestriction(ctx context.Context, id int, actorIP string) error
+}
+
+// adminRegionService implements AdminRegionService
+type adminRegionService struct {
+	restrictionRepo repository.RegionRestrictionRepository
+	prefectureRepo  repository.PrefectureRepository
+	auditLogRepo    repository.AuditLogRepository
+	txManager       repository.TxManager
+	validator       *validator.CustomValidator
+	log             *logger.Logger
+}
+
+// NewAdminRegionService creates a new admin region service
+func NewAdminRegionService(
+	restrictionRepo repository.RegionRestrictionRepository,
+	prefectureRepo repository.PrefectureRepository,
+	auditLogRepo repository.AuditLogRepository,
+	txManager repository.TxManager,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) AdminRegionService {
+	return &adminRegionService{
+		restrictionRepo: restrictionRepo,
+		prefectureRepo:  prefectureRepo,
+		auditLogRepo:    auditLogRepo,
+		txManager:       txManager,
+		validator:       validator,
+		log:             log,
+	}
+}
+
+// ListRegionRestrictions returns the restrictions, optionally of one prefecture
+func (s *adminRegionService) ListRegionRestrictions(
+	ctx context.Context, req *dto.AdminRegionRestrictionListRequest,
+) (*dto.AdminRegionRestrictionsResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	restrictions, err := s.restrictionRepo.List(ctx, req.Prefecture)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get region restrictions: %w", err)
+	}
+
+	resp := &dto.AdminRegionRestrictionsResponse{
+		Restrictions: make([]dto.AdminRegionRestrictionResponse, 0, len(restrictions)),
+	}
+	for _, restriction := range restrictions {
+		resp.Restrictions = append(resp.Restrictions, *toAdminRegionRestrictionResponse(restriction))
+	}
+	return resp, nil
+}
+
+// CreateRegionRestriction adds a restriction to the master data
+func (s *adminRegionService) CreateRegionRestriction(
+	ctx context.Context, req *dto.AdminRegionRestrictionRequest, actorIP string,
+) (*dto.AdminRegionRestrictionResponse, error) {
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var created *model.RegionRestriction
+	err := s.write(ctx, "region_restriction.created", req, actorIP, func(txCtx context.Context) (int, error) {
+		var err error
+		created, err = s.restrictionRepo.Create(txCtx, newRegionRestriction(0, req))
+		if err != nil {
+			return 0, err
+		}
+		return created.ID, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create region restriction: %w", err)
+	}
+
+	return toAdminRegionRestrictionResponse(created), nil
+}
+
+// UpdateRegionRestriction replaces a restriction
+func (s *adminRegionService) UpdateRegionRestriction(
+	ctx context.Context, id int, req *dto.AdminRegionRestrictionRequest, actorIP string,
+) (*dto.AdminRegionRestrictionResponse, error) {
+	if err := s.validate(ctx, req); err != nil {
+		return nil, err
+	}
+
+	var updated *model.RegionRestriction
+	err := s.write(ctx, "region_restriction.updated", req, actorIP, func(txCtx context.Context) (int, error) {
+		var err error
+		updated, err = s.restrictionRepo.Update(txCtx, newRegionRestriction(id, req))
+		return id, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update region restriction: %w", err)
+	}
+
+	return toAdminRegionRestrictionResponse(updated), nil
+}
+
+// DeleteRegionRestriction removes a restriction, so the option falls back to the prefecture
+// entry or is allowed
+func (s *adminRegionService) DeleteRegionRestriction(ctx context.Context, id int, actorIP string) error {
+	err := s.write(ctx, "region_restriction.deleted", nil, actorIP, func(txCtx context.Context) (int, error) {
+		return id, s.restrictionRepo.Delete(txCtx, id)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete region restriction: %w", err)
+	}
+
+	return nil
+}
+
+// validate checks the request struct and that the prefecture exists, since a misspelled
+// prefecture would silently never match
+func (s *adminRegionService) validate(ctx context.Context, req *dto.AdminRegionRestrictionRequest) error {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	_, err := s.prefectureRepo.GetByName(ctx, req.Prefecture)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return apperr.Errorf(apperr.ErrValidation, "validation errors: unknown prefecture %s", req.Prefecture)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get prefecture: %w", err)
+	}
+	return nil
+}
+
+// write runs change and its audit entry in one transaction. change returns the ID of the
+// restriction it changed.
+func (s *adminRegionService) write(
+	ctx context.Context, action string, details any, actorIP string,
+	change func(txCtx context.Context) (int, error),
+) error {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+
+	var id int
+	err = s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		if id, err = change(txCtx); err != nil {
+			return err
+		}
+
+		entry := newAdminAuditLog(txCtx, action, "region_restriction", strconv.Itoa(id), detailsJSON, actorIP)
+		_, err = s.auditLogRepo.Create(txCtx, entry)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.log.WithContext(ctx).
+		WithField("action", action).
+		WithField("region_restriction_id", id).
+		Warn("Region restriction master data changed")
+	return nil
+}
+
+// newRegionRestriction builds the restriction model from an admin request
+func newRegionRestriction(id int, req *dto.AdminRegionRestrictionRequest) *model.RegionRestriction {
+	return &model.RegionRestriction{
+		ID:         id,
+		Prefecture: req.Prefecture,
+		City:       req.City,
+		OptionType: req.OptionType,
+		Allowed:    *req.Allowed,
+		Note:       req.Note,
+	}
+}
+
+// toAdminRegionRestrictionResponse converts the restriction model to the admin representation
+func toAdminRegionRestrictionResponse(restriction *model.RegionRestriction) *dto.AdminRegionRestrictionResponse {
+	return &dto.AdminRegionRestrictionResponse{
+		ID:         restriction.ID,
+		Prefecture: restriction.Prefecture,
+		City:       restriction.City,
+		OptionType: restriction.OptionType,
+		Allowed:    restriction.Allowed,
+		Note:       restriction.Note,
+		UpdatedAt:  restriction.UpdatedAt,
+	}
+}
@@ -0,0 +1,223 @@
+// Package service provides administration of option stock reservations.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+const (
+	// defaultReservationListLimit is the page size of admin reservation listings
+	defaultReservationListLimit = 50
+)
+
+// AdminReservationService defines the interface for inspecting and clearing option stock holds
+type AdminReservationService interface {
+	ListReservations(
+		ctx context.Context, req *dto.AdminReservationListRequest,
+	) (*dto.AdminReservationListResponse, error)
+	ReleaseReservation(ctx context.Context, id int, actorIP string) (*dto.AdminReservationResponse, error)
+	ExtendReservation(
+		ctx context.Context, id int, req *dto.AdminReservationExtendRequest, actorIP string,
+	) (*dto.AdminReservationResponse, error)
+}
+
+// adminReservationService implements AdminReservationService
+type adminReservationService struct {
+	reservationRepo repository.ReservationRepository
+	auditLogRepo    repository.AuditLogRepository
+	txManager       repository.TxManager
+	validator       *validator.CustomValidator
+	log             *logger.Logger
+}
+
+// NewAdminReservationService creates a new admin reservation service
+func NewAdminReservationService(
+	reservationRepo repository.ReservationRepository,
+	auditLogRepo repository.AuditLogRepository,
+	txManager repository.TxManager,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) AdminReservationService {
+	return &adminReservationService{
+		reservationRepo: reservationRepo,
+		auditLogRepo:    auditLogRepo,
+		txManager:       txManager,
+		validator:       validator,
+		log:             log,
+	}
+}
+
+// ListReservations lists reservations, held ones by default, oldest first
+func (s *adminReservationService) ListReservations(
+	ctx context.Context, req *dto.AdminReservationListRequest,
+) (*dto.AdminReservationListResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	status := req.Status
+	if status == "" {
+		status = model.ReservationStatusHeld
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultReservationListLimit
+	}
+	now := time.Now()
+	createdBefore := now.Add(-time.Duration(req.MinAgeMinutes) * time.Minute)
+
+	reservations, err := s.reservationRepo.List(
+		ctx, status, req.OptionType, req.SessionID, createdBefore, limit, req.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.AdminReservationListResponse{
+		Reservations: make([]*dto.AdminReservationResponse, 0, len(reservations)),
+	}
+	for _, reservation := range reservations {
+		resp.Reservations = append(resp.Reservations, toAdminReservationResponse(reservation, now))
+	}
+	return resp, nil
+}
+
+// ReleaseReservation returns the stock of a hold, for holds left behind by sessions that
+// will not register
+func (s *adminReservationService) ReleaseReservation(
+	ctx context.Context, id int, actorIP string,
+) (*dto.AdminReservationResponse, error) {
+	var released *model.OptionReservation
+	err := s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		reservation, err := s.reservationRepo.GetByIDForUpdate(txCtx, id)
+		if err != nil {
+			return err
+		}
+
+		released, err = s.reservationRepo.Release(txCtx, id)
+		if err != nil {
+			return err
+		}
+		if released == nil {
+			return apperr.Errorf(apperr.ErrValidation, "option reservation %d is already %s", id, reservation.Status)
+		}
+
+		return s.audit(txCtx, "option_reservation.released", id, map[string]any{
+			"session_id": reservation.SessionID, "option_type": reservation.OptionType,
+			"quantity": reservation.Quantity, "expires_at": reservation.ExpiresAt,
+		}, actorIP)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to release option reservation: %w", err)
+	}
+
+	s.log.WithContext(ctx).
+		WithField("reservation_id", id).
+		WithField("option_type", released.OptionType).
+		Info("Option reservation released by admin")
+	return toAdminReservationResponse(released, time.Now()), nil
+}
+
+// ExtendReservation moves the expiry of an unexpired hold, for sessions that need longer to
+// register. A hold never outlives its session, so the expiry is capped at the session's.
+func (s *adminReservationService) ExtendReservation(
+	ctx context.Context, id int, req *dto.AdminReservationExtendRequest, actorIP string,
+) (*dto.AdminReservationResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	var extended *model.OptionReservation
+	err := s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		reservation, err := s.reservationRepo.GetByIDForUpdate(txCtx, id)
+		if err != nil {
+			return err
+		}
+		if reservation.Status != model.ReservationStatusHeld {
+			return apperr.Errorf(apperr.ErrValidation, "option reservation %d is %s", id, reservation.Status)
+		}
+		// An expired hold no longer counts against stock, so extending it could hold stock
+		// another session has taken since
+		now := time.Now()
+		if !reservation.ExpiresAt.After(now) {
+			return apperr.Errorf(apperr.ErrValidation,
+				"option reservation %d has expired; the session must reserve the options again", id)
+		}
+		if reservation.SessionExpiresAt == nil || !reservation.SessionExpiresAt.After(now) {
+			return apperr.Errorf(apperr.ErrValidation, "the session of option reservation %d has ended", id)
+		}
+
+		expiresAt := reservation.ExpiresAt.Add(time.Duration(req.Minutes) * time.Minute)
+		if expiresAt.After(*reservation.SessionExpiresAt) {
+			expiresAt = *reservation.SessionExpiresAt
+		}
+		if !expiresAt.After(reservation.ExpiresAt) {
+			return apperr.Errorf(apperr.ErrValidation,
+				"option reservation %d already lasts until its session expires; extend the session first", id)
+		}
+
+		extended, err = s.reservationRepo.Extend(txCtx, id, expiresAt)
+		if err != nil {
+			return err
+		}
+		if extended == nil {
+			return apperr.Errorf(apperr.ErrValidation, "option reservation %d is no longer held", id)
+		}
+
+		return s.audit(txCtx, "option_reservation.extended", id, map[string]any{
+			"session_id": reservation.SessionID, "option_type": reservation.OptionType,
+			"previous_expires_at": reservation.ExpiresAt, "expires_at": expiresAt,
+		}, actorIP)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extend option reservation: %w", err)
+	}
+
+	s.log.WithContext(ctx).
+		WithField("reservation_id", id).
+		WithField("expires_at", extended.ExpiresAt).
+		Info("Option reservation extended by admin")
+	return toAdminReservationResponse(extended, time.Now()), nil
+}
+
+// audit records an admin action on a reservation
+func (s *adminReservationService) audit(
+	ctx context.Context, action string, id int, details map[string]any, actorIP string,
+) error {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+	_, err = s.auditLogRepo.Create(ctx, newAdminAuditLog(
+		ctx, action, "option_reservation", strconv.Itoa(id), detailsJSON, actorIP,
+	))
+	return err
+}
+
+// toAdminReservationResponse converts a reservation to the admin view as of now
+func toAdminReservationResponse(reservation *model.OptionReservation, now time.Time) *dto.AdminReservationResponse {
+	return &dto.AdminReservationResponse{
+		ID:               reservation.ID,
+		SessionID:        reservation.SessionID,
+		OptionType:       reservation.OptionType,
+		Quantity:         reservation.Quantity,
+		Status:           reservation.Status,
+		UserID:           reservation.UserID,
+		ExpiresAt:        reservation.ExpiresAt,
+		SessionExpiresAt: reservation.SessionExpiresAt,
+		AgeSeconds:       int64(now.Sub(reservation.CreatedAt).Seconds()),
+		CreatedAt:        reservation.CreatedAt,
+		UpdatedAt:        reservation.UpdatedAt,
+	}
+}
@@ -0,0 +1,352 @@
+// Package service provides administrative user operations.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/correlation"
+	"github.com/octop162/normal-form-app-by-claude/pkg/events"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+const (
+	// Number of users changed per transaction in bulk operations
+	bulkStatusChunkSize = 100
+)
+
+// AdminUserService defines the interface for administrative user operations
+type AdminUserService interface {
+	GetUser(ctx context.Context, userID int) (*dto.AdminUserResponse, error)
+	FindDuplicates(ctx context.Context, userID int) (*dto.AdminUserDuplicatesResponse, error)
+	BulkUpdateStatus(ctx context.Context, req *dto.BulkUserStatusRequest, actorIP string) (*dto.BulkUserStatusResponse, error)
+	SetLegalHold(ctx context.Context, userID int, req *dto.LegalHoldRequest, actorIP string) (*dto.LegalHoldResponse, error)
+}
+
+// adminUserService implements AdminUserService
+type adminUserService struct {
+	userRepo     repository.UserRepository
+	auditLogRepo repository.AuditLogRepository
+	outboxRepo   repository.OutboxRepository
+	txManager    repository.TxManager
+	duplicates   DuplicateService
+	validator    *validator.CustomValidator
+	log          *logger.Logger
+}
+
+// NewAdminUserService creates a new admin user service
+func NewAdminUserService(
+	userRepo repository.UserRepository,
+	auditLogRepo repository.AuditLogRepository,
+	outboxRepo repository.OutboxRepository,
+	txManager repository.TxManager,
+	duplicates DuplicateService,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) AdminUserService {
+	return &adminUserService{
+		userRepo:     userRepo,
+		auditLogRepo: auditLogRepo,
+		outboxRepo:   outboxRepo,
+		txManager:    txManager,
+		duplicates:   duplicates,
+		validator:    validator,
+		log:          log,
+	}
+}
+
+// GetUser retrieves a user with its legal hold and email deliverability
+func (s *adminUserService) GetUser(ctx context.Context, userID int) (*dto.AdminUserResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.AdminUserResponse{
+		UserResponse: *toUserResponse(user),
+		LegalHold:    user.LegalHold,
+		EmailDeliverability: dto.EmailDeliverabilityResponse{
+			Status:     user.EmailDeliverability,
+			Suppressed: user.EmailSuppressed(),
+			Reason:     user.EmailDeliverabilityReason,
+			UpdatedAt:  user.EmailDeliverabilityUpdatedAt,
+		},
+	}, nil
+}
+
+// FindDuplicates lists the users that may be the same person as a user, running every
+// configured duplicate detection strategy regardless of the confidence that rejects
+// registrations, for administrators deciding which registrations to merge
+func (s *adminUserService) FindDuplicates(ctx context.Context, userID int) (*dto.AdminUserDuplicatesResponse, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	matches, err := s.duplicates.FindCandidates(ctx, userToRequest(user, nil), user.ID)
+	if err != nil {
+		s.log.WithContext(ctx).WithError(err).WithField("user_id", userID).Error("Failed to find duplicate users")
+		return nil, fmt.Errorf("failed to find duplicate users: %w", err)
+	}
+
+	// Matches come most confident first, so candidates keep that order
+	resp := &dto.AdminUserDuplicatesResponse{UserID: userID, Candidates: []dto.AdminDuplicateCandidate{}}
+	positions := make(map[int]int)
+	for _, match := range matches {
+		position, ok := positions[match.UserID]
+		if !ok {
+			candidate, err := s.userRepo.GetByID(ctx, match.UserID)
+			if errors.Is(err, apperr.ErrNotFound) {
+				// Deleted since it was matched
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			position = len(resp.Candidates)
+			positions[match.UserID] = position
+			resp.Candidates = append(resp.Candidates, dto.AdminDuplicateCandidate{
+				User:       *toUserResponse(candidate),
+				Confidence: match.Confidence,
+			})
+		}
+		resp.Candidates[position].Matches = append(resp.Candidates[position].Matches, dto.AdminDuplicateMatch{
+			Strategy:   match.Strategy,
+			Confidence: match.Confidence,
+		})
+	}
+
+	return resp, nil
+}
+
+// BulkUpdateStatus suspends or activates the given users. Users are processed in chunks,
+// each in its own transaction: a failing chunk is rolled back and reported as failed
+// without affecting chunks that were already committed.
+func (s *adminUserService) BulkUpdateStatus(
+	ctx context.Context, req *dto.BulkUserStatusRequest, actorIP string,
+) (*dto.BulkUserStatusResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	status := model.UserStatusActive
+	if req.Action == dto.BulkUserStatusActionSuspend {
+		status = model.UserStatusSuspended
+	}
+
+	userIDs := uniqueIDs(req.UserIDs)
+	resp := &dto.BulkUserStatusResponse{
+		Action:  req.Action,
+		Status:  status,
+		Total:   len(userIDs),
+		Results: make([]dto.BulkUserStatusResult, 0, len(userIDs)),
+	}
+
+	for start := 0; start < len(userIDs); start += bulkStatusChunkSize {
+		chunk := userIDs[start:min(start+bulkStatusChunkSize, len(userIDs))]
+
+		var results []dto.BulkUserStatusResult
+		err := s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+			results = make([]dto.BulkUserStatusResult, 0, len(chunk))
+			for _, userID := range chunk {
+				result, err := s.changeStatus(txCtx, userID, status, req, actorIP)
+				if err != nil {
+					return err
+				}
+				results = append(results, result)
+			}
+			return nil
+		})
+		if err != nil {
+			s.log.WithContext(ctx).WithError(err).
+				WithField("first_user_id", chunk[0]).
+				WithField("chunk_size", len(chunk)).
+				Error("Bulk status chunk failed and was rolled back")
+
+			results = make([]dto.BulkUserStatusResult, 0, len(chunk))
+			for _, userID := range chunk {
+				results = append(results, dto.BulkUserStatusResult{
+					UserID: userID,
+					Result: dto.BulkUserStatusResultFailed,
+					Error:  err.Error(),
+				})
+			}
+		}
+
+		resp.Results = append(resp.Results, results...)
+	}
+
+	for _, result := range resp.Results {
+		switch result.Result {
+		case dto.BulkUserStatusResultUpdated:
+			resp.Updated++
+		case dto.BulkUserStatusResultUnchanged:
+			resp.Unchanged++
+		case dto.BulkUserStatusResultNotFound:
+			resp.NotFound++
+		case dto.BulkUserStatusResultFailed:
+			resp.Failed++
+		}
+	}
+
+	s.log.WithContext(ctx).
+		WithField("action", req.Action).
+		WithField("total", resp.Total).
+		WithField("updated", resp.Updated).
+		WithField("failed", resp.Failed).
+		Info("Bulk user status change completed")
+
+	return resp, nil
+}
+
+// SetLegalHold places or releases a legal hold, which blocks deletion and anonymization
+func (s *adminUserService) SetLegalHold(
+	ctx context.Context, userID int, req *dto.LegalHoldRequest, actorIP string,
+) (*dto.LegalHoldResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+	enabled := *req.Enabled
+
+	action := "user.legal_hold_released"
+	if enabled {
+		action = "user.legal_hold_placed"
+	}
+
+	details, err := json.Marshal(map[string]any{"legal_hold": enabled, "reason": req.Reason})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+
+	err = s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.userRepo.SetLegalHold(txCtx, userID, enabled); err != nil {
+			return err
+		}
+
+		_, err := s.auditLogRepo.Create(txCtx, s.newAuditLog(txCtx, action, userID, details, actorIP))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set legal hold: %w", err)
+	}
+
+	s.log.WithContext(ctx).
+		WithField("user_id", userID).
+		WithField("legal_hold", enabled).
+		Warn("User legal hold changed")
+
+	return &dto.LegalHoldResponse{UserID: userID, LegalHold: enabled}, nil
+}
+
+// changeStatus applies the status to a single user inside the chunk transaction,
+// recording an audit entry and a user.status_changed event when it changes
+func (s *adminUserService) changeStatus(
+	ctx context.Context, userID int, status string, req *dto.BulkUserStatusRequest, actorIP string,
+) (dto.BulkUserStatusResult, error) {
+	result := dto.BulkUserStatusResult{UserID: userID}
+
+	previousStatus, err := s.userRepo.GetStatusForUpdate(ctx, userID)
+	if err != nil {
+		if isUserNotFound(err) {
+			result.Result = dto.BulkUserStatusResultNotFound
+			return result, nil
+		}
+		return result, err
+	}
+
+	result.PreviousStatus = previousStatus
+	result.Status = status
+	if previousStatus == status {
+		result.Result = dto.BulkUserStatusResultUnchanged
+		return result, nil
+	}
+
+	if err := s.userRepo.UpdateStatus(ctx, userID, status); err != nil {
+		return result, err
+	}
+
+	payload, err := json.Marshal(&events.UserStatusChangedData{
+		UserID:         userID,
+		PreviousStatus: previousStatus,
+		Status:         status,
+		Reason:         req.Reason,
+		ChangedAt:      time.Now(),
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to marshal user status changed event: %w", err)
+	}
+
+	entry := s.newAuditLog(ctx, "user."+req.Action, userID, payload, actorIP)
+	if _, err := s.auditLogRepo.Create(ctx, entry); err != nil {
+		return result, err
+	}
+
+	_, err = s.outboxRepo.Create(ctx, &model.OutboxEvent{
+		AggregateType: "user",
+		AggregateID:   strconv.Itoa(userID),
+		EventType:     events.EventTypeUserStatusChanged,
+		Payload:       payload,
+		CorrelationID: entry.CorrelationID,
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to record user status changed event: %w", err)
+	}
+
+	result.Result = dto.BulkUserStatusResultUpdated
+	return result, nil
+}
+
+// newAuditLog builds an audit entry for an admin change to a user
+func (s *adminUserService) newAuditLog(
+	ctx context.Context, action string, userID int, details json.RawMessage, actorIP string,
+) *model.AuditLog {
+	return newAdminAuditLog(ctx, action, "user", strconv.Itoa(userID), details, actorIP)
+}
+
+// newAdminAuditLog builds an audit entry for a change made through the admin API
+func newAdminAuditLog(
+	ctx context.Context, action, resourceType, resourceID string, details json.RawMessage, actorIP string,
+) *model.AuditLog {
+	entry := &model.AuditLog{
+		Actor:        model.AuditActorAdminAPI,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+		Details:      details,
+	}
+	if actorIP != "" {
+		entry.ActorIP = &actorIP
+	}
+	if id := correlation.CorrelationID(ctx); id != "" {
+		entry.CorrelationID = &id
+	}
+	return entry
+}
+
+// uniqueIDs removes duplicate IDs while keeping the original order
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]struct{}, len(ids))
+	unique := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+	return unique
+}
+
+// isUserNotFound reports whether a repository error means the user does not exist
+func isUserNotFound(err error) bool {
+	return errors.Is(err, apperr.ErrNotFound)
+}
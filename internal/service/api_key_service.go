@@ -0,0 +1,277 @@
+// Package service provides partner API key administration and authentication.
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+const (
+	// apiKeyPrefix starts every partner API key so leaked keys are easy to recognize
+	apiKeyPrefix = "pk_"
+	// apiKeyRandomBytes is the entropy of a key
+	apiKeyRandomBytes = 24
+	// apiKeyDisplayLength is the number of leading characters stored to tell keys apart
+	apiKeyDisplayLength = len(apiKeyPrefix) + 8
+	// usageMonthLayout is the layout of the month of a usage report
+	usageMonthLayout = "2006-01"
+	// defaultUsageGranularity is the period usage reports are broken down by
+	defaultUsageGranularity = "day"
+)
+
+// APIKeyService defines the interface for partner API keys
+type APIKeyService interface {
+	Authenticate(ctx context.Context, key string) (*model.APIKey, error)
+	CreateKey(ctx context.Context, req *dto.AdminAPIKeyCreateRequest, actorIP string) (*dto.AdminAPIKeyCreatedResponse, error)
+	ListKeys(ctx context.Context) (*dto.AdminAPIKeyListResponse, error)
+	RevokeKey(ctx context.Context, id int64, actorIP string) (*dto.AdminAPIKeyResponse, error)
+	GetUsage(ctx context.Context, id int64, req *dto.AdminAPIKeyUsageRequest) (*dto.AdminAPIKeyUsageResponse, error)
+}
+
+// apiKeyService implements APIKeyService
+type apiKeyService struct {
+	keyRepo      repository.APIKeyRepository
+	usageRepo    repository.APIKeyUsageRepository
+	auditLogRepo repository.AuditLogRepository
+	txManager    repository.TxManager
+	validator    *validator.CustomValidator
+	log          *logger.Logger
+}
+
+// NewAPIKeyService creates a new API key service
+func NewAPIKeyService(
+	keyRepo repository.APIKeyRepository,
+	usageRepo repository.APIKeyUsageRepository,
+	auditLogRepo repository.AuditLogRepository,
+	txManager repository.TxManager,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) APIKeyService {
+	return &apiKeyService{
+		keyRepo:      keyRepo,
+		usageRepo:    usageRepo,
+		auditLogRepo: auditLogRepo,
+		txManager:    txManager,
+		validator:    validator,
+		log:          log,
+	}
+}
+
+// Authenticate returns the key a partner sent, or nil when it is unknown or revoked
+func (s *apiKeyService) Authenticate(ctx context.Context, key string) (*model.APIKey, error) {
+	stored, err := s.keyRepo.GetByHash(ctx, hashAPIKey(key))
+	if errors.Is(err, apperr.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stored.IsRevoked() {
+		s.log.WithContext(ctx).WithField("api_key_id", stored.ID).Warn("Revoked partner API key used")
+		return nil, nil
+	}
+	return stored, nil
+}
+
+// CreateKey issues a key to a partner. The key is returned once and only its hash is kept.
+func (s *apiKeyService) CreateKey(
+	ctx context.Context, req *dto.AdminAPIKeyCreateRequest, actorIP string,
+) (*dto.AdminAPIKeyCreatedResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	random, err := randomHex(apiKeyRandomBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	key := apiKeyPrefix + random
+
+	scopes := slices.Clone(req.Scopes)
+	slices.Sort(scopes)
+	scopes = slices.Compact(scopes)
+
+	var created *model.APIKey
+	err = s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		created, err = s.keyRepo.Create(txCtx, &model.APIKey{
+			Partner:   req.Partner,
+			KeyPrefix: key[:apiKeyDisplayLength],
+			KeyHash:   hashAPIKey(key),
+			Scopes:    scopes,
+			Note:      req.Note,
+		})
+		if err != nil {
+			return err
+		}
+
+		details, err := json.Marshal(map[string]any{"partner": created.Partner, "scopes": created.Scopes})
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit details: %w", err)
+		}
+		_, err = s.auditLogRepo.Create(txCtx, newAdminAuditLog(
+			txCtx, "api_key.created", "api_key", strconv.FormatInt(created.ID, 10), details, actorIP,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	s.log.WithField("api_key_id", created.ID).WithField("partner", created.Partner).Warn("Partner API key created")
+	return &dto.AdminAPIKeyCreatedResponse{AdminAPIKeyResponse: toAdminAPIKeyResponse(created), Key: key}, nil
+}
+
+// ListKeys lists every key, including revoked keys
+func (s *apiKeyService) ListKeys(ctx context.Context) (*dto.AdminAPIKeyListResponse, error) {
+	keys, err := s.keyRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.AdminAPIKeyListResponse{Keys: make([]*dto.AdminAPIKeyResponse, 0, len(keys))}
+	for _, key := range keys {
+		resp.Keys = append(resp.Keys, toAdminAPIKeyResponse(key))
+	}
+	return resp, nil
+}
+
+// RevokeKey revokes a key; requests with it are rejected from now on
+func (s *apiKeyService) RevokeKey(ctx context.Context, id int64, actorIP string) (*dto.AdminAPIKeyResponse, error) {
+	var revoked *model.APIKey
+	err := s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		revoked, err = s.keyRepo.Revoke(txCtx, id)
+		if err != nil {
+			return err
+		}
+
+		details, err := json.Marshal(map[string]any{"partner": revoked.Partner})
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit details: %w", err)
+		}
+		_, err = s.auditLogRepo.Create(txCtx, newAdminAuditLog(
+			txCtx, "api_key.revoked", "api_key", strconv.FormatInt(id, 10), details, actorIP,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	s.log.WithField("api_key_id", id).WithField("partner", revoked.Partner).Warn("Partner API key revoked")
+	return toAdminAPIKeyResponse(revoked), nil
+}
+
+// GetUsage reports the usage of a key in a month, in total, per endpoint and per endpoint
+// and hour or day. Periods are in UTC.
+func (s *apiKeyService) GetUsage(
+	ctx context.Context, id int64, req *dto.AdminAPIKeyUsageRequest,
+) (*dto.AdminAPIKeyUsageResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	from := time.Now().UTC()
+	from = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if req.Month != "" {
+		var err error
+		if from, err = time.Parse(usageMonthLayout, req.Month); err != nil {
+			return nil, apperr.Errorf(apperr.ErrValidation, "invalid month %q: %w", req.Month, err)
+		}
+	}
+	granularity := req.Granularity
+	if granularity == "" {
+		granularity = defaultUsageGranularity
+	}
+
+	key, err := s.keyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.usageRepo.Summarize(ctx, id, from, from.AddDate(0, 1, 0), granularity)
+	if err != nil {
+		return nil, err
+	}
+
+	var total model.APIKeyUsage
+	endpoints := make(map[string]*model.APIKeyUsage)
+	resp := &dto.AdminAPIKeyUsageResponse{
+		APIKeyID:    key.ID,
+		Partner:     key.Partner,
+		Month:       from.Format(usageMonthLayout),
+		Granularity: granularity,
+		Endpoints:   []*dto.AdminAPIKeyUsageEntry{},
+		Usage:       make([]*dto.AdminAPIKeyUsageEntry, 0, len(usage)),
+	}
+	for _, u := range usage {
+		total.Add(u)
+		if endpoints[u.Endpoint] == nil {
+			endpoints[u.Endpoint] = &model.APIKeyUsage{Endpoint: u.Endpoint}
+		}
+		endpoints[u.Endpoint].Add(u)
+
+		periodStart := u.PeriodStart.UTC()
+		resp.Usage = append(resp.Usage, &dto.AdminAPIKeyUsageEntry{
+			PeriodStart:       &periodStart,
+			Endpoint:          u.Endpoint,
+			APIKeyUsageCounts: toAPIKeyUsageCounts(u),
+		})
+	}
+
+	resp.Total = toAPIKeyUsageCounts(&total)
+	for _, endpoint := range slices.Sorted(maps.Keys(endpoints)) {
+		resp.Endpoints = append(resp.Endpoints, &dto.AdminAPIKeyUsageEntry{
+			Endpoint:          endpoint,
+			APIKeyUsageCounts: toAPIKeyUsageCounts(endpoints[endpoint]),
+		})
+	}
+	return resp, nil
+}
+
+// hashAPIKey returns the hash a key is stored and looked up by. Keys are random, so an
+// unsalted hash cannot be reversed.
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// toAdminAPIKeyResponse converts a key to its admin view
+func toAdminAPIKeyResponse(key *model.APIKey) *dto.AdminAPIKeyResponse {
+	return &dto.AdminAPIKeyResponse{
+		ID:        key.ID,
+		Partner:   key.Partner,
+		KeyPrefix: key.KeyPrefix,
+		Scopes:    key.Scopes,
+		Note:      key.Note,
+		CreatedAt: key.CreatedAt,
+		RevokedAt: key.RevokedAt,
+	}
+}
+
+// toAPIKeyUsageCounts converts metered usage to its report form
+func toAPIKeyUsageCounts(usage *model.APIKeyUsage) dto.APIKeyUsageCounts {
+	return dto.APIKeyUsageCounts{
+		Requests:      usage.RequestCount,
+		RequestBytes:  usage.RequestBytes,
+		ResponseBytes: usage.ResponseBytes,
+		ClientErrors:  usage.ClientErrorCount,
+		ServerErrors:  usage.ServerErrorCount,
+		ErrorRate:     usage.ErrorRate(),
+	}
+}
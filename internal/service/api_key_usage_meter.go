@@ -0,0 +1,133 @@
+// Package service provides metering of partner API usage.
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const (
+	// usageMeterPeriod is the period usage is counted in
+	usageMeterPeriod = time.Hour
+	// usageFlushTimeout bounds the final flush when the meter stops
+	usageFlushTimeout = 10 * time.Second
+)
+
+// usageKey identifies a usage counter
+type usageKey struct {
+	apiKeyID    int64
+	periodStart time.Time
+	endpoint    string
+}
+
+// APIKeyUsageMeter counts partner API requests, their byte volumes and errors per key, hour
+// and endpoint in memory and adds the counts to the api_key_usage table periodically, so metering does not write to
+// the database on every request. Counts not yet flushed are lost if the process crashes.
+type APIKeyUsageMeter struct {
+	usageRepo     repository.APIKeyUsageRepository
+	flushInterval time.Duration
+	log           *logger.Logger
+
+	mu     sync.Mutex
+	counts map[usageKey]*model.APIKeyUsage
+}
+
+// NewAPIKeyUsageMeter creates a new usage meter flushing every flushInterval
+func NewAPIKeyUsageMeter(
+	usageRepo repository.APIKeyUsageRepository, flushInterval time.Duration, log *logger.Logger,
+) *APIKeyUsageMeter {
+	return &APIKeyUsageMeter{
+		usageRepo:     usageRepo,
+		flushInterval: flushInterval,
+		log:           log,
+		counts:        make(map[usageKey]*model.APIKeyUsage),
+	}
+}
+
+// Record counts a request of a key to an endpoint answered with status
+func (m *APIKeyUsageMeter) Record(apiKeyID int64, endpoint string, status int, requestBytes, responseBytes int64) {
+	key := usageKey{
+		apiKeyID:    apiKeyID,
+		periodStart: time.Now().UTC().Truncate(usageMeterPeriod),
+		endpoint:    endpoint,
+	}
+	request := &model.APIKeyUsage{RequestCount: 1, RequestBytes: requestBytes, ResponseBytes: responseBytes}
+	switch {
+	case status >= 500:
+		request.ServerErrorCount = 1
+	case status >= 400:
+		request.ClientErrorCount = 1
+	}
+
+	m.mu.Lock()
+	m.add(key, request)
+	m.mu.Unlock()
+}
+
+// add adds usage to the counts of key; the caller holds mu
+func (m *APIKeyUsageMeter) add(key usageKey, usage *model.APIKeyUsage) {
+	counts, ok := m.counts[key]
+	if !ok {
+		counts = &model.APIKeyUsage{APIKeyID: key.apiKeyID, PeriodStart: key.periodStart, Endpoint: key.endpoint}
+		m.counts[key] = counts
+	}
+	counts.Add(usage)
+}
+
+// Run flushes counts every flush interval until the context is cancelled, then flushes
+// once more so a graceful shutdown loses nothing
+func (m *APIKeyUsageMeter) Run(ctx context.Context) {
+	m.log.WithField("flush_interval", m.flushInterval).Info("API key usage meter started")
+
+	ticker := time.NewTicker(m.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), usageFlushTimeout)
+			if err := m.Flush(flushCtx); err != nil {
+				m.log.WithError(err).Error("Final API key usage flush failed")
+			}
+			cancel()
+			m.log.Info("API key usage meter stopped")
+			return
+		case <-ticker.C:
+			if err := m.Flush(ctx); err != nil {
+				m.log.WithError(err).Error("API key usage flush failed")
+			}
+		}
+	}
+}
+
+// Flush adds the counted requests to the database. Counts that fail to be written are
+// kept for the next flush.
+func (m *APIKeyUsageMeter) Flush(ctx context.Context) error {
+	m.mu.Lock()
+	counts := m.counts
+	m.counts = make(map[usageKey]*model.APIKeyUsage)
+	m.mu.Unlock()
+
+	var failed int
+	var lastErr error
+	for key, usage := range counts {
+		if err := m.usageRepo.Add(ctx, usage); err != nil {
+			m.mu.Lock()
+			m.add(key, usage)
+			m.mu.Unlock()
+			failed++
+			lastErr = err
+		}
+	}
+
+	if lastErr != nil {
+		return fmt.Errorf("failed to flush %d of %d usage counters: %w", failed, len(counts), lastErr)
+	}
+	return nil
+}
@@ -0,0 +1,224 @@
+// Package service provides the background malware scanner for uploaded attachments.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/events"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/scan"
+)
+
+const (
+	// Upper bound for the delay between scan attempts
+	maxScanRetryDelay = 30 * time.Minute
+)
+
+// AttachmentScannerConfig holds attachment scanner settings
+type AttachmentScannerConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// MaxAttempts is the number of failed scans after which an attachment stays pending
+	// for an administrator to look at
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+}
+
+// AttachmentScanner polls pending attachments and scans them. Clean attachments become
+// linkable; infected ones are moved to quarantine storage and reported through the
+// attachment.infected outbox event.
+type AttachmentScanner struct {
+	txManager      repository.TxManager
+	attachmentRepo repository.AttachmentRepository
+	outboxRepo     repository.OutboxRepository
+	scanner        scan.Scanner
+	stores         AttachmentStores
+	config         AttachmentScannerConfig
+	log            *logger.Logger
+}
+
+// NewAttachmentScanner creates a new attachment scanner
+func NewAttachmentScanner(
+	txManager repository.TxManager,
+	attachmentRepo repository.AttachmentRepository,
+	outboxRepo repository.OutboxRepository,
+	scanner scan.Scanner,
+	stores AttachmentStores,
+	config AttachmentScannerConfig,
+	log *logger.Logger,
+) *AttachmentScanner {
+	return &AttachmentScanner{
+		txManager:      txManager,
+		attachmentRepo: attachmentRepo,
+		outboxRepo:     outboxRepo,
+		scanner:        scanner,
+		stores:         stores,
+		config:         config,
+		log:            log,
+	}
+}
+
+// Run scans pending attachments until the context is cancelled
+func (s *AttachmentScanner) Run(ctx context.Context) {
+	s.log.WithField("poll_interval", s.config.PollInterval).Info("Attachment scanner started")
+
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the backlog before waiting for the next tick
+		for {
+			processed, err := s.ProcessBatch(ctx)
+			if err != nil {
+				s.log.WithError(err).Error("Attachment scan batch failed")
+				break
+			}
+			if processed < s.config.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			s.log.Info("Attachment scanner stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch claims and scans one batch of attachments, returning the number processed
+func (s *AttachmentScanner) ProcessBatch(ctx context.Context) (int, error) {
+	processed := 0
+
+	err := s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		attachments, err := s.attachmentRepo.ClaimPending(txCtx, s.config.BatchSize, s.config.MaxAttempts)
+		if err != nil {
+			return err
+		}
+
+		for _, attachment := range attachments {
+			if err := s.scanAttachment(txCtx, attachment); err != nil {
+				return err
+			}
+			processed++
+		}
+		return nil
+	})
+	if err != nil {
+		return processed, fmt.Errorf("failed to process attachment scan batch: %w", err)
+	}
+
+	return processed, nil
+}
+
+// scanAttachment scans a single attachment and records the verdict. Scanner failures are
+// recorded for a later retry; only storage and database failures are returned.
+func (s *AttachmentScanner) scanAttachment(ctx context.Context, attachment *model.Attachment) error {
+	result, scanErr := s.scan(ctx, attachment)
+	if scanErr != nil {
+		attempts := attachment.ScanAttempts + 1
+		entry := s.log.WithError(scanErr).
+			WithField("attachment_id", attachment.ID).
+			WithField("attempts", attempts)
+		if attempts >= s.config.MaxAttempts {
+			entry.Error("Attachment scan failed permanently, attachment stays pending")
+		} else {
+			entry.Warn("Attachment scan failed, will retry")
+		}
+		return s.attachmentRepo.MarkScanFailed(ctx, attachment.ID, scanErr.Error(),
+			time.Now().Add(s.retryDelay(attempts)))
+	}
+
+	if !result.Infected {
+		s.log.WithField("attachment_id", attachment.ID).Info("Attachment scanned clean")
+		return s.attachmentRepo.MarkClean(ctx, attachment.ID)
+	}
+
+	return s.quarantine(ctx, attachment, result.Signature)
+}
+
+// scan streams the stored content to the scanner
+func (s *AttachmentScanner) scan(ctx context.Context, attachment *model.Attachment) (*scan.Result, error) {
+	content, err := s.stores.Uploads.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	return s.scanner.Scan(ctx, content)
+}
+
+// quarantine moves infected content out of upload storage, marks the attachment infected
+// and records the attachment.infected event for webhook consumers and administrators
+func (s *AttachmentScanner) quarantine(ctx context.Context, attachment *model.Attachment, signature string) error {
+	content, err := s.stores.Uploads.Open(ctx, attachment.StorageKey)
+	if err != nil {
+		return fmt.Errorf("failed to open infected attachment: %w", err)
+	}
+	err = s.stores.Quarantine.Save(ctx, attachment.ID, content)
+	content.Close()
+	if err != nil {
+		return fmt.Errorf("failed to quarantine attachment: %w", err)
+	}
+
+	if err := s.attachmentRepo.MarkInfected(ctx, attachment.ID, signature, attachment.ID); err != nil {
+		return err
+	}
+	if err := s.recordInfectedEvent(ctx, attachment, signature); err != nil {
+		return err
+	}
+
+	// Removed last, so a failure rolls back the verdict and the next attempt repeats the move
+	if err := s.stores.Uploads.Delete(ctx, attachment.StorageKey); err != nil {
+		return fmt.Errorf("failed to remove infected attachment: %w", err)
+	}
+
+	// Logged at error level so that log-based alerting notifies administrators
+	s.log.WithField("attachment_id", attachment.ID).
+		WithField("signature", signature).
+		WithField("sha256", attachment.SHA256).
+		Error("Infected attachment quarantined")
+	return nil
+}
+
+// recordInfectedEvent writes the attachment.infected event to the outbox
+func (s *AttachmentScanner) recordInfectedEvent(ctx context.Context, attachment *model.Attachment, signature string) error {
+	payload, err := json.Marshal(&events.AttachmentInfectedData{
+		AttachmentID:  attachment.ID,
+		Filename:      attachment.Filename,
+		ContentType:   attachment.ContentType,
+		SHA256:        attachment.SHA256,
+		Signature:     signature,
+		QuarantinedAt: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal attachment infected event: %w", err)
+	}
+
+	_, err = s.outboxRepo.Create(ctx, &model.OutboxEvent{
+		AggregateType: "attachment",
+		AggregateID:   attachment.ID,
+		EventType:     events.EventTypeAttachmentInfected,
+		Payload:       payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record attachment infected event: %w", err)
+	}
+
+	return nil
+}
+
+// retryDelay returns an exponential backoff delay for the given attempt number
+func (s *AttachmentScanner) retryDelay(attempts int) time.Duration {
+	delay := s.config.RetryBaseDelay
+	for i := 1; i < attempts && delay < maxScanRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxScanRetryDelay)
+}
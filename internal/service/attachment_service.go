@@ -0,0 +1,248 @@
+// Package service provides uploaded attachments and their linking to form sessions.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/upload"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+const (
+	attachmentIDBytes = 16
+	// maxFilenameLength matches the attachments.filename column
+	maxFilenameLength = 255
+	// defaultAttachmentListLimit is the page size of admin attachment listings
+	defaultAttachmentListLimit = 50
+)
+
+var (
+	// ErrAttachmentScanPending is returned when linking an attachment whose scan has not finished
+	ErrAttachmentScanPending = errors.New("attachment is still being scanned")
+	// ErrAttachmentInfected is returned when linking an attachment that was quarantined
+	ErrAttachmentInfected = errors.New("attachment failed the malware scan")
+)
+
+// AttachmentStores holds the storage of uploaded attachments and the separate storage
+// infected ones are moved to
+type AttachmentStores struct {
+	Uploads    upload.Storage
+	Quarantine upload.Storage
+}
+
+// AttachmentService defines the interface for uploaded attachments
+type AttachmentService interface {
+	Upload(ctx context.Context, filename string, r io.Reader) (*dto.AttachmentResponse, error)
+	GetAttachment(ctx context.Context, id string) (*dto.AttachmentResponse, error)
+	LinkToSession(ctx context.Context, sessionID string, req *dto.AttachmentLinkRequest) (*dto.AttachmentResponse, error)
+	ListForAdmin(ctx context.Context, req *dto.AdminAttachmentListRequest) (*dto.AdminAttachmentListResponse, error)
+}
+
+// attachmentService implements AttachmentService
+type attachmentService struct {
+	attachmentRepo repository.AttachmentRepository
+	sessionRepo    repository.SessionRepository
+	txManager      repository.TxManager
+	processor      *upload.Processor
+	validator      *validator.CustomValidator
+	log            *logger.Logger
+}
+
+// NewAttachmentService creates a new attachment service
+func NewAttachmentService(
+	attachmentRepo repository.AttachmentRepository,
+	sessionRepo repository.SessionRepository,
+	txManager repository.TxManager,
+	processor *upload.Processor,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) AttachmentService {
+	return &attachmentService{
+		attachmentRepo: attachmentRepo,
+		sessionRepo:    sessionRepo,
+		txManager:      txManager,
+		processor:      processor,
+		validator:      validator,
+		log:            log,
+	}
+}
+
+// Upload validates and stores a file. The attachment starts with a pending scan; the
+// AttachmentScanner scans it in the background.
+func (s *attachmentService) Upload(ctx context.Context, filename string, r io.Reader) (*dto.AttachmentResponse, error) {
+	if filename == "" || utf8.RuneCountInString(filename) > maxFilenameLength {
+		return nil, apperr.Errorf(apperr.ErrValidation, "file name must be 1 to %d characters", maxFilenameLength)
+	}
+
+	id, err := randomHex(attachmentIDBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate attachment ID: %w", err)
+	}
+
+	result, err := s.processor.Store(ctx, id, filename, r)
+	if err != nil {
+		if isUploadRejection(err) {
+			return nil, apperr.Errorf(apperr.ErrValidation, "%w", err)
+		}
+		s.log.WithContext(ctx).WithError(err).Error("Failed to store upload")
+		return nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+
+	attachment := &model.Attachment{
+		ID:          id,
+		StorageKey:  result.Key,
+		Filename:    filename,
+		ContentType: result.ContentType,
+		Size:        result.Size,
+		SHA256:      result.SHA256,
+	}
+	if err := s.attachmentRepo.Create(ctx, attachment); err != nil {
+		return nil, err
+	}
+
+	s.log.WithContext(ctx).
+		WithField("attachment_id", id).
+		WithField("content_type", result.ContentType).
+		WithField("size", result.Size).
+		Info("Attachment uploaded, awaiting scan")
+
+	return convertAttachmentToResponse(attachment), nil
+}
+
+// GetAttachment returns an attachment and its scan status
+func (s *attachmentService) GetAttachment(ctx context.Context, id string) (*dto.AttachmentResponse, error) {
+	attachment, err := s.attachmentRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return convertAttachmentToResponse(attachment), nil
+}
+
+// LinkToSession links a clean attachment to an active form session
+func (s *attachmentService) LinkToSession(
+	ctx context.Context, sessionID string, req *dto.AttachmentLinkRequest,
+) (*dto.AttachmentResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	var linked *model.Attachment
+	err := s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		exists, err := s.sessionRepo.Exists(txCtx, sessionID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return apperr.Errorf(apperr.ErrNotFound, "session not found")
+		}
+
+		// Lock the attachment so the scanner cannot quarantine it while it is linked
+		attachment, err := s.attachmentRepo.GetByIDForUpdate(txCtx, req.AttachmentID)
+		if err != nil {
+			return err
+		}
+		switch attachment.ScanStatus {
+		case model.ScanStatusPending:
+			return ErrAttachmentScanPending
+		case model.ScanStatusInfected:
+			return ErrAttachmentInfected
+		}
+
+		if err := s.attachmentRepo.LinkToSession(txCtx, attachment.ID, sessionID); err != nil {
+			return err
+		}
+		attachment.SessionID = &sessionID
+		linked = attachment
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.WithContext(ctx).
+		WithField("attachment_id", linked.ID).
+		WithField("session_id", sessionID).
+		Info("Attachment linked to session")
+
+	return convertAttachmentToResponse(linked), nil
+}
+
+// ListForAdmin lists attachments by scan status, infected ones by default
+func (s *attachmentService) ListForAdmin(
+	ctx context.Context, req *dto.AdminAttachmentListRequest,
+) (*dto.AdminAttachmentListResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	status := req.ScanStatus
+	if status == "" {
+		status = model.ScanStatusInfected
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultAttachmentListLimit
+	}
+
+	attachments, err := s.attachmentRepo.ListByStatus(ctx, status, limit, req.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.AdminAttachmentListResponse{Attachments: make([]*dto.AdminAttachmentResponse, 0, len(attachments))}
+	for _, attachment := range attachments {
+		resp.Attachments = append(resp.Attachments, &dto.AdminAttachmentResponse{
+			ID:            attachment.ID,
+			SessionID:     attachment.SessionID,
+			Filename:      attachment.Filename,
+			ContentType:   attachment.ContentType,
+			Size:          attachment.Size,
+			SHA256:        attachment.SHA256,
+			ScanStatus:    attachment.ScanStatus,
+			ScanSignature: attachment.ScanSignature,
+			ScanAttempts:  attachment.ScanAttempts,
+			ScanError:     attachment.ScanError,
+			ScannedAt:     attachment.ScannedAt,
+			QuarantinedAt: attachment.QuarantinedAt,
+			CreatedAt:     attachment.CreatedAt,
+		})
+	}
+
+	return resp, nil
+}
+
+// isUploadRejection reports whether the upload was rejected for its content rather than
+// failing to be stored
+func isUploadRejection(err error) bool {
+	for _, rejection := range []error{
+		upload.ErrTooLarge, upload.ErrEmpty, upload.ErrUnsupportedType,
+		upload.ErrTypeMismatch, upload.ErrExecutable, upload.ErrInvalidImage, upload.ErrInvalidKey,
+	} {
+		if errors.Is(err, rejection) {
+			return true
+		}
+	}
+	return false
+}
+
+// convertAttachmentToResponse converts an attachment to its public response
+func convertAttachmentToResponse(attachment *model.Attachment) *dto.AttachmentResponse {
+	return &dto.AttachmentResponse{
+		ID:          attachment.ID,
+		Filename:    attachment.Filename,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		ScanStatus:  attachment.ScanStatus,
+		SessionID:   attachment.SessionID,
+		CreatedAt:   attachment.CreatedAt,
+	}
+}
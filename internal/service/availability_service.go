@@ -0,0 +1,95 @@
+// Package service provides combined option availability checks.
+package service
+
+import (
+	"context"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/pkg/external"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+	"golang.org/x/sync/errgroup"
+)
+
+// AvailabilityService defines the interface for combined option availability checks
+type AvailabilityService interface {
+	CheckAvailability(ctx context.Context, req *dto.OptionAvailabilityRequest) (*dto.OptionAvailabilityResponse, error)
+}
+
+// availabilityService implements AvailabilityService
+type availabilityService struct {
+	options   OptionService
+	regions   AddressService
+	validator *validator.CustomValidator
+	log       *logger.Logger
+}
+
+// NewAvailabilityService creates a new availability service
+func NewAvailabilityService(
+	options OptionService,
+	regions AddressService,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) AvailabilityService {
+	return &availabilityService{
+		options:   options,
+		regions:   regions,
+		validator: validator,
+		log:       log,
+	}
+}
+
+// CheckAvailability checks the stock and region restrictions of options in one call. Stock
+// goes through the cached inventory check and restrictions through the region check, so
+// feature flags and local fallbacks apply as they do to the separate endpoints. The two
+// checks run concurrently. When the region check fails the result carries no region data,
+// matching external.Manager.CheckOptionAvailability.
+func (s *availabilityService) CheckAvailability(
+	ctx context.Context, req *dto.OptionAvailabilityRequest,
+) (*dto.OptionAvailabilityResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	var inventory *dto.InventoryCheckResponse
+	var regions map[string]bool
+	g, gctx := errgroup.WithContext(ctx)
+
+	g.Go(func() error {
+		var err error
+		inventory, err = s.options.CheckInventory(gctx, &dto.InventoryCheckRequest{OptionTypes: req.OptionTypes})
+		return err
+	})
+
+	g.Go(func() error {
+		restrictions, err := s.regions.CheckRegionRestrictions(gctx, &dto.RegionCheckRequest{
+			Prefecture:  req.Prefecture,
+			City:        req.City,
+			OptionTypes: req.OptionTypes,
+		})
+		if err != nil && external.IsStrict(ctx) {
+			return err
+		}
+		if err != nil {
+			s.log.WithContext(ctx).WithError(err).
+				WithField("prefecture", req.Prefecture).
+				WithField("city", req.City).
+				Warn("Failed to check region restrictions, continuing without region data")
+			return nil
+		}
+		regions = restrictions.Restrictions
+		return nil
+	})
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return &dto.OptionAvailabilityResponse{
+		OptionAvailabilityResult: external.NewOptionAvailabilityResult(req.OptionTypes, inventory.Inventory, regions),
+		StockStates:              inventory.States,
+		Stale:                    inventory.Stale,
+		CheckedAt:                inventory.CheckedAt,
+	}, nil
+}
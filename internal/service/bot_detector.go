@@ -0,0 +1,171 @@
+// Package service provides honeypot and submission timing bot detection.
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// formTokenVersion prefixes form tokens; it changes with the token format
+const formTokenVersion = "v1"
+
+// Reasons a submission is rejected as a bot
+const (
+	BotReasonHoneypot     = "honeypot"
+	BotReasonTooFast      = "too_fast"
+	BotReasonMissingToken = "missing_token"
+	BotReasonInvalidToken = "invalid_token"
+	BotReasonExpiredToken = "expired_token"
+)
+
+// ErrBotDetected is returned when a submission looks automated; the error names the reason
+var ErrBotDetected = errors.New("submission rejected as automated")
+
+// BotDetectorConfig controls bot detection of registrations
+type BotDetectorConfig struct {
+	Enabled bool
+	// SigningKey signs form tokens; every instance must share it. A random key is generated
+	// when it is empty, so tokens are only accepted by the instance that issued them.
+	SigningKey string
+	// MinFillTime is the least time a person takes between opening the form and submitting it
+	MinFillTime time.Duration
+	// MaxTokenAge is how long a form token is accepted
+	MaxTokenAge time.Duration
+}
+
+// BotDetector rejects registrations that fill the hidden honeypot field or are submitted
+// sooner after the form was issued than a person could fill it in. The time the form was
+// issued is carried by a signed token handed out with the form session, so clients cannot
+// forge it.
+type BotDetector struct {
+	config BotDetectorConfig
+	key    []byte
+	log    *logger.Logger
+
+	// blocked counts rejected submissions by reason since the server started
+	blocked map[string]*atomic.Int64
+}
+
+// NewBotDetector creates a new bot detector
+func NewBotDetector(config BotDetectorConfig, log *logger.Logger) (*BotDetector, error) {
+	key := []byte(config.SigningKey)
+	if config.Enabled && len(key) == 0 {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate form token signing key: %w", err)
+		}
+		log.Warn("No form token signing key configured; tokens are only accepted by the instance that issued them")
+	}
+
+	blocked := make(map[string]*atomic.Int64)
+	for _, reason := range []string{
+		BotReasonHoneypot, BotReasonTooFast, BotReasonMissingToken, BotReasonInvalidToken, BotReasonExpiredToken,
+	} {
+		blocked[reason] = &atomic.Int64{}
+	}
+
+	return &BotDetector{
+		config:  config,
+		key:     key,
+		log:     log,
+		blocked: blocked,
+	}, nil
+}
+
+// Enabled reports whether submissions are checked
+func (d *BotDetector) Enabled() bool {
+	return d.config.Enabled
+}
+
+// IssueFormToken returns a token recording that a form was issued now, or "" when bot
+// detection is disabled
+func (d *BotDetector) IssueFormToken() string {
+	if !d.config.Enabled {
+		return ""
+	}
+	issuedAt := strconv.FormatInt(time.Now().Unix(), 10)
+	return formTokenVersion + "." + issuedAt + "." + d.sign(issuedAt)
+}
+
+// Check returns ErrBotDetected when the honeypot field is filled, the form token is
+// missing, forged or expired, or the form was submitted faster than MinFillTime
+func (d *BotDetector) Check(ctx context.Context, honeypot, formToken, clientIP string) error {
+	if !d.config.Enabled {
+		return nil
+	}
+
+	reason := d.reason(honeypot, formToken)
+	if reason == "" {
+		return nil
+	}
+
+	d.blocked[reason].Add(1)
+	d.log.WithContext(ctx).
+		WithField("client_ip", clientIP).
+		WithField("reason", reason).
+		Warn("Submission rejected as automated")
+	return fmt.Errorf("%w: %s", ErrBotDetected, reason)
+}
+
+// Stats returns the submissions rejected since the server started
+func (d *BotDetector) Stats() *dto.BotDetectionStatsResponse {
+	resp := &dto.BotDetectionStatsResponse{
+		Enabled:     d.config.Enabled,
+		MinFillTime: d.config.MinFillTime.String(),
+		Blocked:     make(map[string]int64, len(d.blocked)),
+	}
+	for reason, count := range d.blocked {
+		resp.Blocked[reason] = count.Load()
+		resp.Total += resp.Blocked[reason]
+	}
+	return resp
+}
+
+// reason returns why a submission looks automated, or "" when it does not
+func (d *BotDetector) reason(honeypot, formToken string) string {
+	// People never see the honeypot field, so anything in it was filled in by a script
+	if strings.TrimSpace(honeypot) != "" {
+		return BotReasonHoneypot
+	}
+	if formToken == "" {
+		return BotReasonMissingToken
+	}
+
+	parts := strings.Split(formToken, ".")
+	if len(parts) != 3 || parts[0] != formTokenVersion ||
+		!hmac.Equal([]byte(parts[2]), []byte(d.sign(parts[1]))) {
+		return BotReasonInvalidToken
+	}
+	issuedAt, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return BotReasonInvalidToken
+	}
+
+	elapsed := time.Since(time.Unix(issuedAt, 0))
+	if d.config.MaxTokenAge > 0 && elapsed > d.config.MaxTokenAge {
+		return BotReasonExpiredToken
+	}
+	if elapsed < d.config.MinFillTime {
+		return BotReasonTooFast
+	}
+	return ""
+}
+
+// sign returns the signature of the time a form was issued
+func (d *BotDetector) sign(issuedAt string) string {
+	mac := hmac.New(sha256.New, d.key)
+	mac.Write([]byte(formTokenVersion + "." + issuedAt))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
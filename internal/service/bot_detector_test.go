@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// formTokenAt returns a token of d recording that a form was issued at
+func formTokenAt(d *BotDetector, at time.Time) string {
+	issuedAt := strconv.FormatInt(at.Unix(), 10)
+	return formTokenVersion + "." + issuedAt + "." + d.sign(issuedAt)
+}
+
+func TestBotDetector_reason(t *testing.T) {
+	config := BotDetectorConfig{
+		Enabled: true, SigningKey: "signing-key", MinFillTime: 5 * time.Second, MaxTokenAge: time.Hour,
+	}
+	d, err := NewBotDetector(config, logger.NewLogger("error"))
+	if err != nil {
+		t.Fatalf("NewBotDetector: %v", err)
+	}
+	config.SigningKey = "other-key"
+	other, err := NewBotDetector(config, logger.NewLogger("error"))
+	if err != nil {
+		t.Fatalf("NewBotDetector: %v", err)
+	}
+
+	now := time.Now()
+	valid := formTokenAt(d, now.Add(-time.Minute))
+	parts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name      string
+		honeypot  string
+		formToken string
+		want      string
+	}{
+		{"person", "", valid, ""},
+		{"issued token", "", d.IssueFormToken(), BotReasonTooFast},
+		{"honeypot filled", "https://spam.example", valid, BotReasonHoneypot},
+		{"honeypot of spaces", "  ", valid, ""},
+		{"no token", "", "", BotReasonMissingToken},
+		{"too fast", "", formTokenAt(d, now.Add(-2*time.Second)), BotReasonTooFast},
+		{"expired", "", formTokenAt(d, now.Add(-2*time.Hour)), BotReasonExpiredToken},
+		{"signed with another key", "", formTokenAt(other, now.Add(-time.Minute)), BotReasonInvalidToken},
+		{"time changed", "", parts[0] + "." + strconv.FormatInt(now.Add(-time.Hour/2).Unix(), 10) + "." + parts[2], BotReasonInvalidToken},
+		{"other version", "", "v0." + parts[1] + "." + parts[2], BotReasonInvalidToken},
+		{"missing signature", "", parts[0] + "." + parts[1], BotReasonInvalidToken},
+		{"not a number", "", formTokenVersion + ".soon." + d.sign("soon"), BotReasonInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := d.reason(tt.honeypot, tt.formToken); got != tt.want {
+				t.Errorf("reason(%q, %q) = %q, want %q", tt.honeypot, tt.formToken, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBotDetector_Check(t *testing.T) {
+	d, err := NewBotDetector(BotDetectorConfig{Enabled: true, SigningKey: "signing-key"}, logger.NewLogger("error"))
+	if err != nil {
+		t.Fatalf("NewBotDetector: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := d.Check(ctx, "", d.IssueFormToken(), "192.0.2.1"); err != nil {
+		t.Errorf("Check of an issued token: %v", err)
+	}
+	if err := d.Check(ctx, "filled", d.IssueFormToken(), "192.0.2.1"); !errors.Is(err, ErrBotDetected) {
+		t.Errorf("Check with the honeypot filled: err = %v, want ErrBotDetected", err)
+	}
+	if stats := d.Stats(); stats.Total != 1 || stats.Blocked[BotReasonHoneypot] != 1 {
+		t.Errorf("Stats = %+v, want one honeypot rejection", stats)
+	}
+
+	disabled, err := NewBotDetector(BotDetectorConfig{}, logger.NewLogger("error"))
+	if err != nil {
+		t.Fatalf("NewBotDetector: %v", err)
+	}
+	if token := disabled.IssueFormToken(); token != "" {
+		t.Errorf("IssueFormToken while disabled = %q, want none", token)
+	}
+	if err := disabled.Check(ctx, "filled", "", "192.0.2.1"); err != nil {
+		t.Errorf("Check while disabled: %v", err)
+	}
+}
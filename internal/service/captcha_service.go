@@ -0,0 +1,139 @@
+// Package service provides captcha verification of registrations.
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/captcha"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/lru"
+)
+
+// maxVerifiedCaptchas bounds the verified tokens remembered for redemption
+const maxVerifiedCaptchas = 10000
+
+// ErrCaptchaFailed is returned when a captcha token is missing or rejected by the provider
+var ErrCaptchaFailed = errors.New("captcha verification failed")
+
+// CaptchaConfig controls captcha verification of registrations
+type CaptchaConfig struct {
+	// VerifiedTTL is how long a token verified by a validation can be redeemed by the
+	// registration. Providers accept a token once, but the form validates a registration
+	// before it submits it with the same token.
+	VerifiedTTL time.Duration
+}
+
+// CaptchaService verifies the captcha tokens sent with registrations. Both methods return
+// ErrCaptchaFailed when the token is missing or rejected and an ExternalAPIError when the
+// provider could not be asked.
+type CaptchaService interface {
+	// Verify checks the token of a validation with the provider and remembers the verdict
+	// for the client IP, so that the registration that follows can redeem the token once
+	Verify(ctx context.Context, token, remoteIP string) error
+	// Redeem checks the token of a registration, consuming the verdict Verify remembered for
+	// the same client IP, or asking the provider when there is none
+	Redeem(ctx context.Context, token, remoteIP string) error
+}
+
+// verifiedCaptcha is a verdict remembered for redemption
+type verifiedCaptcha struct {
+	remoteIP   string
+	expiration time.Time
+}
+
+// captchaService implements CaptchaService
+type captchaService struct {
+	verifier captcha.Verifier
+	config   CaptchaConfig
+	log      *logger.Logger
+
+	mu sync.Mutex
+	// verified maps the hash of a verified token to its verdict
+	verified *lru.Cache[string, verifiedCaptcha]
+}
+
+// NewCaptchaService creates a new captcha service
+func NewCaptchaService(verifier captcha.Verifier, config CaptchaConfig, log *logger.Logger) CaptchaService {
+	return &captchaService{
+		verifier: verifier,
+		config:   config,
+		log:      log,
+		verified: lru.New[string, verifiedCaptcha](maxVerifiedCaptchas),
+	}
+}
+
+// Verify checks the token with the provider and remembers the verdict for the verified TTL
+func (s *captchaService) Verify(ctx context.Context, token, remoteIP string) error {
+	token = strings.TrimSpace(token)
+	if err := s.check(ctx, token, remoteIP); err != nil {
+		return err
+	}
+
+	if s.config.VerifiedTTL > 0 {
+		s.mu.Lock()
+		s.verified.Add(hashCaptchaToken(token), verifiedCaptcha{
+			remoteIP:   remoteIP,
+			expiration: time.Now().Add(s.config.VerifiedTTL),
+		})
+		s.mu.Unlock()
+	}
+	return nil
+}
+
+// Redeem accepts a token verified for the same client IP within the verified TTL once, and
+// checks other tokens with the provider
+func (s *captchaService) Redeem(ctx context.Context, token, remoteIP string) error {
+	token = strings.TrimSpace(token)
+	if token != "" && s.redeemVerified(hashCaptchaToken(token), remoteIP) {
+		return nil
+	}
+	return s.check(ctx, token, remoteIP)
+}
+
+// check asks the provider whether a token is valid
+func (s *captchaService) check(ctx context.Context, token, remoteIP string) error {
+	if token == "" {
+		return ErrCaptchaFailed
+	}
+
+	result, err := s.verifier.Verify(ctx, token, remoteIP)
+	if err != nil {
+		return newExternalAPIError(ExternalAPICaptcha, err)
+	}
+	if !result.Success {
+		s.log.WithContext(ctx).
+			WithField("client_ip", remoteIP).
+			WithField("error_codes", result.ErrorCodes).
+			Warn("Captcha verification failed")
+		return ErrCaptchaFailed
+	}
+	return nil
+}
+
+// redeemVerified reports whether the token hashed to key was verified for remoteIP within the
+// verified TTL, forgetting the verdict so that it is redeemed only once. A verdict for another
+// client IP is left for its own client.
+func (s *captchaService) redeemVerified(key, remoteIP string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	verdict, ok := s.verified.Get(key)
+	if !ok || verdict.remoteIP != remoteIP {
+		return false
+	}
+	s.verified.Remove(key)
+	return time.Now().Before(verdict.expiration)
+}
+
+// hashCaptchaToken returns the key a token is remembered by, so that tokens are not kept in
+// memory
+func hashCaptchaToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/captcha"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// onceVerifier accepts each token once, as captcha providers do
+type onceVerifier struct {
+	used map[string]bool
+}
+
+func (v *onceVerifier) Verify(_ context.Context, token, _ string) (*captcha.Result, error) {
+	if v.used[token] {
+		return &captcha.Result{ErrorCodes: []string{"timeout-or-duplicate"}}, nil
+	}
+	v.used[token] = true
+	return &captcha.Result{Success: true}, nil
+}
+
+func TestCaptchaService_Redeem(t *testing.T) {
+	const clientIP, otherIP = "192.0.2.1", "198.51.100.1"
+
+	tests := []struct {
+		name string
+		// verifyIP validates the token first unless empty; redeemIPs then submit it in turn
+		verifyIP  string
+		redeemIPs []string
+		want      []error
+	}{
+		{"validate then submit", clientIP, []string{clientIP}, []error{nil}},
+		{"submit twice", clientIP, []string{clientIP, clientIP}, []error{nil, ErrCaptchaFailed}},
+		{"submit from another IP", clientIP, []string{otherIP, clientIP}, []error{ErrCaptchaFailed, nil}},
+		{"submit without validation", "", []string{clientIP, clientIP}, []error{nil, ErrCaptchaFailed}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := NewCaptchaService(&onceVerifier{used: make(map[string]bool)},
+				CaptchaConfig{VerifiedTTL: time.Minute}, logger.NewLogger("error"))
+			ctx := context.Background()
+
+			if tt.verifyIP != "" {
+				if err := service.Verify(ctx, "token", tt.verifyIP); err != nil {
+					t.Fatalf("Verify: %v", err)
+				}
+			}
+			for i, ip := range tt.redeemIPs {
+				if err := service.Redeem(ctx, "token", ip); !errors.Is(err, tt.want[i]) {
+					t.Errorf("Redeem %d from %s: err = %v, want %v", i+1, ip, err, tt.want[i])
+				}
+			}
+		})
+	}
+
+	t.Run("validate twice", func(t *testing.T) {
+		service := NewCaptchaService(&onceVerifier{used: make(map[string]bool)},
+			CaptchaConfig{VerifiedTTL: time.Minute}, logger.NewLogger("error"))
+		ctx := context.Background()
+
+		if err := service.Verify(ctx, "token", clientIP); err != nil {
+			t.Fatalf("Verify: %v", err)
+		}
+		if err := service.Verify(ctx, "token", clientIP); !errors.Is(err, ErrCaptchaFailed) {
+			t.Errorf("second Verify: err = %v, want ErrCaptchaFailed", err)
+		}
+	})
+}
@@ -0,0 +1,79 @@
+// Package service provides user deletion tombstones and their retention.
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// DeletionPolicy controls the tombstones written when users are hard-deleted
+type DeletionPolicy struct {
+	Retention    time.Duration
+	EmailHashKey string
+}
+
+// hashEmail returns the hex encoded hash of the normalized email. A keyed HMAC prevents
+// tombstones from being matched against lists of known addresses.
+func (p DeletionPolicy) hashEmail(email string) string {
+	normalized := []byte(strings.ToLower(strings.TrimSpace(email)))
+
+	if p.EmailHashKey == "" {
+		sum := sha256.Sum256(normalized)
+		return hex.EncodeToString(sum[:])
+	}
+
+	mac := hmac.New(sha256.New, []byte(p.EmailHashKey))
+	mac.Write(normalized)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// DeletionRecordPurger periodically removes tombstones whose retention has ended
+type DeletionRecordPurger struct {
+	repo     repository.DeletionRecordRepository
+	interval time.Duration
+	log      *logger.Logger
+}
+
+// NewDeletionRecordPurger creates a new deletion record purger
+func NewDeletionRecordPurger(
+	repo repository.DeletionRecordRepository, interval time.Duration, log *logger.Logger,
+) *DeletionRecordPurger {
+	return &DeletionRecordPurger{
+		repo:     repo,
+		interval: interval,
+		log:      log,
+	}
+}
+
+// Run purges expired records until the context is cancelled. A non-positive interval disables purging.
+func (p *DeletionRecordPurger) Run(ctx context.Context) {
+	if p.interval <= 0 {
+		p.log.Warn("Deletion record purge is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := p.repo.DeleteExpired(ctx, time.Now())
+		if err != nil {
+			p.log.WithError(err).Error("Deletion record purge failed")
+		} else if purged > 0 {
+			p.log.WithField("purged", purged).Info("Purged expired deletion records")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,446 @@
+// Package service provides duplicate registration detection.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/japanese"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// Duplicate detection strategies, named in DuplicateConfig.Strategies and reported as the
+// criteria a registration matched
+const (
+	DuplicateCriterionEmail       = "email"
+	DuplicateCriterionEmailName   = "email_name"
+	DuplicateCriterionPhone       = "phone"
+	DuplicateCriterionNameAddress = "name_address"
+)
+
+// duplicateReportFloor is the least confidence a strategy reports a match with; weaker
+// similarities are not worth an administrator's review
+const duplicateReportFloor = 0.5
+
+// ErrPossibleDuplicate is returned when a registration matches an existing user on
+// criteria other than the email address
+var ErrPossibleDuplicate = errors.New("possible duplicate registration")
+
+// PossibleDuplicateError reports the criteria on which a registration matched existing users
+type PossibleDuplicateError struct {
+	Criteria []string
+	// Confidence is the highest confidence of the matches
+	Confidence float64
+}
+
+// Error describes the matched criteria
+func (e *PossibleDuplicateError) Error() string {
+	return fmt.Sprintf("%s: matches an existing user on %s", ErrPossibleDuplicate, strings.Join(e.Criteria, ", "))
+}
+
+// Is reports the error as ErrPossibleDuplicate
+func (e *PossibleDuplicateError) Is(target error) bool {
+	return target == ErrPossibleDuplicate
+}
+
+// DuplicateConfig selects the duplicate detection strategies and the confidence that rejects
+// a registration
+type DuplicateConfig struct {
+	// Strategies are run in order; the pre-check stops at the first that rejects. "none"
+	// runs none.
+	Strategies []string
+	// MinConfidence is the confidence from which a match rejects a registration
+	MinConfidence float64
+}
+
+// DuplicateMatch is an existing user a registration may duplicate
+type DuplicateMatch struct {
+	UserID   int
+	Strategy string
+	// Confidence is how likely the users are the same person, from 0 to 1
+	Confidence float64
+}
+
+// DuplicateDetector is a strategy finding existing users a registration may duplicate
+type DuplicateDetector interface {
+	// Name is the criterion the detector reports matches on
+	Name() string
+	// Detect returns the users other than excludeUserID the registration may duplicate, with
+	// a confidence of at least duplicateReportFloor
+	Detect(ctx context.Context, req *dto.UserCreateRequest, excludeUserID int) ([]DuplicateMatch, error)
+}
+
+// DuplicateService defines the interface for detecting duplicate registrations
+type DuplicateService interface {
+	// FindDuplicates runs the strategies in order before a user is created and returns the
+	// matches of the first strategy reaching MinConfidence, or none
+	FindDuplicates(ctx context.Context, req *dto.UserCreateRequest) ([]DuplicateMatch, error)
+	// FindCandidates runs every strategy for a stored user and returns all their matches,
+	// for administrators reviewing duplicates
+	FindCandidates(ctx context.Context, req *dto.UserCreateRequest, excludeUserID int) ([]DuplicateMatch, error)
+}
+
+// duplicateService implements DuplicateService
+type duplicateService struct {
+	detectors     []DuplicateDetector
+	minConfidence float64
+	log           *logger.Logger
+}
+
+// NewDuplicateService creates a new duplicate detection service running the configured
+// strategies in order
+func NewDuplicateService(
+	userRepo repository.UserRepository, config DuplicateConfig, log *logger.Logger,
+) (DuplicateService, error) {
+	if config.MinConfidence <= 0 || config.MinConfidence > 1 {
+		return nil, fmt.Errorf("duplicate min confidence must be greater than 0 and at most 1: %v", config.MinConfidence)
+	}
+
+	available := map[string]DuplicateDetector{
+		DuplicateCriterionEmail:       &emailDuplicateDetector{userRepo: userRepo},
+		DuplicateCriterionEmailName:   &emailNameDuplicateDetector{userRepo: userRepo},
+		DuplicateCriterionPhone:       &phoneDuplicateDetector{userRepo: userRepo},
+		DuplicateCriterionNameAddress: &nameAddressDuplicateDetector{userRepo: userRepo},
+	}
+	detectors := make([]DuplicateDetector, 0, len(config.Strategies))
+	for _, name := range config.Strategies {
+		name = strings.TrimSpace(name)
+		if name == "" || name == "none" {
+			continue
+		}
+		detector, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown duplicate detection strategy: %s", name)
+		}
+		detectors = append(detectors, detector)
+	}
+
+	return &duplicateService{
+		detectors:     detectors,
+		minConfidence: config.MinConfidence,
+		log:           log,
+	}, nil
+}
+
+// FindDuplicates returns the matches of the first strategy reaching MinConfidence
+func (s *duplicateService) FindDuplicates(ctx context.Context, req *dto.UserCreateRequest) ([]DuplicateMatch, error) {
+	for _, detector := range s.detectors {
+		matches, err := detector.Detect(ctx, req, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		var confident []DuplicateMatch
+		for _, match := range matches {
+			if match.Confidence >= s.minConfidence {
+				confident = append(confident, match)
+			}
+		}
+		if len(confident) > 0 {
+			s.log.WithContext(ctx).
+				WithField("criteria", detector.Name()).
+				WithField("confidence", maxDuplicateConfidence(confident)).
+				Info("Possible duplicate registration detected")
+			return confident, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// FindCandidates returns the matches of every strategy, most confident first
+func (s *duplicateService) FindCandidates(
+	ctx context.Context, req *dto.UserCreateRequest, excludeUserID int,
+) ([]DuplicateMatch, error) {
+	var candidates []DuplicateMatch
+	for _, detector := range s.detectors {
+		matches, err := detector.Detect(ctx, req, excludeUserID)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, matches...)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	return candidates, nil
+}
+
+// newPossibleDuplicateError reports the criteria and the highest confidence of matches
+func newPossibleDuplicateError(matches []DuplicateMatch) *PossibleDuplicateError {
+	err := &PossibleDuplicateError{Confidence: maxDuplicateConfidence(matches)}
+	for _, match := range matches {
+		if !slices.Contains(err.Criteria, match.Strategy) {
+			err.Criteria = append(err.Criteria, match.Strategy)
+		}
+	}
+	return err
+}
+
+// maxDuplicateConfidence returns the highest confidence of matches
+func maxDuplicateConfidence(matches []DuplicateMatch) float64 {
+	var highest float64
+	for _, match := range matches {
+		highest = max(highest, match.Confidence)
+	}
+	return highest
+}
+
+// emailDuplicateDetector matches the same email address, compared case-insensitively
+type emailDuplicateDetector struct {
+	userRepo repository.UserRepository
+}
+
+func (d *emailDuplicateDetector) Name() string {
+	return DuplicateCriterionEmail
+}
+
+func (d *emailDuplicateDetector) Detect(
+	ctx context.Context, req *dto.UserCreateRequest, excludeUserID int,
+) ([]DuplicateMatch, error) {
+	exists, err := d.userRepo.ExistsByEmail(ctx, req.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check email duplicates: %w", err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	user, err := d.userRepo.GetByEmail(ctx, req.Email)
+	if errors.Is(err, apperr.ErrNotFound) {
+		// Deleted since it was checked
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check email duplicates: %w", err)
+	}
+	if user.ID == excludeUserID {
+		return nil, nil
+	}
+	return []DuplicateMatch{{UserID: user.ID, Strategy: DuplicateCriterionEmail, Confidence: 1}}, nil
+}
+
+// emailNameDuplicateDetector matches users with the same kana name whose email address is
+// similar once the variants a mailbox accepts are removed, such as plus tags and the dots
+// Gmail ignores
+type emailNameDuplicateDetector struct {
+	userRepo repository.UserRepository
+}
+
+func (d *emailNameDuplicateDetector) Name() string {
+	return DuplicateCriterionEmailName
+}
+
+func (d *emailNameDuplicateDetector) Detect(
+	ctx context.Context, req *dto.UserCreateRequest, excludeUserID int,
+) ([]DuplicateMatch, error) {
+	candidates, err := d.userRepo.ListByNameKana(ctx,
+		japanese.ToKatakana(req.LastNameKana), japanese.ToKatakana(req.FirstNameKana))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check email and name duplicates: %w", err)
+	}
+
+	email := canonicalEmail(req.Email)
+	var matches []DuplicateMatch
+	for _, user := range candidates {
+		if user.ID == excludeUserID {
+			continue
+		}
+		// The name is already equal, so the email decides; an equal mailbox stays below the
+		// exact address as the plus tag may be a family member's
+		confidence := 0.95 * trigramSimilarity(email, canonicalEmail(user.Email))
+		if confidence >= duplicateReportFloor {
+			matches = append(matches, DuplicateMatch{
+				UserID: user.ID, Strategy: DuplicateCriterionEmailName, Confidence: confidence,
+			})
+		}
+	}
+	return matches, nil
+}
+
+// phoneDuplicateDetector matches the same phone number. A household may share a number, so
+// the match is certain only when the kana name is the same too.
+type phoneDuplicateDetector struct {
+	userRepo repository.UserRepository
+}
+
+func (d *phoneDuplicateDetector) Name() string {
+	return DuplicateCriterionPhone
+}
+
+func (d *phoneDuplicateDetector) Detect(
+	ctx context.Context, req *dto.UserCreateRequest, excludeUserID int,
+) ([]DuplicateMatch, error) {
+	candidates, err := d.userRepo.ListByPhone(ctx, normalizePhone(req.Phone1, req.Phone2, req.Phone3))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check phone duplicates: %w", err)
+	}
+
+	lastNameKana, firstNameKana := japanese.ToKatakana(req.LastNameKana), japanese.ToKatakana(req.FirstNameKana)
+	var matches []DuplicateMatch
+	for _, user := range candidates {
+		if user.ID == excludeUserID {
+			continue
+		}
+		confidence := 0.9
+		if user.LastNameKana == lastNameKana && user.FirstNameKana == firstNameKana {
+			confidence = 1
+		}
+		matches = append(matches, DuplicateMatch{
+			UserID: user.ID, Strategy: DuplicateCriterionPhone, Confidence: confidence,
+		})
+	}
+	return matches, nil
+}
+
+// nameAddressDuplicateDetector matches users with the same kana name and postal code whose
+// address is similar. Candidates are narrowed by postal code and name in the database;
+// addresses, which are entered free-form and stored encrypted, are compared after
+// normalization by trigram similarity.
+type nameAddressDuplicateDetector struct {
+	userRepo repository.UserRepository
+}
+
+func (d *nameAddressDuplicateDetector) Name() string {
+	return DuplicateCriterionNameAddress
+}
+
+func (d *nameAddressDuplicateDetector) Detect(
+	ctx context.Context, req *dto.UserCreateRequest, excludeUserID int,
+) ([]DuplicateMatch, error) {
+	candidates, err := d.userRepo.ListByNameKanaAndPostalCode(ctx,
+		japanese.ToKatakana(req.LastNameKana), japanese.ToKatakana(req.FirstNameKana),
+		req.PostalCode1, req.PostalCode2,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check name and address duplicates: %w", err)
+	}
+
+	address := normalizeAddress(req.Prefecture, req.City, stringValue(req.Town),
+		stringValue(req.Chome), req.Banchi, stringValue(req.Go), stringValue(req.Building), stringValue(req.Room))
+	var matches []DuplicateMatch
+	for _, user := range candidates {
+		if user.ID == excludeUserID {
+			continue
+		}
+		candidate := normalizeAddress(user.Prefecture, user.City, stringValue(user.Town),
+			stringValue(user.Chome), user.Banchi, stringValue(user.Go), stringValue(user.Building), stringValue(user.Room))
+		confidence := trigramSimilarity(address, candidate)
+		if confidence >= duplicateReportFloor {
+			matches = append(matches, DuplicateMatch{
+				UserID: user.ID, Strategy: DuplicateCriterionNameAddress, Confidence: confidence,
+			})
+		}
+	}
+	return matches, nil
+}
+
+// gmailDomains are the domains whose mailboxes ignore dots in the local part
+var gmailDomains = map[string]bool{"gmail.com": true, "googlemail.com": true}
+
+// canonicalEmail lowercases an email address and removes the plus tag, and the dots of
+// Gmail addresses, which deliver to the same mailbox
+func canonicalEmail(email string) string {
+	local, domain, found := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+	if !found {
+		return local
+	}
+	local, _, _ = strings.Cut(local, "+")
+	if gmailDomains[domain] {
+		local = strings.ReplaceAll(local, ".", "")
+		domain = "gmail.com"
+	}
+	return local + "@" + domain
+}
+
+// trigramSimilarity returns the share of the character trigrams of a and b that both hold,
+// padded like PostgreSQL's pg_trgm so that short strings and their ends count; 1 is equal
+func trigramSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for trigram := range ta {
+		if tb[trigram] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// trigrams returns the set of three-character windows of s padded with two leading spaces
+// and one trailing space
+func trigrams(s string) map[string]bool {
+	runes := []rune("  " + s + " ")
+	set := make(map[string]bool, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		set[string(runes[i:i+3])] = true
+	}
+	return set
+}
+
+// normalizePhone joins the phone number parts into half-width digits
+func normalizePhone(parts ...string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, width.Fold.String(strings.Join(parts, "")))
+}
+
+// addressNumberSuffixes are the unit words dropped from block numbers, so "1丁目2番地3号" and
+// "1-2-3" compare equal
+var addressNumberSuffixes = []string{"丁目", "番地", "番", "号"}
+
+// normalizeAddress builds a comparison key for an address. Widths are folded, spaces removed
+// and hyphen variants unified; the block numbers, building and room are joined with hyphens
+// regardless of which field they were entered in.
+func normalizeAddress(prefecture, city, town, chome, banchi, goNumber, building, room string) string {
+	area := normalizeAddressPart(prefecture + city + town)
+
+	var numbers []string
+	for _, part := range []string{chome, banchi, goNumber, building, room} {
+		part = normalizeAddressPart(part)
+		for _, suffix := range addressNumberSuffixes {
+			part = strings.ReplaceAll(part, suffix, "-")
+		}
+		for _, segment := range strings.Split(part, "-") {
+			if segment != "" {
+				numbers = append(numbers, segment)
+			}
+		}
+	}
+
+	return area + "|" + strings.Join(numbers, "-")
+}
+
+// normalizeAddressPart folds widths, drops spaces and unifies hyphen variants
+func normalizeAddressPart(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return -1
+		case r == 'ー' || r == '‐' || r == '−' || r == '―' || r == '–' || r == '—':
+			return '-'
+		default:
+			return unicode.ToLower(r)
+		}
+	}, width.Fold.String(s))
+}
@@ -0,0 +1,110 @@
+// Package service provides ingestion of mail provider bounce and complaint events.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/mail"
+)
+
+// maxEmailDeliverabilityReasonLength is the length of users.email_deliverability_reason
+const maxEmailDeliverabilityReasonLength = 255
+
+// emailDeliverabilityByEvent maps mail provider events to the deliverability they record
+var emailDeliverabilityByEvent = map[string]string{
+	mail.EventBounce:    model.EmailBounced,
+	mail.EventComplaint: model.EmailComplained,
+}
+
+// EmailEventSources holds the webhook event sources of the configured mail providers, keyed
+// by provider name
+type EmailEventSources map[string]mail.EventSource
+
+// EmailEventService defines the interface for ingesting mail provider webhooks
+type EmailEventService interface {
+	Ingest(ctx context.Context, provider string, header http.Header, body []byte) (*dto.EmailEventsResponse, error)
+}
+
+// emailEventService implements EmailEventService
+type emailEventService struct {
+	sources  EmailEventSources
+	userRepo repository.UserRepository
+	log      *logger.Logger
+}
+
+// NewEmailEventService creates a new email event service
+func NewEmailEventService(
+	sources EmailEventSources,
+	userRepo repository.UserRepository,
+	log *logger.Logger,
+) EmailEventService {
+	return &emailEventService{
+		sources:  sources,
+		userRepo: userRepo,
+		log:      log,
+	}
+}
+
+// Ingest verifies a webhook delivery of provider, detected from the request when empty, and
+// records the addresses it reports as bounced or complained on the users registered with
+// them, which suppresses further email to them. A request failing verification returns
+// mail.ErrInvalidSignature.
+func (s *emailEventService) Ingest(
+	ctx context.Context, provider string, header http.Header, body []byte,
+) (*dto.EmailEventsResponse, error) {
+	if provider == "" {
+		provider = mail.DetectEventProvider(header)
+		if provider == "" {
+			return nil, apperr.Errorf(apperr.ErrNotFound, "email event webhook sender not recognized")
+		}
+	}
+
+	source, ok := s.sources[provider]
+	if !ok {
+		return nil, apperr.Errorf(apperr.ErrNotFound, "email event webhook of %s is not configured", provider)
+	}
+
+	events, err := source.Events(ctx, header, body)
+	if errors.Is(err, mail.ErrInvalidEvent) {
+		return nil, apperr.Errorf(apperr.ErrValidation, "%w", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.EmailEventsResponse{Provider: provider, Events: len(events)}
+	for _, event := range events {
+		status, ok := emailDeliverabilityByEvent[event.Kind]
+		if !ok {
+			continue
+		}
+		reason := event.Reason
+		if runes := []rune(reason); len(runes) > maxEmailDeliverabilityReasonLength {
+			reason = string(runes[:maxEmailDeliverabilityReasonLength])
+		}
+
+		updated, err := s.userRepo.SetEmailDeliverability(ctx, event.Email, status, reason)
+		if err != nil {
+			return nil, fmt.Errorf("failed to set email deliverability: %w", err)
+		}
+		resp.Updated += updated
+	}
+
+	if len(events) > 0 {
+		s.log.WithContext(ctx).
+			WithField("provider", provider).
+			WithField("events", resp.Events).
+			WithField("updated", resp.Updated).
+			Info("Recorded email deliverability reported by the mail provider")
+	}
+
+	return resp, nil
+}
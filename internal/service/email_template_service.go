@@ -0,0 +1,469 @@
+// Package service provides versioned email templates.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/mail"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+// Email templates sent by the application. Each has a built-in version in pkg/mail/templates.
+const (
+	EmailTemplateLookupCode               = "lookup_code"
+	EmailTemplateRegistrationConfirmation = "registration_confirmation"
+	EmailTemplateReminder                 = "reminder"
+	EmailTemplateApprovalResult           = "approval_result"
+)
+
+// testSendSubjectPrefix marks test emails so recipients do not mistake them for real ones
+const testSendSubjectPrefix = "[TEST] "
+
+// LookupCodeEmail is the data of the lookup_code template
+type LookupCodeEmail struct {
+	Code    string
+	Minutes int
+}
+
+// RegistrationConfirmationEmail is the data of the registration_confirmation template
+type RegistrationConfirmationEmail struct {
+	Name string
+	// PhoneNumber is masked to its last four digits
+	PhoneNumber  string
+	PostalCode   string
+	Address      string
+	PlanType     string
+	PlanName     string
+	Options      []RegistrationConfirmationOption
+	RegisteredAt time.Time
+}
+
+// RegistrationConfirmationOption is a selected option in the registration_confirmation template
+type RegistrationConfirmationOption struct {
+	OptionType string
+	OptionName string
+	Quantity   int
+	// StartDate is YYYY-MM-DD or empty
+	StartDate string
+}
+
+// ReminderEmail is the data of the reminder template
+type ReminderEmail struct {
+	Name      string
+	ResumeURL string
+	ExpiresAt time.Time
+}
+
+// ApprovalResultEmail is the data of the approval_result template
+type ApprovalResultEmail struct {
+	Name     string
+	Approved bool
+	Reason   string
+}
+
+// emailTemplateSamples render previews and check new versions before they are stored;
+// translations of a template use its sample
+var emailTemplateSamples = map[string]any{
+	EmailTemplateLookupCode: LookupCodeEmail{Code: "123456", Minutes: 15},
+	EmailTemplateRegistrationConfirmation: RegistrationConfirmationEmail{
+		Name:        "山田 太郎",
+		PhoneNumber: "***-****-5678",
+		PostalCode:  "1000001",
+		Address:     "東京都千代田区千代田1-1",
+		PlanType:    "A",
+		PlanName:    "Aプラン",
+		Options: []RegistrationConfirmationOption{
+			{OptionType: "AA", OptionName: "AAオプション", Quantity: 1},
+			{OptionType: "AB", OptionName: "ABオプション", Quantity: 2, StartDate: "2024-04-01"},
+		},
+		RegisteredAt: time.Date(2024, time.January, 15, 10, 30, 0, 0, time.UTC),
+	},
+	EmailTemplateReminder: ReminderEmail{
+		Name:      "山田 太郎",
+		ResumeURL: "https://example.com/form?session=550e8400-e29b-41d4-a716-446655440000",
+		ExpiresAt: time.Date(2024, time.January, 16, 10, 30, 0, 0, time.UTC),
+	},
+	EmailTemplateApprovalResult: ApprovalResultEmail{Name: "山田 太郎", Approved: false, Reason: "ご提供エリア外のため"},
+}
+
+// EmailTemplateService renders the emails the application sends and manages versions of
+// their templates
+type EmailTemplateService interface {
+	Render(ctx context.Context, name, language string, data any) (*mail.Message, error)
+	ListTemplates(ctx context.Context) (*dto.EmailTemplatesResponse, error)
+	GetTemplate(ctx context.Context, name string) (*dto.EmailTemplateVersionsResponse, error)
+	CreateVersion(
+		ctx context.Context, name string, req *dto.EmailTemplateVersionRequest, actorIP string,
+	) (*dto.EmailTemplateVersionResponse, error)
+	ActivateVersion(ctx context.Context, name string, version int, actorIP string) (*dto.EmailTemplateVersionsResponse, error)
+	Preview(ctx context.Context, name string, req *dto.EmailTemplatePreviewRequest) (*dto.EmailTemplatePreviewResponse, error)
+	TestSend(
+		ctx context.Context, name string, req *dto.EmailTemplateTestSendRequest, actorIP string,
+	) (*dto.EmailTemplatePreviewResponse, error)
+}
+
+// emailTemplateService implements EmailTemplateService
+type emailTemplateService struct {
+	templateRepo repository.EmailTemplateRepository
+	auditLogRepo repository.AuditLogRepository
+	txManager    repository.TxManager
+	mailer       mail.Sender
+	validator    *validator.CustomValidator
+	log          *logger.Logger
+}
+
+// NewEmailTemplateService creates a new email template service
+func NewEmailTemplateService(
+	templateRepo repository.EmailTemplateRepository,
+	auditLogRepo repository.AuditLogRepository,
+	txManager repository.TxManager,
+	mailer mail.Sender,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) EmailTemplateService {
+	return &emailTemplateService{
+		templateRepo: templateRepo,
+		auditLogRepo: auditLogRepo,
+		txManager:    txManager,
+		mailer:       mailer,
+		validator:    validator,
+		log:          log,
+	}
+}
+
+// Render renders the active version of a template in language, or in the default
+// language when the template has no translation into it. When the stored version cannot be
+// loaded or rendered the built-in template is used, so the email is still sent.
+func (s *emailTemplateService) Render(ctx context.Context, name, language string, data any) (*mail.Message, error) {
+	if localized := mail.LocalizedName(name, language); localized != name {
+		if _, ok := mail.DefaultTemplate(localized); ok {
+			name = localized
+		}
+	}
+
+	builtIn, ok := mail.DefaultTemplate(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %s", name)
+	}
+
+	stored, err := s.templateRepo.GetActive(ctx, name)
+	switch {
+	case errors.Is(err, apperr.ErrNotFound):
+	case err != nil:
+		s.log.WithContext(ctx).WithError(err).WithField("template", name).
+			Warn("Failed to load email template, using the built-in version")
+	default:
+		msg, err := toMailTemplate(stored).Render(data)
+		if err == nil {
+			return msg, nil
+		}
+		s.log.WithContext(ctx).WithError(err).
+			WithField("template", name).
+			WithField("version", stored.Version).
+			Error("Failed to render email template, using the built-in version")
+	}
+
+	return builtIn.Render(data)
+}
+
+// ListTemplates lists the templates with the version of each that is sent
+func (s *emailTemplateService) ListTemplates(ctx context.Context) (*dto.EmailTemplatesResponse, error) {
+	active, err := s.templateRepo.GetAllActive(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get active email templates: %w", err)
+	}
+	activeVersions := make(map[string]int, len(active))
+	for _, template := range active {
+		activeVersions[template.Name] = template.Version
+	}
+
+	names := mail.DefaultTemplateNames()
+	resp := &dto.EmailTemplatesResponse{Templates: make([]dto.EmailTemplateSummary, 0, len(names))}
+	for _, name := range names {
+		resp.Templates = append(resp.Templates, dto.EmailTemplateSummary{
+			Name:          name,
+			ActiveVersion: activeVersions[name],
+		})
+	}
+	return resp, nil
+}
+
+// GetTemplate returns every version of a template
+func (s *emailTemplateService) GetTemplate(ctx context.Context, name string) (*dto.EmailTemplateVersionsResponse, error) {
+	builtIn, err := defaultEmailTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.templateRepo.ListVersions(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email template versions: %w", err)
+	}
+
+	resp := &dto.EmailTemplateVersionsResponse{
+		Name:     name,
+		Versions: make([]dto.EmailTemplateVersionResponse, 0, len(stored)+1),
+	}
+	for _, template := range stored {
+		if template.IsActive {
+			resp.ActiveVersion = template.Version
+		}
+		resp.Versions = append(resp.Versions, *toEmailTemplateVersionResponse(template))
+	}
+	resp.Versions = append(resp.Versions, dto.EmailTemplateVersionResponse{
+		Name:     name,
+		Subject:  builtIn.Subject,
+		Body:     builtIn.Body,
+		HTMLBody: builtIn.HTMLBody,
+		IsActive: resp.ActiveVersion == 0,
+	})
+	return resp, nil
+}
+
+// CreateVersion stores a new version of a template after rendering it with the sample data
+func (s *emailTemplateService) CreateVersion(
+	ctx context.Context, name string, req *dto.EmailTemplateVersionRequest, actorIP string,
+) (*dto.EmailTemplateVersionResponse, error) {
+	if _, err := defaultEmailTemplate(name); err != nil {
+		return nil, err
+	}
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	draft := &mail.Template{Name: name, Subject: req.Subject, Body: req.Body}
+	if _, err := draft.Render(emailTemplateSamples[mail.BaseName(name)]); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "template does not render with the sample data: %w", err)
+	}
+
+	var created *model.EmailTemplate
+	err := s.write(ctx, "email_template.created", name, req, actorIP, func(txCtx context.Context) error {
+		var err error
+		created, err = s.templateRepo.Create(txCtx, &model.EmailTemplate{
+			Name:    name,
+			Subject: req.Subject,
+			Body:    req.Body,
+			Note:    req.Note,
+		})
+		if err != nil || !req.Activate {
+			return err
+		}
+		created.IsActive = true
+		return s.templateRepo.Activate(txCtx, name, created.Version)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create email template version: %w", err)
+	}
+
+	return toEmailTemplateVersionResponse(created), nil
+}
+
+// ActivateVersion switches the version that is sent; version 0 returns to the built-in template
+func (s *emailTemplateService) ActivateVersion(
+	ctx context.Context, name string, version int, actorIP string,
+) (*dto.EmailTemplateVersionsResponse, error) {
+	if _, err := defaultEmailTemplate(name); err != nil {
+		return nil, err
+	}
+
+	details := map[string]int{"version": version}
+	err := s.write(ctx, "email_template.activated", name, details, actorIP, func(txCtx context.Context) error {
+		return s.templateRepo.Activate(txCtx, name, version)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to activate email template version: %w", err)
+	}
+
+	return s.GetTemplate(ctx, name)
+}
+
+// Preview renders a draft, a stored version or the active version of a template
+func (s *emailTemplateService) Preview(
+	ctx context.Context, name string, req *dto.EmailTemplatePreviewRequest,
+) (*dto.EmailTemplatePreviewResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	template, err := s.previewTemplate(ctx, name, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var data any = emailTemplateSamples[mail.BaseName(name)]
+	if req.Data != nil {
+		data = req.Data
+	}
+
+	msg, err := template.Render(data)
+	if err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "failed to render template: %w", err)
+	}
+
+	resp := &dto.EmailTemplatePreviewResponse{
+		Name:     name,
+		Subject:  msg.Subject,
+		Body:     msg.Body,
+		HTMLBody: msg.HTMLBody,
+	}
+	if req.Subject == nil && req.Body == nil {
+		resp.Version = &template.Version
+	}
+	return resp, nil
+}
+
+// TestSend renders a template like Preview and emails it to an administrator
+func (s *emailTemplateService) TestSend(
+	ctx context.Context, name string, req *dto.EmailTemplateTestSendRequest, actorIP string,
+) (*dto.EmailTemplatePreviewResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	preview, err := s.Preview(ctx, name, &req.EmailTemplatePreviewRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.mailer.Send(ctx, &mail.Message{
+		To:       req.To,
+		Subject:  testSendSubjectPrefix + preview.Subject,
+		Body:     preview.Body,
+		HTMLBody: preview.HTMLBody,
+	})
+	if errors.Is(err, mail.ErrSuppressed) {
+		return nil, apperr.Errorf(apperr.ErrValidation, "%s bounced or complained and receives no email", req.To)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to send test email: %w", err)
+	}
+
+	details, err := json.Marshal(map[string]any{"version": preview.Version})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+	entry := newAdminAuditLog(ctx, "email_template.test_sent", "email_template", name, details, actorIP)
+	if _, err := s.auditLogRepo.Create(ctx, entry); err != nil {
+		s.log.WithContext(ctx).WithError(err).Error("Failed to record test email in audit log")
+	}
+
+	return preview, nil
+}
+
+// previewTemplate selects the template a preview renders
+func (s *emailTemplateService) previewTemplate(
+	ctx context.Context, name string, req *dto.EmailTemplatePreviewRequest,
+) (*mail.Template, error) {
+	builtIn, err := defaultEmailTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case req.Subject != nil || req.Body != nil:
+		// A draft may change only one part of the active version
+		draft, err := s.previewTemplate(ctx, name, &dto.EmailTemplatePreviewRequest{Version: req.Version})
+		if err != nil {
+			return nil, err
+		}
+		if req.Subject != nil {
+			draft.Subject = *req.Subject
+		}
+		if req.Body != nil {
+			// A stored version has no HTML part, so the draft is previewed as it will be sent
+			draft.Body = *req.Body
+			draft.HTMLBody = ""
+		}
+		return draft, nil
+	case req.Version != nil && *req.Version == 0:
+		return builtIn, nil
+	case req.Version != nil:
+		stored, err := s.templateRepo.GetVersion(ctx, name, *req.Version)
+		if err != nil {
+			return nil, err
+		}
+		return toMailTemplate(stored), nil
+	default:
+		stored, err := s.templateRepo.GetActive(ctx, name)
+		if errors.Is(err, apperr.ErrNotFound) {
+			return builtIn, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		return toMailTemplate(stored), nil
+	}
+}
+
+// write runs change and its audit entry in one transaction
+func (s *emailTemplateService) write(
+	ctx context.Context, action, name string, details any, actorIP string,
+	change func(txCtx context.Context) error,
+) error {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+
+	err = s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := change(txCtx); err != nil {
+			return err
+		}
+
+		entry := newAdminAuditLog(txCtx, action, "email_template", name, detailsJSON, actorIP)
+		_, err := s.auditLogRepo.Create(txCtx, entry)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.log.WithContext(ctx).
+		WithField("action", action).
+		WithField("template", name).
+		Warn("Email template changed")
+	return nil
+}
+
+// defaultEmailTemplate returns the built-in template, or a not found error for a name the
+// application does not send
+func defaultEmailTemplate(name string) (*mail.Template, error) {
+	template, ok := mail.DefaultTemplate(name)
+	if !ok {
+		return nil, apperr.Errorf(apperr.ErrNotFound, "email template %s not found", name)
+	}
+	return template, nil
+}
+
+// toMailTemplate converts a stored version for rendering. Stored versions are text only:
+// an administrator changing the text would otherwise send an HTML part that disagrees with it.
+func toMailTemplate(template *model.EmailTemplate) *mail.Template {
+	return &mail.Template{
+		Name:    template.Name,
+		Version: template.Version,
+		Subject: template.Subject,
+		Body:    template.Body,
+	}
+}
+
+// toEmailTemplateVersionResponse converts a stored version to the admin representation
+func toEmailTemplateVersionResponse(template *model.EmailTemplate) *dto.EmailTemplateVersionResponse {
+	return &dto.EmailTemplateVersionResponse{
+		Name:      template.Name,
+		Version:   template.Version,
+		Subject:   template.Subject,
+		Body:      template.Body,
+		IsActive:  template.IsActive,
+		Note:      template.Note,
+		CreatedAt: &template.CreatedAt,
+	}
+}
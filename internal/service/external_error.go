@@ -0,0 +1,72 @@
+// Package service provides the error reported when an external API fails.
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/pkg/external"
+)
+
+// External APIs named by ExternalAPIError
+const (
+	ExternalAPIInventory = "inventory"
+	ExternalAPIRegion    = "region"
+	ExternalAPIAddress   = "address"
+	ExternalAPICaptcha   = "captcha"
+)
+
+// DefaultExternalRetryAfter is the wait suggested to clients when the API did not ask for one
+const DefaultExternalRetryAfter = 30 * time.Second
+
+// ExternalAPIError reports that an external API failed and nothing local could answer in its
+// place. It is apperr.ErrExternalTimeout when the API did not answer in time and
+// apperr.ErrExternalAPI otherwise.
+type ExternalAPIError struct {
+	// API names the failed API, one of the ExternalAPI constants
+	API     string
+	Timeout bool
+	// RetryAfter is how long clients should wait before trying again
+	RetryAfter time.Duration
+	// Strict marks a failure local data could have answered for, reported because the request
+	// is in strict mode
+	Strict bool
+	Err    error
+}
+
+// newExternalAPIError wraps the failure of an external API, keeping the wait the API asked for
+func newExternalAPIError(api string, err error) *ExternalAPIError {
+	retryAfter := external.RequestedRetryAfter(err)
+	if retryAfter <= 0 {
+		retryAfter = DefaultExternalRetryAfter
+	}
+	return &ExternalAPIError{
+		API:        api,
+		Timeout:    external.IsTimeout(err),
+		RetryAfter: retryAfter,
+		Err:        err,
+	}
+}
+
+// newStrictExternalAPIError wraps the failure of an external API that local data would
+// answer for outside strict mode
+func newStrictExternalAPIError(api string, err error) *ExternalAPIError {
+	apiErr := newExternalAPIError(api, err)
+	apiErr.Strict = true
+	return apiErr
+}
+
+// Error names the API and the cause
+func (e *ExternalAPIError) Error() string {
+	return fmt.Sprintf("%s API failed: %v", e.API, e.Err)
+}
+
+// Unwrap exposes the error kind and the cause to errors.Is and errors.As
+func (e *ExternalAPIError) Unwrap() []error {
+	kind := apperr.ErrExternalAPI
+	if e.Timeout {
+		kind = apperr.ErrExternalTimeout
+	}
+	return []error{kind, e.Err}
+}
@@ -0,0 +1,265 @@
+// Package service provides runtime feature flags.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+// Known feature flags
+const (
+	// FlagSkipInventoryCheck reports every active option as in stock without checking inventory
+	FlagSkipInventoryCheck = "skip_inventory_check"
+	// FlagDisableRegionRestriction allows every option in every region
+	FlagDisableRegionRestriction = "disable_region_restriction"
+	// FlagReadOnlyMode rejects requests that create or change registrations
+	FlagReadOnlyMode = "read_only_mode"
+	// FlagQueueUserCreation accepts registrations with 202 and creates the users in the background
+	FlagQueueUserCreation = "queue_user_creation"
+)
+
+// featureFlagDescriptions lists the known flags; overrides for other names are rejected
+var featureFlagDescriptions = map[string]string{
+	FlagSkipInventoryCheck:       "Report every active option as in stock without checking inventory",
+	FlagDisableRegionRestriction: "Allow every option in every region without checking restrictions",
+	FlagReadOnlyMode:             "Reject requests that create or change registrations and sessions",
+	FlagQueueUserCreation:        "Accept registrations with 202 and create the users in the background in order",
+}
+
+// ErrUnknownFeatureFlag is returned for flag names that are not defined
+var ErrUnknownFeatureFlag = apperr.Errorf(apperr.ErrNotFound, "unknown feature flag")
+
+// FeatureFlagConfig controls feature flag defaults and override refresh
+type FeatureFlagConfig struct {
+	// Defaults are the configured values used when a flag has no override
+	Defaults map[string]bool
+	// RefreshInterval is how often overrides set by other instances are reloaded
+	RefreshInterval time.Duration
+}
+
+// FeatureFlags resolves feature flags from configured defaults and database overrides.
+// Overrides are cached in memory so that checking a flag never touches the database.
+type FeatureFlags struct {
+	repo         repository.FeatureFlagRepository
+	auditLogRepo repository.AuditLogRepository
+	txManager    repository.TxManager
+	config       FeatureFlagConfig
+	validator    *validator.CustomValidator
+	log          *logger.Logger
+
+	mu        sync.RWMutex
+	overrides map[string]*model.FeatureFlagOverride
+}
+
+// NewFeatureFlags creates a new feature flag resolver
+func NewFeatureFlags(
+	repo repository.FeatureFlagRepository,
+	auditLogRepo repository.AuditLogRepository,
+	txManager repository.TxManager,
+	config FeatureFlagConfig,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) *FeatureFlags {
+	return &FeatureFlags{
+		repo:         repo,
+		auditLogRepo: auditLogRepo,
+		txManager:    txManager,
+		config:       config,
+		validator:    validator,
+		log:          log,
+		overrides:    make(map[string]*model.FeatureFlagOverride),
+	}
+}
+
+// Enabled reports whether the flag is on. A nil FeatureFlags treats every flag as off.
+func (f *FeatureFlags) Enabled(name string) bool {
+	if f == nil {
+		return false
+	}
+
+	f.mu.RLock()
+	override, ok := f.overrides[name]
+	f.mu.RUnlock()
+	if ok {
+		return override.Enabled
+	}
+
+	return f.config.Defaults[name]
+}
+
+// List returns the effective state of every known flag
+func (f *FeatureFlags) List() *dto.FeatureFlagsResponse {
+	names := make([]string, 0, len(featureFlagDescriptions))
+	for name := range featureFlagDescriptions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resp := &dto.FeatureFlagsResponse{Flags: make([]dto.FeatureFlagResponse, 0, len(names))}
+	for _, name := range names {
+		resp.Flags = append(resp.Flags, f.describe(name))
+	}
+	return resp
+}
+
+// Set overrides a flag for every instance until the override is cleared
+func (f *FeatureFlags) Set(
+	ctx context.Context, name string, req *dto.FeatureFlagUpdateRequest, actorIP string,
+) (*dto.FeatureFlagResponse, error) {
+	if _, ok := featureFlagDescriptions[name]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFeatureFlag, name)
+	}
+	if err := f.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	override := &model.FeatureFlagOverride{Name: name, Enabled: *req.Enabled}
+	if req.Reason != "" {
+		override.Reason = &req.Reason
+	}
+
+	details, err := json.Marshal(map[string]any{"enabled": override.Enabled, "reason": req.Reason})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal audit details: %w", err)
+	}
+
+	var saved *model.FeatureFlagOverride
+	err = f.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var err error
+		if saved, err = f.repo.Upsert(txCtx, override); err != nil {
+			return err
+		}
+
+		entry := newAdminAuditLog(txCtx, "feature_flag.set", "feature_flag", name, details, actorIP)
+		_, err = f.auditLogRepo.Create(txCtx, entry)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set feature flag: %w", err)
+	}
+
+	f.mu.Lock()
+	f.overrides[name] = saved
+	f.mu.Unlock()
+
+	f.log.WithContext(ctx).
+		WithField("flag", name).
+		WithField("enabled", saved.Enabled).
+		Warn("Feature flag overridden")
+
+	resp := f.describe(name)
+	return &resp, nil
+}
+
+// Clear removes the override so the flag falls back to its configured default
+func (f *FeatureFlags) Clear(ctx context.Context, name, actorIP string) (*dto.FeatureFlagResponse, error) {
+	if _, ok := featureFlagDescriptions[name]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFeatureFlag, name)
+	}
+
+	err := f.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		deleted, err := f.repo.Delete(txCtx, name)
+		if err != nil || !deleted {
+			return err
+		}
+
+		entry := newAdminAuditLog(txCtx, "feature_flag.cleared", "feature_flag", name, nil, actorIP)
+		_, err = f.auditLogRepo.Create(txCtx, entry)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear feature flag: %w", err)
+	}
+
+	f.mu.Lock()
+	delete(f.overrides, name)
+	f.mu.Unlock()
+
+	f.log.WithContext(ctx).WithField("flag", name).Warn("Feature flag override cleared")
+
+	resp := f.describe(name)
+	return &resp, nil
+}
+
+// Refresh reloads the overrides from the database
+func (f *FeatureFlags) Refresh(ctx context.Context) error {
+	overrides, err := f.repo.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	loaded := make(map[string]*model.FeatureFlagOverride, len(overrides))
+	for _, override := range overrides {
+		if _, ok := featureFlagDescriptions[override.Name]; !ok {
+			f.log.WithField("flag", override.Name).Warn("Ignoring override for unknown feature flag")
+			continue
+		}
+		loaded[override.Name] = override
+	}
+
+	f.mu.Lock()
+	f.overrides = loaded
+	f.mu.Unlock()
+	return nil
+}
+
+// Run reloads overrides until the context is cancelled. A non-positive interval loads them once.
+// When a reload fails the previously loaded overrides stay in effect.
+func (f *FeatureFlags) Run(ctx context.Context) {
+	if err := f.Refresh(ctx); err != nil {
+		f.log.WithError(err).Warn("Failed to load feature flag overrides")
+	}
+	if f.config.RefreshInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(f.config.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := f.Refresh(ctx); err != nil {
+			f.log.WithError(err).Warn("Failed to refresh feature flag overrides")
+		}
+	}
+}
+
+// describe builds the effective state of a single flag
+func (f *FeatureFlags) describe(name string) dto.FeatureFlagResponse {
+	resp := dto.FeatureFlagResponse{
+		Name:        name,
+		Description: featureFlagDescriptions[name],
+		Default:     f.config.Defaults[name],
+		Source:      dto.FeatureFlagSourceDefault,
+	}
+	resp.Enabled = resp.Default
+
+	f.mu.RLock()
+	override, ok := f.overrides[name]
+	f.mu.RUnlock()
+	if ok {
+		resp.Enabled = override.Enabled
+		resp.Source = dto.FeatureFlagSourceOverride
+		resp.Reason = override.Reason
+		updatedAt := override.UpdatedAt
+		resp.UpdatedAt = &updatedAt
+	}
+
+	return resp
+}
@@ -0,0 +1,117 @@
+// Package service provides the stale-while-revalidate cache of external inventory levels.
+package service
+
+import (
+	"sync"
+	"time"
+)
+
+// InventoryCacheConfig controls caching of inventory API results
+type InventoryCacheConfig struct {
+	// TTL is how long fetched stock is served as fresh; 0 disables the cache
+	TTL time.Duration
+	// StaleTTL is how long after TTL stock is still served, flagged stale, while it is
+	// refreshed in the background; 0 always waits for fresh stock
+	StaleTTL time.Duration
+}
+
+// inventoryCache holds the stock last fetched from the inventory API per option type
+type inventoryCache struct {
+	config InventoryCacheConfig
+
+	mu         sync.Mutex
+	entries    map[string]inventoryCacheEntry
+	refreshing map[string]bool
+}
+
+// inventoryCacheEntry is the stock of an option type and when it was fetched
+type inventoryCacheEntry struct {
+	stock     int
+	fetchedAt time.Time
+}
+
+// cachedInventory is a cache lookup result
+type cachedInventory struct {
+	stock map[string]int
+	// checkedAt is when the oldest of the stock figures was fetched
+	checkedAt time.Time
+	// stale lists the option types served past the TTL
+	stale []string
+}
+
+// newInventoryCache creates an empty cache
+func newInventoryCache(config InventoryCacheConfig) *inventoryCache {
+	return &inventoryCache{
+		config:     config,
+		entries:    make(map[string]inventoryCacheEntry),
+		refreshing: make(map[string]bool),
+	}
+}
+
+// enabled reports whether results are cached
+func (c *inventoryCache) enabled() bool {
+	return c.config.TTL > 0
+}
+
+// lookup returns the cached stock of every option type, or false when any of them is
+// missing or too old to serve even as stale
+func (c *inventoryCache) lookup(optionTypes []string, now time.Time) (*cachedInventory, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result := &cachedInventory{stock: make(map[string]int, len(optionTypes)), checkedAt: now}
+	for _, optionType := range optionTypes {
+		entry, ok := c.entries[optionType]
+		if !ok {
+			return nil, false
+		}
+		age := now.Sub(entry.fetchedAt)
+		if age > c.config.TTL+c.config.StaleTTL {
+			return nil, false
+		}
+		if age > c.config.TTL {
+			result.stale = append(result.stale, optionType)
+		}
+		result.stock[optionType] = entry.stock
+		if entry.fetchedAt.Before(result.checkedAt) {
+			result.checkedAt = entry.fetchedAt
+		}
+	}
+	return result, true
+}
+
+// store records freshly fetched stock
+func (c *inventoryCache) store(stock map[string]int, fetchedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for optionType, level := range stock {
+		c.entries[optionType] = inventoryCacheEntry{stock: level, fetchedAt: fetchedAt}
+	}
+}
+
+// claimRefresh marks option types as being refreshed and returns those no other request is
+// already refreshing
+func (c *inventoryCache) claimRefresh(optionTypes []string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var claimed []string
+	for _, optionType := range optionTypes {
+		if !c.refreshing[optionType] {
+			c.refreshing[optionType] = true
+			claimed = append(claimed, optionType)
+		}
+	}
+	return claimed
+}
+
+// finishRefresh releases option types claimed by claimRefresh
+func (c *inventoryCache) finishRefresh(optionTypes []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, optionType := range optionTypes {
+		delete(c.refreshing, optionType)
+	}
+}
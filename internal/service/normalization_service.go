@@ -0,0 +1,112 @@
+// Package service provides input normalization business logic.
+package service
+
+import (
+	"context"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/pkg/japanese"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+// NormalizationService defines the interface for correcting form input before validation
+type NormalizationService interface {
+	NormalizeKana(ctx context.Context, req *dto.NormalizeKanaRequest) (*dto.NormalizeKanaResponse, error)
+	NormalizeAddress(ctx context.Context, req *dto.NormalizeAddressRequest) (*dto.NormalizeAddressResponse, error)
+}
+
+// normalizationService implements NormalizationService
+type normalizationService struct {
+	validator *validator.CustomValidator
+	log       *logger.Logger
+}
+
+// NewNormalizationService creates a new normalization service
+func NewNormalizationService(validator *validator.CustomValidator, log *logger.Logger) NormalizationService {
+	return &normalizationService{
+		validator: validator,
+		log:       log,
+	}
+}
+
+// NormalizeKana converts half-width katakana, hiragana and romaji to full-width katakana
+func (s *normalizationService) NormalizeKana(
+	ctx context.Context, req *dto.NormalizeKanaRequest,
+) (*dto.NormalizeKanaResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	resp := &dto.NormalizeKanaResponse{Fields: make(map[string]dto.NormalizedValue, len(req.Fields))}
+	for field, value := range req.Fields {
+		normalized := japanese.ToKatakana(value)
+		resp.Fields[field] = dto.NormalizedValue{
+			Value:   normalized,
+			Changed: normalized != value,
+			Valid:   japanese.IsKatakana(normalized),
+		}
+	}
+
+	s.log.WithContext(ctx).WithField("fields", len(req.Fields)).Debug("Kana fields normalized")
+
+	return resp, nil
+}
+
+// NormalizeAddress converts address parts to the canonical form in which registrations
+// store them. Block numbers are valid when they could be read as numbers; the other parts
+// are free text and always valid.
+func (s *normalizationService) NormalizeAddress(
+	ctx context.Context, req *dto.NormalizeAddressRequest,
+) (*dto.NormalizeAddressResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	normalized := japanese.NormalizeAddress(japanese.Address{
+		Town: req.Town, Chome: req.Chome, Banchi: req.Banchi,
+		Go: req.Go, Building: req.Building, Room: req.Room,
+	})
+
+	normalizedValue := func(input, value string, valid bool) dto.NormalizedValue {
+		return dto.NormalizedValue{Value: value, Changed: value != input, Valid: valid}
+	}
+	resp := &dto.NormalizeAddressResponse{Fields: map[string]dto.NormalizedValue{
+		"town":     normalizedValue(req.Town, normalized.Town, true),
+		"chome":    normalizedValue(req.Chome, normalized.Chome, normalized.Chome == "" || japanese.IsChome(normalized.Chome)),
+		"banchi":   normalizedValue(req.Banchi, normalized.Banchi, japanese.IsBlockNumber(normalized.Banchi)),
+		"go":       normalizedValue(req.Go, normalized.Go, normalized.Go == "" || japanese.IsBlockNumber(normalized.Go)),
+		"building": normalizedValue(req.Building, normalized.Building, true),
+		"room":     normalizedValue(req.Room, normalized.Room, true),
+	}}
+
+	s.log.WithContext(ctx).Debug("Address normalized")
+
+	return resp, nil
+}
+
+// normalizeUserAddress converts the address parts of a registration or update to the
+// canonical form before they are validated and stored. Optional parts left out stay nil
+// unless another part was split into them.
+func normalizeUserAddress(req *dto.UserCreateRequest) {
+	normalized := japanese.NormalizeAddress(japanese.Address{
+		Town: stringValue(req.Town), Chome: stringValue(req.Chome), Banchi: req.Banchi,
+		Go: stringValue(req.Go), Building: stringValue(req.Building), Room: stringValue(req.Room),
+	})
+
+	req.Town = normalizedAddressPart(req.Town, normalized.Town)
+	req.Chome = normalizedAddressPart(req.Chome, normalized.Chome)
+	req.Banchi = normalized.Banchi
+	req.Go = normalizedAddressPart(req.Go, normalized.Go)
+	req.Building = normalizedAddressPart(req.Building, normalized.Building)
+	req.Room = normalizedAddressPart(req.Room, normalized.Room)
+}
+
+// normalizedAddressPart returns the normalized value of an optional address part
+func normalizedAddressPart(input *string, normalized string) *string {
+	if input == nil && normalized == "" {
+		return nil
+	}
+	return &normalized
+}
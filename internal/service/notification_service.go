@@ -0,0 +1,265 @@
+// Package service provides the notification service that emails users about their registration.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/domain"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/mail"
+	"github.com/octop162/normal-form-app-by-claude/pkg/masking"
+)
+
+const (
+	// Upper bound for the delay between send attempts
+	maxNotificationRetryDelay = 1 * time.Hour
+)
+
+// NotificationServiceConfig holds notification service settings
+type NotificationServiceConfig struct {
+	// ConfirmationEnabled sends the registration confirmation email after CreateUser
+	ConfirmationEnabled bool
+	PollInterval        time.Duration
+	BatchSize           int
+	MaxAttempts         int
+	RetryBaseDelay      time.Duration
+}
+
+// NotificationService emails users after changes to their registration. Notifications are
+// queued in the transaction of the change and sent by Run, so registration never waits for
+// or fails with the mail provider; failed sends are retried with exponential backoff.
+type NotificationService struct {
+	txManager        repository.TxManager
+	notificationRepo repository.EmailNotificationRepository
+	userRepo         repository.UserRepository
+	userOptionRepo   repository.UserOptionRepository
+	planRepo         repository.PlanRepository
+	optionRepo       repository.OptionRepository
+	templates        EmailTemplateService
+	mailer           mail.Sender
+	config           NotificationServiceConfig
+	log              *logger.Logger
+}
+
+// NewNotificationService creates a new notification service
+func NewNotificationService(
+	txManager repository.TxManager,
+	notificationRepo repository.EmailNotificationRepository,
+	userRepo repository.UserRepository,
+	userOptionRepo repository.UserOptionRepository,
+	planRepo repository.PlanRepository,
+	optionRepo repository.OptionRepository,
+	templates EmailTemplateService,
+	mailer mail.Sender,
+	config NotificationServiceConfig,
+	log *logger.Logger,
+) *NotificationService {
+	return &NotificationService{
+		txManager:        txManager,
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		userOptionRepo:   userOptionRepo,
+		planRepo:         planRepo,
+		optionRepo:       optionRepo,
+		templates:        templates,
+		mailer:           mailer,
+		config:           config,
+		log:              log,
+	}
+}
+
+// Subscribe registers the notification handlers on bus
+func (s *NotificationService) Subscribe(bus domain.Bus) {
+	if s.config.ConfirmationEnabled {
+		bus.Subscribe(domain.EventUserCreated, s.userCreated)
+	}
+}
+
+// userCreated queues the registration confirmation email
+func (s *NotificationService) userCreated(ctx context.Context, event domain.Event) error {
+	created := event.(domain.UserCreated)
+	return s.notificationRepo.Enqueue(ctx, EmailTemplateRegistrationConfirmation, created.User.ID)
+}
+
+// Run sends due notifications until the context is cancelled
+func (s *NotificationService) Run(ctx context.Context) {
+	s.log.WithField("poll_interval", s.config.PollInterval).Info("Notification sender started")
+
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the backlog before waiting for the next tick
+		for {
+			processed, err := s.ProcessBatch(ctx)
+			if err != nil {
+				s.log.WithError(err).Error("Notification batch failed")
+				break
+			}
+			if processed < s.config.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			s.log.Info("Notification sender stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch claims and sends one batch of notifications, returning the number processed
+func (s *NotificationService) ProcessBatch(ctx context.Context) (int, error) {
+	processed := 0
+
+	err := s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		notifications, err := s.notificationRepo.ClaimDue(txCtx, s.config.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, notification := range notifications {
+			if err := s.send(txCtx, notification); err != nil {
+				return err
+			}
+			processed++
+		}
+		return nil
+	})
+	if err != nil {
+		return processed, fmt.Errorf("failed to process notification batch: %w", err)
+	}
+
+	return processed, nil
+}
+
+// send renders and sends a single notification and records the outcome
+func (s *NotificationService) send(ctx context.Context, notification *model.EmailNotification) error {
+	entry := s.log.WithContext(ctx).
+		WithField("notification_id", notification.ID).
+		WithField("template", notification.Template).
+		WithField("user_id", notification.UserID)
+
+	msg, sendErr := s.render(ctx, notification)
+	if sendErr == nil {
+		sendErr = s.mailer.Send(ctx, msg)
+	}
+
+	switch {
+	case sendErr == nil:
+		entry.Info("Notification email sent")
+		return s.notificationRepo.MarkSent(ctx, notification.ID)
+	case errors.Is(sendErr, mail.ErrSuppressed):
+		entry.Info("Notification email skipped; the address bounced or complained")
+		return s.notificationRepo.MarkSkipped(ctx, notification.ID, sendErr.Error())
+	case errors.Is(sendErr, apperr.ErrNotFound):
+		entry.WithError(sendErr).Warn("Notification email skipped; the user no longer exists")
+		return s.notificationRepo.MarkSkipped(ctx, notification.ID, sendErr.Error())
+	}
+
+	attempts := notification.Attempts + 1
+	terminal := attempts >= s.config.MaxAttempts
+	nextAttemptAt := time.Now().Add(s.retryDelay(attempts))
+
+	entry = entry.WithError(sendErr).WithField("attempts", attempts)
+	if terminal {
+		entry.Error("Notification email failed permanently")
+	} else {
+		entry.WithField("next_attempt_at", nextAttemptAt).Warn("Notification email failed, will retry")
+	}
+
+	return s.notificationRepo.MarkFailed(ctx, notification.ID, sendErr.Error(), nextAttemptAt, terminal)
+}
+
+// render renders the notification from the user's current data
+func (s *NotificationService) render(ctx context.Context, notification *model.EmailNotification) (*mail.Message, error) {
+	if notification.Template != EmailTemplateRegistrationConfirmation {
+		return nil, fmt.Errorf("unsupported notification template %s", notification.Template)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, notification.UserID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.registrationConfirmation(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := s.templates.Render(ctx, notification.Template, user.Locale, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s email: %w", notification.Template, err)
+	}
+	msg.To = user.Email
+	return msg, nil
+}
+
+// registrationConfirmation builds the registration_confirmation data of a user. The phone
+// number is masked since email travels and is stored outside the application.
+func (s *NotificationService) registrationConfirmation(
+	ctx context.Context, user *model.User,
+) (*RegistrationConfirmationEmail, error) {
+	options, err := s.userOptionRepo.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user options: %w", err)
+	}
+
+	data := &RegistrationConfirmationEmail{
+		Name:         user.GetFullName(),
+		PhoneNumber:  masking.Apply(masking.RuleLast4, user.GetPhoneNumber()),
+		PostalCode:   user.PostalCode1 + user.PostalCode2,
+		Address:      user.GetFullAddress(),
+		PlanType:     user.PlanType,
+		PlanName:     s.planName(ctx, user.PlanType),
+		Options:      make([]RegistrationConfirmationOption, 0, len(options)),
+		RegisteredAt: user.CreatedAt,
+	}
+	for _, option := range options {
+		data.Options = append(data.Options, RegistrationConfirmationOption{
+			OptionType: option.OptionType,
+			OptionName: s.optionName(ctx, option.OptionType),
+			Quantity:   option.Quantity,
+			StartDate:  formatOptionStartDate(option.StartDate),
+		})
+	}
+	return data, nil
+}
+
+// planName returns the display name of a plan, falling back to its type
+func (s *NotificationService) planName(ctx context.Context, planType string) string {
+	plan, err := s.planRepo.GetByPlanType(ctx, planType)
+	if err != nil {
+		s.log.WithContext(ctx).WithError(err).WithField("plan_type", planType).
+			Warn("Failed to get plan name for notification email")
+		return planType + "プラン"
+	}
+	return plan.PlanName
+}
+
+// optionName returns the display name of an option, falling back to its type
+func (s *NotificationService) optionName(ctx context.Context, optionType string) string {
+	option, err := s.optionRepo.GetByOptionType(ctx, optionType)
+	if err != nil {
+		s.log.WithContext(ctx).WithError(err).WithField("option_type", optionType).
+			Warn("Failed to get option name for notification email")
+		return optionType
+	}
+	return option.OptionName
+}
+
+// retryDelay returns an exponential backoff delay for the given attempt number
+func (s *NotificationService) retryDelay(attempts int) time.Duration {
+	delay := s.config.RetryBaseDelay
+	for i := 1; i < attempts && delay < maxNotificationRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxNotificationRetryDelay)
+}
@@ -4,10 +4,13 @@ package service
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/octop162/normal-form-app-by-claude/internal/dto"
 	"github.com/octop162/normal-form-app-by-claude/internal/model"
 	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/events"
 	"github.com/octop162/normal-form-app-by-claude/pkg/external"
 	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
 )
@@ -17,12 +20,28 @@ const (
 	mockInventoryAA       = 10
 	mockInventoryAB       = 25
 	defaultInventoryLevel = 5
+	// Reported for active options while the inventory check is skipped
+	assumedInventoryLevel = 1
+
+	// Upper bound for publishing an inventory check result
+	inventoryEventTimeout = 5 * time.Second
+	// Upper bound for refreshing stale cached inventory in the background
+	inventoryRefreshTimeout = 10 * time.Second
 )
 
+// StockStateConfig maps stock numbers to the states shown to users
+type StockStateConfig struct {
+	// LowStockThreshold is the stock at or below which an option is low on stock; 0 disables
+	LowStockThreshold int
+	// LowStockThresholds overrides LowStockThreshold by option type
+	LowStockThresholds map[string]int
+}
+
 // OptionService defines the interface for option business logic
 type OptionService interface {
 	GetAvailableOptions(ctx context.Context, req *dto.OptionsGetRequest) (*dto.OptionsGetResponse, error)
 	CheckInventory(ctx context.Context, req *dto.InventoryCheckRequest) (*dto.InventoryCheckResponse, error)
+	CheckLiveInventory(ctx context.Context, req *dto.InventoryCheckRequest) (*dto.InventoryCheckResponse, error)
 	GetOptionByType(ctx context.Context, optionType string) (*dto.OptionResponse, error)
 	GetAllOptions(ctx context.Context) (*dto.OptionsGetResponse, error)
 }
@@ -31,6 +50,10 @@ type OptionService interface {
 type optionService struct {
 	optionRepo  repository.OptionRepository
 	externalAPI *external.Manager
+	eventBus    events.Publisher
+	flags       *FeatureFlags
+	inventory   *inventoryCache
+	stockStates StockStateConfig
 	log         *logger.Logger
 }
 
@@ -38,11 +61,19 @@ type optionService struct {
 func NewOptionService(
 	optionRepo repository.OptionRepository,
 	externalAPI *external.Manager,
+	eventBus events.Publisher,
+	flags *FeatureFlags,
+	inventoryCacheConfig InventoryCacheConfig,
+	stockStates StockStateConfig,
 	log *logger.Logger,
 ) OptionService {
 	return &optionService{
 		optionRepo:  optionRepo,
 		externalAPI: externalAPI,
+		eventBus:    eventBus,
+		flags:       flags,
+		inventory:   newInventoryCache(inventoryCacheConfig),
+		stockStates: stockStates,
 		log:         log,
 	}
 }
@@ -86,34 +117,119 @@ func (s *optionService) GetAvailableOptions(
 	}, nil
 }
 
-// CheckInventory checks inventory levels for specified option types
+// CheckInventory checks inventory levels for specified option types. Stock from the inventory
+// API may be served from cache; figures past the cache TTL are flagged stale and refreshed
+// in the background.
 func (s *optionService) CheckInventory(
 	ctx context.Context, req *dto.InventoryCheckRequest,
+) (*dto.InventoryCheckResponse, error) {
+	return s.checkInventory(ctx, req, true)
+}
+
+// CheckLiveInventory checks inventory levels without serving cached stock, for decisions
+// such as reservations that must not rely on old figures. When the inventory API fails it
+// returns an ExternalAPIError instead of the local fallback figures.
+func (s *optionService) CheckLiveInventory(
+	ctx context.Context, req *dto.InventoryCheckRequest,
+) (*dto.InventoryCheckResponse, error) {
+	return s.checkInventory(ctx, req, false)
+}
+
+// checkInventory checks inventory levels and the stock state of each option, serving cached
+// external stock when useCache is set
+func (s *optionService) checkInventory(
+	ctx context.Context, req *dto.InventoryCheckRequest, useCache bool,
+) (*dto.InventoryCheckResponse, error) {
+	if s.flags.Enabled(FlagSkipInventoryCheck) {
+		return s.assumeInventory(ctx, req.OptionTypes), nil
+	}
+
+	resp, err := s.lookupInventory(ctx, req, useCache)
+	if err != nil {
+		return nil, err
+	}
+	resp.States = make(map[string]string, len(resp.Inventory))
+	for optionType, stock := range resp.Inventory {
+		resp.States[optionType] = s.stockState(optionType, stock)
+	}
+	return resp, nil
+}
+
+// stockState maps the stock of an option to the state shown to users
+func (s *optionService) stockState(optionType string, stock int) string {
+	threshold, ok := s.stockStates.LowStockThresholds[optionType]
+	if !ok {
+		threshold = s.stockStates.LowStockThreshold
+	}
+
+	switch {
+	case stock <= 0:
+		return model.StockStateOutOfStock
+	case stock <= threshold:
+		return model.StockStateLowStock
+	default:
+		return model.StockStateInStock
+	}
+}
+
+// lookupInventory checks inventory levels, serving cached external stock when useCache is set.
+// Only cached checks outside strict mode fall back to local figures when the inventory API fails.
+func (s *optionService) lookupInventory(
+	ctx context.Context, req *dto.InventoryCheckRequest, useCache bool,
 ) (*dto.InventoryCheckResponse, error) {
 	inventory := make(map[string]int)
 
 	// Try external inventory API first if available
 	if s.externalAPI != nil && s.externalAPI.InventoryClient() != nil {
-		externalInventory, err := s.externalAPI.InventoryClient().CheckInventory(ctx, req.OptionTypes)
+		// The inventory cache holds production stock, so sandbox requests neither read nor
+		// fill it. Strict requests are not answered with stale stock.
+		shared := !external.IsSandbox(ctx)
+		if useCache && shared && s.inventory.enabled() {
+			if cached, ok := s.inventory.lookup(req.OptionTypes, time.Now()); ok &&
+				(len(cached.stale) == 0 || !external.IsStrict(ctx)) {
+				if len(cached.stale) > 0 {
+					s.refreshInventory(ctx, cached.stale)
+				}
+				return &dto.InventoryCheckResponse{
+					Inventory: s.availableInventory(ctx, cached.stock),
+					Stale:     len(cached.stale) > 0,
+					CheckedAt: cached.checkedAt,
+				}, nil
+			}
+		}
+
+		// Live checks must not be answered by a response cache configured on the client
+		apiCtx := ctx
+		if !useCache {
+			apiCtx = external.WithoutCache(ctx)
+		}
+		checkedAt := time.Now()
+		externalInventory, err := s.externalAPI.InventoryClient().CheckInventory(apiCtx, req.OptionTypes)
+		if err != nil && !useCache {
+			s.log.WithError(err).WithField("option_types", req.OptionTypes).Warn("External inventory API failed during a live check")
+			return nil, newExternalAPIError(ExternalAPIInventory, err)
+		}
+		if err != nil && external.IsStrict(ctx) {
+			s.log.WithError(err).WithField("option_types", req.OptionTypes).Warn("External inventory API failed in strict mode")
+			return nil, newStrictExternalAPIError(ExternalAPIInventory, err)
+		}
 		if err != nil {
 			s.log.WithError(err).WithField("option_types", req.OptionTypes).Warn("External inventory API failed, falling back to local logic")
 		} else {
-			// Validate options exist in local database and are active
-			for optionType, stock := range externalInventory {
-				option, err := s.optionRepo.GetByOptionType(ctx, optionType)
-				if err != nil || !option.IsActive {
-					inventory[optionType] = 0
-				} else {
-					inventory[optionType] = stock
-				}
+			if shared {
+				s.inventory.store(externalInventory, checkedAt)
 			}
+			inventory = s.availableInventory(ctx, externalInventory)
+			s.publishInventoryChecked(ctx, req.OptionTypes, inventory, events.InventorySourceExternal)
 			return &dto.InventoryCheckResponse{
 				Inventory: inventory,
+				CheckedAt: checkedAt,
 			}, nil
 		}
 	}
 
 	// Fallback to local logic
+	now := time.Now()
 	for _, optionType := range req.OptionTypes {
 		option, err := s.optionRepo.GetByOptionType(ctx, optionType)
 		if err != nil {
@@ -123,8 +239,8 @@ func (s *optionService) CheckInventory(
 			continue
 		}
 
-		if !option.IsActive {
-			// Inactive options have 0 inventory
+		if !option.IsAvailableAt(now) {
+			// Inactive and out-of-period options have 0 inventory
 			inventory[optionType] = 0
 			continue
 		}
@@ -134,11 +250,54 @@ func (s *optionService) CheckInventory(
 		inventory[optionType] = inventoryLevel
 	}
 
+	s.publishInventoryChecked(ctx, req.OptionTypes, inventory, events.InventorySourceFallback)
+
 	return &dto.InventoryCheckResponse{
 		Inventory: inventory,
+		CheckedAt: now,
 	}, nil
 }
 
+// availableInventory reports the stock of options that are not offered now as 0
+func (s *optionService) availableInventory(ctx context.Context, stock map[string]int) map[string]int {
+	inventory := make(map[string]int, len(stock))
+	now := time.Now()
+	for optionType, level := range stock {
+		option, err := s.optionRepo.GetByOptionType(ctx, optionType)
+		if err != nil || !option.IsAvailableAt(now) {
+			inventory[optionType] = 0
+		} else {
+			inventory[optionType] = level
+		}
+	}
+	return inventory
+}
+
+// refreshInventory fetches stale option types in the background. Each option type is
+// refreshed by one request at a time; failures keep the stale figures until they age out.
+func (s *optionService) refreshInventory(ctx context.Context, optionTypes []string) {
+	claimed := s.inventory.claimRefresh(optionTypes)
+	if len(claimed) == 0 {
+		return
+	}
+
+	go func() {
+		defer s.inventory.finishRefresh(claimed)
+
+		refreshCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), inventoryRefreshTimeout)
+		defer cancel()
+
+		checkedAt := time.Now()
+		stock, err := s.externalAPI.InventoryClient().CheckInventory(external.WithoutCache(refreshCtx), claimed)
+		if err != nil {
+			s.log.WithError(err).WithField("option_types", claimed).Warn("Failed to refresh cached inventory")
+			return
+		}
+		s.inventory.store(stock, checkedAt)
+		s.publishInventoryChecked(refreshCtx, claimed, s.availableInventory(refreshCtx, stock), events.InventorySourceExternal)
+	}()
+}
+
 // GetOptionByType retrieves a specific option by its type
 func (s *optionService) GetOptionByType(ctx context.Context, optionType string) (*dto.OptionResponse, error) {
 	option, err := s.optionRepo.GetByOptionType(ctx, optionType)
@@ -183,19 +342,82 @@ func (s *optionService) convertOptionToResponse(option *model.OptionMaster) dto.
 		OptionName:        option.OptionName,
 		Description:       description,
 		PlanCompatibility: option.PlanCompatibility,
+		MaxQuantity:       option.MaxQuantity,
 		IsActive:          option.IsActive,
+		MonthlyPrice:      option.MonthlyPrice,
+	}
+}
+
+// assumeInventory reports every available option as in stock while the inventory check is skipped
+func (s *optionService) assumeInventory(ctx context.Context, optionTypes []string) *dto.InventoryCheckResponse {
+	inventory := make(map[string]int, len(optionTypes))
+	states := make(map[string]string, len(optionTypes))
+	now := time.Now()
+	for _, optionType := range optionTypes {
+		option, err := s.optionRepo.GetByOptionType(ctx, optionType)
+		if err != nil || !option.IsAvailableAt(now) {
+			inventory[optionType] = 0
+			states[optionType] = model.StockStateOutOfStock
+			continue
+		}
+		inventory[optionType] = assumedInventoryLevel
+		// The assumed level is not real stock, so it is never reported as low
+		states[optionType] = model.StockStateInStock
+	}
+
+	s.log.WithContext(ctx).WithField("option_types", optionTypes).Debug("Inventory check skipped by feature flag")
+	s.publishInventoryChecked(ctx, optionTypes, inventory, events.InventorySourceSkipped)
+
+	return &dto.InventoryCheckResponse{
+		Inventory: inventory,
+		States:    states,
+		CheckedAt: now,
 	}
 }
 
 // filterOptionsByRegion filters options based on region restrictions
 // TODO: Implement actual region-based filtering logic
 func (s *optionService) filterOptionsByRegion(options []*model.OptionMaster, region string) []*model.OptionMaster {
+	if s.flags.Enabled(FlagDisableRegionRestriction) {
+		return options
+	}
+
 	// For now, return all options without filtering
 	// In production, this would call external region restriction API
 	s.log.WithField("region", region).Debug("Region-based filtering not yet implemented")
 	return options
 }
 
+// publishInventoryChecked publishes the inventory check result in the background.
+// Publishing is best-effort and never delays or fails the request.
+func (s *optionService) publishInventoryChecked(
+	ctx context.Context, optionTypes []string, inventory map[string]int, source string,
+) {
+	if s.eventBus == nil {
+		return
+	}
+
+	event, err := events.NewEvent(ctx, events.EventTypeInventoryChecked, strings.Join(optionTypes, ","), &events.InventoryCheckedData{
+		OptionTypes: optionTypes,
+		Inventory:   inventory,
+		Source:      source,
+		CheckedAt:   time.Now(),
+	})
+	if err != nil {
+		s.log.WithError(err).Warn("Failed to build inventory checked event")
+		return
+	}
+
+	go func() {
+		publishCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), inventoryEventTimeout)
+		defer cancel()
+
+		if err := s.eventBus.Publish(publishCtx, event); err != nil {
+			s.log.WithError(err).WithField("event_id", event.ID).Warn("Failed to publish inventory checked event")
+		}
+	}()
+}
+
 // getMockInventoryLevel returns mock inventory levels for testing
 // TODO: Replace with actual external API call
 func (s *optionService) getMockInventoryLevel(optionType string) int {
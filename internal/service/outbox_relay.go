@@ -0,0 +1,234 @@
+// Package service provides the outbox relay that publishes recorded events.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/events"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const (
+	// Upper bound for the delay between delivery attempts
+	maxOutboxRetryDelay = 1 * time.Hour
+)
+
+// OutboxPublisher delivers outbox events to downstream consumers
+type OutboxPublisher interface {
+	Publish(ctx context.Context, event *model.OutboxEvent) error
+}
+
+// OutboxRelayConfig holds outbox relay settings
+type OutboxRelayConfig struct {
+	PollInterval   time.Duration
+	BatchSize      int
+	MaxAttempts    int
+	RetryBaseDelay time.Duration
+	// Lease is how long claimed events are held before another relay may take them over;
+	// it should exceed the time to publish a whole batch
+	Lease time.Duration
+}
+
+// OutboxRelay polls the outbox table and publishes pending events.
+// Delivery is at-least-once: consumers should deduplicate by event ID.
+type OutboxRelay struct {
+	txManager  repository.TxManager
+	outboxRepo repository.OutboxRepository
+	publisher  OutboxPublisher
+	config     OutboxRelayConfig
+	log        *logger.Logger
+}
+
+// NewOutboxRelay creates a new outbox relay
+func NewOutboxRelay(
+	txManager repository.TxManager,
+	outboxRepo repository.OutboxRepository,
+	publisher OutboxPublisher,
+	config OutboxRelayConfig,
+	log *logger.Logger,
+) *OutboxRelay {
+	return &OutboxRelay{
+		txManager:  txManager,
+		outboxRepo: outboxRepo,
+		publisher:  publisher,
+		config:     config,
+		log:        log,
+	}
+}
+
+// Run publishes pending events until the context is cancelled
+func (r *OutboxRelay) Run(ctx context.Context) {
+	r.log.WithField("poll_interval", r.config.PollInterval).Info("Outbox relay started")
+
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the backlog before waiting for the next tick
+		for {
+			processed, err := r.ProcessBatch(ctx)
+			if err != nil {
+				r.log.WithError(err).Error("Outbox relay batch failed")
+				break
+			}
+			if processed < r.config.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			r.log.Info("Outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch claims and publishes one batch of events, returning the number claimed.
+// The events are leased in one statement and their outcomes recorded in a short transaction
+// afterwards, so a slow publisher holds no connection or row lock while it delivers.
+func (r *OutboxRelay) ProcessBatch(ctx context.Context) (int, error) {
+	events, err := r.outboxRepo.ClaimPending(ctx, r.config.BatchSize, r.config.Lease)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim outbox batch: %w", err)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	// Events left unpublished on shutdown are retried once their lease ends
+	outcomes := make([]error, 0, len(events))
+	for _, event := range events {
+		if ctx.Err() != nil {
+			break
+		}
+		outcomes = append(outcomes, r.publisher.Publish(ctx, event))
+	}
+
+	// Record what was delivered even when shutting down, so it is not published again
+	err = r.txManager.WithinTransaction(context.WithoutCancel(ctx), func(txCtx context.Context) error {
+		for i, publishErr := range outcomes {
+			if err := r.record(txCtx, events[i], publishErr); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return len(events), fmt.Errorf("failed to record outbox batch: %w", err)
+	}
+
+	return len(events), nil
+}
+
+// record stores the outcome of publishing a single event
+func (r *OutboxRelay) record(ctx context.Context, event *model.OutboxEvent, publishErr error) error {
+	if publishErr == nil {
+		return r.outboxRepo.MarkPublished(ctx, event.ID)
+	}
+
+	attempts := event.Attempts + 1
+	terminal := attempts >= r.config.MaxAttempts
+	nextAttemptAt := time.Now().Add(r.retryDelay(attempts))
+
+	entry := r.log.WithError(publishErr).
+		WithField("event_id", event.ID).
+		WithField("event_type", event.EventType).
+		WithField("correlation_id", stringValue(event.CorrelationID)).
+		WithField("attempts", attempts)
+	if terminal {
+		entry.Error("Outbox event delivery failed permanently")
+	} else {
+		entry.Warn("Outbox event delivery failed, will retry")
+	}
+
+	return r.outboxRepo.MarkFailed(ctx, event.ID, publishErr.Error(), nextAttemptAt, terminal)
+}
+
+// retryDelay returns an exponential backoff delay for the given attempt number
+func (r *OutboxRelay) retryDelay(attempts int) time.Duration {
+	delay := r.config.RetryBaseDelay
+	for i := 1; i < attempts && delay < maxOutboxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxOutboxRetryDelay)
+}
+
+// stringValue returns the pointed-to string or an empty string for nil
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// eventBusOutboxPublisher publishes outbox events to the message bus
+type eventBusOutboxPublisher struct {
+	publisher events.Publisher
+}
+
+// NewEventBusOutboxPublisher creates a publisher that delivers events to the message bus
+func NewEventBusOutboxPublisher(publisher events.Publisher) OutboxPublisher {
+	return &eventBusOutboxPublisher{publisher: publisher}
+}
+
+// Publish sends the event to the message bus keyed by aggregate ID
+func (p *eventBusOutboxPublisher) Publish(ctx context.Context, event *model.OutboxEvent) error {
+	return p.publisher.Publish(ctx, &events.Event{
+		ID:            "outbox-" + strconv.FormatInt(event.ID, 10),
+		Type:          event.EventType,
+		SchemaVersion: events.SchemaVersion(event.EventType),
+		Key:           event.AggregateID,
+		CorrelationID: stringValue(event.CorrelationID),
+		OccurredAt:    event.CreatedAt,
+		Data:          event.Payload,
+	})
+}
+
+// fanoutOutboxPublisher delivers each event to several publishers.
+// A failure in any of them retries the event for all, so consumers must deduplicate.
+type fanoutOutboxPublisher struct {
+	publishers []OutboxPublisher
+}
+
+// NewFanoutOutboxPublisher creates a publisher that delivers events to all given publishers
+func NewFanoutOutboxPublisher(publishers ...OutboxPublisher) OutboxPublisher {
+	return &fanoutOutboxPublisher{publishers: publishers}
+}
+
+// Publish sends the event to every publisher and joins their errors
+func (p *fanoutOutboxPublisher) Publish(ctx context.Context, event *model.OutboxEvent) error {
+	var errs []error
+	for _, publisher := range p.publishers {
+		if err := publisher.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// logOutboxPublisher logs events instead of delivering them (development default)
+type logOutboxPublisher struct {
+	log *logger.Logger
+}
+
+// NewLogOutboxPublisher creates a publisher that only logs events
+func NewLogOutboxPublisher(log *logger.Logger) OutboxPublisher {
+	return &logOutboxPublisher{log: log}
+}
+
+// Publish logs the event
+func (p *logOutboxPublisher) Publish(_ context.Context, event *model.OutboxEvent) error {
+	p.log.WithField("event_id", event.ID).
+		WithField("event_type", event.EventType).
+		WithField("aggregate_id", event.AggregateID).
+		Info("Outbox event published (no consumer configured)")
+	return nil
+}
@@ -0,0 +1,232 @@
+// Package service provides the handling of personal data requests.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+const (
+	// erasedNotificationReason is recorded on the notifications an erasure cancels
+	erasedNotificationReason = "personal data erased"
+)
+
+// PersonalDataService answers personal data requests: it exports everything stored about a
+// user and erases their personal data while keeping what aggregate statistics need
+type PersonalDataService interface {
+	Export(ctx context.Context, userID int, actorIP string) (*dto.PersonalDataExport, error)
+	Erase(
+		ctx context.Context, userID int, req *dto.PersonalDataEraseRequest, actorIP string,
+	) (*dto.PersonalDataEraseResponse, error)
+}
+
+// personalDataService implements PersonalDataService
+type personalDataService struct {
+	userRepo         repository.UserRepository
+	userOptionRepo   repository.UserOptionRepository
+	sessionRepo      repository.SessionRepository
+	notificationRepo repository.EmailNotificationRepository
+	auditLogRepo     repository.AuditLogRepository
+	txManager        repository.TxManager
+	validator        *validator.CustomValidator
+	log              *logger.Logger
+}
+
+// NewPersonalDataService creates a new personal data service
+func NewPersonalDataService(
+	userRepo repository.UserRepository,
+	userOptionRepo repository.UserOptionRepository,
+	sessionRepo repository.SessionRepository,
+	notificationRepo repository.EmailNotificationRepository,
+	auditLogRepo repository.AuditLogRepository,
+	txManager repository.TxManager,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) PersonalDataService {
+	return &personalDataService{
+		userRepo:         userRepo,
+		userOptionRepo:   userOptionRepo,
+		sessionRepo:      sessionRepo,
+		notificationRepo: notificationRepo,
+		auditLogRepo:     auditLogRepo,
+		txManager:        txManager,
+		validator:        validator,
+		log:              log,
+	}
+}
+
+// Export returns the user, their options and the form sessions of their email address. The
+// export is recorded in the audit log in the same transaction, so no data leaves unrecorded.
+func (s *personalDataService) Export(ctx context.Context, userID int, actorIP string) (*dto.PersonalDataExport, error) {
+	var export *dto.PersonalDataExport
+	err := s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		user, err := s.userRepo.GetByID(txCtx, userID)
+		if err != nil {
+			return err
+		}
+		options, err := s.userOptionRepo.GetByUserID(txCtx, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get user options: %w", err)
+		}
+		sessions, err := s.sessionRepo.ListByEmail(txCtx, user.Email)
+		if err != nil {
+			return err
+		}
+
+		export = &dto.PersonalDataExport{
+			ExportedAt: time.Now(),
+			User:       toPersonalDataUser(user),
+			Options:    make([]dto.PersonalDataOption, 0, len(options)),
+			Sessions:   make([]dto.PersonalDataSession, 0, len(sessions)),
+		}
+		for _, option := range options {
+			export.Options = append(export.Options, toPersonalDataOption(option))
+		}
+		for _, session := range sessions {
+			export.Sessions = append(export.Sessions, dto.PersonalDataSession{
+				ID:        session.ID,
+				UserData:  sessionFormDTO(session.UserData),
+				ClientIP:  session.ClientIP,
+				CreatedAt: session.CreatedAt,
+				UpdatedAt: session.UpdatedAt,
+				ExpiresAt: session.ExpiresAt,
+			})
+		}
+
+		details, err := json.Marshal(map[string]any{"options": len(options), "sessions": len(sessions)})
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit details: %w", err)
+		}
+		_, err = s.auditLogRepo.Create(txCtx, newAdminAuditLog(
+			txCtx, "user.exported", "user", strconv.Itoa(userID), details, actorIP,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to export personal data: %w", err)
+	}
+
+	s.log.WithContext(ctx).WithField("user_id", userID).Warn("User personal data exported")
+	return export, nil
+}
+
+// Erase replaces the personal data of a user with placeholders, deletes the form sessions of
+// their email address and cancels their pending notification emails. The user row, plan,
+// options, region and registration date remain so statistics are unchanged. Users under legal
+// hold cannot be erased.
+func (s *personalDataService) Erase(
+	ctx context.Context, userID int, req *dto.PersonalDataEraseRequest, actorIP string,
+) (*dto.PersonalDataEraseResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	resp := &dto.PersonalDataEraseResponse{UserID: userID}
+	err := s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		user, err := s.userRepo.GetByIDForUpdate(txCtx, userID)
+		if err != nil {
+			return err
+		}
+		if user.LegalHold {
+			return apperr.Errorf(apperr.ErrLegalHold, "user %d is under legal hold", userID)
+		}
+		if user.IsErased() {
+			return apperr.Errorf(apperr.ErrDuplicate, "personal data of user %d is already erased", userID)
+		}
+
+		// Sessions are found by the email address, so they go before it is replaced
+		if resp.SessionsDeleted, err = s.sessionRepo.DeleteByEmail(txCtx, user.Email); err != nil {
+			return err
+		}
+		resp.NotificationsSkipped, err = s.notificationRepo.SkipPendingByUserID(txCtx, userID, erasedNotificationReason)
+		if err != nil {
+			return err
+		}
+		if resp.ErasedAt, err = s.userRepo.Anonymize(txCtx, userID); err != nil {
+			return err
+		}
+
+		details, err := json.Marshal(map[string]any{
+			"reason":                req.Reason,
+			"sessions_deleted":      resp.SessionsDeleted,
+			"notifications_skipped": resp.NotificationsSkipped,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit details: %w", err)
+		}
+		_, err = s.auditLogRepo.Create(txCtx, newAdminAuditLog(
+			txCtx, "user.erased", "user", strconv.Itoa(userID), details, actorIP,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to erase personal data: %w", err)
+	}
+
+	s.log.WithContext(ctx).
+		WithField("user_id", userID).
+		WithField("sessions_deleted", resp.SessionsDeleted).
+		Warn("User personal data erased")
+
+	return resp, nil
+}
+
+// toPersonalDataUser converts a user to its export form
+func toPersonalDataUser(user *model.User) dto.PersonalDataUser {
+	return dto.PersonalDataUser{
+		ID:                           user.ID,
+		LastName:                     user.LastName,
+		FirstName:                    user.FirstName,
+		LastNameKana:                 user.LastNameKana,
+		FirstNameKana:                user.FirstNameKana,
+		Phone1:                       user.Phone1,
+		Phone2:                       user.Phone2,
+		Phone3:                       user.Phone3,
+		PhoneVerified:                user.PhoneVerified,
+		PostalCode1:                  user.PostalCode1,
+		PostalCode2:                  user.PostalCode2,
+		Prefecture:                   user.Prefecture,
+		City:                         user.City,
+		Town:                         user.Town,
+		Chome:                        user.Chome,
+		Banchi:                       user.Banchi,
+		Go:                           user.Go,
+		Building:                     user.Building,
+		Room:                         user.Room,
+		Email:                        user.Email,
+		PlanType:                     user.PlanType,
+		Locale:                       user.Locale,
+		Status:                       user.Status,
+		LegalHold:                    user.LegalHold,
+		EmailDeliverability:          user.EmailDeliverability,
+		EmailDeliverabilityReason:    user.EmailDeliverabilityReason,
+		EmailDeliverabilityUpdatedAt: user.EmailDeliverabilityUpdatedAt,
+		ErasedAt:                     user.ErasedAt,
+		CreatedAt:                    user.CreatedAt,
+		UpdatedAt:                    user.UpdatedAt,
+	}
+}
+
+// toPersonalDataOption converts a user option to its export form
+func toPersonalDataOption(option *model.UserOption) dto.PersonalDataOption {
+	exported := dto.PersonalDataOption{
+		OptionType: option.OptionType,
+		Quantity:   option.Quantity,
+		CreatedAt:  option.CreatedAt,
+	}
+	if option.StartDate != nil {
+		startDate := formatOptionStartDate(option.StartDate)
+		exported.StartDate = &startDate
+	}
+	return exported
+}
@@ -0,0 +1,220 @@
+// Package service provides phone number verification by SMS one-time code.
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/sms"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+const (
+	phoneVerificationIDBytes    = 16
+	phoneVerificationCodeDigits = 6
+	// mobileNumberLength is the digit count of Japanese mobile numbers (e.g. 090-1234-5678)
+	mobileNumberLength = 11
+	// countryCodeJapan replaces the leading trunk prefix "0" in E.164 numbers
+	countryCodeJapan = "+81"
+)
+
+// mobilePrefixes are the leading digits of Japanese mobile numbers, which can receive SMS
+var mobilePrefixes = []string{"070", "080", "090"}
+
+var (
+	// ErrPhoneVerificationFailed is returned for every verification failure so that callers
+	// cannot tell unknown, expired, used and wrong codes apart
+	ErrPhoneVerificationFailed = errors.New("invalid or expired verification code")
+	// ErrPhoneCodeCooldown is returned when a code was sent to the number too recently
+	ErrPhoneCodeCooldown = errors.New("a verification code was sent to this number recently")
+)
+
+// PhoneVerificationConfig controls SMS phone verification
+type PhoneVerificationConfig struct {
+	CodeTTL     time.Duration
+	MaxAttempts int
+	// ResendInterval is the minimum time between codes sent to the same number
+	ResendInterval time.Duration
+	// VerifiedTTL is how long a verified code marks registrations with the number as verified
+	VerifiedTTL time.Duration
+}
+
+// PhoneVerificationService defines the interface for SMS phone verification
+type PhoneVerificationService interface {
+	SendCode(ctx context.Context, req *dto.PhoneSendCodeRequest) (*dto.PhoneSendCodeResponse, error)
+	VerifyCode(ctx context.Context, req *dto.PhoneVerifyCodeRequest) (*dto.PhoneVerifyCodeResponse, error)
+	// IsVerified reports whether the phone number (digits only) was verified within VerifiedTTL
+	IsVerified(ctx context.Context, phone string) (bool, error)
+}
+
+// phoneVerificationService implements PhoneVerificationService
+type phoneVerificationService struct {
+	userRepo         repository.UserRepository
+	verificationRepo repository.PhoneVerificationRepository
+	txManager        repository.TxManager
+	sender           sms.Sender
+	config           PhoneVerificationConfig
+	validator        *validator.CustomValidator
+	log              *logger.Logger
+}
+
+// NewPhoneVerificationService creates a new phone verification service
+func NewPhoneVerificationService(
+	userRepo repository.UserRepository,
+	verificationRepo repository.PhoneVerificationRepository,
+	txManager repository.TxManager,
+	sender sms.Sender,
+	config PhoneVerificationConfig,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) PhoneVerificationService {
+	return &phoneVerificationService{
+		userRepo:         userRepo,
+		verificationRepo: verificationRepo,
+		txManager:        txManager,
+		sender:           sender,
+		config:           config,
+		validator:        validator,
+		log:              log,
+	}
+}
+
+// SendCode sends a verification code by SMS to a mobile number
+func (s *phoneVerificationService) SendCode(
+	ctx context.Context, req *dto.PhoneSendCodeRequest,
+) (*dto.PhoneSendCodeResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	phone := normalizePhone(req.Phone1, req.Phone2, req.Phone3)
+	if !isMobileNumber(phone) {
+		return nil, apperr.Errorf(apperr.ErrValidation, "phone number cannot receive SMS")
+	}
+
+	now := time.Now()
+	recent, err := s.verificationRepo.CountSince(ctx, phone, now.Add(-s.config.ResendInterval))
+	if err != nil {
+		return nil, err
+	}
+	if recent > 0 {
+		return nil, ErrPhoneCodeCooldown
+	}
+
+	verificationID, err := randomHex(phoneVerificationIDBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate verification ID: %w", err)
+	}
+	code, err := randomDigits(phoneVerificationCodeDigits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate verification code: %w", err)
+	}
+
+	if _, err := s.verificationRepo.DeleteExpired(ctx, now, now.Add(-s.config.VerifiedTTL)); err != nil {
+		s.log.WithContext(ctx).WithError(err).Warn("Failed to clean up expired phone verifications")
+	}
+
+	verification := &model.PhoneVerification{
+		ID:        verificationID,
+		Phone:     phone,
+		CodeHash:  hashVerificationCode(verificationID, code),
+		ExpiresAt: now.Add(s.config.CodeTTL),
+	}
+	if err := s.verificationRepo.Create(ctx, verification); err != nil {
+		return nil, err
+	}
+
+	minutes := int(s.config.CodeTTL.Minutes())
+	err = s.sender.Send(ctx, &sms.Message{
+		To:   countryCodeJapan + phone[1:],
+		Body: fmt.Sprintf("【会員登録】認証コード: %s\n%d分以内に入力してください。", code, minutes),
+	})
+	if err != nil {
+		s.log.WithContext(ctx).WithError(err).Error("Failed to send phone verification SMS")
+		return nil, fmt.Errorf("failed to send verification code: %w", err)
+	}
+
+	s.log.WithContext(ctx).WithField("verification_id", verificationID).Info("Phone verification code sent")
+
+	return &dto.PhoneSendCodeResponse{
+		VerificationID: verificationID,
+		ExpiresAt:      verification.ExpiresAt,
+		ResendAfter:    now.Add(s.config.ResendInterval),
+	}, nil
+}
+
+// VerifyCode checks the code and marks users registered with the phone number as verified
+func (s *phoneVerificationService) VerifyCode(
+	ctx context.Context, req *dto.PhoneVerifyCodeRequest,
+) (*dto.PhoneVerifyCodeResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	verified := false
+
+	err := s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		verification, err := s.verificationRepo.GetByIDForUpdate(txCtx, req.VerificationID)
+		if err != nil {
+			if errors.Is(err, apperr.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		if verification.VerifiedAt != nil || verification.IsExpired() ||
+			verification.Attempts >= s.config.MaxAttempts {
+			return nil
+		}
+
+		expected := []byte(verification.CodeHash)
+		actual := []byte(hashVerificationCode(verification.ID, req.Code))
+		if subtle.ConstantTimeCompare(expected, actual) != 1 {
+			// Commit the failed attempt so the code locks after MaxAttempts guesses
+			return s.verificationRepo.IncrementAttempts(txCtx, verification.ID)
+		}
+
+		if err := s.verificationRepo.MarkVerified(txCtx, verification.ID); err != nil {
+			return err
+		}
+		updated, err := s.userRepo.MarkPhoneVerified(txCtx, verification.Phone)
+		if err != nil {
+			return err
+		}
+
+		s.log.WithContext(ctx).
+			WithField("verification_id", verification.ID).
+			WithField("users_updated", updated).
+			Info("Phone number verified")
+		verified = true
+		return nil
+	})
+	if err != nil {
+		s.log.WithContext(ctx).WithError(err).Error("Failed to verify phone code")
+		return nil, fmt.Errorf("failed to verify phone code: %w", err)
+	}
+	if !verified {
+		return nil, ErrPhoneVerificationFailed
+	}
+
+	return &dto.PhoneVerifyCodeResponse{PhoneVerified: true}, nil
+}
+
+// IsVerified reports whether a code sent to the phone number was verified within VerifiedTTL
+func (s *phoneVerificationService) IsVerified(ctx context.Context, phone string) (bool, error) {
+	return s.verificationRepo.ExistsVerifiedSince(ctx, phone, time.Now().Add(-s.config.VerifiedTTL))
+}
+
+// isMobileNumber reports whether a digits-only phone number is a Japanese mobile number
+func isMobileNumber(phone string) bool {
+	return len(phone) == mobileNumberLength && slices.Contains(mobilePrefixes, phone[:3])
+}
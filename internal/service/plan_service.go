@@ -3,9 +3,13 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
 	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
 	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
 )
 
@@ -18,60 +22,67 @@ type PlanService interface {
 
 // planService implements PlanService
 type planService struct {
-	log *logger.Logger
+	planRepo repository.PlanRepository
+	log      *logger.Logger
 }
 
 // NewPlanService creates a new plan service
-func NewPlanService(log *logger.Logger) PlanService {
+func NewPlanService(planRepo repository.PlanRepository, log *logger.Logger) PlanService {
 	return &planService{
-		log: log,
+		planRepo: planRepo,
+		log:      log,
 	}
 }
 
-// GetAvailablePlans retrieves all available plans
-func (s *planService) GetAvailablePlans(_ context.Context) (*dto.PlansGetResponse, error) {
-	// TODO: In production, this might come from a database or external service
-	// For now, return static plan data
-	plans := []dto.PlanResponse{
-		{
-			PlanType:    "A",
-			PlanName:    "Aプラン",
-			Description: "基本プランです。標準的なサービスをご利用いただけます。",
-		},
-		{
-			PlanType:    "B",
-			PlanName:    "Bプラン",
-			Description: "プレミアムプランです。より充実したサービスをご利用いただけます。",
-		},
+// GetAvailablePlans retrieves the plans new registrations may choose, in display order
+func (s *planService) GetAvailablePlans(ctx context.Context) (*dto.PlansGetResponse, error) {
+	plans, err := s.planRepo.GetActivePlans(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plans: %w", err)
 	}
 
-	return &dto.PlansGetResponse{
-		Plans: plans,
-	}, nil
+	resp := &dto.PlansGetResponse{Plans: make([]dto.PlanResponse, 0, len(plans))}
+	for _, plan := range plans {
+		resp.Plans = append(resp.Plans, *toPlanResponse(plan))
+	}
+	return resp, nil
 }
 
-// GetPlanByType retrieves a specific plan by type
+// GetPlanByType retrieves a specific active plan by type
 func (s *planService) GetPlanByType(ctx context.Context, planType string) (*dto.PlanResponse, error) {
-	plans, err := s.GetAvailablePlans(ctx)
+	plan, err := s.planRepo.GetByPlanType(ctx, planType)
 	if err != nil {
 		return nil, err
 	}
-
-	for _, plan := range plans.Plans {
-		if plan.PlanType == planType {
-			return &plan, nil
-		}
+	if !plan.IsActive {
+		return nil, apperr.Errorf(apperr.ErrNotFound, "plan type %s not found", planType)
 	}
 
-	return nil, fmt.Errorf("plan type %s not found", planType)
+	return toPlanResponse(plan), nil
 }
 
 // ValidatePlanType validates if a plan type is valid
 func (s *planService) ValidatePlanType(ctx context.Context, planType string) (bool, error) {
 	_, err := s.GetPlanByType(ctx, planType)
-	if err != nil {
+	if errors.Is(err, apperr.ErrNotFound) {
 		return false, nil // Plan type not found, but no error
 	}
+	if err != nil {
+		return false, err
+	}
 
 	return true, nil
 }
+
+// toPlanResponse converts the plan model to the public representation
+func toPlanResponse(plan *model.PlanMaster) *dto.PlanResponse {
+	resp := &dto.PlanResponse{
+		PlanType:     plan.PlanType,
+		PlanName:     plan.PlanName,
+		MonthlyPrice: plan.MonthlyPrice,
+	}
+	if plan.Description != nil {
+		resp.Description = *plan.Description
+	}
+	return resp
+}
@@ -0,0 +1,127 @@
+// Package service provides pricing business logic.
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/money"
+)
+
+// taxRateDateLayout is the format of tax rate effective dates
+const taxRateDateLayout = "2006-01-02"
+
+// taxZone is the time zone in which tax rate changes take effect
+var taxZone = time.FixedZone("Asia/Tokyo", 9*60*60)
+
+// ErrNoTaxRate is returned for a date before the first configured tax rate
+var ErrNoTaxRate = errors.New("no tax rate is in effect")
+
+// PricingConfig holds consumption tax configuration
+type PricingConfig struct {
+	// TaxRates lists "effective date=percent" entries separated by ";", e.g.
+	// "2014-04-01=8;2019-10-01=10". Each rate applies until the next one takes effect.
+	TaxRates string
+	// TaxRounding is the rounding mode applied to tax: "down", "half_up" or "up"
+	TaxRounding string
+}
+
+// TaxRate is a consumption tax rate and the day it takes effect
+type TaxRate struct {
+	EffectiveFrom time.Time
+	Rate          money.Rate
+}
+
+// PricingService defines the interface for pricing business logic
+type PricingService interface {
+	TaxRateAt(t time.Time) (*TaxRate, error)
+	TaxRounding() money.Rounding
+}
+
+// pricingService implements PricingService
+type pricingService struct {
+	taxRates    []TaxRate
+	taxRounding money.Rounding
+	log         *logger.Logger
+}
+
+// NewPricingService creates a new pricing service, rejecting an invalid tax configuration
+func NewPricingService(config PricingConfig, log *logger.Logger) (PricingService, error) {
+	taxRates, err := ParseTaxRates(config.TaxRates)
+	if err != nil {
+		return nil, err
+	}
+
+	taxRounding, err := money.ParseRounding(config.TaxRounding)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tax rounding: %w", err)
+	}
+
+	return &pricingService{
+		taxRates:    taxRates,
+		taxRounding: taxRounding,
+		log:         log,
+	}, nil
+}
+
+// TaxRateAt returns the tax rate in effect at t. Rates change at midnight Japan time, so a
+// registration at 23:59 JST on the day before a change still gets the old rate.
+func (s *pricingService) TaxRateAt(t time.Time) (*TaxRate, error) {
+	i := sort.Search(len(s.taxRates), func(i int) bool { return s.taxRates[i].EffectiveFrom.After(t) })
+	if i == 0 {
+		return nil, fmt.Errorf("%w on %s", ErrNoTaxRate, t.In(taxZone).Format(taxRateDateLayout))
+	}
+
+	rate := s.taxRates[i-1]
+	return &rate, nil
+}
+
+// TaxRounding returns the rounding mode applied to tax
+func (s *pricingService) TaxRounding() money.Rounding {
+	return s.taxRounding
+}
+
+// ParseTaxRates parses a TaxRates specification into rates ordered by effective date
+func ParseTaxRates(spec string) ([]TaxRate, error) {
+	var rates []TaxRate
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		date, percent, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid tax rate %q: expected date=percent", entry)
+		}
+
+		effectiveFrom, err := time.ParseInLocation(taxRateDateLayout, strings.TrimSpace(date), taxZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tax rate %q: %w", entry, err)
+		}
+
+		rate, err := money.ParseRate(percent)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tax rate %q: %w", entry, err)
+		}
+
+		rates = append(rates, TaxRate{EffectiveFrom: effectiveFrom, Rate: rate})
+	}
+
+	if len(rates) == 0 {
+		return nil, errors.New("no tax rates configured")
+	}
+
+	sort.Slice(rates, func(i, j int) bool { return rates[i].EffectiveFrom.Before(rates[j].EffectiveFrom) })
+	for i := 1; i < len(rates); i++ {
+		if rates[i].EffectiveFrom.Equal(rates[i-1].EffectiveFrom) {
+			return nil, fmt.Errorf("duplicate tax rate for %s", rates[i].EffectiveFrom.Format(taxRateDateLayout))
+		}
+	}
+
+	return rates, nil
+}
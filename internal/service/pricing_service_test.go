@@ -0,0 +1,55 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/money"
+)
+
+func TestPricingService_TaxRateAt(t *testing.T) {
+	pricing, err := NewPricingService(PricingConfig{
+		TaxRates:    "2014-04-01=8;2019-10-01=10",
+		TaxRounding: "down",
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewPricingService: %v", err)
+	}
+
+	jst := time.FixedZone("JST", 9*60*60)
+	tests := []struct {
+		name string
+		at   time.Time
+		want money.Rate
+	}{
+		{"day before the change", time.Date(2019, 9, 30, 12, 0, 0, 0, jst), money.Percent(8)},
+		{"day of the change", time.Date(2019, 10, 1, 12, 0, 0, 0, jst), money.Percent(10)},
+		{"day after the change", time.Date(2019, 10, 2, 12, 0, 0, 0, jst), money.Percent(10)},
+		{"last instant before midnight JST", time.Date(2019, 9, 30, 23, 59, 59, 999999999, jst), money.Percent(8)},
+		{"midnight JST", time.Date(2019, 10, 1, 0, 0, 0, 0, jst), money.Percent(10)},
+		// 15:00 UTC on 30 September is midnight on 1 October in Japan
+		{"before midnight JST in UTC", time.Date(2019, 9, 30, 14, 59, 59, 0, time.UTC), money.Percent(8)},
+		{"midnight JST in UTC", time.Date(2019, 9, 30, 15, 0, 0, 0, time.UTC), money.Percent(10)},
+		{"first day of the first rate", time.Date(2014, 4, 1, 0, 0, 0, 0, jst), money.Percent(8)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pricing.TaxRateAt(tt.at)
+			if err != nil {
+				t.Fatalf("TaxRateAt(%s): %v", tt.at, err)
+			}
+			if got.Rate != tt.want {
+				t.Errorf("TaxRateAt(%s) = %s, want %s", tt.at, got.Rate, tt.want)
+			}
+		})
+	}
+
+	t.Run("before the first rate", func(t *testing.T) {
+		_, err := pricing.TaxRateAt(time.Date(2014, 3, 31, 23, 59, 59, 0, jst))
+		if !errors.Is(err, ErrNoTaxRate) {
+			t.Errorf("TaxRateAt before the first rate: err = %v, want ErrNoTaxRate", err)
+		}
+	})
+}
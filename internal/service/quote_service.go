@@ -0,0 +1,184 @@
+// Package service provides monthly price quotes.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/money"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+// Kinds of quote lines
+const (
+	QuoteLinePlan   = "plan"
+	QuoteLineOption = "option"
+)
+
+// QuoteService defines the interface for pricing a plan and options
+type QuoteService interface {
+	Quote(ctx context.Context, req *dto.QuoteRequest) (*dto.QuoteResponse, error)
+	// Price prices a plan and options the way Quote does, as the snapshot stored with a
+	// registration; the caller sets its user ID
+	Price(ctx context.Context, req *dto.QuoteRequest) (*model.UserPricingSnapshot, error)
+}
+
+// quoteService implements QuoteService
+type quoteService struct {
+	planRepo   repository.PlanRepository
+	optionRepo repository.OptionRepository
+	pricing    PricingService
+	validator  *validator.CustomValidator
+	log        *logger.Logger
+}
+
+// NewQuoteService creates a new quote service
+func NewQuoteService(
+	planRepo repository.PlanRepository,
+	optionRepo repository.OptionRepository,
+	pricing PricingService,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) QuoteService {
+	return &quoteService{
+		planRepo:   planRepo,
+		optionRepo: optionRepo,
+		pricing:    pricing,
+		validator:  validator,
+		log:        log,
+	}
+}
+
+// Quote computes the monthly total of a plan and its options at the current tax rate. The
+// selection is checked the way registration checks it, so a quote is never shown for a
+// combination that cannot be submitted.
+func (s *quoteService) Quote(ctx context.Context, req *dto.QuoteRequest) (*dto.QuoteResponse, error) {
+	pricing, err := s.Price(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.QuoteResponse{
+		PlanType: pricing.PlanType,
+		Lines:    toQuoteLines(pricing.Lines),
+		Subtotal: pricing.Subtotal,
+		TaxRate:  pricing.TaxRate.String(),
+		Tax:      pricing.Tax,
+		Total:    pricing.Total,
+		QuotedAt: pricing.PricedAt,
+	}, nil
+}
+
+// Price computes the monthly total of a plan and its options at the current tax rate
+func (s *quoteService) Price(ctx context.Context, req *dto.QuoteRequest) (*model.UserPricingSnapshot, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	now := time.Now()
+
+	plan, err := s.planRepo.GetByPlanType(ctx, req.PlanType)
+	if errors.Is(err, apperr.ErrNotFound) || (err == nil && !plan.IsActive) {
+		return nil, apperr.Errorf(apperr.ErrValidation, "plan %s is not available", req.PlanType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get plan: %w", err)
+	}
+
+	lines := []model.PricingLine{{
+		Kind:      QuoteLinePlan,
+		Code:      plan.PlanType,
+		Name:      plan.PlanName,
+		UnitPrice: plan.MonthlyPrice,
+		Quantity:  1,
+		Amount:    plan.MonthlyPrice,
+	}}
+
+	// Quantities are only accepted for selected options
+	for _, optionType := range slices.Sorted(maps.Keys(req.OptionDetails)) {
+		if !slices.Contains(req.OptionTypes, optionType) {
+			return nil, apperr.Errorf(apperr.ErrValidation, "option %s is not selected", optionType)
+		}
+	}
+
+	for _, optionType := range req.OptionTypes {
+		option, err := s.optionRepo.GetByOptionType(ctx, optionType)
+		if errors.Is(err, apperr.ErrNotFound) {
+			return nil, apperr.Errorf(apperr.ErrValidation, "option %s not found", optionType)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get option: %w", err)
+		}
+		if !option.IsAvailableAt(now) {
+			return nil, apperr.Errorf(apperr.ErrValidation, "option %s is not available", optionType)
+		}
+		if !isOptionCompatibleWithPlan(option, plan.PlanType) {
+			return nil, apperr.Errorf(apperr.ErrValidation,
+				"option %s is not compatible with plan %s", optionType, plan.PlanType)
+		}
+
+		quantity := 1
+		if detail := req.OptionDetails[optionType]; detail.Quantity > 0 {
+			quantity = detail.Quantity
+		}
+		if quantity > option.MaxQuantity {
+			return nil, apperr.Errorf(apperr.ErrValidation,
+				"quantity of option %s must be at most %d", optionType, option.MaxQuantity)
+		}
+
+		lines = append(lines, model.PricingLine{
+			Kind:      QuoteLineOption,
+			Code:      option.OptionType,
+			Name:      option.OptionName,
+			UnitPrice: option.MonthlyPrice,
+			Quantity:  quantity,
+			Amount:    option.MonthlyPrice.Times(quantity),
+		})
+	}
+
+	taxRate, err := s.pricing.TaxRateAt(now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tax rate: %w", err)
+	}
+
+	subtotal := money.Yen(0)
+	for _, line := range lines {
+		subtotal += line.Amount
+	}
+	tax := money.Tax(subtotal, taxRate.Rate, s.pricing.TaxRounding())
+
+	return &model.UserPricingSnapshot{
+		PlanType: plan.PlanType,
+		Lines:    lines,
+		Subtotal: subtotal,
+		TaxRate:  taxRate.Rate,
+		Tax:      tax,
+		Total:    subtotal + tax,
+		PricedAt: now,
+	}, nil
+}
+
+// toQuoteLines converts priced lines to their response form
+func toQuoteLines(lines []model.PricingLine) []dto.QuoteLine {
+	quoteLines := make([]dto.QuoteLine, 0, len(lines))
+	for _, line := range lines {
+		quoteLines = append(quoteLines, dto.QuoteLine{
+			Kind:      line.Kind,
+			Code:      line.Code,
+			Name:      line.Name,
+			UnitPrice: line.UnitPrice,
+			Quantity:  line.Quantity,
+			Amount:    line.Amount,
+		})
+	}
+	return quoteLines
+}
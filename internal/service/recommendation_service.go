@@ -0,0 +1,251 @@
+// Package service provides option recommendations for the registration form.
+package service
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/lru"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+// Weights of the recommendation score factors; they add up to 1
+const (
+	recommendAvailabilityWeight = 0.4
+	recommendPopularityWeight   = 0.4
+	recommendPlanFitWeight      = 0.2
+)
+
+const (
+	// lowStockAvailability is the availability factor of options low on stock
+	lowStockAvailability = 0.5
+	// sharedOptionPlanFit is the plan fit factor of options offered with every plan
+	sharedOptionPlanFit = 0.5
+	// maxCachedTakeUps bounds the take-up counts kept, keyed by plan and prefecture
+	maxCachedTakeUps = 256
+)
+
+// RecommendationConfig holds option recommendation settings
+type RecommendationConfig struct {
+	// PopularityTTL is how long take-up counts are reused; 0 counts on every request
+	PopularityTTL time.Duration
+	// MinSample is the number of registrations of a plan a prefecture needs before its own
+	// take-up is used instead of the nationwide take-up
+	MinSample int
+}
+
+// RecommendationService defines the interface for option recommendations
+type RecommendationService interface {
+	Recommend(ctx context.Context, req *dto.OptionRecommendationRequest) (*dto.OptionRecommendationResponse, error)
+}
+
+// takeUpKey identifies cached take-up counts; an empty prefecture is nationwide
+type takeUpKey struct {
+	planType   string
+	prefecture string
+}
+
+// cachedTakeUp is take-up counts and when they were counted
+type cachedTakeUp struct {
+	takeUp    *model.OptionTakeUp
+	countedAt time.Time
+}
+
+// recommendationService implements RecommendationService
+type recommendationService struct {
+	options        OptionService
+	availability   AvailabilityService
+	userOptionRepo repository.UserOptionRepository
+	config         RecommendationConfig
+	validator      *validator.CustomValidator
+	log            *logger.Logger
+
+	mu      sync.Mutex
+	takeUps *lru.Cache[takeUpKey, cachedTakeUp]
+}
+
+// NewRecommendationService creates a new recommendation service
+func NewRecommendationService(
+	options OptionService,
+	availability AvailabilityService,
+	userOptionRepo repository.UserOptionRepository,
+	config RecommendationConfig,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) RecommendationService {
+	return &recommendationService{
+		options:        options,
+		availability:   availability,
+		userOptionRepo: userOptionRepo,
+		config:         config,
+		validator:      validator,
+		log:            log,
+		takeUps:        lru.New[takeUpKey, cachedTakeUp](maxCachedTakeUps),
+	}
+}
+
+// Recommend ranks the options of a plan for a region by availability, popularity and plan
+// fit. Options out of stock or restricted in the region are left out. Popularity is the
+// take-up among registrations of the plan in the prefecture, or nationwide when the
+// prefecture has fewer than the minimum sample.
+func (s *recommendationService) Recommend(
+	ctx context.Context, req *dto.OptionRecommendationRequest,
+) (*dto.OptionRecommendationResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	options, err := s.options.GetAvailableOptions(ctx, &dto.OptionsGetRequest{PlanType: req.PlanType})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.OptionRecommendationResponse{
+		PlanType:        req.PlanType,
+		Prefecture:      req.Prefecture,
+		PopularityScope: dto.PopularityScopePrefecture,
+		Recommendations: []dto.OptionRecommendation{},
+		CheckedAt:       time.Now(),
+	}
+	if len(options.Options) == 0 {
+		return resp, nil
+	}
+
+	optionTypes := make([]string, 0, len(options.Options))
+	for _, option := range options.Options {
+		optionTypes = append(optionTypes, option.OptionType)
+	}
+
+	states, restricted, err := s.checkAvailability(ctx, req, optionTypes, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	takeUp, err := s.takeUp(ctx, req.PlanType, req.Prefecture)
+	if err != nil {
+		return nil, err
+	}
+	if takeUp.Users < int64(s.config.MinSample) {
+		if takeUp, err = s.takeUp(ctx, req.PlanType, ""); err != nil {
+			return nil, err
+		}
+		resp.PopularityScope = dto.PopularityScopeNationwide
+	}
+
+	var maxRate float64
+	for _, optionType := range optionTypes {
+		maxRate = max(maxRate, takeUp.Rate(optionType))
+	}
+
+	for _, option := range options.Options {
+		state := states[option.OptionType]
+		if state == "" {
+			state = model.StockStateInStock
+		}
+		if state == model.StockStateOutOfStock || restricted[option.OptionType] {
+			continue
+		}
+
+		scores := dto.OptionRecommendationScores{Availability: 1, PlanFit: 1}
+		if state == model.StockStateLowStock {
+			scores.Availability = lowStockAvailability
+		}
+		if option.PlanCompatibility != req.PlanType {
+			scores.PlanFit = sharedOptionPlanFit
+		}
+		rate := takeUp.Rate(option.OptionType)
+		if maxRate > 0 {
+			scores.Popularity = roundScore(rate / maxRate)
+		}
+
+		resp.Recommendations = append(resp.Recommendations, dto.OptionRecommendation{
+			OptionResponse: option,
+			Score: roundScore(recommendAvailabilityWeight*scores.Availability +
+				recommendPopularityWeight*scores.Popularity +
+				recommendPlanFitWeight*scores.PlanFit),
+			Scores:     scores,
+			StockState: state,
+			TakeUpRate: roundScore(rate),
+		})
+	}
+
+	// Options come ordered by type, which breaks ties
+	slices.SortStableFunc(resp.Recommendations, func(a, b dto.OptionRecommendation) int {
+		return cmp.Compare(b.Score, a.Score)
+	})
+	return resp, nil
+}
+
+// checkAvailability returns the stock state of each option and the options restricted in the
+// region, recording on resp whether stale stock was used. Region restrictions need the city,
+// so without it only stock is checked.
+func (s *recommendationService) checkAvailability(
+	ctx context.Context, req *dto.OptionRecommendationRequest, optionTypes []string,
+	resp *dto.OptionRecommendationResponse,
+) (map[string]string, map[string]bool, error) {
+	restricted := make(map[string]bool)
+
+	if req.City == "" {
+		inventory, err := s.options.CheckInventory(ctx, &dto.InventoryCheckRequest{OptionTypes: optionTypes})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to check inventory: %w", err)
+		}
+		resp.Stale, resp.CheckedAt = inventory.Stale, inventory.CheckedAt
+		return inventory.States, restricted, nil
+	}
+
+	availability, err := s.availability.CheckAvailability(ctx, &dto.OptionAvailabilityRequest{
+		Prefecture:  req.Prefecture,
+		City:        req.City,
+		OptionTypes: optionTypes,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	for optionType, result := range availability.OptionResults {
+		if result.IsRegionAllowed != nil && !*result.IsRegionAllowed {
+			restricted[optionType] = true
+		}
+	}
+	resp.Stale, resp.CheckedAt = availability.Stale, availability.CheckedAt
+	return availability.StockStates, restricted, nil
+}
+
+// takeUp returns the take-up counts of a plan in a prefecture, or nationwide for an empty
+// prefecture, counting them again once they are older than the popularity TTL
+func (s *recommendationService) takeUp(ctx context.Context, planType, prefecture string) (*model.OptionTakeUp, error) {
+	key := takeUpKey{planType: planType, prefecture: prefecture}
+	now := time.Now()
+
+	s.mu.Lock()
+	cached, ok := s.takeUps.Get(key)
+	s.mu.Unlock()
+	if ok && now.Sub(cached.countedAt) < s.config.PopularityTTL {
+		return cached.takeUp, nil
+	}
+
+	takeUp, err := s.userOptionRepo.CountTakeUp(ctx, planType, prefecture)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.takeUps.Add(key, cachedTakeUp{takeUp: takeUp, countedAt: now})
+	s.mu.Unlock()
+	return takeUp, nil
+}
+
+// roundScore rounds a score to three decimal places
+func roundScore(score float64) float64 {
+	return math.Round(score*1000) / 1000
+}
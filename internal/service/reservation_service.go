@@ -0,0 +1,253 @@
+// Package service provides option stock reservations for form sessions.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+// ErrInsufficientInventory is returned when the stock left after other sessions' holds does
+// not cover a reservation
+var ErrInsufficientInventory = errors.New("insufficient inventory")
+
+// InsufficientInventoryError reports the quantity still available for each option type that
+// could not be reserved
+type InsufficientInventoryError struct {
+	Available map[string]int
+}
+
+// Error lists the option types that could not be reserved
+func (e *InsufficientInventoryError) Error() string {
+	optionTypes := make([]string, 0, len(e.Available))
+	for optionType := range e.Available {
+		optionTypes = append(optionTypes, optionType)
+	}
+	sort.Strings(optionTypes)
+	return fmt.Sprintf("%s: %s", ErrInsufficientInventory, strings.Join(optionTypes, ", "))
+}
+
+// Is reports the error as ErrInsufficientInventory
+func (e *InsufficientInventoryError) Is(target error) bool {
+	return target == ErrInsufficientInventory
+}
+
+// ReservationConfig controls option stock holds
+type ReservationConfig struct {
+	// TTL is how long a hold lasts; holds never outlive their session
+	TTL time.Duration
+}
+
+// ReservationService defines the interface for option stock reservations
+type ReservationService interface {
+	Reserve(ctx context.Context, req *dto.OptionReserveRequest) (*dto.OptionReserveResponse, error)
+	Consume(ctx context.Context, sessionID string, userID int) error
+}
+
+// reservationService implements ReservationService
+type reservationService struct {
+	reservationRepo repository.ReservationRepository
+	sessionRepo     repository.SessionRepository
+	txManager       repository.TxManager
+	options         OptionService
+	config          ReservationConfig
+	validator       *validator.CustomValidator
+	log             *logger.Logger
+}
+
+// NewReservationService creates a new reservation service
+func NewReservationService(
+	reservationRepo repository.ReservationRepository,
+	sessionRepo repository.SessionRepository,
+	txManager repository.TxManager,
+	options OptionService,
+	config ReservationConfig,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) ReservationService {
+	return &reservationService{
+		reservationRepo: reservationRepo,
+		sessionRepo:     sessionRepo,
+		txManager:       txManager,
+		options:         options,
+		config:          config,
+		validator:       validator,
+		log:             log,
+	}
+}
+
+// Reserve replaces the holds of a session with the requested options. The stock reported by
+// the inventory check, less what other sessions hold, must cover every option; otherwise
+// nothing is reserved and the session keeps its previous holds.
+func (s *reservationService) Reserve(
+	ctx context.Context, req *dto.OptionReserveRequest,
+) (*dto.OptionReserveResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	quantities := make(map[string]int, len(req.OptionTypes))
+	for _, optionType := range req.OptionTypes {
+		quantities[optionType] = 1
+		if detail := req.OptionDetails[optionType]; detail.Quantity > 0 {
+			quantities[optionType] = detail.Quantity
+		}
+	}
+	// Option types are locked in a fixed order so concurrent reservations cannot deadlock
+	optionTypes := make([]string, 0, len(quantities))
+	for optionType := range quantities {
+		optionTypes = append(optionTypes, optionType)
+	}
+	sort.Strings(optionTypes)
+
+	// Stock comes from the inventory API, which is called before holding any lock
+	stock, err := s.options.CheckLiveInventory(ctx, &dto.InventoryCheckRequest{OptionTypes: optionTypes})
+	if err != nil {
+		return nil, fmt.Errorf("failed to check inventory: %w", err)
+	}
+
+	resp := &dto.OptionReserveResponse{
+		SessionID:    req.SessionID,
+		Reservations: make([]dto.OptionReservationResponse, 0, len(optionTypes)),
+	}
+	err = s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		session, err := s.sessionRepo.GetByIDForUpdate(txCtx, req.SessionID)
+		if err != nil {
+			return err
+		}
+		if _, err := s.reservationRepo.ReleaseBySession(txCtx, session.ID); err != nil {
+			return err
+		}
+
+		shortages := make(map[string]int)
+		for _, optionType := range optionTypes {
+			if err := s.reservationRepo.LockOptionType(txCtx, optionType); err != nil {
+				return err
+			}
+			held, err := s.reservationRepo.SumHeld(txCtx, optionType, session.ID)
+			if err != nil {
+				return err
+			}
+			if available := stock.Inventory[optionType] - held; quantities[optionType] > available {
+				shortages[optionType] = max(available, 0)
+			}
+		}
+		if len(shortages) > 0 {
+			return &InsufficientInventoryError{Available: shortages}
+		}
+
+		expiresAt := time.Now().Add(s.config.TTL)
+		if session.ExpiresAt.Before(expiresAt) {
+			expiresAt = session.ExpiresAt
+		}
+		resp.ExpiresAt = expiresAt
+
+		for _, optionType := range optionTypes {
+			reservation := &model.OptionReservation{
+				SessionID:  &session.ID,
+				OptionType: optionType,
+				Quantity:   quantities[optionType],
+				ExpiresAt:  expiresAt,
+			}
+			if err := s.reservationRepo.Create(txCtx, reservation); err != nil {
+				return err
+			}
+			resp.Reservations = append(resp.Reservations, dto.OptionReservationResponse{
+				OptionType: reservation.OptionType,
+				Quantity:   reservation.Quantity,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrInsufficientInventory) {
+			s.log.WithContext(ctx).WithError(err).WithField("session_id", req.SessionID).Info("Option reservation rejected")
+		}
+		return nil, err
+	}
+
+	s.log.WithContext(ctx).
+		WithField("session_id", req.SessionID).
+		WithField("option_types", optionTypes).
+		Info("Options reserved")
+
+	return resp, nil
+}
+
+// Consume marks the holds of the session a user registered from as used, so they are not
+// released back into stock. Registrations without a session have nothing to consume.
+func (s *reservationService) Consume(ctx context.Context, sessionID string, userID int) error {
+	if sessionID == "" {
+		return nil
+	}
+
+	consumed, err := s.reservationRepo.ConsumeBySession(ctx, sessionID, userID)
+	if err != nil {
+		return err
+	}
+
+	if consumed > 0 {
+		s.log.WithContext(ctx).
+			WithField("session_id", sessionID).
+			WithField("user_id", userID).
+			WithField("consumed", consumed).
+			Info("Option reservations consumed")
+	}
+	return nil
+}
+
+// ReservationReleaser periodically releases holds whose time ran out or whose session expired
+// or was deleted. Such holds already stop counting against stock; releasing them records the
+// outcome.
+type ReservationReleaser struct {
+	repo     repository.ReservationRepository
+	interval time.Duration
+	log      *logger.Logger
+}
+
+// NewReservationReleaser creates a new reservation releaser
+func NewReservationReleaser(
+	repo repository.ReservationRepository, interval time.Duration, log *logger.Logger,
+) *ReservationReleaser {
+	return &ReservationReleaser{
+		repo:     repo,
+		interval: interval,
+		log:      log,
+	}
+}
+
+// Run releases expired holds until the context is cancelled. A non-positive interval disables releasing.
+func (r *ReservationReleaser) Run(ctx context.Context) {
+	if r.interval <= 0 {
+		r.log.Warn("Option reservation release is disabled")
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		released, err := r.repo.ReleaseExpired(ctx)
+		if err != nil {
+			r.log.WithError(err).Error("Option reservation release failed")
+		} else if released > 0 {
+			r.log.WithField("released", released).Info("Released expired option reservations")
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
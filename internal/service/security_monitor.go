@@ -0,0 +1,325 @@
+// Package service provides security event monitoring and automatic IP blocking.
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+const (
+	defaultSecurityEventListLimit = 50
+	// securityEventMaxPathLength is the length of security_events.path; unmatched paths are cut
+	securityEventMaxPathLength = 500
+	// defaultSecurityBlockRefresh is used when no positive refresh interval is configured
+	defaultSecurityBlockRefresh = 30 * time.Second
+	// securityEventPurgeInterval is how often events past the retention are deleted
+	securityEventPurgeInterval = time.Hour
+)
+
+// SecurityMonitorConfig controls when repeated security events block a client IP
+type SecurityMonitorConfig struct {
+	// Window is how far back events from a client IP are counted
+	Window time.Duration
+	// Thresholds maps an event type to the count within Window that blocks the client IP;
+	// types without a positive threshold are only recorded
+	Thresholds    map[string]int
+	BlockDuration time.Duration
+	// RefreshInterval is how often blocks set or lifted by other instances are reloaded
+	RefreshInterval time.Duration
+	// Retention is how long events are kept; 0 keeps them forever
+	Retention time.Duration
+	// QueueSize bounds the events waiting to be recorded
+	QueueSize int
+}
+
+// SecurityMonitor records requests rejected in ways typical of abuse and blocks client IPs
+// that repeat them. Events are recorded in the background so that requests never wait for
+// the database, and active blocks are cached in memory so that checking one is cheap.
+type SecurityMonitor struct {
+	repo         repository.SecurityEventRepository
+	auditLogRepo repository.AuditLogRepository
+	txManager    repository.TxManager
+	config       SecurityMonitorConfig
+	validator    *validator.CustomValidator
+	log          *logger.Logger
+
+	queue   chan *model.SecurityEvent
+	dropped atomic.Int64
+
+	mu     sync.RWMutex
+	blocks map[string]time.Time
+}
+
+// NewSecurityMonitor creates a new security monitor
+func NewSecurityMonitor(
+	repo repository.SecurityEventRepository,
+	auditLogRepo repository.AuditLogRepository,
+	txManager repository.TxManager,
+	config SecurityMonitorConfig,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) *SecurityMonitor {
+	return &SecurityMonitor{
+		repo:         repo,
+		auditLogRepo: auditLogRepo,
+		txManager:    txManager,
+		config:       config,
+		validator:    validator,
+		log:          log,
+		queue:        make(chan *model.SecurityEvent, max(config.QueueSize, 1)),
+		blocks:       make(map[string]time.Time),
+	}
+}
+
+// BlockedUntil reports whether a client IP is blocked and until when
+func (m *SecurityMonitor) BlockedUntil(clientIP string) (time.Time, bool) {
+	m.mu.RLock()
+	until, ok := m.blocks[clientIP]
+	m.mu.RUnlock()
+
+	if !ok || !time.Now().Before(until) {
+		return time.Time{}, false
+	}
+	return until, true
+}
+
+// Record queues a rejected request to be recorded. It never blocks; when the queue is full
+// the event is dropped and counted.
+func (m *SecurityMonitor) Record(clientIP, eventType, method, path string, status int, requestID string) {
+	if len(path) > securityEventMaxPathLength {
+		path = strings.ToValidUTF8(path[:securityEventMaxPathLength], "")
+	}
+	event := &model.SecurityEvent{
+		ClientIP:   clientIP,
+		EventType:  eventType,
+		Method:     method,
+		Path:       path,
+		StatusCode: status,
+	}
+	if requestID != "" {
+		event.RequestID = &requestID
+	}
+
+	select {
+	case m.queue <- event:
+	default:
+		m.dropped.Add(1)
+	}
+}
+
+// Run records queued events, reloads blocks and deletes events past the retention until the
+// context is cancelled
+func (m *SecurityMonitor) Run(ctx context.Context) {
+	m.log.WithField("window", m.config.Window).
+		WithField("block_duration", m.config.BlockDuration).
+		Info("Security monitor started")
+
+	if err := m.Refresh(ctx); err != nil {
+		m.log.WithError(err).Warn("Failed to load IP blocks")
+	}
+
+	refreshInterval := m.config.RefreshInterval
+	if refreshInterval <= 0 {
+		refreshInterval = defaultSecurityBlockRefresh
+	}
+	refresh := time.NewTicker(refreshInterval)
+	defer refresh.Stop()
+	purge := time.NewTicker(securityEventPurgeInterval)
+	defer purge.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.log.Info("Security monitor stopped")
+			return
+		case event := <-m.queue:
+			if err := m.handle(ctx, event); err != nil {
+				m.log.WithError(err).WithField("client_ip", event.ClientIP).Error("Failed to record security event")
+			}
+		case <-refresh.C:
+			if dropped := m.dropped.Swap(0); dropped > 0 {
+				m.log.WithField("dropped", dropped).Warn("Security events dropped because the queue was full")
+			}
+			if err := m.Refresh(ctx); err != nil {
+				m.log.WithError(err).Warn("Failed to refresh IP blocks")
+			}
+		case <-purge.C:
+			m.purge(ctx)
+		}
+	}
+}
+
+// Refresh reloads the active blocks from the database
+func (m *SecurityMonitor) Refresh(ctx context.Context) error {
+	blocks, err := m.repo.ListActiveBlocks(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	loaded := make(map[string]time.Time, len(blocks))
+	for _, block := range blocks {
+		loaded[block.ClientIP] = block.BlockedUntil
+	}
+
+	m.mu.Lock()
+	m.blocks = loaded
+	m.mu.Unlock()
+	return nil
+}
+
+// ListEvents lists recorded events, newest first
+func (m *SecurityMonitor) ListEvents(
+	ctx context.Context, req *dto.SecurityEventListRequest,
+) (*dto.SecurityEventListResponse, error) {
+	if err := m.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultSecurityEventListLimit
+	}
+
+	events, err := m.repo.List(ctx, req.ClientIP, req.EventType, limit, req.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.SecurityEventListResponse{Events: make([]*dto.SecurityEventResponse, 0, len(events))}
+	for _, event := range events {
+		resp.Events = append(resp.Events, &dto.SecurityEventResponse{
+			ID:         event.ID,
+			ClientIP:   event.ClientIP,
+			EventType:  event.EventType,
+			Method:     event.Method,
+			Path:       event.Path,
+			StatusCode: event.StatusCode,
+			RequestID:  event.RequestID,
+			CreatedAt:  event.CreatedAt,
+		})
+	}
+	return resp, nil
+}
+
+// ListBlocks lists the client IPs currently blocked
+func (m *SecurityMonitor) ListBlocks(ctx context.Context) (*dto.IPBlockListResponse, error) {
+	blocks, err := m.repo.ListActiveBlocks(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.IPBlockListResponse{Blocks: make([]*dto.IPBlockResponse, 0, len(blocks))}
+	for _, block := range blocks {
+		resp.Blocks = append(resp.Blocks, &dto.IPBlockResponse{
+			ClientIP:     block.ClientIP,
+			Reason:       block.Reason,
+			EventCount:   block.EventCount,
+			BlockedUntil: block.BlockedUntil,
+			CreatedAt:    block.CreatedAt,
+			UpdatedAt:    block.UpdatedAt,
+		})
+	}
+	return resp, nil
+}
+
+// Unblock lifts the block of a client IP. Events recorded before it no longer count toward
+// a new block. Other instances stop rejecting the IP at their next refresh.
+func (m *SecurityMonitor) Unblock(ctx context.Context, clientIP, actorIP string) error {
+	err := m.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		unblocked, err := m.repo.Unblock(txCtx, clientIP, time.Now())
+		if err != nil {
+			return err
+		}
+		if !unblocked {
+			return apperr.Errorf(apperr.ErrNotFound, "client IP %s is not blocked", clientIP)
+		}
+
+		entry := newAdminAuditLog(txCtx, "security.unblocked", "ip_block", clientIP, nil, actorIP)
+		_, err = m.auditLogRepo.Create(txCtx, entry)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to unblock client IP: %w", err)
+	}
+
+	m.mu.Lock()
+	delete(m.blocks, clientIP)
+	m.mu.Unlock()
+
+	m.log.WithContext(ctx).WithField("client_ip", clientIP).Warn("Client IP unblocked")
+	return nil
+}
+
+// handle records an event and blocks its client IP once the events of the type within the
+// window reach the threshold
+func (m *SecurityMonitor) handle(ctx context.Context, event *model.SecurityEvent) error {
+	if err := m.repo.Create(ctx, event); err != nil {
+		return err
+	}
+
+	threshold := m.config.Thresholds[event.EventType]
+	if threshold <= 0 {
+		return nil
+	}
+	if _, blocked := m.BlockedUntil(event.ClientIP); blocked {
+		// Requests in flight when the block was set are still reported
+		return nil
+	}
+
+	now := time.Now()
+	count, err := m.repo.CountSince(ctx, event.ClientIP, event.EventType, now.Add(-m.config.Window))
+	if err != nil {
+		return err
+	}
+	if count < threshold {
+		return nil
+	}
+
+	block, err := m.repo.Block(ctx, &model.IPBlock{
+		ClientIP:     event.ClientIP,
+		Reason:       event.EventType,
+		EventCount:   count,
+		BlockedUntil: now.Add(m.config.BlockDuration),
+	})
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.blocks[block.ClientIP] = block.BlockedUntil
+	m.mu.Unlock()
+
+	m.log.WithField("client_ip", block.ClientIP).
+		WithField("reason", block.Reason).
+		WithField("event_count", block.EventCount).
+		WithField("blocked_until", block.BlockedUntil).
+		Warn("Client IP blocked after repeated security events")
+	return nil
+}
+
+// purge deletes the events past the retention
+func (m *SecurityMonitor) purge(ctx context.Context) {
+	if m.config.Retention <= 0 {
+		return
+	}
+
+	deleted, err := m.repo.DeleteBefore(ctx, time.Now().Add(-m.config.Retention))
+	if err != nil {
+		m.log.WithError(err).Warn("Failed to delete old security events")
+		return
+	}
+	if deleted > 0 {
+		m.log.WithField("deleted", deleted).Info("Deleted old security events")
+	}
+}
@@ -0,0 +1,174 @@
+// Package service provides conversions of the form data saved in sessions.
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+)
+
+// sessionFormModel converts form data received from the client for saving
+func sessionFormModel(form *dto.SessionFormData) model.SessionFormData {
+	if form == nil {
+		return model.SessionFormData{}
+	}
+
+	saved := model.SessionFormData{
+		LastName:      form.LastName,
+		FirstName:     form.FirstName,
+		LastNameKana:  form.LastNameKana,
+		FirstNameKana: form.FirstNameKana,
+		Phone1:        form.Phone1,
+		Phone2:        form.Phone2,
+		Phone3:        form.Phone3,
+		PostalCode1:   form.PostalCode1,
+		PostalCode2:   form.PostalCode2,
+		Prefecture:    form.Prefecture,
+		City:          form.City,
+		Town:          form.Town,
+		Chome:         form.Chome,
+		Banchi:        form.Banchi,
+		Go:            form.Go,
+		Building:      form.Building,
+		Room:          form.Room,
+		Email:         form.Email,
+		EmailConfirm:  form.EmailConfirm,
+		PlanType:      form.PlanType,
+		OptionTypes:   form.OptionTypes,
+		Locale:        form.Locale,
+	}
+	if form.OptionDetails != nil {
+		saved.OptionDetails = make(map[string]model.SessionOptionDetail, len(form.OptionDetails))
+		for optionType, detail := range form.OptionDetails {
+			saved.OptionDetails[optionType] = model.SessionOptionDetail(detail)
+		}
+	}
+	return saved
+}
+
+// sessionFormDTO converts saved form data for a response
+func sessionFormDTO(form model.SessionFormData) dto.SessionFormData {
+	data := dto.SessionFormData{
+		LastName:      form.LastName,
+		FirstName:     form.FirstName,
+		LastNameKana:  form.LastNameKana,
+		FirstNameKana: form.FirstNameKana,
+		Phone1:        form.Phone1,
+		Phone2:        form.Phone2,
+		Phone3:        form.Phone3,
+		PostalCode1:   form.PostalCode1,
+		PostalCode2:   form.PostalCode2,
+		Prefecture:    form.Prefecture,
+		City:          form.City,
+		Town:          form.Town,
+		Chome:         form.Chome,
+		Banchi:        form.Banchi,
+		Go:            form.Go,
+		Building:      form.Building,
+		Room:          form.Room,
+		Email:         form.Email,
+		EmailConfirm:  form.EmailConfirm,
+		PlanType:      form.PlanType,
+		OptionTypes:   form.OptionTypes,
+		Locale:        form.Locale,
+	}
+	if form.OptionDetails != nil {
+		data.OptionDetails = make(map[string]dto.SessionFormOption, len(form.OptionDetails))
+		for optionType, detail := range form.OptionDetails {
+			data.OptionDetails[optionType] = dto.SessionFormOption(detail)
+		}
+	}
+	return data
+}
+
+// sessionUserRequest builds the user request the saved form data would submit, with fields
+// not entered yet left empty
+func sessionUserRequest(form model.SessionFormData) *dto.UserCreateRequest {
+	req := &dto.UserCreateRequest{
+		LastName:      stringValue(form.LastName),
+		FirstName:     stringValue(form.FirstName),
+		LastNameKana:  stringValue(form.LastNameKana),
+		FirstNameKana: stringValue(form.FirstNameKana),
+		Phone1:        stringValue(form.Phone1),
+		Phone2:        stringValue(form.Phone2),
+		Phone3:        stringValue(form.Phone3),
+		PostalCode1:   stringValue(form.PostalCode1),
+		PostalCode2:   stringValue(form.PostalCode2),
+		Prefecture:    stringValue(form.Prefecture),
+		City:          stringValue(form.City),
+		Town:          form.Town,
+		Chome:         form.Chome,
+		Banchi:        stringValue(form.Banchi),
+		Go:            form.Go,
+		Building:      form.Building,
+		Room:          form.Room,
+		Email:         stringValue(form.Email),
+		EmailConfirm:  stringValue(form.EmailConfirm),
+		PlanType:      stringValue(form.PlanType),
+		OptionTypes:   form.OptionTypes,
+		Locale:        stringValue(form.Locale),
+	}
+	if form.OptionDetails != nil {
+		req.OptionDetails = make(map[string]dto.UserOptionDetail, len(form.OptionDetails))
+		for optionType, detail := range form.OptionDetails {
+			optionDetail := dto.UserOptionDetail{StartDate: detail.StartDate}
+			if detail.Quantity != nil {
+				optionDetail.Quantity = *detail.Quantity
+			}
+			req.OptionDetails[optionType] = optionDetail
+		}
+	}
+	return req
+}
+
+// mergeSessionFields applies an autosave to saved form data: each field replaces the value
+// under its JSON name and a null value removes it. Fields that are not form fields, or hold
+// a value of the wrong type, are rejected with ErrInvalidSessionData.
+func mergeSessionFields(form model.SessionFormData, fields map[string]interface{}) (model.SessionFormData, error) {
+	encoded, err := json.Marshal(form)
+	if err != nil {
+		return form, fmt.Errorf("failed to encode form data: %w", err)
+	}
+	var document map[string]interface{}
+	if err := json.Unmarshal(encoded, &document); err != nil {
+		return form, fmt.Errorf("failed to decode form data: %w", err)
+	}
+
+	for field, value := range fields {
+		if value == nil {
+			delete(document, field)
+			continue
+		}
+		document[field] = value
+	}
+
+	if encoded, err = json.Marshal(document); err != nil {
+		return form, fmt.Errorf("%w: %v", ErrInvalidSessionData, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.DisallowUnknownFields()
+	var merged model.SessionFormData
+	if err := decoder.Decode(&merged); err != nil {
+		return form, fmt.Errorf("%w: %v", ErrInvalidSessionData, err)
+	}
+	return merged, nil
+}
+
+// sessionFormKeys returns the JSON names of the fields entered in form data, with its JSON
+// encoding
+func sessionFormKeys(form model.SessionFormData) ([]string, []byte, error) {
+	encoded, err := json.Marshal(form)
+	if err != nil {
+		return nil, nil, err
+	}
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &members); err != nil {
+		return nil, nil, err
+	}
+	return slices.Sorted(maps.Keys(members)), encoded, nil
+}
@@ -3,9 +3,14 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
 	"github.com/octop162/normal-form-app-by-claude/internal/dto"
 	"github.com/octop162/normal-form-app-by-claude/internal/model"
 	"github.com/octop162/normal-form-app-by-claude/internal/repository"
@@ -14,58 +19,135 @@ import (
 	"github.com/google/uuid"
 )
 
-const (
-	// Default session timeout duration
-	defaultSessionTimeout = 4 * time.Hour
-)
+// SessionConfig controls how long form sessions live
+type SessionConfig struct {
+	// IdleTimeout is how long a session lives after it was last saved
+	IdleTimeout time.Duration
+	// MaxLifetime caps how long a session lives after creation; 0 disables the cap
+	MaxLifetime time.Duration
+	// MaxPerClientIP and MaxPerEmail cap the active sessions per owner; 0 disables a cap
+	MaxPerClientIP int
+	MaxPerEmail    int
+	// EvictOldest deletes the owner's oldest sessions instead of rejecting a new one over the cap
+	EvictOldest bool
+	// WizardRequired rejects user registration that does not reference a completed form wizard
+	WizardRequired bool
+	// MaxDataBytes caps the JSON size of the saved form data; 0 disables the cap
+	MaxDataBytes int
+	// AllowedKeys lists the form fields, by JSON name, the saved form data may hold; empty
+	// allows every field
+	AllowedKeys []string
+}
+
+// ErrSessionLimitExceeded is returned when an owner already has the maximum number of active sessions
+var ErrSessionLimitExceeded = errors.New("session limit exceeded")
+
+// ErrInvalidSessionData is returned when saved form data is over the size cap or holds a key
+// outside the allowed keys, so that sessions cannot be used to store arbitrary data
+var ErrInvalidSessionData = errors.New("invalid session data")
+
+// ErrSessionVersionConflict is returned when a session was saved by another writer since the
+// caller last read it
+var ErrSessionVersionConflict = errors.New("session was modified by another request")
 
 // SessionService defines the interface for session business logic
 type SessionService interface {
-	CreateSession(ctx context.Context, req *dto.SessionCreateRequest) (*dto.SessionCreateResponse, error)
+	CreateSession(ctx context.Context, req *dto.SessionCreateRequest, clientIP string) (*dto.SessionCreateResponse, error)
 	GetSession(ctx context.Context, sessionID string) (*dto.SessionGetResponse, error)
 	UpdateSession(ctx context.Context, sessionID string, req *dto.SessionUpdateRequest) (*dto.SessionUpdateResponse, error)
+	UpdateSessionFields(
+		ctx context.Context, sessionID string, req *dto.SessionFieldsUpdateRequest,
+	) (*dto.SessionUpdateResponse, error)
 	DeleteSession(ctx context.Context, sessionID string) (*dto.SessionDeleteResponse, error)
 	CleanupExpiredSessions(ctx context.Context) (int64, error)
 	ExtendSession(ctx context.Context, sessionID string, duration time.Duration) (*dto.SessionUpdateResponse, error)
 	IsSessionValid(ctx context.Context, sessionID string) (bool, error)
+	CompleteStep(ctx context.Context, sessionID, stepName string) (*dto.WizardProgressResponse, error)
+	GetProgress(ctx context.Context, sessionID string) (*dto.WizardProgressResponse, error)
+	GetSummary(ctx context.Context, sessionID string, req *dto.SessionSummaryRequest) (*dto.SessionSummaryResponse, error)
+	CheckWizardComplete(ctx context.Context, sessionID string, req *dto.UserCreateRequest) error
+	RecordSubmission(ctx context.Context, sessionID string) error
 }
 
 // sessionService implements SessionService
 type sessionService struct {
 	sessionRepo repository.SessionRepository
+	eventRepo   repository.SessionEventRepository
+	txManager   repository.TxManager
+	userService UserService
+	telemetry   *ValidationTelemetry
+	config      SessionConfig
 	log         *logger.Logger
 }
 
 // NewSessionService creates a new session service
 func NewSessionService(
 	sessionRepo repository.SessionRepository,
+	eventRepo repository.SessionEventRepository,
+	txManager repository.TxManager,
+	userService UserService,
+	telemetry *ValidationTelemetry,
+	config SessionConfig,
 	log *logger.Logger,
 ) SessionService {
 	return &sessionService{
 		sessionRepo: sessionRepo,
+		eventRepo:   eventRepo,
+		txManager:   txManager,
+		userService: userService,
+		telemetry:   telemetry,
+		config:      config,
 		log:         log,
 	}
 }
 
-// CreateSession creates a new session with user data
+// CreateSession creates a new session with user data. Owners at their session limit are
+// rejected with ErrSessionLimitExceeded unless the oldest sessions are configured to be evicted.
 func (s *sessionService) CreateSession(
-	ctx context.Context, req *dto.SessionCreateRequest,
+	ctx context.Context, req *dto.SessionCreateRequest, clientIP string,
 ) (*dto.SessionCreateResponse, error) {
+	form := sessionFormModel(req.UserData)
+	if err := s.checkFormData(form); err != nil {
+		s.log.WithContext(ctx).WithError(err).WithField("client_ip", clientIP).Warn("Rejected session data")
+		return nil, err
+	}
+
 	// Generate unique session ID
 	sessionID := uuid.New().String()
 
 	// Calculate expiration time
-	expiresAt := time.Now().Add(defaultSessionTimeout)
+	now := time.Now()
+	expiresAt := s.capExpiration(now, now.Add(s.config.IdleTimeout))
 
 	// Create session model
 	session := &model.UserSession{
 		ID:        sessionID,
-		UserData:  req.UserData,
+		UserData:  form,
 		ExpiresAt: expiresAt,
+		Email:     sessionEmail(form),
+	}
+	if clientIP != "" {
+		session.ClientIP = &clientIP
 	}
 
-	// Save session
-	createdSession, err := s.sessionRepo.Create(ctx, session)
+	// Save session, checking the owner limits under a lock so concurrent requests cannot exceed them
+	var createdSession *model.UserSession
+	err := s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		if err := s.enforceLimits(txCtx, session); err != nil {
+			return err
+		}
+
+		var err error
+		createdSession, err = s.sessionRepo.Create(txCtx, session)
+		if err != nil {
+			return err
+		}
+		return s.eventRepo.Create(txCtx, &model.SessionEvent{SessionID: sessionID, EventType: model.SessionEventCreated})
+	})
+	if errors.Is(err, ErrSessionLimitExceeded) {
+		s.log.WithContext(ctx).WithError(err).WithField("client_ip", clientIP).Warn("Rejected session over limit")
+		return nil, err
+	}
 	if err != nil {
 		s.log.WithError(err).Error("Failed to create session")
 		return nil, fmt.Errorf("failed to create session: %w", err)
@@ -74,8 +156,10 @@ func (s *sessionService) CreateSession(
 	s.log.WithField("session_id", sessionID).Info("Session created successfully")
 
 	return &dto.SessionCreateResponse{
-		SessionID: createdSession.ID,
-		ExpiresAt: createdSession.ExpiresAt,
+		SessionID:       createdSession.ID,
+		Version:         createdSession.Version,
+		ExpiresAt:       createdSession.ExpiresAt,
+		SessionLifetime: s.lifetime(createdSession.CreatedAt),
 	}, nil
 }
 
@@ -90,15 +174,17 @@ func (s *sessionService) GetSession(ctx context.Context, sessionID string) (*dto
 	// Check if session is expired
 	if session.IsExpired() {
 		s.log.WithField("session_id", sessionID).Warn("Attempted to access expired session")
-		return nil, fmt.Errorf("session has expired")
+		return nil, apperr.Errorf(apperr.ErrExpired, "session has expired")
 	}
 
 	return &dto.SessionGetResponse{
-		SessionID: session.ID,
-		UserData:  session.UserData,
-		ExpiresAt: session.ExpiresAt,
-		CreatedAt: session.CreatedAt,
-		UpdatedAt: session.UpdatedAt,
+		SessionID:       session.ID,
+		UserData:        sessionFormDTO(session.UserData),
+		Version:         session.Version,
+		ExpiresAt:       session.ExpiresAt,
+		CreatedAt:       session.CreatedAt,
+		UpdatedAt:       session.UpdatedAt,
+		SessionLifetime: s.lifetime(session.CreatedAt),
 	}, nil
 }
 
@@ -114,12 +200,19 @@ func (s *sessionService) UpdateSession(
 
 	// Check if session is expired
 	if existingSession.IsExpired() {
-		return nil, fmt.Errorf("session has expired")
+		return nil, apperr.Errorf(apperr.ErrExpired, "session has expired")
+	}
+
+	form := sessionFormModel(req.UserData)
+	if err := s.checkFormData(form); err != nil {
+		s.log.WithContext(ctx).WithError(err).WithField("session_id", sessionID).Warn("Rejected session data")
+		return nil, err
 	}
 
-	// Update session data and extend expiration
-	existingSession.UserData = req.UserData
-	existingSession.ExpiresAt = time.Now().Add(defaultSessionTimeout)
+	// Update session data and extend expiration, but not past the max lifetime
+	existingSession.UserData = form
+	existingSession.Email = sessionEmail(form)
+	existingSession.ExpiresAt = s.capExpiration(existingSession.CreatedAt, time.Now().Add(s.config.IdleTimeout))
 
 	// Save updated session
 	updatedSession, err := s.sessionRepo.Update(ctx, existingSession)
@@ -131,9 +224,70 @@ func (s *sessionService) UpdateSession(
 	s.log.WithField("session_id", sessionID).Info("Session updated successfully")
 
 	return &dto.SessionUpdateResponse{
-		SessionID: updatedSession.ID,
-		ExpiresAt: updatedSession.ExpiresAt,
-		UpdatedAt: updatedSession.UpdatedAt,
+		SessionID:       updatedSession.ID,
+		Version:         updatedSession.Version,
+		ExpiresAt:       updatedSession.ExpiresAt,
+		UpdatedAt:       updatedSession.UpdatedAt,
+		SessionLifetime: s.lifetime(existingSession.CreatedAt),
+	}, nil
+}
+
+// UpdateSessionFields merges individual form fields into the session data and extends
+// expiration. The save is rejected with ErrSessionVersionConflict when req.Version is not the
+// current version, so an autosave never overwrites fields saved by another tab.
+func (s *sessionService) UpdateSessionFields(
+	ctx context.Context, sessionID string, req *dto.SessionFieldsUpdateRequest,
+) (*dto.SessionUpdateResponse, error) {
+	if len(req.Fields) == 0 {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: fields must not be empty")
+	}
+	if req.Version < 1 {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: version is required")
+	}
+
+	var updatedSession *model.UserSession
+	err := s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		session, err := s.sessionRepo.GetByIDForUpdate(txCtx, sessionID)
+		if err != nil {
+			return err
+		}
+		if session.Version != req.Version {
+			return fmt.Errorf("%w: current version is %d", ErrSessionVersionConflict, session.Version)
+		}
+
+		if session.UserData, err = mergeSessionFields(session.UserData, req.Fields); err != nil {
+			return err
+		}
+		// The merged data is checked, as autosaves add to what earlier saves stored
+		if err := s.checkFormData(session.UserData); err != nil {
+			return err
+		}
+
+		session.Email = sessionEmail(session.UserData)
+		session.ExpiresAt = s.capExpiration(session.CreatedAt, time.Now().Add(s.config.IdleTimeout))
+
+		updatedSession, err = s.sessionRepo.Update(txCtx, session)
+		return err
+	})
+	if errors.Is(err, ErrSessionVersionConflict) {
+		s.log.WithContext(ctx).WithError(err).WithField("session_id", sessionID).Info("Rejected stale session save")
+		return nil, err
+	}
+	if errors.Is(err, ErrInvalidSessionData) {
+		s.log.WithContext(ctx).WithError(err).WithField("session_id", sessionID).Warn("Rejected session data")
+		return nil, err
+	}
+	if err != nil {
+		s.log.WithError(err).WithField("session_id", sessionID).Error("Failed to update session fields")
+		return nil, fmt.Errorf("failed to update session fields: %w", err)
+	}
+
+	return &dto.SessionUpdateResponse{
+		SessionID:       updatedSession.ID,
+		Version:         updatedSession.Version,
+		ExpiresAt:       updatedSession.ExpiresAt,
+		UpdatedAt:       updatedSession.UpdatedAt,
+		SessionLifetime: s.lifetime(updatedSession.CreatedAt),
 	}, nil
 }
 
@@ -179,11 +333,11 @@ func (s *sessionService) ExtendSession(
 
 	// Check if session is expired
 	if existingSession.IsExpired() {
-		return nil, fmt.Errorf("session has expired")
+		return nil, apperr.Errorf(apperr.ErrExpired, "session has expired")
 	}
 
-	// Extend expiration time
-	existingSession.ExpiresAt = time.Now().Add(duration)
+	// Extend expiration time, but not past the max lifetime
+	existingSession.ExpiresAt = s.capExpiration(existingSession.CreatedAt, time.Now().Add(duration))
 
 	// Save updated session
 	updatedSession, err := s.sessionRepo.Update(ctx, existingSession)
@@ -197,9 +351,11 @@ func (s *sessionService) ExtendSession(
 		Info("Session extended successfully")
 
 	return &dto.SessionUpdateResponse{
-		SessionID: updatedSession.ID,
-		ExpiresAt: updatedSession.ExpiresAt,
-		UpdatedAt: updatedSession.UpdatedAt,
+		SessionID:       updatedSession.ID,
+		Version:         updatedSession.Version,
+		ExpiresAt:       updatedSession.ExpiresAt,
+		UpdatedAt:       updatedSession.UpdatedAt,
+		SessionLifetime: s.lifetime(existingSession.CreatedAt),
 	}, nil
 }
 
@@ -213,3 +369,125 @@ func (s *sessionService) IsSessionValid(ctx context.Context, sessionID string) (
 
 	return exists, nil
 }
+
+// enforceLimits checks the active sessions of the new session's owners, deleting the oldest
+// ones when eviction is enabled. It must run inside a transaction.
+func (s *sessionService) enforceLimits(ctx context.Context, session *model.UserSession) error {
+	type ownerLimit struct {
+		key    string
+		max    int
+		active func() ([]string, error)
+	}
+
+	var limits []ownerLimit
+	if session.ClientIP != nil && s.config.MaxPerClientIP > 0 {
+		ip := *session.ClientIP
+		limits = append(limits, ownerLimit{
+			key:    "session:ip:" + ip,
+			max:    s.config.MaxPerClientIP,
+			active: func() ([]string, error) { return s.sessionRepo.GetActiveIDsByClientIP(ctx, ip) },
+		})
+	}
+	if session.Email != nil && s.config.MaxPerEmail > 0 {
+		email := *session.Email
+		limits = append(limits, ownerLimit{
+			key:    "session:email:" + email,
+			max:    s.config.MaxPerEmail,
+			active: func() ([]string, error) { return s.sessionRepo.GetActiveIDsByEmail(ctx, email) },
+		})
+	}
+
+	// Lock in a fixed order so requests sharing both owners cannot deadlock
+	sort.Slice(limits, func(i, j int) bool { return limits[i].key < limits[j].key })
+	for _, limit := range limits {
+		if err := s.sessionRepo.LockOwner(ctx, limit.key); err != nil {
+			return err
+		}
+	}
+
+	evicted := make(map[string]bool)
+	for _, limit := range limits {
+		ids, err := limit.active()
+		if err != nil {
+			return err
+		}
+
+		remaining := make([]string, 0, len(ids))
+		for _, id := range ids {
+			if !evicted[id] {
+				remaining = append(remaining, id)
+			}
+		}
+		if len(remaining) < limit.max {
+			continue
+		}
+		if !s.config.EvictOldest {
+			return fmt.Errorf("%w: at most %d active sessions", ErrSessionLimitExceeded, limit.max)
+		}
+
+		for _, id := range remaining[:len(remaining)-limit.max+1] {
+			if err := s.sessionRepo.Delete(ctx, id); err != nil {
+				return err
+			}
+			evicted[id] = true
+			s.log.WithContext(ctx).WithField("session_id", id).Info("Evicted oldest session over limit")
+		}
+	}
+
+	return nil
+}
+
+// checkFormData rejects form data with a field outside the allowed keys or a JSON encoding
+// over the size cap with ErrInvalidSessionData
+func (s *sessionService) checkFormData(form model.SessionFormData) error {
+	keys, encoded, err := sessionFormKeys(form)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSessionData, err)
+	}
+	if len(s.config.AllowedKeys) > 0 {
+		for _, key := range keys {
+			if !slices.Contains(s.config.AllowedKeys, key) {
+				return fmt.Errorf("%w: key %q is not allowed", ErrInvalidSessionData, key)
+			}
+		}
+	}
+
+	if s.config.MaxDataBytes > 0 && len(encoded) > s.config.MaxDataBytes {
+		return fmt.Errorf("%w: user_data is %d bytes, over the limit of %d bytes",
+			ErrInvalidSessionData, len(encoded), s.config.MaxDataBytes)
+	}
+	return nil
+}
+
+// sessionEmail returns the normalized email entered in the form, if any
+func sessionEmail(form model.SessionFormData) *string {
+	email := strings.ToLower(strings.TrimSpace(stringValue(form.Email)))
+	if email == "" {
+		return nil
+	}
+	return &email
+}
+
+// capExpiration limits an expiration time to the max lifetime of a session created at createdAt
+func (s *sessionService) capExpiration(createdAt, expiresAt time.Time) time.Time {
+	if s.config.MaxLifetime <= 0 {
+		return expiresAt
+	}
+	if maxExpiresAt := createdAt.Add(s.config.MaxLifetime); expiresAt.After(maxExpiresAt) {
+		return maxExpiresAt
+	}
+	return expiresAt
+}
+
+// lifetime describes the configured lifetime of a session created at createdAt
+func (s *sessionService) lifetime(createdAt time.Time) dto.SessionLifetime {
+	lifetime := dto.SessionLifetime{
+		IdleTimeoutSeconds: int64(s.config.IdleTimeout.Seconds()),
+	}
+	if s.config.MaxLifetime > 0 {
+		maxExpiresAt := createdAt.Add(s.config.MaxLifetime)
+		lifetime.MaxLifetimeSeconds = int64(s.config.MaxLifetime.Seconds())
+		lifetime.MaxExpiresAt = &maxExpiresAt
+	}
+	return lifetime
+}
@@ -0,0 +1,85 @@
+// Package service provides formatted summaries of saved form data.
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/pkg/locale"
+)
+
+// GetSummary formats the saved form data for the confirmation screen, an email or a printed
+// document. Fields that are not filled in yet are left empty.
+func (s *sessionService) GetSummary(
+	ctx context.Context, sessionID string, req *dto.SessionSummaryRequest,
+) (*dto.SessionSummaryResponse, error) {
+	channel, err := locale.ParseChannel(req.Channel)
+	if err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "%w", err)
+	}
+	format := locale.ForChannel(channel)
+	if req.DateStyle != "" {
+		if format.DateStyle, err = locale.ParseDateStyle(req.DateStyle); err != nil {
+			return nil, apperr.Errorf(apperr.ErrValidation, "%w", err)
+		}
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return sessionSummary(session, sessionUserRequest(session.UserData), channel, format), nil
+}
+
+// sessionSummary formats the form data of a session
+func sessionSummary(
+	session *model.UserSession, form *dto.UserCreateRequest, channel locale.Channel, format locale.Formatter,
+) *dto.SessionSummaryResponse {
+	summary := &dto.SessionSummaryResponse{
+		SessionID:   session.ID,
+		Channel:     string(channel),
+		Name:        strings.TrimSpace(form.LastName + " " + form.FirstName),
+		NameKana:    strings.TrimSpace(form.LastNameKana + " " + form.FirstNameKana),
+		PhoneNumber: format.Phone(form.Phone1, form.Phone2, form.Phone3),
+		PostalCode:  format.PostalCode(form.PostalCode1, form.PostalCode2),
+		Email:       form.Email,
+		PlanType:    form.PlanType,
+		Options:     make([]dto.SessionSummaryOption, 0, len(form.OptionTypes)),
+		ExpiresAt:   format.DateTime(session.ExpiresAt),
+	}
+
+	// The address is written the way it is stored on registration
+	if form.Prefecture != "" || form.City != "" || form.Banchi != "" {
+		user := &model.User{
+			Prefecture: form.Prefecture,
+			City:       form.City,
+			Town:       form.Town,
+			Chome:      form.Chome,
+			Banchi:     form.Banchi,
+			Go:         form.Go,
+			Building:   form.Building,
+			Room:       form.Room,
+		}
+		summary.Address = format.Text(user.GetFullAddress())
+	}
+
+	for _, optionType := range slices.Sorted(slices.Values(form.OptionTypes)) {
+		detail := form.OptionDetails[optionType]
+		quantity := max(detail.Quantity, 1)
+		option := dto.SessionSummaryOption{
+			OptionType: optionType,
+			Quantity:   format.Number(int64(quantity)),
+		}
+		if detail.StartDate != nil {
+			option.StartDate = format.DateString(*detail.StartDate)
+		}
+		summary.Options = append(summary.Options, option)
+	}
+
+	return summary
+}
@@ -0,0 +1,277 @@
+// Package service provides the form wizard tracked inside sessions.
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+)
+
+var (
+	// ErrUnknownWizardStep is returned for a step name that is not part of the wizard
+	ErrUnknownWizardStep = errors.New("unknown wizard step")
+	// ErrWizardStepOutOfOrder is returned when completing a step before the steps preceding it
+	ErrWizardStepOutOfOrder = errors.New("previous wizard steps are not complete")
+	// ErrWizardIncomplete is returned when submitting data whose wizard steps have not all validated
+	ErrWizardIncomplete = errors.New("wizard steps are not complete")
+)
+
+// wizardStep is a step of the registration form and the request fields it collects
+type wizardStep struct {
+	name   string
+	fields []string
+	// errorKeys are validation error keys reported for the step in addition to its fields
+	errorKeys []string
+}
+
+// wizardSteps are the form wizard steps in the order they must be completed
+var wizardSteps = []wizardStep{
+	{
+		name: "personal_info",
+		fields: []string{
+			"last_name", "first_name", "last_name_kana", "first_name_kana",
+			"phone1", "phone2", "phone3", "email", "email_confirm",
+		},
+		errorKeys: []string{"phone"},
+	},
+	{
+		name: "address",
+		fields: []string{
+			"postal_code1", "postal_code2", "prefecture", "city", "town", "chome", "banchi", "go", "building", "room",
+		},
+		errorKeys: []string{"postal_code"},
+	},
+	{
+		name:   "plan_options",
+		fields: []string{"plan_type", "option_types", "option_details"},
+	},
+}
+
+// CompleteStep validates the fields of a wizard step in the saved form data. Steps must be
+// completed in order; an invalid step is recorded with its errors and does not advance the wizard.
+func (s *sessionService) CompleteStep(
+	ctx context.Context, sessionID, stepName string,
+) (*dto.WizardProgressResponse, error) {
+	index := slices.IndexFunc(wizardSteps, func(step wizardStep) bool { return step.name == stepName })
+	if index < 0 {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownWizardStep, stepName)
+	}
+	step := wizardSteps[index]
+
+	var progress *dto.WizardProgressResponse
+	var failureCodes map[string]string
+	err := s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		session, err := s.sessionRepo.GetByIDForUpdate(txCtx, sessionID)
+		if err != nil {
+			return err
+		}
+
+		req := sessionUserRequest(session.UserData)
+		for _, previous := range wizardSteps[:index] {
+			if wizardStepStatus(session.Wizard, previous, req) != model.WizardStepValid {
+				return fmt.Errorf("%w: complete %s first", ErrWizardStepOutOfOrder, previous.name)
+			}
+		}
+
+		result := model.WizardStep{Status: model.WizardStepValid, ValidatedAt: time.Now()}
+		validation, err := s.userService.ValidateUserData(txCtx, &dto.UserValidateRequest{UserCreateRequest: *req})
+		if err != nil {
+			return fmt.Errorf("failed to validate step: %w", err)
+		}
+		result.Errors = wizardStepErrors(step, validation.Errors)
+		failureCodes = wizardStepErrors(step, validation.Codes)
+		if len(result.Errors) > 0 {
+			result.Status = model.WizardStepInvalid
+		} else {
+			result.Fingerprint = wizardStepFingerprint(step, req)
+		}
+
+		if session.Wizard.Steps == nil {
+			session.Wizard.Steps = make(map[string]model.WizardStep)
+		}
+		session.Wizard.Steps[step.name] = result
+
+		if err := s.sessionRepo.UpdateWizardState(txCtx, session); err != nil {
+			return err
+		}
+
+		event := &model.SessionEvent{SessionID: sessionID, EventType: model.SessionEventStepCompleted, Step: &step.name}
+		if result.Status != model.WizardStepValid {
+			event.EventType = model.SessionEventStepFailed
+		}
+		if err := s.eventRepo.Create(txCtx, event); err != nil {
+			return err
+		}
+
+		progress = wizardProgress(session, req)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.telemetry.Record(ctx, step.name, failureCodes)
+
+	s.log.WithContext(ctx).
+		WithField("session_id", sessionID).
+		WithField("step", stepName).
+		WithField("status", progress.Steps[index].Status).
+		Info("Wizard step validated")
+
+	return progress, nil
+}
+
+// GetProgress reports the status of every wizard step against the current form data
+func (s *sessionService) GetProgress(ctx context.Context, sessionID string) (*dto.WizardProgressResponse, error) {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return wizardProgress(session, sessionUserRequest(session.UserData)), nil
+}
+
+// CheckWizardComplete verifies that every wizard step of the session validated the same data
+// as req. Without a session ID the check passes unless the wizard is required.
+func (s *sessionService) CheckWizardComplete(ctx context.Context, sessionID string, req *dto.UserCreateRequest) error {
+	if sessionID == "" {
+		if s.config.WizardRequired {
+			return fmt.Errorf("%w: a form session is required", ErrWizardIncomplete)
+		}
+		return nil
+	}
+
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to get session: %w", err)
+	}
+
+	for _, step := range wizardSteps {
+		if wizardStepStatus(session.Wizard, step, req) != model.WizardStepValid {
+			return fmt.Errorf("%w: %s has not been validated with the submitted data", ErrWizardIncomplete, step.name)
+		}
+	}
+
+	return nil
+}
+
+// RecordSubmission records that the data of a form session was registered as a user, the
+// last stage of the registration funnel. Registrations without a session are not recorded.
+func (s *sessionService) RecordSubmission(ctx context.Context, sessionID string) error {
+	if sessionID == "" {
+		return nil
+	}
+	return s.eventRepo.Create(ctx, &model.SessionEvent{SessionID: sessionID, EventType: model.SessionEventSubmitted})
+}
+
+// wizardProgress builds the progress of a session whose form data would submit req
+func wizardProgress(session *model.UserSession, req *dto.UserCreateRequest) *dto.WizardProgressResponse {
+	progress := &dto.WizardProgressResponse{
+		SessionID: session.ID,
+		Steps:     make([]dto.WizardStepResponse, 0, len(wizardSteps)),
+	}
+
+	for _, step := range wizardSteps {
+		state := session.Wizard.Steps[step.name]
+		status := wizardStepStatus(session.Wizard, step, req)
+
+		resp := dto.WizardStepResponse{Step: step.name, Status: status}
+		if status != model.WizardStepPending {
+			validatedAt := state.ValidatedAt
+			resp.ValidatedAt = &validatedAt
+		}
+		if status == model.WizardStepInvalid {
+			resp.Errors = state.Errors
+		}
+		progress.Steps = append(progress.Steps, resp)
+
+		if status != model.WizardStepValid && progress.CurrentStep == "" {
+			progress.CurrentStep = step.name
+		}
+	}
+	progress.Complete = progress.CurrentStep == ""
+
+	return progress
+}
+
+// wizardStepStatus returns the status of a step, reporting a valid step as stale when the
+// fields it validated no longer match req
+func wizardStepStatus(wizard model.WizardState, step wizardStep, req *dto.UserCreateRequest) string {
+	state, ok := wizard.Steps[step.name]
+	if !ok {
+		return model.WizardStepPending
+	}
+	if state.Status != model.WizardStepValid {
+		return state.Status
+	}
+	if state.Fingerprint != wizardStepFingerprint(step, req) {
+		return model.WizardStepStale
+	}
+	return model.WizardStepValid
+}
+
+// wizardStepErrors keeps the validation errors, or their failure codes, that belong to a step
+func wizardStepErrors(step wizardStep, validationErrors map[string]string) map[string]string {
+	stepErrors := make(map[string]string)
+	for key, message := range validationErrors {
+		// Errors not tied to a field are reported for every step
+		if key == "validation" || slices.Contains(step.fields, key) || slices.Contains(step.errorKeys, key) {
+			stepErrors[key] = message
+		}
+	}
+	if len(stepErrors) == 0 {
+		return nil
+	}
+	return stepErrors
+}
+
+// wizardStepFingerprint hashes the values of a step's fields. Empty and missing values hash
+// the same, so an omitted optional field matches an empty one.
+func wizardStepFingerprint(step wizardStep, req *dto.UserCreateRequest) string {
+	encoded, err := json.Marshal(req)
+	if err != nil {
+		return ""
+	}
+	var all map[string]any
+	if err := json.Unmarshal(encoded, &all); err != nil {
+		return ""
+	}
+
+	values := make(map[string]any, len(step.fields))
+	for _, field := range step.fields {
+		if !isEmptyJSONValue(all[field]) {
+			values[field] = all[field]
+		}
+	}
+
+	// Maps are marshaled with sorted keys, so equal values give equal fingerprints
+	canonical, err := json.Marshal(values)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:])
+}
+
+// isEmptyJSONValue reports whether a decoded JSON value is null, "" or an empty array or object
+func isEmptyJSONValue(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
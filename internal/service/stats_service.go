@@ -0,0 +1,249 @@
+// Package service provides statistics for administrators.
+package service
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+const (
+	// statsDateLayout is the layout of the days statistics are selected by
+	statsDateLayout = "2006-01-02"
+	// defaultFunnelDays is the number of days statistics cover by default, today included
+	defaultFunnelDays = 30
+	// Funnel stages before and after the wizard steps
+	funnelStageStarted   = "started"
+	funnelStageSubmitted = "submitted"
+)
+
+// StatsService defines the interface for admin statistics
+type StatsService interface {
+	GetFunnel(ctx context.Context, req *dto.AdminFunnelRequest) (*dto.AdminFunnelResponse, error)
+	GetValidationFailures(
+		ctx context.Context, req *dto.AdminValidationFailuresRequest,
+	) (*dto.AdminValidationFailuresResponse, error)
+}
+
+// statsService implements StatsService
+type statsService struct {
+	sessionEventRepo    repository.SessionEventRepository
+	validationCountRepo repository.ValidationCountRepository
+	validator           *validator.CustomValidator
+	log                 *logger.Logger
+}
+
+// NewStatsService creates a new stats service
+func NewStatsService(
+	sessionEventRepo repository.SessionEventRepository,
+	validationCountRepo repository.ValidationCountRepository,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) StatsService {
+	return &statsService{
+		sessionEventRepo:    sessionEventRepo,
+		validationCountRepo: validationCountRepo,
+		validator:           validator,
+		log:                 log,
+	}
+}
+
+// GetFunnel reports how many of the sessions started in the period reached each wizard step
+// and were submitted, and where the others dropped off
+func (s *statsService) GetFunnel(ctx context.Context, req *dto.AdminFunnelRequest) (*dto.AdminFunnelResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	from, to, err := parseStatsPeriod(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.sessionEventRepo.CountFunnel(ctx, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	reached := make(map[string]*model.SessionFunnelCount)
+	failed := make(map[string]int64)
+	for _, count := range counts {
+		switch count.EventType {
+		case model.SessionEventCreated:
+			reached[funnelStageStarted] = count
+		case model.SessionEventStepCompleted:
+			reached[count.Step] = count
+		case model.SessionEventStepFailed:
+			failed[count.Step] = count.Sessions
+		case model.SessionEventSubmitted:
+			reached[funnelStageSubmitted] = count
+		}
+	}
+
+	stages := []string{funnelStageStarted}
+	for _, step := range wizardSteps {
+		stages = append(stages, step.name)
+	}
+	stages = append(stages, funnelStageSubmitted)
+
+	resp := &dto.AdminFunnelResponse{
+		From:   from.Format(statsDateLayout),
+		To:     to.Format(statsDateLayout),
+		Stages: make([]dto.AdminFunnelStage, 0, len(stages)),
+	}
+	if started := reached[funnelStageStarted]; started != nil {
+		resp.Started = started.Sessions
+	}
+	if submitted := reached[funnelStageSubmitted]; submitted != nil {
+		resp.Submitted = submitted.Sessions
+	}
+	resp.ConversionRate = ratio(resp.Submitted, resp.Started)
+
+	for i, name := range stages {
+		stage := dto.AdminFunnelStage{Stage: name}
+		if count := reached[name]; count != nil {
+			stage.Sessions = count.Sessions
+			if name != funnelStageStarted {
+				median := count.MedianSeconds
+				stage.MedianSecondsFromStart = &median
+			}
+		}
+		stage.ReachRate = ratio(stage.Sessions, resp.Started)
+		if i < len(stages)-1 {
+			var next int64
+			if count := reached[stages[i+1]]; count != nil {
+				next = count.Sessions
+			}
+			dropOff := ratio(max(stage.Sessions-next, 0), stage.Sessions)
+			stage.DropOffRate = &dropOff
+		}
+		if name != funnelStageStarted && name != funnelStageSubmitted {
+			failedSessions := failed[name]
+			stage.FailedSessions = &failedSessions
+		}
+		resp.Stages = append(resp.Stages, stage)
+	}
+
+	return resp, nil
+}
+
+// GetValidationFailures reports how often validations at each form stage failed in the
+// period and which fields failed for which reasons, most frequent first
+func (s *statsService) GetValidationFailures(
+	ctx context.Context, req *dto.AdminValidationFailuresRequest,
+) (*dto.AdminValidationFailuresResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	from, to, err := parseStatsPeriod(req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts, err := s.validationCountRepo.SumAttempts(ctx, from, to.AddDate(0, 0, 1), req.Stage)
+	if err != nil {
+		return nil, err
+	}
+	failures, err := s.validationCountRepo.SumFailures(ctx, from, to.AddDate(0, 0, 1), req.Stage)
+	if err != nil {
+		return nil, err
+	}
+
+	stages := make(map[string]*dto.AdminValidationStage, len(attempts))
+	for _, count := range attempts {
+		stages[count.Stage] = &dto.AdminValidationStage{
+			Stage:       count.Stage,
+			Attempts:    count.Attempts,
+			Failed:      count.Failed,
+			FailureRate: ratio(count.Failed, count.Attempts),
+			Failures:    []dto.AdminValidationFailure{},
+		}
+	}
+	// Failures are summed most frequent first, so each stage keeps that order
+	for _, count := range failures {
+		stage := stages[count.Stage]
+		if stage == nil {
+			continue
+		}
+		stage.Failures = append(stage.Failures, dto.AdminValidationFailure{
+			Field: count.Field,
+			Code:  count.Code,
+			Count: count.Failures,
+			Rate:  ratio(count.Failures, stage.Attempts),
+		})
+	}
+
+	resp := &dto.AdminValidationFailuresResponse{
+		From:   from.Format(statsDateLayout),
+		To:     to.Format(statsDateLayout),
+		Stages: make([]*dto.AdminValidationStage, 0, len(stages)),
+	}
+	// Stages are listed in form order: the wizard steps, validation, then submission
+	for _, name := range validationStages() {
+		if stage := stages[name]; stage != nil {
+			resp.Stages = append(resp.Stages, stage)
+			delete(stages, name)
+		}
+	}
+	// Stages no longer in the form are listed last
+	for _, name := range slices.Sorted(maps.Keys(stages)) {
+		resp.Stages = append(resp.Stages, stages[name])
+	}
+
+	return resp, nil
+}
+
+// validationStages returns the form stages validations are counted at, in form order
+func validationStages() []string {
+	stages := make([]string, 0, len(wizardSteps)+2)
+	for _, step := range wizardSteps {
+		stages = append(stages, step.name)
+	}
+	return append(stages, ValidationStageValidate, ValidationStageSubmit)
+}
+
+// parseStatsPeriod parses the first and last day of a statistics query. The last day
+// defaults to today and the first to the defaultFunnelDays days up to the last.
+func parseStatsPeriod(fromValue, toValue string) (time.Time, time.Time, error) {
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	to, err := parseStatsDate(toValue, today)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	from, err := parseStatsDate(fromValue, to.AddDate(0, 0, 1-defaultFunnelDays))
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if from.After(to) {
+		return time.Time{}, time.Time{}, apperr.Errorf(apperr.ErrValidation, "from %s is after to %s", fromValue, toValue)
+	}
+	return from, to, nil
+}
+
+// parseStatsDate parses a day of a statistics query, returning fallback for an empty value
+func parseStatsDate(value string, fallback time.Time) (time.Time, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	date, err := time.Parse(statsDateLayout, value)
+	if err != nil {
+		return time.Time{}, apperr.Errorf(apperr.ErrValidation, "invalid date %q: %w", value, err)
+	}
+	return date, nil
+}
+
+// ratio returns part / whole, or 0 when whole is 0
+func ratio(part, whole int64) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return float64(part) / float64(whole)
+}
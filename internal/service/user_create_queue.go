@@ -0,0 +1,340 @@
+// Package service provides the queue that creates users accepted during traffic spikes.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const (
+	// defaultUserCreateQueuePoll is used when no positive poll interval is configured
+	defaultUserCreateQueuePoll = 2 * time.Second
+	// userCreateTicketPurgeInterval is how often finished tickets past the retention are deleted
+	userCreateTicketPurgeInterval = time.Hour
+
+	// Error codes of failed tickets; they match the codes POST /users responds with
+	userCreateErrorValidation        = "VALIDATION_ERROR"
+	userCreateErrorDuplicate         = "DUPLICATE_ERROR"
+	userCreateErrorPossibleDuplicate = "POSSIBLE_DUPLICATE"
+	userCreateErrorInternal          = "INTERNAL_ERROR"
+)
+
+// UserCreateQueueConfig holds the user creation workers settings
+type UserCreateQueueConfig struct {
+	Workers      int
+	PollInterval time.Duration
+	// Lease is how long a claimed ticket is held before another worker may take it over
+	Lease time.Duration
+	// MaxAttempts is how often a ticket is tried before it fails; validation and duplicate
+	// errors fail it at once
+	MaxAttempts int
+	// RetryBaseDelay is the delay before the first retry; it doubles with each attempt
+	RetryBaseDelay time.Duration
+	// Retention is how long finished tickets are kept; 0 keeps them forever
+	Retention time.Duration
+}
+
+// UserCreateQueue accepts registrations while the queue_user_creation flag is on and creates
+// the users in the background, so that a spike of registrations waits in the queue instead
+// of holding database connections. Registrations are validated before they are accepted;
+// duplicates are only detected when the ticket is processed and fail the ticket.
+type UserCreateQueue struct {
+	repo         repository.UserCreateTicketRepository
+	txManager    repository.TxManager
+	userService  UserService
+	reservations ReservationService
+	sessions     SessionService
+	featureFlags *FeatureFlags
+	telemetry    *ValidationTelemetry
+	config       UserCreateQueueConfig
+	log          *logger.Logger
+
+	// wake lets an idle worker pick up a ticket accepted by this instance before its next poll
+	wake chan struct{}
+}
+
+// NewUserCreateQueue creates a new user create queue
+func NewUserCreateQueue(
+	repo repository.UserCreateTicketRepository,
+	txManager repository.TxManager,
+	userService UserService,
+	reservations ReservationService,
+	sessions SessionService,
+	featureFlags *FeatureFlags,
+	telemetry *ValidationTelemetry,
+	config UserCreateQueueConfig,
+	log *logger.Logger,
+) *UserCreateQueue {
+	return &UserCreateQueue{
+		repo:         repo,
+		txManager:    txManager,
+		userService:  userService,
+		reservations: reservations,
+		sessions:     sessions,
+		featureFlags: featureFlags,
+		telemetry:    telemetry,
+		config:       config,
+		log:          log,
+		wake:         make(chan struct{}, 1),
+	}
+}
+
+// Enabled reports whether registrations are queued instead of created during the request
+func (q *UserCreateQueue) Enabled() bool {
+	return q.featureFlags.Enabled(FlagQueueUserCreation)
+}
+
+// Enqueue validates a registration and queues it. sessionID is the form session whose option
+// reservations are consumed once the user is created.
+func (q *UserCreateQueue) Enqueue(
+	ctx context.Context, req *dto.UserCreateRequest, sessionID string,
+) (*dto.UserCreateTicketResponse, error) {
+	validationResp, err := q.userService.ValidateUserData(ctx, &dto.UserValidateRequest{UserCreateRequest: *req})
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate user data: %w", err)
+	}
+	q.telemetry.Record(ctx, ValidationStageSubmit, validationResp.Codes)
+	if !validationResp.Valid {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %v", validationResp.Errors)
+	}
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal user create request: %w", err)
+	}
+	encoded := string(payload)
+
+	ticket := &model.UserCreateTicket{
+		TicketID: uuid.New().String(),
+		Payload:  &encoded,
+	}
+	if sessionID != "" {
+		ticket.SessionID = &sessionID
+	}
+	if err := q.repo.Create(ctx, ticket, req.Email); err != nil {
+		return nil, err
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	q.log.WithContext(ctx).WithField("ticket_id", ticket.TicketID).Info("User creation queued")
+	return toUserCreateTicketResponse(ticket), nil
+}
+
+// GetTicket returns the status of a queued registration
+func (q *UserCreateQueue) GetTicket(ctx context.Context, ticketID string) (*dto.UserCreateTicketResponse, error) {
+	ticket, err := q.repo.GetByTicketID(ctx, ticketID)
+	if err != nil {
+		return nil, err
+	}
+	return toUserCreateTicketResponse(ticket), nil
+}
+
+// Backlog returns the number of queued registrations waiting for a worker
+func (q *UserCreateQueue) Backlog(ctx context.Context) (int, error) {
+	return q.repo.CountDue(ctx)
+}
+
+// Run processes tickets with the configured number of workers and deletes finished tickets
+// past the retention until the context is cancelled. Workers finish the ticket in hand
+// before they stop.
+func (q *UserCreateQueue) Run(ctx context.Context) {
+	workers := max(q.config.Workers, 1)
+	q.log.WithField("workers", workers).
+		WithField("poll_interval", q.config.PollInterval).
+		Info("User create queue started")
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx)
+		}()
+	}
+
+	purge := time.NewTicker(userCreateTicketPurgeInterval)
+	defer purge.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			q.log.Info("User create queue stopped")
+			return
+		case <-purge.C:
+			q.purge(ctx)
+		}
+	}
+}
+
+// ProcessNext claims the next due ticket and creates its user, reporting whether a ticket
+// was processed
+func (q *UserCreateQueue) ProcessNext(ctx context.Context) (bool, error) {
+	ticket, err := q.repo.ClaimNext(ctx, q.config.Lease)
+	if err != nil {
+		return false, err
+	}
+	if ticket == nil {
+		return false, nil
+	}
+
+	entry := q.log.WithField("ticket_id", ticket.TicketID).WithField("attempts", ticket.Attempts)
+
+	// A ticket whose worker keeps stopping while holding it is not tried forever
+	if ticket.Attempts > max(q.config.MaxAttempts, 1) {
+		entry.Error("User create ticket failed permanently after its workers stopped")
+		return true, q.repo.MarkFailed(ctx, ticket.ID, userCreateErrorInternal, "Failed to create user", nil)
+	}
+
+	var req dto.UserCreateRequest
+	if ticket.Payload == nil {
+		entry.Error("User create ticket has no payload")
+		return true, q.repo.MarkFailed(ctx, ticket.ID, userCreateErrorInternal, "Failed to create user", nil)
+	}
+	if err := json.Unmarshal([]byte(*ticket.Payload), &req); err != nil {
+		entry.WithError(err).Error("Failed to decode user create ticket")
+		return true, q.repo.MarkFailed(ctx, ticket.ID, userCreateErrorInternal, "Failed to create user", nil)
+	}
+
+	// The ticket is completed in the transaction that creates the user, so a user is never
+	// created twice for one ticket. Its validation was counted when it was queued.
+	var userID int
+	err = q.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		resp, err := q.userService.CreateUser(withoutValidationTelemetry(txCtx), &req)
+		if err != nil {
+			return err
+		}
+		userID = resp.ID
+		return q.repo.MarkCompleted(txCtx, ticket.ID, userID)
+	})
+	if err != nil {
+		return true, q.fail(ctx, ticket, err)
+	}
+
+	// The user is already created, so holds left unconsumed are only logged; they expire on their own
+	if ticket.SessionID != nil {
+		if err := q.reservations.Consume(ctx, *ticket.SessionID, userID); err != nil {
+			entry.WithError(err).WithField("user_id", userID).Warn("Failed to consume option reservations")
+		}
+		if err := q.sessions.RecordSubmission(ctx, *ticket.SessionID); err != nil {
+			entry.WithError(err).WithField("user_id", userID).Warn("Failed to record form session submission")
+		}
+	}
+
+	entry.WithField("user_id", userID).Info("Queued user created successfully")
+	return true, nil
+}
+
+// work processes tickets until the context is cancelled, waiting for the next poll or an
+// accepted ticket whenever none is due
+func (q *UserCreateQueue) work(ctx context.Context) {
+	// A ticket in hand is finished even when the queue is stopping
+	processCtx := context.WithoutCancel(ctx)
+
+	pollInterval := q.config.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = defaultUserCreateQueuePoll
+	}
+
+	for {
+		processed, err := q.ProcessNext(processCtx)
+		if err != nil {
+			q.log.WithError(err).Error("Failed to process user create ticket")
+		}
+		if processed && err == nil && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// fail records a failed attempt. Rejected registrations fail the ticket with the error
+// POST /users would have returned; other errors are retried with exponential backoff.
+func (q *UserCreateQueue) fail(ctx context.Context, ticket *model.UserCreateTicket, err error) error {
+	entry := q.log.WithError(err).WithField("ticket_id", ticket.TicketID).WithField("attempts", ticket.Attempts)
+
+	var duplicateErr *PossibleDuplicateError
+	switch {
+	case errors.As(err, &duplicateErr):
+		details := make(map[string]string, len(duplicateErr.Criteria))
+		for _, criterion := range duplicateErr.Criteria {
+			details[criterion] = "matches an existing user"
+		}
+		entry.Info("Queued registration rejected as a possible duplicate")
+		return q.repo.MarkFailed(ctx, ticket.ID, userCreateErrorPossibleDuplicate,
+			"This registration may duplicate an existing user", details)
+	case errors.Is(err, apperr.ErrValidation):
+		entry.Info("Queued registration rejected as invalid")
+		return q.repo.MarkFailed(ctx, ticket.ID, userCreateErrorValidation, err.Error(), nil)
+	case errors.Is(err, apperr.ErrDuplicate):
+		// The email address is not repeated, as the message is kept until the ticket is deleted
+		entry.Info("Queued registration rejected as a duplicate")
+		return q.repo.MarkFailed(ctx, ticket.ID, userCreateErrorDuplicate,
+			"A user with this email address already exists", nil)
+	}
+
+	if ticket.Attempts >= max(q.config.MaxAttempts, 1) {
+		entry.Error("User create ticket failed permanently")
+		return q.repo.MarkFailed(ctx, ticket.ID, userCreateErrorInternal, "Failed to create user", nil)
+	}
+
+	nextAttemptAt := time.Now().Add(q.config.RetryBaseDelay << (ticket.Attempts - 1))
+	entry.WithField("next_attempt_at", nextAttemptAt).Warn("Failed to create queued user, will retry")
+	return q.repo.Retry(ctx, ticket.ID, err.Error(), nextAttemptAt)
+}
+
+// purge deletes the finished tickets past the retention
+func (q *UserCreateQueue) purge(ctx context.Context) {
+	if q.config.Retention <= 0 {
+		return
+	}
+
+	deleted, err := q.repo.DeleteFinishedBefore(ctx, time.Now().Add(-q.config.Retention))
+	if err != nil {
+		q.log.WithError(err).Warn("Failed to delete finished user create tickets")
+		return
+	}
+	if deleted > 0 {
+		q.log.WithField("deleted", deleted).Info("Deleted finished user create tickets")
+	}
+}
+
+// toUserCreateTicketResponse converts a ticket to its response; the status URL is set by the handler
+func toUserCreateTicketResponse(ticket *model.UserCreateTicket) *dto.UserCreateTicketResponse {
+	resp := &dto.UserCreateTicketResponse{
+		TicketID:    ticket.TicketID,
+		Status:      ticket.Status,
+		UserID:      ticket.UserID,
+		AcceptedAt:  ticket.CreatedAt,
+		CompletedAt: ticket.CompletedAt,
+	}
+	if ticket.Status == model.UserCreateTicketFailed && ticket.ErrorCode != nil {
+		resp.Error = &dto.APIError{
+			Code:    *ticket.ErrorCode,
+			Message: stringValue(ticket.ErrorMessage),
+			Details: ticket.ErrorDetails,
+		}
+	}
+	return resp
+}
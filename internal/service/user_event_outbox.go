@@ -0,0 +1,64 @@
+// Package service provides the outbox subscriber of user domain events.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/domain"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/correlation"
+	"github.com/octop162/normal-form-app-by-claude/pkg/events"
+)
+
+// UserEventOutbox records user domain events in the outbox, from which the relay delivers
+// them to the webhook endpoint and the message bus
+type UserEventOutbox struct {
+	outboxRepo repository.OutboxRepository
+}
+
+// NewUserEventOutbox creates a new user event outbox subscriber
+func NewUserEventOutbox(outboxRepo repository.OutboxRepository) *UserEventOutbox {
+	return &UserEventOutbox{outboxRepo: outboxRepo}
+}
+
+// Subscribe registers the outbox handlers on bus
+func (o *UserEventOutbox) Subscribe(bus domain.Bus) {
+	bus.Subscribe(domain.EventUserCreated, o.userCreated)
+}
+
+// userCreated writes the user.created event to the outbox
+func (o *UserEventOutbox) userCreated(ctx context.Context, event domain.Event) error {
+	created := event.(domain.UserCreated)
+
+	payload, err := json.Marshal(&events.UserCreatedData{
+		UserID:      created.User.ID,
+		PlanType:    created.User.PlanType,
+		OptionTypes: created.OptionTypes,
+		Prefecture:  created.User.Prefecture,
+		Locale:      created.User.Locale,
+		CreatedAt:   created.User.CreatedAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal user created event: %w", err)
+	}
+
+	outboxEvent := &model.OutboxEvent{
+		AggregateType: "user",
+		AggregateID:   strconv.Itoa(created.User.ID),
+		EventType:     events.EventTypeUserCreated,
+		Payload:       payload,
+	}
+	if id := correlation.CorrelationID(ctx); id != "" {
+		outboxEvent.CorrelationID = &id
+	}
+
+	if _, err := o.outboxRepo.Create(ctx, outboxEvent); err != nil {
+		return fmt.Errorf("failed to record user created event: %w", err)
+	}
+
+	return nil
+}
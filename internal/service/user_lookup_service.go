@@ -0,0 +1,271 @@
+// Package service provides the self-service registration lookup flow.
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/mail"
+	"github.com/octop162/normal-form-app-by-claude/pkg/masking"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+const (
+	lookupIDBytes    = 16
+	lookupCodeDigits = 6
+	// Upper bound for sending the verification email in the background
+	lookupMailTimeout = 30 * time.Second
+)
+
+// ErrLookupVerificationFailed is returned for every verification failure so that
+// callers cannot tell unknown, expired, used and wrong codes apart
+var ErrLookupVerificationFailed = errors.New("invalid or expired verification code")
+
+// UserLookupConfig controls self-service lookups
+type UserLookupConfig struct {
+	CodeTTL     time.Duration
+	MaxAttempts int
+}
+
+// UserLookupService defines the interface for self-service registration lookups
+type UserLookupService interface {
+	StartLookup(ctx context.Context, req *dto.UserLookupRequest) (*dto.UserLookupResponse, error)
+	VerifyLookup(ctx context.Context, req *dto.UserLookupVerifyRequest) (*dto.UserLookupResultResponse, error)
+}
+
+// userLookupService implements UserLookupService
+type userLookupService struct {
+	userRepo       repository.UserRepository
+	userOptionRepo repository.UserOptionRepository
+	lookupRepo     repository.UserLookupRepository
+	txManager      repository.TxManager
+	mailer         mail.Sender
+	templates      EmailTemplateService
+	masking        *masking.Policy
+	config         UserLookupConfig
+	validator      *validator.CustomValidator
+	log            *logger.Logger
+}
+
+// NewUserLookupService creates a new user lookup service
+func NewUserLookupService(
+	userRepo repository.UserRepository,
+	userOptionRepo repository.UserOptionRepository,
+	lookupRepo repository.UserLookupRepository,
+	txManager repository.TxManager,
+	mailer mail.Sender,
+	templates EmailTemplateService,
+	maskingPolicy *masking.Policy,
+	config UserLookupConfig,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) UserLookupService {
+	return &userLookupService{
+		userRepo:       userRepo,
+		userOptionRepo: userOptionRepo,
+		lookupRepo:     lookupRepo,
+		txManager:      txManager,
+		mailer:         mailer,
+		templates:      templates,
+		masking:        maskingPolicy,
+		config:         config,
+		validator:      validator,
+		log:            log,
+	}
+}
+
+// StartLookup emails a verification code to the address if it is registered.
+// The response is the same for unknown addresses so registrations cannot be enumerated.
+func (s *userLookupService) StartLookup(
+	ctx context.Context, req *dto.UserLookupRequest,
+) (*dto.UserLookupResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	lookupID, err := randomHex(lookupIDBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate lookup ID: %w", err)
+	}
+
+	resp := &dto.UserLookupResponse{
+		LookupID:  lookupID,
+		ExpiresAt: time.Now().Add(s.config.CodeTTL),
+		Message:   "If the email address is registered, a verification code has been sent",
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, apperr.ErrNotFound) {
+			s.log.WithContext(ctx).Debug("Lookup requested for unregistered email")
+			return resp, nil
+		}
+		return nil, fmt.Errorf("failed to look up user: %w", err)
+	}
+
+	code, err := randomDigits(lookupCodeDigits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate verification code: %w", err)
+	}
+
+	if _, err := s.lookupRepo.DeleteExpired(ctx, time.Now()); err != nil {
+		s.log.WithContext(ctx).WithError(err).Warn("Failed to clean up expired lookups")
+	}
+
+	err = s.lookupRepo.Create(ctx, &model.UserLookup{
+		ID:        lookupID,
+		UserID:    user.ID,
+		CodeHash:  hashVerificationCode(lookupID, code),
+		ExpiresAt: resp.ExpiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Send in the background so response timing does not reveal whether the email exists
+	go s.sendCode(context.WithoutCancel(ctx), user.Email, user.Locale, code)
+
+	return resp, nil
+}
+
+// VerifyLookup checks the code and returns a masked view of the registration
+func (s *userLookupService) VerifyLookup(
+	ctx context.Context, req *dto.UserLookupVerifyRequest,
+) (*dto.UserLookupResultResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	var userID int
+	verified := false
+
+	err := s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		lookup, err := s.lookupRepo.GetByIDForUpdate(txCtx, req.LookupID)
+		if err != nil {
+			if errors.Is(err, apperr.ErrNotFound) {
+				return nil
+			}
+			return err
+		}
+
+		if lookup.VerifiedAt != nil || lookup.IsExpired() || lookup.Attempts >= s.config.MaxAttempts {
+			return nil
+		}
+
+		expected := []byte(lookup.CodeHash)
+		actual := []byte(hashVerificationCode(lookup.ID, req.Code))
+		if subtle.ConstantTimeCompare(expected, actual) != 1 {
+			// Commit the failed attempt so the code locks after MaxAttempts guesses
+			return s.lookupRepo.IncrementAttempts(txCtx, lookup.ID)
+		}
+
+		if err := s.lookupRepo.MarkVerified(txCtx, lookup.ID); err != nil {
+			return err
+		}
+		userID = lookup.UserID
+		verified = true
+		return nil
+	})
+	if err != nil {
+		s.log.WithContext(ctx).WithError(err).Error("Failed to verify user lookup")
+		return nil, fmt.Errorf("failed to verify user lookup: %w", err)
+	}
+	if !verified {
+		return nil, ErrLookupVerificationFailed
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	options, err := s.userOptionRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user options: %w", err)
+	}
+
+	optionTypes := make([]string, 0, len(options))
+	for _, option := range options {
+		optionTypes = append(optionTypes, option.OptionType)
+	}
+
+	s.log.WithContext(ctx).WithField("user_id", userID).Info("Self-service lookup verified")
+
+	resp := &dto.UserLookupResultResponse{
+		Status:       user.Status,
+		Name:         user.LastName + " " + user.FirstName,
+		Email:        user.Email,
+		PhoneNumber:  user.GetPhoneNumber(),
+		Prefecture:   user.Prefecture,
+		PlanType:     user.PlanType,
+		OptionTypes:  optionTypes,
+		RegisteredAt: user.CreatedAt,
+		Options:      userOptionSummaries(options),
+	}
+	resp.Mask(s.masking, masking.RoleSelf)
+
+	return resp, nil
+}
+
+// sendCode emails the verification code in the language of the user
+func (s *userLookupService) sendCode(ctx context.Context, email, language, code string) {
+	ctx, cancel := context.WithTimeout(ctx, lookupMailTimeout)
+	defer cancel()
+
+	msg, err := s.templates.Render(ctx, EmailTemplateLookupCode, language, LookupCodeEmail{
+		Code:    code,
+		Minutes: int(s.config.CodeTTL.Minutes()),
+	})
+	if err != nil {
+		s.log.WithContext(ctx).WithError(err).Error("Failed to render lookup verification email")
+		return
+	}
+
+	msg.To = email
+	err = s.mailer.Send(ctx, msg)
+	if errors.Is(err, mail.ErrSuppressed) {
+		s.log.WithContext(ctx).Info("Lookup verification email suppressed; the address bounced or complained")
+		return
+	}
+	if err != nil {
+		s.log.WithContext(ctx).WithError(err).Error("Failed to send lookup verification email")
+	}
+}
+
+// hashVerificationCode binds a code to its lookup or phone verification so hashes cannot be
+// reused across them
+func hashVerificationCode(lookupID, code string) string {
+	sum := sha256.Sum256([]byte(lookupID + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex returns n random bytes encoded as hex
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// randomDigits returns a uniformly random numeric string of the given length
+func randomDigits(digits int) (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
@@ -3,24 +3,53 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	playground "github.com/go-playground/validator/v10"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/domain"
 	"github.com/octop162/normal-form-app-by-claude/internal/dto"
 	"github.com/octop162/normal-form-app-by-claude/internal/model"
 	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/etag"
+	"github.com/octop162/normal-form-app-by-claude/pkg/external"
+	"github.com/octop162/normal-form-app-by-claude/pkg/locale"
 	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/masking"
 	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
 )
 
+// ErrUserPreconditionFailed is returned when an update's If-Match header does not list the
+// user's current tag, i.e. the user changed since the client read it
+var ErrUserPreconditionFailed = errors.New("user precondition failed")
+
+const (
+	// Longest deletion reason stored in a tombstone
+	maxDeletionReasonLength = 500
+
+	// Format of option start dates
+	optionStartDateLayout = "2006-01-02"
+)
+
 // UserService defines the interface for user business logic
 type UserService interface {
 	CreateUser(ctx context.Context, req *dto.UserCreateRequest) (*dto.UserCreateResponse, error)
 	ValidateUserData(ctx context.Context, req *dto.UserValidateRequest) (*dto.UserValidateResponse, error)
 	GetUserByID(ctx context.Context, id int) (*dto.UserResponse, error)
 	GetUserByEmail(ctx context.Context, email string) (*dto.UserResponse, error)
-	UpdateUser(ctx context.Context, id int, req *dto.UserCreateRequest) (*dto.UserResponse, error)
-	DeleteUser(ctx context.Context, id int) error
+	UpdateUser(ctx context.Context, id int, req *dto.UserUpdateRequest, ifMatch string) (*dto.UserResponse, error)
+	PreviewUpdateUser(ctx context.Context, id int, req *dto.UserCreateRequest) (*dto.UserUpdatePreviewResponse, error)
+	PatchUser(ctx context.Context, id int, req *dto.UserPatchRequest, ifMatch string) (*dto.UserResponse, error)
+	DeleteUser(ctx context.Context, id int, reason string) error
 }
 
 // userService implements UserService
@@ -28,6 +57,18 @@ type userService struct {
 	userRepo       repository.UserRepository
 	userOptionRepo repository.UserOptionRepository
 	optionRepo     repository.OptionRepository
+	planRepo       repository.PlanRepository
+	pricingRepo    repository.UserPricingSnapshotRepository
+	domainEvents   domain.Bus
+	deletionRepo   repository.DeletionRecordRepository
+	txManager      repository.TxManager
+	deletionPolicy DeletionPolicy
+	rules          *ValidationRules
+	duplicates     DuplicateService
+	phones         PhoneVerificationService
+	regions        AddressService
+	quotes         QuoteService
+	telemetry      *ValidationTelemetry
 	validator      *validator.CustomValidator
 	log            *logger.Logger
 }
@@ -37,6 +78,18 @@ func NewUserService(
 	userRepo repository.UserRepository,
 	userOptionRepo repository.UserOptionRepository,
 	optionRepo repository.OptionRepository,
+	planRepo repository.PlanRepository,
+	pricingRepo repository.UserPricingSnapshotRepository,
+	domainEvents domain.Bus,
+	deletionRepo repository.DeletionRecordRepository,
+	txManager repository.TxManager,
+	deletionPolicy DeletionPolicy,
+	rules *ValidationRules,
+	duplicates DuplicateService,
+	phones PhoneVerificationService,
+	regions AddressService,
+	quotes QuoteService,
+	telemetry *ValidationTelemetry,
 	validator *validator.CustomValidator,
 	log *logger.Logger,
 ) UserService {
@@ -44,6 +97,18 @@ func NewUserService(
 		userRepo:       userRepo,
 		userOptionRepo: userOptionRepo,
 		optionRepo:     optionRepo,
+		planRepo:       planRepo,
+		pricingRepo:    pricingRepo,
+		domainEvents:   domainEvents,
+		deletionRepo:   deletionRepo,
+		txManager:      txManager,
+		deletionPolicy: deletionPolicy,
+		rules:          rules,
+		duplicates:     duplicates,
+		phones:         phones,
+		regions:        regions,
+		quotes:         quotes,
+		telemetry:      telemetry,
 		validator:      validator,
 		log:            log,
 	}
@@ -51,54 +116,98 @@ func NewUserService(
 
 // CreateUser creates a new user with validation
 func (s *userService) CreateUser(ctx context.Context, req *dto.UserCreateRequest) (*dto.UserCreateResponse, error) {
+	normalizeUserAddress(req)
+
 	// Validate request
 	validationResp, err := s.ValidateUserData(ctx, &dto.UserValidateRequest{UserCreateRequest: *req})
 	if err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+		return nil, fmt.Errorf("failed to validate user data: %w", err)
 	}
+	s.telemetry.Record(ctx, ValidationStageSubmit, validationResp.Codes)
 
 	if !validationResp.Valid {
-		return nil, fmt.Errorf("validation errors: %v", validationResp.Errors)
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %v", validationResp.Errors)
 	}
 
 	// Check if user already exists
 	exists, err := s.userRepo.ExistsByEmail(ctx, req.Email)
 	if err != nil {
-		s.log.WithError(err).Error("Failed to check user existence")
+		s.log.WithContext(ctx).WithError(err).Error("Failed to check user existence")
 		return nil, fmt.Errorf("failed to check user existence: %w", err)
 	}
 
 	if exists {
-		return nil, fmt.Errorf("user with email %s already exists", req.Email)
+		return nil, apperr.Errorf(apperr.ErrDuplicate, "user with email %s already exists", req.Email)
+	}
+
+	// The same person may register again under another email address
+	matches, err := s.duplicates.FindDuplicates(ctx, req)
+	if err != nil {
+		s.log.WithContext(ctx).WithError(err).Error("Failed to check duplicate registrations")
+		return nil, fmt.Errorf("failed to check duplicate registrations: %w", err)
+	}
+	if len(matches) > 0 {
+		return nil, newPossibleDuplicateError(matches)
 	}
 
 	// Convert DTO to model
 	user := s.convertCreateRequestToModel(req)
 
-	// Create user
-	createdUser, err := s.userRepo.Create(ctx, user)
+	// A number verified by SMS shortly before registering counts for the new user
+	user.PhoneVerified, err = s.phones.IsVerified(ctx, normalizePhone(req.Phone1, req.Phone2, req.Phone3))
+	if err != nil {
+		s.log.WithContext(ctx).WithError(err).Error("Failed to check phone verification")
+		return nil, fmt.Errorf("failed to check phone verification: %w", err)
+	}
+
+	// The prices in effect now are kept with the user, so later price changes do not alter
+	// what the user agreed to
+	pricing, err := s.quotes.Price(ctx, &dto.QuoteRequest{
+		PlanType:      req.PlanType,
+		OptionTypes:   req.OptionTypes,
+		OptionDetails: req.OptionDetails,
+	})
 	if err != nil {
-		s.log.WithError(err).Error("Failed to create user")
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		s.log.WithContext(ctx).WithError(err).Error("Failed to price registration")
+		return nil, fmt.Errorf("failed to price registration: %w", err)
 	}
 
-	// Create user options if any
-	if len(req.OptionTypes) > 0 {
-		userOptions := make([]*model.UserOption, 0, len(req.OptionTypes))
-		for _, optionType := range req.OptionTypes {
-			userOptions = append(userOptions, &model.UserOption{
-				UserID:     createdUser.ID,
-				OptionType: optionType,
-			})
+	// Persist the user, its options and prices, and raise UserCreated within the same transaction
+	var createdUser *model.User
+	err = s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		var createErr error
+		createdUser, createErr = s.userRepo.Create(txCtx, user)
+		if createErr != nil {
+			s.log.WithContext(ctx).WithError(createErr).Error("Failed to create user")
+			return fmt.Errorf("failed to create user: %w", createErr)
 		}
 
-		if err := s.userOptionRepo.CreateBatch(ctx, userOptions); err != nil {
-			s.log.WithError(err).Error("Failed to create user options")
-			return nil, fmt.Errorf("failed to create user options: %w", err)
+		// Create user options if any
+		if len(req.OptionTypes) > 0 {
+			userOptions := make([]*model.UserOption, 0, len(req.OptionTypes))
+			for _, optionType := range req.OptionTypes {
+				userOptions = append(userOptions, newUserOption(createdUser.ID, optionType, req.OptionDetails))
+			}
+
+			if createErr = s.userOptionRepo.CreateBatch(txCtx, userOptions); createErr != nil {
+				s.log.WithContext(ctx).WithError(createErr).Error("Failed to create user options")
+				return fmt.Errorf("failed to create user options: %w", createErr)
+			}
+		}
+
+		pricing.UserID = createdUser.ID
+		if createErr = s.pricingRepo.Create(txCtx, pricing); createErr != nil {
+			s.log.WithContext(ctx).WithError(createErr).Error("Failed to create pricing snapshot")
+			return fmt.Errorf("failed to create pricing snapshot: %w", createErr)
 		}
+
+		return s.domainEvents.Publish(txCtx, domain.UserCreated{User: createdUser, OptionTypes: req.OptionTypes})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	s.log.WithField("user_id", createdUser.ID).Info("User created successfully with options")
+	s.log.WithContext(ctx).WithField("user_id", createdUser.ID).Info("User created successfully with options")
 
 	return &dto.UserCreateResponse{
 		ID:      createdUser.ID,
@@ -110,161 +219,562 @@ func (s *userService) CreateUser(ctx context.Context, req *dto.UserCreateRequest
 func (s *userService) ValidateUserData(
 	ctx context.Context, req *dto.UserValidateRequest,
 ) (*dto.UserValidateResponse, error) {
-	errors := make(map[string]string)
+	resp := s.validateUserData(ctx, &req.UserCreateRequest, "", nil)
+	if !resp.Valid || !external.IsStrict(ctx) {
+		return resp, nil
+	}
+
+	if err := s.validateRegion(ctx, &req.UserCreateRequest, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// validateRegion checks the selected options against the region restrictions of the address,
+// for submissions in strict mode, which must not be accepted with options unavailable in the
+// region. An address that cannot be checked fails with the error of the region API.
+func (s *userService) validateRegion(
+	ctx context.Context, req *dto.UserCreateRequest, resp *dto.UserValidateResponse,
+) error {
+	if len(req.OptionTypes) == 0 {
+		return nil
+	}
+
+	regionResp, err := s.regions.CheckRegionRestrictions(ctx, &dto.RegionCheckRequest{
+		Prefecture:  req.Prefecture,
+		City:        req.City,
+		OptionTypes: req.OptionTypes,
+	})
+	if errors.Is(err, apperr.ErrNotFound) {
+		resp.Valid = false
+		resp.Errors["prefecture"] = "Unknown prefecture: " + req.Prefecture
+		resp.Codes["prefecture"] = "not_found"
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, optionType := range req.OptionTypes {
+		if available, ok := regionResp.Restrictions[optionType]; ok && !available {
+			resp.Valid = false
+			resp.Errors["option_types"] = "Option is not available in the region: " + optionType
+			resp.Codes["option_types"] = "region_restricted"
+			break
+		}
+	}
+	return nil
+}
+
+// validateUserData validates user data. The plan heldPlan and options in heldOptions pass even
+// if they are no longer offered, so users keep a plan or options that were withdrawn after
+// they selected them.
+func (s *userService) validateUserData(
+	ctx context.Context, req *dto.UserCreateRequest, heldPlan string, heldOptions []string,
+) *dto.UserValidateResponse {
+	errs := newValidationErrors()
 
 	// Struct validation
 	if err := s.validator.ValidateStruct(req); err != nil {
-		s.log.WithError(err).Debug("Struct validation failed")
-		// Convert validation errors to map
-		// Note: This is a simplified version - production code would parse validation errors properly
-		errors["validation"] = err.Error()
+		s.log.WithContext(ctx).WithError(err).Debug("Struct validation failed")
+		addStructValidationErrors(err, errs)
 	}
 
 	// Business logic validation
-	s.validateBusinessRules(ctx, &req.UserCreateRequest, errors)
+	s.validateBusinessRules(ctx, req, heldPlan, heldOptions, errs)
 
-	valid := len(errors) == 0
+	valid := len(errs.messages) == 0
 
 	return &dto.UserValidateResponse{
 		Valid:  valid,
-		Errors: errors,
-	}, nil
+		Errors: errs.messages,
+		Codes:  errs.codes,
+	}
+}
+
+// validationErrors collects the message and failure code of every field rejected by validation
+type validationErrors struct {
+	messages map[string]string
+	codes    map[string]string
+}
+
+func newValidationErrors() *validationErrors {
+	return &validationErrors{
+		messages: make(map[string]string),
+		codes:    make(map[string]string),
+	}
+}
+
+// add records the error of a field, replacing an earlier one
+func (e *validationErrors) add(field, code, message string) {
+	e.messages[field] = message
+	e.codes[field] = code
+}
+
+// userRequestFields maps UserCreateRequest field names to their JSON names
+var userRequestFields = func() map[string]string {
+	fields := make(map[string]string)
+	t := reflect.TypeOf(dto.UserCreateRequest{})
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		fields[t.Field(i).Name] = name
+	}
+	return fields
+}()
+
+// addStructValidationErrors records struct validation failures of a UserCreateRequest
+// under the JSON name of the top-level field, with the failed tag as the failure code;
+// other errors are recorded as "validation"
+func addStructValidationErrors(err error, errs *validationErrors) {
+	var fieldErrors playground.ValidationErrors
+	if !errors.As(err, &fieldErrors) {
+		errs.add("validation", "invalid", err.Error())
+		return
+	}
+
+	for _, fieldError := range fieldErrors {
+		// The namespace is Type.Field or Type.Field[key].Nested
+		_, path, _ := strings.Cut(fieldError.StructNamespace(), ".")
+		name := path
+		if i := strings.IndexAny(path, ".["); i >= 0 {
+			name = path[:i]
+		}
+		field, ok := userRequestFields[name]
+		if !ok {
+			field = "validation"
+		}
+		if _, exists := errs.messages[field]; exists {
+			continue
+		}
+
+		if fieldError.Tag() == "required" {
+			errs.add(field, fieldError.Tag(), field+" is required")
+		} else {
+			errs.add(field, fieldError.Tag(), fmt.Sprintf("%s is invalid (%s)", field, fieldError.Tag()))
+		}
+	}
 }
 
 // GetUserByID retrieves a user by ID
 func (s *userService) GetUserByID(ctx context.Context, id int) (*dto.UserResponse, error) {
 	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
-		s.log.WithError(err).WithField("user_id", id).Error("Failed to get user by ID")
+		s.log.WithContext(ctx).WithError(err).WithField("user_id", id).Error("Failed to get user by ID")
 		return nil, fmt.Errorf("failed to get user by ID: %w", err)
 	}
 
-	return s.convertModelToResponse(user), nil
+	pricing, err := s.pricingRepo.GetByUserID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pricing snapshot: %w", err)
+	}
+
+	resp := toUserResponse(user)
+	resp.PricingSnapshot = toPricingSnapshotResponse(pricing)
+	return resp, nil
 }
 
 // GetUserByEmail retrieves a user by email
 func (s *userService) GetUserByEmail(ctx context.Context, email string) (*dto.UserResponse, error) {
 	user, err := s.userRepo.GetByEmail(ctx, email)
 	if err != nil {
-		s.log.WithError(err).WithField("email", email).Error("Failed to get user by email")
+		s.log.WithContext(ctx).WithError(err).WithField("email", masking.Apply(masking.RuleEmail, email)).Error("Failed to get user by email")
 		return nil, fmt.Errorf("failed to get user by email: %w", err)
 	}
 
-	return s.convertModelToResponse(user), nil
+	return toUserResponse(user), nil
 }
 
-// UpdateUser updates an existing user
-func (s *userService) UpdateUser(ctx context.Context, id int, req *dto.UserCreateRequest) (*dto.UserResponse, error) {
-	// Validate request
-	validationResp, err := s.ValidateUserData(ctx, &dto.UserValidateRequest{UserCreateRequest: *req})
+// UserETag returns the entity tag of a user as shown to a caller role. It changes with the
+// user's version, and differs between roles as they see differently masked fields.
+func UserETag(id, version int, role string) string {
+	return etag.FromParts(strconv.Itoa(id), strconv.Itoa(version), role)
+}
+
+// checkUserIfMatch returns ErrUserPreconditionFailed unless a non-empty If-Match header lists
+// the current tag of the user
+func checkUserIfMatch(ctx context.Context, user *model.User, ifMatch string) error {
+	if ifMatch == "" {
+		return nil
+	}
+	tag := UserETag(user.ID, user.Version, masking.RoleFromContext(ctx))
+	if !etag.MatchesAny(ifMatch, tag) {
+		return fmt.Errorf("%w: user %d is at %s", ErrUserPreconditionFailed, user.ID, tag)
+	}
+	return nil
+}
+
+// UpdateUser updates an existing user. A non-empty ifMatch is an If-Match header: the update
+// is refused with ErrUserPreconditionFailed unless it lists the user's current tag. An update
+// based on another version than the stored one fails with an *apperr.VersionConflictError.
+func (s *userService) UpdateUser(
+	ctx context.Context, id int, req *dto.UserUpdateRequest, ifMatch string,
+) (*dto.UserResponse, error) {
+	currentUser, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("validation failed: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 
+	heldOptions, err := s.userOptionRepo.GetByUserID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user options: %w", err)
+	}
+
+	normalizeUserAddress(&req.UserCreateRequest)
+
+	// Validate request
+	validationResp := s.validateUserData(ctx, &req.UserCreateRequest, currentUser.PlanType, userOptionTypes(heldOptions))
 	if !validationResp.Valid {
-		return nil, fmt.Errorf("validation errors: %v", validationResp.Errors)
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %v", validationResp.Errors)
+	}
+
+	// Update the user and its options atomically so a failed option change rolls back the user
+	var updatedUser *model.User
+	err = s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		existingUser, err := s.userRepo.GetByIDForUpdate(txCtx, id)
+		if err != nil {
+			return err
+		}
+		// Checked under the row lock so that a concurrent update cannot slip in between
+		if err := checkUserIfMatch(ctx, existingUser, ifMatch); err != nil {
+			return err
+		}
+
+		// Check email uniqueness if email is being changed
+		if existingUser.Email != req.Email {
+			emailExists, emailErr := s.userRepo.ExistsByEmail(txCtx, req.Email)
+			if emailErr != nil {
+				return fmt.Errorf("failed to check email uniqueness: %w", emailErr)
+			}
+			if emailExists {
+				return apperr.Errorf(apperr.ErrDuplicate, "user with email %s already exists", req.Email)
+			}
+		}
+
+		previousUser := *existingUser
+		s.updateUserFields(existingUser, &req.UserCreateRequest)
+		if req.Version != nil {
+			existingUser.Version = *req.Version
+		}
+
+		if updatedUser, err = s.userRepo.Update(txCtx, existingUser); err != nil {
+			s.log.WithContext(ctx).WithError(err).Error("Failed to update user")
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+
+		if err := s.updateUserOptions(txCtx, id, &req.UserCreateRequest); err != nil {
+			s.log.WithContext(ctx).WithError(err).Error("Failed to update user options")
+			return fmt.Errorf("failed to update user options: %w", err)
+		}
+
+		return s.publishUserUpdated(txCtx, &previousUser, updatedUser, diffUserFields(&previousUser, updatedUser))
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	// Get existing user
+	s.log.WithContext(ctx).WithField("user_id", id).Info("User updated successfully")
+
+	return toUserResponse(updatedUser), nil
+}
+
+// PreviewUpdateUser reports the field-level changes an update would make without saving them
+func (s *userService) PreviewUpdateUser(
+	ctx context.Context, id int, req *dto.UserCreateRequest,
+) (*dto.UserUpdatePreviewResponse, error) {
 	existingUser, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	existingOptions, err := s.userOptionRepo.GetByUserID(ctx, id)
+	if err != nil {
+		s.log.WithContext(ctx).WithError(err).WithField("user_id", id).Error("Failed to get user options")
+		return nil, fmt.Errorf("failed to get user options: %w", err)
 	}
 
-	// Check email uniqueness if email is being changed
+	currentOptionTypes := userOptionTypes(existingOptions)
+
+	normalizeUserAddress(req)
+	validationResp := s.validateUserData(ctx, req, existingUser.PlanType, currentOptionTypes)
+	errors := validationResp.Errors
+	if errors == nil {
+		errors = make(map[string]string)
+	}
+
+	// Mirror the uniqueness check performed by UpdateUser
 	if existingUser.Email != req.Email {
 		emailExists, emailErr := s.userRepo.ExistsByEmail(ctx, req.Email)
 		if emailErr != nil {
 			return nil, fmt.Errorf("failed to check email uniqueness: %w", emailErr)
 		}
 		if emailExists {
-			return nil, fmt.Errorf("user with email %s already exists", req.Email)
+			errors["email"] = "Email is already registered"
 		}
 	}
 
-	// Update user fields
-	s.updateUserFields(existingUser, req)
+	proposedUser := *existingUser
+	s.updateUserFields(&proposedUser, req)
 
-	// Update user
-	updatedUser, err := s.userRepo.Update(ctx, existingUser)
-	if err != nil {
-		s.log.WithError(err).Error("Failed to update user")
-		return nil, fmt.Errorf("failed to update user: %w", err)
+	changes := diffUserFields(existingUser, &proposedUser)
+	if change, ok := diffOptionTypes(currentOptionTypes, req.OptionTypes); ok {
+		changes = append(changes, change)
+	}
+	if change, ok := diffOptionDetails(existingOptions, id, req); ok {
+		changes = append(changes, change)
 	}
 
-	// Update user options
-	if err := s.updateUserOptions(ctx, id, req.OptionTypes); err != nil {
-		s.log.WithError(err).Error("Failed to update user options")
-		return nil, fmt.Errorf("failed to update user options: %w", err)
+	return &dto.UserUpdatePreviewResponse{
+		UserID:     id,
+		HasChanges: len(changes) > 0,
+		Changes:    changes,
+		Valid:      len(errors) == 0,
+		Errors:     errors,
+	}, nil
+}
+
+// PatchUser applies a partial update. The patch is merged onto the stored user and validated
+// as a whole, and only the columns whose values change are written. ifMatch is checked as in
+// UpdateUser.
+func (s *userService) PatchUser(
+	ctx context.Context, id int, req *dto.UserPatchRequest, ifMatch string,
+) (*dto.UserResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
 	}
 
-	s.log.WithField("user_id", id).Info("User updated successfully")
+	var patchedUser *model.User
+	err := s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		existingUser, err := s.userRepo.GetByIDForUpdate(txCtx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkUserIfMatch(ctx, existingUser, ifMatch); err != nil {
+			return err
+		}
+		// Checked here too, as a patch that changes no column never reaches the repository
+		if req.Version != nil && *req.Version != existingUser.Version {
+			return &apperr.VersionConflictError{
+				Resource: "user", ID: strconv.Itoa(id),
+				ExpectedVersion: *req.Version, CurrentVersion: existingUser.Version,
+			}
+		}
+
+		existingOptions, err := s.userOptionRepo.GetByUserID(txCtx, id)
+		if err != nil {
+			return fmt.Errorf("failed to get user options: %w", err)
+		}
+
+		merged := userToRequest(existingUser, existingOptions)
+		applyUserPatch(merged, req)
+		normalizeUserAddress(merged)
+
+		validationResp := s.validateUserData(txCtx, merged, existingUser.PlanType, userOptionTypes(existingOptions))
+		if !validationResp.Valid {
+			return apperr.Errorf(apperr.ErrValidation, "validation errors: %v", validationResp.Errors)
+		}
+
+		if existingUser.Email != merged.Email {
+			emailExists, emailErr := s.userRepo.ExistsByEmail(txCtx, merged.Email)
+			if emailErr != nil {
+				return fmt.Errorf("failed to check email uniqueness: %w", emailErr)
+			}
+			if emailExists {
+				return apperr.Errorf(apperr.ErrDuplicate, "user with email %s already exists", merged.Email)
+			}
+		}
 
-	return s.convertModelToResponse(updatedUser), nil
+		proposedUser := *existingUser
+		s.updateUserFields(&proposedUser, merged)
+
+		// Change fields are named after their columns
+		changes := diffUserFields(existingUser, &proposedUser)
+		patchedUser = existingUser
+		if len(changes) > 0 {
+			columns := make([]string, 0, len(changes))
+			for _, change := range changes {
+				columns = append(columns, change.Field)
+			}
+			if patchedUser, err = s.userRepo.UpdateColumns(txCtx, &proposedUser, columns); err != nil {
+				s.log.WithContext(ctx).WithError(err).Error("Failed to patch user")
+				return fmt.Errorf("failed to patch user: %w", err)
+			}
+		}
+
+		if req.OptionTypes != nil || req.OptionDetails != nil {
+			if err := s.updateUserOptions(txCtx, id, merged); err != nil {
+				s.log.WithContext(ctx).WithError(err).Error("Failed to update user options")
+				return fmt.Errorf("failed to update user options: %w", err)
+			}
+		}
+
+		return s.publishUserUpdated(txCtx, existingUser, patchedUser, changes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.WithContext(ctx).WithField("user_id", id).Info("User patched successfully")
+
+	return toUserResponse(patchedUser), nil
 }
 
-// DeleteUser deletes a user
-func (s *userService) DeleteUser(ctx context.Context, id int) error {
-	// Delete user options first
-	if err := s.userOptionRepo.DeleteByUserID(ctx, id); err != nil {
-		s.log.WithError(err).Error("Failed to delete user options")
-		return fmt.Errorf("failed to delete user options: %w", err)
+// DeleteUser hard-deletes a user and leaves a tombstone. Users under legal hold cannot be deleted.
+func (s *userService) DeleteUser(ctx context.Context, id int, reason string) error {
+	if utf8.RuneCountInString(reason) > maxDeletionReasonLength {
+		return apperr.Errorf(apperr.ErrValidation, "validation errors: reason must be at most %d characters", maxDeletionReasonLength)
 	}
 
-	// Delete user
-	if err := s.userRepo.Delete(ctx, id); err != nil {
-		s.log.WithError(err).Error("Failed to delete user")
+	err := s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		user, err := s.userRepo.GetByIDForUpdate(txCtx, id)
+		if err != nil {
+			return err
+		}
+
+		if user.LegalHold {
+			return apperr.Errorf(apperr.ErrLegalHold, "user %d is under legal hold", id)
+		}
+
+		// Delete user options first
+		if err := s.userOptionRepo.DeleteByUserID(txCtx, id); err != nil {
+			return fmt.Errorf("failed to delete user options: %w", err)
+		}
+
+		if err := s.userRepo.Delete(txCtx, id); err != nil {
+			return err
+		}
+
+		if err := s.recordDeletion(txCtx, user, reason); err != nil {
+			return err
+		}
+
+		return s.domainEvents.Publish(txCtx, domain.UserDeleted{User: user, Reason: reason})
+	})
+	if err != nil {
+		s.log.WithContext(ctx).WithError(err).WithField("user_id", id).Error("Failed to delete user")
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
 
-	s.log.WithField("user_id", id).Info("User deleted successfully")
+	s.log.WithContext(ctx).WithField("user_id", id).Info("User deleted successfully")
+	return nil
+}
+
+// publishUserUpdated raises UserUpdated when changes is not empty
+func (s *userService) publishUserUpdated(
+	ctx context.Context, previous, updated *model.User, changes []dto.UserFieldChange,
+) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	fields := make([]string, 0, len(changes))
+	for _, change := range changes {
+		fields = append(fields, change.Field)
+	}
+	return s.domainEvents.Publish(ctx, domain.UserUpdated{Previous: previous, User: updated, ChangedFields: fields})
+}
+
+// recordDeletion writes the tombstone of a deleted user
+func (s *userService) recordDeletion(ctx context.Context, user *model.User, reason string) error {
+	record := &model.DeletionRecord{
+		UserID:      user.ID,
+		EmailHash:   s.deletionPolicy.hashEmail(user.Email),
+		Operator:    model.DeletionOperatorUserAPI,
+		RetainUntil: time.Now().Add(s.deletionPolicy.Retention),
+	}
+	if reason != "" {
+		record.Reason = &reason
+	}
+
+	if _, err := s.deletionRepo.Create(ctx, record); err != nil {
+		return fmt.Errorf("failed to record user deletion: %w", err)
+	}
+
 	return nil
 }
 
 // validateBusinessRules validates business-specific rules
 func (s *userService) validateBusinessRules(
-	ctx context.Context, req *dto.UserCreateRequest, errors map[string]string,
+	ctx context.Context, req *dto.UserCreateRequest, heldPlan string, heldOptions []string, errs *validationErrors,
 ) {
+	// Configurable per-field rules
+	s.rules.Validate(userFormValues(req), errs.messages, errs.codes)
+
 	// Validate phone number format
 	fullPhone := req.Phone1 + req.Phone2 + req.Phone3
 	if !validator.IsValidPhone(fullPhone) {
-		errors["phone"] = "Invalid phone number format"
+		errs.add("phone", "format", "Invalid phone number format")
 	}
 
 	// Validate postal code
 	fullPostalCode := req.PostalCode1 + "-" + req.PostalCode2
 	if !validator.IsValidPostalCode(fullPostalCode) {
-		errors["postal_code"] = "Invalid postal code format"
+		errs.add("postal_code", "format", "Invalid postal code format")
 	}
 
 	// Validate plan type
-	if !validator.IsValidPlanType(req.PlanType) {
-		errors["plan_type"] = "Invalid plan type"
+	if code, message := s.validatePlan(ctx, req.PlanType, heldPlan); code != "" {
+		errs.add("plan_type", code, message)
 	}
 
 	// Validate option types
 	for _, optionType := range req.OptionTypes {
 		if !validator.IsValidOptionType(optionType) {
-			errors["option_types"] = "Invalid option type: " + optionType
+			errs.add("option_types", "invalid", "Invalid option type: "+optionType)
 			break
 		}
 
 		// Check if option is compatible with plan
 		option, err := s.optionRepo.GetByOptionType(ctx, optionType)
 		if err != nil {
-			errors["option_types"] = "Option not found: " + optionType
+			errs.add("option_types", "not_found", "Option not found: "+optionType)
 			continue
 		}
 
-		if !s.isOptionCompatibleWithPlan(option, req.PlanType) {
-			errors["option_types"] = fmt.Sprintf("Option %s is not compatible with plan %s", optionType, req.PlanType)
+		if !option.IsAvailableAt(time.Now()) && !slices.Contains(heldOptions, optionType) {
+			errs.add("option_types", "unavailable", "Option is not available: "+optionType)
+			break
+		}
+
+		if !isOptionCompatibleWithPlan(option, req.PlanType) {
+			errs.add("option_types", "incompatible",
+				fmt.Sprintf("Option %s is not compatible with plan %s", optionType, req.PlanType))
 			break
 		}
+
+		if detail := req.OptionDetails[optionType]; detail.Quantity > option.MaxQuantity {
+			errs.add("option_details", "max_quantity",
+				fmt.Sprintf("Quantity of option %s must be at most %d", optionType, option.MaxQuantity))
+		}
+	}
+
+	// Settings are only accepted for selected options
+	for _, optionType := range slices.Sorted(maps.Keys(req.OptionDetails)) {
+		if !slices.Contains(req.OptionTypes, optionType) {
+			errs.add("option_details", "not_selected", "Option is not selected: "+optionType)
+			break
+		}
+	}
+}
+
+// validatePlan checks the plan against the plan master and returns the failure code and
+// reason it cannot be chosen, or empty strings
+func (s *userService) validatePlan(ctx context.Context, planType, heldPlan string) (string, string) {
+	plan, err := s.planRepo.GetByPlanType(ctx, planType)
+	if errors.Is(err, apperr.ErrNotFound) {
+		return "not_found", "Invalid plan type"
+	}
+	if err != nil {
+		s.log.WithContext(ctx).WithError(err).WithField("plan_type", planType).Error("Failed to get plan")
+		return "unverified", "Plan could not be verified: " + planType
+	}
+	if !plan.IsActive && planType != heldPlan {
+		return "unavailable", "Plan is not available: " + planType
 	}
+	return "", ""
 }
 
 // isOptionCompatibleWithPlan checks if an option is compatible with a plan
-func (s *userService) isOptionCompatibleWithPlan(option *model.OptionMaster, planType string) bool {
+func isOptionCompatibleWithPlan(option *model.OptionMaster, planType string) bool {
 	switch option.PlanCompatibility {
 	case "A":
 		return planType == "A"
@@ -299,13 +809,14 @@ func (s *userService) convertCreateRequestToModel(req *dto.UserCreateRequest) *m
 		Room:          req.Room,
 		Email:         req.Email,
 		PlanType:      req.PlanType,
+		Locale:        locale.Negotiate(req.Locale, ""),
 		CreatedAt:     time.Now(),
 		UpdatedAt:     time.Now(),
 	}
 }
 
-// convertModelToResponse converts model to response DTO
-func (s *userService) convertModelToResponse(user *model.User) *dto.UserResponse {
+// toUserResponse converts model to response DTO
+func toUserResponse(user *model.User) *dto.UserResponse {
 	return &dto.UserResponse{
 		ID:            user.ID,
 		LastName:      user.LastName,
@@ -313,15 +824,35 @@ func (s *userService) convertModelToResponse(user *model.User) *dto.UserResponse
 		LastNameKana:  user.LastNameKana,
 		FirstNameKana: user.FirstNameKana,
 		PhoneNumber:   user.GetPhoneNumber(),
+		PhoneVerified: user.PhoneVerified,
 		PostalCode:    user.GetPostalCode(),
 		Address:       user.GetFullAddress(),
 		Email:         user.Email,
 		PlanType:      user.PlanType,
+		Locale:        user.Locale,
+		Status:        user.Status,
+		Version:       user.Version,
 		CreatedAt:     user.CreatedAt,
 		UpdatedAt:     user.UpdatedAt,
 	}
 }
 
+// toPricingSnapshotResponse converts a pricing snapshot to its response form; nil stays nil
+func toPricingSnapshotResponse(pricing *model.UserPricingSnapshot) *dto.UserPricingSnapshotResponse {
+	if pricing == nil {
+		return nil
+	}
+	return &dto.UserPricingSnapshotResponse{
+		PlanType: pricing.PlanType,
+		Lines:    toQuoteLines(pricing.Lines),
+		Subtotal: pricing.Subtotal,
+		TaxRate:  pricing.TaxRate.String(),
+		Tax:      pricing.Tax,
+		Total:    pricing.Total,
+		PricedAt: pricing.PricedAt,
+	}
+}
+
 // updateUserFields updates user fields from request
 func (s *userService) updateUserFields(user *model.User, req *dto.UserCreateRequest) {
 	user.LastName = req.LastName
@@ -345,21 +876,194 @@ func (s *userService) updateUserFields(user *model.User, req *dto.UserCreateRequ
 	user.PlanType = req.PlanType
 }
 
-// updateUserOptions updates user options
-func (s *userService) updateUserOptions(ctx context.Context, userID int, optionTypes []string) error {
-	// Delete existing options
-	if err := s.userOptionRepo.DeleteByUserID(ctx, userID); err != nil {
-		return fmt.Errorf("failed to delete existing options: %w", err)
+// userToRequest builds a full update request holding the stored user and options
+func userToRequest(user *model.User, options []*model.UserOption) *dto.UserCreateRequest {
+	req := &dto.UserCreateRequest{
+		LastName:      user.LastName,
+		FirstName:     user.FirstName,
+		LastNameKana:  user.LastNameKana,
+		FirstNameKana: user.FirstNameKana,
+		Phone1:        user.Phone1,
+		Phone2:        user.Phone2,
+		Phone3:        user.Phone3,
+		PostalCode1:   user.PostalCode1,
+		PostalCode2:   user.PostalCode2,
+		Prefecture:    user.Prefecture,
+		City:          user.City,
+		Town:          user.Town,
+		Chome:         user.Chome,
+		Banchi:        user.Banchi,
+		Go:            user.Go,
+		Building:      user.Building,
+		Room:          user.Room,
+		Email:         user.Email,
+		EmailConfirm:  user.Email,
+		PlanType:      user.PlanType,
+		OptionTypes:   userOptionTypes(options),
+		OptionDetails: make(map[string]dto.UserOptionDetail, len(options)),
+	}
+
+	for _, summary := range userOptionSummaries(options) {
+		req.OptionDetails[summary.OptionType] = dto.UserOptionDetail{
+			Quantity:  summary.Quantity,
+			StartDate: summary.StartDate,
+		}
+	}
+
+	return req
+}
+
+// applyUserPatch overlays the fields present in patch onto req
+func applyUserPatch(req *dto.UserCreateRequest, patch *dto.UserPatchRequest) {
+	setString := func(target *string, value *string) {
+		if value != nil {
+			*target = *value
+		}
+	}
+	// An empty string clears an optional field
+	setOptional := func(target **string, value *string) {
+		if value == nil {
+			return
+		}
+		if *value == "" {
+			*target = nil
+			return
+		}
+		v := *value
+		*target = &v
+	}
+
+	setString(&req.LastName, patch.LastName)
+	setString(&req.FirstName, patch.FirstName)
+	setString(&req.LastNameKana, patch.LastNameKana)
+	setString(&req.FirstNameKana, patch.FirstNameKana)
+	setString(&req.Phone1, patch.Phone1)
+	setString(&req.Phone2, patch.Phone2)
+	setString(&req.Phone3, patch.Phone3)
+	setString(&req.PostalCode1, patch.PostalCode1)
+	setString(&req.PostalCode2, patch.PostalCode2)
+	setString(&req.Prefecture, patch.Prefecture)
+	setString(&req.City, patch.City)
+	setOptional(&req.Town, patch.Town)
+	setOptional(&req.Chome, patch.Chome)
+	setString(&req.Banchi, patch.Banchi)
+	setOptional(&req.Go, patch.Go)
+	setOptional(&req.Building, patch.Building)
+	setOptional(&req.Room, patch.Room)
+	setString(&req.PlanType, patch.PlanType)
+
+	// A new email must be confirmed just like on a full update
+	if patch.Email != nil {
+		req.Email = *patch.Email
+		req.EmailConfirm = stringValue(patch.EmailConfirm)
+	}
+
+	if patch.OptionTypes != nil {
+		req.OptionTypes = *patch.OptionTypes
+		for optionType := range req.OptionDetails {
+			if !slices.Contains(req.OptionTypes, optionType) {
+				delete(req.OptionDetails, optionType)
+			}
+		}
+	}
+	for optionType, detail := range patch.OptionDetails {
+		req.OptionDetails[optionType] = detail
+	}
+}
+
+// diffUserFields lists the editable fields whose values differ between current and proposed
+func diffUserFields(current, proposed *model.User) []dto.UserFieldChange {
+	changes := make([]dto.UserFieldChange, 0)
+
+	addString := func(field, currentValue, proposedValue string) {
+		if currentValue != proposedValue {
+			changes = append(changes, dto.UserFieldChange{Field: field, Current: currentValue, Proposed: proposedValue})
+		}
+	}
+	// Nil and empty optional fields are stored the same way, so they are not reported as changes
+	addOptional := func(field string, currentValue, proposedValue *string) {
+		if stringValue(currentValue) != stringValue(proposedValue) {
+			changes = append(changes, dto.UserFieldChange{Field: field, Current: currentValue, Proposed: proposedValue})
+		}
+	}
+
+	addString("last_name", current.LastName, proposed.LastName)
+	addString("first_name", current.FirstName, proposed.FirstName)
+	addString("last_name_kana", current.LastNameKana, proposed.LastNameKana)
+	addString("first_name_kana", current.FirstNameKana, proposed.FirstNameKana)
+	addString("phone1", current.Phone1, proposed.Phone1)
+	addString("phone2", current.Phone2, proposed.Phone2)
+	addString("phone3", current.Phone3, proposed.Phone3)
+	addString("postal_code1", current.PostalCode1, proposed.PostalCode1)
+	addString("postal_code2", current.PostalCode2, proposed.PostalCode2)
+	addString("prefecture", current.Prefecture, proposed.Prefecture)
+	addString("city", current.City, proposed.City)
+	addOptional("town", current.Town, proposed.Town)
+	addOptional("chome", current.Chome, proposed.Chome)
+	addString("banchi", current.Banchi, proposed.Banchi)
+	addOptional("go", current.Go, proposed.Go)
+	addOptional("building", current.Building, proposed.Building)
+	addOptional("room", current.Room, proposed.Room)
+	addString("email", current.Email, proposed.Email)
+	addString("plan_type", current.PlanType, proposed.PlanType)
+
+	return changes
+}
+
+// diffOptionTypes compares option selections ignoring order
+func diffOptionTypes(current, proposed []string) (dto.UserFieldChange, bool) {
+	currentSorted := slices.Sorted(slices.Values(current))
+	proposedSorted := slices.Sorted(slices.Values(proposed))
+	if currentSorted == nil {
+		currentSorted = []string{}
+	}
+	if proposedSorted == nil {
+		proposedSorted = []string{}
+	}
+
+	if slices.Equal(currentSorted, proposedSorted) {
+		return dto.UserFieldChange{}, false
+	}
+
+	return dto.UserFieldChange{Field: "option_types", Current: currentSorted, Proposed: proposedSorted}, true
+}
+
+// updateUserOptions applies the difference between the stored and requested options.
+// Kept options keep their row and created_at; call it inside the user update transaction.
+// OptionsChanged is raised when anything changes.
+func (s *userService) updateUserOptions(ctx context.Context, userID int, req *dto.UserCreateRequest) error {
+	existingOptions, err := s.userOptionRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get existing options: %w", err)
+	}
+
+	added, removed := optionTypeChanges(userOptionTypes(existingOptions), req.OptionTypes)
+
+	var updated []string
+	for _, existing := range existingOptions {
+		if !slices.Contains(req.OptionTypes, existing.OptionType) {
+			continue
+		}
+		proposed := newUserOption(userID, existing.OptionType, req.OptionDetails)
+		if sameOptionDetails(existing, proposed) {
+			continue
+		}
+		if err := s.userOptionRepo.UpdateDetails(ctx, proposed); err != nil {
+			return fmt.Errorf("failed to update option %s: %w", existing.OptionType, err)
+		}
+		updated = append(updated, existing.OptionType)
+	}
+
+	for _, optionType := range removed {
+		if err := s.userOptionRepo.DeleteByUserIDAndOptionType(ctx, userID, optionType); err != nil {
+			return fmt.Errorf("failed to delete option %s: %w", optionType, err)
+		}
 	}
 
-	// Create new options
-	if len(optionTypes) > 0 {
-		userOptions := make([]*model.UserOption, 0, len(optionTypes))
-		for _, optionType := range optionTypes {
-			userOptions = append(userOptions, &model.UserOption{
-				UserID:     userID,
-				OptionType: optionType,
-			})
+	if len(added) > 0 {
+		userOptions := make([]*model.UserOption, 0, len(added))
+		for _, optionType := range added {
+			userOptions = append(userOptions, newUserOption(userID, optionType, req.OptionDetails))
 		}
 
 		if err := s.userOptionRepo.CreateBatch(ctx, userOptions); err != nil {
@@ -367,5 +1071,122 @@ func (s *userService) updateUserOptions(ctx context.Context, userID int, optionT
 		}
 	}
 
-	return nil
+	if len(added) == 0 && len(removed) == 0 && len(updated) == 0 {
+		return nil
+	}
+
+	s.log.WithContext(ctx).
+		WithField("user_id", userID).
+		WithField("added", added).
+		WithField("removed", removed).
+		WithField("updated", updated).
+		Info("User options changed")
+
+	return s.domainEvents.Publish(ctx, domain.OptionsChanged{
+		UserID: userID, Added: added, Removed: removed, Updated: updated,
+	})
+}
+
+// optionTypeChanges returns the option types to insert and delete to turn current into
+// proposed. Duplicates in proposed are ignored.
+func optionTypeChanges(current, proposed []string) (added, removed []string) {
+	for _, optionType := range slices.Compact(slices.Sorted(slices.Values(proposed))) {
+		if !slices.Contains(current, optionType) {
+			added = append(added, optionType)
+		}
+	}
+	for _, optionType := range current {
+		if !slices.Contains(proposed, optionType) {
+			removed = append(removed, optionType)
+		}
+	}
+	return added, removed
+}
+
+// userOptionTypes lists the option types of stored options
+func userOptionTypes(options []*model.UserOption) []string {
+	optionTypes := make([]string, 0, len(options))
+	for _, option := range options {
+		optionTypes = append(optionTypes, option.OptionType)
+	}
+	return optionTypes
+}
+
+// newUserOption builds the stored option for optionType from the requested settings
+func newUserOption(userID int, optionType string, details map[string]dto.UserOptionDetail) *model.UserOption {
+	option := &model.UserOption{
+		UserID:     userID,
+		OptionType: optionType,
+		Quantity:   1,
+	}
+
+	detail := details[optionType]
+	if detail.Quantity > 0 {
+		option.Quantity = detail.Quantity
+	}
+	if detail.StartDate != nil && *detail.StartDate != "" {
+		// The format is checked by struct validation
+		if startDate, err := time.Parse(optionStartDateLayout, *detail.StartDate); err == nil {
+			option.StartDate = &startDate
+		}
+	}
+
+	return option
+}
+
+// sameOptionDetails reports whether two options have the same quantity and start date
+func sameOptionDetails(a, b *model.UserOption) bool {
+	return a.Quantity == b.Quantity && formatOptionStartDate(a.StartDate) == formatOptionStartDate(b.StartDate)
+}
+
+// diffOptionDetails reports changed settings of the options kept by an update
+func diffOptionDetails(
+	existing []*model.UserOption, userID int, req *dto.UserCreateRequest,
+) (dto.UserFieldChange, bool) {
+	var current, proposed []*model.UserOption
+	for _, option := range existing {
+		if !slices.Contains(req.OptionTypes, option.OptionType) {
+			continue
+		}
+		next := newUserOption(userID, option.OptionType, req.OptionDetails)
+		if sameOptionDetails(option, next) {
+			continue
+		}
+		current = append(current, option)
+		proposed = append(proposed, next)
+	}
+
+	if len(current) == 0 {
+		return dto.UserFieldChange{}, false
+	}
+
+	return dto.UserFieldChange{
+		Field:    "option_details",
+		Current:  userOptionSummaries(current),
+		Proposed: userOptionSummaries(proposed),
+	}, true
+}
+
+// userOptionSummaries converts stored options to their API representation
+func userOptionSummaries(options []*model.UserOption) []dto.UserOptionSummary {
+	summaries := make([]dto.UserOptionSummary, 0, len(options))
+	for _, option := range options {
+		summary := dto.UserOptionSummary{
+			OptionType: option.OptionType,
+			Quantity:   option.Quantity,
+		}
+		if startDate := formatOptionStartDate(option.StartDate); startDate != "" {
+			summary.StartDate = &startDate
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// formatOptionStartDate formats a start date, returning "" for no date
+func formatOptionStartDate(startDate *time.Time) string {
+	if startDate == nil {
+		return ""
+	}
+	return startDate.Format(optionStartDateLayout)
 }
@@ -0,0 +1,190 @@
+// Package service provides anonymous telemetry of validation failures at form stages.
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// Form stages validations are counted at besides the wizard steps
+const (
+	// ValidationStageValidate is POST /api/v1/users/validate
+	ValidationStageValidate = "validate"
+	// ValidationStageSubmit is POST /api/v1/users, counted when the registration is accepted or queued
+	ValidationStageSubmit = "submit"
+)
+
+// validationTelemetryFlushTimeout bounds the final flush when the telemetry stops
+const validationTelemetryFlushTimeout = 10 * time.Second
+
+// ValidationTelemetryConfig controls the counting of validation failures
+type ValidationTelemetryConfig struct {
+	Enabled       bool
+	FlushInterval time.Duration
+}
+
+// validationAttemptKey identifies the validations of a stage on a day
+type validationAttemptKey struct {
+	day   time.Time
+	stage string
+}
+
+// validationFailureKey identifies the errors of a field for a failure code
+type validationFailureKey struct {
+	validationAttemptKey
+	field string
+	code  string
+}
+
+// skipValidationTelemetryKey marks contexts whose validations are not counted
+type skipValidationTelemetryKey struct{}
+
+// withoutValidationTelemetry returns a context whose validations are not counted, for
+// validations that repeat one already counted, such as the queue creating a queued user
+func withoutValidationTelemetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipValidationTelemetryKey{}, true)
+}
+
+// ValidationTelemetry counts validations of registration data and their failures per UTC
+// day, form stage, field and failure code, so that product can see which rules reject users
+// most often. Only field names and failure codes are counted, never values. Counts are kept
+// in memory and added to the database periodically; counts not yet flushed are lost if the
+// process crashes.
+type ValidationTelemetry struct {
+	repo   repository.ValidationCountRepository
+	config ValidationTelemetryConfig
+	log    *logger.Logger
+
+	mu       sync.Mutex
+	attempts map[validationAttemptKey]*model.ValidationAttemptCount
+	failures map[validationFailureKey]*model.ValidationFailureCount
+}
+
+// NewValidationTelemetry creates a new validation telemetry
+func NewValidationTelemetry(
+	repo repository.ValidationCountRepository, config ValidationTelemetryConfig, log *logger.Logger,
+) *ValidationTelemetry {
+	return &ValidationTelemetry{
+		repo:     repo,
+		config:   config,
+		log:      log,
+		attempts: make(map[validationAttemptKey]*model.ValidationAttemptCount),
+		failures: make(map[validationFailureKey]*model.ValidationFailureCount),
+	}
+}
+
+// Enabled reports whether validations are counted
+func (t *ValidationTelemetry) Enabled() bool {
+	return t.config.Enabled
+}
+
+// Record counts a validation at a stage with the failure codes of its errors keyed by field;
+// no codes is a validation that passed
+func (t *ValidationTelemetry) Record(ctx context.Context, stage string, codes map[string]string) {
+	if !t.config.Enabled || ctx.Value(skipValidationTelemetryKey{}) != nil {
+		return
+	}
+
+	key := validationAttemptKey{day: time.Now().UTC().Truncate(24 * time.Hour), stage: stage}
+	attempt := &model.ValidationAttemptCount{Attempts: 1}
+	if len(codes) > 0 {
+		attempt.Failed = 1
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.addAttempts(key, attempt)
+	for field, code := range codes {
+		t.addFailures(validationFailureKey{validationAttemptKey: key, field: field, code: code}, 1)
+	}
+}
+
+// addAttempts adds validations to the counts of key; the caller holds mu
+func (t *ValidationTelemetry) addAttempts(key validationAttemptKey, count *model.ValidationAttemptCount) {
+	counts, ok := t.attempts[key]
+	if !ok {
+		counts = &model.ValidationAttemptCount{Day: key.day, Stage: key.stage}
+		t.attempts[key] = counts
+	}
+	counts.Attempts += count.Attempts
+	counts.Failed += count.Failed
+}
+
+// addFailures adds errors to the counts of key; the caller holds mu
+func (t *ValidationTelemetry) addFailures(key validationFailureKey, failures int64) {
+	counts, ok := t.failures[key]
+	if !ok {
+		counts = &model.ValidationFailureCount{Day: key.day, Stage: key.stage, Field: key.field, Code: key.code}
+		t.failures[key] = counts
+	}
+	counts.Failures += failures
+}
+
+// Run flushes counts every flush interval until the context is cancelled, then flushes
+// once more so a graceful shutdown loses nothing
+func (t *ValidationTelemetry) Run(ctx context.Context) {
+	t.log.WithField("flush_interval", t.config.FlushInterval).Info("Validation telemetry started")
+
+	ticker := time.NewTicker(t.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), validationTelemetryFlushTimeout)
+			if err := t.Flush(flushCtx); err != nil {
+				t.log.WithError(err).Error("Final validation telemetry flush failed")
+			}
+			cancel()
+			t.log.Info("Validation telemetry stopped")
+			return
+		case <-ticker.C:
+			if err := t.Flush(ctx); err != nil {
+				t.log.WithError(err).Error("Validation telemetry flush failed")
+			}
+		}
+	}
+}
+
+// Flush adds the counted validations to the database. Counts that fail to be written are
+// kept for the next flush.
+func (t *ValidationTelemetry) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	attempts, failures := t.attempts, t.failures
+	t.attempts = make(map[validationAttemptKey]*model.ValidationAttemptCount)
+	t.failures = make(map[validationFailureKey]*model.ValidationFailureCount)
+	t.mu.Unlock()
+
+	var failed int
+	var lastErr error
+	for key, count := range attempts {
+		if err := t.repo.AddAttempts(ctx, count); err != nil {
+			t.mu.Lock()
+			t.addAttempts(key, count)
+			t.mu.Unlock()
+			failed++
+			lastErr = err
+		}
+	}
+	for key, count := range failures {
+		if err := t.repo.AddFailures(ctx, count); err != nil {
+			t.mu.Lock()
+			t.addFailures(key, count.Failures)
+			t.mu.Unlock()
+			failed++
+			lastErr = err
+		}
+	}
+
+	if lastErr != nil {
+		return fmt.Errorf("failed to flush %d of %d validation counters: %w",
+			failed, len(attempts)+len(failures), lastErr)
+	}
+	return nil
+}
@@ -0,0 +1,147 @@
+// Package service provides administration of the webhook delivery queue.
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/apperr"
+	"github.com/octop162/normal-form-app-by-claude/internal/dto"
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/validator"
+)
+
+const (
+	// defaultWebhookDeliveryListLimit is the page size of admin webhook delivery listings
+	defaultWebhookDeliveryListLimit = 50
+)
+
+// WebhookDeliveryService defines the interface for inspecting and replaying webhook deliveries
+type WebhookDeliveryService interface {
+	ListDeliveries(
+		ctx context.Context, req *dto.AdminWebhookDeliveryListRequest,
+	) (*dto.AdminWebhookDeliveryListResponse, error)
+	ReplayDelivery(ctx context.Context, id int64, actorIP string) (*dto.AdminWebhookDeliveryResponse, error)
+}
+
+// webhookDeliveryService implements WebhookDeliveryService
+type webhookDeliveryService struct {
+	deliveryRepo repository.WebhookDeliveryRepository
+	auditLogRepo repository.AuditLogRepository
+	txManager    repository.TxManager
+	validator    *validator.CustomValidator
+	log          *logger.Logger
+}
+
+// NewWebhookDeliveryService creates a new webhook delivery service
+func NewWebhookDeliveryService(
+	deliveryRepo repository.WebhookDeliveryRepository,
+	auditLogRepo repository.AuditLogRepository,
+	txManager repository.TxManager,
+	validator *validator.CustomValidator,
+	log *logger.Logger,
+) WebhookDeliveryService {
+	return &webhookDeliveryService{
+		deliveryRepo: deliveryRepo,
+		auditLogRepo: auditLogRepo,
+		txManager:    txManager,
+		validator:    validator,
+		log:          log,
+	}
+}
+
+// ListDeliveries lists deliveries by status, dead letters by default
+func (s *webhookDeliveryService) ListDeliveries(
+	ctx context.Context, req *dto.AdminWebhookDeliveryListRequest,
+) (*dto.AdminWebhookDeliveryListResponse, error) {
+	if err := s.validator.ValidateStruct(req); err != nil {
+		return nil, apperr.Errorf(apperr.ErrValidation, "validation errors: %w", err)
+	}
+
+	status := req.Status
+	if status == "" {
+		status = model.WebhookDeliveryDead
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = defaultWebhookDeliveryListLimit
+	}
+
+	deliveries, err := s.deliveryRepo.ListByStatus(ctx, status, limit, req.Offset)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &dto.AdminWebhookDeliveryListResponse{
+		Deliveries: make([]*dto.AdminWebhookDeliveryResponse, 0, len(deliveries)),
+	}
+	for _, delivery := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, toAdminWebhookDeliveryResponse(delivery))
+	}
+	return resp, nil
+}
+
+// ReplayDelivery queues an undelivered delivery to be sent now with a fresh retry schedule
+func (s *webhookDeliveryService) ReplayDelivery(
+	ctx context.Context, id int64, actorIP string,
+) (*dto.AdminWebhookDeliveryResponse, error) {
+	var replayed *model.WebhookDelivery
+	err := s.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		delivery, err := s.deliveryRepo.GetByID(txCtx, id)
+		if err != nil {
+			return err
+		}
+
+		replayed, err = s.deliveryRepo.Requeue(txCtx, id)
+		if err != nil {
+			return err
+		}
+		if replayed == nil {
+			return apperr.Errorf(apperr.ErrValidation, "webhook delivery %d was already delivered", id)
+		}
+
+		details, err := json.Marshal(map[string]any{
+			"status": delivery.Status, "attempts": delivery.Attempts, "last_error": delivery.LastError,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit details: %w", err)
+		}
+		_, err = s.auditLogRepo.Create(txCtx, newAdminAuditLog(
+			txCtx, "webhook_delivery.replayed", "webhook_delivery", strconv.FormatInt(id, 10), details, actorIP,
+		))
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay webhook delivery: %w", err)
+	}
+
+	s.log.WithField("delivery_id", id).WithField("event_type", replayed.EventType).Info("Webhook delivery replayed")
+	return toAdminWebhookDeliveryResponse(replayed), nil
+}
+
+// toAdminWebhookDeliveryResponse converts a delivery to its admin view
+func toAdminWebhookDeliveryResponse(delivery *model.WebhookDelivery) *dto.AdminWebhookDeliveryResponse {
+	resp := &dto.AdminWebhookDeliveryResponse{
+		ID:            delivery.ID,
+		EventID:       delivery.OutboxEventID,
+		EventType:     delivery.EventType,
+		CorrelationID: delivery.CorrelationID,
+		Payload:       delivery.Payload,
+		OccurredAt:    delivery.OccurredAt,
+		Status:        delivery.Status,
+		Attempts:      delivery.Attempts,
+		LastError:     delivery.LastError,
+		DeliveredAt:   delivery.DeliveredAt,
+		DeadAt:        delivery.DeadAt,
+		CreatedAt:     delivery.CreatedAt,
+	}
+	if delivery.Status == model.WebhookDeliveryPending {
+		nextAttemptAt := delivery.NextAttemptAt
+		resp.NextAttemptAt = &nextAttemptAt
+	}
+	return resp
+}
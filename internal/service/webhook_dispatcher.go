@@ -0,0 +1,157 @@
+// Package service provides the dispatcher that sends queued webhook deliveries.
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/internal/model"
+	"github.com/octop162/normal-form-app-by-claude/internal/repository"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"github.com/octop162/normal-form-app-by-claude/pkg/webhook"
+)
+
+// WebhookDispatcherConfig holds webhook dispatcher settings
+type WebhookDispatcherConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// RetrySchedule is the delay before each retry. A delivery that fails once more than
+	// the schedule has entries is dead-lettered.
+	RetrySchedule []time.Duration
+}
+
+// WebhookDispatcher polls the webhook delivery queue and sends due deliveries, so a slow
+// or failing endpoint never holds up the request or the outbox relay.
+// Delivery is at-least-once: consumers should deduplicate by the X-Webhook-ID header.
+type WebhookDispatcher struct {
+	txManager    repository.TxManager
+	deliveryRepo repository.WebhookDeliveryRepository
+	sender       *webhook.Sender
+	config       WebhookDispatcherConfig
+	log          *logger.Logger
+}
+
+// NewWebhookDispatcher creates a new webhook dispatcher
+func NewWebhookDispatcher(
+	txManager repository.TxManager,
+	deliveryRepo repository.WebhookDeliveryRepository,
+	sender *webhook.Sender,
+	config WebhookDispatcherConfig,
+	log *logger.Logger,
+) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		txManager:    txManager,
+		deliveryRepo: deliveryRepo,
+		sender:       sender,
+		config:       config,
+		log:          log,
+	}
+}
+
+// Run sends due deliveries until the context is cancelled
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	d.log.WithField("poll_interval", d.config.PollInterval).Info("Webhook dispatcher started")
+
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the backlog before waiting for the next tick
+		for {
+			processed, err := d.ProcessBatch(ctx)
+			if err != nil {
+				d.log.WithError(err).Error("Webhook dispatch batch failed")
+				break
+			}
+			if processed < d.config.BatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			d.log.Info("Webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch claims and sends one batch of deliveries, returning the number processed
+func (d *WebhookDispatcher) ProcessBatch(ctx context.Context) (int, error) {
+	processed := 0
+
+	err := d.txManager.WithinTransaction(ctx, func(txCtx context.Context) error {
+		deliveries, err := d.deliveryRepo.ClaimDue(txCtx, d.config.BatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, delivery := range deliveries {
+			if err := d.deliver(txCtx, delivery); err != nil {
+				return err
+			}
+			processed++
+		}
+		return nil
+	})
+	if err != nil {
+		return processed, fmt.Errorf("failed to process webhook delivery batch: %w", err)
+	}
+
+	return processed, nil
+}
+
+// deliver sends a single delivery and records the outcome
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery *model.WebhookDelivery) error {
+	sendErr := d.sender.Send(ctx, &webhook.Message{
+		ID:            strconv.FormatInt(delivery.OutboxEventID, 10),
+		Type:          delivery.EventType,
+		CorrelationID: stringValue(delivery.CorrelationID),
+		OccurredAt:    delivery.OccurredAt,
+		Payload:       delivery.Payload,
+	})
+	if sendErr == nil {
+		return d.deliveryRepo.MarkDelivered(ctx, delivery.ID)
+	}
+
+	attempts := delivery.Attempts + 1
+	dead := attempts > len(d.config.RetrySchedule)
+	nextAttemptAt := time.Now()
+	if !dead {
+		nextAttemptAt = nextAttemptAt.Add(d.config.RetrySchedule[attempts-1])
+	}
+
+	entry := d.log.WithError(sendErr).
+		WithField("delivery_id", delivery.ID).
+		WithField("event_id", delivery.OutboxEventID).
+		WithField("event_type", delivery.EventType).
+		WithField("correlation_id", stringValue(delivery.CorrelationID)).
+		WithField("attempts", attempts)
+	if dead {
+		entry.Error("Webhook delivery failed permanently, moved to dead letters")
+	} else {
+		entry.WithField("next_attempt_at", nextAttemptAt).Warn("Webhook delivery failed, will retry")
+	}
+
+	return d.deliveryRepo.MarkFailed(ctx, delivery.ID, sendErr.Error(), nextAttemptAt, dead)
+}
+
+// webhookQueuePublisher queues outbox events for the webhook dispatcher
+type webhookQueuePublisher struct {
+	deliveryRepo repository.WebhookDeliveryRepository
+}
+
+// NewWebhookQueuePublisher creates a publisher that queues events for the webhook endpoint.
+// The relay publishes within its transaction, so the event is queued exactly when it is
+// marked published.
+func NewWebhookQueuePublisher(deliveryRepo repository.WebhookDeliveryRepository) OutboxPublisher {
+	return &webhookQueuePublisher{deliveryRepo: deliveryRepo}
+}
+
+// Publish queues the event
+func (p *webhookQueuePublisher) Publish(ctx context.Context, event *model.OutboxEvent) error {
+	return p.deliveryRepo.Enqueue(ctx, event)
+}
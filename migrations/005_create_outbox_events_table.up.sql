@@ -0,0 +1,35 @@
+-- Create outbox_events table for reliable event publishing (transactional outbox)
+CREATE TABLE outbox_events (
+    id BIGSERIAL PRIMARY KEY,
+    aggregate_type VARCHAR(50) NOT NULL,
+    aggregate_id VARCHAR(100) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    created_at TIMESTAMP DEFAULT NOW(),
+    published_at TIMESTAMP
+);
+
+-- Create indexes
+CREATE INDEX idx_outbox_events_pending ON outbox_events(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_outbox_events_aggregate ON outbox_events(aggregate_type, aggregate_id);
+CREATE INDEX idx_outbox_events_created_at ON outbox_events(created_at);
+
+-- Add constraints
+ALTER TABLE outbox_events ADD CONSTRAINT chk_outbox_events_status
+    CHECK (status IN ('pending', 'published', 'failed'));
+
+-- Add comments
+COMMENT ON TABLE outbox_events IS 'Transactional outbox for domain events awaiting delivery';
+COMMENT ON COLUMN outbox_events.aggregate_type IS 'Type of the entity the event belongs to (e.g. user)';
+COMMENT ON COLUMN outbox_events.aggregate_id IS 'Identifier of the entity the event belongs to';
+COMMENT ON COLUMN outbox_events.event_type IS 'Event type (e.g. user.created)';
+COMMENT ON COLUMN outbox_events.payload IS 'JSON event payload';
+COMMENT ON COLUMN outbox_events.status IS 'Delivery status: pending, published, failed';
+COMMENT ON COLUMN outbox_events.attempts IS 'Number of delivery attempts';
+COMMENT ON COLUMN outbox_events.last_error IS 'Last delivery error';
+COMMENT ON COLUMN outbox_events.next_attempt_at IS 'Earliest time of the next delivery attempt';
+COMMENT ON COLUMN outbox_events.published_at IS 'Delivery timestamp';
@@ -0,0 +1,3 @@
+-- Drop correlation_id from outbox_events
+DROP INDEX IF EXISTS idx_outbox_events_correlation_id;
+ALTER TABLE outbox_events DROP COLUMN IF EXISTS correlation_id;
@@ -0,0 +1,8 @@
+-- Record the correlation ID of the request that produced each outbox event
+ALTER TABLE outbox_events ADD COLUMN correlation_id VARCHAR(128);
+
+-- Create indexes
+CREATE INDEX idx_outbox_events_correlation_id ON outbox_events(correlation_id) WHERE correlation_id IS NOT NULL;
+
+-- Add comments
+COMMENT ON COLUMN outbox_events.correlation_id IS 'X-Correlation-ID of the originating request, forwarded to consumers';
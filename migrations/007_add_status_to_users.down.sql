@@ -0,0 +1,4 @@
+-- Drop status from users
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_status;
+DROP INDEX IF EXISTS idx_users_status;
+ALTER TABLE users DROP COLUMN IF EXISTS status;
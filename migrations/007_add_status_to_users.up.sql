@@ -0,0 +1,12 @@
+-- Add account status to users so admins can suspend and reactivate accounts
+ALTER TABLE users ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'active';
+
+-- Create indexes
+CREATE INDEX idx_users_status ON users(status);
+
+-- Add constraints
+ALTER TABLE users ADD CONSTRAINT chk_users_status
+    CHECK (status IN ('active', 'suspended'));
+
+-- Add comments
+COMMENT ON COLUMN users.status IS 'Account status: active or suspended';
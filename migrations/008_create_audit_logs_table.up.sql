@@ -0,0 +1,27 @@
+-- Create audit_logs table recording administrative changes
+CREATE TABLE audit_logs (
+    id BIGSERIAL PRIMARY KEY,
+    actor VARCHAR(100) NOT NULL,
+    actor_ip VARCHAR(45),
+    action VARCHAR(100) NOT NULL,
+    resource_type VARCHAR(50) NOT NULL,
+    resource_id VARCHAR(100) NOT NULL,
+    details JSONB,
+    correlation_id VARCHAR(128),
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_audit_logs_resource ON audit_logs(resource_type, resource_id);
+CREATE INDEX idx_audit_logs_created_at ON audit_logs(created_at);
+CREATE INDEX idx_audit_logs_correlation_id ON audit_logs(correlation_id) WHERE correlation_id IS NOT NULL;
+
+-- Add comments
+COMMENT ON TABLE audit_logs IS 'Append-only log of administrative changes';
+COMMENT ON COLUMN audit_logs.actor IS 'Who made the change (e.g. admin_api)';
+COMMENT ON COLUMN audit_logs.actor_ip IS 'Client IP of the actor';
+COMMENT ON COLUMN audit_logs.action IS 'Action performed (e.g. user.suspend)';
+COMMENT ON COLUMN audit_logs.resource_type IS 'Type of the changed entity (e.g. user)';
+COMMENT ON COLUMN audit_logs.resource_id IS 'Identifier of the changed entity';
+COMMENT ON COLUMN audit_logs.details IS 'JSON details of the change';
+COMMENT ON COLUMN audit_logs.correlation_id IS 'X-Correlation-ID of the originating request';
@@ -0,0 +1,4 @@
+-- Drop deletion_records table and legal_hold from users
+DROP TABLE IF EXISTS deletion_records;
+DROP INDEX IF EXISTS idx_users_legal_hold;
+ALTER TABLE users DROP COLUMN IF EXISTS legal_hold;
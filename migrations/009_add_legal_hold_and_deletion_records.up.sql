@@ -0,0 +1,28 @@
+-- Flag users whose data must be preserved (legal hold blocks deletion and anonymization)
+ALTER TABLE users ADD COLUMN legal_hold BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Create deletion_records table keeping a minimal tombstone of hard-deleted users
+CREATE TABLE deletion_records (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL,
+    email_hash CHAR(64) NOT NULL,
+    reason TEXT,
+    operator VARCHAR(100) NOT NULL,
+    deleted_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    retain_until TIMESTAMP NOT NULL
+);
+
+-- Create indexes
+CREATE INDEX idx_users_legal_hold ON users(id) WHERE legal_hold;
+CREATE INDEX idx_deletion_records_user_id ON deletion_records(user_id);
+CREATE INDEX idx_deletion_records_email_hash ON deletion_records(email_hash);
+CREATE INDEX idx_deletion_records_retain_until ON deletion_records(retain_until);
+
+-- Add comments
+COMMENT ON COLUMN users.legal_hold IS 'When true the user cannot be deleted or anonymized';
+COMMENT ON TABLE deletion_records IS 'Tombstones of hard-deleted users, purged after retain_until';
+COMMENT ON COLUMN deletion_records.user_id IS 'ID of the deleted user';
+COMMENT ON COLUMN deletion_records.email_hash IS 'Hex encoded (HMAC-)SHA-256 of the normalized email';
+COMMENT ON COLUMN deletion_records.reason IS 'Reason given for the deletion';
+COMMENT ON COLUMN deletion_records.operator IS 'Who performed the deletion (e.g. user_api, admin_api)';
+COMMENT ON COLUMN deletion_records.retain_until IS 'The record is purged after this time';
@@ -0,0 +1,2 @@
+-- Drop user_lookups table
+DROP TABLE IF EXISTS user_lookups;
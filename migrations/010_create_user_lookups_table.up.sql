@@ -0,0 +1,22 @@
+-- Create user_lookups table holding email verification codes for self-service lookups
+CREATE TABLE user_lookups (
+    id VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash CHAR(64) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    verified_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_user_lookups_user_id ON user_lookups(user_id);
+CREATE INDEX idx_user_lookups_expires_at ON user_lookups(expires_at);
+
+-- Add comments
+COMMENT ON TABLE user_lookups IS 'Pending self-service registration lookups awaiting email verification';
+COMMENT ON COLUMN user_lookups.id IS 'Random lookup identifier returned to the client';
+COMMENT ON COLUMN user_lookups.code_hash IS 'SHA-256 of the verification code sent by email';
+COMMENT ON COLUMN user_lookups.attempts IS 'Number of failed verification attempts';
+COMMENT ON COLUMN user_lookups.expires_at IS 'The code cannot be used after this time';
+COMMENT ON COLUMN user_lookups.verified_at IS 'Set when the code was verified; codes are single use';
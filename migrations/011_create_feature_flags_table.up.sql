@@ -0,0 +1,13 @@
+-- Create feature_flags table holding runtime overrides of configured feature flags
+CREATE TABLE feature_flags (
+    name VARCHAR(100) PRIMARY KEY,
+    enabled BOOLEAN NOT NULL,
+    reason VARCHAR(255),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Add comments
+COMMENT ON TABLE feature_flags IS 'Runtime overrides of feature flags; flags without a row use the configured default';
+COMMENT ON COLUMN feature_flags.name IS 'Feature flag name (e.g. skip_inventory_check)';
+COMMENT ON COLUMN feature_flags.enabled IS 'Overridden flag value';
+COMMENT ON COLUMN feature_flags.reason IS 'Why the override was set';
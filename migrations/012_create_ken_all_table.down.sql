@@ -0,0 +1,2 @@
+-- Drop ken_all table
+DROP TABLE IF EXISTS ken_all;
@@ -0,0 +1,22 @@
+-- Create ken_all table holding the Japan Post postal code master (KEN_ALL.CSV)
+CREATE TABLE ken_all (
+    id SERIAL PRIMARY KEY,
+    local_government_code VARCHAR(6) NOT NULL,
+    postal_code CHAR(7) NOT NULL,
+    prefecture_kana VARCHAR(50) NOT NULL,
+    city_kana VARCHAR(100) NOT NULL,
+    town_kana VARCHAR(200) NOT NULL,
+    prefecture VARCHAR(10) NOT NULL,
+    city VARCHAR(50) NOT NULL,
+    town VARCHAR(200) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_ken_all_postal_code ON ken_all(postal_code);
+
+-- Add comments
+COMMENT ON TABLE ken_all IS 'Japan Post postal code master loaded by cmd/import-postal';
+COMMENT ON COLUMN ken_all.local_government_code IS 'National local government code (全国地方公共団体コード)';
+COMMENT ON COLUMN ken_all.postal_code IS '7-digit postal code without hyphen';
+COMMENT ON COLUMN ken_all.town IS 'Town name; empty when the postal code covers the whole municipality';
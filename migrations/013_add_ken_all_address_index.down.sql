@@ -0,0 +1,2 @@
+-- Drop ken_all address index
+DROP INDEX IF EXISTS idx_ken_all_prefecture_city;
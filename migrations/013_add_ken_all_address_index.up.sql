@@ -0,0 +1,2 @@
+-- Support postal code search by prefecture and city
+CREATE INDEX idx_ken_all_prefecture_city ON ken_all(prefecture, city);
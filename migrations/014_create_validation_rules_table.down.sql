@@ -0,0 +1,2 @@
+-- Drop validation_rules table
+DROP TABLE IF EXISTS validation_rules;
@@ -0,0 +1,18 @@
+-- Create validation_rules table holding configurable per-field form validation rules
+CREATE TABLE validation_rules (
+    field_name VARCHAR(50) PRIMARY KEY,
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    max_length INTEGER CHECK (max_length IS NULL OR max_length > 0),
+    pattern TEXT,
+    forbidden_chars VARCHAR(100),
+    message VARCHAR(255),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Add comments
+COMMENT ON TABLE validation_rules IS 'Per-field validation rules checked in addition to the built-in limits; reloaded via the admin API';
+COMMENT ON COLUMN validation_rules.field_name IS 'Form field name as sent in the request (e.g. last_name)';
+COMMENT ON COLUMN validation_rules.max_length IS 'Maximum length in characters';
+COMMENT ON COLUMN validation_rules.pattern IS 'Regular expression the whole value must match';
+COMMENT ON COLUMN validation_rules.forbidden_chars IS 'Characters the value must not contain';
+COMMENT ON COLUMN validation_rules.message IS 'Error message replacing the default one';
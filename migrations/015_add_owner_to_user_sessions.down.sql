@@ -0,0 +1,7 @@
+-- Remove session owner columns
+DROP INDEX IF EXISTS idx_user_sessions_email;
+DROP INDEX IF EXISTS idx_user_sessions_client_ip;
+
+ALTER TABLE user_sessions
+    DROP COLUMN IF EXISTS email,
+    DROP COLUMN IF EXISTS client_ip;
@@ -0,0 +1,12 @@
+-- Record who created each session so concurrent drafts can be limited per IP and email
+ALTER TABLE user_sessions
+    ADD COLUMN client_ip VARCHAR(45),
+    ADD COLUMN email VARCHAR(256);
+
+-- Create indexes
+CREATE INDEX idx_user_sessions_client_ip ON user_sessions(client_ip, expires_at) WHERE client_ip IS NOT NULL;
+CREATE INDEX idx_user_sessions_email ON user_sessions(email, expires_at) WHERE email IS NOT NULL;
+
+-- Add comments
+COMMENT ON COLUMN user_sessions.client_ip IS 'Client IP the session was created from';
+COMMENT ON COLUMN user_sessions.email IS 'Lower-cased email address entered in the form, if any';
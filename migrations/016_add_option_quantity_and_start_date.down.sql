@@ -0,0 +1,8 @@
+-- Remove option quantity and start date
+ALTER TABLE options_master DROP CONSTRAINT IF EXISTS chk_options_master_max_quantity;
+ALTER TABLE options_master DROP COLUMN IF EXISTS max_quantity;
+
+ALTER TABLE user_options DROP CONSTRAINT IF EXISTS chk_user_options_quantity;
+ALTER TABLE user_options
+    DROP COLUMN IF EXISTS start_date,
+    DROP COLUMN IF EXISTS quantity;
@@ -0,0 +1,22 @@
+-- Let each selected option carry a quantity and a desired start date
+ALTER TABLE user_options
+    ADD COLUMN quantity INTEGER NOT NULL DEFAULT 1,
+    ADD COLUMN start_date DATE;
+
+ALTER TABLE user_options ADD CONSTRAINT chk_user_options_quantity
+    CHECK (quantity > 0);
+
+-- Limit how many units of each option a user can order
+ALTER TABLE options_master
+    ADD COLUMN max_quantity INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE options_master ADD CONSTRAINT chk_options_master_max_quantity
+    CHECK (max_quantity > 0);
+
+UPDATE options_master SET max_quantity = 3 WHERE option_type IN ('AA', 'BB');
+UPDATE options_master SET max_quantity = 5 WHERE option_type = 'AB';
+
+-- Add comments
+COMMENT ON COLUMN user_options.quantity IS 'Number of units ordered';
+COMMENT ON COLUMN user_options.start_date IS 'Date the user wants the option to start; NULL means as soon as possible';
+COMMENT ON COLUMN options_master.max_quantity IS 'Maximum number of units a user can order';
@@ -0,0 +1,7 @@
+-- Remove option validity period
+DROP INDEX IF EXISTS idx_options_master_validity;
+
+ALTER TABLE options_master DROP CONSTRAINT IF EXISTS chk_options_master_validity;
+ALTER TABLE options_master
+    DROP COLUMN IF EXISTS valid_until,
+    DROP COLUMN IF EXISTS valid_from;
@@ -0,0 +1,14 @@
+-- Let options be scheduled to appear and disappear automatically
+ALTER TABLE options_master
+    ADD COLUMN valid_from TIMESTAMP,
+    ADD COLUMN valid_until TIMESTAMP;
+
+ALTER TABLE options_master ADD CONSTRAINT chk_options_master_validity
+    CHECK (valid_from IS NULL OR valid_until IS NULL OR valid_from < valid_until);
+
+-- Create indexes
+CREATE INDEX idx_options_master_validity ON options_master(valid_from, valid_until) WHERE is_active = TRUE;
+
+-- Add comments
+COMMENT ON COLUMN options_master.valid_from IS 'Time the option becomes available; NULL means no start limit';
+COMMENT ON COLUMN options_master.valid_until IS 'Time the option stops being available (exclusive); NULL means no end limit';
@@ -0,0 +1,3 @@
+-- Remove session version column
+ALTER TABLE user_sessions
+    DROP COLUMN IF EXISTS version;
@@ -0,0 +1,6 @@
+-- Count saves so concurrent autosaves can detect that the draft changed underneath them
+ALTER TABLE user_sessions
+    ADD COLUMN version INT NOT NULL DEFAULT 1;
+
+-- Add comments
+COMMENT ON COLUMN user_sessions.version IS 'Incremented on every save; used for optimistic concurrency';
@@ -0,0 +1,3 @@
+-- Remove wizard state column
+ALTER TABLE user_sessions
+    DROP COLUMN IF EXISTS wizard_state;
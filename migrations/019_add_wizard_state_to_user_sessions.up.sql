@@ -0,0 +1,6 @@
+-- Track the validation status of each form wizard step
+ALTER TABLE user_sessions
+    ADD COLUMN wizard_state JSONB NOT NULL DEFAULT '{}';
+
+-- Add comments
+COMMENT ON COLUMN user_sessions.wizard_state IS 'Validation status of each completed wizard step';
@@ -0,0 +1,3 @@
+-- Remove duplicate registration check indexes
+DROP INDEX IF EXISTS idx_users_postal_code_name_kana;
+DROP INDEX IF EXISTS idx_users_phone;
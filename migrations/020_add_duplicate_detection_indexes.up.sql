@@ -0,0 +1,4 @@
+-- Support duplicate registration checks on phone number and on name and address
+CREATE INDEX idx_users_phone ON users ((phone1 || phone2 || phone3));
+CREATE INDEX idx_users_postal_code_name_kana
+    ON users (postal_code1, postal_code2, last_name_kana, first_name_kana);
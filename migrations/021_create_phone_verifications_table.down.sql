@@ -0,0 +1,3 @@
+-- Drop phone_verifications table and the verified flag
+DROP TABLE IF EXISTS phone_verifications;
+ALTER TABLE users DROP COLUMN IF EXISTS phone_verified;
@@ -0,0 +1,27 @@
+-- Flag users whose phone number was confirmed with an SMS code
+ALTER TABLE users ADD COLUMN phone_verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Create phone_verifications table holding SMS verification codes
+CREATE TABLE phone_verifications (
+    id VARCHAR(64) PRIMARY KEY,
+    phone VARCHAR(20) NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP NOT NULL,
+    verified_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_phone_verifications_phone_created_at ON phone_verifications(phone, created_at);
+CREATE INDEX idx_phone_verifications_expires_at ON phone_verifications(expires_at);
+
+-- Add comments
+COMMENT ON COLUMN users.phone_verified IS 'True once the phone number was confirmed by SMS; cleared when the number changes';
+COMMENT ON TABLE phone_verifications IS 'SMS verification codes sent to phone numbers';
+COMMENT ON COLUMN phone_verifications.id IS 'Random verification identifier returned to the client';
+COMMENT ON COLUMN phone_verifications.phone IS 'Phone number as digits only (phone1 || phone2 || phone3)';
+COMMENT ON COLUMN phone_verifications.code_hash IS 'SHA-256 of the verification code sent by SMS';
+COMMENT ON COLUMN phone_verifications.attempts IS 'Number of failed verification attempts';
+COMMENT ON COLUMN phone_verifications.expires_at IS 'The code cannot be used after this time';
+COMMENT ON COLUMN phone_verifications.verified_at IS 'Set when the code was verified; codes are single use';
@@ -0,0 +1,42 @@
+-- Create attachments table holding uploaded files and their malware scan status
+CREATE TABLE attachments (
+    id VARCHAR(64) PRIMARY KEY,
+    session_id VARCHAR(255) REFERENCES user_sessions(id) ON DELETE CASCADE,
+    storage_key VARCHAR(255) NOT NULL,
+    filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    sha256 CHAR(64) NOT NULL,
+    scan_status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    scan_signature VARCHAR(255),
+    scan_attempts INTEGER NOT NULL DEFAULT 0,
+    scan_error TEXT,
+    next_scan_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    scanned_at TIMESTAMP,
+    quarantined_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_attachments_pending ON attachments(next_scan_at) WHERE scan_status = 'pending';
+CREATE INDEX idx_attachments_session_id ON attachments(session_id) WHERE session_id IS NOT NULL;
+CREATE INDEX idx_attachments_scan_status_created_at ON attachments(scan_status, created_at);
+
+-- Add constraints
+ALTER TABLE attachments ADD CONSTRAINT chk_attachments_scan_status
+    CHECK (scan_status IN ('pending', 'clean', 'infected'));
+
+-- Add comments
+COMMENT ON TABLE attachments IS 'Uploaded files; only clean files can be linked to sessions';
+COMMENT ON COLUMN attachments.id IS 'Random attachment identifier returned to the client';
+COMMENT ON COLUMN attachments.session_id IS 'Form session the attachment is linked to, set once the scan is clean';
+COMMENT ON COLUMN attachments.storage_key IS 'Key of the content in upload storage, or in quarantine storage when infected';
+COMMENT ON COLUMN attachments.filename IS 'File name supplied by the client';
+COMMENT ON COLUMN attachments.content_type IS 'Content type sniffed from the file content';
+COMMENT ON COLUMN attachments.sha256 IS 'SHA-256 of the stored content';
+COMMENT ON COLUMN attachments.scan_status IS 'Malware scan status: pending, clean, infected';
+COMMENT ON COLUMN attachments.scan_signature IS 'Malware signature reported by the scanner';
+COMMENT ON COLUMN attachments.scan_attempts IS 'Number of failed scan attempts';
+COMMENT ON COLUMN attachments.scan_error IS 'Last scan error';
+COMMENT ON COLUMN attachments.next_scan_at IS 'Earliest time of the next scan attempt';
+COMMENT ON COLUMN attachments.quarantined_at IS 'Time the infected content was moved to quarantine';
@@ -0,0 +1,2 @@
+-- Drop option_reservations table
+DROP TABLE IF EXISTS option_reservations;
@@ -0,0 +1,27 @@
+-- Create option_reservations table holding stock for form sessions until registration
+CREATE TABLE option_reservations (
+    id SERIAL PRIMARY KEY,
+    session_id VARCHAR(255) NOT NULL REFERENCES user_sessions(id) ON DELETE CASCADE,
+    option_type VARCHAR(10) NOT NULL,
+    quantity INTEGER NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'held',
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    CONSTRAINT chk_option_reservations_option_type CHECK (option_type IN ('AA', 'BB', 'AB')),
+    CONSTRAINT chk_option_reservations_quantity CHECK (quantity > 0),
+    CONSTRAINT chk_option_reservations_status CHECK (status IN ('held', 'consumed', 'released'))
+);
+
+-- Create indexes
+CREATE INDEX idx_option_reservations_session_id ON option_reservations(session_id);
+CREATE INDEX idx_option_reservations_held ON option_reservations(option_type, expires_at) WHERE status = 'held';
+
+-- Add comments
+COMMENT ON TABLE option_reservations IS 'Temporary option stock holds placed by form sessions';
+COMMENT ON COLUMN option_reservations.session_id IS 'Form session holding the stock; holds are deleted with the session';
+COMMENT ON COLUMN option_reservations.quantity IS 'Number of units held';
+COMMENT ON COLUMN option_reservations.status IS 'held until registration (consumed) or expiry (released)';
+COMMENT ON COLUMN option_reservations.user_id IS 'User whose registration consumed the hold';
+COMMENT ON COLUMN option_reservations.expires_at IS 'The hold no longer counts against stock after this time';
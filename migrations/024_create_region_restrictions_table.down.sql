@@ -0,0 +1,2 @@
+-- Drop region_restrictions table
+DROP TABLE IF EXISTS region_restrictions;
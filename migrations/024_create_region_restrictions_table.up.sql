@@ -0,0 +1,28 @@
+-- Create region_restrictions table holding where options may be offered when the region API is unavailable
+CREATE TABLE region_restrictions (
+    id SERIAL PRIMARY KEY,
+    prefecture VARCHAR(10) NOT NULL,
+    city VARCHAR(50) NOT NULL DEFAULT '',
+    option_type VARCHAR(10) NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    note VARCHAR(255),
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    CONSTRAINT uq_region_restrictions_region_option UNIQUE (prefecture, city, option_type)
+);
+
+-- Create indexes
+CREATE INDEX idx_region_restrictions_prefecture ON region_restrictions(prefecture);
+
+-- Add comments
+COMMENT ON TABLE region_restrictions IS 'Prefecture/city/option matrix used as the fallback for the external region API; managed via the admin API';
+COMMENT ON COLUMN region_restrictions.city IS 'City the entry applies to; an empty string applies to the whole prefecture';
+COMMENT ON COLUMN region_restrictions.allowed IS 'Whether the option may be offered; a city entry overrides the prefecture entry, and options without an entry are allowed';
+COMMENT ON COLUMN region_restrictions.note IS 'Reason for the restriction, shown to administrators';
+
+-- Carry over the restrictions previously built into the application
+INSERT INTO region_restrictions (prefecture, option_type, allowed, note) VALUES
+('北海道', 'AA', FALSE, 'AAオプションは北海道では提供不可'),
+('東京都', 'BB', FALSE, 'BBオプションは大都市圏では提供不可'),
+('大阪府', 'BB', FALSE, 'BBオプションは大都市圏では提供不可'),
+('愛知県', 'BB', FALSE, 'BBオプションは大都市圏では提供不可');
@@ -0,0 +1,24 @@
+-- Create email_templates table holding versions of email templates edited via the admin API
+CREATE TABLE email_templates (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL,
+    version INTEGER NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT FALSE,
+    note VARCHAR(255),
+    created_at TIMESTAMP DEFAULT NOW(),
+    CONSTRAINT uq_email_templates_name_version UNIQUE (name, version),
+    CONSTRAINT chk_email_templates_version CHECK (version > 0)
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX idx_email_templates_active ON email_templates(name) WHERE is_active;
+
+-- Add comments
+COMMENT ON TABLE email_templates IS 'Versions of email templates; templates without an active version use the one built into the application';
+COMMENT ON COLUMN email_templates.name IS 'Template name, one of the built-in templates (e.g. lookup_code)';
+COMMENT ON COLUMN email_templates.version IS 'Version number per template, starting at 1; versions are never modified';
+COMMENT ON COLUMN email_templates.subject IS 'Subject line as a Go text template';
+COMMENT ON COLUMN email_templates.body IS 'Plain-text body as a Go text template';
+COMMENT ON COLUMN email_templates.is_active IS 'Whether this version is sent; at most one version per template is active';
@@ -0,0 +1,7 @@
+-- Restore the fixed list of plans and drop plans_master table
+ALTER TABLE users DROP CONSTRAINT IF EXISTS fk_users_plan_type;
+ALTER TABLE users ADD CONSTRAINT chk_users_plan_type
+    CHECK (plan_type IN ('A', 'B'));
+COMMENT ON COLUMN users.plan_type IS 'Plan type: A or B';
+
+DROP TABLE IF EXISTS plans_master;
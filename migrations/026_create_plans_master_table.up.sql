@@ -0,0 +1,32 @@
+-- Create plans_master table so plans can be launched and retired without a release
+CREATE TABLE plans_master (
+    id SERIAL PRIMARY KEY,
+    plan_type VARCHAR(10) NOT NULL UNIQUE,
+    plan_name VARCHAR(100) NOT NULL,
+    description TEXT,
+    display_order INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Carry over the plans previously built into the application
+INSERT INTO plans_master (plan_type, plan_name, description, display_order) VALUES
+('A', 'Aプラン', '基本プランです。標準的なサービスをご利用いただけます。', 10),
+('B', 'Bプラン', 'プレミアムプランです。より充実したサービスをご利用いただけます。', 20);
+
+-- Users reference the master instead of a fixed list of plans
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_plan_type;
+ALTER TABLE users ADD CONSTRAINT fk_users_plan_type
+    FOREIGN KEY (plan_type) REFERENCES plans_master(plan_type);
+
+-- Create indexes
+CREATE INDEX idx_plans_master_display_order ON plans_master(display_order, plan_type) WHERE is_active = TRUE;
+
+-- Add comments
+COMMENT ON TABLE plans_master IS 'Master data for plans; managed via the admin API';
+COMMENT ON COLUMN plans_master.plan_type IS 'Plan type identifier stored in users.plan_type';
+COMMENT ON COLUMN plans_master.plan_name IS 'Display name for the plan';
+COMMENT ON COLUMN plans_master.display_order IS 'Position in the plan list, ascending';
+COMMENT ON COLUMN plans_master.is_active IS 'Whether new registrations may choose the plan; users already on a deactivated plan keep it';
+COMMENT ON COLUMN users.plan_type IS 'Plan type from plans_master';
@@ -0,0 +1,6 @@
+-- Remove monthly prices
+ALTER TABLE options_master DROP CONSTRAINT IF EXISTS chk_options_master_monthly_price;
+ALTER TABLE plans_master DROP CONSTRAINT IF EXISTS chk_plans_master_monthly_price;
+
+ALTER TABLE options_master DROP COLUMN IF EXISTS monthly_price;
+ALTER TABLE plans_master DROP COLUMN IF EXISTS monthly_price;
@@ -0,0 +1,17 @@
+-- Add tax-exclusive monthly prices to plans and options for quotes
+ALTER TABLE plans_master ADD COLUMN monthly_price INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE options_master ADD COLUMN monthly_price INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE plans_master ADD CONSTRAINT chk_plans_master_monthly_price CHECK (monthly_price >= 0);
+ALTER TABLE options_master ADD CONSTRAINT chk_options_master_monthly_price CHECK (monthly_price >= 0);
+
+-- Set the prices of the initial plans and options
+UPDATE plans_master SET monthly_price = 1000 WHERE plan_type = 'A';
+UPDATE plans_master SET monthly_price = 2000 WHERE plan_type = 'B';
+UPDATE options_master SET monthly_price = 500 WHERE option_type = 'AA';
+UPDATE options_master SET monthly_price = 800 WHERE option_type = 'BB';
+UPDATE options_master SET monthly_price = 300 WHERE option_type = 'AB';
+
+-- Add comments
+COMMENT ON COLUMN plans_master.monthly_price IS 'Monthly price in yen, excluding consumption tax';
+COMMENT ON COLUMN options_master.monthly_price IS 'Monthly price in yen per unit, excluding consumption tax';
@@ -0,0 +1,2 @@
+-- Drop user_pricing_snapshots table
+DROP TABLE IF EXISTS user_pricing_snapshots;
@@ -0,0 +1,28 @@
+-- Create user_pricing_snapshots table holding the prices a user agreed to at registration
+CREATE TABLE user_pricing_snapshots (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    plan_type VARCHAR(10) NOT NULL,
+    lines JSONB NOT NULL,
+    subtotal INTEGER NOT NULL,
+    tax_rate INTEGER NOT NULL,
+    tax INTEGER NOT NULL,
+    total INTEGER NOT NULL,
+    priced_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Add constraints
+ALTER TABLE user_pricing_snapshots ADD CONSTRAINT chk_user_pricing_snapshots_amounts
+    CHECK (subtotal >= 0 AND tax >= 0 AND total = subtotal + tax);
+ALTER TABLE user_pricing_snapshots ADD CONSTRAINT chk_user_pricing_snapshots_tax_rate
+    CHECK (tax_rate BETWEEN 0 AND 10000);
+
+-- Add comments
+COMMENT ON TABLE user_pricing_snapshots IS 'Monthly prices applied at registration; later price and tax changes do not alter them';
+COMMENT ON COLUMN user_pricing_snapshots.plan_type IS 'Plan type at registration';
+COMMENT ON COLUMN user_pricing_snapshots.lines IS 'Priced plan and option lines: kind, code, name, unit_price, quantity, amount';
+COMMENT ON COLUMN user_pricing_snapshots.subtotal IS 'Monthly total in yen, excluding consumption tax';
+COMMENT ON COLUMN user_pricing_snapshots.tax_rate IS 'Consumption tax rate in basis points (1000 = 10%)';
+COMMENT ON COLUMN user_pricing_snapshots.tax IS 'Consumption tax in yen, computed once on the subtotal';
+COMMENT ON COLUMN user_pricing_snapshots.total IS 'Monthly total in yen, including consumption tax';
+COMMENT ON COLUMN user_pricing_snapshots.priced_at IS 'Time the prices were taken from the plan and option masters';
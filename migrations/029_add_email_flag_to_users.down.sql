@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_users_email_lower;
+DROP INDEX IF EXISTS idx_users_email_flag;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_email_flag;
+ALTER TABLE users DROP COLUMN IF EXISTS email_flagged_at;
+ALTER TABLE users DROP COLUMN IF EXISTS email_flag_reason;
+ALTER TABLE users DROP COLUMN IF EXISTS email_flag;
@@ -0,0 +1,16 @@
+-- Flag users whose email address bounced permanently or reported a message as spam
+ALTER TABLE users ADD COLUMN email_flag VARCHAR(20);
+ALTER TABLE users ADD COLUMN email_flag_reason VARCHAR(255);
+ALTER TABLE users ADD COLUMN email_flagged_at TIMESTAMP;
+
+ALTER TABLE users ADD CONSTRAINT chk_users_email_flag CHECK (email_flag IN ('bounce', 'complaint'));
+
+-- Create indexes
+CREATE INDEX idx_users_email_flag ON users(email_flag) WHERE email_flag IS NOT NULL;
+-- Providers may report addresses in another case than registered
+CREATE INDEX idx_users_email_lower ON users(LOWER(email));
+
+-- Add comments
+COMMENT ON COLUMN users.email_flag IS 'Set when the mail provider reported the address: bounce (permanent) or complaint (spam report)';
+COMMENT ON COLUMN users.email_flag_reason IS 'Reason reported by the mail provider, e.g. the SMTP diagnostic';
+COMMENT ON COLUMN users.email_flagged_at IS 'When the address was last flagged';
@@ -0,0 +1,17 @@
+-- Restore the email flag
+DROP INDEX IF EXISTS idx_users_email_deliverability;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_email_deliverability;
+ALTER TABLE users ALTER COLUMN email_deliverability DROP NOT NULL;
+ALTER TABLE users ALTER COLUMN email_deliverability DROP DEFAULT;
+
+UPDATE users SET email_deliverability = CASE email_deliverability
+    WHEN 'bounced' THEN 'bounce'
+    WHEN 'complained' THEN 'complaint'
+END;
+
+ALTER TABLE users RENAME COLUMN email_deliverability_updated_at TO email_flagged_at;
+ALTER TABLE users RENAME COLUMN email_deliverability_reason TO email_flag_reason;
+ALTER TABLE users RENAME COLUMN email_deliverability TO email_flag;
+
+ALTER TABLE users ADD CONSTRAINT chk_users_email_flag CHECK (email_flag IN ('bounce', 'complaint'));
+CREATE INDEX idx_users_email_flag ON users(email_flag) WHERE email_flag IS NOT NULL;
@@ -0,0 +1,27 @@
+-- Replace the email flag with a deliverability status that suppresses sending
+DROP INDEX IF EXISTS idx_users_email_flag;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS chk_users_email_flag;
+
+ALTER TABLE users RENAME COLUMN email_flag TO email_deliverability;
+ALTER TABLE users RENAME COLUMN email_flag_reason TO email_deliverability_reason;
+ALTER TABLE users RENAME COLUMN email_flagged_at TO email_deliverability_updated_at;
+
+UPDATE users SET email_deliverability = CASE email_deliverability
+    WHEN 'bounce' THEN 'bounced'
+    WHEN 'complaint' THEN 'complained'
+    ELSE 'deliverable'
+END;
+
+ALTER TABLE users ALTER COLUMN email_deliverability SET DEFAULT 'deliverable';
+ALTER TABLE users ALTER COLUMN email_deliverability SET NOT NULL;
+ALTER TABLE users ADD CONSTRAINT chk_users_email_deliverability
+    CHECK (email_deliverability IN ('deliverable', 'bounced', 'complained'));
+
+-- Create indexes
+CREATE INDEX idx_users_email_deliverability ON users(email_deliverability)
+    WHERE email_deliverability <> 'deliverable';
+
+-- Add comments
+COMMENT ON COLUMN users.email_deliverability IS 'deliverable, bounced (permanent bounce) or complained (spam report); email is not sent unless deliverable';
+COMMENT ON COLUMN users.email_deliverability_reason IS 'Reason reported by the mail provider, e.g. the SMTP diagnostic';
+COMMENT ON COLUMN users.email_deliverability_updated_at IS 'When the mail provider last reported the address';
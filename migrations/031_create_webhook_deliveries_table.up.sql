@@ -0,0 +1,36 @@
+-- Create webhook_deliveries table, the queue of webhook sends with its dead letters
+CREATE TABLE webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    outbox_event_id BIGINT NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+    event_type VARCHAR(100) NOT NULL,
+    correlation_id VARCHAR(128),
+    payload JSONB NOT NULL,
+    occurred_at TIMESTAMP NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP,
+    dead_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX idx_webhook_deliveries_outbox_event ON webhook_deliveries(outbox_event_id);
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_status ON webhook_deliveries(status, id);
+
+-- Add constraints
+ALTER TABLE webhook_deliveries ADD CONSTRAINT chk_webhook_deliveries_status
+    CHECK (status IN ('pending', 'delivered', 'dead'));
+
+-- Add comments
+COMMENT ON TABLE webhook_deliveries IS 'Queued webhook sends; dead rows are the dead letters awaiting replay';
+COMMENT ON COLUMN webhook_deliveries.outbox_event_id IS 'Outbox event delivered; one delivery per event';
+COMMENT ON COLUMN webhook_deliveries.status IS 'Delivery status: pending, delivered, dead';
+COMMENT ON COLUMN webhook_deliveries.attempts IS 'Number of delivery attempts since enqueued or last replayed';
+COMMENT ON COLUMN webhook_deliveries.last_error IS 'Last delivery error';
+COMMENT ON COLUMN webhook_deliveries.next_attempt_at IS 'Earliest time of the next delivery attempt';
+COMMENT ON COLUMN webhook_deliveries.delivered_at IS 'Delivery timestamp';
+COMMENT ON COLUMN webhook_deliveries.dead_at IS 'Time the retry schedule ran out';
@@ -0,0 +1,2 @@
+-- Drop email_notifications table
+DROP TABLE IF EXISTS email_notifications;
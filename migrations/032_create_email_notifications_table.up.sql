@@ -0,0 +1,31 @@
+-- Create email_notifications table, the queue of notification emails sent after a change
+CREATE TABLE email_notifications (
+    id BIGSERIAL PRIMARY KEY,
+    template VARCHAR(100) NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_email_notifications_pending ON email_notifications(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_email_notifications_user_id ON email_notifications(user_id);
+
+-- Add constraints
+ALTER TABLE email_notifications ADD CONSTRAINT chk_email_notifications_status
+    CHECK (status IN ('pending', 'sent', 'failed', 'skipped'));
+
+-- Add comments
+COMMENT ON TABLE email_notifications IS 'Queued notification emails; the content is rendered from the user when sent';
+COMMENT ON COLUMN email_notifications.template IS 'Email template sent';
+COMMENT ON COLUMN email_notifications.user_id IS 'Recipient; deleting the user cancels the notification';
+COMMENT ON COLUMN email_notifications.status IS 'Notification status: pending, sent, failed (attempts ran out), skipped (address suppressed)';
+COMMENT ON COLUMN email_notifications.attempts IS 'Number of send attempts';
+COMMENT ON COLUMN email_notifications.last_error IS 'Last send error';
+COMMENT ON COLUMN email_notifications.next_attempt_at IS 'Earliest time of the next send attempt';
+COMMENT ON COLUMN email_notifications.sent_at IS 'Send timestamp';
@@ -0,0 +1,3 @@
+-- Drop api_key_usage and api_keys tables
+DROP TABLE IF EXISTS api_key_usage;
+DROP TABLE IF EXISTS api_keys;
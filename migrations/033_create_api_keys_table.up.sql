@@ -0,0 +1,36 @@
+-- Create api_keys table, the keys partners use to call the partner API
+CREATE TABLE api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    partner VARCHAR(100) NOT NULL,
+    key_prefix VARCHAR(16) NOT NULL,
+    key_hash CHAR(64) NOT NULL,
+    scopes VARCHAR(255) NOT NULL,
+    note VARCHAR(255),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMP
+);
+
+-- Create api_key_usage table, request counts per key, hour and endpoint
+CREATE TABLE api_key_usage (
+    api_key_id BIGINT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    period_start TIMESTAMP NOT NULL,
+    endpoint VARCHAR(100) NOT NULL,
+    request_count BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (api_key_id, period_start, endpoint)
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX idx_api_keys_key_hash ON api_keys(key_hash);
+CREATE INDEX idx_api_keys_partner ON api_keys(partner);
+CREATE INDEX idx_api_key_usage_period_start ON api_key_usage(period_start);
+
+-- Add comments
+COMMENT ON TABLE api_keys IS 'Partner API keys; the key itself is shown once at creation and only its hash is stored';
+COMMENT ON COLUMN api_keys.partner IS 'Partner the key was issued to';
+COMMENT ON COLUMN api_keys.key_prefix IS 'First characters of the key, to tell keys apart';
+COMMENT ON COLUMN api_keys.key_hash IS 'SHA-256 of the key, hex encoded';
+COMMENT ON COLUMN api_keys.scopes IS 'Comma-separated scopes the key grants, e.g. availability:read,plans:read';
+COMMENT ON COLUMN api_keys.revoked_at IS 'Revocation timestamp; revoked keys are rejected';
+COMMENT ON TABLE api_key_usage IS 'Partner API requests per key, hour and endpoint, for billing and reporting';
+COMMENT ON COLUMN api_key_usage.period_start IS 'Start of the hour counted';
+COMMENT ON COLUMN api_key_usage.endpoint IS 'Route name of the endpoint called';
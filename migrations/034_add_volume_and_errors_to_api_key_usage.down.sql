@@ -0,0 +1,6 @@
+-- Drop byte volumes and errors from api_key_usage
+DROP INDEX IF EXISTS idx_api_key_usage_key_period;
+ALTER TABLE api_key_usage DROP COLUMN IF EXISTS server_error_count;
+ALTER TABLE api_key_usage DROP COLUMN IF EXISTS client_error_count;
+ALTER TABLE api_key_usage DROP COLUMN IF EXISTS response_bytes;
+ALTER TABLE api_key_usage DROP COLUMN IF EXISTS request_bytes;
@@ -0,0 +1,15 @@
+-- Meter byte volumes and errors of partner API requests alongside their count
+ALTER TABLE api_key_usage ADD COLUMN request_bytes BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE api_key_usage ADD COLUMN response_bytes BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE api_key_usage ADD COLUMN client_error_count BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE api_key_usage ADD COLUMN server_error_count BIGINT NOT NULL DEFAULT 0;
+
+-- Create indexes
+CREATE INDEX idx_api_key_usage_key_period ON api_key_usage(api_key_id, period_start);
+
+-- Add comments
+COMMENT ON COLUMN api_key_usage.endpoint IS 'Route pattern of the endpoint called, e.g. /partner/v1/plans';
+COMMENT ON COLUMN api_key_usage.request_bytes IS 'Total size of the request bodies';
+COMMENT ON COLUMN api_key_usage.response_bytes IS 'Total size of the response bodies';
+COMMENT ON COLUMN api_key_usage.client_error_count IS 'Requests answered with a 4xx status, including rate limited requests';
+COMMENT ON COLUMN api_key_usage.server_error_count IS 'Requests answered with a 5xx status';
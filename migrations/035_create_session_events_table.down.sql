@@ -0,0 +1,2 @@
+-- Drop session_events table
+DROP TABLE IF EXISTS session_events;
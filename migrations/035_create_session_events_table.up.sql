@@ -0,0 +1,22 @@
+-- Create session_events table, the progress of form sessions through the wizard for funnel analytics
+CREATE TABLE session_events (
+    id BIGSERIAL PRIMARY KEY,
+    session_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(30) NOT NULL,
+    step VARCHAR(50),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_session_events_session_id ON session_events(session_id);
+CREATE INDEX idx_session_events_type_created_at ON session_events(event_type, created_at);
+
+-- Add constraints
+ALTER TABLE session_events ADD CONSTRAINT chk_session_events_event_type
+    CHECK (event_type IN ('created', 'step_completed', 'step_failed', 'submitted'));
+
+-- Add comments
+COMMENT ON TABLE session_events IS 'Form session progress; kept after the session expires or is deleted';
+COMMENT ON COLUMN session_events.session_id IS 'Form session; not a foreign key so events outlive the session';
+COMMENT ON COLUMN session_events.event_type IS 'Event: created, step_completed, step_failed (validation errors), submitted (user registered)';
+COMMENT ON COLUMN session_events.step IS 'Wizard step of step_completed and step_failed events';
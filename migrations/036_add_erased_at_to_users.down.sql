@@ -0,0 +1,2 @@
+-- Drop erased_at from users
+ALTER TABLE users DROP COLUMN IF EXISTS erased_at;
@@ -0,0 +1,5 @@
+-- Record when the personal data of a user was erased on request
+ALTER TABLE users ADD COLUMN erased_at TIMESTAMP;
+
+-- Add comments
+COMMENT ON COLUMN users.erased_at IS 'Set when the personal data was replaced with placeholders; plan, prefecture and city are kept for statistics';
@@ -0,0 +1,29 @@
+-- Encrypted values do not fit the original columns: run reencrypt-pii with
+-- PII_ENCRYPTION_KEY_ID unset first to write every row back in plaintext
+DROP INDEX IF EXISTS idx_users_email_hash;
+DROP INDEX IF EXISTS idx_users_phone_hash;
+DROP INDEX IF EXISTS idx_users_email_plaintext;
+DROP INDEX IF EXISTS idx_users_email_lower_plaintext;
+DROP INDEX IF EXISTS idx_users_phone_plaintext;
+
+ALTER TABLE users DROP COLUMN IF EXISTS email_hash;
+ALTER TABLE users DROP COLUMN IF EXISTS phone_hash;
+
+ALTER TABLE users
+    ALTER COLUMN phone1 TYPE VARCHAR(5),
+    ALTER COLUMN phone2 TYPE VARCHAR(4),
+    ALTER COLUMN phone3 TYPE VARCHAR(4),
+    ALTER COLUMN town TYPE VARCHAR(50),
+    ALTER COLUMN chome TYPE VARCHAR(10),
+    ALTER COLUMN banchi TYPE VARCHAR(10),
+    ALTER COLUMN go TYPE VARCHAR(10),
+    ALTER COLUMN building TYPE VARCHAR(100),
+    ALTER COLUMN room TYPE VARCHAR(20),
+    ALTER COLUMN email TYPE VARCHAR(256);
+
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+CREATE INDEX idx_users_email ON users(email);
+CREATE INDEX idx_users_email_lower ON users(LOWER(email));
+CREATE INDEX idx_users_phone ON users ((phone1 || phone2 || phone3));
+
+COMMENT ON COLUMN users.email IS 'Email address (unique)';
@@ -0,0 +1,34 @@
+-- Store phone, email and street address encrypted. Ciphertext is longer than the values and
+-- cannot be compared in SQL, so the columns are widened and lookups use keyed hashes.
+ALTER TABLE users
+    ALTER COLUMN phone1 TYPE TEXT,
+    ALTER COLUMN phone2 TYPE TEXT,
+    ALTER COLUMN phone3 TYPE TEXT,
+    ALTER COLUMN town TYPE TEXT,
+    ALTER COLUMN chome TYPE TEXT,
+    ALTER COLUMN banchi TYPE TEXT,
+    ALTER COLUMN go TYPE TEXT,
+    ALTER COLUMN building TYPE TEXT,
+    ALTER COLUMN room TYPE TEXT,
+    ALTER COLUMN email TYPE TEXT;
+
+ALTER TABLE users ADD COLUMN email_hash CHAR(64);
+ALTER TABLE users ADD COLUMN phone_hash CHAR(64);
+
+-- Rows written before encryption have no hashes until reencrypt-pii rewrites them; until
+-- then they are matched and kept unique by their plaintext
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+DROP INDEX IF EXISTS idx_users_email;
+DROP INDEX IF EXISTS idx_users_email_lower;
+DROP INDEX IF EXISTS idx_users_phone;
+
+CREATE UNIQUE INDEX idx_users_email_hash ON users(email_hash);
+CREATE INDEX idx_users_phone_hash ON users(phone_hash);
+CREATE UNIQUE INDEX idx_users_email_plaintext ON users(email) WHERE email_hash IS NULL;
+CREATE INDEX idx_users_email_lower_plaintext ON users(LOWER(email)) WHERE email_hash IS NULL;
+CREATE INDEX idx_users_phone_plaintext ON users ((phone1 || phone2 || phone3)) WHERE phone_hash IS NULL;
+
+-- Add comments
+COMMENT ON COLUMN users.email IS 'Email address, encrypted (enc:v1:KEY_ID:...) unless written before encryption was enabled';
+COMMENT ON COLUMN users.email_hash IS 'Keyed hash of the lowercased email address, unique; NULL for rows not yet encrypted and erased users';
+COMMENT ON COLUMN users.phone_hash IS 'Keyed hash of the phone number digits; NULL for rows not yet encrypted and erased users';
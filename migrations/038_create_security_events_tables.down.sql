@@ -0,0 +1,3 @@
+-- Drop security_events and ip_blocks tables
+DROP TABLE IF EXISTS ip_blocks;
+DROP TABLE IF EXISTS security_events;
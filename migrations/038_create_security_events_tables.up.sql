@@ -0,0 +1,39 @@
+-- Create security_events table, rejected requests recorded by the security monitor
+CREATE TABLE security_events (
+    id BIGSERIAL PRIMARY KEY,
+    client_ip VARCHAR(45) NOT NULL,
+    event_type VARCHAR(30) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(500) NOT NULL,
+    status_code INTEGER NOT NULL,
+    request_id VARCHAR(100),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create ip_blocks table, client IPs rejected after repeated security events
+CREATE TABLE ip_blocks (
+    client_ip VARCHAR(45) PRIMARY KEY,
+    reason VARCHAR(30) NOT NULL,
+    event_count INTEGER NOT NULL,
+    blocked_until TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX idx_security_events_ip_type_created_at ON security_events(client_ip, event_type, created_at);
+CREATE INDEX idx_security_events_created_at ON security_events(created_at);
+CREATE INDEX idx_ip_blocks_blocked_until ON ip_blocks(blocked_until);
+
+-- Add constraints
+ALTER TABLE security_events ADD CONSTRAINT chk_security_events_event_type
+    CHECK (event_type IN ('csrf_failure', 'rate_limited', 'validation_failure'));
+
+-- Add comments
+COMMENT ON TABLE security_events IS 'Rejected requests that may be part of an attack, counted per client IP to detect abuse';
+COMMENT ON COLUMN security_events.event_type IS 'Event: csrf_failure, rate_limited, validation_failure';
+COMMENT ON COLUMN security_events.path IS 'Route pattern, or the request path when no route matched';
+COMMENT ON TABLE ip_blocks IS 'Client IPs whose requests are rejected until blocked_until; rows stay after the block ends so only later events count toward a new one';
+COMMENT ON COLUMN ip_blocks.blocked_until IS 'End of the block; set to the time an admin lifted it';
+COMMENT ON COLUMN ip_blocks.reason IS 'Event type whose repetition caused the block';
+COMMENT ON COLUMN ip_blocks.event_count IS 'Events of the type within the detection window when the block was set';
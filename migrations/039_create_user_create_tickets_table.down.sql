@@ -0,0 +1,2 @@
+-- Drop user_create_tickets table
+DROP TABLE IF EXISTS user_create_tickets;
@@ -0,0 +1,42 @@
+-- Create user_create_tickets table, registrations accepted while user creation is queued
+CREATE TABLE user_create_tickets (
+    id BIGSERIAL PRIMARY KEY,
+    ticket_id VARCHAR(36) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    email_hash CHAR(64) NOT NULL,
+    payload TEXT,
+    session_id VARCHAR(255),
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    error_code VARCHAR(50),
+    error_message TEXT,
+    error_details JSONB,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMP,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX idx_user_create_tickets_ticket_id ON user_create_tickets(ticket_id);
+CREATE INDEX idx_user_create_tickets_unfinished ON user_create_tickets(id) WHERE status IN ('queued', 'processing');
+CREATE INDEX idx_user_create_tickets_email_hash ON user_create_tickets(email_hash, id) WHERE status IN ('queued', 'processing');
+CREATE INDEX idx_user_create_tickets_completed_at ON user_create_tickets(completed_at);
+
+-- Add constraints
+ALTER TABLE user_create_tickets ADD CONSTRAINT chk_user_create_tickets_status
+    CHECK (status IN ('queued', 'processing', 'completed', 'failed'));
+
+-- Add comments
+COMMENT ON TABLE user_create_tickets IS 'Registrations accepted with 202 and created by the queue workers in the order they were accepted';
+COMMENT ON COLUMN user_create_tickets.id IS 'Order of acceptance';
+COMMENT ON COLUMN user_create_tickets.ticket_id IS 'Random ID clients poll the status with';
+COMMENT ON COLUMN user_create_tickets.status IS 'Ticket status: queued, processing, completed, failed';
+COMMENT ON COLUMN user_create_tickets.email_hash IS 'Blind index of the email address; tickets of one address are processed one at a time';
+COMMENT ON COLUMN user_create_tickets.payload IS 'Encrypted registration request; cleared once the ticket is completed or failed';
+COMMENT ON COLUMN user_create_tickets.session_id IS 'Form session whose reservations the created user consumes';
+COMMENT ON COLUMN user_create_tickets.error_code IS 'API error code of a failed ticket, as a synchronous registration would return';
+COMMENT ON COLUMN user_create_tickets.last_error IS 'Last error retried';
+COMMENT ON COLUMN user_create_tickets.locked_until IS 'End of the lease of the worker processing the ticket; expired leases are claimed again';
@@ -0,0 +1,2 @@
+-- Drop locale from users
+ALTER TABLE users DROP COLUMN IF EXISTS locale;
@@ -0,0 +1,5 @@
+-- Add the language negotiated at registration, in which emails to the user are written
+ALTER TABLE users ADD COLUMN locale VARCHAR(10) NOT NULL DEFAULT 'ja';
+
+-- Add comments
+COMMENT ON COLUMN users.locale IS 'Language negotiated at registration (ja, en); emails are written in it';
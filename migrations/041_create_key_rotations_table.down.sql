@@ -0,0 +1,2 @@
+-- Drop key_rotations table
+DROP TABLE IF EXISTS key_rotations;
@@ -0,0 +1,22 @@
+-- Create key_rotations table, checkpoints of the rotate-keys command
+CREATE TABLE key_rotations (
+    id BIGSERIAL PRIMARY KEY,
+    from_key_id VARCHAR(100) NOT NULL,
+    to_key_id VARCHAR(100) NOT NULL,
+    target VARCHAR(50) NOT NULL,
+    last_id BIGINT NOT NULL DEFAULT 0,
+    rewritten BIGINT NOT NULL DEFAULT 0,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE UNIQUE INDEX idx_key_rotations_keys_target ON key_rotations(from_key_id, to_key_id, target);
+
+-- Add comments
+COMMENT ON TABLE key_rotations IS 'Progress of re-encrypting personal data from one key to another, so an interrupted rotation resumes where it stopped';
+COMMENT ON COLUMN key_rotations.target IS 'Table rewritten: users, user_create_tickets';
+COMMENT ON COLUMN key_rotations.last_id IS 'Last row ID rewritten; the rotation continues after it';
+COMMENT ON COLUMN key_rotations.rewritten IS 'Rows rewritten so far';
+COMMENT ON COLUMN key_rotations.completed_at IS 'When no rows of the target were left encrypted with the old key';
@@ -0,0 +1,3 @@
+-- Drop validation count tables
+DROP TABLE IF EXISTS validation_failure_counts;
+DROP TABLE IF EXISTS validation_attempt_counts;
@@ -0,0 +1,24 @@
+-- Create validation count tables, anonymous daily counts of form validations and their failures
+CREATE TABLE validation_attempt_counts (
+    day DATE NOT NULL,
+    stage VARCHAR(50) NOT NULL,
+    attempts BIGINT NOT NULL DEFAULT 0,
+    failed BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, stage)
+);
+
+CREATE TABLE validation_failure_counts (
+    day DATE NOT NULL,
+    stage VARCHAR(50) NOT NULL,
+    field VARCHAR(50) NOT NULL,
+    code VARCHAR(50) NOT NULL,
+    failures BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (day, stage, field, code)
+);
+
+-- Add comments
+COMMENT ON TABLE validation_attempt_counts IS 'Validations of registration data per UTC day and form stage';
+COMMENT ON COLUMN validation_attempt_counts.stage IS 'Wizard step, validate (POST /users/validate) or submit (POST /users)';
+COMMENT ON COLUMN validation_attempt_counts.failed IS 'Validations with at least one error';
+COMMENT ON TABLE validation_failure_counts IS 'Validation errors per UTC day, form stage, field and failure code; values are never recorded';
+COMMENT ON COLUMN validation_failure_counts.code IS 'Failure category, e.g. required, katakana, max, rule_pattern, unavailable';
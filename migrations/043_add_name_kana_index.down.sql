@@ -0,0 +1,2 @@
+-- Remove the kana name index
+DROP INDEX IF EXISTS idx_users_name_kana;
@@ -0,0 +1,2 @@
+-- Support duplicate registration checks on the kana name across postal codes
+CREATE INDEX idx_users_name_kana ON users (last_name_kana, first_name_kana);
@@ -0,0 +1,11 @@
+-- Restore deleting option reservations with their session
+DROP INDEX IF EXISTS idx_option_reservations_created_at;
+
+DELETE FROM option_reservations WHERE session_id IS NULL;
+ALTER TABLE option_reservations DROP CONSTRAINT option_reservations_session_id_fkey;
+ALTER TABLE option_reservations ADD CONSTRAINT option_reservations_session_id_fkey
+    FOREIGN KEY (session_id) REFERENCES user_sessions(id) ON DELETE CASCADE;
+ALTER TABLE option_reservations ALTER COLUMN session_id SET NOT NULL;
+
+COMMENT ON COLUMN option_reservations.session_id IS 'Form session holding the stock; holds are deleted with the session';
+COMMENT ON COLUMN option_reservations.status IS 'held until registration (consumed) or expiry (released)';
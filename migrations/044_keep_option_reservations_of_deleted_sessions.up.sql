@@ -0,0 +1,12 @@
+-- Keep option reservations when their session is deleted, so holds released with the session stay on record
+ALTER TABLE option_reservations ALTER COLUMN session_id DROP NOT NULL;
+ALTER TABLE option_reservations DROP CONSTRAINT option_reservations_session_id_fkey;
+ALTER TABLE option_reservations ADD CONSTRAINT option_reservations_session_id_fkey
+    FOREIGN KEY (session_id) REFERENCES user_sessions(id) ON DELETE SET NULL;
+
+-- Create indexes
+CREATE INDEX idx_option_reservations_created_at ON option_reservations(created_at) WHERE status = 'held';
+
+-- Add comments
+COMMENT ON COLUMN option_reservations.session_id IS 'Form session holding the stock; NULL once the session is deleted';
+COMMENT ON COLUMN option_reservations.status IS 'held until registration (consumed), expiry, end of the session or release by an admin (released)';
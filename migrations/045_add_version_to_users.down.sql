@@ -0,0 +1,2 @@
+-- Drop version from users
+ALTER TABLE users DROP COLUMN IF EXISTS version;
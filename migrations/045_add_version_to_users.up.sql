@@ -0,0 +1,5 @@
+-- Add the row version incremented by every update, so that updates based on an outdated read are detected
+ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- Add comments
+COMMENT ON COLUMN users.version IS 'Incremented by every update; updates carrying an older version are rejected (optimistic locking)';
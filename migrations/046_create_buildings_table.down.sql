@@ -0,0 +1,2 @@
+-- Drop buildings table
+DROP TABLE IF EXISTS buildings;
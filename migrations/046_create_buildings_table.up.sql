@@ -0,0 +1,19 @@
+-- Create buildings table holding the names of apartment and office buildings by address
+CREATE TABLE buildings (
+    id SERIAL PRIMARY KEY,
+    postal_code CHAR(7) NOT NULL,
+    banchi VARCHAR(40) NOT NULL,
+    name VARCHAR(200) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (postal_code, banchi, name)
+);
+
+-- Create indexes
+CREATE INDEX idx_buildings_postal_code_banchi ON buildings(postal_code, banchi);
+
+-- Add comments
+COMMENT ON TABLE buildings IS 'Building names suggested for the building field of the registration form';
+COMMENT ON COLUMN buildings.postal_code IS '7-digit postal code without hyphen';
+COMMENT ON COLUMN buildings.banchi IS 'Block numbers after the town in canonical form, e.g. 8-1 or 2-8-1';
+COMMENT ON COLUMN buildings.name IS 'Building name as stored in the users building column';
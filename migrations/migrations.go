@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL schema migrations so they ship inside the binaries.
+package migrations
+
+import "embed"
+
+// FS holds the NNN_name.up.sql / NNN_name.down.sql migration files
+//
+//go:embed *.sql
+var FS embed.FS
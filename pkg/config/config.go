@@ -2,8 +2,10 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -16,10 +18,52 @@ const (
 
 // Config holds all configuration for the application
 type Config struct {
-	Server      ServerConfig      `json:"server"`
-	Database    database.Config   `json:"database"`
-	Log         LogConfig         `json:"log"`
-	ExternalAPI ExternalAPIConfig `json:"external_api"`
+	// Debug turns on the behaviors meant for local development only: Gin debug mode, pprof
+	// on the admin listener, the schema drift check at startup and bind error details in
+	// responses. It defaults to on in development and may not be set in production.
+	Debug bool `json:"debug"`
+
+	Server       ServerConfig       `json:"server"`
+	Database     database.Config    `json:"database"`
+	Log          LogConfig          `json:"log"`
+	ExternalAPI  ExternalAPIConfig  `json:"external_api"`
+	Webhook      WebhookConfig      `json:"webhook"`
+	Outbox       OutboxConfig       `json:"outbox"`
+	Events       EventsConfig       `json:"events"`
+	Admin        AdminConfig        `json:"admin"`
+	RateLimit    RateLimitConfig    `json:"rate_limit"`
+	CSRF         CSRFConfig         `json:"csrf"`
+	Redis        RedisConfig        `json:"redis"`
+	Cache        CacheConfig        `json:"cache"`
+	Inventory    InventoryConfig    `json:"inventory"`
+	Recommend    RecommendConfig    `json:"recommend"`
+	Privacy      PrivacyConfig      `json:"privacy"`
+	Mail         MailConfig         `json:"mail"`
+	Notification NotificationConfig `json:"notification"`
+	Partner      PartnerConfig      `json:"partner"`
+	Lookup       LookupConfig       `json:"lookup"`
+	SMS          SMSConfig          `json:"sms"`
+	Phone        PhoneConfig        `json:"phone"`
+	Upload       UploadConfig       `json:"upload"`
+	Scan         ScanConfig         `json:"scan"`
+	Reservation  ReservationConfig  `json:"reservation"`
+	Features     FeaturesConfig     `json:"features"`
+	Masking      MaskingConfig      `json:"masking"`
+	Session      SessionConfig      `json:"session"`
+	Validation   ValidationConfig   `json:"validation"`
+	Pricing      PricingConfig      `json:"pricing"`
+	Duplicate    DuplicateConfig    `json:"duplicate"`
+	Security     SecurityConfig     `json:"security"`
+	UserQueue    UserQueueConfig    `json:"user_queue"`
+	Captcha      CaptchaConfig      `json:"captcha"`
+	BotDetection BotDetectionConfig `json:"bot_detection"`
+
+	ValidationTelemetry ValidationTelemetryConfig `json:"validation_telemetry"`
+	Inspection          InspectionConfig          `json:"inspection"`
+	StrictMode          StrictModeConfig          `json:"strict_mode"`
+	APIVersions         APIVersionsConfig         `json:"api_versions"`
+	Readiness           ReadinessConfig           `json:"readiness"`
+	Health              HealthConfig              `json:"health"`
 }
 
 // ServerConfig holds server configuration
@@ -27,11 +71,63 @@ type ServerConfig struct {
 	Port string `json:"port"`
 	Host string `json:"host"`
 	Mode string `json:"mode"`
+	// TrustedPlatform is "cloudflare", "appengine" or a custom header name carrying the client IP
+	TrustedPlatform string   `json:"trusted_platform"`
+	TrustedProxies  []string `json:"trusted_proxies"`
+	// ProxyDepth is the number of reverse proxies in front of the server (0 disables depth-based resolution)
+	ProxyDepth int `json:"proxy_depth"`
+
+	// AdminPort moves health probes, metrics and pprof to an internal listener; empty keeps
+	// the health probes on the public listener and disables pprof. pprof is served only with
+	// Debug.
+	AdminPort string `json:"admin_port"`
+	AdminHost string `json:"admin_host"`
+	// AdminWriteTimeout must exceed the longest pprof profile or trace requested
+	AdminReadTimeout  time.Duration `json:"admin_read_timeout"`
+	AdminWriteTimeout time.Duration `json:"admin_write_timeout"`
+
+	// MaxConnections caps open connections on the public listener (0 = unlimited)
+	MaxConnections int `json:"max_connections"`
+	// MaxConnectionsPerIP caps open connections from one peer IP (0 = unlimited). Behind a
+	// load balancer every connection comes from the balancer, so leave it off there.
+	MaxConnectionsPerIP int `json:"max_connections_per_ip"`
+
+	// TLSCertFile and TLSKeyFile serve HTTPS with a PEM certificate and key
+	TLSCertFile string `json:"tls_cert_file"`
+	TLSKeyFile  string `json:"-"`
+	// TLSAutocertDomains obtains certificates from Let's Encrypt for these host names instead
+	// of TLSCertFile; the domains must resolve to this server on ports 443 or HTTPRedirectPort
+	TLSAutocertDomains  []string `json:"tls_autocert_domains"`
+	TLSAutocertEmail    string   `json:"tls_autocert_email"`
+	TLSAutocertCacheDir string   `json:"tls_autocert_cache_dir"`
+	// TLSMinVersion is "1.2" or "1.3"
+	TLSMinVersion string `json:"tls_min_version"`
+	// HTTPRedirectPort runs a plain HTTP listener redirecting to HTTPS; it also answers
+	// Let's Encrypt HTTP challenges. Empty disables it.
+	HTTPRedirectPort string `json:"http_redirect_port"`
+
+	// Socket also serves the public API on a Unix domain socket, for a reverse proxy on the
+	// same host; empty disables it
+	Socket string `json:"socket"`
+	// SocketMode is the octal permission of the socket file
+	SocketMode string `json:"socket_mode"`
+
+	// PIDFile records the PID of the serving process, which changes on a binary reload
+	PIDFile string `json:"pid_file"`
+	// ReloadTimeout is how long a reloaded process may take to become ready
+	ReloadTimeout time.Duration `json:"reload_timeout"`
 }
 
 // LogConfig holds logging configuration
 type LogConfig struct {
 	Level string `json:"level"`
+	// Bodies logs masked request and response bodies of sampled requests and of requests
+	// sending X-Debug-Trace; meant for staging
+	Bodies bool `json:"bodies"`
+	// BodySampleRate is the share of requests whose bodies are logged, from 0 to 1
+	BodySampleRate float64 `json:"body_sample_rate"`
+	// BodyMaxBytes bounds each body logged; larger JSON bodies are not logged
+	BodyMaxBytes int `json:"body_max_bytes"`
 }
 
 // ExternalAPIConfig holds external API configuration
@@ -39,6 +135,25 @@ type ExternalAPIConfig struct {
 	InventoryAPI APIConfig `json:"inventory_api"`
 	RegionAPI    APIConfig `json:"region_api"`
 	AddressAPI   APIConfig `json:"address_api"`
+
+	// AddressProvider selects the postal code API: custom, zipcloud or kenall
+	AddressProvider string `json:"address_provider"`
+	// AddressAPIKey is the credential for providers that require one (kenall)
+	AddressAPIKey string `json:"-"`
+
+	// AvailabilityTimeout is the overall deadline of a combined inventory and region check
+	AvailabilityTimeout time.Duration `json:"availability_timeout"`
+	// AvailabilityCallTimeout bounds each API call of that check, retries included
+	AvailabilityCallTimeout time.Duration `json:"availability_call_timeout"`
+
+	// EgressAllowedHosts limits the hosts external API calls connect to ("*.example.com"
+	// matches subdomains); empty allows every host
+	EgressAllowedHosts []string `json:"egress_allowed_hosts"`
+	// EgressBlockPrivateIPs refuses connections to loopback, private and link-local addresses
+	EgressBlockPrivateIPs bool `json:"egress_block_private_ips"`
+	// EgressPinDNS keeps using the addresses a host first resolved to for EgressPinTTL
+	EgressPinDNS bool          `json:"egress_pin_dns"`
+	EgressPinTTL time.Duration `json:"egress_pin_ttl"`
 }
 
 // APIConfig holds configuration for a single external API
@@ -47,18 +162,467 @@ type APIConfig struct {
 	Timeout    time.Duration `json:"timeout"`
 	MaxRetries int           `json:"max_retries"`
 	RetryDelay time.Duration `json:"retry_delay"`
+	// MaxRetryDelay caps the exponential backoff between retries
+	MaxRetryDelay time.Duration `json:"max_retry_delay"`
+	// EndpointMaxRetries overrides MaxRetries by endpoint path prefix
+	EndpointMaxRetries map[string]int `json:"endpoint_max_retries"`
+
+	CircuitBreakerThreshold int           `json:"circuit_breaker_threshold"`
+	CircuitBreakerTimeout   time.Duration `json:"circuit_breaker_timeout"`
+
+	// SandboxBaseURL is the partner sandbox used for requests sent with X-Use-Sandbox
+	SandboxBaseURL string `json:"sandbox_base_url"`
+
+	// CacheTTL reuses responses to identical requests for this long (0 = no caching)
+	CacheTTL        time.Duration `json:"cache_ttl"`
+	CacheMaxEntries int           `json:"cache_max_entries"`
+}
+
+// WebhookConfig holds outgoing webhook configuration
+type WebhookConfig struct {
+	URL     string        `json:"url"`
+	Secret  string        `json:"-"`
+	Timeout time.Duration `json:"timeout"`
+	// RetrySchedule is the delay before each retry of a failed delivery; once it runs out
+	// the delivery is dead-lettered until an administrator replays it
+	RetrySchedule []time.Duration `json:"retry_schedule"`
+	PollInterval  time.Duration   `json:"poll_interval"`
+	BatchSize     int             `json:"batch_size"`
+}
+
+// OutboxConfig holds transactional outbox relay configuration
+type OutboxConfig struct {
+	Enabled        bool          `json:"enabled"`
+	PollInterval   time.Duration `json:"poll_interval"`
+	BatchSize      int           `json:"batch_size"`
+	MaxAttempts    int           `json:"max_attempts"`
+	RetryBaseDelay time.Duration `json:"retry_base_delay"`
+	// Lease is how long a relay holds the events it claimed before another may take them over
+	Lease time.Duration `json:"lease"`
+}
+
+// EventsConfig holds message bus publisher configuration
+type EventsConfig struct {
+	// Driver is "noop", "kafka" or "nats"
+	Driver            string        `json:"driver"`
+	Source            string        `json:"source"`
+	Timeout           time.Duration `json:"timeout"`
+	KafkaRESTProxyURL string        `json:"kafka_rest_proxy_url"`
+	KafkaTopic        string        `json:"kafka_topic"`
+	NATSURL           string        `json:"nats_url"`
+	NATSSubjectPrefix string        `json:"nats_subject_prefix"`
+}
+
+// AdminConfig holds admin API configuration
+type AdminConfig struct {
+	// APIToken authenticates admin requests; admin endpoints are disabled when empty
+	APIToken string `json:"-"`
+}
+
+// RateLimitConfig holds rate limiting configuration
+type RateLimitConfig struct {
+	// Backend is "memory" (single instance) or "redis" (shared across instances)
+	Backend       string        `json:"backend"`
+	DefaultLimit  int           `json:"default_limit"`
+	DefaultPeriod time.Duration `json:"default_period"`
+	DefaultBurst  int           `json:"default_burst"`
+	// Rules holds per-route limits, e.g. "POST /api/v1/users/validate=20/1m:5;POST /api/v1/users=10/1m"
+	Rules string `json:"rules"`
+	// MemoryMaxEntries bounds the buckets held by the memory backend; least recently used buckets are evicted
+	MemoryMaxEntries int `json:"memory_max_entries"`
+}
+
+// CSRFConfig holds CSRF protection configuration
+type CSRFConfig struct {
+	// Backend is "memory" (single instance) or "redis" (shared across instances)
+	Backend string `json:"backend"`
+	// MemoryMaxTokens bounds the tokens held by the memory backend; the oldest tokens are evicted
+	MemoryMaxTokens int `json:"memory_max_tokens"`
+}
+
+// RedisConfig holds Redis connection configuration
+type RedisConfig struct {
+	Addr      string `json:"addr"`
+	Password  string `json:"-"`
+	DB        int    `json:"db"`
+	PoolSize  int    `json:"pool_size"`
+	KeyPrefix string `json:"key_prefix"`
+}
+
+// InventoryConfig holds how stock is presented to users
+type InventoryConfig struct {
+	// LowStockThreshold is the stock at or below which an option is shown as low on stock
+	// (0 never shows it)
+	LowStockThreshold int `json:"low_stock_threshold"`
+	// LowStockThresholds overrides LowStockThreshold by option type
+	LowStockThresholds map[string]int `json:"low_stock_thresholds"`
+}
+
+// RecommendConfig holds option recommendation settings
+type RecommendConfig struct {
+	// PopularityTTL is how long option take-up counts are reused before being counted again
+	PopularityTTL time.Duration `json:"popularity_ttl"`
+	// MinSample is the number of registrations a prefecture needs before its own take-up
+	// is used; below it the nationwide take-up of the plan is used
+	MinSample int `json:"min_sample"`
+}
+
+// CacheConfig holds in-process cache configuration
+type CacheConfig struct {
+	// MasterDataEnabled caches options and prefectures master data; disable it in tests
+	MasterDataEnabled bool          `json:"master_data_enabled"`
+	MasterDataTTL     time.Duration `json:"master_data_ttl"`
+	// InventoryTTL is how long inventory API results are served as fresh (0 disables caching);
+	// for InventoryStaleTTL longer they are served flagged stale while refreshed in the background
+	InventoryTTL      time.Duration `json:"inventory_ttl"`
+	InventoryStaleTTL time.Duration `json:"inventory_stale_ttl"`
+}
+
+// PrivacyConfig holds personal data retention configuration
+type PrivacyConfig struct {
+	// DeletionRecordRetention is how long tombstones of deleted users are kept
+	DeletionRecordRetention     time.Duration `json:"deletion_record_retention"`
+	DeletionRecordPurgeInterval time.Duration `json:"deletion_record_purge_interval"`
+	// EmailHashKey keys the email hash stored in tombstones; plain SHA-256 is used when empty
+	EmailHashKey string `json:"-"`
+	// EncryptionKeys are the AES-256 keys of the phone, email and address columns as
+	// "ID=BASE64,..."; retired keys stay until their rows are re-encrypted
+	EncryptionKeys string `json:"-"`
+	// EncryptionKeyID names the key new values are encrypted with; empty writes plaintext
+	EncryptionKeyID string `json:"encryption_key_id"`
+	// BlindIndexKey keys the hashes email and phone are searched by; required when
+	// EncryptionKeyID is set, plain SHA-256 is used when empty
+	BlindIndexKey string `json:"-"`
+}
+
+// ValidateEncryption reports an error when personal data is encrypted without a blind index
+// key. Unkeyed hashes of phone numbers and email addresses can be reversed by brute force,
+// so a leaked database would expose them despite the encryption.
+func (p *PrivacyConfig) ValidateEncryption() error {
+	if p.EncryptionKeyID != "" && p.BlindIndexKey == "" {
+		return errors.New("PII_BLIND_INDEX_KEY is required when PII_ENCRYPTION_KEY_ID is set")
+	}
+	return nil
+}
+
+// MailConfig holds outgoing email configuration
+type MailConfig struct {
+	// Driver is "log" (development default), "smtp", "ses" or "sendgrid"
+	Driver  string        `json:"driver"`
+	From    string        `json:"from"`
+	Timeout time.Duration `json:"timeout"`
+	// RateLimit caps messages per second; 0 uses the driver default and negative disables it
+	RateLimit    float64 `json:"rate_limit"`
+	SMTPHost     string  `json:"smtp_host"`
+	SMTPPort     int     `json:"smtp_port"`
+	SMTPUsername string  `json:"smtp_username"`
+	SMTPPassword string  `json:"-"`
+	// SES credentials fall back to the standard AWS_* variables
+	SESRegion           string `json:"ses_region"`
+	SESAccessKeyID      string `json:"ses_access_key_id"`
+	SESSecretAccessKey  string `json:"-"`
+	SESSessionToken     string `json:"-"`
+	SESConfigurationSet string `json:"ses_configuration_set"`
+	// SESWebhookTopicARNs are the SNS topics accepted by the SES bounce and complaint webhook
+	SESWebhookTopicARNs []string `json:"ses_webhook_topic_arns"`
+	SendGridAPIKey      string   `json:"-"`
+	// SendGridWebhookPublicKey verifies the SendGrid signed event webhook
+	SendGridWebhookPublicKey string `json:"-"`
+}
+
+// NotificationConfig holds configuration of the emails sent to users after changes to
+// their registration
+type NotificationConfig struct {
+	// ConfirmationEnabled sends the registration confirmation email after registration
+	ConfirmationEnabled bool          `json:"confirmation_enabled"`
+	PollInterval        time.Duration `json:"poll_interval"`
+	BatchSize           int           `json:"batch_size"`
+	MaxAttempts         int           `json:"max_attempts"`
+	RetryBaseDelay      time.Duration `json:"retry_base_delay"`
+}
+
+// PartnerConfig holds partner API configuration
+type PartnerConfig struct {
+	// UsageFlushInterval is how often request counts per API key are written to the database
+	UsageFlushInterval time.Duration `json:"usage_flush_interval"`
+}
+
+// LookupConfig holds self-service registration lookup configuration
+type LookupConfig struct {
+	CodeTTL     time.Duration `json:"code_ttl"`
+	MaxAttempts int           `json:"max_attempts"`
+}
+
+// SMSConfig holds outgoing SMS configuration
+type SMSConfig struct {
+	// Driver is "log" (development default) or "http"
+	Driver     string        `json:"driver"`
+	From       string        `json:"from"`
+	Timeout    time.Duration `json:"timeout"`
+	GatewayURL string        `json:"gateway_url"`
+	APIKey     string        `json:"-"`
+}
+
+// PhoneConfig holds SMS phone verification configuration
+type PhoneConfig struct {
+	CodeTTL     time.Duration `json:"code_ttl"`
+	MaxAttempts int           `json:"max_attempts"`
+	// ResendInterval is the minimum time between codes sent to the same number
+	ResendInterval time.Duration `json:"resend_interval"`
+	// VerifiedTTL is how long a verified number counts for a registration made after the verification
+	VerifiedTTL time.Duration `json:"verified_ttl"`
 }
 
-// LoadConfig loads configuration from environment variables
+// UploadConfig holds attachment upload configuration
+type UploadConfig struct {
+	// Dir stores uploaded attachments; QuarantineDir receives the infected ones
+	Dir           string `json:"dir"`
+	QuarantineDir string `json:"quarantine_dir"`
+	MaxBytes      int64  `json:"max_bytes"`
+}
+
+// ScanConfig holds malware scanning configuration for uploaded attachments
+type ScanConfig struct {
+	// Driver is "none" (development default), "clamav" or "icap"
+	Driver     string        `json:"driver"`
+	Timeout    time.Duration `json:"timeout"`
+	ClamAVAddr string        `json:"clamav_addr"`
+	ICAPURL    string        `json:"icap_url"`
+
+	PollInterval   time.Duration `json:"poll_interval"`
+	BatchSize      int           `json:"batch_size"`
+	MaxAttempts    int           `json:"max_attempts"`
+	RetryBaseDelay time.Duration `json:"retry_base_delay"`
+}
+
+// ReservationConfig holds option stock reservation configuration
+type ReservationConfig struct {
+	// TTL is how long a form session holds reserved stock; holds never outlive the session
+	TTL             time.Duration `json:"ttl"`
+	ReleaseInterval time.Duration `json:"release_interval"`
+}
+
+// FeaturesConfig holds feature flag defaults; admins can override them at runtime
+type FeaturesConfig struct {
+	SkipInventoryCheck       bool          `json:"skip_inventory_check"`
+	DisableRegionRestriction bool          `json:"disable_region_restriction"`
+	ReadOnlyMode             bool          `json:"read_only_mode"`
+	QueueUserCreation        bool          `json:"queue_user_creation"`
+	RefreshInterval          time.Duration `json:"refresh_interval"`
+}
+
+// MaskingConfig holds the response masking policy
+type MaskingConfig struct {
+	// Policy lists per-role field rules, e.g. "public:email=email,phone=last4;self:name=first_char"
+	Policy string `json:"policy"`
+}
+
+// SessionConfig holds form session lifetime configuration
+type SessionConfig struct {
+	// IdleTimeout is how long a session lives after it was last saved
+	IdleTimeout time.Duration `json:"idle_timeout"`
+	// MaxLifetime caps how long a session lives after creation regardless of activity; 0 disables the cap
+	MaxLifetime time.Duration `json:"max_lifetime"`
+	// MaxPerClientIP and MaxPerEmail cap concurrent active sessions per owner; 0 disables a cap
+	MaxPerClientIP int `json:"max_per_client_ip"`
+	MaxPerEmail    int `json:"max_per_email"`
+	// EvictOldest deletes the owner's oldest draft instead of rejecting a new session over the cap
+	EvictOldest bool `json:"evict_oldest"`
+	// WizardRequired rejects registrations that do not reference a session with all wizard steps valid
+	WizardRequired bool `json:"wizard_required"`
+	// MaxDataBytes caps the JSON size of saved form data; 0 disables the cap
+	MaxDataBytes int `json:"max_data_bytes"`
+	// AllowedKeys lists the form fields saved form data may hold; empty allows every field
+	AllowedKeys []string `json:"allowed_keys"`
+}
+
+// ValidationConfig holds the source of configurable field validation rules
+type ValidationConfig struct {
+	// RulesSource is "database" (the validation_rules table) or "file"
+	RulesSource string `json:"rules_source"`
+	// RulesFile is the YAML rule file read when RulesSource is "file"
+	RulesFile string `json:"rules_file"`
+}
+
+// PricingConfig holds consumption tax configuration
+type PricingConfig struct {
+	// TaxRates lists "effective date=percent" entries, e.g. "2014-04-01=8;2019-10-01=10"
+	TaxRates string `json:"tax_rates"`
+	// TaxRounding is "down", "half_up" or "up"
+	TaxRounding string `json:"tax_rounding"`
+}
+
+// DuplicateConfig selects the duplicate registration checks run besides the email address
+type DuplicateConfig struct {
+	// Strategies lists the detection strategies in the order they run: email, email_name,
+	// phone, name_address, or none
+	Strategies []string `json:"strategies"`
+	// MinConfidence is the match confidence from which a registration is rejected
+	MinConfidence float64 `json:"min_confidence"`
+}
+
+// SecurityConfig holds security event monitoring and automatic IP blocking configuration
+type SecurityConfig struct {
+	MonitorEnabled bool `json:"monitor_enabled"`
+	// Window is how far back events from a client IP are counted toward a block
+	Window time.Duration `json:"window"`
+	// Thresholds are the events of a type within Window that block the client IP; 0 only records them
+	CSRFFailureThreshold       int `json:"csrf_failure_threshold"`
+	RateLimitThreshold         int `json:"rate_limit_threshold"`
+	ValidationFailureThreshold int `json:"validation_failure_threshold"`
+
+	BlockDuration time.Duration `json:"block_duration"`
+	// RefreshInterval is how often blocks set or lifted by other instances are reloaded
+	RefreshInterval time.Duration `json:"refresh_interval"`
+	// Retention is how long events are kept for review
+	Retention time.Duration `json:"retention"`
+	// QueueSize bounds the events waiting to be recorded; events beyond it are dropped
+	QueueSize int `json:"queue_size"`
+}
+
+// UserQueueConfig holds the workers creating users accepted while the queue_user_creation
+// feature flag is on
+type UserQueueConfig struct {
+	Workers      int           `json:"workers"`
+	PollInterval time.Duration `json:"poll_interval"`
+	// Lease is how long a ticket stays claimed by a worker before another may take it over
+	Lease          time.Duration `json:"lease"`
+	MaxAttempts    int           `json:"max_attempts"`
+	RetryBaseDelay time.Duration `json:"retry_base_delay"`
+	// Retention is how long finished tickets can be polled
+	Retention time.Duration `json:"retention"`
+}
+
+// CaptchaConfig holds captcha verification of registrations
+type CaptchaConfig struct {
+	// Driver is "none" (development default), "hcaptcha" or "recaptcha"; GO_ENV=test always uses none
+	Driver    string `json:"driver"`
+	SecretKey string `json:"-"`
+	SiteKey   string `json:"site_key"`
+	// VerifyURL overrides the provider's siteverify endpoint
+	VerifyURL string        `json:"verify_url"`
+	MinScore  float64       `json:"min_score"`
+	Timeout   time.Duration `json:"timeout"`
+	// VerifiedTTL is how long a verified token is accepted again, so that a registration can be
+	// validated and submitted with the same token
+	VerifiedTTL time.Duration `json:"verified_ttl"`
+}
+
+// BotDetectionConfig holds honeypot and submission timing checks of registrations
+type BotDetectionConfig struct {
+	// Enabled is off by default; GO_ENV=test always disables it
+	Enabled bool `json:"enabled"`
+	// SigningKey signs the form tokens and must be shared by every instance
+	SigningKey string `json:"-"`
+	// MinFillTime is the least time between issuing the form and submitting it
+	MinFillTime time.Duration `json:"min_fill_time"`
+	MaxTokenAge time.Duration `json:"max_token_age"`
+}
+
+// ValidationTelemetryConfig holds the anonymous counting of validation failures per form stage
+type ValidationTelemetryConfig struct {
+	Enabled bool `json:"enabled"`
+	// FlushInterval is how often the counts are written to the database
+	FlushInterval time.Duration `json:"flush_interval"`
+}
+
+// InspectionConfig holds the inspection of JSON request bodies of the form and partner APIs
+type InspectionConfig struct {
+	Enabled bool `json:"enabled"`
+	// MaxBodyBytes, MaxDepth and MaxArrayLength are unlimited when 0
+	MaxBodyBytes       int64 `json:"max_body_bytes"`
+	MaxDepth           int   `json:"max_depth"`
+	MaxArrayLength     int   `json:"max_array_length"`
+	RejectControlChars bool  `json:"reject_control_chars"`
+	// InjectionChecks lists the injection patterns rejected: sql, script, path_traversal, or none
+	InjectionChecks []string `json:"injection_checks"`
+}
+
+// StrictModeConfig selects the routes that reject requests, instead of falling back to local
+// data, when an external API cannot verify them
+type StrictModeConfig struct {
+	// Classes lists the route classes served in strict mode, submit or browse; empty disables it
+	Classes []string `json:"classes"`
+	// Routes lists further routes served in strict mode, by operation ID
+	Routes []string `json:"routes"`
+}
+
+// APIVersionsConfig holds the lifecycle of API versions
+type APIVersionsConfig struct {
+	// V1Sunset is the date (YYYY-MM-DD) version 1 routes carried over to version 2 stop being
+	// served, announced in their Sunset header; empty announces no date
+	V1Sunset string `json:"v1_sunset"`
+}
+
+// ReadinessConfig holds the load criteria that fail the readiness probe, so that instances
+// which could only answer 503 are taken out of the load balancer. Each is off when zero.
+type ReadinessConfig struct {
+	// DBPoolMaxUsage fails readiness when this share of the connection pool is in use, e.g.
+	// 1.0 for an exhausted pool
+	DBPoolMaxUsage float64 `json:"db_pool_max_usage"`
+	// QueueMaxBacklog fails readiness when more queued registrations than this are due
+	QueueMaxBacklog int `json:"queue_max_backlog"`
+	// CircuitBreakers lists the external APIs (inventory, region, address) whose open circuit
+	// breaker fails readiness
+	CircuitBreakers []string `json:"circuit_breakers"`
+}
+
+// HealthConfig holds the external API checks of GET /health
+type HealthConfig struct {
+	// ExternalChecks calls the configured external APIs on health checks
+	ExternalChecks bool `json:"external_checks"`
+	// CriticalAPIs lists the external APIs (inventory, region, address) whose failure makes
+	// the service unhealthy; the failure of the others only degrades it
+	CriticalAPIs []string `json:"critical_apis"`
+	// ExternalCacheTTL is how long external API results are reused, so that frequent health
+	// checks do not call the APIs every time
+	ExternalCacheTTL time.Duration `json:"external_cache_ttl"`
+	// ExternalTimeout bounds the external API calls of a health check
+	ExternalTimeout time.Duration `json:"external_timeout"`
+}
+
+// LoadConfig loads configuration from environment variables. It fails when Debug is
+// enabled in production.
 func LoadConfig() (*Config, error) {
 	// Load .env file if it exists
 	_ = godotenv.Load() // .env file not found is not an error
 
+	// Default backend for security stores (rate limit buckets, CSRF tokens)
+	securityStoreBackend := getEnv("SECURITY_STORE_BACKEND", "memory")
+	mode := getEnv("GO_ENV", "development")
+
 	config := &Config{
+		Debug: getEnvAsBool("DEBUG", mode == "development"),
 		Server: ServerConfig{
 			Port: getEnv("PORT", "8080"),
 			Host: getEnv("HOST", "0.0.0.0"),
-			Mode: getEnv("GO_ENV", "development"),
+			Mode: mode,
+
+			TrustedPlatform: getEnv("TRUSTED_PLATFORM", ""),
+			TrustedProxies:  getEnvAsSlice("TRUSTED_PROXIES", nil),
+			ProxyDepth:      getEnvAsInt("PROXY_DEPTH", 0),
+
+			AdminPort:         getEnv("ADMIN_PORT", ""),
+			AdminHost:         getEnv("ADMIN_HOST", "0.0.0.0"),
+			AdminReadTimeout:  getEnvAsDuration("ADMIN_READ_TIMEOUT", 5*time.Second),
+			AdminWriteTimeout: getEnvAsDuration("ADMIN_WRITE_TIMEOUT", 60*time.Second),
+
+			MaxConnections:      getEnvAsInt("MAX_CONNECTIONS", 0),
+			MaxConnectionsPerIP: getEnvAsInt("MAX_CONNECTIONS_PER_IP", 0),
+
+			TLSCertFile:         getEnv("TLS_CERT_FILE", ""),
+			TLSKeyFile:          getEnv("TLS_KEY_FILE", ""),
+			TLSAutocertDomains:  getEnvAsSlice("TLS_AUTOCERT_DOMAINS", nil),
+			TLSAutocertEmail:    getEnv("TLS_AUTOCERT_EMAIL", ""),
+			TLSAutocertCacheDir: getEnv("TLS_AUTOCERT_CACHE_DIR", "./autocert"),
+			TLSMinVersion:       getEnv("TLS_MIN_VERSION", "1.2"),
+			HTTPRedirectPort:    getEnv("HTTP_REDIRECT_PORT", ""),
+
+			Socket:     getEnv("SERVER_SOCKET", ""),
+			SocketMode: getEnv("SERVER_SOCKET_MODE", "0660"),
+
+			PIDFile:       getEnv("PID_FILE", ""),
+			ReloadTimeout: getEnvAsDuration("RELOAD_TIMEOUT", 60*time.Second),
 		},
 		Database: database.Config{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -69,7 +633,10 @@ func LoadConfig() (*Config, error) {
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
 		},
 		Log: LogConfig{
-			Level: getEnv("LOG_LEVEL", "info"),
+			Level:          getEnv("LOG_LEVEL", "info"),
+			Bodies:         getEnvAsBool("LOG_BODIES", false),
+			BodySampleRate: getEnvAsFloat("LOG_BODY_SAMPLE_RATE", 0),
+			BodyMaxBytes:   getEnvAsInt("LOG_BODY_MAX_BYTES", 16*1024),
 		},
 		ExternalAPI: ExternalAPIConfig{
 			InventoryAPI: APIConfig{
@@ -77,25 +644,336 @@ func LoadConfig() (*Config, error) {
 				Timeout:    getEnvAsDuration("INVENTORY_API_TIMEOUT", 30*time.Second),
 				MaxRetries: getEnvAsInt("INVENTORY_API_MAX_RETRIES", 3),
 				RetryDelay: getEnvAsDuration("INVENTORY_API_RETRY_DELAY", 1*time.Second),
+
+				MaxRetryDelay:      getEnvAsDuration("INVENTORY_API_MAX_RETRY_DELAY", 10*time.Second),
+				EndpointMaxRetries: getEnvAsIntMap("INVENTORY_API_ENDPOINT_RETRIES"),
+
+				CircuitBreakerThreshold: getEnvAsInt("INVENTORY_API_CIRCUIT_BREAKER_THRESHOLD", 5),
+				CircuitBreakerTimeout:   getEnvAsDuration("INVENTORY_API_CIRCUIT_BREAKER_TIMEOUT", 30*time.Second),
+
+				SandboxBaseURL: getEnv("INVENTORY_API_SANDBOX_URL", ""),
+
+				CacheTTL:        getEnvAsDuration("INVENTORY_API_CACHE_TTL", 0),
+				CacheMaxEntries: getEnvAsInt("INVENTORY_API_CACHE_MAX_ENTRIES", 1000),
 			},
 			RegionAPI: APIConfig{
 				BaseURL:    getEnv("REGION_API_URL", ""),
 				Timeout:    getEnvAsDuration("REGION_API_TIMEOUT", 30*time.Second),
 				MaxRetries: getEnvAsInt("REGION_API_MAX_RETRIES", 3),
 				RetryDelay: getEnvAsDuration("REGION_API_RETRY_DELAY", 1*time.Second),
+
+				MaxRetryDelay:      getEnvAsDuration("REGION_API_MAX_RETRY_DELAY", 10*time.Second),
+				EndpointMaxRetries: getEnvAsIntMap("REGION_API_ENDPOINT_RETRIES"),
+
+				CircuitBreakerThreshold: getEnvAsInt("REGION_API_CIRCUIT_BREAKER_THRESHOLD", 5),
+				CircuitBreakerTimeout:   getEnvAsDuration("REGION_API_CIRCUIT_BREAKER_TIMEOUT", 30*time.Second),
+
+				SandboxBaseURL: getEnv("REGION_API_SANDBOX_URL", ""),
+
+				CacheTTL:        getEnvAsDuration("REGION_API_CACHE_TTL", 5*time.Minute),
+				CacheMaxEntries: getEnvAsInt("REGION_API_CACHE_MAX_ENTRIES", 1000),
 			},
 			AddressAPI: APIConfig{
 				BaseURL:    getEnv("ADDRESS_API_URL", ""),
 				Timeout:    getEnvAsDuration("ADDRESS_API_TIMEOUT", 30*time.Second),
 				MaxRetries: getEnvAsInt("ADDRESS_API_MAX_RETRIES", 3),
 				RetryDelay: getEnvAsDuration("ADDRESS_API_RETRY_DELAY", 1*time.Second),
+
+				MaxRetryDelay:      getEnvAsDuration("ADDRESS_API_MAX_RETRY_DELAY", 10*time.Second),
+				EndpointMaxRetries: getEnvAsIntMap("ADDRESS_API_ENDPOINT_RETRIES"),
+
+				CircuitBreakerThreshold: getEnvAsInt("ADDRESS_API_CIRCUIT_BREAKER_THRESHOLD", 5),
+				CircuitBreakerTimeout:   getEnvAsDuration("ADDRESS_API_CIRCUIT_BREAKER_TIMEOUT", 30*time.Second),
+
+				SandboxBaseURL: getEnv("ADDRESS_API_SANDBOX_URL", ""),
+
+				CacheTTL:        getEnvAsDuration("ADDRESS_API_CACHE_TTL", 10*time.Minute),
+				CacheMaxEntries: getEnvAsInt("ADDRESS_API_CACHE_MAX_ENTRIES", 1000),
 			},
+			AddressProvider: getEnv("ADDRESS_API_PROVIDER", "custom"),
+			AddressAPIKey:   getEnv("ADDRESS_API_KEY", ""),
+
+			AvailabilityTimeout:     getEnvAsDuration("AVAILABILITY_CHECK_TIMEOUT", 10*time.Second),
+			AvailabilityCallTimeout: getEnvAsDuration("AVAILABILITY_CALL_TIMEOUT", 5*time.Second),
+
+			EgressAllowedHosts:    getEnvAsSlice("EXTERNAL_API_ALLOWED_HOSTS", nil),
+			EgressBlockPrivateIPs: getEnvAsBool("EXTERNAL_API_BLOCK_PRIVATE_IPS", false),
+			EgressPinDNS:          getEnvAsBool("EXTERNAL_API_PIN_DNS", false),
+			EgressPinTTL:          getEnvAsDuration("EXTERNAL_API_DNS_PIN_TTL", 5*time.Minute),
+		},
+		Webhook: WebhookConfig{
+			URL:     getEnv("WEBHOOK_URL", ""),
+			Secret:  getEnv("WEBHOOK_SECRET", ""),
+			Timeout: getEnvAsDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+			RetrySchedule: getEnvAsDurationSlice("WEBHOOK_RETRY_SCHEDULE", []time.Duration{
+				1 * time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 16 * time.Minute,
+				32 * time.Minute, 1 * time.Hour, 2 * time.Hour, 4 * time.Hour, 8 * time.Hour,
+			}),
+			PollInterval: getEnvAsDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second),
+			BatchSize:    getEnvAsInt("WEBHOOK_BATCH_SIZE", 20),
+		},
+		Outbox: OutboxConfig{
+			Enabled:        getEnvAsBool("OUTBOX_RELAY_ENABLED", true),
+			PollInterval:   getEnvAsDuration("OUTBOX_POLL_INTERVAL", 5*time.Second),
+			BatchSize:      getEnvAsInt("OUTBOX_BATCH_SIZE", 50),
+			MaxAttempts:    getEnvAsInt("OUTBOX_MAX_ATTEMPTS", 10),
+			RetryBaseDelay: getEnvAsDuration("OUTBOX_RETRY_BASE_DELAY", 30*time.Second),
+			Lease:          getEnvAsDuration("OUTBOX_LEASE", 5*time.Minute),
+		},
+		Events: EventsConfig{
+			Driver:            getEnv("EVENTS_DRIVER", "noop"),
+			Source:            getEnv("EVENTS_SOURCE", "normal-form-app"),
+			Timeout:           getEnvAsDuration("EVENTS_TIMEOUT", 5*time.Second),
+			KafkaRESTProxyURL: getEnv("KAFKA_REST_PROXY_URL", ""),
+			KafkaTopic:        getEnv("KAFKA_TOPIC", "normal-form-events"),
+			NATSURL:           getEnv("NATS_URL", "nats://localhost:4222"),
+			NATSSubjectPrefix: getEnv("NATS_SUBJECT_PREFIX", "normal-form"),
+		},
+		Admin: AdminConfig{
+			APIToken: getEnv("ADMIN_API_TOKEN", ""),
+		},
+		RateLimit: RateLimitConfig{
+			Backend:       getEnv("RATE_LIMIT_BACKEND", securityStoreBackend),
+			DefaultLimit:  getEnvAsInt("RATE_LIMIT_DEFAULT_LIMIT", 100),
+			DefaultPeriod: getEnvAsDuration("RATE_LIMIT_DEFAULT_PERIOD", 1*time.Minute),
+			DefaultBurst:  getEnvAsInt("RATE_LIMIT_DEFAULT_BURST", 100),
+			Rules: getEnv("RATE_LIMIT_RULES",
+				"POST /api/v1/users/validate=20/1m:5;POST /api/v1/users=10/1m:3;"+
+					"POST /api/v1/users/lookup=5/10m:2;POST /api/v1/users/lookup/verify=10/10m:5;"+
+					"POST /api/v1/users/phone/send-code=5/10m:2;POST /api/v1/users/phone/verify-code=10/10m:5;"+
+					"POST /api/v1/attachments=10/10m:3;admin=60/1m:20;partner=600/1m:60"),
+			MemoryMaxEntries: getEnvAsInt("RATE_LIMIT_MEMORY_MAX_ENTRIES", 100000),
+		},
+		CSRF: CSRFConfig{
+			Backend:         getEnv("CSRF_STORE_BACKEND", securityStoreBackend),
+			MemoryMaxTokens: getEnvAsInt("CSRF_MEMORY_MAX_TOKENS", 100000),
+		},
+		Redis: RedisConfig{
+			Addr:      getEnv("REDIS_ADDR", ""),
+			Password:  getEnv("REDIS_PASSWORD", ""),
+			DB:        getEnvAsInt("REDIS_DB", 0),
+			PoolSize:  getEnvAsInt("REDIS_POOL_SIZE", 10),
+			KeyPrefix: getEnv("REDIS_KEY_PREFIX", "normal-form:"),
+		},
+		Cache: CacheConfig{
+			MasterDataEnabled: getEnvAsBool("MASTER_DATA_CACHE_ENABLED", true),
+			MasterDataTTL:     getEnvAsDuration("MASTER_DATA_CACHE_TTL", 10*time.Minute),
+			InventoryTTL:      getEnvAsDuration("INVENTORY_CACHE_TTL", 10*time.Second),
+			InventoryStaleTTL: getEnvAsDuration("INVENTORY_CACHE_STALE_TTL", time.Minute),
+		},
+		Inventory: InventoryConfig{
+			LowStockThreshold:  getEnvAsInt("INVENTORY_LOW_STOCK_THRESHOLD", 3),
+			LowStockThresholds: getEnvAsIntMap("INVENTORY_LOW_STOCK_THRESHOLDS"),
+		},
+		Recommend: RecommendConfig{
+			PopularityTTL: getEnvAsDuration("RECOMMEND_POPULARITY_TTL", 10*time.Minute),
+			MinSample:     getEnvAsInt("RECOMMEND_MIN_SAMPLE", 30),
+		},
+		Privacy: PrivacyConfig{
+			DeletionRecordRetention:     getEnvAsDuration("DELETION_RECORD_RETENTION", 5*365*24*time.Hour),
+			DeletionRecordPurgeInterval: getEnvAsDuration("DELETION_RECORD_PURGE_INTERVAL", 24*time.Hour),
+			EmailHashKey:                getEnv("DELETION_EMAIL_HASH_KEY", ""),
+			EncryptionKeys:              getEnv("PII_ENCRYPTION_KEYS", ""),
+			EncryptionKeyID:             getEnv("PII_ENCRYPTION_KEY_ID", ""),
+			BlindIndexKey:               getEnv("PII_BLIND_INDEX_KEY", ""),
+		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", "log"),
+			From:         getEnv("MAIL_FROM", "Normal Form App <no-reply@example.com>"),
+			Timeout:      getEnvAsDuration("MAIL_TIMEOUT", 10*time.Second),
+			SMTPHost:     getEnv("SMTP_HOST", ""),
+			SMTPPort:     getEnvAsInt("SMTP_PORT", 587),
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			RateLimit:    getEnvAsFloat("MAIL_RATE_LIMIT", 0),
+
+			SESRegion:           getEnv("SES_REGION", getEnv("AWS_REGION", "")),
+			SESAccessKeyID:      getEnv("SES_ACCESS_KEY_ID", getEnv("AWS_ACCESS_KEY_ID", "")),
+			SESSecretAccessKey:  getEnv("SES_SECRET_ACCESS_KEY", getEnv("AWS_SECRET_ACCESS_KEY", "")),
+			SESSessionToken:     getEnv("SES_SESSION_TOKEN", getEnv("AWS_SESSION_TOKEN", "")),
+			SESConfigurationSet: getEnv("SES_CONFIGURATION_SET", ""),
+			SESWebhookTopicARNs: getEnvAsSlice("SES_WEBHOOK_TOPIC_ARNS", nil),
+
+			SendGridAPIKey:           getEnv("SENDGRID_API_KEY", ""),
+			SendGridWebhookPublicKey: getEnv("SENDGRID_WEBHOOK_PUBLIC_KEY", ""),
+		},
+		Notification: NotificationConfig{
+			ConfirmationEnabled: getEnvAsBool("NOTIFICATION_CONFIRMATION_ENABLED", true),
+			PollInterval:        getEnvAsDuration("NOTIFICATION_POLL_INTERVAL", 5*time.Second),
+			BatchSize:           getEnvAsInt("NOTIFICATION_BATCH_SIZE", 20),
+			MaxAttempts:         getEnvAsInt("NOTIFICATION_MAX_ATTEMPTS", 8),
+			RetryBaseDelay:      getEnvAsDuration("NOTIFICATION_RETRY_BASE_DELAY", 1*time.Minute),
+		},
+		Partner: PartnerConfig{
+			UsageFlushInterval: getEnvAsDuration("PARTNER_USAGE_FLUSH_INTERVAL", 1*time.Minute),
+		},
+		Lookup: LookupConfig{
+			CodeTTL:     getEnvAsDuration("USER_LOOKUP_CODE_TTL", 10*time.Minute),
+			MaxAttempts: getEnvAsInt("USER_LOOKUP_MAX_ATTEMPTS", 5),
+		},
+		SMS: SMSConfig{
+			Driver:     getEnv("SMS_DRIVER", "log"),
+			From:       getEnv("SMS_FROM", ""),
+			Timeout:    getEnvAsDuration("SMS_TIMEOUT", 10*time.Second),
+			GatewayURL: getEnv("SMS_GATEWAY_URL", ""),
+			APIKey:     getEnv("SMS_API_KEY", ""),
+		},
+		Phone: PhoneConfig{
+			CodeTTL:        getEnvAsDuration("PHONE_VERIFICATION_CODE_TTL", 5*time.Minute),
+			MaxAttempts:    getEnvAsInt("PHONE_VERIFICATION_MAX_ATTEMPTS", 5),
+			ResendInterval: getEnvAsDuration("PHONE_VERIFICATION_RESEND_INTERVAL", time.Minute),
+			VerifiedTTL:    getEnvAsDuration("PHONE_VERIFICATION_VERIFIED_TTL", time.Hour),
+		},
+		Upload: UploadConfig{
+			Dir:           getEnv("UPLOAD_DIR", "./data/uploads"),
+			QuarantineDir: getEnv("UPLOAD_QUARANTINE_DIR", "./data/quarantine"),
+			MaxBytes:      int64(getEnvAsInt("UPLOAD_MAX_BYTES", 10<<20)),
+		},
+		Reservation: ReservationConfig{
+			TTL:             getEnvAsDuration("RESERVATION_TTL", 15*time.Minute),
+			ReleaseInterval: getEnvAsDuration("RESERVATION_RELEASE_INTERVAL", time.Minute),
+		},
+		Scan: ScanConfig{
+			Driver:     getEnv("SCAN_DRIVER", "none"),
+			Timeout:    getEnvAsDuration("SCAN_TIMEOUT", 30*time.Second),
+			ClamAVAddr: getEnv("CLAMAV_ADDR", ""),
+			ICAPURL:    getEnv("ICAP_URL", ""),
+
+			PollInterval:   getEnvAsDuration("SCAN_POLL_INTERVAL", 2*time.Second),
+			BatchSize:      getEnvAsInt("SCAN_BATCH_SIZE", 10),
+			MaxAttempts:    getEnvAsInt("SCAN_MAX_ATTEMPTS", 5),
+			RetryBaseDelay: getEnvAsDuration("SCAN_RETRY_BASE_DELAY", 30*time.Second),
+		},
+		Features: FeaturesConfig{
+			SkipInventoryCheck:       getEnvAsBool("FEATURE_SKIP_INVENTORY_CHECK", false),
+			DisableRegionRestriction: getEnvAsBool("FEATURE_DISABLE_REGION_RESTRICTION", false),
+			ReadOnlyMode:             getEnvAsBool("FEATURE_READ_ONLY_MODE", false),
+			QueueUserCreation:        getEnvAsBool("FEATURE_QUEUE_USER_CREATION", false),
+			RefreshInterval:          getEnvAsDuration("FEATURE_FLAG_REFRESH_INTERVAL", 30*time.Second),
+		},
+		Masking: MaskingConfig{
+			Policy: getEnv("MASKING_POLICY",
+				"public:email=email,phone=last4;self:name=first_char,email=email,phone=last4"),
+		},
+		Session: SessionConfig{
+			IdleTimeout: getEnvAsDuration("SESSION_IDLE_TIMEOUT", 4*time.Hour),
+			MaxLifetime: getEnvAsDuration("SESSION_MAX_LIFETIME", 24*time.Hour),
+
+			MaxPerClientIP: getEnvAsInt("SESSION_MAX_PER_IP", 50),
+			MaxPerEmail:    getEnvAsInt("SESSION_MAX_PER_EMAIL", 5),
+			EvictOldest:    getEnvAsBool("SESSION_EVICT_OLDEST", false),
+
+			WizardRequired: getEnvAsBool("SESSION_WIZARD_REQUIRED", false),
+
+			MaxDataBytes: getEnvAsInt("SESSION_MAX_DATA_BYTES", 32*1024),
+			AllowedKeys:  getEnvAsSlice("SESSION_ALLOWED_KEYS", nil),
+		},
+		Validation: ValidationConfig{
+			RulesSource: getEnv("VALIDATION_RULES_SOURCE", "database"),
+			RulesFile:   getEnv("VALIDATION_RULES_FILE", ""),
+		},
+		Pricing: PricingConfig{
+			TaxRates:    getEnv("TAX_RATES", "1989-04-01=3;1997-04-01=5;2014-04-01=8;2019-10-01=10"),
+			TaxRounding: getEnv("TAX_ROUNDING", "down"),
+		},
+		Duplicate: DuplicateConfig{
+			Strategies:    getEnvAsSlice("DUPLICATE_STRATEGIES", defaultDuplicateStrategies()),
+			MinConfidence: getEnvAsFloat("DUPLICATE_MIN_CONFIDENCE", 0.9),
+		},
+		Security: SecurityConfig{
+			MonitorEnabled: getEnvAsBool("SECURITY_MONITOR_ENABLED", true),
+			Window:         getEnvAsDuration("SECURITY_EVENT_WINDOW", 10*time.Minute),
+
+			CSRFFailureThreshold:       getEnvAsInt("SECURITY_CSRF_FAILURE_THRESHOLD", 20),
+			RateLimitThreshold:         getEnvAsInt("SECURITY_RATE_LIMIT_THRESHOLD", 50),
+			ValidationFailureThreshold: getEnvAsInt("SECURITY_VALIDATION_FAILURE_THRESHOLD", 30),
+
+			BlockDuration:   getEnvAsDuration("SECURITY_BLOCK_DURATION", 30*time.Minute),
+			RefreshInterval: getEnvAsDuration("SECURITY_BLOCK_REFRESH_INTERVAL", 30*time.Second),
+			Retention:       getEnvAsDuration("SECURITY_EVENT_RETENTION", 30*24*time.Hour),
+			QueueSize:       getEnvAsInt("SECURITY_EVENT_QUEUE_SIZE", 1000),
+		},
+		UserQueue: UserQueueConfig{
+			Workers:        getEnvAsInt("USER_QUEUE_WORKERS", 4),
+			PollInterval:   getEnvAsDuration("USER_QUEUE_POLL_INTERVAL", 2*time.Second),
+			Lease:          getEnvAsDuration("USER_QUEUE_LEASE", 1*time.Minute),
+			MaxAttempts:    getEnvAsInt("USER_QUEUE_MAX_ATTEMPTS", 5),
+			RetryBaseDelay: getEnvAsDuration("USER_QUEUE_RETRY_BASE_DELAY", 5*time.Second),
+			Retention:      getEnvAsDuration("USER_QUEUE_RETENTION", 24*time.Hour),
+		},
+		Captcha: CaptchaConfig{
+			Driver:      getEnv("CAPTCHA_DRIVER", "none"),
+			SecretKey:   getEnv("CAPTCHA_SECRET_KEY", ""),
+			SiteKey:     getEnv("CAPTCHA_SITE_KEY", ""),
+			VerifyURL:   getEnv("CAPTCHA_VERIFY_URL", ""),
+			MinScore:    getEnvAsFloat("CAPTCHA_MIN_SCORE", 0.5),
+			Timeout:     getEnvAsDuration("CAPTCHA_TIMEOUT", 5*time.Second),
+			VerifiedTTL: getEnvAsDuration("CAPTCHA_VERIFIED_TTL", 2*time.Minute),
+		},
+		BotDetection: BotDetectionConfig{
+			Enabled:     getEnvAsBool("BOT_DETECTION_ENABLED", false),
+			SigningKey:  getEnv("BOT_DETECTION_SIGNING_KEY", ""),
+			MinFillTime: getEnvAsDuration("BOT_DETECTION_MIN_FILL_TIME", 3*time.Second),
+			MaxTokenAge: getEnvAsDuration("BOT_DETECTION_MAX_TOKEN_AGE", 24*time.Hour),
+		},
+		ValidationTelemetry: ValidationTelemetryConfig{
+			Enabled:       getEnvAsBool("VALIDATION_TELEMETRY_ENABLED", true),
+			FlushInterval: getEnvAsDuration("VALIDATION_TELEMETRY_FLUSH_INTERVAL", 1*time.Minute),
+		},
+		Inspection: InspectionConfig{
+			Enabled:            getEnvAsBool("REQUEST_INSPECTION_ENABLED", true),
+			MaxBodyBytes:       int64(getEnvAsInt("REQUEST_MAX_BODY_BYTES", 1<<20)),
+			MaxDepth:           getEnvAsInt("REQUEST_MAX_JSON_DEPTH", 10),
+			MaxArrayLength:     getEnvAsInt("REQUEST_MAX_JSON_ARRAY_LENGTH", 100),
+			RejectControlChars: getEnvAsBool("REQUEST_REJECT_CONTROL_CHARS", true),
+			InjectionChecks: getEnvAsSlice("REQUEST_INJECTION_CHECKS",
+				[]string{"sql", "script", "path_traversal"}),
+		},
+		StrictMode: StrictModeConfig{
+			Classes: getEnvAsSlice("STRICT_MODE", nil),
+			Routes:  getEnvAsSlice("STRICT_MODE_ROUTES", nil),
+		},
+		APIVersions: APIVersionsConfig{
+			V1Sunset: getEnv("API_V1_SUNSET", ""),
+		},
+		Readiness: ReadinessConfig{
+			DBPoolMaxUsage:  getEnvAsFloat("READINESS_DB_POOL_MAX_USAGE", 0),
+			QueueMaxBacklog: getEnvAsInt("READINESS_QUEUE_MAX_BACKLOG", 0),
+			CircuitBreakers: getEnvAsSlice("READINESS_CIRCUIT_BREAKERS", nil),
+		},
+		Health: HealthConfig{
+			ExternalChecks:   getEnvAsBool("HEALTH_EXTERNAL_CHECKS", true),
+			CriticalAPIs:     getEnvAsSlice("HEALTH_CRITICAL_APIS", nil),
+			ExternalCacheTTL: getEnvAsDuration("HEALTH_EXTERNAL_CACHE_TTL", 30*time.Second),
+			ExternalTimeout:  getEnvAsDuration("HEALTH_EXTERNAL_TIMEOUT", 5*time.Second),
 		},
 	}
 
+	// Debug surfaces expose internals, so a production build refuses to start with them
+	if config.Debug && config.IsProduction() {
+		return nil, errors.New("DEBUG must not be enabled when GO_ENV is production")
+	}
+
 	return config, nil
 }
 
+// defaultDuplicateStrategies returns the duplicate checks run when DUPLICATE_STRATEGIES is
+// not set: phone and name_address, less those turned off with the DUPLICATE_CHECK_PHONE and
+// DUPLICATE_CHECK_NAME_ADDRESS settings it replaces
+func defaultDuplicateStrategies() []string {
+	var strategies []string
+	if getEnvAsBool("DUPLICATE_CHECK_PHONE", true) {
+		strategies = append(strategies, "phone")
+	}
+	if getEnvAsBool("DUPLICATE_CHECK_NAME_ADDRESS", true) {
+		strategies = append(strategies, "name_address")
+	}
+	if len(strategies) == 0 {
+		return []string{"none"}
+	}
+	return strategies
+}
+
 // getEnv gets an environment variable or returns a default value
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -114,6 +992,57 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// getEnvAsFloat gets an environment variable as a float or returns a default value
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsBool gets an environment variable as boolean or returns a default value
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsSlice gets a comma-separated environment variable as a slice or returns a default value
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// getEnvAsIntMap gets a comma-separated list of key=number pairs; malformed pairs are skipped
+func getEnvAsIntMap(key string) map[string]int {
+	result := make(map[string]int)
+	for _, item := range getEnvAsSlice(key, nil) {
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			continue
+		}
+		if number, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+			result[strings.TrimSpace(name)] = number
+		}
+	}
+	return result
+}
+
 // getEnvAsDuration gets an environment variable as duration or returns a default value
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	if value := os.Getenv(key); value != "" {
@@ -124,6 +1053,25 @@ func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
 	return defaultValue
 }
 
+// getEnvAsDurationSlice gets a comma-separated list of durations or returns a default value
+// when it is unset or any item is malformed
+func getEnvAsDurationSlice(key string, defaultValue []time.Duration) []time.Duration {
+	items := getEnvAsSlice(key, nil)
+	if len(items) == 0 {
+		return defaultValue
+	}
+
+	result := make([]time.Duration, 0, len(items))
+	for _, item := range items {
+		duration, err := time.ParseDuration(item)
+		if err != nil {
+			return defaultValue
+		}
+		result = append(result, duration)
+	}
+	return result
+}
+
 // IsProduction returns true if the application is running in production mode
 func (c *Config) IsProduction() bool {
 	return c.Server.Mode == "production"
@@ -134,7 +1082,27 @@ func (c *Config) IsDevelopment() bool {
 	return c.Server.Mode == "development"
 }
 
+// IsTest returns true if the application is running in test mode
+func (c *Config) IsTest() bool {
+	return c.Server.Mode == "test"
+}
+
 // GetServerAddress returns the server address
 func (c *Config) GetServerAddress() string {
 	return c.Server.Host + ":" + c.Server.Port
 }
+
+// TLSEnabled reports whether the public listener serves HTTPS
+func (c *Config) TLSEnabled() bool {
+	return c.Server.TLSCertFile != "" || len(c.Server.TLSAutocertDomains) > 0
+}
+
+// GetRedirectAddress returns the address of the HTTP to HTTPS redirect listener
+func (c *Config) GetRedirectAddress() string {
+	return c.Server.Host + ":" + c.Server.HTTPRedirectPort
+}
+
+// GetAdminAddress returns the address of the internal operations listener
+func (c *Config) GetAdminAddress() string {
+	return c.Server.AdminHost + ":" + c.Server.AdminPort
+}
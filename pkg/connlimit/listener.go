@@ -0,0 +1,147 @@
+// Package connlimit caps the connections a listener hands to the HTTP server.
+//
+// Limits are enforced before any request is read, so they also hold against clients that
+// open connections and never finish a request, which middleware cannot see.
+package connlimit
+
+import (
+	"net"
+	"sync"
+)
+
+// Config sets the connection limits; zero values leave a limit off
+type Config struct {
+	// MaxConnections caps open connections in total. Once reached, Accept waits for a
+	// connection to close and new connections queue in the kernel backlog.
+	MaxConnections int
+	// MaxConnectionsPerIP caps open connections from one remote IP. Connections beyond it
+	// are closed as soon as they are accepted.
+	MaxConnectionsPerIP int
+}
+
+// Listener is a net.Listener enforcing Config
+type Listener struct {
+	net.Listener
+	config Config
+
+	slots chan struct{} // nil when the total is not capped
+	done  chan struct{}
+
+	closeOnce sync.Once
+	mu        sync.Mutex
+	perIP     map[string]int
+}
+
+// NewListener wraps l with the configured limits
+func NewListener(l net.Listener, config Config) *Listener {
+	listener := &Listener{
+		Listener: l,
+		config:   config,
+		done:     make(chan struct{}),
+		perIP:    make(map[string]int),
+	}
+	if config.MaxConnections > 0 {
+		listener.slots = make(chan struct{}, config.MaxConnections)
+	}
+	return listener
+}
+
+// Accept waits for a free slot and returns the next connection within the per-IP limit
+func (l *Listener) Accept() (net.Conn, error) {
+	for {
+		if l.slots != nil {
+			select {
+			case l.slots <- struct{}{}:
+			case <-l.done:
+				return nil, net.ErrClosed
+			}
+		}
+
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			l.releaseSlot()
+			return nil, err
+		}
+
+		ip := remoteIP(conn)
+		if !l.acquireIP(ip) {
+			_ = conn.Close()
+			l.releaseSlot()
+			continue
+		}
+
+		return &limitedConn{Conn: conn, listener: l, ip: ip}, nil
+	}
+}
+
+// Close stops accepting connections and unblocks a pending Accept
+func (l *Listener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return l.Listener.Close()
+}
+
+// acquireIP counts a connection against its remote IP, or reports false when the IP is at
+// its limit. Connections without an IP, such as Unix sockets, are not limited per IP.
+func (l *Listener) acquireIP(ip string) bool {
+	if l.config.MaxConnectionsPerIP <= 0 || ip == "" {
+		return true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.perIP[ip] >= l.config.MaxConnectionsPerIP {
+		return false
+	}
+	l.perIP[ip]++
+	return true
+}
+
+// releaseIP undoes acquireIP
+func (l *Listener) releaseIP(ip string) {
+	if l.config.MaxConnectionsPerIP <= 0 || ip == "" {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.perIP[ip] <= 1 {
+		delete(l.perIP, ip)
+		return
+	}
+	l.perIP[ip]--
+}
+
+// releaseSlot frees a slot taken in Accept
+func (l *Listener) releaseSlot() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+// limitedConn gives its slot back when closed
+type limitedConn struct {
+	net.Conn
+	listener    *Listener
+	ip          string
+	releaseOnce sync.Once
+}
+
+// Close closes the connection and frees its slot; closing twice frees it once
+func (c *limitedConn) Close() error {
+	err := c.Conn.Close()
+	c.releaseOnce.Do(func() {
+		c.listener.releaseIP(c.ip)
+		c.listener.releaseSlot()
+	})
+	return err
+}
+
+// remoteIP returns the IP of a TCP peer, or "" for other connection types
+func remoteIP(conn net.Conn) string {
+	if addr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		return addr.IP.String()
+	}
+	return ""
+}
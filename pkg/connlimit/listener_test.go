@@ -0,0 +1,171 @@
+package connlimit
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeConn is a connection from a remote address that records whether it was closed
+type fakeConn struct {
+	net.Conn
+	remote net.Addr
+	closed atomic.Bool
+}
+
+func (c *fakeConn) RemoteAddr() net.Addr { return c.remote }
+func (c *fakeConn) Close() error         { c.closed.Store(true); return nil }
+
+// fakeListener hands out queued connections
+type fakeListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+}
+
+func newFakeListener() *fakeListener {
+	return &fakeListener{conns: make(chan net.Conn, 16), done: make(chan struct{})}
+}
+
+func (l *fakeListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *fakeListener) Close() error   { close(l.done); return nil }
+func (l *fakeListener) Addr() net.Addr { return &net.TCPAddr{} }
+
+// dial queues a connection from ip, or from a Unix socket when ip is empty
+func (l *fakeListener) dial(ip string) *fakeConn {
+	var remote net.Addr = &net.UnixAddr{Net: "unix"}
+	if ip != "" {
+		remote = &net.TCPAddr{IP: net.ParseIP(ip), Port: 40000}
+	}
+	conn := &fakeConn{remote: remote}
+	l.conns <- conn
+	return conn
+}
+
+func TestListener_PerIPLimit(t *testing.T) {
+	tests := []struct {
+		name string
+		// dials are the remote IPs of the connections, in order; "" is a Unix socket peer
+		dials    []string
+		accepted []bool
+	}{
+		{"under the limit", []string{"192.0.2.1", "192.0.2.1"}, []bool{true, true}},
+		{"over the limit", []string{"192.0.2.1", "192.0.2.1", "192.0.2.1", "192.0.2.2"}, []bool{true, true, false, true}},
+		{"Unix socket peers", []string{"", "", ""}, []bool{true, true, true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inner := newFakeListener()
+			l := NewListener(inner, Config{MaxConnectionsPerIP: 2})
+			defer l.Close()
+
+			conns := make([]*fakeConn, len(tt.dials))
+			for i, ip := range tt.dials {
+				conns[i] = inner.dial(ip)
+			}
+			// The last connection is always accepted, so every rejection happened before
+			// Accept returns it
+			accepted := 0
+			for _, want := range tt.accepted {
+				if want {
+					accepted++
+				}
+			}
+			for i := 0; i < accepted; i++ {
+				if _, err := l.Accept(); err != nil {
+					t.Fatalf("Accept: %v", err)
+				}
+			}
+			for i, conn := range conns {
+				if conn.closed.Load() == tt.accepted[i] {
+					t.Errorf("connection %d from %q: closed = %v, want accepted = %v", i+1, tt.dials[i], conn.closed.Load(), tt.accepted[i])
+				}
+			}
+		})
+	}
+}
+
+func TestListener_PerIPLimitReleasedOnClose(t *testing.T) {
+	inner := newFakeListener()
+	l := NewListener(inner, Config{MaxConnectionsPerIP: 1})
+	defer l.Close()
+
+	inner.dial("192.0.2.1")
+	first, err := l.Accept()
+	if err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	// Closing twice frees the IP's count once
+	_ = first.Close()
+	_ = first.Close()
+
+	second := inner.dial("192.0.2.1")
+	if _, err := l.Accept(); err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+	if second.closed.Load() {
+		t.Error("connection after the first closed was rejected")
+	}
+	if n := l.perIP["192.0.2.1"]; n != 1 {
+		t.Errorf("connections counted for the IP = %d, want 1", n)
+	}
+}
+
+func TestListener_MaxConnections(t *testing.T) {
+	inner := newFakeListener()
+	l := NewListener(inner, Config{MaxConnections: 1})
+
+	inner.dial("192.0.2.1")
+	first, err := l.Accept()
+	if err != nil {
+		t.Fatalf("Accept: %v", err)
+	}
+
+	inner.dial("192.0.2.2")
+	accepted := make(chan error, 1)
+	go func() {
+		_, err := l.Accept()
+		accepted <- err
+	}()
+
+	select {
+	case <-accepted:
+		t.Fatal("Accept returned a connection over the limit")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	_ = first.Close()
+	select {
+	case err := <-accepted:
+		if err != nil {
+			t.Fatalf("Accept: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Accept still waits after a connection closed")
+	}
+
+	// Close unblocks an Accept waiting for a slot
+	go func() {
+		_, err := l.Accept()
+		accepted <- err
+	}()
+	_ = l.Close()
+	select {
+	case err := <-accepted:
+		if !errors.Is(err, net.ErrClosed) {
+			t.Errorf("Accept after Close: err = %v, want net.ErrClosed", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Accept still waits after Close")
+	}
+}
@@ -0,0 +1,76 @@
+// Package correlation carries request and correlation identifiers through contexts.
+//
+// The request ID is generated by the server for every HTTP request. The correlation ID
+// is supplied by the frontend (or generated when absent) and is propagated to logs,
+// events, webhooks and outbound API calls so a customer journey can be traced end to end.
+package correlation
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// HTTP headers carrying the identifiers
+const (
+	HeaderRequestID     = "X-Request-ID"
+	HeaderCorrelationID = "X-Correlation-ID"
+)
+
+const (
+	maxIDLength = 128
+	idBytes     = 16
+)
+
+type requestIDKey struct{}
+
+type correlationIDKey struct{}
+
+// WithRequestID returns a context carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID stored in the context, or an empty string
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// WithCorrelationID returns a context carrying the correlation ID
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationID returns the correlation ID stored in the context, or an empty string
+func CorrelationID(ctx context.Context) string {
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// NewID generates a random identifier
+func NewID() string {
+	b := make([]byte, idBytes)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// IsValid reports whether an externally supplied ID is safe to log and forward.
+// Only short values of letters, digits and ".-_:" are accepted to prevent header and log injection.
+func IsValid(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
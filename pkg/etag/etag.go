@@ -0,0 +1,76 @@
+// Package etag generates entity tags and evaluates the conditional request headers that
+// carry them (RFC 9110).
+//
+// Tags are strong: two responses share a tag only when their representations are identical.
+package etag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// HTTP headers carrying entity tags
+const (
+	HeaderETag        = "ETag"
+	HeaderIfMatch     = "If-Match"
+	HeaderIfNoneMatch = "If-None-Match"
+)
+
+// tagHexLength is the number of hex digits of the hash kept in a tag
+const tagHexLength = 32
+
+// FromBytes returns the tag of a representation
+func FromBytes(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:])[:tagHexLength] + `"`
+}
+
+// FromParts returns the tag of a representation identified by parts, such as a resource ID
+// and the time it was last updated, without rendering it
+func FromParts(parts ...string) string {
+	return FromBytes([]byte(strings.Join(parts, "\x00")))
+}
+
+// MatchesAny evaluates If-Match: it reports whether the header is "*" or lists tag. Weak
+// tags never match, as If-Match uses the strong comparison.
+func MatchesAny(header, tag string) bool {
+	return matches(header, tag, false)
+}
+
+// NoneMatch evaluates If-None-Match: it reports whether the header neither is "*" nor lists
+// tag, comparing weakly so that W/ prefixes are ignored. An empty header matches nothing.
+func NoneMatch(header, tag string) bool {
+	return !matches(header, tag, true)
+}
+
+// matches reports whether a list of tags, or "*", matches tag
+func matches(header, tag string, weak bool) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	if strings.HasPrefix(tag, "W/") {
+		if !weak {
+			return false
+		}
+		tag = tag[len("W/"):]
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = candidate[len("W/"):]
+		}
+		if candidate == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package etag
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFromBytes(t *testing.T) {
+	tag := FromBytes([]byte("body"))
+	if len(tag) != tagHexLength+2 || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) {
+		t.Errorf("FromBytes = %s, want a quoted %d digit hash", tag, tagHexLength)
+	}
+	if FromBytes([]byte("body")) != tag {
+		t.Error("FromBytes is not deterministic")
+	}
+	if FromBytes([]byte("other")) == tag {
+		t.Error("FromBytes returns the same tag for different bodies")
+	}
+}
+
+func TestFromParts(t *testing.T) {
+	// Parts are separated, so moving characters between them changes the tag
+	if FromParts("1", "23") == FromParts("12", "3") {
+		t.Error(`FromParts("1", "23") == FromParts("12", "3")`)
+	}
+}
+
+func TestMatchesAny(t *testing.T) {
+	tests := []struct {
+		header string
+		tag    string
+		want   bool
+	}{
+		{``, `"a"`, false},
+		{`*`, `"a"`, true},
+		{`"a"`, `"a"`, true},
+		{`"b"`, `"a"`, false},
+		{`"b", "a"`, `"a"`, true},
+		{` "b" ,"a" `, `"a"`, true},
+		// If-Match compares strongly
+		{`W/"a"`, `"a"`, false},
+		{`"a"`, `W/"a"`, false},
+		{`W/"a"`, `W/"a"`, false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchesAny(tt.header, tt.tag); got != tt.want {
+			t.Errorf("MatchesAny(%q, %q) = %v, want %v", tt.header, tt.tag, got, tt.want)
+		}
+	}
+}
+
+func TestNoneMatch(t *testing.T) {
+	tests := []struct {
+		header string
+		tag    string
+		want   bool
+	}{
+		{``, `"a"`, true},
+		{`*`, `"a"`, false},
+		{`"a"`, `"a"`, false},
+		{`"b"`, `"a"`, true},
+		{`"b", "a"`, `"a"`, false},
+		// If-None-Match compares weakly
+		{`W/"a"`, `"a"`, false},
+		{`"a"`, `W/"a"`, false},
+		{`W/"b"`, `W/"a"`, true},
+	}
+
+	for _, tt := range tests {
+		if got := NoneMatch(tt.header, tt.tag); got != tt.want {
+			t.Errorf("NoneMatch(%q, %q) = %v, want %v", tt.header, tt.tag, got, tt.want)
+		}
+	}
+}
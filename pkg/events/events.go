@@ -0,0 +1,124 @@
+// Package events provides publishing of domain events to a message bus.
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/correlation"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// Supported publisher drivers
+const (
+	DriverNoop  = "noop"
+	DriverKafka = "kafka"
+	DriverNATS  = "nats"
+)
+
+const (
+	defaultTimeout = 5 * time.Second
+	defaultSource  = "normal-form-app"
+	eventIDBytes   = 16
+)
+
+// Event is the envelope published to the message bus
+type Event struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	SchemaVersion int             `json:"schema_version"`
+	Source        string          `json:"source"`
+	Key           string          `json:"key,omitempty"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Data          json.RawMessage `json:"data"`
+}
+
+// Publisher publishes events to a message bus
+type Publisher interface {
+	Publish(ctx context.Context, event *Event) error
+	Close() error
+}
+
+// Config holds event publisher configuration
+type Config struct {
+	Driver  string        `json:"driver"`
+	Source  string        `json:"source"`
+	Timeout time.Duration `json:"timeout"`
+	Kafka   KafkaConfig   `json:"kafka"`
+	NATS    NATSConfig    `json:"nats"`
+}
+
+// KafkaConfig holds Kafka REST proxy configuration
+type KafkaConfig struct {
+	RESTProxyURL string `json:"rest_proxy_url"`
+	Topic        string `json:"topic"`
+}
+
+// NATSConfig holds NATS configuration
+type NATSConfig struct {
+	URL           string `json:"url"`
+	SubjectPrefix string `json:"subject_prefix"`
+}
+
+// NewPublisher creates the publisher selected by config.Driver
+func NewPublisher(config *Config, log *logger.Logger) (Publisher, error) {
+	if config.Source == "" {
+		config.Source = defaultSource
+	}
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	switch config.Driver {
+	case "", DriverNoop:
+		return NewNoopPublisher(log), nil
+	case DriverKafka:
+		return NewKafkaPublisher(config, log)
+	case DriverNATS:
+		return NewNATSPublisher(config, log)
+	default:
+		return nil, fmt.Errorf("unsupported event publisher driver: %s", config.Driver)
+	}
+}
+
+// NewEvent creates an event with a random ID, serializing data as the event payload.
+// The correlation ID of ctx, if any, is attached to the event.
+func NewEvent(ctx context.Context, eventType, key string, data any) (*Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %s event data: %w", eventType, err)
+	}
+
+	id := make([]byte, eventIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate event ID: %w", err)
+	}
+
+	return &Event{
+		ID:            hex.EncodeToString(id),
+		Type:          eventType,
+		SchemaVersion: SchemaVersion(eventType),
+		Key:           key,
+		CorrelationID: correlation.CorrelationID(ctx),
+		OccurredAt:    time.Now(),
+		Data:          payload,
+	}, nil
+}
+
+// encode fills in the source and serializes the event envelope
+func encode(event *Event, source string) ([]byte, error) {
+	if event.Source == "" {
+		event.Source = source
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal event: %w", err)
+	}
+	return body, nil
+}
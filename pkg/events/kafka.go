@@ -0,0 +1,120 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const (
+	kafkaContentType      = "application/vnd.kafka.json.v2+json"
+	kafkaAcceptType       = "application/vnd.kafka.v2+json"
+	kafkaMaxErrorBodySize = 512
+)
+
+// kafkaRecord is a single record in a REST proxy produce request
+type kafkaRecord struct {
+	Key   string          `json:"key,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+// kafkaProduceRequest is the REST proxy v2 produce request body
+type kafkaProduceRequest struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+// kafkaProduceResponse is the REST proxy v2 produce response body
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		Partition int    `json:"partition"`
+		Offset    int64  `json:"offset"`
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// kafkaPublisher publishes events to Kafka through a Confluent-compatible REST proxy.
+// The event key is used as the record key so events for one aggregate stay ordered.
+type kafkaPublisher struct {
+	httpClient *http.Client
+	endpoint   string
+	source     string
+	log        *logger.Logger
+}
+
+// NewKafkaPublisher creates a new Kafka REST proxy publisher
+func NewKafkaPublisher(config *Config, log *logger.Logger) (Publisher, error) {
+	if config.Kafka.RESTProxyURL == "" {
+		return nil, errors.New("kafka REST proxy URL is required")
+	}
+	if config.Kafka.Topic == "" {
+		return nil, errors.New("kafka topic is required")
+	}
+
+	return &kafkaPublisher{
+		httpClient: &http.Client{Timeout: config.Timeout},
+		endpoint:   strings.TrimRight(config.Kafka.RESTProxyURL, "/") + "/topics/" + url.PathEscape(config.Kafka.Topic),
+		source:     config.Source,
+		log:        log,
+	}, nil
+}
+
+// Publish produces the event as a single record
+func (p *kafkaPublisher) Publish(ctx context.Context, event *Event) error {
+	value, err := encode(event, p.source)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(&kafkaProduceRequest{
+		Records: []kafkaRecord{{Key: event.Key, Value: value}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal kafka produce request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create kafka produce request: %w", err)
+	}
+	req.Header.Set("Content-Type", kafkaContentType)
+	req.Header.Set("Accept", kafkaAcceptType)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("kafka produce request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, kafkaMaxErrorBodySize))
+		return fmt.Errorf("kafka REST proxy returned status %d: %s", resp.StatusCode, string(snippet))
+	}
+
+	var produceResp kafkaProduceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&produceResp); err != nil {
+		return fmt.Errorf("failed to decode kafka produce response: %w", err)
+	}
+	for _, offset := range produceResp.Offsets {
+		if offset.ErrorCode != nil {
+			return fmt.Errorf("kafka rejected record (code %d): %s", *offset.ErrorCode, offset.Error)
+		}
+	}
+
+	p.log.WithField("event_id", event.ID).WithField("event_type", event.Type).Debug("Event published to Kafka")
+	return nil
+}
+
+// Close releases idle HTTP connections
+func (p *kafkaPublisher) Close() error {
+	p.httpClient.CloseIdleConnections()
+	return nil
+}
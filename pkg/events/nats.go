@@ -0,0 +1,227 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const (
+	natsDefaultPort = "4222"
+	natsClientName  = "normal-form-app"
+)
+
+// natsConnectOptions is the payload of the NATS CONNECT command
+type natsConnectOptions struct {
+	Verbose   bool   `json:"verbose"`
+	Pedantic  bool   `json:"pedantic"`
+	Name      string `json:"name"`
+	Lang      string `json:"lang"`
+	Version   string `json:"version"`
+	AuthToken string `json:"auth_token,omitempty"`
+	User      string `json:"user,omitempty"`
+	Pass      string `json:"pass,omitempty"`
+}
+
+// natsPublisher publishes events to NATS core subjects using the text protocol.
+// Each publish is followed by a PING so the server acknowledges (or rejects) it.
+// TLS is not supported; terminate TLS in front of the server if required.
+type natsPublisher struct {
+	mu            sync.Mutex
+	conn          net.Conn
+	reader        *bufio.Reader
+	address       string
+	connect       natsConnectOptions
+	subjectPrefix string
+	source        string
+	timeout       time.Duration
+	log           *logger.Logger
+}
+
+// NewNATSPublisher creates a new NATS publisher. The connection is opened lazily.
+func NewNATSPublisher(config *Config, log *logger.Logger) (Publisher, error) {
+	if config.NATS.URL == "" {
+		return nil, errors.New("nats URL is required")
+	}
+
+	u, err := url.Parse(config.NATS.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nats URL: %w", err)
+	}
+	if u.Scheme != "nats" {
+		return nil, fmt.Errorf("unsupported nats URL scheme: %s", u.Scheme)
+	}
+
+	address := u.Host
+	if u.Port() == "" {
+		address = net.JoinHostPort(u.Hostname(), natsDefaultPort)
+	}
+
+	connect := natsConnectOptions{Name: natsClientName, Lang: "go", Version: "1.0"}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			connect.User = u.User.Username()
+			connect.Pass = pass
+		} else {
+			connect.AuthToken = u.User.Username()
+		}
+	}
+
+	return &natsPublisher{
+		address:       address,
+		connect:       connect,
+		subjectPrefix: strings.TrimSuffix(config.NATS.SubjectPrefix, "."),
+		source:        config.Source,
+		timeout:       config.Timeout,
+		log:           log,
+	}, nil
+}
+
+// Publish sends the event to "<prefix>.<event type>"
+func (p *natsPublisher) Publish(ctx context.Context, event *Event) error {
+	body, err := encode(event, p.source)
+	if err != nil {
+		return err
+	}
+
+	subject := event.Type
+	if p.subjectPrefix != "" {
+		subject = p.subjectPrefix + "." + event.Type
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.ensureConnected(); err != nil {
+		return err
+	}
+
+	if err := p.publish(ctx, subject, body); err != nil {
+		// Drop the connection so the next publish reconnects
+		p.closeConn()
+		return err
+	}
+
+	p.log.WithField("event_id", event.ID).WithField("subject", subject).Debug("Event published to NATS")
+	return nil
+}
+
+// Close closes the connection to the server
+func (p *natsPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closeConn()
+	return nil
+}
+
+// ensureConnected dials the server and performs the handshake if needed
+func (p *natsPublisher) ensureConnected() error {
+	if p.conn != nil {
+		return nil
+	}
+
+	conn, err := net.DialTimeout("tcp", p.address, p.timeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to nats: %w", err)
+	}
+	p.conn = conn
+	p.reader = bufio.NewReader(conn)
+
+	if err := p.handshake(); err != nil {
+		p.closeConn()
+		return err
+	}
+
+	p.log.WithField("address", p.address).Info("Connected to NATS")
+	return nil
+}
+
+// handshake reads the server INFO and sends CONNECT
+func (p *natsPublisher) handshake() error {
+	if err := p.conn.SetDeadline(time.Now().Add(p.timeout)); err != nil {
+		return fmt.Errorf("failed to set nats deadline: %w", err)
+	}
+
+	line, err := p.readLine()
+	if err != nil {
+		return fmt.Errorf("failed to read nats server info: %w", err)
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("unexpected nats greeting: %s", line)
+	}
+
+	options, err := json.Marshal(&p.connect)
+	if err != nil {
+		return fmt.Errorf("failed to marshal nats connect options: %w", err)
+	}
+	if _, err := fmt.Fprintf(p.conn, "CONNECT %s\r\n", options); err != nil {
+		return fmt.Errorf("failed to send nats connect: %w", err)
+	}
+
+	return nil
+}
+
+// publish writes PUB followed by PING and waits for the PONG acknowledgement
+func (p *natsPublisher) publish(ctx context.Context, subject string, body []byte) error {
+	deadline := time.Now().Add(p.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := p.conn.SetDeadline(deadline); err != nil {
+		return fmt.Errorf("failed to set nats deadline: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(p.conn, "PUB %s %d\r\n%s\r\nPING\r\n", subject, len(body), body); err != nil {
+		return fmt.Errorf("failed to publish to nats: %w", err)
+	}
+
+	for {
+		line, err := p.readLine()
+		if err != nil {
+			return fmt.Errorf("failed to read nats acknowledgement: %w", err)
+		}
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return fmt.Errorf("failed to answer nats ping: %w", err)
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("nats server error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+		// +OK and async INFO updates are ignored
+	}
+}
+
+// readLine reads a single protocol line without the trailing CRLF
+func (p *natsPublisher) readLine() (string, error) {
+	line, err := p.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// closeConn closes and forgets the current connection
+func (p *natsPublisher) closeConn() {
+	if p.conn == nil {
+		return
+	}
+	if err := p.conn.Close(); err != nil {
+		p.log.WithError(err).Warn("Failed to close NATS connection")
+	}
+	p.conn = nil
+	p.reader = nil
+}
@@ -0,0 +1,30 @@
+package events
+
+import (
+	"context"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// noopPublisher discards events (development default)
+type noopPublisher struct {
+	log *logger.Logger
+}
+
+// NewNoopPublisher creates a publisher that only logs events at debug level
+func NewNoopPublisher(log *logger.Logger) Publisher {
+	return &noopPublisher{log: log}
+}
+
+// Publish logs and discards the event
+func (p *noopPublisher) Publish(_ context.Context, event *Event) error {
+	p.log.WithField("event_id", event.ID).
+		WithField("event_type", event.Type).
+		Debug("Event discarded by no-op publisher")
+	return nil
+}
+
+// Close does nothing
+func (p *noopPublisher) Close() error {
+	return nil
+}
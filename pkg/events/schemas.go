@@ -0,0 +1,91 @@
+package events
+
+import (
+	"embed"
+	"fmt"
+	"time"
+)
+
+// Event types
+const (
+	EventTypeUserCreated        = "user.created"
+	EventTypeUserStatusChanged  = "user.status_changed"
+	EventTypeInventoryChecked   = "inventory.checked"
+	EventTypeAttachmentInfected = "attachment.infected"
+)
+
+// Inventory check result sources
+const (
+	InventorySourceExternal = "external"
+	InventorySourceFallback = "fallback"
+	// InventorySourceSkipped means the check was bypassed by a feature flag
+	InventorySourceSkipped = "skipped"
+)
+
+// schemaVersions holds the current schema version of each event type.
+// Bump the version and add a new schema file on breaking payload changes.
+var schemaVersions = map[string]int{
+	EventTypeUserCreated:        1,
+	EventTypeUserStatusChanged:  1,
+	EventTypeInventoryChecked:   1,
+	EventTypeAttachmentInfected: 1,
+}
+
+//go:embed schemas/*.json
+var schemaFS embed.FS
+
+// UserCreatedData is the payload of the user.created event
+type UserCreatedData struct {
+	UserID      int       `json:"user_id"`
+	PlanType    string    `json:"plan_type"`
+	OptionTypes []string  `json:"option_types"`
+	Prefecture  string    `json:"prefecture"`
+	Locale      string    `json:"locale,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// UserStatusChangedData is the payload of the user.status_changed event
+type UserStatusChangedData struct {
+	UserID         int       `json:"user_id"`
+	PreviousStatus string    `json:"previous_status"`
+	Status         string    `json:"status"`
+	Reason         string    `json:"reason,omitempty"`
+	ChangedAt      time.Time `json:"changed_at"`
+}
+
+// InventoryCheckedData is the payload of the inventory.checked event
+type InventoryCheckedData struct {
+	OptionTypes []string       `json:"option_types"`
+	Inventory   map[string]int `json:"inventory"`
+	Source      string         `json:"source"`
+	CheckedAt   time.Time      `json:"checked_at"`
+}
+
+// AttachmentInfectedData is the payload of the attachment.infected event
+type AttachmentInfectedData struct {
+	AttachmentID  string    `json:"attachment_id"`
+	Filename      string    `json:"filename"`
+	ContentType   string    `json:"content_type"`
+	SHA256        string    `json:"sha256"`
+	Signature     string    `json:"signature"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
+
+// SchemaVersion returns the current schema version for an event type (0 if unknown)
+func SchemaVersion(eventType string) int {
+	return schemaVersions[eventType]
+}
+
+// Schema returns the JSON Schema document describing the payload of an event type
+func Schema(eventType string) ([]byte, error) {
+	version, ok := schemaVersions[eventType]
+	if !ok {
+		return nil, fmt.Errorf("unknown event type: %s", eventType)
+	}
+
+	schema, err := schemaFS.ReadFile(fmt.Sprintf("schemas/%s.v%d.json", eventType, version))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema for %s: %w", eventType, err)
+	}
+	return schema, nil
+}
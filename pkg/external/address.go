@@ -3,6 +3,7 @@ package external
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"regexp"
 
@@ -20,15 +21,22 @@ var (
 
 // AddressClient handles address search-related external API calls
 type AddressClient struct {
-	client *Client
-	log    *logger.Logger
+	client   *Client
+	provider AddressProvider
+	log      *logger.Logger
 }
 
-// NewAddressClient creates a new address API client
-func NewAddressClient(config *Config, log *logger.Logger) *AddressClient {
+// NewAddressClient creates a new address API client using the given provider,
+// or the in-house API contract when provider is nil
+func NewAddressClient(config *Config, provider AddressProvider, log *logger.Logger) *AddressClient {
+	if provider == nil {
+		provider = customAddressProvider{}
+	}
+
 	return &AddressClient{
-		client: NewClient(config, log),
-		log:    log,
+		client:   NewClient(config, log),
+		provider: provider,
+		log:      log,
 	}
 }
 
@@ -76,42 +84,27 @@ func (ac *AddressClient) SearchByPostalCode(ctx context.Context, postalCode stri
 	// Normalize postal code (remove hyphen if present)
 	normalizedPostalCode := normalizePostalCode(postalCode)
 
-	// Prepare request
-	req := &AddressSearchRequest{
-		PostalCode: normalizedPostalCode,
-	}
-
-	// Make API call
-	var resp AddressSearchResponse
-	err := ac.client.PostJSON(ctx, addressSearchEndpoint, req, &resp)
+	data, err := ac.provider.Search(ctx, ac.client, normalizedPostalCode)
 	if err != nil {
-		ac.log.WithError(err).WithField("postal_code", postalCode).Error("Failed to search address")
-		return nil, fmt.Errorf("address search API call failed: %w", err)
-	}
-
-	// Validate response
-	if !resp.Success {
-		errMsg := "unknown error"
-		if resp.Error != "" {
-			errMsg = resp.Error
+		if errors.Is(err, ErrAddressNotFound) {
+			ac.log.WithField("postal_code", postalCode).WithField("provider", ac.provider.Name()).Debug("Address not found")
+			return nil, fmt.Errorf("no address data found for postal code %s: %w", postalCode, err)
 		}
-		ac.log.WithField("postal_code", postalCode).WithField("api_error", errMsg).Error("Address API returned error")
-		return nil, fmt.Errorf("address API error: %s", errMsg)
-	}
-
-	if resp.Data == nil {
-		ac.log.WithField("postal_code", postalCode).Error("Address API returned no data")
-		return nil, fmt.Errorf("no address data found for postal code: %s", postalCode)
+		ac.log.WithError(err).
+			WithField("postal_code", postalCode).
+			WithField("provider", ac.provider.Name()).
+			Error("Failed to search address")
+		return nil, fmt.Errorf("address search API call failed: %w", err)
 	}
 
 	// Convert to AddressInfo format
 	addressInfo := &AddressInfo{
 		PostalCode1: normalizedPostalCode[:3],
 		PostalCode2: normalizedPostalCode[3:],
-		Prefecture:  resp.Data.Prefecture,
-		City:        resp.Data.City,
-		Town:        resp.Data.Town,
-		FullAddress: buildFullAddress(resp.Data),
+		Prefecture:  data.Prefecture,
+		City:        data.City,
+		Town:        data.Town,
+		FullAddress: buildFullAddress(data),
 	}
 
 	ac.log.WithField("postal_code", postalCode).WithField("address_info", addressInfo).Debug("Address search completed")
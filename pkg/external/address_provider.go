@@ -0,0 +1,190 @@
+// Package external provides postal code lookup providers for the address client.
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Supported address providers
+const (
+	// AddressProviderCustom is the in-house POST /api/address/search contract
+	AddressProviderCustom = "custom"
+	// AddressProviderZipcloud is the public zipcloud API (https://zipcloud.ibsnet.co.jp)
+	AddressProviderZipcloud = "zipcloud"
+	// AddressProviderKenall is the kenall API serving Japan Post KEN_ALL data (https://kenall.jp)
+	AddressProviderKenall = "kenall"
+)
+
+const (
+	zipcloudBaseURL        = "https://zipcloud.ibsnet.co.jp"
+	zipcloudSearchEndpoint = "/api/search"
+	zipcloudStatusOK       = 200
+
+	kenallBaseURL            = "https://api.kenall.jp"
+	kenallPostalCodeEndpoint = "/v1/postalcode/"
+)
+
+// ErrAddressNotFound is returned when the provider has no address for the postal code
+var ErrAddressNotFound = errors.New("address not found")
+
+// AddressProvider adapts a postal code lookup API to the address client
+type AddressProvider interface {
+	// Name returns the provider name
+	Name() string
+	// DefaultBaseURL returns the public API URL, or "" when the base URL must be configured
+	DefaultBaseURL() string
+	// Search looks up a 7-digit postal code, returning ErrAddressNotFound when it does not exist
+	Search(ctx context.Context, client *Client, postalCode string) (*AddressData, error)
+}
+
+// NewAddressProvider creates the named provider. apiKey is only used by providers that require one.
+func NewAddressProvider(name, apiKey string) (AddressProvider, error) {
+	switch name {
+	case "", AddressProviderCustom:
+		return customAddressProvider{}, nil
+	case AddressProviderZipcloud:
+		return zipcloudAddressProvider{}, nil
+	case AddressProviderKenall:
+		if apiKey == "" {
+			return nil, errors.New("address provider kenall requires an API key")
+		}
+		return kenallAddressProvider{apiKey: apiKey}, nil
+	default:
+		return nil, fmt.Errorf("unsupported address provider: %s", name)
+	}
+}
+
+// customAddressProvider calls the in-house address search API
+type customAddressProvider struct{}
+
+// Name returns the provider name
+func (customAddressProvider) Name() string { return AddressProviderCustom }
+
+// DefaultBaseURL returns "" because the in-house API has no public URL
+func (customAddressProvider) DefaultBaseURL() string { return "" }
+
+// Search looks up a postal code
+func (customAddressProvider) Search(ctx context.Context, client *Client, postalCode string) (*AddressData, error) {
+	var resp AddressSearchResponse
+	if err := client.PostJSON(ctx, addressSearchEndpoint, &AddressSearchRequest{PostalCode: postalCode}, &resp); err != nil {
+		return nil, err
+	}
+
+	if !resp.Success {
+		errMsg := "unknown error"
+		if resp.Error != "" {
+			errMsg = resp.Error
+		}
+		return nil, fmt.Errorf("address API error: %s", errMsg)
+	}
+	if resp.Data == nil {
+		return nil, ErrAddressNotFound
+	}
+
+	return resp.Data, nil
+}
+
+// zipcloudResponse is the response of GET /api/search?zipcode=
+type zipcloudResponse struct {
+	Status  int     `json:"status"`
+	Message *string `json:"message"`
+	Results []struct {
+		Zipcode  string `json:"zipcode"`
+		Address1 string `json:"address1"`
+		Address2 string `json:"address2"`
+		Address3 string `json:"address3"`
+	} `json:"results"`
+}
+
+// zipcloudAddressProvider calls the public zipcloud API, which needs no credentials
+type zipcloudAddressProvider struct{}
+
+// Name returns the provider name
+func (zipcloudAddressProvider) Name() string { return AddressProviderZipcloud }
+
+// DefaultBaseURL returns the public zipcloud URL
+func (zipcloudAddressProvider) DefaultBaseURL() string { return zipcloudBaseURL }
+
+// Search looks up a postal code. zipcloud reports errors in the body with HTTP 200
+// and returns null results for unknown postal codes.
+func (zipcloudAddressProvider) Search(ctx context.Context, client *Client, postalCode string) (*AddressData, error) {
+	var resp zipcloudResponse
+	endpoint := zipcloudSearchEndpoint + "?" + url.Values{"zipcode": {postalCode}}.Encode()
+	if err := client.GetJSON(ctx, endpoint, &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Status != zipcloudStatusOK {
+		errMsg := "unknown error"
+		if resp.Message != nil {
+			errMsg = *resp.Message
+		}
+		return nil, fmt.Errorf("zipcloud error (status %d): %s", resp.Status, errMsg)
+	}
+	if len(resp.Results) == 0 {
+		return nil, ErrAddressNotFound
+	}
+
+	// A postal code can cover several towns; the first entry is used
+	result := resp.Results[0]
+	return &AddressData{
+		PostalCode: result.Zipcode,
+		Prefecture: result.Address1,
+		City:       result.Address2,
+		Town:       result.Address3,
+	}, nil
+}
+
+// kenallResponse is the response of GET /v1/postalcode/{code}
+type kenallResponse struct {
+	Version string `json:"version"`
+	Data    []struct {
+		PostalCode string `json:"postal_code"`
+		Prefecture string `json:"prefecture"`
+		City       string `json:"city"`
+		Town       string `json:"town"`
+	} `json:"data"`
+}
+
+// kenallAddressProvider calls the kenall API, which serves Japan Post KEN_ALL data
+type kenallAddressProvider struct {
+	apiKey string
+}
+
+// Name returns the provider name
+func (kenallAddressProvider) Name() string { return AddressProviderKenall }
+
+// DefaultBaseURL returns the public kenall URL
+func (kenallAddressProvider) DefaultBaseURL() string { return kenallBaseURL }
+
+// Search looks up a postal code. kenall responds 404 for unknown postal codes.
+func (p kenallAddressProvider) Search(ctx context.Context, client *Client, postalCode string) (*AddressData, error) {
+	headers := http.Header{}
+	headers.Set("Authorization", "Token "+p.apiKey)
+
+	var resp kenallResponse
+	err := client.GetJSONWithHeaders(ctx, kenallPostalCodeEndpoint+url.PathEscape(postalCode), headers, &resp)
+	if err != nil {
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+			return nil, ErrAddressNotFound
+		}
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, ErrAddressNotFound
+	}
+
+	// A postal code can cover several towns; the first entry is used
+	result := resp.Data[0]
+	return &AddressData{
+		PostalCode: result.PostalCode,
+		Prefecture: result.Prefecture,
+		City:       result.City,
+		Town:       result.Town,
+	}, nil
+}
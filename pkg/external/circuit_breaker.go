@@ -0,0 +1,177 @@
+// Package external provides a circuit breaker for external API clients.
+package external
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFailureThreshold = 5
+	defaultOpenTimeout      = 30 * time.Second
+)
+
+// CircuitState represents the state of a circuit breaker
+type CircuitState string
+
+// Circuit breaker states
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// CircuitOverride is a manual override of the circuit breaker state
+type CircuitOverride string
+
+// Circuit breaker overrides
+const (
+	// CircuitOverrideNone lets the breaker follow observed failures
+	CircuitOverrideNone CircuitOverride = "none"
+	// CircuitOverrideForceOpen rejects every call (e.g. during partner maintenance)
+	CircuitOverrideForceOpen CircuitOverride = "force_open"
+	// CircuitOverrideForceClosed allows every call regardless of failures
+	CircuitOverrideForceClosed CircuitOverride = "force_closed"
+)
+
+// ErrCircuitOpen is returned when a call is rejected because the circuit is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreaker stops calling an API after consecutive failures and lets a single
+// trial call through once the open timeout has elapsed
+type CircuitBreaker struct {
+	mu                  sync.Mutex
+	state               CircuitState
+	failureThreshold    int
+	openTimeout         time.Duration
+	consecutiveFailures int
+	openedAt            time.Time
+	trialInFlight       bool
+	override            CircuitOverride
+}
+
+// CircuitBreakerStatus is a point-in-time view of a circuit breaker
+type CircuitBreakerStatus struct {
+	State               CircuitState    `json:"state"`
+	ConsecutiveFailures int             `json:"consecutive_failures"`
+	FailureThreshold    int             `json:"failure_threshold"`
+	OpenTimeout         string          `json:"open_timeout"`
+	OpenedAt            *time.Time      `json:"opened_at,omitempty"`
+	Override            CircuitOverride `json:"override"`
+}
+
+// NewCircuitBreaker creates a new circuit breaker in the closed state
+func NewCircuitBreaker(failureThreshold int, openTimeout time.Duration) *CircuitBreaker {
+	if failureThreshold <= 0 {
+		failureThreshold = defaultFailureThreshold
+	}
+	if openTimeout <= 0 {
+		openTimeout = defaultOpenTimeout
+	}
+
+	return &CircuitBreaker{
+		state:            CircuitClosed,
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		override:         CircuitOverrideNone,
+	}
+}
+
+// Allow reports whether a call may proceed, returning ErrCircuitOpen otherwise
+func (cb *CircuitBreaker) Allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.override {
+	case CircuitOverrideForceOpen:
+		return ErrCircuitOpen
+	case CircuitOverrideForceClosed:
+		return nil
+	}
+
+	switch cb.state {
+	case CircuitOpen:
+		if time.Since(cb.openedAt) < cb.openTimeout {
+			return ErrCircuitOpen
+		}
+		cb.state = CircuitHalfOpen
+		cb.trialInFlight = true
+		return nil
+	case CircuitHalfOpen:
+		// Only one trial call at a time while half-open
+		if cb.trialInFlight {
+			return ErrCircuitOpen
+		}
+		cb.trialInFlight = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// RecordSuccess closes the circuit and resets the failure count
+func (cb *CircuitBreaker) RecordSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.state = CircuitClosed
+	cb.consecutiveFailures = 0
+	cb.trialInFlight = false
+}
+
+// RecordFailure counts a failure and opens the circuit when the threshold is reached
+// or when the half-open trial call fails
+func (cb *CircuitBreaker) RecordFailure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.consecutiveFailures++
+	cb.trialInFlight = false
+
+	if cb.state == CircuitHalfOpen || cb.consecutiveFailures >= cb.failureThreshold {
+		cb.state = CircuitOpen
+		cb.openedAt = time.Now()
+	}
+}
+
+// SetOverride sets a manual override. Clearing the override resets the breaker to closed
+// so normal operation resumes from a clean state.
+func (cb *CircuitBreaker) SetOverride(override CircuitOverride) error {
+	switch override {
+	case CircuitOverrideNone, CircuitOverrideForceOpen, CircuitOverrideForceClosed:
+	default:
+		return fmt.Errorf("invalid circuit breaker override: %s", override)
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.override = override
+	if override == CircuitOverrideNone {
+		cb.state = CircuitClosed
+		cb.consecutiveFailures = 0
+		cb.trialInFlight = false
+	}
+	return nil
+}
+
+// Status returns the current circuit breaker status
+func (cb *CircuitBreaker) Status() *CircuitBreakerStatus {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	status := &CircuitBreakerStatus{
+		State:               cb.state,
+		ConsecutiveFailures: cb.consecutiveFailures,
+		FailureThreshold:    cb.failureThreshold,
+		OpenTimeout:         cb.openTimeout.String(),
+		Override:            cb.override,
+	}
+	if cb.state != CircuitClosed {
+		openedAt := cb.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
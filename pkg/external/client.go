@@ -5,21 +5,27 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/octop162/normal-form-app-by-claude/pkg/correlation"
 	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
 )
 
 const (
-	defaultTimeout     = 30 * time.Second
-	defaultMaxRetries  = 3
-	defaultRetryDelay  = 1 * time.Second
-	contentTypeJSON    = "application/json"
-	headerContentType  = "Content-Type"
-	headerUserAgent    = "User-Agent"
-	userAgentValue     = "normal-form-app/1.0"
+	defaultTimeout    = 30 * time.Second
+	defaultMaxRetries = 3
+	defaultRetryDelay = 1 * time.Second
+	contentTypeJSON   = "application/json"
+	headerContentType = "Content-Type"
+	headerUserAgent   = "User-Agent"
+	userAgentValue    = "normal-form-app/1.0"
 )
 
 // HTTPClient defines the interface for HTTP operations
@@ -31,10 +37,21 @@ type HTTPClient interface {
 type Client struct {
 	httpClient HTTPClient
 	baseURL    string
+	sandboxURL string
 	timeout    time.Duration
 	maxRetries int
 	retryDelay time.Duration
-	log        *logger.Logger
+	// maxRetryDelay caps the backoff; a Retry-After longer than it ends the retries
+	maxRetryDelay   time.Duration
+	endpointRetries map[string]int
+	breaker         *CircuitBreaker
+	cache           *responseCache
+	log             *logger.Logger
+
+	statsMu       sync.Mutex
+	lastError     string
+	lastErrorAt   time.Time
+	lastSuccessAt time.Time
 }
 
 // Config holds configuration for the external API client
@@ -42,7 +59,26 @@ type Config struct {
 	BaseURL    string        `json:"base_url"`
 	Timeout    time.Duration `json:"timeout"`
 	MaxRetries int           `json:"max_retries"`
+	// RetryDelay is the backoff before the first retry; it doubles with every further retry
 	RetryDelay time.Duration `json:"retry_delay"`
+	// MaxRetryDelay caps the backoff between retries
+	MaxRetryDelay time.Duration `json:"max_retry_delay"`
+	// EndpointMaxRetries overrides MaxRetries for endpoint paths, such as calls that are
+	// expensive for the API or unsafe to repeat. 0 disables retries for the endpoint.
+	EndpointMaxRetries map[string]int `json:"endpoint_max_retries"`
+	// SandboxBaseURL is used instead of BaseURL for requests marked with WithSandbox
+	SandboxBaseURL string `json:"sandbox_base_url"`
+	// CircuitBreakerThreshold is the number of consecutive failed calls that opens the circuit
+	CircuitBreakerThreshold int `json:"circuit_breaker_threshold"`
+	// CircuitBreakerTimeout is how long the circuit stays open before a trial call is allowed
+	CircuitBreakerTimeout time.Duration `json:"circuit_breaker_timeout"`
+	// CacheTTL is how long successful responses are reused for identical requests; 0 disables
+	// the cache. Only APIs whose calls do not change upstream state may enable it.
+	CacheTTL time.Duration `json:"cache_ttl"`
+	// CacheMaxEntries bounds the response cache
+	CacheMaxEntries int `json:"cache_max_entries"`
+	// Egress restricts the hosts and addresses the client connects to; nil allows any
+	Egress *EgressPolicy `json:"egress"`
 }
 
 // NewClient creates a new external API client with the provided configuration
@@ -56,18 +92,30 @@ func NewClient(config *Config, log *logger.Logger) *Client {
 	if config.RetryDelay == 0 {
 		config.RetryDelay = defaultRetryDelay
 	}
+	if config.MaxRetryDelay == 0 {
+		config.MaxRetryDelay = defaultMaxRetryDelay
+	}
 
 	httpClient := &http.Client{
 		Timeout: config.Timeout,
 	}
+	if config.Egress.enabled() {
+		httpClient.Transport = newEgressTransport(config.Egress)
+	}
 
 	return &Client{
 		httpClient: httpClient,
 		baseURL:    config.BaseURL,
+		sandboxURL: config.SandboxBaseURL,
 		timeout:    config.Timeout,
 		maxRetries: config.MaxRetries,
 		retryDelay: config.RetryDelay,
-		log:        log,
+
+		maxRetryDelay:   config.MaxRetryDelay,
+		endpointRetries: config.EndpointMaxRetries,
+		breaker:         NewCircuitBreaker(config.CircuitBreakerThreshold, config.CircuitBreakerTimeout),
+		cache:           newResponseCache(config.CacheTTL, config.CacheMaxEntries),
+		log:             log,
 	}
 }
 
@@ -80,8 +128,6 @@ type APIResponse struct {
 
 // PostJSON performs a POST request with JSON payload and returns the response
 func (c *Client) PostJSON(ctx context.Context, endpoint string, payload interface{}, result interface{}) error {
-	url := c.baseURL + endpoint
-	
 	// Marshal the payload to JSON
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
@@ -89,80 +135,126 @@ func (c *Client) PostJSON(ctx context.Context, endpoint string, payload interfac
 		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	var lastErr error
-	for attempt := 0; attempt <= c.maxRetries; attempt++ {
-		if attempt > 0 {
-			c.log.WithField("attempt", attempt).WithField("endpoint", endpoint).Info("Retrying API call")
-			time.Sleep(c.retryDelay)
-		}
+	return c.execute(ctx, http.MethodPost, endpoint, jsonData, nil, result)
+}
 
-		// Create HTTP request
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
-		if err != nil {
-			lastErr = fmt.Errorf("failed to create request: %w", err)
-			continue
-		}
+// GetJSON performs a GET request and returns the response
+func (c *Client) GetJSON(ctx context.Context, endpoint string, result interface{}) error {
+	return c.execute(ctx, http.MethodGet, endpoint, nil, nil, result)
+}
 
-		// Set headers
-		req.Header.Set(headerContentType, contentTypeJSON)
-		req.Header.Set(headerUserAgent, userAgentValue)
+// GetJSONWithHeaders performs a GET request with additional headers, such as API credentials
+func (c *Client) GetJSONWithHeaders(ctx context.Context, endpoint string, headers http.Header, result interface{}) error {
+	return c.execute(ctx, http.MethodGet, endpoint, nil, headers, result)
+}
 
-		// Execute request
-		resp, err := c.httpClient.Do(req)
-		if err != nil {
-			c.log.WithError(err).WithField("endpoint", endpoint).WithField("attempt", attempt).Warn("HTTP request failed")
-			lastErr = fmt.Errorf("HTTP request failed: %w", err)
-			continue
+// execute performs a request with retries, guarded by the circuit breaker
+func (c *Client) execute(
+	ctx context.Context, method, endpoint string, body []byte, headers http.Header, result interface{},
+) error {
+	// Sandbox calls are isolated from production: they never fall back to the
+	// production URL and do not affect the breaker or call statistics
+	if IsSandbox(ctx) {
+		if c.sandboxURL == "" {
+			return fmt.Errorf("API call to %s rejected: %w", endpoint, ErrSandboxNotConfigured)
 		}
+		_, err := c.attempt(ctx, method, c.sandboxURL, endpoint, body, headers, result)
+		return err
+	}
 
-		// Process response
-		err = c.processResponse(resp, result)
-		if err != nil {
-			c.log.WithError(err).WithField("endpoint", endpoint).WithField("status", resp.StatusCode).Warn("Failed to process response")
-			lastErr = err
-			
-			// Don't retry on client errors (4xx)
-			if resp.StatusCode >= 400 && resp.StatusCode < 500 {
-				break
+	// Probes always reach the API; other calls may be answered from the response cache
+	cacheKey := ""
+	if c.cache != nil && !isProbe(ctx) {
+		cacheKey = responseCacheKey(method, endpoint, body)
+		if !isCacheBypassed(ctx) {
+			if cached, ok := c.cache.get(cacheKey, time.Now()); ok && json.Unmarshal(cached, result) == nil {
+				c.log.WithField("endpoint", endpoint).Debug("API response served from cache")
+				return nil
 			}
-			continue
 		}
+	}
 
-		// Success
-		c.log.WithField("endpoint", endpoint).WithField("attempt", attempt).Debug("API call successful")
-		return nil
+	// Probes bypass the breaker so an API can be checked while the circuit is open
+	if !isProbe(ctx) {
+		if err := c.breaker.Allow(); err != nil {
+			c.log.WithField("endpoint", endpoint).Warn("API call rejected by open circuit breaker")
+			return fmt.Errorf("API call to %s rejected: %w", endpoint, err)
+		}
+	}
+
+	lastErr, err := c.attempt(ctx, method, c.baseURL, endpoint, body, headers, result)
+	if err != nil {
+		c.recordFailure(lastErr)
+		return err
 	}
 
-	c.log.WithError(lastErr).WithField("endpoint", endpoint).WithField("max_retries", c.maxRetries).Error("API call failed after all retries")
-	return fmt.Errorf("API call failed after %d retries: %w", c.maxRetries, lastErr)
+	c.recordSuccess()
+	if cacheKey != "" {
+		if encoded, err := json.Marshal(result); err == nil {
+			c.cache.put(cacheKey, encoded, time.Now())
+		}
+	}
+	return nil
 }
 
-// GetJSON performs a GET request and returns the response
-func (c *Client) GetJSON(ctx context.Context, endpoint string, result interface{}) error {
-	url := c.baseURL + endpoint
+// attempt performs a request with retries against baseURL. Retries back off exponentially
+// with jitter, wait at least as long as a Retry-After response asks, and stop early when
+// the caller's deadline would pass during the wait. On failure it returns the last
+// attempt's error alongside the wrapped error reported to the caller.
+func (c *Client) attempt(
+	ctx context.Context, method, baseURL, endpoint string, body []byte, headers http.Header, result interface{},
+) (lastErr, err error) {
+	url := baseURL + endpoint
+	maxRetries := c.retriesFor(endpoint)
 
-	var lastErr error
-	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+	attempts := 0
+	var requestedWait time.Duration
+	for attempt := 0; attempt <= maxRetries; attempt++ {
 		if attempt > 0 {
+			if requestedWait > c.maxRetryDelay {
+				c.log.WithField("endpoint", endpoint).WithField("retry_after", requestedWait).Warn("Retry-After exceeds the maximum retry delay, giving up")
+				break
+			}
+			delay := max(backoff(attempt, c.retryDelay, c.maxRetryDelay), requestedWait)
+			if !waitForRetry(ctx, delay) {
+				c.log.WithField("endpoint", endpoint).WithField("delay", delay).Warn("Request context ends before the next retry, giving up")
+				break
+			}
 			c.log.WithField("attempt", attempt).WithField("endpoint", endpoint).Info("Retrying API call")
-			time.Sleep(c.retryDelay)
 		}
+		attempts++
+		requestedWait = 0
 
 		// Create HTTP request
-		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		var reqBody io.Reader
+		if body != nil {
+			reqBody = bytes.NewReader(body)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
 		if err != nil {
 			lastErr = fmt.Errorf("failed to create request: %w", err)
 			continue
 		}
 
 		// Set headers
+		if body != nil {
+			req.Header.Set(headerContentType, contentTypeJSON)
+		}
 		req.Header.Set(headerUserAgent, userAgentValue)
+		for name, values := range headers {
+			req.Header[name] = values
+		}
+		setCorrelationHeader(req)
 
 		// Execute request
 		resp, err := c.httpClient.Do(req)
 		if err != nil {
 			c.log.WithError(err).WithField("endpoint", endpoint).WithField("attempt", attempt).Warn("HTTP request failed")
 			lastErr = fmt.Errorf("HTTP request failed: %w", err)
+			// The policy refuses the destination again on every retry
+			if errors.Is(err, ErrEgressDenied) {
+				break
+			}
 			continue
 		}
 
@@ -171,21 +263,110 @@ func (c *Client) GetJSON(ctx context.Context, endpoint string, result interface{
 		if err != nil {
 			c.log.WithError(err).WithField("endpoint", endpoint).WithField("status", resp.StatusCode).Warn("Failed to process response")
 			lastErr = err
-			
-			// Don't retry on client errors (4xx)
-			if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+
+			// Don't retry on client errors (4xx) other than 429
+			if !isRetryable(err) {
 				break
 			}
+			var statusErr *StatusError
+			if errors.As(err, &statusErr) {
+				requestedWait = statusErr.RetryAfter
+			}
 			continue
 		}
 
 		// Success
 		c.log.WithField("endpoint", endpoint).WithField("attempt", attempt).Debug("API call successful")
-		return nil
+		return nil, nil
+	}
+
+	c.log.WithError(lastErr).WithField("endpoint", endpoint).WithField("attempts", attempts).Error("API call failed after all retries")
+	return lastErr, fmt.Errorf("API call failed after %d attempts: %w", attempts, lastErr)
+}
+
+// retriesFor returns the retry budget of an endpoint: that of the longest configured path
+// prefix, so "/api/search" also covers "/api/search?zipcode=..."
+func (c *Client) retriesFor(endpoint string) int {
+	retries, matched := c.maxRetries, ""
+	for prefix, budget := range c.endpointRetries {
+		if strings.HasPrefix(endpoint, prefix) && len(prefix) > len(matched) {
+			retries, matched = budget, prefix
+		}
+	}
+	return retries
+}
+
+// recordSuccess records a successful call
+func (c *Client) recordSuccess() {
+	c.breaker.RecordSuccess()
+
+	c.statsMu.Lock()
+	c.lastSuccessAt = time.Now()
+	c.statsMu.Unlock()
+}
+
+// recordFailure records a failed call. Client errors (4xx) are caused by the request,
+// not by the API being unavailable, so they do not count towards opening the circuit.
+func (c *Client) recordFailure(err error) {
+	if isClientError(err) {
+		c.breaker.RecordSuccess()
+	} else {
+		c.breaker.RecordFailure()
 	}
 
-	c.log.WithError(lastErr).WithField("endpoint", endpoint).WithField("max_retries", c.maxRetries).Error("API call failed after all retries")
-	return fmt.Errorf("API call failed after %d retries: %w", c.maxRetries, lastErr)
+	c.statsMu.Lock()
+	c.lastError = err.Error()
+	c.lastErrorAt = time.Now()
+	c.statsMu.Unlock()
+}
+
+// setCorrelationHeader forwards the caller's correlation ID to the external API
+func setCorrelationHeader(req *http.Request) {
+	if id := correlation.CorrelationID(req.Context()); id != "" {
+		req.Header.Set(correlation.HeaderCorrelationID, id)
+	}
+}
+
+// StatusError is returned when the API responds with a non-2xx status code
+type StatusError struct {
+	StatusCode int
+	// RetryAfter is the wait requested by a Retry-After header, or 0
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+}
+
+// isClientError reports whether err is a 4xx response
+func isClientError(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode >= 400 && statusErr.StatusCode < 500
+}
+
+// IsTimeout reports whether a call failed because the API did not answer in time: the
+// deadline passed, the connection timed out or a gateway in front of the API timed out
+func IsTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusGatewayTimeout
+}
+
+// RequestedRetryAfter returns the wait the API asked for with a Retry-After header when the
+// call failed, or 0
+func RequestedRetryAfter(err error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryAfter
+	}
+	return 0
 }
 
 // processResponse handles the HTTP response and unmarshals it into the result
@@ -194,7 +375,10 @@ func (c *Client) processResponse(resp *http.Response, result interface{}) error
 
 	// Check status code
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return &StatusError{
+			StatusCode: resp.StatusCode,
+			RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
 	}
 
 	// Decode JSON response
@@ -203,4 +387,4 @@ func (c *Client) processResponse(resp *http.Response, result interface{}) error
 	}
 
 	return nil
-}
\ No newline at end of file
+}
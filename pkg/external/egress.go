@@ -0,0 +1,195 @@
+// Package external provides egress control for external API calls.
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultPinTTL is how long resolved addresses are pinned when no TTL is configured
+const defaultPinTTL = 5 * time.Minute
+
+// ErrEgressDenied is returned when a call would connect to a host or address the egress
+// policy does not allow
+var ErrEgressDenied = errors.New("egress denied")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which net.IP does not
+// count as private
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// EgressPolicy restricts the destinations of external API calls, so that a base URL taken
+// from data rather than configuration cannot reach internal services (SSRF)
+type EgressPolicy struct {
+	// AllowedHosts lists the host names calls may connect to; "*.example.com" matches its
+	// subdomains. Empty allows every host.
+	AllowedHosts []string `json:"allowed_hosts"`
+	// BlockPrivateIPs refuses loopback, private, link-local and other non-public addresses.
+	// Addresses are checked after DNS resolution, so host names resolving to them are
+	// refused too.
+	BlockPrivateIPs bool `json:"block_private_ips"`
+	// PinDNS keeps connecting to the addresses a host first resolved to, for PinTTL, so
+	// that a changed DNS answer cannot move calls to another address between checks
+	PinDNS bool          `json:"pin_dns"`
+	PinTTL time.Duration `json:"pin_ttl"`
+}
+
+// enabled reports whether the policy changes how connections are made
+func (p *EgressPolicy) enabled() bool {
+	return p != nil && (len(p.AllowedHosts) > 0 || p.BlockPrivateIPs || p.PinDNS)
+}
+
+// AllowsHost reports whether calls may connect to host
+func (p *EgressPolicy) AllowsHost(host string) bool {
+	if p == nil || len(p.AllowedHosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range p.AllowedHosts {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok {
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return true
+			}
+			continue
+		}
+		if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckURL reports an error wrapping ErrEgressDenied when calls to rawURL would be refused
+// by the host allowlist, so that misconfigured base URLs fail at startup rather than on
+// every call. Addresses are checked on connection, since DNS answers change.
+func (p *EgressPolicy) CheckURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	if !p.AllowsHost(parsed.Hostname()) {
+		return fmt.Errorf("%w: host %s of %s is not in the allowlist", ErrEgressDenied, parsed.Hostname(), rawURL)
+	}
+	return nil
+}
+
+// allowsIP reports whether calls may connect to ip
+func (p *EgressPolicy) allowsIP(ip net.IP) bool {
+	if !p.BlockPrivateIPs {
+		return true
+	}
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() || sharedAddressSpace.Contains(ip))
+}
+
+// pinnedAddresses are the addresses of a host kept until expiresAt
+type pinnedAddresses struct {
+	ips       []net.IP
+	expiresAt time.Time
+}
+
+// egressDialer resolves destinations itself and connects only to the addresses it has
+// checked, so the checked address is the one connected to
+type egressDialer struct {
+	policy   *EgressPolicy
+	dialer   *net.Dialer
+	resolver *net.Resolver
+
+	mu   sync.Mutex
+	pins map[string]pinnedAddresses
+}
+
+// newEgressTransport returns a transport enforcing policy on every connection, redirects
+// included. Proxies from the environment are not used, since the policy could only check
+// the proxy's address.
+func newEgressTransport(policy *EgressPolicy) *http.Transport {
+	dialer := &egressDialer{
+		policy:   policy,
+		dialer:   &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second},
+		resolver: net.DefaultResolver,
+		pins:     make(map[string]pinnedAddresses),
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
+
+// DialContext connects to the first allowed address of the host in addr
+func (d *egressDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if !d.policy.AllowsHost(host) {
+		return nil, fmt.Errorf("%w: host %s is not in the allowlist", ErrEgressDenied, host)
+	}
+
+	ips, err := d.resolve(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var lastErr error
+	for _, ip := range ips {
+		if !d.policy.allowsIP(ip) {
+			lastErr = fmt.Errorf("%w: non-public address %s of %s", ErrEgressDenied, ip, host)
+			continue
+		}
+		conn, err := d.dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no addresses found for %s", host)
+	}
+	return nil, lastErr
+}
+
+// resolve returns the addresses of host, from the pins when DNS pinning is on
+func (d *egressDialer) resolve(ctx context.Context, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+
+	now := time.Now()
+	if d.policy.PinDNS {
+		d.mu.Lock()
+		pinned, ok := d.pins[host]
+		d.mu.Unlock()
+		if ok && now.Before(pinned.expiresAt) {
+			return pinned.ips, nil
+		}
+	}
+
+	addrs, err := d.resolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	ips := make([]net.IP, 0, len(addrs))
+	for _, addr := range addrs {
+		ips = append(ips, addr.IP)
+	}
+
+	if d.policy.PinDNS {
+		ttl := d.policy.PinTTL
+		if ttl <= 0 {
+			ttl = defaultPinTTL
+		}
+		d.mu.Lock()
+		d.pins[host] = pinnedAddresses{ips: ips, expiresAt: now.Add(ttl)}
+		d.mu.Unlock()
+	}
+	return ips, nil
+}
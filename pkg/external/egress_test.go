@@ -0,0 +1,100 @@
+package external
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestEgressPolicy_AllowsHost(t *testing.T) {
+	policy := &EgressPolicy{AllowedHosts: []string{"api.example.com", " *.Partner.example ", "zipcloud.ibsnet.co.jp"}}
+
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"api.example.com", true},
+		{"API.Example.com", true},
+		{"api.example.com.", true},
+		{"www.example.com", false},
+		{"api.example.com.evil.test", false},
+		{"evilapi.example.com", false},
+		{"sandbox.partner.example", true},
+		{"a.b.partner.example", true},
+		// The wildcard matches subdomains only
+		{"partner.example", false},
+		{".partner.example", false},
+		{"evilpartner.example", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := policy.AllowsHost(tt.host); got != tt.want {
+			t.Errorf("AllowsHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+
+	t.Run("empty allowlist", func(t *testing.T) {
+		for _, policy := range []*EgressPolicy{nil, {}} {
+			if !policy.AllowsHost("internal.local") {
+				t.Errorf("%+v does not allow every host", policy)
+			}
+		}
+	})
+}
+
+func TestEgressPolicy_allowsIP(t *testing.T) {
+	policy := &EgressPolicy{BlockPrivateIPs: true}
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"203.0.113.10", true},
+		{"2001:db8::1", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"224.0.0.1", false},
+		{"100.64.0.1", false},
+		{"100.127.255.255", false},
+		{"100.128.0.1", true},
+		// IPv4-mapped IPv6 addresses are checked as IPv4
+		{"::ffff:127.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+	}
+
+	for _, tt := range tests {
+		ip := net.ParseIP(tt.ip)
+		if ip == nil {
+			t.Fatalf("invalid test IP %q", tt.ip)
+		}
+		if got := policy.allowsIP(ip); got != tt.want {
+			t.Errorf("allowsIP(%s) = %v, want %v", tt.ip, got, tt.want)
+		}
+	}
+
+	t.Run("private addresses allowed", func(t *testing.T) {
+		if !(&EgressPolicy{}).allowsIP(net.ParseIP("127.0.0.1")) {
+			t.Error("allowsIP(127.0.0.1) = false without BlockPrivateIPs")
+		}
+	})
+}
+
+func TestEgressPolicy_CheckURL(t *testing.T) {
+	policy := &EgressPolicy{AllowedHosts: []string{"api.example.com"}}
+
+	if err := policy.CheckURL("https://api.example.com:8443/v1"); err != nil {
+		t.Errorf("CheckURL of an allowed host: %v", err)
+	}
+	if err := policy.CheckURL("https://metadata.internal/v1"); !errors.Is(err, ErrEgressDenied) {
+		t.Errorf("CheckURL of another host: err = %v, want ErrEgressDenied", err)
+	}
+}
@@ -3,8 +3,19 @@ package external
 
 import (
 	"context"
+	"errors"
+	"sync"
+	"time"
 
 	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// defaultAvailabilityTimeout bounds a combined availability check
+	defaultAvailabilityTimeout = 10 * time.Second
+	// defaultAvailabilityCallTimeout bounds each API call of an availability check, retries included
+	defaultAvailabilityCallTimeout = 5 * time.Second
 )
 
 // Manager provides a unified interface for all external API clients
@@ -13,6 +24,9 @@ type Manager struct {
 	region    *RegionClient
 	address   *AddressClient
 	log       *logger.Logger
+
+	availabilityTimeout     time.Duration
+	availabilityCallTimeout time.Duration
 }
 
 // ManagerConfig holds configuration for all external API clients
@@ -20,6 +34,12 @@ type ManagerConfig struct {
 	InventoryAPI *Config `json:"inventory_api"`
 	RegionAPI    *Config `json:"region_api"`
 	AddressAPI   *Config `json:"address_api"`
+	// AddressProvider selects the postal code lookup API used by the address client
+	AddressProvider AddressProvider `json:"-"`
+	// AvailabilityTimeout is the overall deadline of CheckOptionAvailability
+	AvailabilityTimeout time.Duration `json:"availability_timeout"`
+	// AvailabilityCallTimeout bounds each API call of CheckOptionAvailability, retries included
+	AvailabilityCallTimeout time.Duration `json:"availability_call_timeout"`
 }
 
 // NewManager creates a new external API manager with all clients
@@ -37,7 +57,16 @@ func NewManager(config *ManagerConfig, log *logger.Logger) *Manager {
 	}
 
 	if config.AddressAPI != nil {
-		address = NewAddressClient(config.AddressAPI, log)
+		address = NewAddressClient(config.AddressAPI, config.AddressProvider, log)
+	}
+
+	availabilityTimeout := config.AvailabilityTimeout
+	if availabilityTimeout == 0 {
+		availabilityTimeout = defaultAvailabilityTimeout
+	}
+	availabilityCallTimeout := config.AvailabilityCallTimeout
+	if availabilityCallTimeout == 0 {
+		availabilityCallTimeout = defaultAvailabilityCallTimeout
 	}
 
 	return &Manager{
@@ -45,6 +74,9 @@ func NewManager(config *ManagerConfig, log *logger.Logger) *Manager {
 		region:    region,
 		address:   address,
 		log:       log,
+
+		availabilityTimeout:     availabilityTimeout,
+		availabilityCallTimeout: availabilityCallTimeout,
 	}
 }
 
@@ -63,55 +95,87 @@ func (m *Manager) AddressClient() *AddressClient {
 	return m.address
 }
 
-// CheckOptionAvailability checks both inventory and region restrictions for options
+// CheckOptionAvailability checks both inventory and region restrictions for options. The two
+// APIs are called concurrently, each bounded by the call timeout and both by the overall
+// deadline; a failed or timed out call leaves its data out of the result.
 func (m *Manager) CheckOptionAvailability(ctx context.Context, prefecture, city string, optionIDs []string) (*OptionAvailabilityResult, error) {
-	result := &OptionAvailabilityResult{
-		OptionResults: make(map[string]*OptionAvailability),
-	}
+	ctx, cancel := context.WithTimeout(ctx, m.availabilityTimeout)
+	defer cancel()
 
-	// Check inventory if client is available
+	// Each goroutine writes only its own map, read after Wait
 	var inventoryMap map[string]int
+	var regionMap map[string]bool
+	var g errgroup.Group
+
+	// Check inventory if client is available
 	if m.inventory != nil {
-		var err error
-		inventoryMap, err = m.inventory.CheckInventory(ctx, optionIDs)
-		if err != nil {
-			m.log.WithError(err).WithField("option_ids", optionIDs).Warn("Failed to check inventory, continuing without inventory data")
-			// Continue without inventory data - don't fail the entire operation
-		}
+		g.Go(func() error {
+			callCtx, cancel := context.WithTimeout(ctx, m.availabilityCallTimeout)
+			defer cancel()
+
+			inventory, err := m.inventory.CheckInventory(callCtx, optionIDs)
+			if err != nil {
+				m.log.WithError(err).WithField("option_ids", optionIDs).Warn("Failed to check inventory, continuing without inventory data")
+				// Continue without inventory data - don't fail the entire operation
+				return nil
+			}
+			inventoryMap = inventory
+			return nil
+		})
 	}
 
 	// Check region restrictions if client is available
-	var regionMap map[string]bool
 	if m.region != nil && prefecture != "" && city != "" {
-		var err error
-		regionMap, err = m.region.CheckRegionRestrictions(ctx, prefecture, city, optionIDs)
-		if err != nil {
-			m.log.WithError(err).
-				WithField("prefecture", prefecture).
-				WithField("city", city).
-				WithField("option_ids", optionIDs).
-				Warn("Failed to check region restrictions, continuing without region data")
-			// Continue without region data - don't fail the entire operation
-		}
+		g.Go(func() error {
+			callCtx, cancel := context.WithTimeout(ctx, m.availabilityCallTimeout)
+			defer cancel()
+
+			regions, err := m.region.CheckRegionRestrictions(callCtx, prefecture, city, optionIDs)
+			if err != nil {
+				m.log.WithError(err).
+					WithField("prefecture", prefecture).
+					WithField("city", city).
+					WithField("option_ids", optionIDs).
+					Warn("Failed to check region restrictions, continuing without region data")
+				// Continue without region data - don't fail the entire operation
+				return nil
+			}
+			regionMap = regions
+			return nil
+		})
+	}
+
+	// Failures are logged above and never returned, so Wait only synchronizes
+	_ = g.Wait()
+
+	return NewOptionAvailabilityResult(optionIDs, inventoryMap, regionMap), nil
+}
+
+// NewOptionAvailabilityResult combines stock levels and region restrictions into the
+// availability of each option. Options missing from inventory have no stock; options
+// missing from regions are treated as allowed. Either map may be nil when its data is
+// unavailable.
+func NewOptionAvailabilityResult(optionIDs []string, inventory map[string]int, regions map[string]bool) *OptionAvailabilityResult {
+	result := &OptionAvailabilityResult{
+		OptionResults: make(map[string]*OptionAvailability, len(optionIDs)),
 	}
 
-	// Combine results
 	for _, optionID := range optionIDs {
 		availability := &OptionAvailability{
 			OptionID: optionID,
 		}
 
 		// Set inventory data
-		if inventoryMap != nil {
-			if stock, exists := inventoryMap[optionID]; exists {
+		if inventory != nil {
+			if stock, exists := inventory[optionID]; exists {
 				availability.Stock = &stock
 				availability.HasStock = stock > 0
 			}
 		}
 
 		// Set region restriction data
-		if regionMap != nil {
-			if isAllowed, exists := regionMap[optionID]; exists {
+		if regions != nil {
+			if isAllowed, exists := regions[optionID]; exists {
 				availability.IsRegionAllowed = &isAllowed
 			}
 		}
@@ -122,7 +186,7 @@ func (m *Manager) CheckOptionAvailability(ctx context.Context, prefecture, city
 		result.OptionResults[optionID] = availability
 	}
 
-	return result, nil
+	return result
 }
 
 // OptionAvailabilityResult represents the combined availability check result
@@ -183,51 +247,61 @@ func (r *OptionAvailabilityResult) GetRegionRestrictedOptions() []string {
 	return restricted
 }
 
-// HealthCheck performs health checks on all configured external APIs
+// HealthCheck calls every configured external API concurrently and reports how each
+// answered. The calls skip the response cache but not the circuit breaker, so an API whose
+// circuit is open is reported unhealthy without being called.
 func (m *Manager) HealthCheck(ctx context.Context) *HealthCheckResult {
-	result := &HealthCheckResult{
-		Services: make(map[string]*ServiceHealth),
-	}
+	ctx = WithoutCache(ctx)
 
-	// Check inventory API
+	checks := make(map[string]func(context.Context) error)
 	if m.inventory != nil {
-		health := &ServiceHealth{Name: "inventory"}
-		_, err := m.inventory.CheckInventory(ctx, []string{"TEST"})
-		if err != nil {
-			health.Status = "unhealthy"
-			health.Error = err.Error()
-		} else {
-			health.Status = "healthy"
+		checks[ClientNameInventory] = func(ctx context.Context) error {
+			_, err := m.inventory.CheckInventory(ctx, []string{"TEST"})
+			return err
 		}
-		result.Services["inventory"] = health
 	}
-
-	// Check region API
 	if m.region != nil {
-		health := &ServiceHealth{Name: "region"}
-		_, err := m.region.CheckRegionRestrictions(ctx, "東京都", "渋谷区", []string{"TEST"})
-		if err != nil {
-			health.Status = "unhealthy"
-			health.Error = err.Error()
-		} else {
-			health.Status = "healthy"
+		checks[ClientNameRegion] = func(ctx context.Context) error {
+			_, err := m.region.CheckRegionRestrictions(ctx, "東京都", "渋谷区", []string{"TEST"})
+			return err
 		}
-		result.Services["region"] = health
 	}
-
-	// Check address API
 	if m.address != nil {
-		health := &ServiceHealth{Name: "address"}
-		available := m.address.IsAddressAvailable(ctx)
-		if !available {
-			health.Status = "unhealthy"
-			health.Error = "address search not available"
-		} else {
-			health.Status = "healthy"
+		checks[ClientNameAddress] = func(ctx context.Context) error {
+			_, err := m.address.SearchByPostalCode(ctx, "1000005")
+			return err
 		}
-		result.Services["address"] = health
 	}
 
+	result := &HealthCheckResult{
+		Services: make(map[string]*ServiceHealth, len(checks)),
+	}
+	var mu sync.Mutex
+	var g errgroup.Group
+	for name, check := range checks {
+		g.Go(func() error {
+			start := time.Now()
+			err := check(ctx)
+			health := &ServiceHealth{
+				Name:      name,
+				Status:    "healthy",
+				Latency:   time.Since(start),
+				CheckedAt: start,
+			}
+			if err != nil {
+				health.Status = "unhealthy"
+				health.Error = healthError(err)
+				health.cause = err
+			}
+
+			mu.Lock()
+			result.Services[name] = health
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = g.Wait()
+
 	// Set overall status
 	result.OverallStatus = "healthy"
 	for _, service := range result.Services {
@@ -240,6 +314,24 @@ func (m *Manager) HealthCheck(ctx context.Context) *HealthCheckResult {
 	return result
 }
 
+// healthError describes why a health check call failed without the URL or response
+// details, which health responses must not reveal
+func healthError(err error) string {
+	var statusErr *StatusError
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		return "circuit breaker open"
+	case errors.Is(err, ErrEgressDenied):
+		return "egress denied"
+	case IsTimeout(err):
+		return "timeout"
+	case errors.As(err, &statusErr):
+		return statusErr.Error()
+	default:
+		return "request failed"
+	}
+}
+
 // HealthCheckResult represents the result of external API health checks
 type HealthCheckResult struct {
 	OverallStatus string                    `json:"overall_status"`
@@ -250,7 +342,18 @@ type HealthCheckResult struct {
 type ServiceHealth struct {
 	Name   string `json:"name"`
 	Status string `json:"status"` // "healthy", "unhealthy"
-	Error  string `json:"error,omitempty"`
+	// Error is a short reason for an unhealthy status, safe to show in health responses
+	Error     string        `json:"error,omitempty"`
+	Latency   time.Duration `json:"latency"`
+	CheckedAt time.Time     `json:"checked_at"`
+
+	// cause is the error of the failed call, for logging
+	cause error
+}
+
+// Cause returns the error of the failed health check call, or nil
+func (h *ServiceHealth) Cause() error {
+	return h.cause
 }
 
 // IsHealthy returns true if all services are healthy
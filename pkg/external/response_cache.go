@@ -0,0 +1,123 @@
+// Package external provides caching of external API responses by request hash.
+package external
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/lru"
+)
+
+// defaultCacheMaxEntries bounds a response cache when no size is configured
+const defaultCacheMaxEntries = 1000
+
+// cacheBypassKey marks a context whose external calls skip cached responses
+type cacheBypassKey struct{}
+
+// WithoutCache returns a context whose external API calls always reach the API. The fresh
+// responses still replace cached ones.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+// isCacheBypassed reports whether ctx skips cached responses
+func isCacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
+
+// CacheStatus reports the state of a client's response cache
+type CacheStatus struct {
+	TTL        string `json:"ttl"`
+	Entries    int    `json:"entries"`
+	MaxEntries int    `json:"max_entries"`
+	Hits       int64  `json:"hits"`
+	Misses     int64  `json:"misses"`
+	Evictions  int64  `json:"evictions"`
+}
+
+// responseCache keeps the results of successful calls, keyed by method, endpoint and
+// request body, for a fixed TTL
+type responseCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries *lru.Cache[string, cachedResponse]
+	hits    int64
+	misses  int64
+}
+
+// cachedResponse is a result encoded as JSON and the time it stops being served
+type cachedResponse struct {
+	body      []byte
+	expiresAt time.Time
+}
+
+// newResponseCache creates a cache, or returns nil when ttl disables caching
+func newResponseCache(ttl time.Duration, maxEntries int) *responseCache {
+	if ttl <= 0 {
+		return nil
+	}
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+	return &responseCache{
+		ttl:     ttl,
+		entries: lru.New[string, cachedResponse](maxEntries),
+	}
+}
+
+// responseCacheKey hashes a request so payloads of any size make short keys
+func responseCacheKey(method, endpoint string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(endpoint))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// get returns the cached result for key unless it has expired
+func (c *responseCache) get(key string, now time.Time) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cached, ok := c.entries.Get(key)
+	if !ok || !now.Before(cached.expiresAt) {
+		if ok {
+			c.entries.Remove(key)
+		}
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	return cached.body, true
+}
+
+// put stores a result for the TTL
+func (c *responseCache) put(key string, body []byte, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries.Add(key, cachedResponse{body: body, expiresAt: now.Add(c.ttl)})
+}
+
+// status reports the cache size and hit counts
+func (c *responseCache) status() *CacheStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.entries.Stats()
+	return &CacheStatus{
+		TTL:        c.ttl.String(),
+		Entries:    stats.Entries,
+		MaxEntries: stats.MaxEntries,
+		Hits:       c.hits,
+		Misses:     c.misses,
+		Evictions:  stats.Evictions,
+	}
+}
@@ -0,0 +1,73 @@
+// Package external provides retry backoff for external API calls.
+package external
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultMaxRetryDelay caps the backoff between attempts
+const defaultMaxRetryDelay = 10 * time.Second
+
+// backoff returns the wait before a retry: the delay doubles from base with every attempt
+// up to maxDelay, and a random half of it is dropped so that clients failing together do
+// not retry together
+func backoff(attempt int, base, maxDelay time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxDelay)
+	if delay <= 0 {
+		return 0
+	}
+
+	half := delay / 2
+	return half + rand.N(delay-half+1) //nolint:gosec // jitter needs no cryptographic randomness
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// isRetryable reports whether a failed attempt may succeed when repeated. Client errors
+// are caused by the request and fail again, except 429 which asks to retry later.
+func isRetryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return !isClientError(err)
+}
+
+// waitForRetry sleeps for delay, or reports false when the context ends first. A context
+// whose deadline falls within the delay is given up on without sleeping.
+func waitForRetry(ctx context.Context, delay time.Duration) bool {
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+		return false
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
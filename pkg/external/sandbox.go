@@ -0,0 +1,24 @@
+// Package external provides per-request routing of external API calls to partner sandboxes.
+package external
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrSandboxNotConfigured is returned when a sandbox call is requested but no sandbox URL is configured
+var ErrSandboxNotConfigured = errors.New("sandbox base URL is not configured")
+
+// sandboxKey marks a context whose external calls go to sandbox base URLs
+type sandboxKey struct{}
+
+// WithSandbox returns a context whose external API calls use the sandbox base URLs
+func WithSandbox(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sandboxKey{}, true)
+}
+
+// IsSandbox reports whether external API calls made with ctx use the sandbox base URLs
+func IsSandbox(ctx context.Context) bool {
+	sandbox, _ := ctx.Value(sandboxKey{}).(bool)
+	return sandbox
+}
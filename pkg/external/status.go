@@ -0,0 +1,138 @@
+// Package external provides runtime status reporting for external API clients.
+package external
+
+import (
+	"net/url"
+	"time"
+)
+
+// Client names used in status reports
+const (
+	ClientNameInventory = "inventory"
+	ClientNameRegion    = "region"
+	ClientNameAddress   = "address"
+)
+
+const redactedValue = "REDACTED"
+
+// ClientStatus describes the effective configuration and runtime state of a client
+type ClientStatus struct {
+	Name           string                `json:"name"`
+	Configured     bool                  `json:"configured"`
+	BaseURL        string                `json:"base_url,omitempty"`
+	SandboxBaseURL string                `json:"sandbox_base_url,omitempty"`
+	Timeout        string                `json:"timeout,omitempty"`
+	MaxRetries     int                   `json:"max_retries,omitempty"`
+	RetryDelay     string                `json:"retry_delay,omitempty"`
+	CircuitBreaker *CircuitBreakerStatus `json:"circuit_breaker,omitempty"`
+	Cache          *CacheStatus          `json:"cache,omitempty"`
+	LastError      string                `json:"last_error,omitempty"`
+	LastErrorAt    *time.Time            `json:"last_error_at,omitempty"`
+	LastSuccessAt  *time.Time            `json:"last_success_at,omitempty"`
+}
+
+// Status returns the client's effective configuration and runtime state.
+// Credentials embedded in the base URL are redacted.
+func (c *Client) Status() *ClientStatus {
+	status := &ClientStatus{
+		Configured:     true,
+		BaseURL:        redactURL(c.baseURL),
+		SandboxBaseURL: redactOptionalURL(c.sandboxURL),
+		Timeout:        c.timeout.String(),
+		MaxRetries:     c.maxRetries,
+		RetryDelay:     c.retryDelay.String(),
+		CircuitBreaker: c.breaker.Status(),
+	}
+	if c.cache != nil {
+		status.Cache = c.cache.status()
+	}
+
+	c.statsMu.Lock()
+	defer c.statsMu.Unlock()
+
+	status.LastError = c.lastError
+	if !c.lastErrorAt.IsZero() {
+		lastErrorAt := c.lastErrorAt
+		status.LastErrorAt = &lastErrorAt
+	}
+	if !c.lastSuccessAt.IsZero() {
+		lastSuccessAt := c.lastSuccessAt
+		status.LastSuccessAt = &lastSuccessAt
+	}
+
+	return status
+}
+
+// ClientStatuses returns the status of every known client, including unconfigured ones
+func (m *Manager) ClientStatuses() []*ClientStatus {
+	clients := m.namedClients()
+	statuses := make([]*ClientStatus, 0, len(clients))
+
+	for _, named := range clients {
+		status := &ClientStatus{Name: named.name}
+		if named.client != nil {
+			status = named.client.Status()
+			status.Name = named.name
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// namedClient pairs a client with its name; client is nil when the API is not configured
+type namedClient struct {
+	name   string
+	client *Client
+}
+
+// namedClients returns the underlying HTTP clients in a stable order
+func (m *Manager) namedClients() []namedClient {
+	clients := []namedClient{
+		{name: ClientNameInventory},
+		{name: ClientNameRegion},
+		{name: ClientNameAddress},
+	}
+
+	if m.inventory != nil {
+		clients[0].client = m.inventory.client
+	}
+	if m.region != nil {
+		clients[1].client = m.region.client
+	}
+	if m.address != nil {
+		clients[2].client = m.address.client
+	}
+
+	return clients
+}
+
+// redactOptionalURL redacts a URL, keeping empty values empty
+func redactOptionalURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	return redactURL(raw)
+}
+
+// redactURL removes passwords and query parameter values (which may carry API keys) from a URL
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redactedValue
+	}
+
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.UserPassword(u.User.Username(), redactedValue)
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for key := range query {
+			query.Set(key, redactedValue)
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	return u.String()
+}
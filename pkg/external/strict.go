@@ -0,0 +1,19 @@
+// Package external provides strict mode, in which failed external APIs are not worked around.
+package external
+
+import "context"
+
+// strictKey marks a context that must not be answered from fallbacks
+type strictKey struct{}
+
+// WithStrict returns a context in strict mode: when an external API fails, callers reject
+// the request instead of answering from local data or leaving the API's data out
+func WithStrict(ctx context.Context) context.Context {
+	return context.WithValue(ctx, strictKey{}, true)
+}
+
+// IsStrict reports whether ctx is in strict mode
+func IsStrict(ctx context.Context) bool {
+	strict, _ := ctx.Value(strictKey{}).(bool)
+	return strict
+}
@@ -0,0 +1,244 @@
+// Package fieldcrypt encrypts individual database columns holding personal data.
+//
+// Values are sealed with AES-256-GCM and stored as "enc:v1:KEY_ID:BASE64", so each value
+// names the key it was encrypted with and keys can be rotated by rewriting the rows. Values
+// without the prefix are read as plaintext; rows written before encryption was enabled stay
+// readable until they are rewritten. Encrypted values cannot be compared in SQL, so columns
+// that are searched also store a keyed hash of the plaintext (a blind index).
+package fieldcrypt
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	// prefix marks encrypted values; the version changes with the format
+	prefix = "enc:v1:"
+	// KeySize is the length of AES-256 keys in bytes
+	KeySize = 32
+)
+
+// ErrUnknownKey is returned for a key ID the provider does not have
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// KeyProvider supplies the data keys. Keys stay available after a rotation so values
+// encrypted with them can still be read; a KMS-backed provider fetches them by ID.
+type KeyProvider interface {
+	// ActiveKeyID returns the ID of the key new values are encrypted with, or "" to write
+	// plaintext while still reading encrypted values
+	ActiveKeyID() string
+	// Key returns the AES-256 key with the ID
+	Key(ctx context.Context, id string) ([]byte, error)
+}
+
+// StaticKeys is a KeyProvider holding keys given in the configuration
+type StaticKeys struct {
+	activeID string
+	keys     map[string][]byte
+}
+
+// ParseKeys creates a provider from keys in the form "ID=BASE64,ID=BASE64", each a base64
+// encoded 32-byte key, e.g. "2024a=...,2025a=...". activeID must name one of them or be empty.
+func ParseKeys(spec, activeID string) (*StaticKeys, error) {
+	provider := &StaticKeys{activeID: activeID, keys: make(map[string][]byte)}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, "=")
+		id = strings.TrimSpace(id)
+		if !ok || id == "" || strings.Contains(id, ":") {
+			return nil, fmt.Errorf("invalid encryption key entry %q: expected ID=BASE64", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %s: %w", id, err)
+		}
+		if len(key) != KeySize {
+			return nil, fmt.Errorf("invalid encryption key %s: %d bytes, expected %d", id, len(key), KeySize)
+		}
+		if _, exists := provider.keys[id]; exists {
+			return nil, fmt.Errorf("duplicate encryption key %s", id)
+		}
+		provider.keys[id] = key
+	}
+
+	if activeID != "" {
+		if _, ok := provider.keys[activeID]; !ok {
+			return nil, fmt.Errorf("active encryption key %s is not configured", activeID)
+		}
+	}
+	return provider, nil
+}
+
+// ActiveKeyID implements KeyProvider
+func (p *StaticKeys) ActiveKeyID() string {
+	return p.activeID
+}
+
+// Key implements KeyProvider
+func (p *StaticKeys) Key(_ context.Context, id string) ([]byte, error) {
+	key, ok := p.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+	return key, nil
+}
+
+// Cipher encrypts and decrypts column values and computes their blind indexes. The column
+// name is authenticated with each value, so a value copied to another column fails to decrypt.
+type Cipher struct {
+	keys     KeyProvider
+	indexKey []byte
+
+	mu    sync.Mutex
+	aeads map[string]cipher.AEAD
+}
+
+// NewCipher creates a cipher. Without a key provider values are written in plaintext. The
+// index key keys the blind indexes; plain SHA-256 is used when it is empty.
+func NewCipher(keys KeyProvider, indexKey string) *Cipher {
+	return &Cipher{
+		keys:     keys,
+		indexKey: []byte(indexKey),
+		aeads:    make(map[string]cipher.AEAD),
+	}
+}
+
+// activeKeyID returns the ID of the key values are written with, "" for plaintext
+func (c *Cipher) activeKeyID() string {
+	if c.keys == nil {
+		return ""
+	}
+	return c.keys.ActiveKeyID()
+}
+
+// Enabled reports whether new values are encrypted
+func (c *Cipher) Enabled() bool {
+	return c.activeKeyID() != ""
+}
+
+// Encrypt seals a value of the column with the active key, or returns it unchanged when
+// encryption is disabled
+func (c *Cipher) Encrypt(ctx context.Context, column, plaintext string) (string, error) {
+	id := c.activeKeyID()
+	if id == "" {
+		return plaintext, nil
+	}
+
+	aead, err := c.aead(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(column))
+	return prefix + id + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value of the column; values without the encryption prefix are returned
+// unchanged
+func (c *Cipher) Decrypt(ctx context.Context, column, value string) (string, error) {
+	id, encoded, ok := parse(value)
+	if !ok {
+		return value, nil
+	}
+	if c.keys == nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+
+	aead, err := c.aead(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted value in %s", column)
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(column))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt %s with key %s: %w", column, id, err)
+	}
+	return string(plaintext), nil
+}
+
+// IsCurrent reports whether a stored value is written the way new values are: encrypted with
+// the active key, or plaintext when encryption is disabled
+func (c *Cipher) IsCurrent(value string) bool {
+	id, _, ok := parse(value)
+	if !ok {
+		return c.activeKeyID() == ""
+	}
+	return id == c.activeKeyID()
+}
+
+// KeyPrefix returns the prefix of the values encrypted with the key, so that the rows still
+// holding them can be found in SQL when the key is rotated
+func KeyPrefix(id string) string {
+	return prefix + id + ":"
+}
+
+// BlindIndex returns the hex encoded keyed hash of a value of the column. Equal values of
+// the same column have equal indexes, so they can be searched without decrypting.
+func (c *Cipher) BlindIndex(column, value string) string {
+	message := []byte(column + "\x00" + value)
+
+	if len(c.indexKey) == 0 {
+		sum := sha256.Sum256(message)
+		return hex.EncodeToString(sum[:])
+	}
+
+	mac := hmac.New(sha256.New, c.indexKey)
+	mac.Write(message)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// aead returns the AES-GCM instance of a key, creating it on first use
+func (c *Cipher) aead(ctx context.Context, id string) (cipher.AEAD, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if aead, ok := c.aeads[id]; ok {
+		return aead, nil
+	}
+
+	key, err := c.keys.Key(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key %s: %w", id, err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM for key %s: %w", id, err)
+	}
+	c.aeads[id] = aead
+	return aead, nil
+}
+
+// parse splits an encrypted value into its key ID and base64 payload
+func parse(value string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(value, prefix)
+	if !ok {
+		return "", "", false
+	}
+	return strings.Cut(rest, ":")
+}
@@ -0,0 +1,174 @@
+package fieldcrypt
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// testKey returns a base64 encoded key filled with b
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, KeySize))
+}
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		activeID string
+		wantErr  bool
+	}{
+		{"single key", "k1=" + testKey(1), "k1", false},
+		{"two keys with spaces", " k1 = " + testKey(1) + " , k2=" + testKey(2), "k2", false},
+		{"no active key", "k1=" + testKey(1), "", false},
+		{"empty", "", "", false},
+		{"missing separator", "k1" + testKey(1), "", true},
+		{"empty ID", "=" + testKey(1), "", true},
+		{"colon in ID", "k:1=" + testKey(1), "", true},
+		{"invalid base64", "k1=not base64", "", true},
+		{"short key", "k1=" + base64.StdEncoding.EncodeToString([]byte("short")), "", true},
+		{"duplicate ID", "k1=" + testKey(1) + ",k1=" + testKey(2), "", true},
+		{"unknown active key", "k1=" + testKey(1), "k2", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseKeys(tt.spec, tt.activeID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseKeys(%q, %q) err = %v, wantErr %v", tt.spec, tt.activeID, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCipher_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	keys, err := ParseKeys("old="+testKey(1)+",new="+testKey(2), "old")
+	if err != nil {
+		t.Fatalf("ParseKeys: %v", err)
+	}
+	c := NewCipher(keys, "index-key")
+
+	encrypted, err := c.Encrypt(ctx, "email", "taro@example.com")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !strings.HasPrefix(encrypted, KeyPrefix("old")) || strings.Contains(encrypted, "taro") {
+		t.Fatalf("Encrypt = %q, want a value sealed with key old", encrypted)
+	}
+	again, _ := c.Encrypt(ctx, "email", "taro@example.com")
+	if again == encrypted {
+		t.Error("Encrypt reuses nonces: equal plaintexts give equal values")
+	}
+
+	sealed, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(encrypted, KeyPrefix("old")))
+	sealed[len(sealed)-1] ^= 1
+	tampered := KeyPrefix("old") + base64.StdEncoding.EncodeToString(sealed)
+
+	tests := []struct {
+		name    string
+		column  string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"encrypted value", "email", encrypted, "taro@example.com", false},
+		{"plaintext value", "email", "hanako@example.com", "hanako@example.com", false},
+		{"value of another column", "phone", encrypted, "", true},
+		{"tampered value", "email", tampered, "", true},
+		{"unknown key", "email", KeyPrefix("gone") + "AAAA", "", true},
+		{"malformed value", "email", KeyPrefix("old") + "!", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Decrypt(ctx, tt.column, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Decrypt err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Decrypt = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("unknown key error", func(t *testing.T) {
+		_, err := c.Decrypt(ctx, "email", KeyPrefix("gone")+"AAAA")
+		if !errors.Is(err, ErrUnknownKey) {
+			t.Errorf("Decrypt err = %v, want ErrUnknownKey", err)
+		}
+	})
+}
+
+func TestCipher_IsCurrent(t *testing.T) {
+	ctx := context.Background()
+	keys, err := ParseKeys("old="+testKey(1)+",new="+testKey(2), "new")
+	if err != nil {
+		t.Fatalf("ParseKeys: %v", err)
+	}
+	oldKeys, _ := ParseKeys("old="+testKey(1), "old")
+	oldValue, _ := NewCipher(oldKeys, "").Encrypt(ctx, "email", "taro@example.com")
+	newValue, _ := NewCipher(keys, "").Encrypt(ctx, "email", "taro@example.com")
+	plaintextOnly := NewCipher(nil, "")
+
+	tests := []struct {
+		name   string
+		cipher *Cipher
+		value  string
+		want   bool
+	}{
+		{"active key", NewCipher(keys, ""), newValue, true},
+		{"rotated key", NewCipher(keys, ""), oldValue, false},
+		{"plaintext while encrypting", NewCipher(keys, ""), "taro@example.com", false},
+		{"plaintext while disabled", plaintextOnly, "taro@example.com", true},
+		{"encrypted while disabled", plaintextOnly, newValue, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cipher.IsCurrent(tt.value); got != tt.want {
+				t.Errorf("IsCurrent(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCipher_BlindIndex(t *testing.T) {
+	c := NewCipher(nil, "index-key")
+
+	tests := []struct {
+		name      string
+		column1   string
+		value1    string
+		column2   string
+		value2    string
+		wantEqual bool
+	}{
+		{"same value", "email", "taro@example.com", "email", "taro@example.com", true},
+		{"other value", "email", "taro@example.com", "email", "hanako@example.com", false},
+		{"other column", "email", "0312345678", "phone", "0312345678", false},
+		// The separator keeps the column and value apart
+		{"shifted boundary", "ab", "c", "a", "bc", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			equal := c.BlindIndex(tt.column1, tt.value1) == c.BlindIndex(tt.column2, tt.value2)
+			if equal != tt.wantEqual {
+				t.Errorf("indexes equal = %v, want %v", equal, tt.wantEqual)
+			}
+		})
+	}
+
+	t.Run("keyed", func(t *testing.T) {
+		if c.BlindIndex("email", "taro@example.com") == NewCipher(nil, "").BlindIndex("email", "taro@example.com") {
+			t.Error("BlindIndex with a key equals the unkeyed hash")
+		}
+		if c.BlindIndex("email", "taro@example.com") == NewCipher(nil, "other").BlindIndex("email", "taro@example.com") {
+			t.Error("BlindIndex does not depend on the key")
+		}
+	})
+}
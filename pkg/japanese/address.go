@@ -0,0 +1,228 @@
+// Package japanese provides normalization of the block numbers and other parts of addresses.
+package japanese
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// Address holds the parts of a Japanese address below the city, as entered in the form.
+// Empty parts were not entered.
+type Address struct {
+	Town     string
+	Chome    string
+	Banchi   string
+	Go       string
+	Building string
+	Room     string
+}
+
+// chomeSuffix is the unit of the canonical chome
+const chomeSuffix = "丁目"
+
+// blockNumberSeparators are the words that separate block numbers written out in full, as in
+// 1丁目2番地3号 or 2番の1; each becomes a hyphen
+var blockNumberSeparators = []string{"丁目", "番地", "番", "号", "の", "丁"}
+
+// addressDashes are the characters typed as hyphens between block numbers
+var addressDashes = map[rune]bool{
+	'-': true, '‐': true, '‑': true, '‒': true, '–': true, '—': true, '―': true, '−': true,
+	'─': true, 'ー': true, '～': true, '〜': true,
+}
+
+// kanjiDigits are the kanji numerals for 0 to 9
+var kanjiDigits = map[rune]int{
+	'〇': 0, '零': 0, '一': 1, '二': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+}
+
+// kanjiUnits are the kanji numeral multipliers
+var kanjiUnits = map[rune]int{'十': 10, '百': 100, '千': 1000}
+
+// maxBlockNumberDigits bounds the numerals read as a block number
+const maxBlockNumberDigits = 9
+
+// NormalizeAddress converts address parts to the canonical form in which they are stored:
+// full-width letters and digits become half-width, spaces are trimmed, and block numbers are
+// written in Arabic numerals as chome "2丁目", banchi "8" and go "1". A chome written at the
+// end of the town moves to an empty Chome, and block numbers all entered in Banchi ("2-8-1",
+// "8番1号") are distributed to the empty Chome and Go. Parts that cannot be read as numbers
+// keep their folded text; check them with IsBlockNumber.
+func NormalizeAddress(a Address) Address {
+	normalized := Address{
+		Town:     foldAddressText(a.Town),
+		Chome:    foldAddressText(a.Chome),
+		Banchi:   foldAddressText(a.Banchi),
+		Go:       foldAddressText(a.Go),
+		Building: foldAddressText(a.Building),
+		Room:     foldAddressText(a.Room),
+	}
+
+	if normalized.Chome == "" {
+		normalized.Town, normalized.Chome = splitTownChome(normalized.Town)
+	}
+
+	if numbers, ok := blockNumbers(normalized.Banchi); ok {
+		switch {
+		case len(numbers) == 3 && normalized.Chome == "" && normalized.Go == "":
+			normalized.Chome, normalized.Go = numbers[0], numbers[2]
+			numbers = numbers[1:2]
+		case len(numbers) == 2 && normalized.Go == "":
+			normalized.Go = numbers[1]
+			numbers = numbers[:1]
+		}
+		normalized.Banchi = strings.Join(numbers, "-")
+	}
+	if numbers, ok := blockNumbers(normalized.Chome); ok && len(numbers) == 1 {
+		normalized.Chome = numbers[0] + chomeSuffix
+	}
+	if numbers, ok := blockNumbers(normalized.Go); ok {
+		normalized.Go = strings.Join(numbers, "-")
+	}
+
+	return normalized
+}
+
+// NormalizeBlockNumber converts block numbers entered in one field ("２丁目８番地１号",
+// "2-8-1") to Arabic numerals joined by hyphens ("2-8-1"). It reports false, returning the
+// folded text, when s cannot be read as block numbers.
+func NormalizeBlockNumber(s string) (string, bool) {
+	folded := foldAddressText(s)
+	numbers, ok := blockNumbers(folded)
+	if !ok {
+		return folded, false
+	}
+	return strings.Join(numbers, "-"), true
+}
+
+// IsBlockNumber reports whether s is a block number in canonical form: Arabic numerals,
+// optionally joined by hyphens (8 or 8-1)
+func IsBlockNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, segment := range strings.Split(s, "-") {
+		if segment == "" || strings.TrimFunc(segment, isASCIIDigit) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// IsChome reports whether s is a chome in canonical form, e.g. 2丁目
+func IsChome(s string) bool {
+	number, ok := strings.CutSuffix(s, chomeSuffix)
+	return ok && IsBlockNumber(number) && !strings.Contains(number, "-")
+}
+
+// foldAddressText converts full-width letters, digits and symbols to half-width and
+// half-width katakana to full-width, and collapses spaces
+func foldAddressText(s string) string {
+	return strings.Join(strings.Fields(width.Fold.String(s)), " ")
+}
+
+// splitTownChome splits a chome written at the end of a town (西新宿二丁目) from the town
+func splitTownChome(town string) (string, string) {
+	rest, ok := strings.CutSuffix(town, chomeSuffix)
+	if !ok {
+		return town, ""
+	}
+	runes := []rune(rest)
+	start := len(runes)
+	for start > 0 && isNumeral(runes[start-1]) {
+		start--
+	}
+	if start == 0 || start == len(runes) {
+		return town, ""
+	}
+	number, ok := parseNumber(string(runes[start:]))
+	if !ok {
+		return town, ""
+	}
+	return strings.TrimSpace(string(runes[:start])), strconv.Itoa(number) + chomeSuffix
+}
+
+// blockNumbers reads block numbers written with hyphens or unit words, in Arabic or kanji
+// numerals, and returns them in Arabic numerals. It reports false if s holds anything else.
+func blockNumbers(s string) ([]string, bool) {
+	s = strings.Join(strings.Fields(s), "")
+	if s == "" {
+		return nil, false
+	}
+	for _, separator := range blockNumberSeparators {
+		s = strings.ReplaceAll(s, separator, "-")
+	}
+	s = strings.Map(func(r rune) rune {
+		if addressDashes[r] {
+			return '-'
+		}
+		return r
+	}, s)
+
+	var numbers []string
+	for _, segment := range strings.Split(s, "-") {
+		if segment == "" {
+			continue
+		}
+		number, ok := parseNumber(segment)
+		if !ok {
+			return nil, false
+		}
+		numbers = append(numbers, strconv.Itoa(number))
+	}
+	return numbers, len(numbers) > 0
+}
+
+// parseNumber reads a number in Arabic numerals, or in kanji numerals either with units
+// (二十三) or digit by digit (一〇一)
+func parseNumber(s string) (int, bool) {
+	if s == "" || utf8.RuneCountInString(s) > maxBlockNumberDigits {
+		return 0, false
+	}
+	if strings.TrimFunc(s, isASCIIDigit) == "" {
+		number, err := strconv.Atoi(s)
+		return number, err == nil
+	}
+
+	total, current, positional := 0, 0, true
+	for _, r := range s {
+		if _, ok := kanjiUnits[r]; ok {
+			positional = false
+			break
+		}
+	}
+	for _, r := range s {
+		if digit, ok := kanjiDigits[r]; ok {
+			if positional {
+				current = current*10 + digit
+			} else {
+				current = digit
+			}
+			continue
+		}
+		unit, ok := kanjiUnits[r]
+		if !ok {
+			return 0, false
+		}
+		if current == 0 {
+			current = 1
+		}
+		total += current * unit
+		current = 0
+	}
+	return total + current, true
+}
+
+// isNumeral reports whether r is an Arabic or kanji numeral
+func isNumeral(r rune) bool {
+	_, digit := kanjiDigits[r]
+	_, unit := kanjiUnits[r]
+	return isASCIIDigit(r) || digit || unit
+}
+
+// isASCIIDigit reports whether r is 0 to 9
+func isASCIIDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
@@ -0,0 +1,137 @@
+package japanese
+
+import "testing"
+
+func TestNormalizeAddress(t *testing.T) {
+	tests := []struct {
+		name string
+		in   Address
+		want Address
+	}{
+		{
+			name: "full-width block numbers in banchi",
+			in:   Address{Town: "西新宿", Banchi: "２－８－１"},
+			want: Address{Town: "西新宿", Chome: "2丁目", Banchi: "8", Go: "1"},
+		},
+		{
+			name: "block numbers written with units",
+			in:   Address{Town: "西新宿", Banchi: "２丁目８番地１号"},
+			want: Address{Town: "西新宿", Chome: "2丁目", Banchi: "8", Go: "1"},
+		},
+		{
+			name: "chome at the end of the town",
+			in:   Address{Town: "西新宿二丁目", Banchi: "8-1"},
+			want: Address{Town: "西新宿", Chome: "2丁目", Banchi: "8", Go: "1"},
+		},
+		{
+			name: "banchi and go",
+			in:   Address{Town: "丸の内", Banchi: "8番1号"},
+			want: Address{Town: "丸の内", Banchi: "8", Go: "1"},
+		},
+		{
+			name: "kanji numerals",
+			in:   Address{Chome: "三丁目", Banchi: "二十三", Go: "一〇一"},
+			want: Address{Chome: "3丁目", Banchi: "23", Go: "101"},
+		},
+		{
+			name: "banchi with no", // 2番の1
+			in:   Address{Banchi: "2番の1"},
+			want: Address{Banchi: "2", Go: "1"},
+		},
+		{
+			name: "go already entered",
+			in:   Address{Banchi: "8-1", Go: "5"},
+			want: Address{Banchi: "8-1", Go: "5"},
+		},
+		{
+			name: "chome already entered",
+			in:   Address{Chome: "4丁目", Banchi: "1-2-3"},
+			want: Address{Chome: "4丁目", Banchi: "1-2-3"},
+		},
+		{
+			name: "other dashes",
+			in:   Address{Banchi: "8ー1"},
+			want: Address{Banchi: "8", Go: "1"},
+		},
+		{
+			name: "unreadable banchi",
+			in:   Address{Banchi: "番外地"},
+			want: Address{Banchi: "番外地"},
+		},
+		{
+			name: "town without a numeral before chome",
+			in:   Address{Town: "丁目"},
+			want: Address{Town: "丁目"},
+		},
+		{
+			name: "building and room folded",
+			in:   Address{Building: "  ＡＢＣ　　ﾋﾙ  ", Room: "１０１"},
+			want: Address{Building: "ABC ヒル", Room: "101"},
+		},
+		{
+			name: "empty",
+			in:   Address{},
+			want: Address{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeAddress(tt.in); got != tt.want {
+				t.Errorf("NormalizeAddress(%+v) = %+v, want %+v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeBlockNumber(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{"２丁目８番地１号", "2-8-1", true},
+		{"2-8-1", "2-8-1", true},
+		{" 8 - 1 ", "8-1", true},
+		{"十", "10", true},
+		{"百二", "102", true},
+		{"1234567890", "1234567890", false},
+		{"A棟", "A棟", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := NormalizeBlockNumber(tt.in)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("NormalizeBlockNumber(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestIsBlockNumberAndChome(t *testing.T) {
+	tests := []struct {
+		in          string
+		blockNumber bool
+		chome       bool
+	}{
+		{"8", true, false},
+		{"8-1", true, false},
+		{"8-", false, false},
+		{"-1", false, false},
+		{"８", false, false},
+		{"", false, false},
+		{"2丁目", false, true},
+		{"2-1丁目", false, false},
+		{"二丁目", false, false},
+		{"丁目", false, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsBlockNumber(tt.in); got != tt.blockNumber {
+			t.Errorf("IsBlockNumber(%q) = %v, want %v", tt.in, got, tt.blockNumber)
+		}
+		if got := IsChome(tt.in); got != tt.chome {
+			t.Errorf("IsChome(%q) = %v, want %v", tt.in, got, tt.chome)
+		}
+	}
+}
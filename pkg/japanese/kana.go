@@ -0,0 +1,159 @@
+// Package japanese provides kana conversion and romaji transliteration for name fields.
+package japanese
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	hiraganaStart   = 'ぁ'
+	hiraganaEnd     = 'ゖ'
+	hiraganaToKata  = 'ァ' - 'ぁ'
+	halfwidthVoiced = 'ﾞ'
+	halfwidthSemi   = 'ﾟ'
+	prolongedMark   = 'ー'
+	katakanaStart   = 'ァ'
+	katakanaEnd     = 'ヶ'
+)
+
+// halfwidthKatakana maps half-width katakana (U+FF61–U+FF9D) to full-width
+var halfwidthKatakana = map[rune]rune{
+	'｡': '。', '｢': '「', '｣': '」', '､': '、', '･': '・',
+	'ｦ': 'ヲ', 'ｧ': 'ァ', 'ｨ': 'ィ', 'ｩ': 'ゥ', 'ｪ': 'ェ', 'ｫ': 'ォ',
+	'ｬ': 'ャ', 'ｭ': 'ュ', 'ｮ': 'ョ', 'ｯ': 'ッ', 'ｰ': 'ー',
+	'ｱ': 'ア', 'ｲ': 'イ', 'ｳ': 'ウ', 'ｴ': 'エ', 'ｵ': 'オ',
+	'ｶ': 'カ', 'ｷ': 'キ', 'ｸ': 'ク', 'ｹ': 'ケ', 'ｺ': 'コ',
+	'ｻ': 'サ', 'ｼ': 'シ', 'ｽ': 'ス', 'ｾ': 'セ', 'ｿ': 'ソ',
+	'ﾀ': 'タ', 'ﾁ': 'チ', 'ﾂ': 'ツ', 'ﾃ': 'テ', 'ﾄ': 'ト',
+	'ﾅ': 'ナ', 'ﾆ': 'ニ', 'ﾇ': 'ヌ', 'ﾈ': 'ネ', 'ﾉ': 'ノ',
+	'ﾊ': 'ハ', 'ﾋ': 'ヒ', 'ﾌ': 'フ', 'ﾍ': 'ヘ', 'ﾎ': 'ホ',
+	'ﾏ': 'マ', 'ﾐ': 'ミ', 'ﾑ': 'ム', 'ﾒ': 'メ', 'ﾓ': 'モ',
+	'ﾔ': 'ヤ', 'ﾕ': 'ユ', 'ﾖ': 'ヨ',
+	'ﾗ': 'ラ', 'ﾘ': 'リ', 'ﾙ': 'ル', 'ﾚ': 'レ', 'ﾛ': 'ロ',
+	'ﾜ': 'ワ', 'ﾝ': 'ン',
+	halfwidthVoiced: '゛', halfwidthSemi: '゜',
+}
+
+// voiced maps katakana to their voiced (dakuten) form
+var voiced = map[rune]rune{
+	'カ': 'ガ', 'キ': 'ギ', 'ク': 'グ', 'ケ': 'ゲ', 'コ': 'ゴ',
+	'サ': 'ザ', 'シ': 'ジ', 'ス': 'ズ', 'セ': 'ゼ', 'ソ': 'ゾ',
+	'タ': 'ダ', 'チ': 'ヂ', 'ツ': 'ヅ', 'テ': 'デ', 'ト': 'ド',
+	'ハ': 'バ', 'ヒ': 'ビ', 'フ': 'ブ', 'ヘ': 'ベ', 'ホ': 'ボ',
+	'ウ': 'ヴ', 'ワ': 'ヷ', 'ヲ': 'ヺ',
+}
+
+// semiVoiced maps katakana to their semi-voiced (handakuten) form
+var semiVoiced = map[rune]rune{
+	'ハ': 'パ', 'ヒ': 'ピ', 'フ': 'プ', 'ヘ': 'ペ', 'ホ': 'ポ',
+}
+
+// dashes are characters commonly typed instead of the prolonged sound mark in kana
+var dashes = map[rune]bool{
+	'-': true, '－': true, '‐': true, '―': true, '—': true, '─': true, '～': true, '〜': true,
+}
+
+// HalfwidthToFullwidthKatakana converts half-width katakana to full-width, combining
+// voiced sound marks with the preceding kana (ｶﾞ → ガ). Other characters are unchanged.
+func HalfwidthToFullwidthKatakana(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		full, ok := halfwidthKatakana[runes[i]]
+		if !ok {
+			b.WriteRune(runes[i])
+			continue
+		}
+
+		if i+1 < len(runes) {
+			switch runes[i+1] {
+			case halfwidthVoiced:
+				if combined, ok := voiced[full]; ok {
+					full = combined
+					i++
+				}
+			case halfwidthSemi:
+				if combined, ok := semiVoiced[full]; ok {
+					full = combined
+					i++
+				}
+			}
+		}
+		b.WriteRune(full)
+	}
+
+	return b.String()
+}
+
+// HiraganaToKatakana converts hiragana to katakana. Other characters are unchanged.
+func HiraganaToKatakana(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= hiraganaStart && r <= hiraganaEnd:
+			return r + hiraganaToKata
+		case r == 'ゝ':
+			return 'ヽ'
+		case r == 'ゞ':
+			return 'ヾ'
+		default:
+			return r
+		}
+	}, s)
+}
+
+// IsKatakana reports whether s is non-empty and contains only full-width katakana (ァ–ヶ)
+// and the prolonged sound mark, the characters accepted by the katakana validation rule
+func IsKatakana(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < katakanaStart || r > katakanaEnd) && r != prolongedMark {
+			return false
+		}
+	}
+	return true
+}
+
+// ToKatakana normalizes input for a katakana name field. Half-width katakana and hiragana
+// are converted, dashes after kana become the prolonged sound mark, spaces are removed,
+// and input written entirely in romaji is transliterated. The result is not guaranteed
+// to be valid katakana; check it with IsKatakana.
+func ToKatakana(s string) string {
+	s = strings.Join(strings.FieldsFunc(s, unicode.IsSpace), "")
+
+	if isRomaji(s) {
+		if kana, ok := RomajiToKatakana(s); ok {
+			return kana
+		}
+		return s
+	}
+
+	s = HiraganaToKatakana(HalfwidthToFullwidthKatakana(s))
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if dashes[r] && i > 0 && IsKatakana(string(runes[i-1])) {
+			runes[i] = prolongedMark
+		}
+	}
+	return string(runes)
+}
+
+// isRomaji reports whether s consists of ASCII letters, with optional hyphens and apostrophes
+func isRomaji(s string) bool {
+	hasLetter := false
+	for _, r := range s {
+		switch {
+		case r < unicode.MaxASCII && unicode.IsLetter(r):
+			hasLetter = true
+		case r == '-' || r == '\'':
+		default:
+			return false
+		}
+	}
+	return hasLetter
+}
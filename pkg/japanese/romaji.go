@@ -0,0 +1,106 @@
+// Package japanese provides romaji transliteration.
+package japanese
+
+import (
+	"strings"
+)
+
+// maxRomajiSyllable is the longest key in romajiSyllables
+const maxRomajiSyllable = 4
+
+// romajiSyllables maps romaji, in Hepburn and Kunrei spellings as typed with a Japanese IME,
+// to katakana
+var romajiSyllables = map[string]string{
+	"a": "ア", "i": "イ", "u": "ウ", "e": "エ", "o": "オ",
+	"ka": "カ", "ki": "キ", "ku": "ク", "ke": "ケ", "ko": "コ",
+	"kya": "キャ", "kyu": "キュ", "kyo": "キョ",
+	"ga": "ガ", "gi": "ギ", "gu": "グ", "ge": "ゲ", "go": "ゴ",
+	"gya": "ギャ", "gyu": "ギュ", "gyo": "ギョ",
+	"sa": "サ", "shi": "シ", "si": "シ", "su": "ス", "se": "セ", "so": "ソ",
+	"sha": "シャ", "shu": "シュ", "she": "シェ", "sho": "ショ",
+	"sya": "シャ", "syu": "シュ", "syo": "ショ",
+	"za": "ザ", "ji": "ジ", "zi": "ジ", "zu": "ズ", "ze": "ゼ", "zo": "ゾ",
+	"ja": "ジャ", "ju": "ジュ", "je": "ジェ", "jo": "ジョ",
+	"jya": "ジャ", "jyu": "ジュ", "jyo": "ジョ", "zya": "ジャ", "zyu": "ジュ", "zyo": "ジョ",
+	"ta": "タ", "chi": "チ", "ti": "チ", "tsu": "ツ", "tu": "ツ", "te": "テ", "to": "ト",
+	"cha": "チャ", "chu": "チュ", "che": "チェ", "cho": "チョ",
+	"tya": "チャ", "tyu": "チュ", "tyo": "チョ",
+	"da": "ダ", "di": "ヂ", "du": "ヅ", "de": "デ", "do": "ド",
+	"dya": "ヂャ", "dyu": "ヂュ", "dyo": "ヂョ",
+	"na": "ナ", "ni": "ニ", "nu": "ヌ", "ne": "ネ", "no": "ノ",
+	"nya": "ニャ", "nyu": "ニュ", "nyo": "ニョ",
+	"ha": "ハ", "hi": "ヒ", "fu": "フ", "hu": "フ", "he": "ヘ", "ho": "ホ",
+	"hya": "ヒャ", "hyu": "ヒュ", "hyo": "ヒョ",
+	"fa": "ファ", "fi": "フィ", "fe": "フェ", "fo": "フォ",
+	"ba": "バ", "bi": "ビ", "bu": "ブ", "be": "ベ", "bo": "ボ",
+	"bya": "ビャ", "byu": "ビュ", "byo": "ビョ",
+	"pa": "パ", "pi": "ピ", "pu": "プ", "pe": "ペ", "po": "ポ",
+	"pya": "ピャ", "pyu": "ピュ", "pyo": "ピョ",
+	"ma": "マ", "mi": "ミ", "mu": "ム", "me": "メ", "mo": "モ",
+	"mya": "ミャ", "myu": "ミュ", "myo": "ミョ",
+	"ya": "ヤ", "yu": "ユ", "yo": "ヨ",
+	"ra": "ラ", "ri": "リ", "ru": "ル", "re": "レ", "ro": "ロ",
+	"rya": "リャ", "ryu": "リュ", "ryo": "リョ",
+	"wa": "ワ", "wi": "ウィ", "we": "ウェ", "wo": "ヲ",
+	"va": "ヴァ", "vi": "ヴィ", "vu": "ヴ", "ve": "ヴェ", "vo": "ヴォ",
+	"thi": "ティ", "dhi": "ディ", "twu": "トゥ", "dwu": "ドゥ",
+	"xa": "ァ", "xi": "ィ", "xu": "ゥ", "xe": "ェ", "xo": "ォ",
+	"la": "ァ", "li": "ィ", "lu": "ゥ", "le": "ェ", "lo": "ォ",
+	"xya": "ャ", "xyu": "ュ", "xyo": "ョ", "lya": "ャ", "lyu": "ュ", "lyo": "ョ",
+	"xtu": "ッ", "ltu": "ッ", "xtsu": "ッ", "ltsu": "ッ",
+	"n'": "ン", "-": "ー",
+}
+
+// RomajiToKatakana transliterates romaji to katakana the way a Japanese IME does:
+// a doubled consonant becomes a small tsu (kk → ッk, tch → ッch) and a lone n before a
+// consonant or at the end becomes ン. It reports false if part of the input is not romaji.
+func RomajiToKatakana(s string) (string, bool) {
+	s = strings.ToLower(s)
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		c := s[i]
+
+		// Sokuon: a doubled consonant other than n, or t before ch
+		if i+1 < len(s) && isConsonant(c) && c != 'n' && (s[i+1] == c || (c == 't' && s[i+1] == 'c')) {
+			b.WriteString("ッ")
+			i++
+			continue
+		}
+
+		// Syllabic n: "nn" not followed by a vowel or y is a single ン ("honn"), otherwise
+		// n before a consonant or at the end is ン ("kanna" → カンナ, "konnichi" → コンニチ)
+		if c == 'n' {
+			if i+2 <= len(s) && s[i+1] == 'n' && (i+2 == len(s) || (isConsonant(s[i+2]) && s[i+2] != 'y')) {
+				b.WriteString("ン")
+				i += 2
+				continue
+			}
+			if i+1 == len(s) || (isConsonant(s[i+1]) && s[i+1] != 'y') {
+				b.WriteString("ン")
+				i++
+				continue
+			}
+		}
+
+		matched := false
+		for size := min(maxRomajiSyllable, len(s)-i); size > 0; size-- {
+			if kana, ok := romajiSyllables[s[i:i+size]]; ok {
+				b.WriteString(kana)
+				i += size
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return "", false
+		}
+	}
+
+	return b.String(), true
+}
+
+// isConsonant reports whether c is an ASCII consonant letter
+func isConsonant(c byte) bool {
+	return c >= 'a' && c <= 'z' && !strings.ContainsRune("aiueo", rune(c))
+}
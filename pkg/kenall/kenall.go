@@ -0,0 +1,166 @@
+// Package kenall parses the Japan Post postal code master file KEN_ALL.CSV.
+//
+// The file is published at https://www.post.japanpost.jp/zipcode/download.html in
+// Shift_JIS (KEN_ALL.CSV) and UTF-8 (utf_ken_all.csv). Kana columns are half-width and
+// long town names are split across consecutive rows; Reader joins those rows, widens
+// the kana and drops annotations that are not part of the address.
+package kenall
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/width"
+)
+
+// Supported file encodings
+const (
+	EncodingShiftJIS = "sjis"
+	EncodingUTF8     = "utf8"
+)
+
+// Column positions in KEN_ALL.CSV
+const (
+	colLocalGovernmentCode = 0
+	colPostalCode          = 2
+	colPrefectureKana      = 3
+	colCityKana            = 4
+	colTownKana            = 5
+	colPrefecture          = 6
+	colCity                = 7
+	colTown                = 8
+	columnCount            = 15
+)
+
+const postalCodeLength = 7
+
+// Town values that describe the whole municipality rather than a town
+const (
+	townNotListed        = "以下に掲載がない場合"
+	townNotListedKana    = "イカニケイサイガナイバアイ"
+	townFollowedBySuffix = "の次に番地がくる場合"
+	townWholeSuffix      = "一円"
+)
+
+// Record is a single postal code entry
+type Record struct {
+	LocalGovernmentCode string
+	PostalCode          string
+	PrefectureKana      string
+	CityKana            string
+	TownKana            string
+	Prefecture          string
+	City                string
+	Town                string
+}
+
+// Reader reads records from KEN_ALL.CSV
+type Reader struct {
+	csv     *csv.Reader
+	pending []string
+	line    int
+}
+
+// NewReader creates a reader for a file in the given encoding
+func NewReader(r io.Reader, encoding string) (*Reader, error) {
+	switch encoding {
+	case "", EncodingShiftJIS:
+		r = transform.NewReader(r, japanese.ShiftJIS.NewDecoder())
+	case EncodingUTF8:
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %s", encoding)
+	}
+
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = columnCount
+	reader.ReuseRecord = false
+
+	return &Reader{csv: reader}, nil
+}
+
+// Read returns the next record, or io.EOF when the file is exhausted
+func (r *Reader) Read() (*Record, error) {
+	fields, err := r.next()
+	if err != nil {
+		return nil, err
+	}
+
+	// A town with an unclosed parenthesis continues on the following rows
+	for strings.Count(fields[colTown], "（") > strings.Count(fields[colTown], "）") {
+		continuation, err := r.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if continuation[colPostalCode] != fields[colPostalCode] {
+			r.pending = continuation
+			break
+		}
+		fields[colTown] += continuation[colTown]
+		fields[colTownKana] += continuation[colTownKana]
+	}
+
+	if len(fields[colPostalCode]) != postalCodeLength {
+		return nil, fmt.Errorf("line %d: invalid postal code %q", r.line, fields[colPostalCode])
+	}
+
+	town, townKana := normalizeTown(fields[colTown], width.Widen.String(fields[colTownKana]))
+	return &Record{
+		LocalGovernmentCode: fields[colLocalGovernmentCode],
+		PostalCode:          fields[colPostalCode],
+		PrefectureKana:      width.Widen.String(fields[colPrefectureKana]),
+		CityKana:            width.Widen.String(fields[colCityKana]),
+		TownKana:            townKana,
+		Prefecture:          fields[colPrefecture],
+		City:                fields[colCity],
+		Town:                town,
+	}, nil
+}
+
+// next returns the next raw row, including one pushed back by Read
+func (r *Reader) next() ([]string, error) {
+	if r.pending != nil {
+		fields := r.pending
+		r.pending = nil
+		return fields, nil
+	}
+
+	fields, err := r.csv.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("line %d: %w", r.line+1, err)
+	}
+	r.line++
+	return fields, nil
+}
+
+// normalizeTown drops annotations such as "（次のビルを除く）" and clears towns that
+// stand for the whole municipality
+func normalizeTown(town, townKana string) (string, string) {
+	if town == townNotListed || strings.HasSuffix(town, townFollowedBySuffix) ||
+		(strings.HasSuffix(town, townWholeSuffix) && town != townWholeSuffix) {
+		return "", ""
+	}
+	if townKana == townNotListedKana {
+		townKana = ""
+	}
+
+	return stripAnnotation(town, "（"), stripAnnotation(townKana, "（")
+}
+
+// stripAnnotation removes everything from the first opening parenthesis
+func stripAnnotation(value, open string) string {
+	if i := strings.Index(value, open); i >= 0 {
+		return value[:i]
+	}
+	return value
+}
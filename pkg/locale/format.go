@@ -0,0 +1,227 @@
+// Package locale formats dates, numbers and contact details for Japanese confirmation
+// outputs such as the form summary and emails.
+//
+// Each output channel has its own conventions: screens show half-width digits and
+// Gregorian dates, while printed documents traditionally use full-width digits and the
+// Japanese era. A Formatter holds one set of choices so every value on a page agrees.
+package locale
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Channel is an output medium with its own formatting conventions
+type Channel string
+
+// Supported channels
+const (
+	// ChannelWeb is the confirmation screen of the form
+	ChannelWeb Channel = "web"
+	// ChannelEmail is plain-text email, where full-width digits break copy and paste into forms
+	ChannelEmail Channel = "email"
+	// ChannelPrint is a printed or PDF document in the traditional style
+	ChannelPrint Channel = "print"
+)
+
+// DateStyle selects how dates are written
+type DateStyle string
+
+// Supported date styles
+const (
+	// DateISO writes 2024-04-01
+	DateISO DateStyle = "iso"
+	// DateGregorian writes 2024年4月1日
+	DateGregorian DateStyle = "gregorian"
+	// DateEra writes 令和6年4月1日, with 元年 for the first year of an era
+	DateEra DateStyle = "era"
+)
+
+// Digits selects half-width (123) or full-width (１２３) digits
+type Digits string
+
+// Supported digit widths
+const (
+	DigitsHalfwidth Digits = "halfwidth"
+	DigitsFullwidth Digits = "fullwidth"
+)
+
+// jst is the time zone dates are shown in, whatever zone a time was stored in
+var jst = time.FixedZone("JST", 9*60*60)
+
+// era is a Japanese era, the day from which dates are written in it and the Gregorian year
+// of its first year
+type era struct {
+	name      string
+	start     time.Time
+	firstYear int
+}
+
+// eras lists the eras since the adoption of the Gregorian calendar on Meiji 6 (1873),
+// newest first
+var eras = []era{
+	{name: "令和", start: time.Date(2019, time.May, 1, 0, 0, 0, 0, jst), firstYear: 2019},
+	{name: "平成", start: time.Date(1989, time.January, 8, 0, 0, 0, 0, jst), firstYear: 1989},
+	{name: "昭和", start: time.Date(1926, time.December, 25, 0, 0, 0, 0, jst), firstYear: 1926},
+	{name: "大正", start: time.Date(1912, time.July, 30, 0, 0, 0, 0, jst), firstYear: 1912},
+	{name: "明治", start: time.Date(1873, time.January, 1, 0, 0, 0, 0, jst), firstYear: 1868},
+}
+
+// Formatter renders values for one channel
+type Formatter struct {
+	DateStyle DateStyle
+	Digits    Digits
+}
+
+// ParseChannel validates a channel name; empty selects the web channel
+func ParseChannel(s string) (Channel, error) {
+	switch Channel(s) {
+	case "":
+		return ChannelWeb, nil
+	case ChannelWeb, ChannelEmail, ChannelPrint:
+		return Channel(s), nil
+	default:
+		return "", fmt.Errorf("unsupported channel %q", s)
+	}
+}
+
+// ParseDateStyle validates a date style name
+func ParseDateStyle(s string) (DateStyle, error) {
+	switch DateStyle(s) {
+	case DateISO, DateGregorian, DateEra:
+		return DateStyle(s), nil
+	default:
+		return "", fmt.Errorf("unsupported date style %q", s)
+	}
+}
+
+// ForChannel returns the conventional formatter of a channel
+func ForChannel(channel Channel) Formatter {
+	switch channel {
+	case ChannelPrint:
+		return Formatter{DateStyle: DateEra, Digits: DigitsFullwidth}
+	default:
+		return Formatter{DateStyle: DateGregorian, Digits: DigitsHalfwidth}
+	}
+}
+
+// Date writes a calendar date in Japan time. Dates before the Meiji calendar reform are
+// written in the Gregorian style even when the era style is selected.
+func (f Formatter) Date(t time.Time) string {
+	t = t.In(jst)
+	switch f.DateStyle {
+	case DateISO:
+		return f.digits(t.Format("2006-01-02"))
+	case DateEra:
+		if name, year, ok := eraYear(t); ok {
+			yearText := "元"
+			if year > 1 {
+				yearText = strconv.Itoa(year)
+			}
+			return f.digits(fmt.Sprintf("%s%s年%d月%d日", name, yearText, t.Month(), t.Day()))
+		}
+	}
+	return f.digits(fmt.Sprintf("%d年%d月%d日", t.Year(), t.Month(), t.Day()))
+}
+
+// DateString formats a YYYY-MM-DD date; values that are not such a date are returned as they are
+func (f Formatter) DateString(s string) string {
+	t, err := time.ParseInLocation("2006-01-02", s, jst)
+	if err != nil {
+		return s
+	}
+	return f.Date(t)
+}
+
+// DateTime writes a date and the time of day in Japan time: 2024年4月1日 9:05
+func (f Formatter) DateTime(t time.Time) string {
+	t = t.In(jst)
+	return f.Date(t) + " " + f.digits(fmt.Sprintf("%d:%02d", t.Hour(), t.Minute()))
+}
+
+// Number writes an integer with thousands separators: 1,234,567
+func (f Formatter) Number(n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(d)
+	}
+	return f.digits(b.String())
+}
+
+// Yen writes an amount in yen: 1,100円
+func (f Formatter) Yen(amount int64) string {
+	return f.Number(amount) + "円"
+}
+
+// Phone joins the parts of a phone number with hyphens, skipping empty parts: 090-1234-5678
+func (f Formatter) Phone(parts ...string) string {
+	return f.digits(joinNonEmpty(parts, "-"))
+}
+
+// PostalCode writes a postal code as 123-4567 from its two parts or a single 7-digit string
+func (f Formatter) PostalCode(parts ...string) string {
+	joined := strings.Join(parts, "")
+	if len(parts) == 1 && len(joined) == 7 {
+		return f.digits(joined[:3] + "-" + joined[3:])
+	}
+	return f.digits(joinNonEmpty(parts, "-"))
+}
+
+// Text converts the digits and hyphens in free text such as an address to the formatter's width
+func (f Formatter) Text(s string) string {
+	return f.digits(s)
+}
+
+// digits converts ASCII digits, hyphens and separators to the formatter's width
+func (f Formatter) digits(s string) string {
+	if f.Digits != DigitsFullwidth {
+		return s
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= '0' && r <= '9':
+			return r - '0' + '０'
+		case r == '-':
+			return '－'
+		case r == ',':
+			return '，'
+		case r == ':':
+			return '：'
+		default:
+			return r
+		}
+	}, s)
+}
+
+// eraYear returns the era of t and the year within it
+func eraYear(t time.Time) (string, int, bool) {
+	for _, e := range eras {
+		if !t.Before(e.start) {
+			return e.name, t.Year() - e.firstYear + 1, true
+		}
+	}
+	return "", 0, false
+}
+
+// joinNonEmpty joins the parts that are not empty
+func joinNonEmpty(parts []string, sep string) string {
+	nonEmpty := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, sep)
+}
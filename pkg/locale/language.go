@@ -0,0 +1,99 @@
+// Package locale negotiates the language users are addressed in.
+package locale
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Supported languages, as the primary subtag of a BCP 47 language tag
+const (
+	Japanese = "ja"
+	English  = "en"
+
+	// Default is used when the client prefers no supported language
+	Default = Japanese
+)
+
+// Supported lists the languages the application can address users in
+var Supported = []string{Japanese, English}
+
+// IsSupported reports whether tag is a supported language
+func IsSupported(tag string) bool {
+	for _, supported := range Supported {
+		if tag == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// Negotiate selects the language of a client. An explicit choice of a supported language
+// wins; otherwise the supported language the Accept-Language header ranks highest is used,
+// and Default when it names none. Regional variants such as en-US match their language.
+func Negotiate(explicit, acceptLanguage string) string {
+	if tag := primarySubtag(explicit); IsSupported(tag) {
+		return tag
+	}
+
+	for _, tag := range parseAcceptLanguage(acceptLanguage) {
+		if IsSupported(tag) {
+			return tag
+		}
+	}
+	return Default
+}
+
+// parseAcceptLanguage returns the primary subtags of an Accept-Language header, most
+// preferred first. Ranges with q=0 are excluded; the wildcard is kept as "*".
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var ranges []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = primarySubtag(tag)
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || !strings.EqualFold(strings.TrimSpace(name), "q") {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				parsed = 0
+			}
+			q = parsed
+		}
+		if q <= 0 {
+			continue
+		}
+		ranges = append(ranges, weighted{tag: tag, q: q})
+	}
+
+	// Ranges of equal weight keep the order the client listed them in
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	tags := make([]string, 0, len(ranges))
+	for _, r := range ranges {
+		tags = append(tags, r.tag)
+	}
+	return tags
+}
+
+// primarySubtag returns the lowercased language of a tag such as en-US or ja_JP
+func primarySubtag(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	return tag
+}
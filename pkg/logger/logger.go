@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/octop162/normal-form-app-by-claude/pkg/correlation"
 	"github.com/sirupsen/logrus"
 )
 
@@ -42,6 +43,9 @@ func NewLogger(level string) *Logger {
 	// Set output
 	log.SetOutput(os.Stdout)
 
+	// Add request identifiers to entries created with WithContext
+	log.AddHook(&correlationHook{})
+
 	return &Logger{log}
 }
 
@@ -69,6 +73,28 @@ func (l *Logger) WithRequest(method, path, userAgent string) *logrus.Entry {
 	})
 }
 
+// correlationHook copies request and correlation IDs from the entry context into its fields
+type correlationHook struct{}
+
+// Levels returns the levels the hook applies to
+func (h *correlationHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire adds the identifiers to the entry
+func (h *correlationHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	if id := correlation.RequestID(entry.Context); id != "" {
+		entry.Data["request_id"] = id
+	}
+	if id := correlation.CorrelationID(entry.Context); id != "" {
+		entry.Data["correlation_id"] = id
+	}
+	return nil
+}
+
 // GetLevel returns the current log level
 func (l *Logger) GetLevel() logrus.Level {
 	return l.Logger.GetLevel()
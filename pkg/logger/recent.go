@@ -0,0 +1,81 @@
+// Package logger provides a buffer of the most recent error log entries.
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RecentEntry is an error logged by the application
+type RecentEntry struct {
+	Time      time.Time
+	Level     string
+	Message   string
+	Error     string
+	RequestID string
+}
+
+// RecentErrors keeps the last entries logged at error level or above, for operators without
+// a log search. It is a logrus hook; add it with AddHook after the correlation hook so entries
+// carry their request ID.
+type RecentErrors struct {
+	mu      sync.Mutex
+	entries []RecentEntry
+	next    int
+	full    bool
+}
+
+// NewRecentErrors creates a buffer holding up to size entries
+func NewRecentErrors(size int) *RecentErrors {
+	return &RecentErrors{entries: make([]RecentEntry, max(size, 1))}
+}
+
+// Levels returns the levels the hook applies to
+func (r *RecentErrors) Levels() []logrus.Level {
+	return []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel}
+}
+
+// Fire records the entry, replacing the oldest once the buffer is full
+func (r *RecentErrors) Fire(entry *logrus.Entry) error {
+	recent := RecentEntry{
+		Time:    entry.Time,
+		Level:   entry.Level.String(),
+		Message: entry.Message,
+	}
+	if err, ok := entry.Data[logrus.ErrorKey]; ok {
+		recent.Error = fmt.Sprint(err)
+	}
+	if id, ok := entry.Data["request_id"].(string); ok {
+		recent.RequestID = id
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.entries[r.next] = recent
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+	return nil
+}
+
+// Entries returns the recorded entries, newest first
+func (r *RecentErrors) Entries() []RecentEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := r.next
+	if r.full {
+		count = len(r.entries)
+	}
+
+	entries := make([]RecentEntry, 0, count)
+	for i := 1; i <= count; i++ {
+		entries = append(entries, r.entries[(r.next-i+len(r.entries))%len(r.entries)])
+	}
+	return entries
+}
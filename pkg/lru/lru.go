@@ -0,0 +1,120 @@
+// Package lru provides a size-bounded map that evicts the least recently used entry.
+//
+// Cache is not safe for concurrent use; the in-memory stores that embed it already
+// serialize access with their own mutex and need read-modify-write under that lock.
+package lru
+
+import "container/list"
+
+// Stats reports the size of a bounded store
+type Stats struct {
+	Entries    int   `json:"entries"`
+	MaxEntries int   `json:"max_entries"`
+	Evictions  int64 `json:"evictions"`
+}
+
+// StatsReporter is implemented by stores that report their size
+type StatsReporter interface {
+	Stats() Stats
+}
+
+// entry is a key and value held in the recency list
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// Cache is a map bounded to maxEntries entries
+type Cache[K comparable, V any] struct {
+	maxEntries int
+	order      *list.List // front is most recently used
+	items      map[K]*list.Element
+	evictions  int64
+}
+
+// New creates a cache holding at most maxEntries entries. A non-positive maxEntries
+// leaves the cache unbounded.
+func New[K comparable, V any](maxEntries int) *Cache[K, V] {
+	return &Cache[K, V]{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		items:      make(map[K]*list.Element),
+	}
+}
+
+// Get returns the value for key and marks it as most recently used
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	element, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*entry[K, V]).value, true
+}
+
+// Add stores value for key and marks it as most recently used. When the cache is full
+// the least recently used entry is evicted and returned.
+func (c *Cache[K, V]) Add(key K, value V) (evicted V, wasEvicted bool) {
+	if element, ok := c.items[key]; ok {
+		element.Value.(*entry[K, V]).value = value
+		c.order.MoveToFront(element)
+		return evicted, false
+	}
+
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value})
+	if c.maxEntries <= 0 || c.order.Len() <= c.maxEntries {
+		return evicted, false
+	}
+
+	oldest := c.order.Back()
+	c.removeElement(oldest)
+	c.evictions++
+	return oldest.Value.(*entry[K, V]).value, true
+}
+
+// Remove deletes key and returns its value
+func (c *Cache[K, V]) Remove(key K) (V, bool) {
+	element, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.removeElement(element)
+	return element.Value.(*entry[K, V]).value, true
+}
+
+// RemoveFunc deletes every entry for which remove returns true and reports how many were deleted
+func (c *Cache[K, V]) RemoveFunc(remove func(key K, value V) bool) int {
+	removed := 0
+	for element := c.order.Back(); element != nil; {
+		previous := element.Prev()
+		e := element.Value.(*entry[K, V])
+		if remove(e.key, e.value) {
+			c.removeElement(element)
+			removed++
+		}
+		element = previous
+	}
+	return removed
+}
+
+// Len returns the number of entries
+func (c *Cache[K, V]) Len() int {
+	return c.order.Len()
+}
+
+// Stats returns the current size and the number of entries evicted so far
+func (c *Cache[K, V]) Stats() Stats {
+	return Stats{
+		Entries:    c.order.Len(),
+		MaxEntries: c.maxEntries,
+		Evictions:  c.evictions,
+	}
+}
+
+// removeElement unlinks an element from the list and the index
+func (c *Cache[K, V]) removeElement(element *list.Element) {
+	c.order.Remove(element)
+	delete(c.items, element.Value.(*entry[K, V]).key)
+}
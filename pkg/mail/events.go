@@ -0,0 +1,75 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// Delivery event kinds that mark an address as bad
+const (
+	// EventBounce is a permanent delivery failure
+	EventBounce = "bounce"
+	// EventComplaint is a recipient marking a message as spam
+	EventComplaint = "complaint"
+)
+
+var (
+	// ErrInvalidSignature is returned for webhook requests whose signature does not verify
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrInvalidEvent is returned for webhook requests that cannot be parsed
+	ErrInvalidEvent = errors.New("invalid webhook event")
+)
+
+// Event is a bounce or complaint reported by a mail provider
+type Event struct {
+	Kind       string
+	Email      string
+	Reason     string
+	OccurredAt time.Time
+}
+
+// EventSource verifies and parses the bounce and complaint webhook requests of a provider.
+// Requests carrying nothing to act on, such as deliveries or soft bounces, yield no events.
+type EventSource interface {
+	Events(ctx context.Context, header http.Header, body []byte) ([]Event, error)
+}
+
+// DetectEventProvider returns the provider that sent a webhook request, judged by the
+// headers each provider always sets, or "" when none match
+func DetectEventProvider(header http.Header) string {
+	switch {
+	case header.Get(headerSNSMessageType) != "":
+		return DriverSES
+	case header.Get(headerSendGridSignature) != "":
+		return DriverSendGrid
+	default:
+		return ""
+	}
+}
+
+// NewEventSources creates the event sources of the providers whose webhooks are configured,
+// keyed by driver name. Webhooks are independent of config.Driver so events of a previous
+// provider are still received while switching.
+func NewEventSources(config *Config, log *logger.Logger) (map[string]EventSource, error) {
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	sources := make(map[string]EventSource)
+	if len(config.SES.WebhookTopicARNs) > 0 {
+		sources[DriverSES] = NewSESEventSource(config.SES.WebhookTopicARNs, config.Timeout, log)
+	}
+	if config.SendGrid.WebhookPublicKey != "" {
+		source, err := NewSendGridEventSource(config.SendGrid.WebhookPublicKey)
+		if err != nil {
+			return nil, err
+		}
+		sources[DriverSendGrid] = source
+	}
+
+	return sources, nil
+}
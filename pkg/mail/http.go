@@ -0,0 +1,30 @@
+package mail
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	contentTypeJSON   = "application/json"
+	maxErrorBodyBytes = 512
+)
+
+// doRequest sends an API request to a mail provider. Any non-2xx response is treated as a
+// failure and reported with the start of its body, which carries the provider's reason.
+func doRequest(client *http.Client, req *http.Request, provider string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		return fmt.Errorf("%s returned status %d: %s", provider, resp.StatusCode, string(snippet))
+	}
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBodyBytes))
+	return nil
+}
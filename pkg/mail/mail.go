@@ -0,0 +1,122 @@
+// Package mail provides delivery of transactional email.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// Supported sender drivers
+const (
+	DriverLog      = "log"
+	DriverSMTP     = "smtp"
+	DriverSES      = "ses"
+	DriverSendGrid = "sendgrid"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+)
+
+// defaultRateLimits caps the messages per second each driver sends when Config.RateLimit
+// is 0: a conservative rate for relays, the default SES sending quota and a rate SendGrid
+// accepts on every plan
+var defaultRateLimits = map[string]float64{
+	DriverSMTP:     5,
+	DriverSES:      14,
+	DriverSendGrid: 50,
+}
+
+// Message is an email with a plain-text body and an optional HTML alternative
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+	// HTMLBody is sent alongside Body when set; clients that cannot show HTML use Body
+	HTMLBody string
+}
+
+// Sender delivers email messages
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Config holds mail sender configuration
+type Config struct {
+	Driver  string        `json:"driver"`
+	From    string        `json:"from"`
+	Timeout time.Duration `json:"timeout"`
+	// RateLimit caps messages sent per second; 0 uses the driver default and a negative
+	// value sends without a limit
+	RateLimit float64        `json:"rate_limit"`
+	SMTP      SMTPConfig     `json:"smtp"`
+	SES       SESConfig      `json:"ses"`
+	SendGrid  SendGridConfig `json:"sendgrid"`
+}
+
+// SMTPConfig holds SMTP server configuration
+type SMTPConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Username string `json:"username"`
+	Password string `json:"-"`
+}
+
+// SESConfig holds Amazon SES configuration. Webhook fields are used by NewEventSources.
+type SESConfig struct {
+	Region           string `json:"region"`
+	AccessKeyID      string `json:"access_key_id"`
+	SecretAccessKey  string `json:"-"`
+	SessionToken     string `json:"-"`
+	ConfigurationSet string `json:"configuration_set"`
+	// WebhookTopicARNs are the SNS topics whose bounce and complaint notifications are accepted
+	WebhookTopicARNs []string `json:"webhook_topic_arns"`
+}
+
+// SendGridConfig holds SendGrid configuration. Webhook fields are used by NewEventSources.
+type SendGridConfig struct {
+	APIKey string `json:"-"`
+	// WebhookPublicKey is the base64 verification key of the signed event webhook
+	WebhookPublicKey string `json:"-"`
+}
+
+// NewSender creates the sender selected by config.Driver, limited to the driver's rate
+func NewSender(config *Config, log *logger.Logger) (Sender, error) {
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	sender, err := newDriverSender(config, log)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := config.RateLimit
+	if limit == 0 {
+		limit = defaultRateLimits[config.Driver]
+	}
+	if limit > 0 {
+		sender = NewRateLimitedSender(sender, limit)
+	}
+
+	return sender, nil
+}
+
+// newDriverSender creates the sender of config.Driver
+func newDriverSender(config *Config, log *logger.Logger) (Sender, error) {
+	switch config.Driver {
+	case "", DriverLog:
+		return NewLogSender(log), nil
+	case DriverSMTP:
+		return NewSMTPSender(config, log)
+	case DriverSES:
+		return NewSESSender(config, log)
+	case DriverSendGrid:
+		return NewSendGridSender(config, log)
+	default:
+		return nil, fmt.Errorf("unsupported mail driver: %s", config.Driver)
+	}
+}
@@ -0,0 +1,70 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// rateLimitedSender delays messages so the wrapped sender stays within a rate. It is a
+// token bucket holding up to one second of messages, so short bursts go out at once.
+type rateLimitedSender struct {
+	next  Sender
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimitedSender wraps next so that it sends at most perSecond messages per second
+func NewRateLimitedSender(next Sender, perSecond float64) Sender {
+	burst := math.Max(1, math.Floor(perSecond))
+	return &rateLimitedSender{
+		next:   next,
+		rate:   perSecond,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// Send waits for the rate limit and delivers the message
+func (s *rateLimitedSender) Send(ctx context.Context, msg *Message) error {
+	if err := s.wait(ctx); err != nil {
+		return fmt.Errorf("mail rate limit: %w", err)
+	}
+	return s.next.Send(ctx, msg)
+}
+
+// wait takes a token, sleeping until the bucket has refilled enough to cover it. A wait
+// cut short by ctx gives the token back.
+func (s *rateLimitedSender) wait(ctx context.Context) error {
+	s.mu.Lock()
+	now := time.Now()
+	s.tokens = math.Min(s.burst, s.tokens+now.Sub(s.last).Seconds()*s.rate)
+	s.last = now
+	s.tokens--
+	delay := time.Duration(-s.tokens / s.rate * float64(time.Second))
+	s.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		s.tokens++
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,109 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// sendGridEndpoint is the SendGrid v3 Mail Send API
+const sendGridEndpoint = "https://api.sendgrid.com/v3/mail/send"
+
+// sendGridSender sends messages with the SendGrid Mail Send API
+type sendGridSender struct {
+	httpClient *http.Client
+	endpoint   string
+	apiKey     string
+	from       sendGridAddress
+	log        *logger.Logger
+}
+
+// sendGridEmail is the Mail Send request body
+type sendGridEmail struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+}
+
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// NewSendGridSender creates a sender for SendGrid
+func NewSendGridSender(config *Config, log *logger.Logger) (Sender, error) {
+	if config.SendGrid.APIKey == "" {
+		return nil, fmt.Errorf("sendgrid mail driver requires an API key")
+	}
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mail from address %q: %w", config.From, err)
+	}
+
+	return &sendGridSender{
+		httpClient: &http.Client{Timeout: config.Timeout},
+		endpoint:   sendGridEndpoint,
+		apiKey:     config.SendGrid.APIKey,
+		from:       sendGridAddress{Email: from.Address, Name: from.Name},
+		log:        log,
+	}, nil
+}
+
+// Send delivers the message
+func (s *sendGridSender) Send(ctx context.Context, msg *Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	body, err := json.Marshal(sendGridEmail{
+		Personalizations: []sendGridPersonalization{
+			{To: []sendGridAddress{{Email: to.Address, Name: to.Name}}},
+		},
+		From:    s.from,
+		Subject: msg.Subject,
+		Content: sendGridMessageContent(msg),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal sendgrid message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create sendgrid request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentTypeJSON)
+	req.Header.Set("Authorization", "Bearer "+s.apiKey)
+
+	if err := doRequest(s.httpClient, req, "sendgrid"); err != nil {
+		return err
+	}
+
+	s.log.WithContext(ctx).WithField("subject", msg.Subject).Info("Email sent")
+	return nil
+}
+
+// sendGridMessageContent returns the text part followed by the HTML part, the order
+// SendGrid requires
+func sendGridMessageContent(msg *Message) []sendGridContent {
+	content := []sendGridContent{{Type: "text/plain", Value: msg.Body}}
+	if msg.HTMLBody != "" {
+		content = append(content, sendGridContent{Type: "text/html", Value: msg.HTMLBody})
+	}
+	return content
+}
@@ -0,0 +1,114 @@
+package mail
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Headers of the SendGrid signed event webhook
+const (
+	headerSendGridSignature = "X-Twilio-Email-Event-Webhook-Signature"
+	headerSendGridTimestamp = "X-Twilio-Email-Event-Webhook-Timestamp"
+)
+
+// sendGridEvent is one entry of an event webhook delivery
+type sendGridEvent struct {
+	Email     string `json:"email"`
+	Event     string `json:"event"`
+	Type      string `json:"type"`
+	Reason    string `json:"reason"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// sendGridEventSource receives the SendGrid signed event webhook
+type sendGridEventSource struct {
+	publicKey *ecdsa.PublicKey
+}
+
+// NewSendGridEventSource creates an event source verifying requests with the base64 encoded
+// ECDSA verification key shown in the SendGrid mail settings
+func NewSendGridEventSource(publicKey string) (EventSource, error) {
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil {
+		return nil, fmt.Errorf("sendgrid webhook public key is not base64: %w", err)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sendgrid webhook public key: %w", err)
+	}
+	key, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("sendgrid webhook public key is not an ECDSA key")
+	}
+
+	return &sendGridEventSource{publicKey: key}, nil
+}
+
+// Events verifies a delivery and returns its hard bounces and spam reports. Dropped messages
+// count when SendGrid dropped them for an address that bounced or reported spam before;
+// blocks are temporary and ignored.
+func (s *sendGridEventSource) Events(_ context.Context, header http.Header, body []byte) ([]Event, error) {
+	signature, err := base64.StdEncoding.DecodeString(header.Get(headerSendGridSignature))
+	if err != nil || len(signature) == 0 {
+		return nil, fmt.Errorf("%w: missing or malformed signature", ErrInvalidSignature)
+	}
+	timestamp := header.Get(headerSendGridTimestamp)
+	if timestamp == "" {
+		return nil, fmt.Errorf("%w: missing timestamp", ErrInvalidSignature)
+	}
+
+	digest := sha256.Sum256(append([]byte(timestamp), body...))
+	if !ecdsa.VerifyASN1(s.publicKey, digest[:], signature) {
+		return nil, ErrInvalidSignature
+	}
+
+	var entries []sendGridEvent
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+
+	var events []Event
+	for _, entry := range entries {
+		kind := sendGridEventKind(&entry)
+		if kind == "" {
+			continue
+		}
+		events = append(events, Event{
+			Kind:       kind,
+			Email:      entry.Email,
+			Reason:     entry.Reason,
+			OccurredAt: time.Unix(entry.Timestamp, 0),
+		})
+	}
+
+	return events, nil
+}
+
+// sendGridEventKind maps a SendGrid event to an event kind, or "" when it is not one
+func sendGridEventKind(entry *sendGridEvent) string {
+	switch entry.Event {
+	case "bounce":
+		if entry.Type == "blocked" {
+			return ""
+		}
+		return EventBounce
+	case "spamreport":
+		return EventComplaint
+	case "dropped":
+		switch entry.Reason {
+		case "Bounced Address":
+			return EventBounce
+		case "Spam Reporting Address":
+			return EventComplaint
+		}
+	}
+	return ""
+}
@@ -0,0 +1,131 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// sesService is the SigV4 signing name of the SES API
+const sesService = "ses"
+
+// sesSender sends messages with the SES v2 SendEmail API, signing requests with static
+// credentials
+type sesSender struct {
+	httpClient       *http.Client
+	endpoint         string
+	region           string
+	credentials      awsCredentials
+	from             string
+	configurationSet string
+	log              *logger.Logger
+}
+
+// sesEmail is the SendEmail request body
+type sesEmail struct {
+	FromEmailAddress     string         `json:"FromEmailAddress"`
+	Destination          sesDestination `json:"Destination"`
+	Content              sesContent     `json:"Content"`
+	ConfigurationSetName string         `json:"ConfigurationSetName,omitempty"`
+}
+
+type sesDestination struct {
+	ToAddresses []string `json:"ToAddresses"`
+}
+
+type sesContent struct {
+	Simple sesSimpleContent `json:"Simple"`
+}
+
+type sesSimpleContent struct {
+	Subject sesText `json:"Subject"`
+	Body    sesBody `json:"Body"`
+}
+
+type sesBody struct {
+	Text sesText  `json:"Text"`
+	HTML *sesText `json:"Html,omitempty"`
+}
+
+type sesText struct {
+	Data    string `json:"Data"`
+	Charset string `json:"Charset"`
+}
+
+// NewSESSender creates a sender for Amazon SES in the configured region
+func NewSESSender(config *Config, log *logger.Logger) (Sender, error) {
+	if config.SES.Region == "" {
+		return nil, fmt.Errorf("ses mail driver requires a region")
+	}
+	if config.SES.AccessKeyID == "" || config.SES.SecretAccessKey == "" {
+		return nil, fmt.Errorf("ses mail driver requires an access key ID and secret access key")
+	}
+	from, err := mail.ParseAddress(config.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mail from address %q: %w", config.From, err)
+	}
+
+	return &sesSender{
+		httpClient: &http.Client{Timeout: config.Timeout},
+		endpoint:   "https://email." + config.SES.Region + ".amazonaws.com/v2/email/outbound-emails",
+		region:     config.SES.Region,
+		credentials: awsCredentials{
+			AccessKeyID:     config.SES.AccessKeyID,
+			SecretAccessKey: config.SES.SecretAccessKey,
+			SessionToken:    config.SES.SessionToken,
+		},
+		from:             from.String(),
+		configurationSet: config.SES.ConfigurationSet,
+		log:              log,
+	}, nil
+}
+
+// Send delivers the message
+func (s *sesSender) Send(ctx context.Context, msg *Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	body, err := json.Marshal(sesEmail{
+		FromEmailAddress: s.from,
+		Destination:      sesDestination{ToAddresses: []string{to.String()}},
+		Content: sesContent{Simple: sesSimpleContent{
+			Subject: sesText{Data: msg.Subject, Charset: "UTF-8"},
+			Body:    sesMessageBody(msg),
+		}},
+		ConfigurationSetName: s.configurationSet,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal ses message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create ses request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentTypeJSON)
+	signV4(req, body, s.credentials, s.region, sesService, time.Now())
+
+	if err := doRequest(s.httpClient, req, "ses"); err != nil {
+		return err
+	}
+
+	s.log.WithContext(ctx).WithField("subject", msg.Subject).Info("Email sent")
+	return nil
+}
+
+// sesMessageBody returns the text part and, when the message has one, the HTML part
+func sesMessageBody(msg *Message) sesBody {
+	body := sesBody{Text: sesText{Data: msg.Body, Charset: "UTF-8"}}
+	if msg.HTMLBody != "" {
+		body.HTML = &sesText{Data: msg.HTMLBody, Charset: "UTF-8"}
+	}
+	return body
+}
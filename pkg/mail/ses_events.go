@@ -0,0 +1,321 @@
+package mail
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1" //nolint:gosec // SNS signature version 1 is SHA1withRSA
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// SNS message types
+const (
+	snsTypeNotification             = "Notification"
+	snsTypeSubscriptionConfirmation = "SubscriptionConfirmation"
+	snsTypeUnsubscribeConfirmation  = "UnsubscribeConfirmation"
+)
+
+// headerSNSMessageType is set by SNS on every HTTP(S) delivery
+const headerSNSMessageType = "X-Amz-Sns-Message-Type"
+
+// maxCertificateBytes bounds the SNS signing certificate download
+const maxCertificateBytes = 64 << 10
+
+// snsHostPattern matches the SNS endpoints that serve signing certificates and
+// subscription confirmations
+var snsHostPattern = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// snsMessage is an SNS HTTP(S) delivery
+type snsMessage struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	Token            string `json:"Token"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject"`
+	Message          string `json:"Message"`
+	Timestamp        string `json:"Timestamp"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+	SubscribeURL     string `json:"SubscribeURL"`
+}
+
+// sesNotification is the SES bounce or complaint notification carried in an SNS message.
+// Identity notifications set notificationType and configuration set event publishing sets
+// eventType.
+type sesNotification struct {
+	NotificationType string `json:"notificationType"`
+	EventType        string `json:"eventType"`
+	Bounce           *struct {
+		BounceType        string         `json:"bounceType"`
+		BounceSubType     string         `json:"bounceSubType"`
+		BouncedRecipients []sesRecipient `json:"bouncedRecipients"`
+		Timestamp         time.Time      `json:"timestamp"`
+	} `json:"bounce"`
+	Complaint *struct {
+		ComplainedRecipients  []sesRecipient `json:"complainedRecipients"`
+		ComplaintFeedbackType string         `json:"complaintFeedbackType"`
+		Timestamp             time.Time      `json:"timestamp"`
+	} `json:"complaint"`
+}
+
+type sesRecipient struct {
+	EmailAddress   string `json:"emailAddress"`
+	DiagnosticCode string `json:"diagnosticCode"`
+}
+
+// sesEventSource receives SES notifications published to SNS topics. Every message is
+// verified against the SNS signing certificate and must come from an allowed topic;
+// subscription confirmations are confirmed automatically.
+type sesEventSource struct {
+	httpClient *http.Client
+	topics     map[string]struct{}
+	log        *logger.Logger
+
+	mu    sync.Mutex
+	certs map[string]*rsa.PublicKey
+}
+
+// NewSESEventSource creates an event source accepting the SNS topics topicARNs
+func NewSESEventSource(topicARNs []string, timeout time.Duration, log *logger.Logger) EventSource {
+	topics := make(map[string]struct{}, len(topicARNs))
+	for _, arn := range topicARNs {
+		topics[arn] = struct{}{}
+	}
+
+	return &sesEventSource{
+		httpClient: &http.Client{Timeout: timeout},
+		topics:     topics,
+		log:        log,
+		certs:      make(map[string]*rsa.PublicKey),
+	}
+}
+
+// Events verifies an SNS delivery and returns the permanent bounces and complaints it reports
+func (s *sesEventSource) Events(ctx context.Context, _ http.Header, body []byte) ([]Event, error) {
+	var msg snsMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEvent, err)
+	}
+	if _, ok := s.topics[msg.TopicArn]; !ok {
+		return nil, fmt.Errorf("%w: topic %s is not allowed", ErrInvalidSignature, msg.TopicArn)
+	}
+	if err := s.verify(ctx, &msg); err != nil {
+		return nil, err
+	}
+
+	switch msg.Type {
+	case snsTypeSubscriptionConfirmation:
+		return nil, s.confirmSubscription(ctx, &msg)
+	case snsTypeNotification:
+		return parseSESNotification(msg.Message)
+	default:
+		return nil, nil
+	}
+}
+
+// verify checks the signature of an SNS message against its signing certificate
+func (s *sesEventSource) verify(ctx context.Context, msg *snsMessage) error {
+	signature, err := base64.StdEncoding.DecodeString(msg.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: signature is not base64", ErrInvalidSignature)
+	}
+
+	key, err := s.certificate(ctx, msg.SigningCertURL)
+	if err != nil {
+		return err
+	}
+
+	canonical := snsCanonicalString(msg)
+	switch msg.SignatureVersion {
+	case "1":
+		digest := sha1.Sum([]byte(canonical)) //nolint:gosec // mandated by SNS signature version 1
+		err = rsa.VerifyPKCS1v15(key, crypto.SHA1, digest[:], signature)
+	case "2":
+		digest := sha256.Sum256([]byte(canonical))
+		err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature)
+	default:
+		return fmt.Errorf("%w: unsupported signature version %q", ErrInvalidSignature, msg.SignatureVersion)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+
+	return nil
+}
+
+// certificate returns the public key of an SNS signing certificate, downloading it once.
+// Only certificates served by SNS over HTTPS are trusted.
+func (s *sesEventSource) certificate(ctx context.Context, certURL string) (*rsa.PublicKey, error) {
+	if err := checkSNSURL(certURL); err != nil {
+		return nil, fmt.Errorf("%w: signing certificate %v", ErrInvalidSignature, err)
+	}
+	if !strings.HasSuffix(certURL, ".pem") {
+		return nil, fmt.Errorf("%w: signing certificate is not a .pem file", ErrInvalidSignature)
+	}
+
+	s.mu.Lock()
+	key, ok := s.certs[certURL]
+	s.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, certURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate request: %w", err)
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download sns signing certificate: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sns signing certificate returned status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCertificateBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sns signing certificate: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("sns signing certificate is not PEM encoded")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sns signing certificate: %w", err)
+	}
+	key, ok = cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("sns signing certificate does not hold an RSA key")
+	}
+
+	s.mu.Lock()
+	s.certs[certURL] = key
+	s.mu.Unlock()
+	return key, nil
+}
+
+// confirmSubscription visits the subscribe URL so SNS starts delivering to the endpoint
+func (s *sesEventSource) confirmSubscription(ctx context.Context, msg *snsMessage) error {
+	if err := checkSNSURL(msg.SubscribeURL); err != nil {
+		return fmt.Errorf("%w: subscribe URL %v", ErrInvalidEvent, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, msg.SubscribeURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create subscription confirmation request: %w", err)
+	}
+	if err := doRequest(s.httpClient, req, "sns"); err != nil {
+		return fmt.Errorf("failed to confirm sns subscription: %w", err)
+	}
+
+	s.log.WithContext(ctx).WithField("topic_arn", msg.TopicArn).Info("Confirmed SNS subscription for email events")
+	return nil
+}
+
+// checkSNSURL checks that rawURL is an HTTPS URL of an SNS endpoint
+func checkSNSURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("is not a valid URL")
+	}
+	if parsed.Scheme != "https" || !snsHostPattern.MatchString(parsed.Host) {
+		return fmt.Errorf("is not served by SNS: %s", parsed.Host)
+	}
+	return nil
+}
+
+// snsCanonicalString builds the string SNS signs: the name and value of the signed fields
+// of the message type, each followed by a newline, with an absent Subject left out
+func snsCanonicalString(msg *snsMessage) string {
+	var fields [][2]string
+	switch msg.Type {
+	case snsTypeSubscriptionConfirmation, snsTypeUnsubscribeConfirmation:
+		fields = [][2]string{
+			{"Message", msg.Message},
+			{"MessageId", msg.MessageID},
+			{"SubscribeURL", msg.SubscribeURL},
+			{"Timestamp", msg.Timestamp},
+			{"Token", msg.Token},
+			{"TopicArn", msg.TopicArn},
+			{"Type", msg.Type},
+		}
+	default:
+		fields = [][2]string{{"Message", msg.Message}, {"MessageId", msg.MessageID}}
+		if msg.Subject != "" {
+			fields = append(fields, [2]string{"Subject", msg.Subject})
+		}
+		fields = append(fields,
+			[2]string{"Timestamp", msg.Timestamp},
+			[2]string{"TopicArn", msg.TopicArn},
+			[2]string{"Type", msg.Type},
+		)
+	}
+
+	var b strings.Builder
+	for _, field := range fields {
+		b.WriteString(field[0] + "\n" + field[1] + "\n")
+	}
+	return b.String()
+}
+
+// parseSESNotification returns the addresses of a permanent bounce or a complaint. Transient
+// bounces such as a full mailbox are left for SES to retry.
+func parseSESNotification(message string) ([]Event, error) {
+	var notification sesNotification
+	if err := json.Unmarshal([]byte(message), &notification); err != nil {
+		return nil, fmt.Errorf("%w: ses notification: %v", ErrInvalidEvent, err)
+	}
+
+	kind := notification.NotificationType
+	if kind == "" {
+		kind = notification.EventType
+	}
+
+	var events []Event
+	switch {
+	case kind == "Bounce" && notification.Bounce != nil:
+		if notification.Bounce.BounceType != "Permanent" {
+			return nil, nil
+		}
+		for _, recipient := range notification.Bounce.BouncedRecipients {
+			reason := notification.Bounce.BounceSubType
+			if recipient.DiagnosticCode != "" {
+				reason = recipient.DiagnosticCode
+			}
+			events = append(events, Event{
+				Kind:       EventBounce,
+				Email:      recipient.EmailAddress,
+				Reason:     reason,
+				OccurredAt: notification.Bounce.Timestamp,
+			})
+		}
+	case kind == "Complaint" && notification.Complaint != nil:
+		for _, recipient := range notification.Complaint.ComplainedRecipients {
+			events = append(events, Event{
+				Kind:       EventComplaint,
+				Email:      recipient.EmailAddress,
+				Reason:     notification.Complaint.ComplaintFeedbackType,
+				OccurredAt: notification.Complaint.Timestamp,
+			})
+		}
+	}
+
+	return events, nil
+}
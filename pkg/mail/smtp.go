@@ -0,0 +1,165 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// smtpSender sends messages through an SMTP server using STARTTLS when available
+type smtpSender struct {
+	addr    string
+	host    string
+	from    string
+	auth    smtp.Auth
+	timeout time.Duration
+	log     *logger.Logger
+}
+
+// NewSMTPSender creates a sender for the configured SMTP server
+func NewSMTPSender(config *Config, log *logger.Logger) (Sender, error) {
+	if config.SMTP.Host == "" {
+		return nil, fmt.Errorf("smtp mail driver requires a host")
+	}
+	if _, err := mail.ParseAddress(config.From); err != nil {
+		return nil, fmt.Errorf("invalid mail from address %q: %w", config.From, err)
+	}
+
+	port := config.SMTP.Port
+	if port == 0 {
+		port = 587
+	}
+
+	sender := &smtpSender{
+		addr:    net.JoinHostPort(config.SMTP.Host, strconv.Itoa(port)),
+		host:    config.SMTP.Host,
+		from:    config.From,
+		timeout: config.Timeout,
+		log:     log,
+	}
+	if config.SMTP.Username != "" {
+		sender.auth = smtp.PlainAuth("", config.SMTP.Username, config.SMTP.Password, config.SMTP.Host)
+	}
+
+	return sender, nil
+}
+
+// Send delivers the message
+func (s *smtpSender) Send(ctx context.Context, msg *Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	dialer := &net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to smtp server: %w", err)
+	}
+	deadline := time.Now().Add(s.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	_ = conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start smtp session: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("failed to start tls: %w", err)
+		}
+	}
+	if s.auth != nil {
+		if err := client.Auth(s.auth); err != nil {
+			return fmt.Errorf("smtp authentication failed: %w", err)
+		}
+	}
+
+	from, _ := mail.ParseAddress(s.from)
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("smtp MAIL FROM failed: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("smtp RCPT TO failed: %w", err)
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+	if _, err := writer.Write(buildMessage(s.from, to.String(), msg)); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	if err := client.Quit(); err != nil {
+		s.log.WithError(err).Debug("SMTP QUIT failed after successful delivery")
+	}
+
+	s.log.WithContext(ctx).WithField("subject", msg.Subject).Info("Email sent")
+	return nil
+}
+
+// buildMessage renders the RFC 5322 message with a UTF-8 plain text body, or a
+// multipart/alternative body when the message has an HTML part
+func buildMessage(from, to string, msg *Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	if msg.HTMLBody == "" {
+		b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+		b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+		b.WriteString("\r\n")
+		b.WriteString(crlf(msg.Body))
+		return []byte(b.String())
+	}
+
+	var parts bytes.Buffer
+	writer := multipart.NewWriter(&parts)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", msg.Body},
+		{"text/html; charset=UTF-8", msg.HTMLBody},
+	} {
+		// Writes to a bytes.Buffer do not fail
+		w, _ := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		w.Write([]byte(crlf(part.body)))
+	}
+	writer.Close()
+
+	b.WriteString("Content-Type: multipart/alternative; boundary=" + writer.Boundary() + "\r\n")
+	b.WriteString("\r\n")
+	b.Write(parts.Bytes())
+	return []byte(b.String())
+}
+
+// crlf converts line endings to the CRLF that SMTP requires
+func crlf(body string) string {
+	return strings.ReplaceAll(body, "\n", "\r\n")
+}
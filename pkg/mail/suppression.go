@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// ErrSuppressed is returned for messages to an address that must not receive email
+var ErrSuppressed = errors.New("recipient is suppressed")
+
+// SuppressionList tells which addresses must not receive email, such as addresses that
+// bounced or complained
+type SuppressionList interface {
+	IsEmailSuppressed(ctx context.Context, email string) (bool, error)
+}
+
+// suppressingSender refuses messages to suppressed addresses
+type suppressingSender struct {
+	next Sender
+	list SuppressionList
+	log  *logger.Logger
+}
+
+// NewSuppressingSender wraps next so that messages to addresses on list are not sent.
+// Sending to a suppressed address returns ErrSuppressed.
+func NewSuppressingSender(next Sender, list SuppressionList, log *logger.Logger) Sender {
+	return &suppressingSender{next: next, list: list, log: log}
+}
+
+// Send delivers the message unless its recipient is suppressed
+func (s *suppressingSender) Send(ctx context.Context, msg *Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	suppressed, err := s.list.IsEmailSuppressed(ctx, to.Address)
+	if err != nil {
+		return fmt.Errorf("failed to check suppression list: %w", err)
+	}
+	if suppressed {
+		s.log.WithContext(ctx).WithField("subject", msg.Subject).Info("Email not sent to suppressed address")
+		return ErrSuppressed
+	}
+
+	return s.next.Send(ctx, msg)
+}
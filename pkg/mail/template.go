@@ -0,0 +1,243 @@
+// Package mail provides rendering of email templates.
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/locale"
+)
+
+// subjectHeader starts the first line of a built-in template file
+const subjectHeader = "Subject: "
+
+// htmlSuffix names the optional HTML part of a built-in template: <name>.html.tmpl
+const htmlSuffix = ".html"
+
+// defaultTemplates holds the built-in templates, one <name>.tmpl file each: a subject line,
+// a blank line and the body. A template may add an HTML part in <name>.html.tmpl, and
+// is translated in <name>.<language>.tmpl, which is a template of its own.
+//
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// Template is the subject and body of an email written as Go text templates. Version 0 is
+// the built-in template.
+type Template struct {
+	Name    string
+	Version int
+	Subject string
+	Body    string
+	// HTMLBody is an optional HTML part written as a Go html template, so values are
+	// escaped for the context they appear in
+	HTMLBody string
+}
+
+// languageSeparator joins a template name and a language in the name of a localized
+// template: registration_confirmation.en
+const languageSeparator = "."
+
+// emailFormats format dates and numbers the way plain-text email in a language shows them.
+// Japanese email writes 2024年4月1日; other languages use ISO dates.
+var emailFormats = map[string]locale.Formatter{
+	locale.Japanese: locale.ForChannel(locale.ChannelEmail),
+	locale.English:  {DateStyle: locale.DateISO, Digits: locale.DigitsHalfwidth},
+}
+
+// languageFuncs are the formatting functions available to the templates of each language
+var languageFuncs = func() map[string]template.FuncMap {
+	funcs := make(map[string]template.FuncMap, len(emailFormats))
+	for language, format := range emailFormats {
+		funcs[language] = templateFuncs(format)
+	}
+	return funcs
+}()
+
+// templateFuncs returns the formatting functions writing values with format
+func templateFuncs(format locale.Formatter) template.FuncMap {
+	return template.FuncMap{
+		"date": func(v any) (string, error) {
+			switch d := v.(type) {
+			case time.Time:
+				return format.Date(d), nil
+			case *time.Time:
+				if d == nil {
+					return "", nil
+				}
+				return format.Date(*d), nil
+			case string:
+				return format.DateString(d), nil
+			default:
+				return "", fmt.Errorf("date: unsupported value %T", v)
+			}
+		},
+		"datetime": func(v any) (string, error) {
+			switch d := v.(type) {
+			case time.Time:
+				return format.DateTime(d), nil
+			case string:
+				t, err := time.Parse(time.RFC3339, d)
+				if err != nil {
+					return "", fmt.Errorf("datetime: %w", err)
+				}
+				return format.DateTime(t), nil
+			default:
+				return "", fmt.Errorf("datetime: unsupported value %T", v)
+			}
+		},
+		"number": func(v any) (string, error) {
+			n, err := templateInt(v)
+			return format.Number(n), err
+		},
+		"yen": func(v any) (string, error) {
+			n, err := templateInt(v)
+			return format.Yen(n), err
+		},
+		"phone":  format.Phone,
+		"postal": format.PostalCode,
+	}
+}
+
+// DefaultTemplate returns the built-in template of name
+func DefaultTemplate(name string) (*Template, bool) {
+	content, err := defaultTemplates.ReadFile("templates/" + name + ".tmpl")
+	if err != nil {
+		return nil, false
+	}
+
+	header, body, _ := strings.Cut(string(content), "\n\n")
+	template := &Template{
+		Name:    name,
+		Subject: strings.TrimPrefix(header, subjectHeader),
+		Body:    body,
+	}
+	if html, err := defaultTemplates.ReadFile("templates/" + name + htmlSuffix + ".tmpl"); err == nil {
+		template.HTMLBody = string(html)
+	}
+	return template, true
+}
+
+// LocalizedName returns the name of the template of name translated into language; the
+// templates in the default language have no suffix
+func LocalizedName(name, language string) string {
+	if language == "" || language == locale.Default {
+		return name
+	}
+	return name + languageSeparator + language
+}
+
+// BaseName returns the name of the template a localized template translates
+func BaseName(name string) string {
+	base, _, _ := strings.Cut(name, languageSeparator)
+	return base
+}
+
+// Language returns the language of a template
+func (t *Template) Language() string {
+	if _, language, ok := strings.Cut(t.Name, languageSeparator); ok {
+		return language
+	}
+	return locale.Default
+}
+
+// DefaultTemplateNames lists the built-in templates, which are the templates the application sends
+func DefaultTemplateNames() []string {
+	entries, _ := defaultTemplates.ReadDir("templates")
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".tmpl")
+		if strings.HasSuffix(name, htmlSuffix) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render executes the template with data into a message without a recipient. A reference
+// to a field data does not have is an error, so a mistyped name fails in preview instead
+// of sending a blank.
+func (t *Template) Render(data any) (*Message, error) {
+	funcs, ok := languageFuncs[t.Language()]
+	if !ok {
+		funcs = languageFuncs[locale.Default]
+	}
+
+	subject, err := execute(t.Name+".subject", t.Subject, funcs, data)
+	if err != nil {
+		return nil, fmt.Errorf("subject: %w", err)
+	}
+	subject = strings.TrimSpace(subject)
+	if subject == "" {
+		return nil, errors.New("subject: rendered empty")
+	}
+	if strings.ContainsAny(subject, "\r\n") {
+		return nil, errors.New("subject: must be a single line")
+	}
+
+	body, err := execute(t.Name+".body", t.Body, funcs, data)
+	if err != nil {
+		return nil, fmt.Errorf("body: %w", err)
+	}
+
+	msg := &Message{Subject: subject, Body: body}
+	if t.HTMLBody != "" {
+		if msg.HTMLBody, err = executeHTML(t.Name+".html", t.HTMLBody, funcs, data); err != nil {
+			return nil, fmt.Errorf("html body: %w", err)
+		}
+	}
+	return msg, nil
+}
+
+// execute parses and runs one part of a template
+func execute(name, text string, funcs template.FuncMap, data any) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// executeHTML parses and runs the HTML part of a template
+func executeHTML(name, text string, funcs template.FuncMap, data any) (string, error) {
+	tmpl, err := htmltemplate.New(name).Option("missingkey=error").Funcs(htmltemplate.FuncMap(funcs)).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// templateInt converts the integer kinds, including named types such as money.Yen and the
+// float64 of JSON preview data, to int64
+func templateInt(v any) (int64, error) {
+	value := reflect.ValueOf(v)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return int64(value.Uint()), nil //nolint:gosec // at most 32 bits
+	case reflect.Float64:
+		return int64(value.Float()), nil
+	default:
+		return 0, fmt.Errorf("unsupported number %T", v)
+	}
+}
@@ -0,0 +1,190 @@
+// Package masking hides sensitive personal data in API responses.
+//
+// A Policy decides, per caller role and per field, which Rule is applied when a value
+// is rendered. Fields without a rule for the role are shown unchanged.
+package masking
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Caller roles
+const (
+	// RoleAdmin is a caller authenticated with the admin API token
+	RoleAdmin = "admin"
+	// RolePublic is an unauthenticated caller
+	RolePublic = "public"
+	// RoleSelf is a user viewing their own data through the self-service lookup
+	RoleSelf = "self"
+)
+
+// Maskable fields
+const (
+	FieldName       = "name"
+	FieldEmail      = "email"
+	FieldPhone      = "phone"
+	FieldPostalCode = "postal_code"
+	FieldAddress    = "address"
+)
+
+// Rule is a masking rule
+type Rule string
+
+// Masking rules
+const (
+	// RuleNone shows the value unchanged
+	RuleNone Rule = "none"
+	// RuleLast4 masks every digit except the last four, keeping separators: **-****-5678
+	RuleLast4 Rule = "last4"
+	// RuleEmail keeps the first character of the local part and the domain: t***@example.com
+	RuleEmail Rule = "email"
+	// RuleFirstChar keeps the first character of each word: 田* 太*
+	RuleFirstChar Rule = "first_char"
+	// RuleRedact replaces the whole value
+	RuleRedact Rule = "redact"
+)
+
+const (
+	maskChar      = "*"
+	redactedValue = "***"
+	visibleDigits = 4
+)
+
+var knownRoles = map[string]bool{RoleAdmin: true, RolePublic: true, RoleSelf: true}
+
+var knownFields = map[string]bool{
+	FieldName: true, FieldEmail: true, FieldPhone: true, FieldPostalCode: true, FieldAddress: true,
+}
+
+var knownRules = map[Rule]bool{RuleNone: true, RuleLast4: true, RuleEmail: true, RuleFirstChar: true, RuleRedact: true}
+
+// Policy maps caller roles and fields to masking rules
+type Policy struct {
+	rules map[string]map[string]Rule
+}
+
+// NewPolicy creates a policy from rules in the form
+// "ROLE:FIELD=RULE,FIELD=RULE", with roles separated by semicolons,
+// e.g. "public:email=email,phone=last4;self:name=first_char".
+func NewPolicy(spec string) (*Policy, error) {
+	policy := &Policy{rules: make(map[string]map[string]Rule)}
+
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		role, fieldSpec, ok := strings.Cut(entry, ":")
+		role = strings.TrimSpace(role)
+		if !ok || !knownRoles[role] {
+			return nil, fmt.Errorf("invalid masking rule %q: unknown role %q", entry, role)
+		}
+
+		fields := policy.rules[role]
+		if fields == nil {
+			fields = make(map[string]Rule)
+			policy.rules[role] = fields
+		}
+
+		for _, fieldRule := range strings.Split(fieldSpec, ",") {
+			field, rule, ok := strings.Cut(strings.TrimSpace(fieldRule), "=")
+			field = strings.TrimSpace(field)
+			if !ok || !knownFields[field] {
+				return nil, fmt.Errorf("invalid masking rule %q: unknown field in %q", entry, fieldRule)
+			}
+			if !knownRules[Rule(strings.TrimSpace(rule))] {
+				return nil, fmt.Errorf("invalid masking rule %q: unknown rule in %q", entry, fieldRule)
+			}
+			fields[field] = Rule(strings.TrimSpace(rule))
+		}
+	}
+
+	return policy, nil
+}
+
+// Mask renders the value of a field for the given role. A nil policy masks nothing.
+func (p *Policy) Mask(role, field, value string) string {
+	if p == nil || value == "" {
+		return value
+	}
+	return Apply(p.rules[role][field], value)
+}
+
+// Apply masks a value with a single rule
+func Apply(rule Rule, value string) string {
+	switch rule {
+	case RuleLast4:
+		return maskLast4(value)
+	case RuleEmail:
+		return maskEmail(value)
+	case RuleFirstChar:
+		return maskWords(value)
+	case RuleRedact:
+		return redactedValue
+	default:
+		return value
+	}
+}
+
+type roleKey struct{}
+
+// WithRole returns a context carrying the caller role
+func WithRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, roleKey{}, role)
+}
+
+// RoleFromContext returns the caller role, defaulting to RolePublic
+func RoleFromContext(ctx context.Context) string {
+	if role, ok := ctx.Value(roleKey{}).(string); ok && role != "" {
+		return role
+	}
+	return RolePublic
+}
+
+// maskLast4 masks every digit except the last four
+func maskLast4(value string) string {
+	runes := []rune(value)
+	remaining := visibleDigits
+	for i := len(runes) - 1; i >= 0; i-- {
+		if !unicode.IsDigit(runes[i]) {
+			continue
+		}
+		if remaining > 0 {
+			remaining--
+			continue
+		}
+		runes[i] = '*'
+	}
+	return string(runes)
+}
+
+// maskEmail keeps the first character of the local part and the domain
+func maskEmail(email string) string {
+	local, domain, found := strings.Cut(email, "@")
+	if !found {
+		return keepFirst(email)
+	}
+	return keepFirst(local) + "@" + domain
+}
+
+// maskWords keeps the first character of each space-separated word
+func maskWords(value string) string {
+	words := strings.Split(value, " ")
+	for i, word := range words {
+		words[i] = keepFirst(word)
+	}
+	return strings.Join(words, " ")
+}
+
+// keepFirst keeps the first character and masks the rest
+func keepFirst(value string) string {
+	runes := []rune(value)
+	if len(runes) <= 1 {
+		return strings.Repeat(maskChar, len(runes))
+	}
+	return string(runes[0]) + strings.Repeat(maskChar, len(runes)-1)
+}
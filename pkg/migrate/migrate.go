@@ -0,0 +1,366 @@
+// Package migrate applies versioned SQL schema migrations.
+//
+// Migrations are NNN_name.up.sql / NNN_name.down.sql files. The applied version is
+// stored in the schema_migrations table using the same layout as golang-migrate, so
+// databases previously migrated with scripts/migrate.sh are picked up unchanged.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const (
+	// advisoryLockID serializes migrations across instances starting at the same time
+	advisoryLockID = 872635401
+
+	directionUp   = "up"
+	directionDown = "down"
+)
+
+// migrationFilePattern matches migration file names such as 001_create_users_table.up.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// ErrDirty is returned when a previous migration failed midway and must be fixed manually
+var ErrDirty = errors.New("database is in a dirty migration state; fix the schema and run force")
+
+// Migration is a single schema version
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status describes the migration state of the database
+type Status struct {
+	Version uint `json:"version"`
+	Dirty   bool `json:"dirty"`
+	Latest  uint `json:"latest"`
+	Pending int  `json:"pending"`
+}
+
+// Migrator applies migrations from a file system to a database
+type Migrator struct {
+	db         *sql.DB
+	migrations []*Migration
+	log        *logger.Logger
+}
+
+// New loads the migrations in the root of source
+func New(db *sql.DB, source fs.FS, log *logger.Logger) (*Migrator, error) {
+	migrations, err := load(source)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		log:        log,
+	}, nil
+}
+
+// Migrations returns the known migrations in ascending version order
+func (m *Migrator) Migrations() []*Migration {
+	return m.migrations
+}
+
+// Status returns the applied and latest versions
+func (m *Migrator) Status(ctx context.Context) (*Status, error) {
+	status := &Status{}
+	if len(m.migrations) > 0 {
+		status.Latest = m.migrations[len(m.migrations)-1].Version
+	}
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		status.Version = version
+		status.Dirty = dirty
+		for _, migration := range m.migrations {
+			if migration.Version > version {
+				status.Pending++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// Up applies up to steps pending migrations (all when steps <= 0) and returns the number applied
+func (m *Migrator) Up(ctx context.Context, steps int) (int, error) {
+	applied := 0
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("%w (version %d)", ErrDirty, version)
+		}
+		if err := m.checkKnownVersion(version); err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if steps > 0 && applied >= steps {
+				break
+			}
+
+			if err := m.apply(ctx, conn, migration, directionUp, migration.Version); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down reverts up to steps applied migrations (all when steps <= 0) and returns the number reverted
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	reverted := 0
+
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, dirty, err := readVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("%w (version %d)", ErrDirty, version)
+		}
+		if err := m.checkKnownVersion(version); err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version > version {
+				continue
+			}
+			if steps > 0 && reverted >= steps {
+				break
+			}
+
+			var previous uint
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := m.apply(ctx, conn, migration, directionDown, previous); err != nil {
+				return err
+			}
+			reverted++
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Force records version as applied and clears the dirty flag without running any SQL.
+// A version of 0 marks the database as having no migrations applied.
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	if version != 0 {
+		if err := m.checkKnownVersion(version); err != nil {
+			return err
+		}
+	}
+
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		if err := setVersion(ctx, tx, version); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit forced version: %w", err)
+		}
+
+		m.log.WithField("version", version).Warn("Forced schema migration version")
+		return nil
+	})
+}
+
+// apply runs one migration and records the resulting version in the same transaction,
+// so a failed migration leaves neither partial schema changes nor a dirty version
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration *Migration, direction string, newVersion uint) error {
+	script := migration.Up
+	if direction == directionDown {
+		script = migration.Down
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("failed to apply migration %d_%s (%s): %w", migration.Version, migration.Name, direction, err)
+	}
+	if err := setVersion(ctx, tx, newVersion); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit migration %d_%s (%s): %w", migration.Version, migration.Name, direction, err)
+	}
+
+	m.log.WithField("version", migration.Version).
+		WithField("name", migration.Name).
+		WithField("direction", direction).
+		Info("Applied schema migration")
+	return nil
+}
+
+// checkKnownVersion rejects versions that have no matching migration files
+func (m *Migrator) checkKnownVersion(version uint) error {
+	if version == 0 {
+		return nil
+	}
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return nil
+		}
+	}
+	return fmt.Errorf("database version %d has no matching migration file", version)
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory lock
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire database connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, advisoryLockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		// Use a fresh context so the lock is released even if ctx was cancelled
+		if _, err := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, advisoryLockID); err != nil {
+			m.log.WithError(err).Warn("Failed to release migration lock")
+		}
+	}()
+
+	query := `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`
+	if _, err := conn.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	return fn(conn)
+}
+
+// readVersion returns the applied version, or 0 when no migration has been applied
+func readVersion(ctx context.Context, conn *sql.Conn) (uint, bool, error) {
+	var version int64
+	var dirty bool
+
+	err := conn.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version < 0 {
+		// golang-migrate records -1 after reverting every migration
+		return 0, dirty, nil
+	}
+
+	return uint(version), dirty, nil
+}
+
+// setVersion replaces the recorded version
+func setVersion(ctx context.Context, tx *sql.Tx, version uint) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return fmt.Errorf("failed to clear schema version: %w", err)
+	}
+	if version == 0 {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, FALSE)`, int64(version)); err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+	return nil
+}
+
+// load reads and pairs the up and down files in source
+func load(source fs.FS) ([]*Migration, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		matches := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(matches[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+
+		content, err := fs.ReadFile(source, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: matches[2]}
+			byVersion[uint(version)] = migration
+		} else if migration.Name != matches[2] {
+			return nil, fmt.Errorf("conflicting migration names for version %d: %s and %s",
+				version, migration.Name, matches[2])
+		}
+
+		if matches[3] == directionUp {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down files", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
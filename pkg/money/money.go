@@ -0,0 +1,193 @@
+// Package money handles yen amounts and consumption tax with integer arithmetic.
+//
+// Amounts are whole yen and tax rates are basis points, so no float rounding error can
+// creep into prices. Tax follows the Japanese qualified invoice convention: it is
+// computed once per tax rate on the summed amounts and rounded with a single Rounding,
+// never per line.
+package money
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Yen is an amount in whole yen
+type Yen int64
+
+// Rate is a tax rate in basis points (1000 = 10%)
+type Rate int64
+
+// basisPoints is the denominator of Rate
+const basisPoints = 10000
+
+// Percent returns the rate for a whole percentage
+func Percent(p int) Rate {
+	return Rate(p * 100)
+}
+
+// ParseRate parses a percentage with up to two decimal places: "10", "6.5"
+func ParseRate(s string) (Rate, error) {
+	whole, frac, hasFrac := strings.Cut(strings.TrimSuffix(strings.TrimSpace(s), "%"), ".")
+	if hasFrac && (frac == "" || len(frac) > 2) {
+		return 0, fmt.Errorf("invalid rate %q", s)
+	}
+
+	units, err := strconv.ParseUint(whole, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid rate %q", s)
+	}
+	rate := Rate(units * 100)
+
+	if hasFrac {
+		hundredths, err := strconv.ParseUint(frac, 10, 8)
+		if err != nil {
+			return 0, fmt.Errorf("invalid rate %q", s)
+		}
+		if len(frac) == 1 {
+			hundredths *= 10
+		}
+		rate += Rate(hundredths)
+	}
+
+	if rate > basisPoints {
+		return 0, fmt.Errorf("rate %q exceeds 100%%", s)
+	}
+	return rate, nil
+}
+
+// String formats the rate as a percentage: 10%, 6.5%
+func (r Rate) String() string {
+	whole, frac := int64(r)/100, int64(r)%100
+	if frac == 0 {
+		return strconv.FormatInt(whole, 10) + "%"
+	}
+	if frac%10 == 0 {
+		return fmt.Sprintf("%d.%d%%", whole, frac/10)
+	}
+	return fmt.Sprintf("%d.%02d%%", whole, frac)
+}
+
+// Rounding is how fractional yen are rounded
+type Rounding string
+
+// Rounding modes. Negative amounts round symmetrically, so a refund mirrors its charge.
+const (
+	// RoundDown truncates fractions (切り捨て), the most common choice for consumption tax
+	RoundDown Rounding = "down"
+	// RoundHalfUp rounds half a yen and above up (四捨五入)
+	RoundHalfUp Rounding = "half_up"
+	// RoundUp raises any fraction to the next yen (切り上げ)
+	RoundUp Rounding = "up"
+)
+
+// ParseRounding parses a rounding mode name
+func ParseRounding(s string) (Rounding, error) {
+	switch r := Rounding(s); r {
+	case RoundDown, RoundHalfUp, RoundUp:
+		return r, nil
+	default:
+		return "", fmt.Errorf("unknown rounding mode %q", s)
+	}
+}
+
+// String formats the amount with thousands separators: ¥1,234
+func (y Yen) String() string {
+	digits := strconv.FormatInt(int64(y), 10)
+	sign := ""
+	if y < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	grouped := make([]byte, 0, len(digits)+len(digits)/3)
+	for i := range len(digits) {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped = append(grouped, ',')
+		}
+		grouped = append(grouped, digits[i])
+	}
+	return sign + "¥" + string(grouped)
+}
+
+// Times multiplies the amount by a quantity
+func (y Yen) Times(quantity int) Yen {
+	return y * Yen(quantity)
+}
+
+// Sum adds amounts
+func Sum(amounts ...Yen) Yen {
+	var total Yen
+	for _, amount := range amounts {
+		total += amount
+	}
+	return total
+}
+
+// Tax returns the consumption tax on a tax-exclusive amount
+func Tax(amount Yen, rate Rate, rounding Rounding) Yen {
+	return divide(int64(amount)*int64(rate), basisPoints, rounding)
+}
+
+// WithTax returns a tax-exclusive amount plus its tax
+func WithTax(amount Yen, rate Rate, rounding Rounding) Yen {
+	return amount + Tax(amount, rate, rounding)
+}
+
+// IncludedTax returns the tax contained in a tax-inclusive amount
+func IncludedTax(total Yen, rate Rate, rounding Rounding) Yen {
+	return divide(int64(total)*int64(rate), basisPoints+int64(rate), rounding)
+}
+
+// Line is a tax-exclusive amount and the rate it is taxed at
+type Line struct {
+	Amount Yen
+	Rate   Rate
+}
+
+// RateTotal is the total and tax of the lines sharing one rate
+type RateTotal struct {
+	Rate     Rate
+	Subtotal Yen
+	Tax      Yen
+}
+
+// TaxByRate sums lines per rate and rounds the tax once per rate, in ascending rate order
+func TaxByRate(lines []Line, rounding Rounding) []RateTotal {
+	subtotals := make(map[Rate]Yen)
+	for _, line := range lines {
+		subtotals[line.Rate] += line.Amount
+	}
+
+	totals := make([]RateTotal, 0, len(subtotals))
+	for rate, subtotal := range subtotals {
+		totals = append(totals, RateTotal{Rate: rate, Subtotal: subtotal, Tax: Tax(subtotal, rate, rounding)})
+	}
+	sort.Slice(totals, func(i, j int) bool { return totals[i].Rate < totals[j].Rate })
+	return totals
+}
+
+// divide returns n/d rounded to whole yen; d must be positive
+func divide(n, d int64, rounding Rounding) Yen {
+	negative := n < 0
+	if negative {
+		n = -n
+	}
+
+	q, r := n/d, n%d
+	switch rounding {
+	case RoundUp:
+		if r > 0 {
+			q++
+		}
+	case RoundHalfUp:
+		if r*2 >= d {
+			q++
+		}
+	}
+
+	if negative {
+		q = -q
+	}
+	return Yen(q)
+}
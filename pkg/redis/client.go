@@ -0,0 +1,345 @@
+// Package redis provides a minimal Redis client speaking the RESP2 protocol.
+//
+// It supports the small command surface used by the application (EVAL, GET/SET,
+// DEL, PING) with a bounded connection pool and is not a general-purpose driver.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const (
+	defaultPoolSize    = 10
+	defaultDialTimeout = 5 * time.Second
+	defaultIOTimeout   = 3 * time.Second
+)
+
+// ErrNil is returned when Redis replies with a null value
+var ErrNil = errors.New("redis: nil reply")
+
+// Error is an error reply returned by the Redis server
+type Error string
+
+// Error implements the error interface
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// Config holds Redis connection configuration
+type Config struct {
+	Addr        string        `json:"addr"`
+	Password    string        `json:"-"`
+	DB          int           `json:"db"`
+	PoolSize    int           `json:"pool_size"`
+	DialTimeout time.Duration `json:"dial_timeout"`
+	IOTimeout   time.Duration `json:"io_timeout"`
+}
+
+// Client is a pooled Redis client safe for concurrent use
+type Client struct {
+	config *Config
+	idle   chan *conn
+	slots  chan struct{}
+	log    *logger.Logger
+}
+
+// conn is a single Redis connection
+type conn struct {
+	netConn net.Conn
+	reader  *bufio.Reader
+}
+
+// NewClient creates a new Redis client. Connections are opened lazily.
+func NewClient(config *Config, log *logger.Logger) *Client {
+	if config.PoolSize <= 0 {
+		config.PoolSize = defaultPoolSize
+	}
+	if config.DialTimeout <= 0 {
+		config.DialTimeout = defaultDialTimeout
+	}
+	if config.IOTimeout <= 0 {
+		config.IOTimeout = defaultIOTimeout
+	}
+
+	return &Client{
+		config: config,
+		idle:   make(chan *conn, config.PoolSize),
+		slots:  make(chan struct{}, config.PoolSize),
+		log:    log,
+	}
+}
+
+// Do sends a command and returns its reply. Replies are decoded as string (status),
+// int64 (integer), []byte (bulk string), []interface{} (array) or nil (null).
+func (c *Client) Do(ctx context.Context, args ...interface{}) (interface{}, error) {
+	cn, err := c.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := cn.roundTrip(ctx, c.config.IOTimeout, args)
+	c.release(cn, err)
+	if err != nil {
+		return nil, err
+	}
+
+	if redisErr, ok := reply.(Error); ok {
+		return nil, redisErr
+	}
+	return reply, nil
+}
+
+// Ping checks connectivity to the server
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.Do(ctx, "PING")
+	return err
+}
+
+// Close closes all idle connections
+func (c *Client) Close() error {
+	for {
+		select {
+		case cn := <-c.idle:
+			_ = cn.netConn.Close()
+			<-c.slots
+		default:
+			return nil
+		}
+	}
+}
+
+// acquire returns an idle connection or dials a new one when the pool has capacity
+func (c *Client) acquire(ctx context.Context) (*conn, error) {
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	default:
+	}
+
+	select {
+	case cn := <-c.idle:
+		return cn, nil
+	case c.slots <- struct{}{}:
+		cn, err := c.dial(ctx)
+		if err != nil {
+			<-c.slots
+			return nil, err
+		}
+		return cn, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// release returns the connection to the pool, discarding it after network errors
+func (c *Client) release(cn *conn, err error) {
+	var redisErr Error
+	if err != nil && !errors.As(err, &redisErr) {
+		_ = cn.netConn.Close()
+		<-c.slots
+		return
+	}
+	c.idle <- cn
+}
+
+// dial opens and initializes a new connection
+func (c *Client) dial(ctx context.Context) (*conn, error) {
+	dialer := net.Dialer{Timeout: c.config.DialTimeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", c.config.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	cn := &conn{netConn: netConn, reader: bufio.NewReader(netConn)}
+
+	if c.config.Password != "" {
+		if err := cn.command(ctx, c.config.IOTimeout, "AUTH", c.config.Password); err != nil {
+			_ = netConn.Close()
+			return nil, fmt.Errorf("failed to authenticate with redis: %w", err)
+		}
+	}
+	if c.config.DB != 0 {
+		if err := cn.command(ctx, c.config.IOTimeout, "SELECT", c.config.DB); err != nil {
+			_ = netConn.Close()
+			return nil, fmt.Errorf("failed to select redis database: %w", err)
+		}
+	}
+
+	c.log.WithField("addr", c.config.Addr).Debug("Opened Redis connection")
+	return cn, nil
+}
+
+// command runs a command whose reply is only checked for errors
+func (cn *conn) command(ctx context.Context, timeout time.Duration, args ...interface{}) error {
+	reply, err := cn.roundTrip(ctx, timeout, args)
+	if err != nil {
+		return err
+	}
+	if redisErr, ok := reply.(Error); ok {
+		return redisErr
+	}
+	return nil
+}
+
+// roundTrip writes a command and reads its reply
+func (cn *conn) roundTrip(ctx context.Context, timeout time.Duration, args []interface{}) (interface{}, error) {
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := cn.netConn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("failed to set redis deadline: %w", err)
+	}
+
+	if err := writeCommand(cn.netConn, args); err != nil {
+		return nil, fmt.Errorf("failed to write redis command: %w", err)
+	}
+
+	reply, err := readReply(cn.reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redis reply: %w", err)
+	}
+	return reply, nil
+}
+
+// writeCommand encodes args as a RESP array of bulk strings
+func writeCommand(w io.Writer, args []interface{}) error {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+
+	for _, arg := range args {
+		var value string
+		switch v := arg.(type) {
+		case string:
+			value = v
+		case []byte:
+			value = string(v)
+		case int:
+			value = strconv.Itoa(v)
+		case int64:
+			value = strconv.FormatInt(v, 10)
+		case float64:
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			value = fmt.Sprint(v)
+		}
+
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(value)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, value...)
+		buf = append(buf, '\r', '\n')
+	}
+
+	_, err := w.Write(buf)
+	return err
+}
+
+// readReply decodes a single RESP reply
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("malformed redis reply: %q", line)
+	}
+	prefix, payload := line[0], line[1:len(line)-2]
+
+	switch prefix {
+	case '+':
+		return payload, nil
+	case '-':
+		return Error(payload), nil
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("malformed redis bulk length: %w", err)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return data[:size], nil
+	case '*':
+		count, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("malformed redis array length: %w", err)
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unknown redis reply type: %q", prefix)
+	}
+}
+
+// Int64 converts an integer reply
+func Int64(reply interface{}, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	switch v := reply.(type) {
+	case int64:
+		return v, nil
+	case nil:
+		return 0, ErrNil
+	default:
+		return 0, fmt.Errorf("unexpected redis reply type %T for integer", reply)
+	}
+}
+
+// String converts a bulk or status reply
+func String(reply interface{}, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	switch v := reply.(type) {
+	case []byte:
+		return string(v), nil
+	case string:
+		return v, nil
+	case nil:
+		return "", ErrNil
+	default:
+		return "", fmt.Errorf("unexpected redis reply type %T for string", reply)
+	}
+}
+
+// Values converts an array reply
+func Values(reply interface{}, err error) ([]interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	switch v := reply.(type) {
+	case []interface{}:
+		return v, nil
+	case nil:
+		return nil, ErrNil
+	default:
+		return nil, fmt.Errorf("unexpected redis reply type %T for array", reply)
+	}
+}
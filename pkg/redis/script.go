@@ -0,0 +1,45 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// Script is a Lua script executed with EVALSHA, falling back to EVAL when not cached
+type Script struct {
+	source string
+	hash   string
+}
+
+// NewScript creates a new script
+func NewScript(source string) *Script {
+	sum := sha1.Sum([]byte(source))
+	return &Script{
+		source: source,
+		hash:   hex.EncodeToString(sum[:]),
+	}
+}
+
+// Run executes the script with the given keys and arguments
+func (s *Script) Run(ctx context.Context, c *Client, keys []string, args ...interface{}) (interface{}, error) {
+	reply, err := c.Do(ctx, s.command("EVALSHA", s.hash, keys, args)...)
+
+	var redisErr Error
+	if errors.As(err, &redisErr) && strings.HasPrefix(string(redisErr), "NOSCRIPT") {
+		return c.Do(ctx, s.command("EVAL", s.source, keys, args)...)
+	}
+	return reply, err
+}
+
+// command builds the EVAL/EVALSHA argument list
+func (s *Script) command(name, script string, keys []string, args []interface{}) []interface{} {
+	cmd := make([]interface{}, 0, 3+len(keys)+len(args))
+	cmd = append(cmd, name, script, len(keys))
+	for _, key := range keys {
+		cmd = append(cmd, key)
+	}
+	return append(cmd, args...)
+}
@@ -0,0 +1,108 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// clamAVScanner streams content to clamd with the INSTREAM command
+type clamAVScanner struct {
+	addr    string
+	timeout time.Duration
+}
+
+// NewClamAVScanner creates a scanner for the configured clamd
+func NewClamAVScanner(config *Config) (Scanner, error) {
+	if config.ClamAV.Addr == "" {
+		return nil, errors.New("clamav scan driver requires a clamd address")
+	}
+
+	return &clamAVScanner{
+		addr:    config.ClamAV.Addr,
+		timeout: config.Timeout,
+	}, nil
+}
+
+// Scan sends the content as length-prefixed chunks and parses the reply, which is
+// "stream: OK", "stream: <signature> FOUND" or "<message> ERROR"
+func (s *clamAVScanner) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(s.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("failed to set clamd deadline: %w", err)
+	}
+
+	// The z prefix makes clamd use NUL-terminated commands and replies
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return nil, fmt.Errorf("failed to send clamd command: %w", err)
+	}
+	if err := writeInstreamChunks(conn, r); err != nil {
+		return nil, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\x00')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return parseClamAVReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// writeInstreamChunks writes the content as 4-byte big-endian length-prefixed chunks
+// followed by a zero-length chunk
+func writeInstreamChunks(w io.Writer, r io.Reader) error {
+	buf := make([]byte, chunkSize)
+	var size [4]byte
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size[:], uint32(n))
+			if _, err := w.Write(size[:]); err != nil {
+				return fmt.Errorf("failed to stream content to clamd: %w", err)
+			}
+			if _, err := w.Write(buf[:n]); err != nil {
+				return fmt.Errorf("failed to stream content to clamd: %w", err)
+			}
+		}
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read content: %w", readErr)
+		}
+	}
+
+	binary.BigEndian.PutUint32(size[:], 0)
+	if _, err := w.Write(size[:]); err != nil {
+		return fmt.Errorf("failed to stream content to clamd: %w", err)
+	}
+	return nil
+}
+
+// parseClamAVReply turns a clamd reply into a verdict
+func parseClamAVReply(reply string) (*Result, error) {
+	_, status, _ := strings.Cut(reply, ": ")
+	switch {
+	case status == "OK":
+		return &Result{}, nil
+	case strings.HasSuffix(status, " FOUND"):
+		return &Result{Infected: true, Signature: strings.TrimSuffix(status, " FOUND")}, nil
+	default:
+		return nil, fmt.Errorf("clamd scan failed: %s", reply)
+	}
+}
@@ -0,0 +1,161 @@
+package scan
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultICAPPort = "1344"
+
+// encapsulatedResponseHeader is the HTTP response wrapping the content in RESPMOD requests
+const encapsulatedResponseHeader = "HTTP/1.1 200 OK\r\nContent-Type: application/octet-stream\r\n\r\n"
+
+// icapScanner sends content to an ICAP antivirus service (RFC 3507) with RESPMOD
+type icapScanner struct {
+	url     *url.URL
+	addr    string
+	timeout time.Duration
+}
+
+// NewICAPScanner creates a scanner for the configured ICAP service
+func NewICAPScanner(config *Config) (Scanner, error) {
+	if config.ICAP.URL == "" {
+		return nil, errors.New("icap scan driver requires a service URL")
+	}
+	serviceURL, err := url.Parse(config.ICAP.URL)
+	if err != nil || serviceURL.Scheme != "icap" || serviceURL.Host == "" {
+		return nil, fmt.Errorf("invalid ICAP service URL: %s", config.ICAP.URL)
+	}
+
+	addr := serviceURL.Host
+	if serviceURL.Port() == "" {
+		addr = net.JoinHostPort(serviceURL.Hostname(), defaultICAPPort)
+	}
+
+	return &icapScanner{
+		url:     serviceURL,
+		addr:    addr,
+		timeout: config.Timeout,
+	}, nil
+}
+
+// Scan sends the content as the body of an encapsulated HTTP response. The service answers
+// 204 for clean content and reports infections in X-Infection-Found or X-Virus-ID headers.
+func (s *icapScanner) Scan(ctx context.Context, r io.Reader) (*Result, error) {
+	dialer := net.Dialer{Timeout: s.timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ICAP service: %w", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(s.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return nil, fmt.Errorf("failed to set ICAP deadline: %w", err)
+	}
+
+	writer := bufio.NewWriter(conn)
+	fmt.Fprintf(writer, "RESPMOD %s ICAP/1.0\r\n", s.url.String())
+	fmt.Fprintf(writer, "Host: %s\r\n", s.url.Host)
+	fmt.Fprintf(writer, "Allow: 204\r\n")
+	fmt.Fprintf(writer, "Encapsulated: res-hdr=0, res-body=%d\r\n\r\n", len(encapsulatedResponseHeader))
+	writer.WriteString(encapsulatedResponseHeader)
+	if err := writeICAPChunks(writer, r); err != nil {
+		return nil, err
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, fmt.Errorf("failed to send ICAP request: %w", err)
+	}
+
+	return readICAPResponse(bufio.NewReader(conn))
+}
+
+// writeICAPChunks writes the content with HTTP chunked encoding
+func writeICAPChunks(w *bufio.Writer, r io.Reader) error {
+	buf := make([]byte, chunkSize)
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			fmt.Fprintf(w, "%x\r\n", n)
+			w.Write(buf[:n])
+			if _, err := w.WriteString("\r\n"); err != nil {
+				return fmt.Errorf("failed to stream content to ICAP service: %w", err)
+			}
+		}
+		if errors.Is(readErr, io.EOF) {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read content: %w", readErr)
+		}
+	}
+
+	if _, err := w.WriteString("0\r\n\r\n"); err != nil {
+		return fmt.Errorf("failed to stream content to ICAP service: %w", err)
+	}
+	return nil
+}
+
+// readICAPResponse reads the status line and headers of the ICAP response. The
+// encapsulated body is not needed for the verdict and is left unread.
+func readICAPResponse(reader *bufio.Reader) (*Result, error) {
+	tp := textproto.NewReader(reader)
+	statusLine, err := tp.ReadLine()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ICAP response: %w", err)
+	}
+	fields := strings.SplitN(statusLine, " ", 3)
+	if len(fields) < 2 || !strings.HasPrefix(fields[0], "ICAP/") {
+		return nil, fmt.Errorf("malformed ICAP status line: %q", statusLine)
+	}
+	status, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("malformed ICAP status line: %q", statusLine)
+	}
+
+	header, err := tp.ReadMIMEHeader()
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read ICAP headers: %w", err)
+	}
+
+	switch status {
+	case 204:
+		return &Result{}, nil
+	case 200:
+		if signature := icapSignature(header); signature != "" {
+			return &Result{Infected: true, Signature: signature}, nil
+		}
+		return &Result{}, nil
+	default:
+		return nil, fmt.Errorf("ICAP service returned status %d", status)
+	}
+}
+
+// icapSignature extracts the threat name from the infection headers used by ICAP antivirus
+// services, e.g. "X-Infection-Found: Type=0; Resolution=2; Threat=Eicar-Test-Signature;"
+func icapSignature(header textproto.MIMEHeader) string {
+	if found := header.Get("X-Infection-Found"); found != "" {
+		for _, part := range strings.Split(found, ";") {
+			if threat, ok := strings.CutPrefix(strings.TrimSpace(part), "Threat="); ok {
+				return threat
+			}
+		}
+		return found
+	}
+	if virus := header.Get("X-Virus-ID"); virus != "" {
+		return virus
+	}
+	return header.Get("X-Violations-Found")
+}
@@ -0,0 +1,80 @@
+package sms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const (
+	contentTypeJSON   = "application/json"
+	maxErrorBodyBytes = 512
+)
+
+// httpSender posts messages as JSON to an SMS gateway:
+// {"from": "...", "to": "+819012345678", "body": "..."}, authenticated with a bearer API key
+type httpSender struct {
+	httpClient *http.Client
+	url        string
+	apiKey     string
+	from       string
+	log        *logger.Logger
+}
+
+// httpMessage is the request body sent to the gateway
+type httpMessage struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to"`
+	Body string `json:"body"`
+}
+
+// NewHTTPSender creates a sender for the configured SMS gateway
+func NewHTTPSender(config *Config, log *logger.Logger) (Sender, error) {
+	if config.HTTP.URL == "" {
+		return nil, fmt.Errorf("http sms driver requires a gateway URL")
+	}
+
+	return &httpSender{
+		httpClient: &http.Client{Timeout: config.Timeout},
+		url:        config.HTTP.URL,
+		apiKey:     config.HTTP.APIKey,
+		from:       config.From,
+		log:        log,
+	}, nil
+}
+
+// Send delivers the message. Any non-2xx response is treated as a failure.
+func (s *httpSender) Send(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(httpMessage{From: s.from, To: msg.To, Body: msg.Body})
+	if err != nil {
+		return fmt.Errorf("failed to marshal sms message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create sms request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentTypeJSON)
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("sms gateway request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		return fmt.Errorf("sms gateway returned status %d: %s", resp.StatusCode, string(snippet))
+	}
+
+	s.log.WithContext(ctx).Debug("SMS delivered to gateway")
+	return nil
+}
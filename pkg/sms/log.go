@@ -0,0 +1,27 @@
+package sms
+
+import (
+	"context"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// logSender logs messages instead of sending them (development default)
+type logSender struct {
+	log *logger.Logger
+}
+
+// NewLogSender creates a sender that only logs messages
+func NewLogSender(log *logger.Logger) Sender {
+	return &logSender{log: log}
+}
+
+// Send logs the message. The body is logged at debug level only since it may hold secrets.
+func (s *logSender) Send(ctx context.Context, msg *Message) error {
+	s.log.WithContext(ctx).Info("SMS not sent (log sms driver)")
+	s.log.WithContext(ctx).
+		WithField("to", msg.To).
+		WithField("body", msg.Body).
+		Debug("SMS body")
+	return nil
+}
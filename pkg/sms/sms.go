@@ -0,0 +1,63 @@
+// Package sms provides delivery of text messages through an SMS gateway.
+package sms
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+// Supported sender drivers
+const (
+	DriverLog  = "log"
+	DriverHTTP = "http"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+)
+
+// Message is a text message
+type Message struct {
+	// To is the recipient in E.164 format, e.g. "+819012345678"
+	To   string
+	Body string
+}
+
+// Sender delivers text messages
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Config holds SMS sender configuration
+type Config struct {
+	Driver string `json:"driver"`
+	// From is the sender ID or number shown to recipients
+	From    string        `json:"from"`
+	Timeout time.Duration `json:"timeout"`
+	HTTP    HTTPConfig    `json:"http"`
+}
+
+// HTTPConfig holds the SMS gateway endpoint configuration
+type HTTPConfig struct {
+	URL    string `json:"url"`
+	APIKey string `json:"-"`
+}
+
+// NewSender creates the sender selected by config.Driver
+func NewSender(config *Config, log *logger.Logger) (Sender, error) {
+	if config.Timeout == 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	switch config.Driver {
+	case "", DriverLog:
+		return NewLogSender(log), nil
+	case DriverHTTP:
+		return NewHTTPSender(config, log)
+	default:
+		return nil, fmt.Errorf("unsupported sms driver: %s", config.Driver)
+	}
+}
@@ -0,0 +1,104 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidKey is returned for storage keys that would escape the storage directory
+var ErrInvalidKey = errors.New("invalid storage key")
+
+// fileStorage stores uploads as files under a directory
+type fileStorage struct {
+	dir string
+}
+
+// NewFileStorage creates a storage writing files under dir
+func NewFileStorage(dir string) Storage {
+	return &fileStorage{dir: dir}
+}
+
+// Save writes the content to a temporary file and renames it into place once complete,
+// so failed or oversized uploads never become visible under the key
+func (s *fileStorage) Save(ctx context.Context, key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create upload file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+
+	_, copyErr := io.Copy(tmp, &contextReader{ctx: ctx, r: r})
+	closeErr := tmp.Close()
+	if copyErr != nil {
+		return copyErr
+	}
+	if closeErr != nil {
+		return fmt.Errorf("failed to write upload file: %w", closeErr)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store upload file: %w", err)
+	}
+	return nil
+}
+
+// Open returns the content stored under key
+func (s *fileStorage) Open(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload file: %w", err)
+	}
+	return file, nil
+}
+
+// Delete removes the file stored under key; missing files are not an error
+func (s *fileStorage) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete upload file: %w", err)
+	}
+	return nil
+}
+
+// path resolves a key to a file path inside the storage directory
+func (s *fileStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if key == "" || clean == "/" || strings.HasPrefix(filepath.Base(clean), ".") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+	return filepath.Join(s.dir, clean), nil
+}
+
+// contextReader stops reading once the context is done, so abandoned uploads are not
+// written to completion
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
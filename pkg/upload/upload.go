@@ -0,0 +1,348 @@
+// Package upload validates uploaded files while streaming them to storage. The content type
+// is sniffed from the leading bytes rather than trusted from the file name or request
+// headers, the size is capped as the data arrives, and images are re-encoded so that only
+// pixel data reaches storage.
+package upload
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Content types recognized by the processor
+const (
+	ContentTypeJPEG = "image/jpeg"
+	ContentTypePNG  = "image/png"
+	ContentTypeGIF  = "image/gif"
+	ContentTypePDF  = "application/pdf"
+)
+
+const (
+	// sniffLen is the number of leading bytes http.DetectContentType considers
+	sniffLen = 512
+
+	defaultMaxBytes    = 10 << 20
+	defaultMaxPixels   = 40_000_000
+	defaultJPEGQuality = 90
+
+	// maxGIFFrames caps the frames of animated GIFs; each frame is a separate bitmap
+	maxGIFFrames = 1000
+)
+
+var (
+	// ErrTooLarge is returned when an upload exceeds the size limit
+	ErrTooLarge = errors.New("upload exceeds the size limit")
+	// ErrEmpty is returned for uploads without content
+	ErrEmpty = errors.New("upload is empty")
+	// ErrUnsupportedType is returned when the sniffed content type is not allowed
+	ErrUnsupportedType = errors.New("file type is not allowed")
+	// ErrTypeMismatch is returned when the file extension does not match the content
+	ErrTypeMismatch = errors.New("file extension does not match its content")
+	// ErrExecutable is returned for executables and scripts, whatever their name
+	ErrExecutable = errors.New("executable content is not allowed")
+	// ErrInvalidImage is returned when an image cannot be decoded or is too large to decode
+	ErrInvalidImage = errors.New("image is invalid")
+)
+
+// executableSignatures are the leading bytes of executable formats and scripts
+var executableSignatures = [][]byte{
+	[]byte("MZ"),               // Windows PE
+	[]byte("\x7fELF"),          // ELF
+	[]byte("\xfe\xed\xfa\xce"), // Mach-O 32-bit
+	[]byte("\xfe\xed\xfa\xcf"), // Mach-O 64-bit
+	[]byte("\xce\xfa\xed\xfe"), // Mach-O 32-bit, little endian
+	[]byte("\xcf\xfa\xed\xfe"), // Mach-O 64-bit, little endian
+	[]byte("\xca\xfe\xba\xbe"), // Mach-O universal binary, Java class
+	[]byte("#!"),               // shell scripts
+}
+
+// extensionTypes maps allowed file extensions to the content type they must contain
+var extensionTypes = map[string]string{
+	".jpg":  ContentTypeJPEG,
+	".jpeg": ContentTypeJPEG,
+	".png":  ContentTypePNG,
+	".gif":  ContentTypeGIF,
+	".pdf":  ContentTypePDF,
+}
+
+// Config holds upload limits
+type Config struct {
+	// MaxBytes caps the size of an upload
+	MaxBytes int64
+	// AllowedTypes lists the accepted sniffed content types
+	AllowedTypes []string
+	// MaxPixels caps width × height of images, summed over the frames of animated GIFs, so
+	// small files cannot expand into huge bitmaps
+	MaxPixels int
+	// KeepImages stores images as uploaded instead of re-encoding them
+	KeepImages bool
+}
+
+// Result describes a stored upload
+type Result struct {
+	Key         string
+	ContentType string
+	Size        int64
+	SHA256      string
+}
+
+// Storage persists uploaded content
+type Storage interface {
+	// Save stores the content read from r under key. When r fails, Save returns its error
+	// and must not leave partial content behind.
+	Save(ctx context.Context, key string, r io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Processor validates uploads and streams them to storage
+type Processor struct {
+	config  Config
+	storage Storage
+}
+
+// NewProcessor creates a processor; zero config values fall back to the defaults
+func NewProcessor(config Config, storage Storage) *Processor {
+	if config.MaxBytes <= 0 {
+		config.MaxBytes = defaultMaxBytes
+	}
+	if len(config.AllowedTypes) == 0 {
+		config.AllowedTypes = []string{ContentTypeJPEG, ContentTypePNG, ContentTypeGIF, ContentTypePDF}
+	}
+	if config.MaxPixels <= 0 {
+		config.MaxPixels = defaultMaxPixels
+	}
+	return &Processor{config: config, storage: storage}
+}
+
+// Store validates the upload read from r and saves it under key. filename is the name the
+// client supplied; its extension must agree with the sniffed content type.
+func (p *Processor) Store(ctx context.Context, key, filename string, r io.Reader) (*Result, error) {
+	limited := &limitReader{r: r, remaining: p.config.MaxBytes}
+	buffered := bufio.NewReaderSize(limited, sniffLen)
+
+	head, err := buffered.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if len(head) == 0 {
+		return nil, ErrEmpty
+	}
+
+	contentType, err := p.detect(head, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	content := io.Reader(buffered)
+	if isImage(contentType) && !p.config.KeepImages {
+		if content, err = p.reencode(buffered, contentType); err != nil {
+			return nil, err
+		}
+	}
+
+	digest := sha256.New()
+	counter := &countingWriter{w: digest}
+	if err := p.storage.Save(ctx, key, io.TeeReader(content, counter)); err != nil {
+		if errors.Is(err, ErrTooLarge) {
+			return nil, ErrTooLarge
+		}
+		return nil, fmt.Errorf("failed to save upload: %w", err)
+	}
+
+	return &Result{
+		Key:         key,
+		ContentType: contentType,
+		Size:        counter.n,
+		SHA256:      hex.EncodeToString(digest.Sum(nil)),
+	}, nil
+}
+
+// detect sniffs the content type from the leading bytes and checks it against the
+// allowed types and the file extension
+func (p *Processor) detect(head []byte, filename string) (string, error) {
+	for _, signature := range executableSignatures {
+		if bytes.HasPrefix(head, signature) {
+			return "", ErrExecutable
+		}
+	}
+
+	contentType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil || !slices.Contains(p.config.AllowedTypes, contentType) {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	if expected, ok := extensionTypes[strings.ToLower(filepath.Ext(filename))]; !ok || expected != contentType {
+		return "", fmt.Errorf("%w: %q contains %s", ErrTypeMismatch, filename, contentType)
+	}
+
+	return contentType, nil
+}
+
+// reencode decodes an image and encodes it again, dropping metadata and any data appended
+// to the image. The dimensions are checked before the pixels are decoded.
+func (p *Processor) reencode(r io.Reader, contentType string) (io.Reader, error) {
+	// Images are decoded in memory anyway; the buffer is bounded by MaxBytes
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width > p.config.MaxPixels/config.Height {
+		return nil, fmt.Errorf("%w: %dx%d exceeds the pixel limit", ErrInvalidImage, config.Width, config.Height)
+	}
+
+	var out bytes.Buffer
+	switch contentType {
+	case ContentTypeGIF:
+		// DecodeAll keeps every frame of animated images, so all of them must fit the limits
+		frames, pixels, err := gifFrames(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+		if frames > maxGIFFrames {
+			return nil, fmt.Errorf("%w: %d frames exceed the frame limit", ErrInvalidImage, frames)
+		}
+		if pixels > int64(p.config.MaxPixels) {
+			return nil, fmt.Errorf("%w: %d pixels over %d frames exceed the pixel limit", ErrInvalidImage, pixels, frames)
+		}
+
+		img, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+		err = gif.EncodeAll(&out, img)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode image: %w", err)
+		}
+	default:
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+		}
+		if contentType == ContentTypePNG {
+			err = png.Encode(&out, img)
+		} else {
+			err = jpeg.Encode(&out, img, &jpeg.Options{Quality: defaultJPEGQuality})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode image: %w", err)
+		}
+	}
+
+	return &out, nil
+}
+
+// gifFrames walks the blocks of a GIF without decompressing them and returns its number of
+// frames and their total pixel count, which is what gif.DecodeAll would allocate
+func gifFrames(data []byte) (frames int, pixels int64, err error) {
+	// Header and logical screen descriptor, followed by the optional global color table
+	const headerLen = 13
+	if len(data) < headerLen {
+		return 0, 0, errors.New("gif: truncated header")
+	}
+	pos := headerLen + colorTableLen(data[10])
+
+	for pos < len(data) {
+		switch data[pos] {
+		case 0x21: // extension: introducer, label and data sub-blocks
+			pos, err = skipGIFSubBlocks(data, pos+2)
+		case 0x2C: // image descriptor: position, size and flags, then the image data
+			if pos+10 > len(data) {
+				return 0, 0, errors.New("gif: truncated image descriptor")
+			}
+			width := int64(binary.LittleEndian.Uint16(data[pos+5:]))
+			height := int64(binary.LittleEndian.Uint16(data[pos+7:]))
+			frames++
+			pixels += width * height
+			// The LZW minimum code size byte precedes the data sub-blocks
+			pos, err = skipGIFSubBlocks(data, pos+10+colorTableLen(data[pos+9])+1)
+		case 0x3B: // trailer
+			return frames, pixels, nil
+		default:
+			return 0, 0, fmt.Errorf("gif: unknown block type 0x%02x", data[pos])
+		}
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+	// A missing trailer is left for the decoder to judge
+	return frames, pixels, nil
+}
+
+// colorTableLen returns the size of the color table announced by a GIF flags byte
+func colorTableLen(flags byte) int {
+	if flags&0x80 == 0 {
+		return 0
+	}
+	return 3 << (flags&0x07 + 1)
+}
+
+// skipGIFSubBlocks returns the position after the sub-blocks starting at pos
+func skipGIFSubBlocks(data []byte, pos int) (int, error) {
+	for {
+		if pos >= len(data) {
+			return 0, errors.New("gif: truncated data sub-blocks")
+		}
+		size := int(data[pos])
+		pos += 1 + size
+		if size == 0 {
+			return pos, nil
+		}
+	}
+}
+
+// isImage reports whether uploads of the content type are re-encoded
+func isImage(contentType string) bool {
+	return contentType == ContentTypeJPEG || contentType == ContentTypePNG || contentType == ContentTypeGIF
+}
+
+// limitReader fails with ErrTooLarge once more than the limit has been read, unlike
+// io.LimitReader which silently truncates
+type limitReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, ErrTooLarge
+	}
+	return n, err
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return c.w.Write(p)
+}
@@ -0,0 +1,155 @@
+package validator
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FieldRule is a configurable validation rule for a single form field. Rules are checked
+// in addition to the built-in struct tags, so they can tighten limits but never loosen them.
+type FieldRule struct {
+	Field    string `json:"field" yaml:"field"`
+	Required bool   `json:"required" yaml:"required"`
+	// MaxLength is counted in characters; 0 means no limit
+	MaxLength int `json:"max_length,omitempty" yaml:"max_length"`
+	// Pattern is a regular expression the whole value must match
+	Pattern string `json:"pattern,omitempty" yaml:"pattern"`
+	// ForbiddenChars lists characters the value must not contain
+	ForbiddenChars string `json:"forbidden_chars,omitempty" yaml:"forbidden_chars"`
+	// Message replaces the default error message
+	Message string `json:"message,omitempty" yaml:"message"`
+}
+
+// ruleFile is the layout of a YAML rule file
+type ruleFile struct {
+	Rules []FieldRule `yaml:"rules"`
+}
+
+// compiledRule is a FieldRule with its pattern compiled
+type compiledRule struct {
+	FieldRule
+	pattern *regexp.Regexp
+}
+
+// RuleSet is an immutable, compiled set of field rules
+type RuleSet struct {
+	rules []compiledRule
+}
+
+// ParseRuleFile parses a YAML rule file of the form
+//
+//	rules:
+//	  - field: last_name
+//	    max_length: 10
+//	    forbidden_chars: "<>&\"'\\"
+func ParseRuleFile(data []byte) ([]FieldRule, error) {
+	var file ruleFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse rule file: %w", err)
+	}
+	return file.Rules, nil
+}
+
+// CompileRules checks and compiles rules. Each field may have at most one rule.
+func CompileRules(rules []FieldRule) (*RuleSet, error) {
+	seen := make(map[string]bool, len(rules))
+	compiled := make([]compiledRule, 0, len(rules))
+
+	for _, rule := range rules {
+		rule.Field = strings.TrimSpace(rule.Field)
+		if rule.Field == "" {
+			return nil, fmt.Errorf("rule without a field name")
+		}
+		if seen[rule.Field] {
+			return nil, fmt.Errorf("duplicate rule for field %s", rule.Field)
+		}
+		seen[rule.Field] = true
+
+		if rule.MaxLength < 0 {
+			return nil, fmt.Errorf("negative max_length for field %s", rule.Field)
+		}
+
+		c := compiledRule{FieldRule: rule}
+		if rule.Pattern != "" {
+			pattern, err := regexp.Compile(`^(?:` + rule.Pattern + `)$`)
+			if err != nil {
+				return nil, fmt.Errorf("bad pattern for field %s: %w", rule.Field, err)
+			}
+			c.pattern = pattern
+		}
+		compiled = append(compiled, c)
+	}
+
+	sort.Slice(compiled, func(i, j int) bool { return compiled[i].Field < compiled[j].Field })
+	return &RuleSet{rules: compiled}, nil
+}
+
+// Rules returns the rules in field order
+func (s *RuleSet) Rules() []FieldRule {
+	if s == nil {
+		return nil
+	}
+	rules := make([]FieldRule, 0, len(s.rules))
+	for _, rule := range s.rules {
+		rules = append(rules, rule.FieldRule)
+	}
+	return rules
+}
+
+// Rule failure codes, reported by Validate for every field that breaks its rule
+const (
+	RuleCodeRequired       = "rule_required"
+	RuleCodeMaxLength      = "rule_max_length"
+	RuleCodeForbiddenChars = "rule_forbidden_chars"
+	RuleCodePattern        = "rule_pattern"
+)
+
+// Validate checks values, keyed by field name, and adds a message to errors and, unless
+// codes is nil, a rule failure code to codes for every field that breaks its rule. Fields
+// without a rule are ignored and a nil RuleSet checks nothing.
+func (s *RuleSet) Validate(values map[string]string, errors, codes map[string]string) {
+	if s == nil {
+		return
+	}
+
+	for _, rule := range s.rules {
+		value := strings.TrimSpace(values[rule.Field])
+		if code, msg := rule.check(value); code != "" {
+			if rule.Message != "" {
+				msg = rule.Message
+			}
+			errors[rule.Field] = msg
+			if codes != nil {
+				codes[rule.Field] = code
+			}
+		}
+	}
+}
+
+// check returns the failure code and default message for the first check value fails, or
+// empty strings if it passes
+func (r *compiledRule) check(value string) (string, string) {
+	if value == "" {
+		if r.Required {
+			return RuleCodeRequired, r.Field + " is required"
+		}
+		return "", ""
+	}
+
+	if r.MaxLength > 0 && utf8.RuneCountInString(value) > r.MaxLength {
+		return RuleCodeMaxLength, fmt.Sprintf("%s must be at most %d characters", r.Field, r.MaxLength)
+	}
+	if r.ForbiddenChars != "" && strings.ContainsAny(value, r.ForbiddenChars) {
+		return RuleCodeForbiddenChars, r.Field + " contains forbidden characters"
+	}
+	if r.pattern != nil && !r.pattern.MatchString(value) {
+		return RuleCodePattern, r.Field + " has an invalid format"
+	}
+
+	return "", ""
+}
@@ -114,11 +114,6 @@ func IsValidPostalCode(postalCode string) bool {
 	return postalPattern.MatchString(postalCode)
 }
 
-// IsValidPlanType validates plan type
-func IsValidPlanType(planType string) bool {
-	return planType == "A" || planType == "B"
-}
-
 // IsValidOptionType validates option type
 func IsValidOptionType(optionType string) bool {
 	return optionType == "AA" || optionType == "BB" || optionType == "AB"
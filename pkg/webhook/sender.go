@@ -0,0 +1,115 @@
+// Package webhook provides delivery of event notifications to HTTP endpoints.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/octop162/normal-form-app-by-claude/pkg/correlation"
+	"github.com/octop162/normal-form-app-by-claude/pkg/logger"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+
+	headerContentType = "Content-Type"
+	headerUserAgent   = "User-Agent"
+	headerEventID     = "X-Webhook-ID"
+	headerEventType   = "X-Webhook-Event"
+	headerSignature   = "X-Webhook-Signature"
+	contentTypeJSON   = "application/json"
+	userAgentValue    = "normal-form-app-webhook/1.0"
+	maxErrorBodyBytes = 512
+)
+
+// Config holds webhook delivery configuration
+type Config struct {
+	URL     string        `json:"url"`
+	Secret  string        `json:"-"`
+	Timeout time.Duration `json:"timeout"`
+}
+
+// Message is the envelope delivered to webhook consumers
+type Message struct {
+	ID            string          `json:"id"`
+	Type          string          `json:"type"`
+	CorrelationID string          `json:"correlation_id,omitempty"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// Sender posts signed webhook messages to a single endpoint
+type Sender struct {
+	httpClient *http.Client
+	url        string
+	secret     []byte
+	log        *logger.Logger
+}
+
+// NewSender creates a new webhook sender
+func NewSender(config *Config, log *logger.Logger) *Sender {
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	return &Sender{
+		httpClient: &http.Client{Timeout: timeout},
+		url:        config.URL,
+		secret:     []byte(config.Secret),
+		log:        log,
+	}
+}
+
+// Send delivers a message. Any non-2xx response is treated as a failure so the caller can retry.
+func (s *Sender) Send(ctx context.Context, msg *Message) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook message: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+
+	req.Header.Set(headerContentType, contentTypeJSON)
+	req.Header.Set(headerUserAgent, userAgentValue)
+	req.Header.Set(headerEventID, msg.ID)
+	req.Header.Set(headerEventType, msg.Type)
+	if msg.CorrelationID != "" {
+		req.Header.Set(correlation.HeaderCorrelationID, msg.CorrelationID)
+	}
+	if len(s.secret) > 0 {
+		req.Header.Set(headerSignature, "sha256="+Sign(s.secret, body))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		return fmt.Errorf("webhook endpoint returned status %d: %s", resp.StatusCode, string(snippet))
+	}
+
+	s.log.WithField("event_id", msg.ID).WithField("event_type", msg.Type).Debug("Webhook delivered")
+	return nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 signature of body
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}